@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/company/version-service/internal/clients"
+	"github.com/company/version-service/internal/config"
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/services"
+)
+
+const bootstrapUsage = `Usage: version-service bootstrap [-seed FILE] [-gitlab-groups GROUPS]
+
+Seeds an empty deployment: writes the initial versions file to GIT_BRANCH of
+GIT_REPO_URL in one commit (creating the branch), warms Redis and prints a
+JSON summary report. Fails if the branch already has commits.
+
+Seed files are CSV (app_id,version[,repo_name]) or JSON ({"app-id": "1.2.3"}
+or an existing versions.json), chosen by extension. Projects in the given
+GitLab groups without a seeded app are added as {project-id}-{project-path},
+seeded from their latest tag.
+
+Flags:
+`
+
+// runBootstrap implements the bootstrap subcommand and returns the process
+// exit code
+func runBootstrap(args []string) int {
+	logger := setupLogger()
+	logger.SetOutput(os.Stderr)
+
+	flags := flag.NewFlagSet("bootstrap", flag.ContinueOnError)
+	seedPath := flags.String("seed", "", "CSV or JSON seed file")
+	groups := flags.String("gitlab-groups", "", "Comma-separated GitLab groups to import projects from")
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), bootstrapUsage)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	var groupList []string
+	for _, group := range strings.Split(*groups, ",") {
+		if group = strings.TrimSpace(group); group != "" {
+			groupList = append(groupList, group)
+		}
+	}
+
+	if *seedPath == "" && len(groupList) == 0 {
+		fmt.Fprintln(os.Stderr, "bootstrap: -seed or -gitlab-groups is required")
+		flags.Usage()
+		return 2
+	}
+
+	var seed []models.BootstrapEntry
+	if *seedPath != "" {
+		data, err := os.ReadFile(*seedPath)
+		if err != nil {
+			logger.WithError(err).Error("Failed to read seed file")
+			return 1
+		}
+
+		format := strings.TrimPrefix(filepath.Ext(*seedPath), ".")
+		if seed, err = services.ParseBootstrapSeed(data, format); err != nil {
+			logger.WithError(err).Error("Failed to parse seed file")
+			return 1
+		}
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.WithError(err).Error("Failed to load configuration")
+		return 1
+	}
+
+	normalization, err := services.ParseNormalization(cfg.VersionNormalization)
+	if err != nil {
+		logger.WithError(err).Error("Invalid VERSION_NORMALIZATION")
+		return 1
+	}
+
+	idScheme, err := models.NewIDScheme(cfg.AppIDScheme)
+	if err != nil {
+		logger.WithError(err).Error("Invalid APP_ID_SCHEME")
+		return 1
+	}
+
+	cacheStorage, closeCache, err := newCacheStorage(cfg, idScheme, logger)
+	if err != nil {
+		logger.WithError(err).Error("Failed to initialize cache storage")
+		return 1
+	}
+	defer closeCache()
+
+	durableStorage, closeStorage, err := newDurableStorage(cfg, nil, logger)
+	if err != nil {
+		logger.WithError(err).Error("Failed to initialize durable storage")
+		return 1
+	}
+	defer closeStorage()
+
+	gitLabClient := clients.NewGitLabClient(cfg.GitLabBaseURL, cfg.GitLabAccessToken, logger)
+	gitLabClient.LenientTags = cfg.GitLabLenientTags
+	gitLabClient.MaxRetries = cfg.GitLabMaxRetries
+	gitLabClient.RetryBaseDelay = cfg.GitLabRetryBaseDelay
+	gitLabClient.RetryMaxDelay = cfg.GitLabRetryMaxDelay
+	tagProvider := newTagProvider(cfg, gitLabClient, logger)
+
+	service := services.NewVersionService(cacheStorage, durableStorage, gitLabClient, logger, services.Options{
+		Normalization: normalization,
+		IDScheme:      idScheme,
+		TagProvider:   tagProvider,
+	})
+
+	report, err := service.Bootstrap(context.Background(), seed, groupList)
+	if err != nil {
+		logger.WithError(err).Error("Bootstrap failed")
+		return 1
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		logger.WithError(err).Error("Failed to write bootstrap report")
+		return 1
+	}
+
+	return 0
+}
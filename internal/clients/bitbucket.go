@@ -0,0 +1,161 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// bitbucketMaxTagPages bounds how many pages of tags are read per lookup
+const bitbucketMaxTagPages = 10
+
+// ErrBitbucketUnavailable is returned when Bitbucket could not be reached or
+// answered 429 or 5xx. Unlike other errors, it says nothing about the
+// project asked for.
+var ErrBitbucketUnavailable = errors.New("Bitbucket unavailable")
+
+// BitbucketClient looks up tags of repositories on Bitbucket Server or Data
+// Center. Project IDs are "{project-key}/{repo-slug}".
+type BitbucketClient struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+	logger     *logrus.Logger
+
+	// LenientTags coerces sloppy tag names ("1.2", "v1", "1.2.3.4") into
+	// semantic versions when looking up a repository's latest tag, instead
+	// of ignoring them
+	LenientTags bool
+
+	// AirGapped refuses every call with ErrAirGapped, except on contexts
+	// from WithOutbound
+	AirGapped bool
+}
+
+type BitbucketTag struct {
+	ID           string `json:"id"`
+	DisplayID    string `json:"displayId"`
+	LatestCommit string `json:"latestCommit"`
+}
+
+// bitbucketTagPage is one page of the paged tags API
+type bitbucketTagPage struct {
+	Values        []BitbucketTag `json:"values"`
+	IsLastPage    bool           `json:"isLastPage"`
+	NextPageStart int            `json:"nextPageStart"`
+}
+
+// NewBitbucketClient creates a client for the server at baseURL, such as
+// https://bitbucket.example.com, authenticating with an HTTP access token
+func NewBitbucketClient(baseURL, token string, logger *logrus.Logger) *BitbucketClient {
+	return &BitbucketClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		logger: logger,
+	}
+}
+
+// GetLatestTag returns the highest semantic version among a repository's
+// tags, following pagination
+func (c *BitbucketClient) GetLatestTag(ctx context.Context, projectID string) (string, error) {
+	key, slug, _ := strings.Cut(strings.Trim(projectID, "/"), "/")
+	if key == "" || slug == "" || strings.Contains(slug, "/") {
+		return "", fmt.Errorf("invalid Bitbucket repository %q: want project-key/repo-slug", projectID)
+	}
+
+	var names []string
+	start, last := 0, false
+	for page := 0; !last && page < bitbucketMaxTagPages; page++ {
+		tags, err := c.listTags(ctx, key, slug, start)
+		if err != nil {
+			return "", err
+		}
+		if tags == nil {
+			c.logger.WithField("project_id", projectID).Debug("Bitbucket repository not found")
+			return "", nil
+		}
+		for _, tag := range tags.Values {
+			names = append(names, tag.DisplayID)
+		}
+		start, last = tags.NextPageStart, tags.IsLastPage
+	}
+
+	if len(names) == 0 {
+		c.logger.WithField("project_id", projectID).Debug("No tags found in Bitbucket repository")
+		return "", nil
+	}
+
+	latestVersion := latestSemanticVersion(names, c.LenientTags, projectID, c.logger)
+	if latestVersion != "" {
+		c.logger.WithFields(logrus.Fields{
+			"project_id": projectID,
+			"version":    latestVersion,
+		}).Info("Found latest tag from Bitbucket")
+	} else {
+		c.logger.WithFields(logrus.Fields{
+			"project_id": projectID,
+			"tags":       len(names),
+			"lenient":    c.LenientTags,
+		}).Warn("No Bitbucket tag is a semantic version")
+	}
+
+	return latestVersion, nil
+}
+
+// listTags reads the page of tags starting at start. It returns nil without
+// error when the repository doesn't exist.
+func (c *BitbucketClient) listTags(ctx context.Context, key, slug string, start int) (*bitbucketTagPage, error) {
+	if c.AirGapped && ctx.Value(outboundKey{}) == nil {
+		return nil, ErrAirGapped
+	}
+
+	url := fmt.Sprintf("%s/rest/api/latest/projects/%s/repos/%s/tags?limit=100&start=%d",
+		c.baseURL, neturl.PathEscape(key), neturl.PathEscape(slug), start)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to fetch tags from Bitbucket: %w", ErrBitbucketUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("%w: Bitbucket API returned status %d", ErrBitbucketUnavailable, resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		c.logger.WithFields(logrus.Fields{
+			"project_key": key,
+			"repo":        slug,
+			"status":      resp.StatusCode,
+		}).Warn("Bitbucket API returned non-OK status")
+		return nil, fmt.Errorf("Bitbucket API returned status %d", resp.StatusCode)
+	}
+
+	var page bitbucketTagPage
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("failed to decode Bitbucket response: %w", err)
+	}
+	return &page, nil
+}
@@ -0,0 +1,182 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// giteaMaxTagPages bounds how many pages of tags are read per lookup
+const giteaMaxTagPages = 20
+
+// ErrGiteaUnavailable is returned when Gitea could not be reached or
+// answered 429 or 5xx. Unlike other errors, it says nothing about the
+// project asked for.
+var ErrGiteaUnavailable = errors.New("Gitea unavailable")
+
+// GiteaClient looks up tags of repositories on a Gitea or Forgejo instance.
+// Project IDs are either "owner/repo" or a numeric repository ID.
+type GiteaClient struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+	logger     *logrus.Logger
+
+	// LenientTags coerces sloppy tag names ("1.2", "v1", "1.2.3.4") into
+	// semantic versions when looking up a repository's latest tag, instead
+	// of ignoring them
+	LenientTags bool
+
+	// AirGapped refuses every call with ErrAirGapped, except on contexts
+	// from WithOutbound
+	AirGapped bool
+}
+
+type GiteaTag struct {
+	Name   string `json:"name"`
+	Commit struct {
+		SHA string `json:"sha"`
+	} `json:"commit"`
+}
+
+type GiteaRepository struct {
+	ID       int    `json:"id"`
+	FullName string `json:"full_name"`
+}
+
+// NewGiteaClient creates a client for the API at baseURL, such as
+// https://gitea.example.com/api/v1. Without a token only public repositories
+// can be read.
+func NewGiteaClient(baseURL, token string, logger *logrus.Logger) *GiteaClient {
+	return &GiteaClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		logger: logger,
+	}
+}
+
+// GetLatestTag returns the highest semantic version among a repository's
+// tags, following pagination. Numeric IDs are resolved to the repository's
+// owner and name first, as Gitea lists tags by name only.
+func (c *GiteaClient) GetLatestTag(ctx context.Context, projectID string) (string, error) {
+	fullName := strings.Trim(projectID, "/")
+	if !strings.Contains(fullName, "/") {
+		repo, err := c.getRepository(ctx, fullName)
+		if err != nil {
+			return "", err
+		}
+		if repo == nil {
+			c.logger.WithField("project_id", projectID).Debug("Gitea repository not found")
+			return "", nil
+		}
+		fullName = repo.FullName
+	}
+
+	owner, name, _ := strings.Cut(fullName, "/")
+	if owner == "" || name == "" || strings.Contains(name, "/") {
+		return "", fmt.Errorf("invalid Gitea repository %q: want owner/repo or a repository ID", projectID)
+	}
+
+	url := fmt.Sprintf("%s/repos/%s/%s/tags?limit=50", c.baseURL, neturl.PathEscape(owner), neturl.PathEscape(name))
+	var names []string
+	for page := 0; url != "" && page < giteaMaxTagPages; page++ {
+		var tags []GiteaTag
+		resp, err := c.get(ctx, url, &tags)
+		if err != nil {
+			return "", err
+		}
+		if resp == nil {
+			c.logger.WithField("project_id", projectID).Debug("Gitea repository not found")
+			return "", nil
+		}
+		for _, tag := range tags {
+			names = append(names, tag.Name)
+		}
+		url = nextPage(resp.Header.Get("Link"))
+	}
+
+	if len(names) == 0 {
+		c.logger.WithField("project_id", projectID).Debug("No tags found in Gitea repository")
+		return "", nil
+	}
+
+	latestVersion := latestSemanticVersion(names, c.LenientTags, projectID, c.logger)
+	if latestVersion != "" {
+		c.logger.WithFields(logrus.Fields{
+			"project_id": projectID,
+			"version":    latestVersion,
+		}).Info("Found latest tag from Gitea")
+	} else {
+		c.logger.WithFields(logrus.Fields{
+			"project_id": projectID,
+			"tags":       len(names),
+			"lenient":    c.LenientTags,
+		}).Warn("No Gitea tag is a semantic version")
+	}
+
+	return latestVersion, nil
+}
+
+// getRepository fetches a repository by numeric ID. It returns nil without
+// error when the repository doesn't exist.
+func (c *GiteaClient) getRepository(ctx context.Context, id string) (*GiteaRepository, error) {
+	var repo GiteaRepository
+	resp, err := c.get(ctx, fmt.Sprintf("%s/repositories/%s", c.baseURL, neturl.PathEscape(id)), &repo)
+	if err != nil || resp == nil {
+		return nil, err
+	}
+	return &repo, nil
+}
+
+// get decodes the answer to a GET of url into v. It returns a nil response
+// without error on 404.
+func (c *GiteaClient) get(ctx context.Context, url string, v interface{}) (*http.Response, error) {
+	if c.AirGapped && ctx.Value(outboundKey{}) == nil {
+		return nil, ErrAirGapped
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "token "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to fetch from Gitea: %w", ErrGiteaUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("%w: Gitea API returned status %d", ErrGiteaUnavailable, resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		c.logger.WithFields(logrus.Fields{
+			"url":    url,
+			"status": resp.StatusCode,
+		}).Warn("Gitea API returned non-OK status")
+		return nil, fmt.Errorf("Gitea API returned status %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return nil, fmt.Errorf("failed to decode Gitea response: %w", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,168 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// githubMaxTagPages bounds how many pages of tags are read per lookup;
+// GitHub lists tags by name, not by version, so every page is needed
+const githubMaxTagPages = 10
+
+// ErrGitHubUnavailable is returned when GitHub could not be reached, its
+// rate limit is exhausted or it answered 5xx. Unlike other errors, it says
+// nothing about the project asked for.
+var ErrGitHubUnavailable = errors.New("GitHub unavailable")
+
+// GitHubClient looks up tags of GitHub repositories, for deployments whose
+// projects are hosted on GitHub. Project IDs are either "owner/repo" or a
+// numeric repository ID.
+type GitHubClient struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+	logger     *logrus.Logger
+
+	// LenientTags coerces sloppy tag names ("1.2", "v1", "1.2.3.4") into
+	// semantic versions when looking up a repository's latest tag, instead
+	// of ignoring them
+	LenientTags bool
+
+	// AirGapped refuses every call with ErrAirGapped, except on contexts
+	// from WithOutbound
+	AirGapped bool
+}
+
+type GitHubTag struct {
+	Name   string `json:"name"`
+	Commit struct {
+		SHA string `json:"sha"`
+	} `json:"commit"`
+}
+
+// NewGitHubClient creates a GitHub client. Without a token only public
+// repositories can be read, under GitHub's lower anonymous rate limit.
+func NewGitHubClient(baseURL, token string, logger *logrus.Logger) *GitHubClient {
+	return &GitHubClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		logger: logger,
+	}
+}
+
+// GetLatestTag returns the highest semantic version among a repository's
+// tags, following pagination
+func (c *GitHubClient) GetLatestTag(ctx context.Context, projectID string) (string, error) {
+	url, err := c.tagsURL(projectID)
+	if err != nil {
+		return "", err
+	}
+
+	var names []string
+	for page := 0; url != "" && page < githubMaxTagPages; page++ {
+		tags, next, err := c.listTags(ctx, url)
+		if err != nil {
+			return "", err
+		}
+		if tags == nil {
+			c.logger.WithField("project_id", projectID).Debug("GitHub repository not found")
+			return "", nil
+		}
+		for _, tag := range tags {
+			names = append(names, tag.Name)
+		}
+		url = next
+	}
+
+	if len(names) == 0 {
+		c.logger.WithField("project_id", projectID).Debug("No tags found in GitHub repository")
+		return "", nil
+	}
+
+	latestVersion := latestSemanticVersion(names, c.LenientTags, projectID, c.logger)
+	if latestVersion != "" {
+		c.logger.WithFields(logrus.Fields{
+			"project_id": projectID,
+			"version":    latestVersion,
+		}).Info("Found latest tag from GitHub")
+	} else {
+		c.logger.WithFields(logrus.Fields{
+			"project_id": projectID,
+			"tags":       len(names),
+			"lenient":    c.LenientTags,
+		}).Warn("No GitHub tag is a semantic version")
+	}
+
+	return latestVersion, nil
+}
+
+// tagsURL is the first page of a repository's tags, addressed by
+// "owner/repo" or by numeric repository ID
+func (c *GitHubClient) tagsURL(projectID string) (string, error) {
+	owner, repo, ok := strings.Cut(strings.Trim(projectID, "/"), "/")
+	switch {
+	case !ok:
+		return fmt.Sprintf("%s/repositories/%s/tags?per_page=100", c.baseURL, neturl.PathEscape(projectID)), nil
+	case owner == "" || repo == "" || strings.Contains(repo, "/"):
+		return "", fmt.Errorf("invalid GitHub repository %q: want owner/repo or a repository ID", projectID)
+	default:
+		return fmt.Sprintf("%s/repos/%s/%s/tags?per_page=100", c.baseURL, neturl.PathEscape(owner), neturl.PathEscape(repo)), nil
+	}
+}
+
+// listTags reads one page of tags and the URL of the next page, if any. It
+// returns nil tags without error when the repository doesn't exist.
+func (c *GitHubClient) listTags(ctx context.Context, url string) ([]GitHubTag, string, error) {
+	if c.AirGapped && ctx.Value(outboundKey{}) == nil {
+		return nil, "", ErrAirGapped
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: failed to fetch tags from GitHub: %w", ErrGitHubUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, "", nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500,
+		resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0":
+		io.Copy(io.Discard, resp.Body)
+		return nil, "", fmt.Errorf("%w: GitHub API returned status %d", ErrGitHubUnavailable, resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		c.logger.WithFields(logrus.Fields{
+			"url":    url,
+			"status": resp.StatusCode,
+		}).Warn("GitHub API returned non-OK status")
+		return nil, "", fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
+	}
+
+	tags := []GitHubTag{}
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return nil, "", fmt.Errorf("failed to decode GitHub response: %w", err)
+	}
+	return tags, nextPage(resp.Header.Get("Link")), nil
+}
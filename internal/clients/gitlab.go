@@ -2,13 +2,20 @@ package clients
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
-	"sort"
+	neturl "net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/company/version-service/pkg/semver"
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/pkg/breaker"
 	"github.com/sirupsen/logrus"
 )
 
@@ -17,6 +24,66 @@ type GitLabClient struct {
 	accessToken string
 	httpClient  *http.Client
 	logger      *logrus.Logger
+
+	// LenientTags coerces sloppy tag names ("1.2", "v1", "1.2.3.4") into
+	// semantic versions when looking up a project's latest tag, instead of
+	// ignoring them
+	LenientTags bool
+
+	// AirGapped refuses every call with ErrAirGapped, except on contexts
+	// from WithOutbound
+	AirGapped bool
+
+	// MaxRetries is how often a request failing with a network error, 429
+	// or 5xx is retried. Retries wait RetryBaseDelay, doubled for every
+	// further retry, or as long as Retry-After or RateLimit-Reset ask; no
+	// wait exceeds RetryMaxDelay.
+	MaxRetries     int
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+
+	// Breaker, if set, opens after consecutive network errors and 5xx
+	// answers; while it is open requests fail at once with
+	// ErrGitLabUnavailable instead of waiting for GitLab to time out
+	Breaker *breaker.Breaker
+
+	// rateLimitedUntil is when GitLab's rate limit resets after a response
+	// reported no requests remaining; requests wait for it
+	rateLimitMu      sync.Mutex
+	rateLimitedUntil time.Time
+
+	statsMu sync.Mutex
+	stats   models.GitLabStats
+
+	// jobTokens remembers verified job tokens by hash for jobTokenCacheTTL
+	jobTokensMu sync.Mutex
+	jobTokens   map[[sha256.Size]byte]verifiedJob
+}
+
+// jobTokenCacheTTL is how long a verified job token is trusted without
+// asking GitLab again; tokens expire with their job
+const jobTokenCacheTTL = time.Minute
+
+type verifiedJob struct {
+	job       *GitLabJob
+	expiresAt time.Time
+}
+
+// GitLabJob is the CI job a job token belongs to
+type GitLabJob struct {
+	ID       int64             `json:"id"`
+	Ref      string            `json:"ref"`
+	Pipeline GitLabJobPipeline `json:"pipeline"`
+	User     struct {
+		Username string `json:"username"`
+	} `json:"user"`
+}
+
+// GitLabJobPipeline is the pipeline of a CI job
+type GitLabJobPipeline struct {
+	ID        int64  `json:"id"`
+	ProjectID int64  `json:"project_id"`
+	SHA       string `json:"sha"`
 }
 
 type GitLabTag struct {
@@ -24,13 +91,13 @@ type GitLabTag struct {
 	Message string `json:"message"`
 	Target  string `json:"target"`
 	Commit  struct {
-		ID             string    `json:"id"`
-		ShortID        string    `json:"short_id"`
-		Title          string    `json:"title"`
-		CreatedAt      time.Time `json:"created_at"`
-		AuthorName     string    `json:"author_name"`
-		AuthorEmail    string    `json:"author_email"`
-		CommittedDate  time.Time `json:"committed_date"`
+		ID            string    `json:"id"`
+		ShortID       string    `json:"short_id"`
+		Title         string    `json:"title"`
+		CreatedAt     time.Time `json:"created_at"`
+		AuthorName    string    `json:"author_name"`
+		AuthorEmail   string    `json:"author_email"`
+		CommittedDate time.Time `json:"committed_date"`
 	} `json:"commit"`
 	Release *struct {
 		TagName     string `json:"tag_name"`
@@ -38,6 +105,91 @@ type GitLabTag struct {
 	} `json:"release"`
 }
 
+type GitLabProject struct {
+	ID                int    `json:"id"`
+	Name              string `json:"name"`
+	Path              string `json:"path"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	DefaultBranch     string `json:"default_branch"`
+	WebURL            string `json:"web_url"`
+}
+
+// GitLabCommit is a commit as returned by the repository compare API
+type GitLabCommit struct {
+	ID            string    `json:"id"`
+	ShortID       string    `json:"short_id"`
+	Title         string    `json:"title"`
+	Message       string    `json:"message"`
+	AuthorName    string    `json:"author_name"`
+	AuthorEmail   string    `json:"author_email"`
+	AuthoredDate  time.Time `json:"authored_date"`
+	CommittedDate time.Time `json:"committed_date"`
+	ParentIDs     []string  `json:"parent_ids"`
+	WebURL        string    `json:"web_url"`
+}
+
+// GitLabComparison is the result of comparing two refs, commits oldest first
+type GitLabComparison struct {
+	Commits []GitLabCommit `json:"commits"`
+	WebURL  string         `json:"web_url"`
+}
+
+// ErrNoCredentials is returned by calls that cannot be skipped when neither
+// an access token nor a delegated job token is available
+var ErrNoCredentials = errors.New("GitLab credentials not configured")
+
+// ErrInvalidJobToken is returned when GitLab doesn't accept a CI job token,
+// such as one of a finished job
+var ErrInvalidJobToken = errors.New("invalid GitLab job token")
+
+// ErrAirGapped is returned by GitLab calls while the client is air-gapped
+var ErrAirGapped = errors.New("outbound calls are deferred in air-gapped mode")
+
+// ErrGitLabUnavailable is returned when GitLab could not be reached, or kept
+// answering 429 or 5xx, after all retries. Unlike other errors, it says
+// nothing about the project asked for.
+var ErrGitLabUnavailable = errors.New("GitLab unavailable")
+
+type delegatedTokenKey struct{}
+
+// delegatedCredential is a job token GitLab accepted and the job it belongs to
+type delegatedCredential struct {
+	token string
+	job   *GitLabJob
+}
+
+type outboundKey struct{}
+
+// WithOutbound returns a context whose GitLab calls are made even when the
+// client is air-gapped, as when flushing deferred calls
+func WithOutbound(ctx context.Context) context.Context {
+	return context.WithValue(ctx, outboundKey{}, true)
+}
+
+// WithDelegatedToken returns a context carrying a caller's GitLab CI job
+// token, verified with VerifyJobToken, and its job. GitLab calls made with
+// this context authenticate as the calling pipeline instead of with the
+// service's own access token.
+func WithDelegatedToken(ctx context.Context, token string, job *GitLabJob) context.Context {
+	return context.WithValue(ctx, delegatedTokenKey{}, delegatedCredential{token: token, job: job})
+}
+
+// HasDelegatedToken reports whether ctx carries a verified job token
+func HasDelegatedToken(ctx context.Context) bool {
+	return delegatedToken(ctx) != ""
+}
+
+// DelegatedJob returns the job of the verified job token ctx carries, or nil
+func DelegatedJob(ctx context.Context) *GitLabJob {
+	credential, _ := ctx.Value(delegatedTokenKey{}).(delegatedCredential)
+	return credential.job
+}
+
+func delegatedToken(ctx context.Context) string {
+	credential, _ := ctx.Value(delegatedTokenKey{}).(delegatedCredential)
+	return credential.token
+}
+
 func NewGitLabClient(baseURL, accessToken string, logger *logrus.Logger) *GitLabClient {
 	return &GitLabClient{
 		baseURL:     baseURL,
@@ -46,26 +198,329 @@ func NewGitLabClient(baseURL, accessToken string, logger *logrus.Logger) *GitLab
 			Timeout: 10 * time.Second,
 		},
 		logger: logger,
+
+		MaxRetries:     3,
+		RetryBaseDelay: 500 * time.Millisecond,
+		RetryMaxDelay:  30 * time.Second,
+	}
+}
+
+// authenticate sets the request credentials. A delegated job token always
+// takes precedence and is never mixed with the service token, so a caller can't
+// reach anything its own pipeline isn't allowed to. It reports false when no
+// credentials are available.
+func (c *GitLabClient) authenticate(ctx context.Context, req *http.Request) bool {
+	if token := delegatedToken(ctx); token != "" {
+		req.Header.Set("JOB-TOKEN", token)
+		return true
+	}
+	if c.accessToken != "" {
+		req.Header.Set("PRIVATE-TOKEN", c.accessToken)
+		return true
+	}
+	return false
+}
+
+// do sends req, retrying network errors, 429 and 5xx answers with backoff
+// and waiting out GitLab's rate limit. Requests must not have a body. The
+// last answer is returned once retries are exhausted; a network error is
+// then wrapped in ErrGitLabUnavailable. Air-gapped clients refuse it.
+func (c *GitLabClient) do(req *http.Request) (*http.Response, error) {
+	if c.AirGapped && req.Context().Value(outboundKey{}) == nil {
+		return nil, ErrAirGapped
+	}
+
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		if err := c.waitForRateLimit(ctx); err != nil {
+			return nil, err
+		}
+		if err := c.Breaker.Allow(); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrGitLabUnavailable, err)
+		}
+
+		resp, err := c.send(req)
+		if ctx.Err() != nil {
+			return resp, err
+		}
+		if err != nil || resp.StatusCode >= http.StatusInternalServerError {
+			c.Breaker.Failure()
+		} else {
+			c.Breaker.Success()
+		}
+		if err == nil {
+			c.observeRateLimit(resp)
+			if !retryableStatus(resp.StatusCode) {
+				return resp, nil
+			}
+		}
+		if attempt >= c.MaxRetries {
+			if err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrGitLabUnavailable, err)
+			}
+			return resp, nil
+		}
+
+		delay := c.retryDelay(attempt, resp)
+		fields := logrus.Fields{
+			"path":     req.URL.Path,
+			"attempt":  attempt + 1,
+			"delay_ms": delay.Milliseconds(),
+		}
+		if err != nil {
+			c.logger.WithError(err).WithFields(fields).Warn("GitLab request failed, retrying")
+		} else {
+			fields["status"] = resp.StatusCode
+			c.logger.WithFields(fields).Warn("GitLab request failed, retrying")
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		c.statsMu.Lock()
+		c.stats.Retries++
+		c.statsMu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// send makes one attempt at req and counts it in the client's stats
+func (c *GitLabClient) send(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	latencyMs := float64(time.Since(start).Milliseconds())
+
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+	c.stats.RequestsTotal++
+	c.stats.LastRequestAt = &start
+	if err != nil || (resp.StatusCode >= 400 && resp.StatusCode != http.StatusNotFound) {
+		c.stats.RequestsFailed++
+	}
+	if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+		c.stats.RateLimited++
+	}
+	if c.stats.AvgLatencyMs == 0 {
+		c.stats.AvgLatencyMs = latencyMs
+	} else {
+		c.stats.AvgLatencyMs = c.stats.AvgLatencyMs*0.9 + latencyMs*0.1
+	}
+	return resp, err
+}
+
+// retryableStatus reports whether an answer may change when asked again
+func retryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// retryDelay is how long to wait before retrying after attempt. A 429 is
+// retried when GitLab says, through Retry-After or RateLimit-Reset, as long
+// as that is no later than RetryMaxDelay.
+func (c *GitLabClient) retryDelay(attempt int, resp *http.Response) time.Duration {
+	delay := c.RetryBaseDelay << attempt
+	if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+		if wait, ok := rateLimitWait(resp.Header, time.Now()); ok {
+			delay = wait
+		}
+	}
+	if delay > c.RetryMaxDelay || delay < 0 {
+		delay = c.RetryMaxDelay
+	}
+	return delay
+}
+
+// rateLimitWait reads how long GitLab asks clients to wait from Retry-After,
+// in seconds or as an HTTP date, or else from RateLimit-Reset, a Unix time
+func rateLimitWait(header http.Header, now time.Time) (time.Duration, bool) {
+	if value := header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if at, err := http.ParseTime(value); err == nil {
+			return at.Sub(now), true
+		}
+	}
+	if value := header.Get("RateLimit-Reset"); value != "" {
+		if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return time.Unix(unix, 0).Sub(now), true
+		}
+	}
+	return 0, false
+}
+
+// observeRateLimit holds back further requests until the rate limit resets
+// once an answer reports no requests remaining
+func (c *GitLabClient) observeRateLimit(resp *http.Response) {
+	if resp.Header.Get("RateLimit-Remaining") != "0" {
+		return
+	}
+	wait, ok := rateLimitWait(resp.Header, time.Now())
+	if !ok || wait <= 0 {
+		return
+	}
+
+	c.rateLimitMu.Lock()
+	defer c.rateLimitMu.Unlock()
+	if until := time.Now().Add(wait); until.After(c.rateLimitedUntil) {
+		c.rateLimitedUntil = until
+		c.logger.WithField("reset_at", until.Format(time.RFC3339)).Warn("GitLab rate limit exhausted, holding requests until it resets")
+	}
+}
+
+// waitForRateLimit blocks until the rate limit resets, for at most
+// RetryMaxDelay
+func (c *GitLabClient) waitForRateLimit(ctx context.Context) error {
+	c.rateLimitMu.Lock()
+	wait := time.Until(c.rateLimitedUntil)
+	c.rateLimitMu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+	if wait > c.RetryMaxDelay {
+		wait = c.RetryMaxDelay
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// statusError describes an unexpected answer; 429 and 5xx, which were
+// retried, are ErrGitLabUnavailable
+func statusError(status int) error {
+	if retryableStatus(status) {
+		return fmt.Errorf("%w: GitLab API returned status %d", ErrGitLabUnavailable, status)
+	}
+	return fmt.Errorf("GitLab API returned status %d", status)
+}
+
+// VerifyJobToken asks GitLab which job a CI job token belongs to, which
+// fails with ErrInvalidJobToken unless the job is still running. Verified
+// tokens are remembered for a minute.
+func (c *GitLabClient) VerifyJobToken(ctx context.Context, token string) (*GitLabJob, error) {
+	key := sha256.Sum256([]byte(token))
+	c.jobTokensMu.Lock()
+	cached, ok := c.jobTokens[key]
+	c.jobTokensMu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.job, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/job", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	req.Header.Set("JOB-TOKEN", token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify job token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return nil, ErrInvalidJobToken
+	case resp.StatusCode != http.StatusOK:
+		return nil, statusError(resp.StatusCode)
+	}
+
+	var job GitLabJob
+	if err := json.NewDecoder(resp.Body).Decode(&job); err != nil {
+		return nil, fmt.Errorf("failed to decode GitLab response: %w", err)
+	}
+
+	now := time.Now()
+	c.jobTokensMu.Lock()
+	if c.jobTokens == nil {
+		c.jobTokens = make(map[[sha256.Size]byte]verifiedJob)
+	}
+	for k, entry := range c.jobTokens {
+		if now.After(entry.expiresAt) {
+			delete(c.jobTokens, k)
+		}
+	}
+	c.jobTokens[key] = verifiedJob{job: &job, expiresAt: now.Add(jobTokenCacheTTL)}
+	c.jobTokensMu.Unlock()
+	return &job, nil
+}
+
+// CreateRelease creates a release of a project with tagName, creating the
+// tag at ref first if it doesn't exist. Only a delegated job token is used,
+// so a release is never created beyond what the calling pipeline may do; a
+// release that already exists counts as created.
+func (c *GitLabClient) CreateRelease(ctx context.Context, projectID, tagName, ref, description string) error {
+	token := delegatedToken(ctx)
+	if token == "" {
+		return ErrNoCredentials
+	}
+
+	params := neturl.Values{}
+	params.Set("tag_name", tagName)
+	params.Set("ref", ref)
+	params.Set("description", description)
+	url := fmt.Sprintf("%s/projects/%s/releases?%s", c.baseURL, neturl.PathEscape(projectID), params.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("JOB-TOKEN", token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to create GitLab release: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusConflict {
+		return statusError(resp.StatusCode)
+	}
+	return nil
+}
+
+// Stats returns the requests made to GitLab since the client was created
+func (c *GitLabClient) Stats() models.GitLabStats {
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+
+	stats := c.stats
+	if stats.RequestsTotal > 0 {
+		stats.SuccessRatePct = float64(stats.RequestsTotal-stats.RequestsFailed) / float64(stats.RequestsTotal) * 100
+	}
+	return stats
 }
 
 func (c *GitLabClient) GetLatestTag(ctx context.Context, projectID string) (string, error) {
-	if c.accessToken == "" {
+	if c.accessToken == "" && delegatedToken(ctx) == "" {
 		c.logger.Debug("GitLab access token not configured, skipping tag lookup")
 		return "", nil
 	}
 
-	url := fmt.Sprintf("%s/projects/%s/repository/tags", c.baseURL, projectID)
+	url := fmt.Sprintf("%s/projects/%s/repository/tags", c.baseURL, neturl.PathEscape(projectID))
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("PRIVATE-TOKEN", c.accessToken)
+	c.authenticate(ctx, req)
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to fetch tags from GitLab: %w", err)
 	}
@@ -81,7 +536,7 @@ func (c *GitLabClient) GetLatestTag(ctx context.Context, projectID string) (stri
 			"project_id": projectID,
 			"status":     resp.StatusCode,
 		}).Warn("GitLab API returned non-OK status")
-		return "", fmt.Errorf("GitLab API returned status %d", resp.StatusCode)
+		return "", statusError(resp.StatusCode)
 	}
 
 	var tags []GitLabTag
@@ -95,75 +550,210 @@ func (c *GitLabClient) GetLatestTag(ctx context.Context, projectID string) (stri
 	}
 
 	// Find the latest semantic version tag
-	latestVersion := c.findLatestSemanticVersion(tags)
+	latestVersion := c.findLatestSemanticVersion(projectID, tags)
 	if latestVersion != "" {
 		c.logger.WithFields(logrus.Fields{
 			"project_id": projectID,
 			"version":    latestVersion,
 		}).Info("Found latest tag from GitLab")
+	} else {
+		c.logger.WithFields(logrus.Fields{
+			"project_id": projectID,
+			"tags":       len(tags),
+			"lenient":    c.LenientTags,
+		}).Warn("No GitLab tag is a semantic version")
 	}
 
 	return latestVersion, nil
 }
 
-func (c *GitLabClient) findLatestSemanticVersion(tags []GitLabTag) string {
-	var validVersions []struct {
-		tag     string
-		version *semver.Version
+// GetProject fetches project details. It returns nil without error when the
+// project doesn't exist or no credentials are configured.
+func (c *GitLabClient) GetProject(ctx context.Context, projectID string) (*GitLabProject, error) {
+	url := fmt.Sprintf("%s/projects/%s", c.baseURL, neturl.PathEscape(projectID))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if !c.authenticate(ctx, req) {
+		c.logger.Debug("GitLab access token not configured, skipping project lookup")
+		return nil, nil
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch project from GitLab: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		c.logger.WithField("project_id", projectID).Debug("GitLab project not found")
+		return nil, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		c.logger.WithFields(logrus.Fields{
+			"project_id": projectID,
+			"status":     resp.StatusCode,
+		}).Warn("GitLab API returned non-OK status")
+		return nil, statusError(resp.StatusCode)
+	}
+
+	var project GitLabProject
+	if err := json.NewDecoder(resp.Body).Decode(&project); err != nil {
+		return nil, fmt.Errorf("failed to decode GitLab response: %w", err)
+	}
+
+	return &project, nil
+}
+
+// GetProjectByPath fetches a project by its full path, such as
+// "group/subgroup/app", instead of its numeric ID. Like GetProject, it
+// returns nil without error when the project doesn't exist or no
+// credentials are configured.
+func (c *GitLabClient) GetProjectByPath(ctx context.Context, path string) (*GitLabProject, error) {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil, nil
 	}
+	return c.GetProject(ctx, path)
+}
 
-	for _, tag := range tags {
-		// Try to parse the tag as a semantic version
-		// Handle tags with or without 'v' prefix
-		tagName := tag.Name
-		if tagName != "" && tagName[0] == 'v' {
-			tagName = tagName[1:]
+// ListGroupProjects returns all non-archived projects in a group, including
+// subgroups, following GitLab's pagination
+func (c *GitLabClient) ListGroupProjects(ctx context.Context, group string) ([]GitLabProject, error) {
+	var projects []GitLabProject
+
+	page := "1"
+	for page != "" {
+		url := fmt.Sprintf("%s/groups/%s/projects?include_subgroups=true&archived=false&per_page=100&page=%s",
+			c.baseURL, neturl.PathEscape(group), page)
+
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
 		}
 
-		version, err := semver.Parse(tagName)
-		if err == nil {
-			validVersions = append(validVersions, struct {
-				tag     string
-				version *semver.Version
-			}{
-				tag:     tag.Name,
-				version: version,
-			})
+		if !c.authenticate(ctx, req) {
+			c.logger.Debug("GitLab access token not configured, skipping group project listing")
+			return nil, nil
+		}
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := c.do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list group projects from GitLab: %w", err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			c.logger.WithFields(logrus.Fields{
+				"group":  group,
+				"status": resp.StatusCode,
+			}).Warn("GitLab API returned non-OK status")
+			return nil, fmt.Errorf("group %s: %w", group, statusError(resp.StatusCode))
+		}
+
+		var batch []GitLabProject
+		err = json.NewDecoder(resp.Body).Decode(&batch)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode GitLab response: %w", err)
 		}
-	}
 
-	if len(validVersions) == 0 {
-		return ""
+		projects = append(projects, batch...)
+		page = resp.Header.Get("X-Next-Page")
 	}
 
-	// Sort versions in descending order (latest first)
-	sort.Slice(validVersions, func(i, j int) bool {
-		vi := validVersions[i].version
-		vj := validVersions[j].version
+	return projects, nil
+}
+
+// FindVersionTag returns the name of the tag for version in a project,
+// trying the 'v' prefixed name first. It returns an empty name when neither
+// tag exists.
+func (c *GitLabClient) FindVersionTag(ctx context.Context, projectID, version string) (string, error) {
+	for _, name := range []string{"v" + version, version} {
+		url := fmt.Sprintf("%s/projects/%s/repository/tags/%s",
+			c.baseURL, neturl.PathEscape(projectID), neturl.PathEscape(name))
 
-		if vi.Major != vj.Major {
-			return vi.Major > vj.Major
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to create request: %w", err)
 		}
-		if vi.Minor != vj.Minor {
-			return vi.Minor > vj.Minor
+
+		if !c.authenticate(ctx, req) {
+			return "", ErrNoCredentials
 		}
-		if vi.Patch != vj.Patch {
-			return vi.Patch > vj.Patch
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := c.do(req)
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch tag from GitLab: %w", err)
 		}
-		// Consider pre-release versions as lower
-		if vi.Prerelease != "" && vj.Prerelease == "" {
-			return false
+		resp.Body.Close()
+
+		if resp.StatusCode == http.StatusOK {
+			return name, nil
 		}
-		if vi.Prerelease == "" && vj.Prerelease != "" {
-			return true
+		if resp.StatusCode != http.StatusNotFound {
+			c.logger.WithFields(logrus.Fields{
+				"project_id": projectID,
+				"tag":        name,
+				"status":     resp.StatusCode,
+			}).Warn("GitLab API returned non-OK status")
+			return "", statusError(resp.StatusCode)
 		}
-		return vi.Prerelease > vj.Prerelease
-	})
+	}
 
-	// Return the version string without 'v' prefix for consistency
-	latestTag := validVersions[0].tag
-	if latestTag != "" && latestTag[0] == 'v' {
-		return latestTag[1:]
+	return "", nil
+}
+
+// CompareRefs lists the commits reachable from to but not from from
+func (c *GitLabClient) CompareRefs(ctx context.Context, projectID, from, to string) (*GitLabComparison, error) {
+	url := fmt.Sprintf("%s/projects/%s/repository/compare?from=%s&to=%s",
+		c.baseURL, neturl.PathEscape(projectID), neturl.QueryEscape(from), neturl.QueryEscape(to))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if !c.authenticate(ctx, req) {
+		return nil, ErrNoCredentials
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare refs in GitLab: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		c.logger.WithFields(logrus.Fields{
+			"project_id": projectID,
+			"from":       from,
+			"to":         to,
+			"status":     resp.StatusCode,
+		}).Warn("GitLab API returned non-OK status")
+		return nil, statusError(resp.StatusCode)
 	}
-	return latestTag
-}
\ No newline at end of file
+
+	var comparison GitLabComparison
+	if err := json.NewDecoder(resp.Body).Decode(&comparison); err != nil {
+		return nil, fmt.Errorf("failed to decode GitLab response: %w", err)
+	}
+
+	return &comparison, nil
+}
+
+func (c *GitLabClient) findLatestSemanticVersion(projectID string, tags []GitLabTag) string {
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name
+	}
+	return latestSemanticVersion(names, c.LenientTags, projectID, c.logger)
+}
@@ -0,0 +1,89 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestGitLabClient(t *testing.T, handler http.HandlerFunc) *GitLabClient {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return NewGitLabClient(server.URL, "service-token", logger)
+}
+
+func TestVerifyJobToken(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestGitLabClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		assert.Equal(t, "/job", r.URL.Path)
+		assert.Empty(t, r.Header.Get("PRIVATE-TOKEN"))
+		if r.Header.Get("JOB-TOKEN") != "valid" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":       7,
+			"ref":      "main",
+			"pipeline": map[string]interface{}{"id": 3, "project_id": 42, "sha": "abc123"},
+		})
+	})
+
+	job, err := client.VerifyJobToken(context.Background(), "valid")
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), job.ID)
+	assert.Equal(t, int64(42), job.Pipeline.ProjectID)
+	assert.Equal(t, "abc123", job.Pipeline.SHA)
+
+	// A verified token is cached
+	_, err = client.VerifyJobToken(context.Background(), "valid")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+
+	_, err = client.VerifyJobToken(context.Background(), "forged")
+	assert.ErrorIs(t, err, ErrInvalidJobToken)
+
+	// Rejected tokens are not cached
+	_, err = client.VerifyJobToken(context.Background(), "forged")
+	assert.ErrorIs(t, err, ErrInvalidJobToken)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestCreateRelease(t *testing.T) {
+	status := http.StatusCreated
+	client := newTestGitLabClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/projects/42/releases", r.URL.Path)
+		assert.Equal(t, "v1.2.3", r.URL.Query().Get("tag_name"))
+		assert.Equal(t, "abc123", r.URL.Query().Get("ref"))
+		assert.Equal(t, "job-token", r.Header.Get("JOB-TOKEN"))
+		assert.Empty(t, r.Header.Get("PRIVATE-TOKEN"))
+		w.WriteHeader(status)
+	})
+
+	// Never falls back to the service token
+	err := client.CreateRelease(context.Background(), "42", "v1.2.3", "abc123", "app 1.2.3")
+	assert.ErrorIs(t, err, ErrNoCredentials)
+
+	ctx := WithDelegatedToken(context.Background(), "job-token", &GitLabJob{ID: 7})
+	assert.NoError(t, client.CreateRelease(ctx, "42", "v1.2.3", "abc123", "app 1.2.3"))
+
+	// An existing release is not an error
+	status = http.StatusConflict
+	assert.NoError(t, client.CreateRelease(ctx, "42", "v1.2.3", "abc123", "app 1.2.3"))
+
+	status = http.StatusForbidden
+	assert.Error(t, client.CreateRelease(ctx, "42", "v1.2.3", "abc123", "app 1.2.3"))
+}
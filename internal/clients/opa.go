@@ -0,0 +1,89 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+)
+
+// OPAClient asks an Open Policy Agent server, usually a sidecar, for
+// authorization decisions through its Data API
+type OPAClient struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewOPAClient returns a client evaluating the rule at policyPath, such as
+// "version_service/authz", on the OPA server at baseURL
+func NewOPAClient(baseURL, policyPath string, timeout time.Duration) *OPAClient {
+	return &OPAClient{
+		url: strings.TrimSuffix(baseURL, "/") + "/v1/data/" + strings.Trim(policyPath, "/"),
+		httpClient: &http.Client{
+			Timeout: timeout,
+		},
+	}
+}
+
+// URL returns the Data API endpoint the client queries
+func (c *OPAClient) URL() string {
+	return c.url
+}
+
+// Authorize evaluates the policy with input. The rule may produce a boolean
+// or an object with allow and reason fields; an undefined result denies.
+func (c *OPAClient) Authorize(ctx context.Context, input *models.AuthorizationInput) (*models.AuthorizationDecision, error) {
+	body, err := json.Marshal(struct {
+		Input *models.AuthorizationInput `json:"input"`
+	}{input})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal policy input: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query policy engine: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("policy engine returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode policy result: %w", err)
+	}
+
+	return decodeDecision(result.Result)
+}
+
+func decodeDecision(raw json.RawMessage) (*models.AuthorizationDecision, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return &models.AuthorizationDecision{Reason: "policy result is undefined"}, nil
+	}
+
+	var allow bool
+	if err := json.Unmarshal(raw, &allow); err == nil {
+		return &models.AuthorizationDecision{Allow: allow}, nil
+	}
+
+	var decision models.AuthorizationDecision
+	if err := json.Unmarshal(raw, &decision); err != nil {
+		return nil, fmt.Errorf("policy result must be a boolean or an object with allow and reason: %w", err)
+	}
+	return &decision, nil
+}
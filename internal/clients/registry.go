@@ -0,0 +1,109 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Tag provider names used in configuration
+const (
+	TagProviderGitLab    = "gitlab"
+	TagProviderGitHub    = "github"
+	TagProviderGitea     = "gitea"
+	TagProviderBitbucket = "bitbucket"
+)
+
+// TagProviderRegistry is the TagProvider of a deployment whose projects live
+// on several forges. Projects routed with Route are looked up with their own
+// provider, under the repository name they have there. All others are looked
+// up along the chain: the first provider that knows a version tag wins.
+// Project IDs without a slash, such as numeric IDs, are specific to one forge
+// and are only looked up with the first provider of the chain.
+type TagProviderRegistry struct {
+	providers map[string]TagProvider
+	chain     []string
+	routes    map[string]tagRoute
+	logger    *logrus.Logger
+}
+
+type tagRoute struct {
+	provider   string
+	repository string
+}
+
+func NewTagProviderRegistry(logger *logrus.Logger) *TagProviderRegistry {
+	return &TagProviderRegistry{
+		providers: make(map[string]TagProvider),
+		routes:    make(map[string]tagRoute),
+		logger:    logger,
+	}
+}
+
+// Register adds a provider under name, replacing any provider of that name
+func (r *TagProviderRegistry) Register(name string, provider TagProvider) {
+	r.providers[name] = provider
+}
+
+// SetChain sets the providers asked, in order, for projects without a route
+func (r *TagProviderRegistry) SetChain(names ...string) error {
+	for _, name := range names {
+		if _, ok := r.providers[name]; !ok {
+			return fmt.Errorf("tag provider %s is not registered", name)
+		}
+	}
+	r.chain = names
+	return nil
+}
+
+// Route looks up a project's tags with the named provider only, as
+// repository there; an empty repository keeps the project ID
+func (r *TagProviderRegistry) Route(projectID, name, repository string) error {
+	if _, ok := r.providers[name]; !ok {
+		return fmt.Errorf("tag provider %s is not registered", name)
+	}
+	if repository == "" {
+		repository = projectID
+	}
+	r.routes[projectID] = tagRoute{provider: name, repository: repository}
+	return nil
+}
+
+// GetLatestTag asks the project's route or else the chain. When no provider
+// of the chain knows a tag and one of them was unavailable, its error is
+// returned, so the project is not seeded with the default version while its
+// forge is down.
+func (r *TagProviderRegistry) GetLatestTag(ctx context.Context, projectID string) (string, error) {
+	if route, ok := r.routes[projectID]; ok {
+		return r.providers[route.provider].GetLatestTag(ctx, route.repository)
+	}
+
+	chain := r.chain
+	if len(chain) > 1 && !strings.Contains(projectID, "/") {
+		chain = chain[:1]
+	}
+
+	var unavailable error
+	for _, name := range chain {
+		tag, err := r.providers[name].GetLatestTag(ctx, projectID)
+		switch {
+		case errors.Is(err, ErrAirGapped):
+			return "", err
+		case TagProviderUnavailable(err):
+			if unavailable == nil {
+				unavailable = err
+			}
+		case err != nil:
+			r.logger.WithError(err).WithFields(logrus.Fields{
+				"project_id": projectID,
+				"provider":   name,
+			}).Debug("Tag provider failed, trying the next")
+		case tag != "":
+			return tag, nil
+		}
+	}
+	return "", unavailable
+}
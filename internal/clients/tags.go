@@ -0,0 +1,82 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/company/version-service/pkg/semver"
+	"github.com/sirupsen/logrus"
+)
+
+// TagProvider finds the latest version tag of a project, which new apps are
+// seeded with. GitLabClient, GitHubClient, GiteaClient and BitbucketClient
+// implement it.
+type TagProvider interface {
+	// GetLatestTag returns the highest semantic version among the project's
+	// tags, without a 'v' prefix. It returns an empty version without error
+	// when the project doesn't exist or has no version tags.
+	GetLatestTag(ctx context.Context, projectID string) (string, error)
+}
+
+var (
+	_ TagProvider = (*GitLabClient)(nil)
+	_ TagProvider = (*GitHubClient)(nil)
+	_ TagProvider = (*GiteaClient)(nil)
+	_ TagProvider = (*BitbucketClient)(nil)
+	_ TagProvider = (*TagProviderRegistry)(nil)
+)
+
+// TagProviderUnavailable reports whether a tag lookup failed because the
+// forge could not answer, rather than because of the project
+func TagProviderUnavailable(err error) bool {
+	return errors.Is(err, ErrGitLabUnavailable) ||
+		errors.Is(err, ErrGitHubUnavailable) ||
+		errors.Is(err, ErrGiteaUnavailable) ||
+		errors.Is(err, ErrBitbucketUnavailable)
+}
+
+// latestSemanticVersion returns the highest semantic version among tag
+// names. Tags may carry a 'v' prefix; the version is returned without it. In
+// lenient mode other sloppy names are coerced, and strict tags go first so
+// they win over coerced tags of equal precedence.
+func latestSemanticVersion(names []string, lenient bool, projectID string, logger *logrus.Logger) string {
+	var strict, coerced []string
+	for _, tag := range names {
+		name := strings.TrimPrefix(tag, "v")
+		if !lenient {
+			strict = append(strict, name)
+			continue
+		}
+
+		version, _, err := semver.ParseLenient(name)
+		if err != nil {
+			continue
+		}
+		if version.String() == name {
+			strict = append(strict, name)
+			continue
+		}
+
+		logger.WithFields(logrus.Fields{
+			"project_id": projectID,
+			"tag":        tag,
+			"version":    version.String(),
+		}).Debug("Coerced tag into a semantic version")
+		coerced = append(coerced, version.String())
+	}
+
+	return semver.Latest(append(strict, coerced...))
+}
+
+// nextPage returns the rel="next" URL of a Link header, as GitHub and
+// Gitea paginate
+func nextPage(link string) string {
+	for _, part := range strings.Split(link, ",") {
+		target, params, ok := strings.Cut(part, ";")
+		if ok && strings.Contains(params, `rel="next"`) {
+			return strings.Trim(strings.TrimSpace(target), "<>")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,137 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// SignatureHeader carries the HMAC-SHA256 of the request body, hex encoded
+// and prefixed with "sha256=", when the client has a secret
+const SignatureHeader = "X-Webhook-Signature"
+
+// WebhookClient posts JSON notifications to an HTTP endpoint. Payloads that
+// carry a top-level "text" field are accepted as-is by Slack incoming webhooks.
+type WebhookClient struct {
+	url        string
+	secret     string
+	httpClient *http.Client
+	logger     *logrus.Logger
+	outbox     Deferrer
+}
+
+// Deferrer queues outbound calls instead of making them, as done in
+// air-gapped mode
+type Deferrer interface {
+	Append(ctx context.Context, calls []models.DeferredCall) error
+}
+
+func NewWebhookClient(url string, logger *logrus.Logger) *WebhookClient {
+	return &WebhookClient{
+		url: url,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		logger: logger,
+	}
+}
+
+// NewSignedWebhookClient returns a client that signs every request body with
+// secret. An empty secret disables signing.
+func NewSignedWebhookClient(url, secret string, logger *logrus.Logger) *WebhookClient {
+	client := NewWebhookClient(url, logger)
+	client.secret = secret
+	return client
+}
+
+// Sign returns the signature header value for body
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// URL returns the endpoint the client posts to
+func (c *WebhookClient) URL() string {
+	return c.url
+}
+
+// DeferTo makes Notify queue notifications to outbox instead of sending
+// them. Post, whose caller waits for the answer, still sends.
+func (c *WebhookClient) DeferTo(outbox Deferrer) *WebhookClient {
+	c.outbox = outbox
+	return c
+}
+
+func (c *WebhookClient) Notify(ctx context.Context, payload interface{}) error {
+	if c.outbox != nil {
+		return c.deferNotification(ctx, payload)
+	}
+	return c.Post(ctx, payload, nil)
+}
+
+func (c *WebhookClient) deferNotification(ctx context.Context, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	call := models.DeferredCall{
+		Kind:     models.DeferredWebhook,
+		URL:      c.url,
+		Secret:   c.secret,
+		Payload:  body,
+		QueuedAt: time.Now(),
+	}
+	if err := c.outbox.Append(ctx, []models.DeferredCall{call}); err != nil {
+		return fmt.Errorf("failed to defer webhook: %w", err)
+	}
+	return nil
+}
+
+// Post sends payload and, when out is non-nil, decodes a JSON response body
+// into it
+func (c *WebhookClient) Post(ctx context.Context, payload interface{}, out interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.secret != "" {
+		req.Header.Set(SignatureHeader, Sign(c.secret, body))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		c.logger.WithField("status", resp.StatusCode).Warn("Webhook endpoint returned non-success status")
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil && err != io.EOF {
+			return fmt.Errorf("failed to decode webhook response: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -2,7 +2,12 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
@@ -15,6 +20,311 @@ type Config struct {
 	GitLabBaseURL     string
 	GitLabAccessToken string
 	LogLevel          string
+
+	// Git authentication: "token" uses GitUsername and GitToken over HTTPS,
+	// "ssh" a private key from GitSSHKey or GitSSHKeyPath. Host keys are
+	// checked against GitSSHKnownHosts, or the user's and system's
+	// known_hosts files when it is empty.
+	GitAuthMethod               string
+	GitSSHUser                  string
+	GitSSHKey                   string
+	GitSSHKeyPath               string
+	GitSSHKeyPassphrase         string
+	GitSSHKnownHosts            string
+	GitSSHInsecureIgnoreHostKey bool
+
+	// Local clone of the Git backend: history depth (0 = full), whether
+	// only the versions files are checked out, and how often a shallow
+	// clone is made again to drop the commits pulled since (0 = never)
+	GitCloneDepth        int
+	GitSparseCheckout    bool
+	GitReshallowInterval time.Duration
+
+	// Maintenance of the local clone every GitMaintenanceInterval (0 =
+	// never): corrupt clones are cloned again, unreachable objects pruned,
+	// and clones larger than GitMaxCloneSizeMB (0 = no cap) replaced
+	GitMaintenanceInterval time.Duration
+	GitMaxCloneSizeMB      int
+
+	// Read-only mirror of GitRepoURL, reached with the same credentials,
+	// that clones and pulls fall back to while the primary is unreachable
+	GitMirrorURL string
+
+	// Tag each version in the Git backend as {app-id}/{version} on the
+	// commit that first writes it
+	GitTagIncrements bool
+
+	// Keep each project's versions on a branch of its own, named
+	// GitProjectBranchPrefix followed by the project ID, instead of
+	// GitBranch
+	GitBranchPerProject    bool
+	GitProjectBranchPrefix string
+
+	// Projects whose versions live in another repository than GitRepoURL,
+	// by project ID
+	GitRepoRoutes map[string]string
+
+	// Serialization of values cached in Redis: json, msgpack or protobuf
+	RedisCodec string
+
+	// Redis ACL credentials, overriding those in RedisURL, and TLS beyond
+	// rediss:// URLs: RedisTLS or any TLS file enables it for redis://
+	// URLs, RedisTLSCAFile replaces the system CAs, and RedisTLSCertFile
+	// and RedisTLSKeyFile present a client certificate
+	RedisUsername              string
+	RedisPassword              string
+	RedisTLS                   bool
+	RedisTLSCAFile             string
+	RedisTLSCertFile           string
+	RedisTLSKeyFile            string
+	RedisTLSInsecureSkipVerify bool
+
+	// Redis connection pool size and minimum idle connections, and the
+	// timeouts of dialing and of each command's read and write; zero keeps
+	// the go-redis defaults
+	RedisPoolSize     int
+	RedisMinIdleConns int
+	RedisDialTimeout  time.Duration
+	RedisReadTimeout  time.Duration
+	RedisWriteTimeout time.Duration
+
+	// Order of the Redis and durable writes: write-back caches in Redis and
+	// persists in the background, write-through persists before answering
+	WritePolicy string
+
+	// How often the Redis cache is rebuilt from Git; 0 rebuilds it on
+	// startup only
+	CacheRebuildInterval time.Duration
+
+	// Attach trace IDs from incoming traceparent headers to duration
+	// histograms as exemplars
+	TracingEnabled bool
+
+	// Serve the read-only web UI at /ui
+	UIEnabled bool
+
+	// Use caller-supplied GitLab CI job tokens for GitLab operations
+	GitLabDelegatedTokens bool
+
+	// Create a GitLab release for increments sent with a job token of the
+	// app's project, tagged with GitLabReleaseTag
+	GitLabCreateReleases bool
+	GitLabReleaseTag     string
+
+	// Coerce sloppy GitLab tags ("1.2", "v1", "1.2.3.4") when seeding apps
+	GitLabLenientTags bool
+
+	// Retries of GitLab calls failing with network errors, 429 or 5xx, and
+	// the first and longest wait between them
+	GitLabMaxRetries     int
+	GitLabRetryBaseDelay time.Duration
+	GitLabRetryMaxDelay  time.Duration
+
+	// Where new apps' initial versions are looked up: gitlab, github, gitea
+	// or bitbucket, asked in order until one knows a tag. The other forges'
+	// APIs are reached at their base URLs, with their tokens if set.
+	TagProviders     []string
+	GitHubBaseURL    string
+	GitHubToken      string
+	GiteaBaseURL     string
+	GiteaToken       string
+	BitbucketBaseURL string
+	BitbucketToken   string
+
+	// Projects looked up with one tag provider only, by project ID; from
+	// TAG_PROVIDER_ROUTES and BITBUCKET_REPOS
+	TagProviderRoutes map[string]TagProviderRoute
+
+	// TLS for the HTTP server, which serves HTTPS when TLSCertFile and
+	// TLSKeyFile are set. With TLSClientCAFile, client certificates are
+	// verified against its CAs; TLSClientAuth "require" refuses clients
+	// without one, "optional" only verifies those sent.
+	TLSCertFile     string
+	TLSKeyFile      string
+	TLSClientCAFile string
+	TLSClientAuth   string
+
+	// HealthPort serves /health and /metrics over plain HTTP on a second
+	// listener, so probes need no client certificate; empty disables it
+	HealthPort string
+
+	// Bearer token for administrative endpoints; empty disables them
+	AdminToken string
+
+	// API keys by key, naming their holders; from API_KEYS and the file at
+	// API_KEYS_FILE. When any are set, writes require one, and so do reads
+	// with APIKeysRequireReads
+	APIKeys             map[string]string
+	APIKeysRequireReads bool
+
+	// Roles of API key holders and OIDC subjects on projects; from
+	// RBAC_GRANTS and the file at RBAC_GRANTS_FILE. Empty disables role
+	// checks
+	RBACGrants []RoleGrant
+
+	// OIDC ID token authentication; disabled when no issuer is configured.
+	// Tokens must be meant for one of OIDCAudiences; the JWKS URL is
+	// discovered from the issuer unless set
+	OIDCIssuerURL    string
+	OIDCAudiences    []string
+	OIDCJWKSURL      string
+	OIDCJWKSRefresh  time.Duration
+	OIDCSubjectClaim string
+	OIDCClaims       []string
+
+	// Per-client rate limits: requests, and writes on top, allowed per
+	// RateLimitWindow in a burst, refilling continuously. Clients are keyed
+	// by API key or OIDC subject, otherwise by IP; 0 disables a limit.
+	RateLimitRequests      int
+	RateLimitWriteRequests int
+	RateLimitWindow        time.Duration
+
+	// TrustedProxies are the IPs and CIDRs whose X-Forwarded-For and
+	// X-Real-IP headers give a client's IP. None are trusted by default, so
+	// clients can't pick their own rate limit bucket.
+	TrustedProxies []string
+
+	// Request bodies are capped at MaxRequestBodyBytes, and at
+	// MaxBulkRequestBodyBytes for whole versions file and state uploads.
+	// StrictJSON rejects JSON bodies with unknown fields.
+	MaxRequestBodyBytes     int
+	MaxBulkRequestBodyBytes int
+	StrictJSON              bool
+
+	// External authorization through an OPA server; disabled when no URL is
+	// configured
+	OPAURL        string
+	OPAPolicyPath string
+	OPATimeout    time.Duration
+	OPAFailOpen   bool
+
+	// Quotas and usage reporting
+	QuotaMaxAppsPerProject    int
+	QuotaMaxIncrementsPerHour int
+	QuotaWarnThreshold        float64
+	AlertWebhookURL           string
+	UsageWindows              string
+
+	// How long increment idempotency keys are remembered
+	IdempotencyTTL time.Duration
+
+	// GitLab project discovery; disabled when no groups are configured
+	DiscoveryGroups   []string
+	DiscoveryInterval time.Duration
+	DiscoveryRegister bool
+
+	// Comma-separated normalization rules applied to incoming versions
+	VersionNormalization string
+
+	// Increment hooks
+	PreIncrementHookURLs  []string
+	PostIncrementHookURLs []string
+	HookTimeout           time.Duration
+	HookFailOpen          bool
+
+	// How long issued dev versions are tracked; 0 disables tracking
+	DevVersionRetention time.Duration
+
+	// How long an app's parsed current version is reused for dev versions;
+	// 0 disables the cache
+	DevVersionCacheTTL time.Duration
+
+	// Default dev version template, e.g. {next}-SNAPSHOT; empty keeps
+	// {current}-{branch}.{sha}
+	DevVersionTemplate string
+
+	// Version {current} stands for in dev templates: current or next
+	DevVersionBase string
+
+	// App ID scheme: project-app, path or uuid
+	AppIDScheme string
+
+	// GitLab project paths resolved to numeric project IDs without asking
+	// GitLab, by path
+	ProjectPaths map[string]string
+
+	// Require apps to be registered via POST /apps instead of being created
+	// on first read
+	RequireAppRegistration bool
+
+	// Freshness SLO: share of writes pushed to Git within the target
+	FreshnessTarget    time.Duration
+	FreshnessObjective float64
+
+	// Background stale app check: apps not updated for StaleAppDays are
+	// flagged or archived every StaleCheckInterval; 0 days disables it
+	StaleAppDays       int
+	StaleAppAction     string
+	StaleCheckInterval time.Duration
+
+	// Read-only follower mode: mutations are proxied to PrimaryURL and Redis
+	// is rebuilt from Git every FollowerSyncInterval (0 disables syncing)
+	PrimaryURL           string
+	FollowerSyncInterval time.Duration
+
+	// Storage failover: writes go to the journal at FailoverJournalPath
+	// once Git has been unhealthy for FailoverThreshold, checked every
+	// FailoverCheckInterval; an empty path disables failover
+	FailoverJournalPath   string
+	FailoverThreshold     time.Duration
+	FailoverCheckInterval time.Duration
+
+	// Circuit breakers around the Git remote, GitLab and Redis open after
+	// CircuitBreakerThreshold consecutive failures (0 disables them) and
+	// probe the dependency again after CircuitBreakerCooldown
+	CircuitBreakerThreshold int
+	CircuitBreakerCooldown  time.Duration
+
+	// Verify every read served from Redis against Git in the background
+	CanaryReads bool
+
+	// Air-gapped mode: GitLab lookups and webhook notifications are queued
+	// to the outbox at AirGapOutboxPath until an admin flushes it
+	AirGapped        bool
+	AirGapOutboxPath string
+
+	// Per-endpoint SLIs: default availability and latency objectives,
+	// per-endpoint overrides ("METHOD /route=availability,latency;...") and
+	// the rolling window they cover. Once an endpoint has less than
+	// SLOConservativeBudget of an error budget left, requests beyond
+	// SLOShedConcurrency are shed and write-through falls back to
+	// write-back; 0 disables conservative mode.
+	SLOAvailability       float64
+	SLOLatency            time.Duration
+	SLOLatencyObjective   float64
+	SLOEndpoints          string
+	SLOWindow             time.Duration
+	SLOConservativeBudget float64
+	SLOShedConcurrency    int
+
+	// HTTP response cache; disabled when the TTL is zero
+	ResponseCacheTTL        time.Duration
+	ResponseCacheMaxEntries int
+	CachePurgeWebhookURL    string
+
+	// Durable storage backends, primary first; writes are mirrored to the
+	// rest. Each is "git", "postgres", "s3", "etcd" or "memory". Also read
+	// from STORAGE_BACKEND.
+	StorageBackends []string
+	// Versions file the memory backend is loaded from and snapshotted to;
+	// empty keeps it in memory only
+	MemorySnapshotPath string
+	// PostgreSQL connection string for the postgres backend
+	PostgresURL string
+	// S3-compatible object storage for the s3 backend; the endpoint defaults
+	// to AWS S3 in the region
+	S3Endpoint        string
+	S3Region          string
+	S3Bucket          string
+	S3Prefix          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+	S3PathStyle       bool
+	// etcd cluster for the etcd backend; keys are written under EtcdPrefix
+	EtcdEndpoints []string
+	EtcdPrefix    string
+	EtcdUsername  string
+	EtcdPassword  string
 }
 
 func Load() (*Config, error) {
@@ -28,22 +338,782 @@ func Load() (*Config, error) {
 		GitLabBaseURL:     getEnv("GITLAB_BASE_URL", "https://gitlab.com/api/v4"),
 		GitLabAccessToken: getEnv("GITLAB_ACCESS_TOKEN", ""),
 		LogLevel:          getEnv("LOG_LEVEL", "info"),
+
+		GitAuthMethod:               getEnv("GIT_AUTH_METHOD", "token"),
+		GitSSHUser:                  getEnv("GIT_SSH_USER", "git"),
+		GitSSHKey:                   getEnv("GIT_SSH_KEY", ""),
+		GitSSHKeyPath:               getEnv("GIT_SSH_KEY_PATH", ""),
+		GitSSHKeyPassphrase:         getEnv("GIT_SSH_KEY_PASSPHRASE", ""),
+		GitSSHKnownHosts:            getEnv("GIT_SSH_KNOWN_HOSTS", ""),
+		GitSSHInsecureIgnoreHostKey: getEnvBool("GIT_SSH_INSECURE_IGNORE_HOST_KEY", false),
+
+		GitCloneDepth:        getEnvInt("GIT_CLONE_DEPTH", 0),
+		GitSparseCheckout:    getEnvBool("GIT_SPARSE_CHECKOUT", false),
+		GitReshallowInterval: getEnvDuration("GIT_RESHALLOW_INTERVAL", 24*time.Hour),
+
+		GitMaintenanceInterval: getEnvDuration("GIT_MAINTENANCE_INTERVAL", time.Hour),
+		GitMaxCloneSizeMB:      getEnvInt("GIT_MAX_CLONE_SIZE_MB", 1024),
+
+		GitMirrorURL: getEnv("GIT_MIRROR_URL", ""),
+
+		GitTagIncrements: getEnvBool("GIT_TAG_INCREMENTS", false),
+
+		GitBranchPerProject:    getEnvBool("GIT_BRANCH_PER_PROJECT", false),
+		GitProjectBranchPrefix: getEnv("GIT_PROJECT_BRANCH_PREFIX", "project/"),
+
+		RedisCodec: getEnv("REDIS_CODEC", "json"),
+
+		RedisUsername:              getEnv("REDIS_USERNAME", ""),
+		RedisPassword:              getEnv("REDIS_PASSWORD", ""),
+		RedisTLS:                   getEnvBool("REDIS_TLS", false),
+		RedisTLSCAFile:             getEnv("REDIS_TLS_CA_FILE", ""),
+		RedisTLSCertFile:           getEnv("REDIS_TLS_CERT_FILE", ""),
+		RedisTLSKeyFile:            getEnv("REDIS_TLS_KEY_FILE", ""),
+		RedisTLSInsecureSkipVerify: getEnvBool("REDIS_TLS_INSECURE_SKIP_VERIFY", false),
+
+		RedisPoolSize:     getEnvInt("REDIS_POOL_SIZE", 0),
+		RedisMinIdleConns: getEnvInt("REDIS_MIN_IDLE_CONNS", 0),
+		RedisDialTimeout:  getEnvDuration("REDIS_DIAL_TIMEOUT", 0),
+		RedisReadTimeout:  getEnvDuration("REDIS_READ_TIMEOUT", 0),
+		RedisWriteTimeout: getEnvDuration("REDIS_WRITE_TIMEOUT", 0),
+
+		WritePolicy:          getEnv("WRITE_POLICY", "write-back"),
+		CacheRebuildInterval: getEnvDuration("CACHE_REBUILD_INTERVAL", 0),
+
+		TracingEnabled: getEnvBool("TRACING_ENABLED", false),
+		UIEnabled:      getEnvBool("UI_ENABLED", true),
+
+		GitLabDelegatedTokens: getEnvBool("GITLAB_DELEGATED_TOKENS", false),
+		GitLabCreateReleases:  getEnvBool("GITLAB_CREATE_RELEASES", false),
+		GitLabReleaseTag:      getEnv("GITLAB_RELEASE_TAG", "v{version}"),
+		GitLabLenientTags:     getEnvBool("GITLAB_LENIENT_TAGS", false),
+		GitLabMaxRetries:      getEnvInt("GITLAB_MAX_RETRIES", 3),
+		GitLabRetryBaseDelay:  getEnvDuration("GITLAB_RETRY_BASE_DELAY", 500*time.Millisecond),
+		GitLabRetryMaxDelay:   getEnvDuration("GITLAB_RETRY_MAX_DELAY", 30*time.Second),
+		AdminToken:            getEnv("ADMIN_TOKEN", ""),
+
+		TagProviders:  getEnvList("TAG_PROVIDER"),
+		GitHubBaseURL: getEnv("GITHUB_BASE_URL", "https://api.github.com"),
+		GitHubToken:   getEnv("GITHUB_TOKEN", ""),
+		GiteaBaseURL:  getEnv("GITEA_BASE_URL", ""),
+		GiteaToken:    getEnv("GITEA_TOKEN", ""),
+
+		BitbucketBaseURL: getEnv("BITBUCKET_BASE_URL", ""),
+		BitbucketToken:   getEnv("BITBUCKET_TOKEN", ""),
+
+		TLSCertFile:     getEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:      getEnv("TLS_KEY_FILE", ""),
+		TLSClientCAFile: getEnv("TLS_CLIENT_CA_FILE", ""),
+		TLSClientAuth:   getEnv("TLS_CLIENT_AUTH", "require"),
+		HealthPort:      getEnv("HEALTH_PORT", ""),
+
+		APIKeysRequireReads: getEnvBool("API_KEYS_REQUIRE_READS", false),
+
+		OIDCIssuerURL:    getEnv("OIDC_ISSUER_URL", ""),
+		OIDCAudiences:    getEnvList("OIDC_AUDIENCE"),
+		OIDCJWKSURL:      getEnv("OIDC_JWKS_URL", ""),
+		OIDCJWKSRefresh:  getEnvDuration("OIDC_JWKS_REFRESH", time.Hour),
+		OIDCSubjectClaim: getEnv("OIDC_SUBJECT_CLAIM", "sub"),
+		OIDCClaims:       getEnvList("OIDC_CLAIMS"),
+
+		RateLimitRequests:      getEnvInt("RATE_LIMIT_REQUESTS", 0),
+		RateLimitWriteRequests: getEnvInt("RATE_LIMIT_WRITE_REQUESTS", 0),
+		RateLimitWindow:        getEnvDuration("RATE_LIMIT_WINDOW", time.Minute),
+		TrustedProxies:         getEnvList("TRUSTED_PROXIES"),
+
+		MaxRequestBodyBytes:     getEnvInt("MAX_REQUEST_BODY_BYTES", 1<<20),
+		MaxBulkRequestBodyBytes: getEnvInt("MAX_BULK_REQUEST_BODY_BYTES", 64<<20),
+		StrictJSON:              getEnvBool("STRICT_JSON", true),
+
+		OPAURL:        getEnv("OPA_URL", ""),
+		OPAPolicyPath: getEnv("OPA_POLICY_PATH", "version_service/authz"),
+		OPATimeout:    getEnvDuration("OPA_TIMEOUT", 2*time.Second),
+		OPAFailOpen:   getEnvBool("OPA_FAIL_OPEN", false),
+
+		QuotaMaxAppsPerProject:    getEnvInt("QUOTA_MAX_APPS_PER_PROJECT", 0),
+		QuotaMaxIncrementsPerHour: getEnvInt("QUOTA_MAX_INCREMENTS_PER_HOUR", 0),
+		QuotaWarnThreshold:        getEnvFloat("QUOTA_WARN_THRESHOLD", 0.8),
+		AlertWebhookURL:           getEnv("ALERT_WEBHOOK_URL", ""),
+		UsageWindows:              getEnv("USAGE_WINDOWS", "1h,24h,7d"),
+
+		IdempotencyTTL: getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+
+		DiscoveryGroups:   getEnvList("GITLAB_DISCOVERY_GROUPS"),
+		DiscoveryInterval: getEnvDuration("GITLAB_DISCOVERY_INTERVAL", 6*time.Hour),
+		DiscoveryRegister: getEnvBool("GITLAB_DISCOVERY_REGISTER", true),
+
+		VersionNormalization: getEnv("VERSION_NORMALIZATION", "strip_prefix,strip_leading_zeros,lowercase_prerelease"),
+
+		PreIncrementHookURLs:  getEnvList("PRE_INCREMENT_HOOK_URLS"),
+		PostIncrementHookURLs: getEnvList("POST_INCREMENT_HOOK_URLS"),
+		HookTimeout:           getEnvDuration("HOOK_TIMEOUT", 5*time.Second),
+		HookFailOpen:          getEnvBool("HOOK_FAIL_OPEN", false),
+
+		DevVersionRetention: getEnvDuration("DEV_VERSION_RETENTION", 30*24*time.Hour),
+		DevVersionCacheTTL:  getEnvDuration("DEV_VERSION_CACHE_TTL", 5*time.Second),
+		DevVersionTemplate:  getEnv("DEV_VERSION_TEMPLATE", ""),
+		DevVersionBase:      getEnv("DEV_VERSION_BASE", "current"),
+
+		AppIDScheme:            getEnv("APP_ID_SCHEME", "project-app"),
+		RequireAppRegistration: getEnvBool("REQUIRE_APP_REGISTRATION", false),
+
+		FreshnessTarget:    getEnvDuration("FRESHNESS_TARGET", time.Minute),
+		FreshnessObjective: getEnvFloat("FRESHNESS_OBJECTIVE", 0.99),
+
+		StaleAppDays:       getEnvInt("STALE_APP_DAYS", 0),
+		StaleAppAction:     getEnv("STALE_APP_ACTION", "flag"),
+		StaleCheckInterval: getEnvDuration("STALE_CHECK_INTERVAL", 24*time.Hour),
+
+		PrimaryURL:           getEnv("PRIMARY_URL", ""),
+		FollowerSyncInterval: getEnvDuration("FOLLOWER_SYNC_INTERVAL", 30*time.Second),
+
+		FailoverJournalPath:   getEnv("FAILOVER_JOURNAL_PATH", ""),
+		FailoverThreshold:     getEnvDuration("FAILOVER_THRESHOLD", 2*time.Minute),
+		FailoverCheckInterval: getEnvDuration("FAILOVER_CHECK_INTERVAL", 15*time.Second),
+
+		CircuitBreakerThreshold: getEnvInt("CIRCUIT_BREAKER_THRESHOLD", 5),
+		CircuitBreakerCooldown:  getEnvDuration("CIRCUIT_BREAKER_COOLDOWN", 30*time.Second),
+
+		CanaryReads: getEnvBool("CANARY_READS", false),
+
+		AirGapped:        getEnvBool("AIR_GAPPED", false),
+		AirGapOutboxPath: getEnv("AIR_GAP_OUTBOX_PATH", ""),
+
+		SLOAvailability:       getEnvFloat("SLO_AVAILABILITY", 0.999),
+		SLOLatency:            getEnvDuration("SLO_LATENCY", 500*time.Millisecond),
+		SLOLatencyObjective:   getEnvFloat("SLO_LATENCY_OBJECTIVE", 0.99),
+		SLOEndpoints:          getEnv("SLO_ENDPOINTS", ""),
+		SLOWindow:             getEnvDuration("SLO_WINDOW", time.Hour),
+		SLOConservativeBudget: getEnvFloat("SLO_CONSERVATIVE_BUDGET", 0),
+		SLOShedConcurrency:    getEnvInt("SLO_SHED_CONCURRENCY", 32),
+
+		ResponseCacheTTL:        getEnvDuration("RESPONSE_CACHE_TTL", 0),
+		ResponseCacheMaxEntries: getEnvInt("RESPONSE_CACHE_MAX_ENTRIES", 10000),
+		CachePurgeWebhookURL:    getEnv("CACHE_PURGE_WEBHOOK_URL", ""),
+
+		StorageBackends: getEnvList("STORAGE_BACKENDS"),
+		PostgresURL:     getEnv("POSTGRES_URL", ""),
+
+		MemorySnapshotPath: getEnv("MEMORY_SNAPSHOT_PATH", ""),
+
+		S3Endpoint:        getEnv("S3_ENDPOINT", ""),
+		S3Region:          getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:          getEnv("S3_BUCKET", ""),
+		S3Prefix:          getEnv("S3_PREFIX", ""),
+		S3AccessKeyID:     getEnv("S3_ACCESS_KEY_ID", ""),
+		S3SecretAccessKey: getEnv("S3_SECRET_ACCESS_KEY", ""),
+		S3PathStyle:       getEnvBool("S3_PATH_STYLE", false),
+
+		EtcdEndpoints: getEnvList("ETCD_ENDPOINTS"),
+		EtcdPrefix:    getEnv("ETCD_PREFIX", "/version-service/"),
+		EtcdUsername:  getEnv("ETCD_USERNAME", ""),
+		EtcdPassword:  getEnv("ETCD_PASSWORD", ""),
+	}
+
+	// STORAGE_BACKEND, as in other deployments' environments, is an alias
+	if alias := getEnvList("STORAGE_BACKEND"); len(alias) > 0 {
+		if len(cfg.StorageBackends) > 0 {
+			return nil, fmt.Errorf("STORAGE_BACKEND is an alias of STORAGE_BACKENDS, set only one")
+		}
+		cfg.StorageBackends = alias
+	}
+	if len(cfg.StorageBackends) == 0 {
+		cfg.StorageBackends = []string{"git"}
+	}
+	seen := make(map[string]bool)
+	for _, backend := range cfg.StorageBackends {
+		if backend != "git" && backend != "postgres" && backend != "s3" && backend != "etcd" && backend != "memory" {
+			return nil, fmt.Errorf("STORAGE_BACKENDS must list git, postgres, s3, etcd or memory, got %q", backend)
+		}
+		if seen[backend] {
+			return nil, fmt.Errorf("STORAGE_BACKENDS lists %s twice", backend)
+		}
+		seen[backend] = true
+	}
+
+	if seen["postgres"] && cfg.PostgresURL == "" {
+		return nil, fmt.Errorf("POSTGRES_URL is required for the postgres storage backend")
 	}
 
-	if cfg.GitRepoURL == "" {
+	if seen["s3"] {
+		if cfg.S3Bucket == "" || cfg.S3AccessKeyID == "" || cfg.S3SecretAccessKey == "" {
+			return nil, fmt.Errorf("S3_BUCKET, S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY are required for the s3 storage backend")
+		}
+		if cfg.S3Endpoint == "" {
+			cfg.S3Endpoint = "https://s3." + cfg.S3Region + ".amazonaws.com"
+		}
+		if u, err := url.Parse(cfg.S3Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("S3_ENDPOINT must be an http(s) URL")
+		}
+	}
+
+	if seen["etcd"] {
+		if len(cfg.EtcdEndpoints) == 0 {
+			return nil, fmt.Errorf("ETCD_ENDPOINTS is required for the etcd storage backend")
+		}
+		for _, endpoint := range cfg.EtcdEndpoints {
+			if u, err := url.Parse(endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return nil, fmt.Errorf("ETCD_ENDPOINTS must list http(s) URLs, got %q", endpoint)
+			}
+		}
+		if cfg.EtcdUsername != "" && cfg.EtcdPassword == "" {
+			return nil, fmt.Errorf("ETCD_PASSWORD is required with ETCD_USERNAME")
+		}
+	}
+
+	if seen["git"] && cfg.GitRepoURL == "" {
 		return nil, fmt.Errorf("GIT_REPO_URL is required")
 	}
 
-	if cfg.GitToken == "" {
+	if cfg.GitAuthMethod != "token" && cfg.GitAuthMethod != "ssh" {
+		return nil, fmt.Errorf("GIT_AUTH_METHOD must be token or ssh")
+	}
+
+	if seen["git"] && cfg.GitAuthMethod == "token" && cfg.GitToken == "" {
 		return nil, fmt.Errorf("GIT_TOKEN is required")
 	}
 
+	if seen["git"] && cfg.GitAuthMethod == "ssh" {
+		if cfg.GitSSHKey == "" && cfg.GitSSHKeyPath == "" {
+			return nil, fmt.Errorf("GIT_SSH_KEY or GIT_SSH_KEY_PATH is required with GIT_AUTH_METHOD=ssh")
+		}
+		if strings.HasPrefix(cfg.GitRepoURL, "http://") || strings.HasPrefix(cfg.GitRepoURL, "https://") {
+			return nil, fmt.Errorf("GIT_REPO_URL must be an SSH URL with GIT_AUTH_METHOD=ssh, got %q", cfg.GitRepoURL)
+		}
+	}
+
+	routes, err := parseRepoRoutes(getEnvList("GIT_REPO_ROUTES"))
+	if err != nil {
+		return nil, err
+	}
+	cfg.GitRepoRoutes = routes
+
+	paths, err := parseProjectPaths(getEnvList("PROJECT_PATHS"))
+	if err != nil {
+		return nil, err
+	}
+	cfg.ProjectPaths = paths
+
+	apiKeys, err := parseAPIKeys(getEnvList("API_KEYS"), getEnv("API_KEYS_FILE", ""))
+	if err != nil {
+		return nil, err
+	}
+	cfg.APIKeys = apiKeys
+
+	grants, err := parseRoleGrants(getEnvList("RBAC_GRANTS"), getEnv("RBAC_GRANTS_FILE", ""))
+	if err != nil {
+		return nil, err
+	}
+	cfg.RBACGrants = grants
+	for projectID, repoURL := range routes {
+		if cfg.GitAuthMethod == "ssh" && (strings.HasPrefix(repoURL, "http://") || strings.HasPrefix(repoURL, "https://")) {
+			return nil, fmt.Errorf("GIT_REPO_ROUTES must route to SSH URLs with GIT_AUTH_METHOD=ssh, got %q for project %s", repoURL, projectID)
+		}
+	}
+
+	if (cfg.RedisTLSCertFile == "") != (cfg.RedisTLSKeyFile == "") {
+		return nil, fmt.Errorf("REDIS_TLS_CERT_FILE and REDIS_TLS_KEY_FILE must be set together")
+	}
+
+	if cfg.RedisPoolSize < 0 || cfg.RedisMinIdleConns < 0 {
+		return nil, fmt.Errorf("REDIS_POOL_SIZE and REDIS_MIN_IDLE_CONNS must not be negative")
+	}
+	if cfg.RedisPoolSize > 0 && cfg.RedisMinIdleConns > cfg.RedisPoolSize {
+		return nil, fmt.Errorf("REDIS_MIN_IDLE_CONNS must not exceed REDIS_POOL_SIZE")
+	}
+	if cfg.RedisDialTimeout < 0 || cfg.RedisReadTimeout < 0 || cfg.RedisWriteTimeout < 0 {
+		return nil, fmt.Errorf("REDIS_DIAL_TIMEOUT, REDIS_READ_TIMEOUT and REDIS_WRITE_TIMEOUT must not be negative")
+	}
+
+	if cfg.WritePolicy != "write-back" && cfg.WritePolicy != "write-through" {
+		return nil, fmt.Errorf("WRITE_POLICY must be write-back or write-through")
+	}
+
+	if cfg.CacheRebuildInterval < 0 {
+		return nil, fmt.Errorf("CACHE_REBUILD_INTERVAL must not be negative")
+	}
+
+	if cfg.GitLabMaxRetries < 0 {
+		return nil, fmt.Errorf("GITLAB_MAX_RETRIES must not be negative")
+	}
+	if cfg.GitLabRetryBaseDelay <= 0 || cfg.GitLabRetryMaxDelay < cfg.GitLabRetryBaseDelay {
+		return nil, fmt.Errorf("GITLAB_RETRY_BASE_DELAY must be positive and at most GITLAB_RETRY_MAX_DELAY")
+	}
+	if cfg.GitLabCreateReleases && !cfg.GitLabDelegatedTokens {
+		return nil, fmt.Errorf("GITLAB_CREATE_RELEASES requires GITLAB_DELEGATED_TOKENS")
+	}
+	if cfg.GitLabCreateReleases && !strings.Contains(cfg.GitLabReleaseTag, "{version}") {
+		return nil, fmt.Errorf("GITLAB_RELEASE_TAG must contain {version}")
+	}
+
+	tagRoutes, err := parseTagProviderRoutes(getEnvList("TAG_PROVIDER_ROUTES"), getEnvList("BITBUCKET_REPOS"))
+	if err != nil {
+		return nil, err
+	}
+	cfg.TagProviderRoutes = tagRoutes
+	if len(cfg.TagProviders) == 0 {
+		cfg.TagProviders = []string{"gitlab"}
+	}
+	for i, name := range cfg.TagProviders {
+		cfg.TagProviders[i] = strings.ToLower(name)
+	}
+	if err := cfg.validateTagProviders(); err != nil {
+		return nil, err
+	}
+
+	if cfg.QuotaWarnThreshold <= 0 || cfg.QuotaWarnThreshold > 1 {
+		return nil, fmt.Errorf("QUOTA_WARN_THRESHOLD must be between 0 and 1")
+	}
+
+	if cfg.FreshnessTarget <= 0 {
+		return nil, fmt.Errorf("FRESHNESS_TARGET must be positive")
+	}
+
+	if cfg.FreshnessObjective <= 0 || cfg.FreshnessObjective >= 1 {
+		return nil, fmt.Errorf("FRESHNESS_OBJECTIVE must be between 0 and 1 (exclusive)")
+	}
+
+	if cfg.StaleAppDays < 0 {
+		return nil, fmt.Errorf("STALE_APP_DAYS must not be negative")
+	}
+
+	if cfg.StaleAppAction != "flag" && cfg.StaleAppAction != "archive" {
+		return nil, fmt.Errorf("STALE_APP_ACTION must be flag or archive")
+	}
+
+	if cfg.PrimaryURL != "" {
+		u, err := url.Parse(cfg.PrimaryURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("PRIMARY_URL must be an http(s) URL")
+		}
+	}
+
+	if cfg.OPAURL != "" {
+		u, err := url.Parse(cfg.OPAURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("OPA_URL must be an http(s) URL")
+		}
+	}
+
+	if cfg.OIDCIssuerURL != "" {
+		u, err := url.Parse(cfg.OIDCIssuerURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("OIDC_ISSUER_URL must be an http(s) URL")
+		}
+		if len(cfg.OIDCAudiences) == 0 {
+			return nil, fmt.Errorf("OIDC_AUDIENCE is required with OIDC_ISSUER_URL")
+		}
+	}
+
+	if cfg.OIDCJWKSURL != "" {
+		u, err := url.Parse(cfg.OIDCJWKSURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("OIDC_JWKS_URL must be an http(s) URL")
+		}
+	}
+
+	if cfg.OIDCJWKSRefresh <= 0 {
+		return nil, fmt.Errorf("OIDC_JWKS_REFRESH must be positive")
+	}
+
+	if cfg.OIDCSubjectClaim == "" {
+		return nil, fmt.Errorf("OIDC_SUBJECT_CLAIM must not be empty")
+	}
+
+	if len(cfg.OIDCClaims) == 0 {
+		cfg.OIDCClaims = []string{"project_path", "ref", "ref_type", "pipeline_id", "job_id", "user_login"}
+	}
+
+	if len(cfg.RBACGrants) > 0 && len(cfg.APIKeys) == 0 && cfg.OIDCIssuerURL == "" {
+		return nil, fmt.Errorf("RBAC_GRANTS needs API_KEYS or OIDC_ISSUER_URL to authenticate callers")
+	}
+
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return nil, fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+
+	if cfg.TLSClientCAFile != "" && cfg.TLSCertFile == "" {
+		return nil, fmt.Errorf("TLS_CLIENT_CA_FILE needs TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+
+	if cfg.TLSClientAuth != "require" && cfg.TLSClientAuth != "optional" {
+		return nil, fmt.Errorf("TLS_CLIENT_AUTH must be require or optional")
+	}
+
+	if cfg.HealthPort != "" && cfg.HealthPort == cfg.Port {
+		return nil, fmt.Errorf("HEALTH_PORT must differ from PORT")
+	}
+
+	if cfg.RateLimitRequests < 0 || cfg.RateLimitWriteRequests < 0 {
+		return nil, fmt.Errorf("RATE_LIMIT_REQUESTS and RATE_LIMIT_WRITE_REQUESTS must not be negative")
+	}
+
+	if cfg.RateLimitWindow <= 0 {
+		return nil, fmt.Errorf("RATE_LIMIT_WINDOW must be positive")
+	}
+
+	for _, proxy := range cfg.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			return nil, fmt.Errorf("TRUSTED_PROXIES entry %q is not an IP or CIDR", proxy)
+		}
+	}
+
+	if cfg.MaxRequestBodyBytes <= 0 || cfg.MaxBulkRequestBodyBytes <= 0 {
+		return nil, fmt.Errorf("MAX_REQUEST_BODY_BYTES and MAX_BULK_REQUEST_BODY_BYTES must be positive")
+	}
+
+	if cfg.OPATimeout <= 0 {
+		return nil, fmt.Errorf("OPA_TIMEOUT must be positive")
+	}
+
+	if cfg.DevVersionCacheTTL < 0 {
+		return nil, fmt.Errorf("DEV_VERSION_CACHE_TTL must not be negative")
+	}
+
+	if cfg.DevVersionBase != "current" && cfg.DevVersionBase != "next" {
+		return nil, fmt.Errorf("DEV_VERSION_BASE must be current or next")
+	}
+
+	if cfg.FollowerSyncInterval < 0 {
+		return nil, fmt.Errorf("FOLLOWER_SYNC_INTERVAL must not be negative")
+	}
+
+	if cfg.FailoverThreshold < 0 {
+		return nil, fmt.Errorf("FAILOVER_THRESHOLD must not be negative")
+	}
+
+	if cfg.FailoverCheckInterval <= 0 {
+		return nil, fmt.Errorf("FAILOVER_CHECK_INTERVAL must be positive")
+	}
+
+	if cfg.CircuitBreakerThreshold < 0 {
+		return nil, fmt.Errorf("CIRCUIT_BREAKER_THRESHOLD must not be negative")
+	}
+
+	if cfg.CircuitBreakerThreshold > 0 && cfg.CircuitBreakerCooldown <= 0 {
+		return nil, fmt.Errorf("CIRCUIT_BREAKER_COOLDOWN must be positive")
+	}
+
+	if cfg.GitCloneDepth < 0 {
+		return nil, fmt.Errorf("GIT_CLONE_DEPTH must not be negative")
+	}
+
+	if cfg.GitReshallowInterval < 0 {
+		return nil, fmt.Errorf("GIT_RESHALLOW_INTERVAL must not be negative")
+	}
+
+	if cfg.GitMaintenanceInterval < 0 {
+		return nil, fmt.Errorf("GIT_MAINTENANCE_INTERVAL must not be negative")
+	}
+
+	if cfg.GitMaxCloneSizeMB < 0 {
+		return nil, fmt.Errorf("GIT_MAX_CLONE_SIZE_MB must not be negative")
+	}
+
+	if cfg.GitMirrorURL != "" && cfg.GitMirrorURL == cfg.GitRepoURL {
+		return nil, fmt.Errorf("GIT_MIRROR_URL must differ from GIT_REPO_URL")
+	}
+
+	if cfg.GitBranchPerProject && cfg.GitProjectBranchPrefix == "" {
+		return nil, fmt.Errorf("GIT_PROJECT_BRANCH_PREFIX is required when GIT_BRANCH_PER_PROJECT is set")
+	}
+
+	if cfg.AirGapped {
+		if cfg.AirGapOutboxPath == "" {
+			return nil, fmt.Errorf("AIR_GAP_OUTBOX_PATH is required when AIR_GAPPED is set")
+		}
+		// Increments wait for the answer of pre-increment hooks, so they
+		// cannot be deferred
+		if len(cfg.PreIncrementHookURLs) > 0 {
+			return nil, fmt.Errorf("PRE_INCREMENT_HOOK_URLS cannot be used when AIR_GAPPED is set")
+		}
+	}
+
+	if cfg.SLOAvailability <= 0 || cfg.SLOAvailability >= 1 {
+		return nil, fmt.Errorf("SLO_AVAILABILITY must be between 0 and 1 (exclusive)")
+	}
+
+	if cfg.SLOLatency <= 0 {
+		return nil, fmt.Errorf("SLO_LATENCY must be positive")
+	}
+
+	if cfg.SLOLatencyObjective <= 0 || cfg.SLOLatencyObjective >= 1 {
+		return nil, fmt.Errorf("SLO_LATENCY_OBJECTIVE must be between 0 and 1 (exclusive)")
+	}
+
+	if cfg.SLOWindow < time.Minute {
+		return nil, fmt.Errorf("SLO_WINDOW must be at least 1m")
+	}
+
+	if cfg.SLOConservativeBudget < 0 || cfg.SLOConservativeBudget >= 1 {
+		return nil, fmt.Errorf("SLO_CONSERVATIVE_BUDGET must be at least 0 and below 1")
+	}
+
+	if cfg.SLOShedConcurrency <= 0 {
+		return nil, fmt.Errorf("SLO_SHED_CONCURRENCY must be positive")
+	}
+
 	return cfg, nil
 }
 
+// Follower reports whether the service runs as a read-only follower
+func (c *Config) Follower() bool {
+	return c.PrimaryURL != ""
+}
+
+// InMemory reports whether the memory backend is the primary store, in which
+// case the cache is kept in memory as well and Redis is not used
+func (c *Config) InMemory() bool {
+	return len(c.StorageBackends) > 0 && c.StorageBackends[0] == "memory"
+}
+
+// WriteThrough reports whether writes are persisted to durable storage
+// before being cached and answered
+func (c *Config) WriteThrough() bool {
+	return c.WritePolicy == "write-through"
+}
+
+// parseRepoRoutes reads project=repository-url entries into a map of
+// project IDs to repository URLs
+func parseRepoRoutes(entries []string) (map[string]string, error) {
+	routes := make(map[string]string)
+	for _, entry := range entries {
+		projectID, repoURL, ok := strings.Cut(entry, "=")
+		projectID, repoURL = strings.TrimSpace(projectID), strings.TrimSpace(repoURL)
+		if !ok || projectID == "" || repoURL == "" {
+			return nil, fmt.Errorf("GIT_REPO_ROUTES entries must be project-id=repository-url, got %q", entry)
+		}
+		if _, dup := routes[projectID]; dup {
+			return nil, fmt.Errorf("GIT_REPO_ROUTES routes project %s twice", projectID)
+		}
+		routes[projectID] = repoURL
+	}
+	return routes, nil
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// parseProjectPaths reads path=project-id entries into a map of GitLab
+// project paths to numeric project IDs
+func parseProjectPaths(entries []string) (map[string]string, error) {
+	paths := make(map[string]string)
+	for _, entry := range entries {
+		path, projectID, ok := strings.Cut(entry, "=")
+		path, projectID = strings.Trim(strings.TrimSpace(path), "/"), strings.TrimSpace(projectID)
+		if !ok || path == "" || projectID == "" {
+			return nil, fmt.Errorf("PROJECT_PATHS entries must be project-path=project-id, got %q", entry)
+		}
+		if _, err := strconv.Atoi(projectID); err != nil {
+			return nil, fmt.Errorf("PROJECT_PATHS must map %s to a numeric project ID, got %q", path, projectID)
+		}
+		if _, dup := paths[path]; dup {
+			return nil, fmt.Errorf("PROJECT_PATHS maps path %s twice", path)
+		}
+		paths[path] = projectID
+	}
+	return paths, nil
+}
+
+// parseAPIKeys reads name=key entries, from API_KEYS and one per line from
+// the file at path, into a map of keys to names. Blank lines and lines
+// starting with # are skipped in the file.
+func parseAPIKeys(entries []string, path string) (map[string]string, error) {
+	fileEntries, err := readEntryFile("API_KEYS_FILE", path)
+	if err != nil {
+		return nil, err
+	}
+	entries = append(entries, fileEntries...)
+
+	keys := make(map[string]string)
+	names := make(map[string]bool)
+	for _, entry := range entries {
+		name, key, ok := strings.Cut(entry, "=")
+		name, key = strings.TrimSpace(name), strings.TrimSpace(key)
+		if !ok || name == "" || key == "" {
+			return nil, fmt.Errorf("API_KEYS entries must be name=key, got an entry named %q", name)
+		}
+		if names[name] {
+			return nil, fmt.Errorf("API_KEYS names %s twice", name)
+		}
+		if _, dup := keys[key]; dup {
+			return nil, fmt.Errorf("API_KEYS gives %s the key of %s", name, keys[key])
+		}
+		names[name] = true
+		keys[key] = name
+	}
+	return keys, nil
+}
+
+// readEntryFile reads the lines of the file at path named by setting,
+// skipping blank lines and lines starting with #. An empty path reads none.
+func readEntryFile(setting, path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", setting, err)
+	}
+	var entries []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			entries = append(entries, line)
+		}
+	}
+	return entries, nil
+}
+
+// RoleGrant gives the callers matching Subject ("{identity type}:{subject}",
+// optionally ending in *) Role on ProjectID, or on every project for "*"
+type RoleGrant struct {
+	Subject   string
+	Role      string
+	ProjectID string
+}
+
+// parseRoleGrants reads subject=role@project-id entries, from RBAC_GRANTS
+// and one per line from the file at path
+func parseRoleGrants(entries []string, path string) ([]RoleGrant, error) {
+	fileEntries, err := readEntryFile("RBAC_GRANTS_FILE", path)
+	if err != nil {
+		return nil, err
+	}
+	entries = append(entries, fileEntries...)
+
+	var grants []RoleGrant
+	for _, entry := range entries {
+		i := strings.LastIndex(entry, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("RBAC_GRANTS entries must be subject=role@project-id, got %q", entry)
+		}
+		subject := strings.TrimSpace(entry[:i])
+		role, projectID, ok := strings.Cut(entry[i+1:], "@")
+		role, projectID = strings.ToLower(strings.TrimSpace(role)), strings.Trim(strings.TrimSpace(projectID), "/")
+		typ, _, _ := strings.Cut(subject, ":")
+		if !ok || projectID == "" || (typ != "api_key" && typ != "oidc") || !strings.Contains(subject, ":") {
+			return nil, fmt.Errorf("RBAC_GRANTS entries must be api_key:name=role@project-id or oidc:subject=role@project-id, got %q", entry)
+		}
+		if role != "read" && role != "write" && role != "admin" {
+			return nil, fmt.Errorf("RBAC_GRANTS role must be read, write or admin, got %q", role)
+		}
+		grants = append(grants, RoleGrant{Subject: subject, Role: role, ProjectID: projectID})
+	}
+	return grants, nil
+}
+
+// TagProviderRoute names the tag provider a project is looked up with and
+// its repository there; an empty repository keeps the project ID
+type TagProviderRoute struct {
+	Provider   string
+	Repository string
+}
+
+// parseTagProviderRoutes reads project-id=provider[:repository] entries and
+// BITBUCKET_REPOS' project-id=project-key/repo-slug entries into routes by
+// project ID
+func parseTagProviderRoutes(entries, bitbucketRepos []string) (map[string]TagProviderRoute, error) {
+	routes := make(map[string]TagProviderRoute)
+	for _, entry := range entries {
+		projectID, target, ok := strings.Cut(entry, "=")
+		provider, repository, _ := strings.Cut(strings.TrimSpace(target), ":")
+		projectID, provider = strings.TrimSpace(projectID), strings.ToLower(strings.TrimSpace(provider))
+		if !ok || projectID == "" || provider == "" {
+			return nil, fmt.Errorf("TAG_PROVIDER_ROUTES entries must be project-id=provider[:repository], got %q", entry)
+		}
+		if _, dup := routes[projectID]; dup {
+			return nil, fmt.Errorf("TAG_PROVIDER_ROUTES routes project %s twice", projectID)
+		}
+		routes[projectID] = TagProviderRoute{Provider: provider, Repository: strings.Trim(strings.TrimSpace(repository), "/")}
+	}
+
+	for _, entry := range bitbucketRepos {
+		projectID, repo, ok := strings.Cut(entry, "=")
+		projectID, repo = strings.TrimSpace(projectID), strings.Trim(strings.TrimSpace(repo), "/")
+		key, slug, _ := strings.Cut(repo, "/")
+		if !ok || projectID == "" || key == "" || slug == "" || strings.Contains(slug, "/") {
+			return nil, fmt.Errorf("BITBUCKET_REPOS entries must be project-id=project-key/repo-slug, got %q", entry)
+		}
+		if _, dup := routes[projectID]; dup {
+			return nil, fmt.Errorf("BITBUCKET_REPOS and TAG_PROVIDER_ROUTES route project %s twice", projectID)
+		}
+		routes[projectID] = TagProviderRoute{Provider: "bitbucket", Repository: repo}
+	}
+	return routes, nil
+}
+
+// validateTagProviders checks that every tag provider in the chain or a
+// route is known and has its base URL configured
+func (c *Config) validateTagProviders() error {
+	used := append([]string(nil), c.TagProviders...)
+	for _, route := range c.TagProviderRoutes {
+		used = append(used, route.Provider)
+	}
+
+	for _, name := range used {
+		switch name {
+		case "gitlab", "github":
+		case "gitea":
+			if c.GiteaBaseURL == "" {
+				return fmt.Errorf("GITEA_BASE_URL is required to look up tags with gitea")
+			}
+		case "bitbucket":
+			if c.BitbucketBaseURL == "" {
+				return fmt.Errorf("BITBUCKET_BASE_URL is required to look up tags with bitbucket")
+			}
+		default:
+			return fmt.Errorf("unknown tag provider %q: want gitlab, github, gitea or bitbucket", name)
+		}
+	}
+	return nil
+}
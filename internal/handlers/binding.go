@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// validationFailure is a field that failed a binding tag, as reported by the
+// validator behind binding.Validator
+type validationFailure interface {
+	Tag() string
+	Param() string
+	StructNamespace() string
+}
+
+// errTrailingData is reported for bodies with more than one JSON value
+var errTrailingData = errors.New("unexpected data after the JSON value")
+
+// bindJSON decodes the request body into obj and validates its binding
+// tags. With strict JSON, unknown fields and data after the value are
+// rejected. Failures are answered with 400 naming the offending fields, or
+// 413 for bodies over the size limit.
+func (h *Handler) bindJSON(c *gin.Context, obj any) bool {
+	err := h.decodeJSON(c.Request, obj)
+	if err == nil {
+		err = binding.Validator.ValidateStruct(obj)
+	}
+	if err == nil {
+		return true
+	}
+
+	if h.bodyTooLarge(c, err) {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, models.ErrorResponse{
+		Error:   "Invalid request body",
+		Code:    "INVALID_REQUEST",
+		Details: bindErrorDetails(err),
+		Fields:  bindErrorFields(obj, err),
+	})
+	return false
+}
+
+// bodyTooLarge answers 413 if err comes from reading past the body size
+// limit
+func (h *Handler) bodyTooLarge(c *gin.Context, err error) bool {
+	var tooLarge *http.MaxBytesError
+	if !errors.As(err, &tooLarge) {
+		return false
+	}
+	h.errorResponse(c, http.StatusRequestEntityTooLarge, "REQUEST_TOO_LARGE", "Request body too large",
+		fmt.Sprintf("request bodies are limited to %d bytes", tooLarge.Limit))
+	return true
+}
+
+func (h *Handler) decodeJSON(req *http.Request, obj any) error {
+	if req.Body == nil {
+		return io.EOF
+	}
+
+	decoder := json.NewDecoder(req.Body)
+	if h.strictJSON {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(obj); err != nil {
+		return err
+	}
+	if !h.strictJSON {
+		return nil
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return err
+		}
+		return errTrailingData
+	}
+	return nil
+}
+
+func bindErrorDetails(err error) string {
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.Is(err, io.EOF):
+		return "request body is empty"
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return "malformed JSON: unexpected end of body"
+	case errors.As(err, &syntaxErr):
+		return fmt.Sprintf("malformed JSON at offset %d: %v", syntaxErr.Offset, err)
+	}
+	return err.Error()
+}
+
+// bindErrorFields lists the fields a decoding or validation error is about,
+// named by their JSON paths
+func bindErrorFields(obj any, err error) []models.FieldError {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return []models.FieldError{{Field: typeErr.Field, Message: "cannot be a JSON " + typeErr.Value}}
+	}
+	if failures := validationFailures(err); len(failures) > 0 {
+		fields := make([]models.FieldError, 0, len(failures))
+		for _, fe := range failures {
+			fields = append(fields, models.FieldError{
+				Field:   jsonFieldPath(reflect.TypeOf(obj), fe.StructNamespace()),
+				Message: validationMessage(fe),
+			})
+		}
+		return fields
+	}
+	if name, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		if unquoted, err := strconv.Unquote(name); err == nil {
+			name = unquoted
+		}
+		return []models.FieldError{{Field: name, Message: "unknown field"}}
+	}
+	return nil
+}
+
+// validationFailures lists the fields of a validation error, which the
+// validator reports as a slice of failures
+func validationFailures(err error) []validationFailure {
+	v := reflect.ValueOf(err)
+	if v.Kind() != reflect.Slice {
+		return nil
+	}
+	failures := make([]validationFailure, 0, v.Len())
+	for i := range v.Len() {
+		if fe, ok := v.Index(i).Interface().(validationFailure); ok {
+			failures = append(failures, fe)
+		}
+	}
+	return failures
+}
+
+// jsonFieldPath turns a validator namespace such as
+// "RegisterAppRequest.ProjectID" into the JSON path "project_id"
+func jsonFieldPath(t reflect.Type, namespace string) string {
+	parts := strings.Split(namespace, ".")[1:]
+	names := make([]string, 0, len(parts))
+	for _, part := range parts {
+		for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map) {
+			t = t.Elem()
+		}
+		name, index, _ := strings.Cut(part, "[")
+		if index != "" {
+			index = "[" + index
+		}
+		if t == nil || t.Kind() != reflect.Struct {
+			names = append(names, part)
+			continue
+		}
+		field, ok := t.FieldByName(name)
+		if !ok {
+			names = append(names, part)
+			continue
+		}
+		if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag != "" && tag != "-" {
+			name = tag
+		}
+		names = append(names, name+index)
+		t = field.Type
+	}
+	return strings.Join(names, ".")
+}
+
+func validationMessage(fe validationFailure) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "oneof":
+		return "must be one of " + fe.Param()
+	default:
+		return fmt.Sprintf("failed the %s check", fe.Tag())
+	}
+}
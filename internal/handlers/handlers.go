@@ -1,7 +1,11 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/company/version-service/internal/middleware"
@@ -14,6 +18,14 @@ import (
 type Handler struct {
 	service services.VersionServiceInterface
 	logger  *logrus.Logger
+	cache   *middleware.ResponseCache
+
+	writeThrough bool
+	strictJSON   bool
+
+	// Roles of callers authenticated by API keys or OIDC tokens; nil grants
+	// everything
+	rbac *models.RBACPolicy
 }
 
 func NewHandler(service services.VersionServiceInterface, logger *logrus.Logger) *Handler {
@@ -23,6 +35,46 @@ func NewHandler(service services.VersionServiceInterface, logger *logrus.Logger)
 	}
 }
 
+// SetResponseCache wires the response cache purged by PurgeCache
+func (h *Handler) SetResponseCache(cache *middleware.ResponseCache) {
+	h.cache = cache
+}
+
+// SetRBACPolicy makes handlers check the caller's role on the project
+// before calling the service
+func (h *Handler) SetRBACPolicy(policy *models.RBACPolicy) {
+	h.rbac = policy
+}
+
+// SetWriteThrough tells the handler that writes are durable once answered,
+// which writes acknowledge with 200 OK instead of 202 Accepted
+func (h *Handler) SetWriteThrough(writeThrough bool) {
+	h.writeThrough = writeThrough
+}
+
+// SetStrictJSON makes request bodies with unknown fields or trailing data
+// invalid
+func (h *Handler) SetStrictJSON(strict bool) {
+	h.strictJSON = strict
+}
+
+// writeContext is the context for the version writes of a request, which
+// writeStatus later answers for
+func (h *Handler) writeContext(c *gin.Context) context.Context {
+	c.Request = c.Request.WithContext(services.WithDurability(c.Request.Context()))
+	return c.Request.Context()
+}
+
+// writeStatus is the status of a successful version write: 200 OK under
+// write-through once Git has the writes of writeContext, otherwise 202
+// Accepted, as Git is written in the background or failed over
+func (h *Handler) writeStatus(c *gin.Context) int {
+	if h.writeThrough && services.Durable(c.Request.Context()) {
+		return http.StatusOK
+	}
+	return http.StatusAccepted
+}
+
 // Health godoc
 // @Summary Health check
 // @Description Get health status of the service
@@ -63,9 +115,13 @@ func (h *Handler) Health(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param app-id path string true "Application ID"
+// @Param format query string false "Version output format (plain, v, docker)"
+// @Param X-Canary-Verify header bool false "Verify the response against Git in the background"
 // @Success 200 {object} models.VersionResponse
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
 // @Router /version/{app-id} [get]
 func (h *Handler) GetVersion(c *gin.Context) {
 	appID := c.Param("app-id")
@@ -74,12 +130,41 @@ func (h *Handler) GetVersion(c *gin.Context) {
 		return
 	}
 
-	version, err := h.service.GetVersion(c.Request.Context(), appID)
+	if !h.authorizeApp(c, appID, models.RoleRead) {
+		return
+	}
+
+	format, ok := h.versionFormat(c)
+	if !ok {
+		return
+	}
+
+	version, err := h.service.GetVersion(h.readContext(c), appID)
 	if err != nil {
 		if strings.Contains(err.Error(), "invalid app ID") {
 			h.errorResponse(c, http.StatusBadRequest, "INVALID_APP_ID", "Invalid app ID format", err.Error())
 			return
 		}
+		if errors.Is(err, services.ErrAppNotRegistered) {
+			h.errorResponse(c, http.StatusNotFound, "APP_NOT_REGISTERED", "App not registered", err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrAppDeleted) {
+			h.errorResponse(c, http.StatusNotFound, "APP_DELETED", "App was deleted", err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrAppNotFound) {
+			h.errorResponse(c, http.StatusNotFound, "APP_NOT_FOUND", "App not found", err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrQuotaExceeded) {
+			h.errorResponse(c, http.StatusTooManyRequests, "QUOTA_EXCEEDED", "Project quota exceeded", err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrGitLabUnavailable) {
+			h.errorResponse(c, http.StatusServiceUnavailable, "GITLAB_UNAVAILABLE", "GitLab is unavailable to seed the app, try again", err.Error())
+			return
+		}
 		h.logger.WithError(err).WithField("app_id", appID).Error("Failed to get version")
 		h.errorResponse(c, http.StatusInternalServerError, "GET_VERSION_FAILED", "Failed to get version", err.Error())
 		middleware.RecordVersionOperation("get", appID, "error")
@@ -87,7 +172,7 @@ func (h *Handler) GetVersion(c *gin.Context) {
 	}
 
 	middleware.RecordVersionOperation("get", appID, "success")
-	c.JSON(http.StatusOK, version)
+	c.JSON(http.StatusOK, version.Formatted(format))
 }
 
 // IncrementVersion godoc
@@ -97,10 +182,18 @@ func (h *Handler) GetVersion(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param app-id path string true "Application ID"
-// @Param type query string false "Increment type (major, minor, patch)" default(patch)
-// @Success 200 {object} models.AppVersion
+// @Param type query string false "Increment type (major, minor, patch, rc, build)" default(patch)
+// @Param Idempotency-Key header string false "Key that makes retries return the original version"
+// @Param request body models.IncrementRequest false "Optional body carrying the idempotency key"
+// @Success 200 {object} models.VersionResponse
+// @Success 202 {object} models.VersionResponse
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
+// @Failure 502 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
 // @Router /version/{app-id}/increment [post]
 func (h *Handler) IncrementVersion(c *gin.Context) {
 	appID := c.Param("app-id")
@@ -109,37 +202,313 @@ func (h *Handler) IncrementVersion(c *gin.Context) {
 		return
 	}
 
-	incrementType := models.IncrementTypePatch
-	if typeParam := c.Query("type"); typeParam != "" {
-		switch typeParam {
-		case "major":
-			incrementType = models.IncrementTypeMajor
-		case "minor":
-			incrementType = models.IncrementTypeMinor
-		case "patch":
-			incrementType = models.IncrementTypePatch
-		default:
-			h.errorResponse(c, http.StatusBadRequest, "INVALID_INCREMENT_TYPE", "Invalid increment type", "Valid types: major, minor, patch")
-			return
-		}
+	if !h.authorizeApp(c, appID, models.RoleWrite) {
+		return
 	}
 
-	response, err := h.service.IncrementVersion(c.Request.Context(), appID, incrementType)
+	incrementType, ok := h.parseIncrementType(c)
+	if !ok {
+		return
+	}
+
+	idempotencyKey, ok := h.parseIdempotencyKey(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.service.IncrementVersion(h.writeContext(c), appID, incrementType, idempotencyKey)
 	if err != nil {
 		if strings.Contains(err.Error(), "invalid app ID") {
 			h.errorResponse(c, http.StatusBadRequest, "INVALID_APP_ID", "Invalid app ID format", err.Error())
 			return
 		}
+		if errors.Is(err, services.ErrAppNotRegistered) {
+			h.errorResponse(c, http.StatusNotFound, "APP_NOT_REGISTERED", "App not registered", err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrAppDeleted) {
+			h.errorResponse(c, http.StatusNotFound, "APP_DELETED", "App was deleted", err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrQuotaExceeded) {
+			h.errorResponse(c, http.StatusTooManyRequests, "QUOTA_EXCEEDED", "Project quota exceeded", err.Error())
+			middleware.RecordVersionOperation("increment", appID, "rejected")
+			return
+		}
+		if errors.Is(err, services.ErrVersionLocked) {
+			h.errorResponse(c, http.StatusConflict, "VERSION_LOCKED", "Version is locked", err.Error())
+			middleware.RecordVersionOperation("increment", appID, "rejected")
+			return
+		}
+		if errors.Is(err, services.ErrIncrementNotAllowed) {
+			h.errorResponse(c, http.StatusConflict, "INCREMENT_NOT_ALLOWED", "Increment type not allowed by app policy", err.Error())
+			middleware.RecordVersionOperation("increment", appID, "rejected")
+			return
+		}
+		if errors.Is(err, services.ErrVersionReserved) {
+			h.errorResponse(c, http.StatusConflict, "VERSION_RESERVED", "Next version is reserved", err.Error())
+			middleware.RecordVersionOperation("increment", appID, "rejected")
+			return
+		}
+		if errors.Is(err, services.ErrHookRejected) {
+			h.errorResponse(c, http.StatusConflict, "INCREMENT_REJECTED", "Increment rejected by policy hook", err.Error())
+			middleware.RecordVersionOperation("increment", appID, "rejected")
+			return
+		}
+		if errors.Is(err, services.ErrHookFailed) {
+			h.errorResponse(c, http.StatusBadGateway, "HOOK_FAILED", "Pre-increment hook failed", err.Error())
+			middleware.RecordVersionOperation("increment", appID, "error")
+			return
+		}
+		if errors.Is(err, services.ErrRevisionConflict) {
+			h.errorResponse(c, http.StatusConflict, "CONCURRENT_INCREMENT", "App kept changing during the increment, try again", err.Error())
+			middleware.RecordVersionOperation("increment", appID, "conflict")
+			return
+		}
+		if errors.Is(err, services.ErrGitLabUnavailable) {
+			h.errorResponse(c, http.StatusServiceUnavailable, "GITLAB_UNAVAILABLE", "GitLab is unavailable to seed the app, try again", err.Error())
+			middleware.RecordVersionOperation("increment", appID, "error")
+			return
+		}
 		h.logger.WithError(err).WithField("app_id", appID).Error("Failed to increment version")
 		h.errorResponse(c, http.StatusInternalServerError, "INCREMENT_FAILED", "Failed to increment version", err.Error())
 		middleware.RecordVersionOperation("increment", appID, "error")
 		return
 	}
 
-	middleware.RecordVersionOperation("increment", appID, "success")
+	if response.Replayed {
+		c.Header("Idempotent-Replayed", "true")
+		middleware.RecordVersionOperation("increment", appID, "replayed")
+	} else {
+		middleware.RecordVersionOperation("increment", appID, "success")
+	}
+	c.JSON(h.writeStatus(c), response)
+}
+
+// IncrementVersions godoc
+// @Summary Increment several application versions
+// @Description Increment a list of applications at once; Git receives a single commit. The batch fails as a whole if any app is invalid or locked.
+// @Tags version
+// @Accept json
+// @Produce json
+// @Param request body models.BatchIncrementRequest true "App IDs and increment type"
+// @Success 200 {object} models.BatchIncrementResponse
+// @Success 202 {object} models.BatchIncrementResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 502 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Router /versions/increment [post]
+func (h *Handler) IncrementVersions(c *gin.Context) {
+	var req models.BatchIncrementRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	typeValue := string(req.Type)
+	if typeValue == "" {
+		typeValue = c.Query("type")
+	}
+	incrementType, ok := h.validateIncrementType(c, typeValue)
+	if !ok {
+		return
+	}
+
+	for _, appID := range req.AppIDs {
+		if !h.authorizeApp(c, appID, models.RoleWrite) {
+			return
+		}
+	}
+
+	response, err := h.service.IncrementVersions(h.writeContext(c), req.AppIDs, incrementType)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "invalid app ID"):
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_APP_ID", "Invalid app ID format", err.Error())
+		case errors.Is(err, services.ErrAppNotRegistered):
+			h.errorResponse(c, http.StatusNotFound, "APP_NOT_REGISTERED", "App not registered", err.Error())
+		case errors.Is(err, services.ErrAppDeleted):
+			h.errorResponse(c, http.StatusNotFound, "APP_DELETED", "App was deleted", err.Error())
+		case errors.Is(err, services.ErrInvalidBatch):
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_BATCH", "Invalid batch request", err.Error())
+		case errors.Is(err, services.ErrVersionLocked):
+			h.errorResponse(c, http.StatusConflict, "VERSION_LOCKED", "Version is locked", err.Error())
+		case errors.Is(err, services.ErrIncrementNotAllowed):
+			h.errorResponse(c, http.StatusConflict, "INCREMENT_NOT_ALLOWED", "Increment type not allowed by app policy", err.Error())
+		case errors.Is(err, services.ErrVersionReserved):
+			h.errorResponse(c, http.StatusConflict, "VERSION_RESERVED", "Next version is reserved", err.Error())
+		case errors.Is(err, services.ErrHookRejected):
+			h.errorResponse(c, http.StatusConflict, "INCREMENT_REJECTED", "Increment rejected by policy hook", err.Error())
+		case errors.Is(err, services.ErrHookFailed):
+			h.errorResponse(c, http.StatusBadGateway, "HOOK_FAILED", "Pre-increment hook failed", err.Error())
+		case errors.Is(err, services.ErrRevisionConflict):
+			h.errorResponse(c, http.StatusConflict, "CONCURRENT_INCREMENT", "Apps kept changing during the batch increment, try again", err.Error())
+		case errors.Is(err, services.ErrQuotaExceeded):
+			h.errorResponse(c, http.StatusTooManyRequests, "QUOTA_EXCEEDED", "Project quota exceeded", err.Error())
+		case errors.Is(err, services.ErrGitLabUnavailable):
+			h.errorResponse(c, http.StatusServiceUnavailable, "GITLAB_UNAVAILABLE", "GitLab is unavailable to seed an app, try again", err.Error())
+		default:
+			h.logger.WithError(err).WithField("app_ids", req.AppIDs).Error("Failed to increment versions")
+			h.errorResponse(c, http.StatusInternalServerError, "INCREMENT_FAILED", "Failed to increment versions", err.Error())
+		}
+		return
+	}
+
+	for appID := range response.Versions {
+		middleware.RecordVersionOperation("increment", appID, "success")
+	}
+	c.JSON(h.writeStatus(c), response)
+}
+
+// PreviewNextVersion godoc
+// @Summary Preview next application version
+// @Description Compute the version an increment would produce without persisting anything
+// @Tags version
+// @Accept json
+// @Produce json
+// @Param app-id path string true "Application ID"
+// @Param type query string false "Increment type (major, minor, patch, rc, build)" default(patch)
+// @Param format query string false "Version output format (plain, v, docker)"
+// @Success 200 {object} models.NextVersionResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Router /version/{app-id}/next [get]
+func (h *Handler) PreviewNextVersion(c *gin.Context) {
+	appID := c.Param("app-id")
+	if appID == "" {
+		h.errorResponse(c, http.StatusBadRequest, "APP_ID_REQUIRED", "app ID is required", "")
+		return
+	}
+
+	if !h.authorizeApp(c, appID, models.RoleRead) {
+		return
+	}
+
+	incrementType, ok := h.parseIncrementType(c)
+	if !ok {
+		return
+	}
+	format, ok := h.versionFormat(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.service.PreviewNextVersion(c.Request.Context(), appID, incrementType)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid app ID") {
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_APP_ID", "Invalid app ID format", err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrAppNotRegistered) {
+			h.errorResponse(c, http.StatusNotFound, "APP_NOT_REGISTERED", "App not registered", err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrAppDeleted) {
+			h.errorResponse(c, http.StatusNotFound, "APP_DELETED", "App was deleted", err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrGitLabUnavailable) {
+			h.errorResponse(c, http.StatusServiceUnavailable, "GITLAB_UNAVAILABLE", "GitLab is unavailable to seed the app, try again", err.Error())
+			return
+		}
+		h.logger.WithError(err).WithField("app_id", appID).Error("Failed to preview next version")
+		h.errorResponse(c, http.StatusInternalServerError, "PREVIEW_FAILED", "Failed to preview next version", err.Error())
+		return
+	}
+
+	response.Current = models.FormatVersion(response.Current, format)
+	response.Next = models.FormatVersion(response.Next, format)
+	c.JSON(http.StatusOK, response)
+}
+
+// CompareVersions godoc
+// @Summary Compare two versions
+// @Description Order two semantic versions and report the most significant component in which they differ (major, minor, patch, prerelease or none)
+// @Tags version
+// @Produce json
+// @Param v1 query string true "First version"
+// @Param v2 query string true "Second version"
+// @Success 200 {object} models.CompareResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Router /version/compare [get]
+func (h *Handler) CompareVersions(c *gin.Context) {
+	v1, v2 := c.Query("v1"), c.Query("v2")
+	if v1 == "" || v2 == "" {
+		h.errorResponse(c, http.StatusBadRequest, "VERSIONS_REQUIRED", "v1 and v2 query parameters are required", "")
+		return
+	}
+
+	response, err := h.service.CompareVersions(v1, v2)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidVersion) {
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_VERSION", "Invalid version", err.Error())
+			return
+		}
+		h.logger.WithError(err).Error("Failed to compare versions")
+		h.errorResponse(c, http.StatusInternalServerError, "COMPARE_FAILED", "Failed to compare versions", err.Error())
+		return
+	}
+
 	c.JSON(http.StatusOK, response)
 }
 
+// GetChangelog godoc
+// @Summary Get changelog between versions
+// @Description List the merge requests and commits between the GitLab tags of two versions of an application
+// @Tags version
+// @Produce json
+// @Param app-id path string true "Application ID"
+// @Param from query string true "Older version"
+// @Param to query string false "Newer version, defaults to the current version"
+// @Success 200 {object} models.Changelog
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Router /version/{app-id}/changelog [get]
+func (h *Handler) GetChangelog(c *gin.Context) {
+	appID := c.Param("app-id")
+	if !h.authorizeApp(c, appID, models.RoleRead) {
+		return
+	}
+
+	from := c.Query("from")
+	if from == "" {
+		h.errorResponse(c, http.StatusBadRequest, "VERSIONS_REQUIRED", "from query parameter is required", "")
+		return
+	}
+
+	changelog, err := h.service.GetChangelog(c.Request.Context(), appID, from, c.Query("to"))
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "invalid app ID"):
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_APP_ID", "Invalid app ID format", err.Error())
+		case errors.Is(err, services.ErrInvalidVersion):
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_VERSION", "Invalid version", err.Error())
+		case errors.Is(err, services.ErrTagNotFound):
+			h.errorResponse(c, http.StatusNotFound, "TAG_NOT_FOUND", "Version tag not found", err.Error())
+		case errors.Is(err, services.ErrAppNotRegistered):
+			h.errorResponse(c, http.StatusNotFound, "APP_NOT_REGISTERED", "App not registered", err.Error())
+		case errors.Is(err, services.ErrAppDeleted):
+			h.errorResponse(c, http.StatusNotFound, "APP_DELETED", "App has been deleted", err.Error())
+		case errors.Is(err, services.ErrAppNotFound):
+			h.errorResponse(c, http.StatusNotFound, "APP_NOT_FOUND", "App not found", err.Error())
+		case errors.Is(err, services.ErrChangelogUnavailable):
+			h.errorResponse(c, http.StatusServiceUnavailable, "CHANGELOG_UNAVAILABLE", "Changelog unavailable", err.Error())
+		default:
+			h.logger.WithError(err).WithField("app_id", appID).Error("Failed to generate changelog")
+			h.errorResponse(c, http.StatusInternalServerError, "CHANGELOG_FAILED", "Failed to generate changelog", err.Error())
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, changelog)
+}
+
 // GetDevVersion godoc
 // @Summary Get development version
 // @Description Get a development version with branch and commit info
@@ -148,9 +517,12 @@ func (h *Handler) IncrementVersion(c *gin.Context) {
 // @Produce json
 // @Param app-id path string true "Application ID"
 // @Param request body models.DevVersionRequest true "Development version request"
+// @Param base query string false "Version {current} stands for: current or next (overrides the body)"
 // @Success 200 {object} models.VersionResponse
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
 // @Router /version/{app-id}/dev [post]
 func (h *Handler) GetDevVersion(c *gin.Context) {
 	appID := c.Param("app-id")
@@ -159,11 +531,17 @@ func (h *Handler) GetDevVersion(c *gin.Context) {
 		return
 	}
 
+	if !h.authorizeApp(c, appID, models.RoleWrite) {
+		return
+	}
+
 	var req models.DevVersionRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.errorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", err.Error())
+	if !h.bindJSON(c, &req) {
 		return
 	}
+	if base := c.Query("base"); base != "" {
+		req.Base = base
+	}
 
 	response, err := h.service.GetDevVersion(c.Request.Context(), appID, &req)
 	if err != nil {
@@ -171,6 +549,26 @@ func (h *Handler) GetDevVersion(c *gin.Context) {
 			h.errorResponse(c, http.StatusBadRequest, "INVALID_APP_ID", "Invalid app ID format", err.Error())
 			return
 		}
+		if errors.Is(err, services.ErrAppNotRegistered) {
+			h.errorResponse(c, http.StatusNotFound, "APP_NOT_REGISTERED", "App not registered", err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrAppDeleted) {
+			h.errorResponse(c, http.StatusNotFound, "APP_DELETED", "App was deleted", err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrInvalidDevTemplate) {
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_DEV_TEMPLATE", "Invalid dev version template", err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrInvalidDevBase) {
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_DEV_BASE", "Invalid dev version base", err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrGitLabUnavailable) {
+			h.errorResponse(c, http.StatusServiceUnavailable, "GITLAB_UNAVAILABLE", "GitLab is unavailable to seed the app, try again", err.Error())
+			return
+		}
 		h.logger.WithError(err).WithField("app_id", appID).Error("Failed to get dev version")
 		h.errorResponse(c, http.StatusInternalServerError, "DEV_VERSION_FAILED", "Failed to get dev version", err.Error())
 		middleware.RecordVersionOperation("dev", appID, "error")
@@ -181,113 +579,1978 @@ func (h *Handler) GetDevVersion(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-// ListVersions godoc
-// @Summary List all versions
-// @Description Get a list of all application versions
+// ListDevVersions godoc
+// @Summary List issued development versions
+// @Description List the development versions issued for an application within the retention window, newest first, so registry cleanup jobs can tell which dev tags exist
 // @Tags version
-// @Accept json
 // @Produce json
-// @Success 200 {object} map[string]models.AppVersion
+// @Param app-id path string true "Application ID"
+// @Param branch query string false "Only versions issued for this branch"
+// @Success 200 {object} models.DevVersionsResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
-// @Router /versions [get]
-func (h *Handler) ListVersions(c *gin.Context) {
-	versions, err := h.service.ListVersions(c.Request.Context())
+// @Router /version/{app-id}/dev/issued [get]
+func (h *Handler) ListDevVersions(c *gin.Context) {
+	appID := c.Param("app-id")
+	if appID == "" {
+		h.errorResponse(c, http.StatusBadRequest, "APP_ID_REQUIRED", "app ID is required", "")
+		return
+	}
+
+	if !h.authorizeApp(c, appID, models.RoleRead) {
+		return
+	}
+
+	response, err := h.service.ListDevVersions(c.Request.Context(), appID, c.Query("branch"))
 	if err != nil {
-		h.logger.WithError(err).Error("Failed to list versions")
-		h.errorResponse(c, http.StatusInternalServerError, "LIST_FAILED", "Failed to list versions", err.Error())
+		switch {
+		case strings.Contains(err.Error(), "invalid app ID"):
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_APP_ID", "Invalid app ID format", err.Error())
+		case errors.Is(err, services.ErrDevTrackingDisabled):
+			h.errorResponse(c, http.StatusNotFound, "DEV_TRACKING_DISABLED", "Dev version tracking is disabled", "")
+		default:
+			h.logger.WithError(err).WithField("app_id", appID).Error("Failed to list dev versions")
+			h.errorResponse(c, http.StatusInternalServerError, "DEV_VERSIONS_FAILED", "Failed to list dev versions", err.Error())
+		}
 		return
 	}
 
-	c.JSON(http.StatusOK, versions)
+	c.JSON(http.StatusOK, response)
 }
 
-// ListVersionsByProject godoc
-// @Summary List versions by project
-// @Description Get a list of versions for a specific project
+// PromoteVersion godoc
+// @Summary Promote prerelease version
+// @Description Strip the prerelease suffix from an application's current version (e.g. 1.4.0-rc.2 → 1.4.0) and persist it
 // @Tags version
-// @Accept json
 // @Produce json
-// @Param project-id path string true "Project ID"
-// @Success 200 {object} map[string]models.AppVersion
+// @Param app-id path string true "Application ID"
+// @Success 200 {object} models.PromoteResponse
+// @Success 202 {object} models.PromoteResponse
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
-// @Router /versions/{project-id} [get]
-func (h *Handler) ListVersionsByProject(c *gin.Context) {
-	projectID := c.Param("project-id")
-	if projectID == "" {
-		h.errorResponse(c, http.StatusBadRequest, "PROJECT_ID_REQUIRED", "project ID is required", "")
+// @Router /version/{app-id}/promote [post]
+func (h *Handler) PromoteVersion(c *gin.Context) {
+	appID := c.Param("app-id")
+	if appID == "" {
+		h.errorResponse(c, http.StatusBadRequest, "APP_ID_REQUIRED", "app ID is required", "")
 		return
 	}
 
-	versions, err := h.service.ListVersionsByProject(c.Request.Context(), projectID)
+	if !h.authorizeApp(c, appID, models.RoleWrite) {
+		return
+	}
+
+	response, err := h.service.PromoteVersion(h.writeContext(c), appID)
 	if err != nil {
-		h.logger.WithError(err).WithField("project_id", projectID).Error("Failed to list versions by project")
-		h.errorResponse(c, http.StatusInternalServerError, "LIST_FAILED", "Failed to list versions", err.Error())
+		switch {
+		case strings.Contains(err.Error(), "invalid app ID"):
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_APP_ID", "Invalid app ID format", err.Error())
+		case errors.Is(err, services.ErrAppNotFound):
+			h.errorResponse(c, http.StatusNotFound, "APP_NOT_FOUND", "App not found", err.Error())
+		case errors.Is(err, services.ErrNotPrerelease):
+			h.errorResponse(c, http.StatusConflict, "NOT_PRERELEASE", "Current version is not a prerelease", err.Error())
+		case errors.Is(err, services.ErrVersionReserved):
+			h.errorResponse(c, http.StatusConflict, "VERSION_RESERVED", "Release version is reserved", err.Error())
+		case errors.Is(err, services.ErrVersionLocked):
+			h.errorResponse(c, http.StatusConflict, "VERSION_LOCKED", "Version is locked", err.Error())
+		default:
+			h.logger.WithError(err).WithField("app_id", appID).Error("Failed to promote version")
+			h.errorResponse(c, http.StatusInternalServerError, "PROMOTE_FAILED", "Failed to promote version", err.Error())
+			middleware.RecordVersionOperation("promote", appID, "error")
+		}
 		return
 	}
 
-	c.JSON(http.StatusOK, versions)
+	middleware.RecordVersionOperation("promote", appID, "success")
+	c.JSON(h.writeStatus(c), response)
 }
 
-// DeleteVersion godoc
-// @Summary Delete application version
-// @Description Delete a specific application version or entire project
+// GraduateVersion godoc
+// @Summary Graduate application to 1.0.0
+// @Description Move an application below 1.0.0 to 1.0.0. Graduating counts as a major increment: the app's policy must allow major increments, 1.0.0 must not be reserved, and quotas and increment hooks apply.
 // @Tags version
-// @Accept json
 // @Produce json
-// @Param id path string true "Application ID (project-id-app-name) or Project ID (project-id)"
-// @Success 200 {object} map[string]string
+// @Param app-id path string true "Application ID"
+// @Success 200 {object} models.GraduateResponse
+// @Success 202 {object} models.GraduateResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
-// @Router /delete/{id} [delete]
-func (h *Handler) DeleteVersion(c *gin.Context) {
-	id := c.Param("id")
-	if id == "" {
-		h.errorResponse(c, http.StatusBadRequest, "ID_REQUIRED", "ID is required", "")
+// @Failure 502 {object} models.ErrorResponse
+// @Router /version/{app-id}/graduate [post]
+func (h *Handler) GraduateVersion(c *gin.Context) {
+	appID := c.Param("app-id")
+	if appID == "" {
+		h.errorResponse(c, http.StatusBadRequest, "APP_ID_REQUIRED", "app ID is required", "")
 		return
 	}
 
-	// Check if this is a project ID (no dash-separated app name) or app ID
-	if strings.Contains(id, "-") && len(strings.Split(id, "-")) >= 2 {
-		// This looks like an app ID (project-id-app-name)
-		_, err := h.service.GetVersion(c.Request.Context(), id)
-		if err != nil {
-			if strings.Contains(err.Error(), "invalid app ID") {
-				h.errorResponse(c, http.StatusBadRequest, "INVALID_APP_ID", "Invalid app ID format", err.Error())
-				return
-			}
-			// If GetVersion fails, it might not exist, but we'll try to delete anyway
+	if !h.authorizeApp(c, appID, models.RoleWrite) {
+		return
+	}
+
+	response, err := h.service.GraduateVersion(h.writeContext(c), appID)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "invalid app ID"):
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_APP_ID", "Invalid app ID format", err.Error())
+		case errors.Is(err, services.ErrAppNotFound):
+			h.errorResponse(c, http.StatusNotFound, "APP_NOT_FOUND", "App not found", err.Error())
+		case errors.Is(err, services.ErrUnsupportedScheme):
+			h.errorResponse(c, http.StatusBadRequest, "UNSUPPORTED_SCHEME", "Not supported by the app's version scheme", err.Error())
+		case errors.Is(err, services.ErrAlreadyGraduated):
+			h.errorResponse(c, http.StatusConflict, "ALREADY_GRADUATED", "App is already at 1.0.0 or above", err.Error())
+		case errors.Is(err, services.ErrVersionLocked):
+			h.errorResponse(c, http.StatusConflict, "VERSION_LOCKED", "Version is locked", err.Error())
+		case errors.Is(err, services.ErrIncrementNotAllowed):
+			h.errorResponse(c, http.StatusConflict, "INCREMENT_NOT_ALLOWED", "Increment type not allowed by app policy", err.Error())
+		case errors.Is(err, services.ErrVersionReserved):
+			h.errorResponse(c, http.StatusConflict, "VERSION_RESERVED", "1.0.0 is reserved", err.Error())
+		case errors.Is(err, services.ErrHookRejected):
+			h.errorResponse(c, http.StatusConflict, "INCREMENT_REJECTED", "Increment rejected by policy hook", err.Error())
+		case errors.Is(err, services.ErrHookFailed):
+			h.errorResponse(c, http.StatusBadGateway, "HOOK_FAILED", "Pre-increment hook failed", err.Error())
+		case errors.Is(err, services.ErrQuotaExceeded):
+			h.errorResponse(c, http.StatusTooManyRequests, "QUOTA_EXCEEDED", "Project quota exceeded", err.Error())
+		default:
+			h.logger.WithError(err).WithField("app_id", appID).Error("Failed to graduate version")
+			h.errorResponse(c, http.StatusInternalServerError, "GRADUATE_FAILED", "Failed to graduate version", err.Error())
+			middleware.RecordVersionOperation("graduate", appID, "error")
+		}
+		return
+	}
+
+	middleware.RecordVersionOperation("graduate", appID, "success")
+	c.JSON(h.writeStatus(c), response)
+}
+
+// LockVersion godoc
+// @Summary Lock application version
+// @Description Freeze an application's version so increments, rollbacks and promotions are rejected with 409 (admin only)
+// @Tags version
+// @Produce json
+// @Param app-id path string true "Application ID"
+// @Success 200 {object} models.AppVersion
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /version/{app-id}/lock [post]
+func (h *Handler) LockVersion(c *gin.Context) {
+	h.setVersionLock(c, true)
+}
+
+// UnlockVersion godoc
+// @Summary Unlock application version
+// @Description Lift a version freeze so the application can be incremented again (admin only)
+// @Tags version
+// @Produce json
+// @Param app-id path string true "Application ID"
+// @Success 200 {object} models.AppVersion
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /version/{app-id}/unlock [post]
+func (h *Handler) UnlockVersion(c *gin.Context) {
+	h.setVersionLock(c, false)
+}
+
+func (h *Handler) setVersionLock(c *gin.Context, locked bool) {
+	operation := "unlock"
+	if locked {
+		operation = "lock"
+	}
+
+	appID := c.Param("app-id")
+	if appID == "" {
+		h.errorResponse(c, http.StatusBadRequest, "APP_ID_REQUIRED", "app ID is required", "")
+		return
+	}
+
+	version, err := h.service.SetVersionLock(c.Request.Context(), appID, locked)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "invalid app ID"):
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_APP_ID", "Invalid app ID format", err.Error())
+		case errors.Is(err, services.ErrAppNotFound):
+			h.errorResponse(c, http.StatusNotFound, "APP_NOT_FOUND", "App not found", err.Error())
+		default:
+			h.logger.WithError(err).WithField("app_id", appID).Errorf("Failed to %s version", operation)
+			h.errorResponse(c, http.StatusInternalServerError, "LOCK_FAILED", fmt.Sprintf("Failed to %s version", operation), err.Error())
+			middleware.RecordVersionOperation(operation, appID, "error")
+		}
+		return
+	}
+
+	middleware.RecordVersionOperation(operation, appID, "success")
+	c.JSON(http.StatusOK, version)
+}
+
+// SetLifecycle godoc
+// @Summary Set application lifecycle state
+// @Description Move an application to another lifecycle state (active, frozen, deprecated, archived or deleted). Only transitions allowed by the lifecycle state machine are accepted (admin only).
+// @Tags version
+// @Accept json
+// @Produce json
+// @Param app-id path string true "Application ID"
+// @Param request body models.SetLifecycleRequest true "Target state"
+// @Success 200 {object} models.AppVersion
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /version/{app-id}/lifecycle [put]
+func (h *Handler) SetLifecycle(c *gin.Context) {
+	appID := c.Param("app-id")
+	if appID == "" {
+		h.errorResponse(c, http.StatusBadRequest, "APP_ID_REQUIRED", "app ID is required", "")
+		return
+	}
+
+	var req models.SetLifecycleRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	version, err := h.service.SetLifecycle(c.Request.Context(), appID, req.State)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "invalid app ID"):
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_APP_ID", "Invalid app ID format", err.Error())
+		case errors.Is(err, services.ErrInvalidLifecycle):
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_LIFECYCLE_STATE", "Invalid lifecycle state", err.Error())
+		case errors.Is(err, services.ErrLifecycleTransition):
+			h.errorResponse(c, http.StatusConflict, "LIFECYCLE_TRANSITION_NOT_ALLOWED", "Lifecycle transition not allowed", err.Error())
+		case errors.Is(err, services.ErrAppNotFound):
+			h.errorResponse(c, http.StatusNotFound, "APP_NOT_FOUND", "App not found", err.Error())
+		default:
+			h.logger.WithError(err).WithField("app_id", appID).Error("Failed to set lifecycle state")
+			h.errorResponse(c, http.StatusInternalServerError, "LIFECYCLE_FAILED", "Failed to set lifecycle state", err.Error())
+			middleware.RecordVersionOperation("lifecycle", appID, "error")
+		}
+		return
+	}
+
+	middleware.RecordVersionOperation("lifecycle", appID, "success")
+	c.JSON(http.StatusOK, version)
+}
+
+// SetIncrementStrategy godoc
+// @Summary Set application increment strategy
+// @Description Set the increment applied when an increment request names no type: patch, minor, major, rc, build or date-patch. An empty strategy restores patch increments (admin only).
+// @Tags version
+// @Accept json
+// @Produce json
+// @Param app-id path string true "Application ID"
+// @Param request body models.SetStrategyRequest true "Increment strategy"
+// @Success 200 {object} models.AppVersion
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /version/{app-id}/strategy [put]
+func (h *Handler) SetIncrementStrategy(c *gin.Context) {
+	appID := c.Param("app-id")
+	if appID == "" {
+		h.errorResponse(c, http.StatusBadRequest, "APP_ID_REQUIRED", "app ID is required", "")
+		return
+	}
+
+	var req models.SetStrategyRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	version, err := h.service.SetIncrementStrategy(c.Request.Context(), appID, req.Strategy)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "invalid app ID"):
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_APP_ID", "Invalid app ID format", err.Error())
+		case errors.Is(err, services.ErrInvalidStrategy):
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_STRATEGY", "Invalid increment strategy", err.Error())
+		case errors.Is(err, services.ErrAppNotFound):
+			h.errorResponse(c, http.StatusNotFound, "APP_NOT_FOUND", "App not found", err.Error())
+		default:
+			h.logger.WithError(err).WithField("app_id", appID).Error("Failed to set increment strategy")
+			h.errorResponse(c, http.StatusInternalServerError, "STRATEGY_FAILED", "Failed to set increment strategy", err.Error())
+			middleware.RecordVersionOperation("strategy", appID, "error")
+		}
+		return
+	}
+
+	middleware.RecordVersionOperation("strategy", appID, "success")
+	c.JSON(http.StatusOK, version)
+}
+
+// SetInitialDevelopment godoc
+// @Summary Set application initial development mode
+// @Description Turn pre-1.0 semantics on or off for a semver application. While on and below 1.0.0, major increments bump the minor version (0.4.0 → 0.5.0) and only graduating reaches 1.0.0 (admin only).
+// @Tags version
+// @Accept json
+// @Produce json
+// @Param app-id path string true "Application ID"
+// @Param request body models.SetInitialDevelopmentRequest true "Initial development mode"
+// @Success 200 {object} models.AppVersion
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /version/{app-id}/initial-development [put]
+func (h *Handler) SetInitialDevelopment(c *gin.Context) {
+	appID := c.Param("app-id")
+	if appID == "" {
+		h.errorResponse(c, http.StatusBadRequest, "APP_ID_REQUIRED", "app ID is required", "")
+		return
+	}
+
+	var req models.SetInitialDevelopmentRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	version, err := h.service.SetInitialDevelopment(c.Request.Context(), appID, req.Enabled)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "invalid app ID"):
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_APP_ID", "Invalid app ID format", err.Error())
+		case errors.Is(err, services.ErrUnsupportedScheme):
+			h.errorResponse(c, http.StatusBadRequest, "UNSUPPORTED_SCHEME", "Not supported by the app's version scheme", err.Error())
+		case errors.Is(err, services.ErrAppNotFound):
+			h.errorResponse(c, http.StatusNotFound, "APP_NOT_FOUND", "App not found", err.Error())
+		default:
+			h.logger.WithError(err).WithField("app_id", appID).Error("Failed to set initial development mode")
+			h.errorResponse(c, http.StatusInternalServerError, "INITIAL_DEVELOPMENT_FAILED", "Failed to set initial development mode", err.Error())
+			middleware.RecordVersionOperation("initial_development", appID, "error")
+		}
+		return
+	}
+
+	middleware.RecordVersionOperation("initial_development", appID, "success")
+	c.JSON(http.StatusOK, version)
+}
+
+// SetVersionAlias godoc
+// @Summary Set version alias
+// @Description Point a named alias (e.g. stable, lts) of an application at one of its versions. The version may not be ahead of the current version.
+// @Tags version
+// @Accept json
+// @Produce json
+// @Param app-id path string true "Application ID"
+// @Param name path string true "Alias name"
+// @Param request body models.SetAliasRequest true "Target version"
+// @Success 200 {object} models.VersionAlias
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /version/{app-id}/alias/{name} [put]
+func (h *Handler) SetVersionAlias(c *gin.Context) {
+	appID := c.Param("app-id")
+	if appID == "" {
+		h.errorResponse(c, http.StatusBadRequest, "APP_ID_REQUIRED", "app ID is required", "")
+		return
+	}
+
+	if !h.authorizeApp(c, appID, models.RoleWrite) {
+		return
+	}
+
+	var req models.SetAliasRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	alias, err := h.service.SetVersionAlias(c.Request.Context(), appID, c.Param("name"), req.Version)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "invalid app ID"):
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_APP_ID", "Invalid app ID format", err.Error())
+		case errors.Is(err, services.ErrInvalidAlias):
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_ALIAS", "Invalid alias", err.Error())
+		case errors.Is(err, services.ErrAppNotFound):
+			h.errorResponse(c, http.StatusNotFound, "APP_NOT_FOUND", "App not found", err.Error())
+		default:
+			h.logger.WithError(err).WithField("app_id", appID).Error("Failed to set version alias")
+			h.errorResponse(c, http.StatusInternalServerError, "ALIAS_FAILED", "Failed to set version alias", err.Error())
+			middleware.RecordVersionOperation("alias", appID, "error")
+		}
+		return
+	}
+
+	middleware.RecordVersionOperation("alias", appID, "success")
+	c.JSON(http.StatusOK, alias)
+}
+
+// GetVersionAlias godoc
+// @Summary Resolve version alias
+// @Description Resolve a named alias of an application to the version it points at
+// @Tags version
+// @Produce json
+// @Param app-id path string true "Application ID"
+// @Param name path string true "Alias name"
+// @Param format query string false "Version output format (plain, v, docker)"
+// @Success 200 {object} models.VersionAlias
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /version/{app-id}/alias/{name} [get]
+func (h *Handler) GetVersionAlias(c *gin.Context) {
+	appID := c.Param("app-id")
+	if appID == "" {
+		h.errorResponse(c, http.StatusBadRequest, "APP_ID_REQUIRED", "app ID is required", "")
+		return
+	}
+
+	if !h.authorizeApp(c, appID, models.RoleRead) {
+		return
+	}
+
+	format, ok := h.versionFormat(c)
+	if !ok {
+		return
+	}
+
+	alias, err := h.service.GetVersionAlias(c.Request.Context(), appID, c.Param("name"))
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "invalid app ID"):
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_APP_ID", "Invalid app ID format", err.Error())
+		case errors.Is(err, services.ErrAppNotFound):
+			h.errorResponse(c, http.StatusNotFound, "APP_NOT_FOUND", "App not found", err.Error())
+		case errors.Is(err, services.ErrAliasNotFound):
+			h.errorResponse(c, http.StatusNotFound, "ALIAS_NOT_FOUND", "Alias not found", err.Error())
+		default:
+			h.logger.WithError(err).WithField("app_id", appID).Error("Failed to resolve version alias")
+			h.errorResponse(c, http.StatusInternalServerError, "ALIAS_FAILED", "Failed to resolve version alias", err.Error())
+		}
+		return
+	}
+
+	alias.Version = models.FormatVersion(alias.Version, format)
+	c.JSON(http.StatusOK, alias)
+}
+
+// UpdateVersionMetadata godoc
+// @Summary Update version metadata
+// @Description Merge annotations (e.g. jira_ticket, released_by, changelog_url) into an application's version record. A null value removes the annotation.
+// @Tags version
+// @Accept json
+// @Produce json
+// @Param app-id path string true "Application ID"
+// @Param request body models.UpdateMetadataRequest true "Annotations to set or remove"
+// @Success 200 {object} models.AppVersion
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /version/{app-id}/metadata [patch]
+func (h *Handler) UpdateVersionMetadata(c *gin.Context) {
+	appID := c.Param("app-id")
+	if appID == "" {
+		h.errorResponse(c, http.StatusBadRequest, "APP_ID_REQUIRED", "app ID is required", "")
+		return
+	}
+
+	if !h.authorizeApp(c, appID, models.RoleWrite) {
+		return
+	}
+
+	var req models.UpdateMetadataRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	version, err := h.service.UpdateVersionMetadata(c.Request.Context(), appID, req.Annotations)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "invalid app ID"):
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_APP_ID", "Invalid app ID format", err.Error())
+		case errors.Is(err, services.ErrInvalidMetadata):
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_METADATA", "Invalid metadata", err.Error())
+		case errors.Is(err, services.ErrAppNotFound):
+			h.errorResponse(c, http.StatusNotFound, "APP_NOT_FOUND", "App not found", err.Error())
+		default:
+			h.logger.WithError(err).WithField("app_id", appID).Error("Failed to update version metadata")
+			h.errorResponse(c, http.StatusInternalServerError, "METADATA_FAILED", "Failed to update version metadata", err.Error())
+			middleware.RecordVersionOperation("metadata", appID, "error")
+		}
+		return
+	}
+
+	middleware.RecordVersionOperation("metadata", appID, "success")
+	c.JSON(http.StatusOK, version)
+}
+
+// GetReservedVersions godoc
+// @Summary Get reserved versions
+// @Description List the versions that will never be issued to an application, from its policy and from its project
+// @Tags version
+// @Accept json
+// @Produce json
+// @Param app-id path string true "Application ID"
+// @Success 200 {object} models.ReservedVersions
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /version/{app-id}/reserved [get]
+func (h *Handler) GetReservedVersions(c *gin.Context) {
+	appID := c.Param("app-id")
+	if !h.authorizeApp(c, appID, models.RoleRead) {
+		return
+	}
+
+	reserved, err := h.service.GetReservedVersions(c.Request.Context(), appID)
+	if err != nil {
+		h.reservedVersionsError(c, err, appID)
+		return
+	}
+
+	c.JSON(http.StatusOK, reserved)
+}
+
+// SetReservedVersions godoc
+// @Summary Set reserved versions
+// @Description Replace the versions reserved in an application's policy. Increments, promotions and registrations that would issue a reserved version fail with 409 VERSION_RESERVED.
+// @Tags version
+// @Accept json
+// @Produce json
+// @Param app-id path string true "Application ID"
+// @Param request body models.ReservedVersionsRequest true "Reserved versions; empty clears the list"
+// @Success 200 {object} models.ReservedVersions
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /version/{app-id}/reserved [put]
+func (h *Handler) SetReservedVersions(c *gin.Context) {
+	appID := c.Param("app-id")
+	if !h.authorizeApp(c, appID, models.RoleAdmin) {
+		return
+	}
+
+	var req models.ReservedVersionsRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	reserved, err := h.service.SetReservedVersions(c.Request.Context(), appID, req.Versions)
+	if err != nil {
+		h.reservedVersionsError(c, err, appID)
+		return
+	}
+
+	middleware.RecordVersionOperation("reserve", appID, "success")
+	c.JSON(http.StatusOK, reserved)
+}
+
+// GetProjectReservedVersions godoc
+// @Summary Get project reserved versions
+// @Description List the versions reserved for every application in a project
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param project-id path string true "Project ID"
+// @Success 200 {object} models.ReservedVersions
+// @Failure 500 {object} models.ErrorResponse
+// @Router /projects/{project-id}/reserved [get]
+func (h *Handler) GetProjectReservedVersions(c *gin.Context) {
+	projectID := c.Param("project-id")
+	if !h.authorizeProject(c, projectID, models.RoleRead) {
+		return
+	}
+
+	reserved, err := h.service.GetProjectReservedVersions(c.Request.Context(), projectID)
+	if err != nil {
+		h.reservedVersionsError(c, err, "")
+		return
+	}
+
+	c.JSON(http.StatusOK, reserved)
+}
+
+// SetProjectReservedVersions godoc
+// @Summary Set project reserved versions
+// @Description Replace the versions reserved for every application in a project
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param project-id path string true "Project ID"
+// @Param request body models.ReservedVersionsRequest true "Reserved versions; empty clears the list"
+// @Success 200 {object} models.ReservedVersions
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /projects/{project-id}/reserved [put]
+func (h *Handler) SetProjectReservedVersions(c *gin.Context) {
+	projectID := c.Param("project-id")
+	if !h.authorizeProject(c, projectID, models.RoleAdmin) {
+		return
+	}
+
+	var req models.ReservedVersionsRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	reserved, err := h.service.SetProjectReservedVersions(c.Request.Context(), projectID, req.Versions)
+	if err != nil {
+		h.reservedVersionsError(c, err, "")
+		return
+	}
+
+	c.JSON(http.StatusOK, reserved)
+}
+
+// reservedVersionsError maps errors of the reserved version endpoints
+func (h *Handler) reservedVersionsError(c *gin.Context, err error, appID string) {
+	switch {
+	case strings.Contains(err.Error(), "invalid app ID"):
+		h.errorResponse(c, http.StatusBadRequest, "INVALID_APP_ID", "Invalid app ID format", err.Error())
+	case errors.Is(err, services.ErrInvalidReservedVersions):
+		h.errorResponse(c, http.StatusBadRequest, "INVALID_RESERVED_VERSIONS", "Invalid reserved versions", err.Error())
+	case errors.Is(err, services.ErrAppNotRegistered):
+		h.errorResponse(c, http.StatusNotFound, "APP_NOT_REGISTERED", "App not registered", err.Error())
+	case errors.Is(err, services.ErrAppDeleted):
+		h.errorResponse(c, http.StatusNotFound, "APP_DELETED", "App has been deleted", err.Error())
+	case errors.Is(err, services.ErrAppNotFound):
+		h.errorResponse(c, http.StatusNotFound, "APP_NOT_FOUND", "App not found", err.Error())
+	default:
+		h.logger.WithError(err).WithFields(logrus.Fields{
+			"app_id":     appID,
+			"project_id": c.Param("project-id"),
+		}).Error("Failed to handle reserved versions")
+		h.errorResponse(c, http.StatusInternalServerError, "RESERVED_VERSIONS_FAILED", "Failed to handle reserved versions", err.Error())
+	}
+}
+
+// GetVersionHistory godoc
+// @Summary Get application version history
+// @Description List the versions recorded for an application in Git history, oldest first
+// @Tags version
+// @Accept json
+// @Produce json
+// @Param app-id path string true "Application ID"
+// @Success 200 {array} models.VersionHistoryEntry
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /version/{app-id}/history [get]
+func (h *Handler) GetVersionHistory(c *gin.Context) {
+	appID := c.Param("app-id")
+	if appID == "" {
+		h.errorResponse(c, http.StatusBadRequest, "APP_ID_REQUIRED", "app ID is required", "")
+		return
+	}
+
+	if !h.authorizeApp(c, appID, models.RoleRead) {
+		return
+	}
+
+	history, err := h.service.GetVersionHistory(c.Request.Context(), appID)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "invalid app ID"):
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_APP_ID", "Invalid app ID format", err.Error())
+		case errors.Is(err, services.ErrAppNotFound):
+			h.errorResponse(c, http.StatusNotFound, "APP_NOT_FOUND", "No history recorded for app", err.Error())
+		default:
+			h.logger.WithError(err).WithField("app_id", appID).Error("Failed to get version history")
+			h.errorResponse(c, http.StatusInternalServerError, "HISTORY_FAILED", "Failed to get version history", err.Error())
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// RollbackVersion godoc
+// @Summary Roll back application version
+// @Description Revert an application to its previous version as recorded in Git history
+// @Tags version
+// @Accept json
+// @Produce json
+// @Param app-id path string true "Application ID"
+// @Success 200 {object} models.RollbackResponse
+// @Success 202 {object} models.RollbackResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /version/{app-id}/rollback [post]
+func (h *Handler) RollbackVersion(c *gin.Context) {
+	appID := c.Param("app-id")
+	if appID == "" {
+		h.errorResponse(c, http.StatusBadRequest, "APP_ID_REQUIRED", "app ID is required", "")
+		return
+	}
+
+	if !h.authorizeApp(c, appID, models.RoleWrite) {
+		return
+	}
+
+	response, err := h.service.RollbackVersion(h.writeContext(c), appID)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "invalid app ID"):
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_APP_ID", "Invalid app ID format", err.Error())
+		case errors.Is(err, services.ErrAppNotRegistered):
+			h.errorResponse(c, http.StatusNotFound, "APP_NOT_REGISTERED", "App not registered", err.Error())
+		case errors.Is(err, services.ErrAppNotFound):
+			h.errorResponse(c, http.StatusNotFound, "APP_NOT_FOUND", "App not found", err.Error())
+		case errors.Is(err, services.ErrNoPreviousVersion):
+			h.errorResponse(c, http.StatusConflict, "NO_PREVIOUS_VERSION", "No previous version to roll back to", err.Error())
+		case errors.Is(err, services.ErrVersionLocked):
+			h.errorResponse(c, http.StatusConflict, "VERSION_LOCKED", "Version is locked", err.Error())
+		default:
+			h.logger.WithError(err).WithField("app_id", appID).Error("Failed to roll back version")
+			h.errorResponse(c, http.StatusInternalServerError, "ROLLBACK_FAILED", "Failed to roll back version", err.Error())
+			middleware.RecordVersionOperation("rollback", appID, "error")
+		}
+		return
+	}
+
+	middleware.RecordVersionOperation("rollback", appID, "success")
+	c.JSON(h.writeStatus(c), response)
+}
+
+// DecrementVersion godoc
+// @Summary Undo last increment
+// @Description Reverse the most recent increment of an application, stepping back to the previous version recorded in Git history
+// @Tags version
+// @Accept json
+// @Produce json
+// @Param app-id path string true "Application ID"
+// @Success 200 {object} models.DecrementResponse
+// @Success 202 {object} models.DecrementResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /version/{app-id}/decrement [post]
+func (h *Handler) DecrementVersion(c *gin.Context) {
+	appID := c.Param("app-id")
+	if appID == "" {
+		h.errorResponse(c, http.StatusBadRequest, "APP_ID_REQUIRED", "app ID is required", "")
+		return
+	}
+
+	if !h.authorizeApp(c, appID, models.RoleWrite) {
+		return
+	}
+
+	response, err := h.service.DecrementVersion(h.writeContext(c), appID)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "invalid app ID"):
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_APP_ID", "Invalid app ID format", err.Error())
+		case errors.Is(err, services.ErrAppNotRegistered):
+			h.errorResponse(c, http.StatusNotFound, "APP_NOT_REGISTERED", "App not registered", err.Error())
+		case errors.Is(err, services.ErrAppNotFound):
+			h.errorResponse(c, http.StatusNotFound, "APP_NOT_FOUND", "App not found", err.Error())
+		case errors.Is(err, services.ErrNoPreviousVersion):
+			h.errorResponse(c, http.StatusConflict, "NO_PREVIOUS_VERSION", "No previous version to step back to", err.Error())
+		case errors.Is(err, services.ErrNotAnIncrement):
+			h.errorResponse(c, http.StatusConflict, "NOT_AN_INCREMENT", "Current version is not a single increment of the previous one", err.Error())
+		case errors.Is(err, services.ErrVersionLocked):
+			h.errorResponse(c, http.StatusConflict, "VERSION_LOCKED", "Version is locked", err.Error())
+		default:
+			h.logger.WithError(err).WithField("app_id", appID).Error("Failed to decrement version")
+			h.errorResponse(c, http.StatusInternalServerError, "DECREMENT_FAILED", "Failed to decrement version", err.Error())
+			middleware.RecordVersionOperation("decrement", appID, "error")
+		}
+		return
+	}
+
+	middleware.RecordVersionOperation("decrement", appID, "success")
+	c.JSON(h.writeStatus(c), response)
+}
+
+// RegisterApp godoc
+// @Summary Register an application
+// @Description Explicitly create an application with its project, name, initial version and versioning policy
+// @Tags apps
+// @Accept json
+// @Produce json
+// @Param request body models.RegisterAppRequest true "App registration"
+// @Success 201 {object} models.RegisterAppResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /apps [post]
+func (h *Handler) RegisterApp(c *gin.Context) {
+	var req models.RegisterAppRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	if !h.authorizeProject(c, req.ProjectID, models.RoleWrite) {
+		return
+	}
+
+	response, err := h.service.RegisterApp(c.Request.Context(), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidRegistration):
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_REGISTRATION", "Invalid app registration", err.Error())
+		case errors.Is(err, services.ErrAppExists):
+			h.errorResponse(c, http.StatusConflict, "APP_EXISTS", "App already exists", err.Error())
+		case errors.Is(err, services.ErrVersionReserved):
+			h.errorResponse(c, http.StatusConflict, "VERSION_RESERVED", "Initial version is reserved", err.Error())
+		case errors.Is(err, services.ErrQuotaExceeded):
+			h.errorResponse(c, http.StatusTooManyRequests, "QUOTA_EXCEEDED", "Project quota exceeded", err.Error())
+		default:
+			h.logger.WithError(err).WithFields(logrus.Fields{
+				"project_id": req.ProjectID,
+				"app_name":   req.AppName,
+			}).Error("Failed to register app")
+			h.errorResponse(c, http.StatusInternalServerError, "REGISTRATION_FAILED", "Failed to register app", err.Error())
+		}
+		return
+	}
+
+	middleware.RecordVersionOperation("register", response.AppID, "success")
+	c.JSON(http.StatusCreated, response)
+}
+
+// ListVersions godoc
+// @Summary List all versions
+// @Description Get a list of all application versions
+// @Tags version
+// @Accept json
+// @Produce json
+// @Param repo query string false "Filter by repo name (case-insensitive substring)"
+// @Param lifecycle query string false "Filter by comma-separated lifecycle states (active, frozen, deprecated, archived)"
+// @Param limit query int false "Page size (1-1000); returns a models.VersionPage instead of a map"
+// @Param cursor query string false "Cursor from the previous page's next_cursor"
+// @Param format query string false "Version output format (plain, v, docker)"
+// @Param X-Canary-Verify header bool false "Verify the response against Git in the background"
+// @Success 200 {object} map[string]models.AppVersion
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /versions [get]
+func (h *Handler) ListVersions(c *gin.Context) {
+	if !h.authorizeProject(c, models.ProjectWildcard, models.RoleRead) {
+		return
+	}
+
+	filter, ok := h.versionFilter(c)
+	if !ok {
+		return
+	}
+	format, ok := h.versionFormat(c)
+	if !ok {
+		return
+	}
+
+	if paged(c) {
+		h.listVersionsPage(c, filter, format)
+		return
+	}
+
+	versions, err := h.service.ListVersions(h.readContext(c))
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list versions")
+		h.errorResponse(c, http.StatusInternalServerError, "LIST_FAILED", "Failed to list versions", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, models.FormatVersions(filter.Apply(versions), format))
+}
+
+// ListVersionsByProject godoc
+// @Summary List versions by project
+// @Description Get a list of versions for a specific project
+// @Tags version
+// @Accept json
+// @Produce json
+// @Param project-id path string true "Project ID"
+// @Param repo query string false "Filter by repo name (case-insensitive substring)"
+// @Param lifecycle query string false "Filter by comma-separated lifecycle states (active, frozen, deprecated, archived)"
+// @Param limit query int false "Page size (1-1000); returns a models.VersionPage instead of a map"
+// @Param cursor query string false "Cursor from the previous page's next_cursor"
+// @Param format query string false "Version output format (plain, v, docker)"
+// @Param X-Canary-Verify header bool false "Verify the response against Git in the background"
+// @Success 200 {object} map[string]models.AppVersion
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /versions/{project-id} [get]
+func (h *Handler) ListVersionsByProject(c *gin.Context) {
+	projectID := c.Param("project-id")
+	if projectID == "" {
+		h.errorResponse(c, http.StatusBadRequest, "PROJECT_ID_REQUIRED", "project ID is required", "")
+		return
+	}
+
+	if !h.authorizeProject(c, projectID, models.RoleRead) {
+		return
+	}
+
+	filter, ok := h.versionFilter(c)
+	if !ok {
+		return
+	}
+	format, ok := h.versionFormat(c)
+	if !ok {
+		return
+	}
+
+	if paged(c) {
+		filter.ProjectID = projectID
+		h.listVersionsPage(c, filter, format)
+		return
+	}
+
+	versions, err := h.service.ListVersionsByProject(h.readContext(c), projectID)
+	if err != nil {
+		h.logger.WithError(err).WithField("project_id", projectID).Error("Failed to list versions by project")
+		h.errorResponse(c, http.StatusInternalServerError, "LIST_FAILED", "Failed to list versions", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, models.FormatVersions(filter.Apply(versions), format))
+}
+
+// defaultStaleDays is the stale threshold when none is given
+const defaultStaleDays = 90
+
+// ListStaleVersions godoc
+// @Summary List stale applications
+// @Description List applications whose version has not changed for more than the given number of days, least recently updated first
+// @Tags version
+// @Accept json
+// @Produce json
+// @Param days query int false "Days without an update (default 90)"
+// @Success 200 {object} models.StaleReport
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /versions/stale [get]
+func (h *Handler) ListStaleVersions(c *gin.Context) {
+	if !h.authorizeProject(c, models.ProjectWildcard, models.RoleRead) {
+		return
+	}
+
+	days := defaultStaleDays
+	if raw := c.Query("days"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_DAYS", "Invalid stale threshold", "days must be a positive integer")
+			return
+		}
+		days = parsed
+	}
+
+	report, err := h.service.ListStaleVersions(c.Request.Context(), days)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidStaleThreshold) {
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_DAYS", "Invalid stale threshold", err.Error())
+			return
+		}
+		h.logger.WithError(err).Error("Failed to list stale versions")
+		h.errorResponse(c, http.StatusInternalServerError, "LIST_FAILED", "Failed to list stale versions", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// DeleteVersion godoc
+// @Summary Delete application version
+// @Description Delete a specific application or entire project. Deleted apps leave a tombstone and can be restored.
+// @Tags version
+// @Accept json
+// @Produce json
+// @Param id path string true "Application ID (project-id-app-name) or Project ID (project-id)"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /delete/{id} [delete]
+func (h *Handler) DeleteVersion(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		h.errorResponse(c, http.StatusBadRequest, "ID_REQUIRED", "ID is required", "")
+		return
+	}
+
+	// Check if this is a project ID (no dash-separated app name) or app ID
+	if strings.Contains(id, "-") && len(strings.Split(id, "-")) >= 2 {
+		// This looks like an app ID (project-id-app-name)
+		if !h.authorizeApp(c, id, models.RoleAdmin) {
+			return
+		}
+
+		_, err := h.service.GetVersion(c.Request.Context(), id)
+		if err != nil {
+			if strings.Contains(err.Error(), "invalid app ID") {
+				h.errorResponse(c, http.StatusBadRequest, "INVALID_APP_ID", "Invalid app ID format", err.Error())
+				return
+			}
+			// If GetVersion fails, it might not exist, but we'll try to delete anyway
+		}
+
+		if err := h.service.DeleteVersion(c.Request.Context(), id); err != nil {
+			if errors.Is(err, services.ErrAppNotFound) || errors.Is(err, services.ErrAppNotRegistered) {
+				h.errorResponse(c, http.StatusNotFound, "APP_NOT_FOUND", "App not found", err.Error())
+				return
+			}
+			h.logger.WithError(err).WithField("app_id", id).Error("Failed to delete version")
+			h.errorResponse(c, http.StatusInternalServerError, "DELETE_FAILED", "Failed to delete version", err.Error())
+			middleware.RecordVersionOperation("delete", id, "error")
+			return
+		}
+
+		h.logger.WithField("app_id", id).Info("Version deleted successfully")
+		middleware.RecordVersionOperation("delete", id, "success")
+		c.JSON(http.StatusOK, map[string]string{
+			"message": "Version deleted successfully",
+			"app_id":  id,
+		})
+	} else {
+		// This looks like a project ID only
+		projectID := id
+		if !h.authorizeProject(c, projectID, models.RoleAdmin) {
+			return
+		}
+
+		if err := h.service.DeleteProject(c.Request.Context(), projectID); err != nil {
+			h.logger.WithError(err).WithField("project_id", projectID).Error("Failed to delete project")
+			h.errorResponse(c, http.StatusInternalServerError, "DELETE_FAILED", "Failed to delete project", err.Error())
+			return
+		}
+
+		h.logger.WithField("project_id", projectID).Info("Project deleted successfully")
+		c.JSON(http.StatusOK, map[string]string{
+			"message":    "Project deleted successfully",
+			"project_id": projectID,
+		})
+	}
+}
+
+// RestoreVersion godoc
+// @Summary Restore deleted application
+// @Description Bring back a deleted application from its tombstone, with the version, aliases and annotations it had when deleted
+// @Tags version
+// @Accept json
+// @Produce json
+// @Param app-id path string true "Application ID"
+// @Success 200 {object} models.AppVersion
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /version/{app-id}/restore [post]
+func (h *Handler) RestoreVersion(c *gin.Context) {
+	appID := c.Param("app-id")
+	if appID == "" {
+		h.errorResponse(c, http.StatusBadRequest, "APP_ID_REQUIRED", "app ID is required", "")
+		return
+	}
+
+	if !h.authorizeApp(c, appID, models.RoleAdmin) {
+		return
+	}
+
+	version, err := h.service.RestoreVersion(c.Request.Context(), appID)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "invalid app ID"):
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_APP_ID", "Invalid app ID format", err.Error())
+		case errors.Is(err, services.ErrAppNotRegistered):
+			h.errorResponse(c, http.StatusNotFound, "APP_NOT_REGISTERED", "App not registered", err.Error())
+		case errors.Is(err, services.ErrAppNotFound):
+			h.errorResponse(c, http.StatusNotFound, "APP_NOT_FOUND", "App not found", err.Error())
+		case errors.Is(err, services.ErrAppNotDeleted):
+			h.errorResponse(c, http.StatusConflict, "NOT_DELETED", "App is not deleted", err.Error())
+		case errors.Is(err, services.ErrQuotaExceeded):
+			h.errorResponse(c, http.StatusTooManyRequests, "QUOTA_EXCEEDED", "Project quota exceeded", err.Error())
+		default:
+			h.logger.WithError(err).WithField("app_id", appID).Error("Failed to restore version")
+			h.errorResponse(c, http.StatusInternalServerError, "RESTORE_FAILED", "Failed to restore version", err.Error())
+			middleware.RecordVersionOperation("restore", appID, "error")
+		}
+		return
+	}
+
+	middleware.RecordVersionOperation("restore", appID, "success")
+	c.JSON(http.StatusOK, version)
+}
+
+// RenameVersion godoc
+// @Summary Rename application
+// @Description Move an application's version record to a new app ID. The old ID is recorded in renamed_from so history and rollbacks follow the app across the rename.
+// @Tags version
+// @Accept json
+// @Produce json
+// @Param app-id path string true "Application ID"
+// @Param request body models.RenameRequest true "New app ID"
+// @Success 200 {object} models.RenameResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /version/{app-id}/rename [post]
+func (h *Handler) RenameVersion(c *gin.Context) {
+	appID := c.Param("app-id")
+	if appID == "" {
+		h.errorResponse(c, http.StatusBadRequest, "APP_ID_REQUIRED", "app ID is required", "")
+		return
+	}
+
+	if !h.authorizeApp(c, appID, models.RoleAdmin) {
+		return
+	}
+
+	var req models.RenameRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	// Moving an app into another project needs the admin role there too
+	if !h.authorizeApp(c, req.NewAppID, models.RoleAdmin) {
+		return
+	}
+
+	response, err := h.service.RenameVersion(c.Request.Context(), appID, req.NewAppID)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "invalid app ID"):
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_APP_ID", "Invalid app ID format", err.Error())
+		case errors.Is(err, services.ErrInvalidRename):
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_RENAME", "Invalid rename", err.Error())
+		case errors.Is(err, services.ErrAppNotRegistered):
+			h.errorResponse(c, http.StatusNotFound, "APP_NOT_REGISTERED", "App not registered", err.Error())
+		case errors.Is(err, services.ErrAppDeleted):
+			h.errorResponse(c, http.StatusNotFound, "APP_DELETED", "App has been deleted", err.Error())
+		case errors.Is(err, services.ErrAppNotFound):
+			h.errorResponse(c, http.StatusNotFound, "APP_NOT_FOUND", "App not found", err.Error())
+		case errors.Is(err, services.ErrAppExists):
+			h.errorResponse(c, http.StatusConflict, "APP_EXISTS", "Target app ID already exists", err.Error())
+		case errors.Is(err, services.ErrVersionLocked):
+			h.errorResponse(c, http.StatusConflict, "VERSION_LOCKED", "Version is locked", err.Error())
+		case errors.Is(err, services.ErrQuotaExceeded):
+			h.errorResponse(c, http.StatusTooManyRequests, "QUOTA_EXCEEDED", "Project quota exceeded", err.Error())
+		default:
+			h.logger.WithError(err).WithFields(logrus.Fields{
+				"app_id":     appID,
+				"new_app_id": req.NewAppID,
+			}).Error("Failed to rename app")
+			h.errorResponse(c, http.StatusInternalServerError, "RENAME_FAILED", "Failed to rename app", err.Error())
+			middleware.RecordVersionOperation("rename", appID, "error")
+		}
+		return
+	}
+
+	middleware.RecordVersionOperation("rename", appID, "success")
+	c.JSON(http.StatusOK, response)
+}
+
+// ListSchemas godoc
+// @Summary List event schemas
+// @Description List the JSON Schemas published for emitted events and webhook payloads, with their versions
+// @Tags schemas
+// @Produce json
+// @Success 200 {array} models.SchemaInfo
+// @Failure 500 {object} models.ErrorResponse
+// @Router /schemas [get]
+func (h *Handler) ListSchemas(c *gin.Context) {
+	schemas, err := models.ListSchemas()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list schemas")
+		h.errorResponse(c, http.StatusInternalServerError, "SCHEMAS_FAILED", "Failed to list schemas", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, schemas)
+}
+
+// GetSchema godoc
+// @Summary Get an event schema
+// @Description Return the JSON Schema for an event; without a version, the version currently emitted
+// @Tags schemas
+// @Produce json
+// @Param event path string true "Event name"
+// @Param version path int false "Schema version"
+// @Success 200 {object} object
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /schemas/{event}/{version} [get]
+func (h *Handler) GetSchema(c *gin.Context) {
+	event := c.Param("event")
+
+	version := 0
+	if value := c.Param("version"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_SCHEMA_VERSION", "Schema version must be a positive integer", value)
+			return
+		}
+		version = parsed
+	}
+
+	schema, ok := models.GetSchema(event, version)
+	if !ok {
+		h.errorResponse(c, http.StatusNotFound, "SCHEMA_NOT_FOUND", "Schema not found", event)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/schema+json", schema)
+}
+
+// GetFreshnessReport godoc
+// @Summary Get write freshness
+// @Description Report the lag between Redis writes and confirmed Git pushes per app, the worst current lag and the freshness SLO burn rates
+// @Tags health
+// @Produce json
+// @Success 200 {object} models.FreshnessReport
+// @Failure 500 {object} models.ErrorResponse
+// @Router /freshness [get]
+func (h *Handler) GetFreshnessReport(c *gin.Context) {
+	report, err := h.service.GetFreshnessReport(c.Request.Context())
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to build freshness report")
+		h.errorResponse(c, http.StatusInternalServerError, "FRESHNESS_FAILED", "Failed to build freshness report", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetRuntimeStats godoc
+// @Summary Get runtime counters
+// @Description Return the Git, Redis and GitLab operation counters since startup, for consumers without Prometheus
+// @Tags health
+// @Produce json
+// @Success 200 {object} models.RuntimeStats
+// @Failure 500 {object} models.ErrorResponse
+// @Router /stats/runtime [get]
+func (h *Handler) GetRuntimeStats(c *gin.Context) {
+	stats, err := h.service.RuntimeStats(c.Request.Context())
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to collect runtime stats")
+		h.errorResponse(c, http.StatusInternalServerError, "STATS_FAILED", "Failed to collect runtime stats", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetDiscoveryReport godoc
+// @Summary Get the last GitLab discovery report
+// @Description Return the projects registered and still unregistered by the most recent GitLab discovery run
+// @Tags discovery
+// @Produce json
+// @Success 200 {object} models.DiscoveryReport
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /discovery [get]
+func (h *Handler) GetDiscoveryReport(c *gin.Context) {
+	if !h.authorizeProject(c, models.ProjectWildcard, models.RoleRead) {
+		return
+	}
+
+	report, err := h.service.GetDiscoveryReport(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, services.ErrDiscoveryDisabled) {
+			h.errorResponse(c, http.StatusNotFound, "DISCOVERY_DISABLED", "GitLab discovery is not configured", "")
+			return
+		}
+		h.logger.WithError(err).Error("Failed to get discovery report")
+		h.errorResponse(c, http.StatusInternalServerError, "DISCOVERY_FAILED", "Failed to get discovery report", err.Error())
+		return
+	}
+
+	if report == nil {
+		h.errorResponse(c, http.StatusNotFound, "NO_DISCOVERY_REPORT", "No discovery run has completed yet", "")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// RunDiscovery godoc
+// @Summary Run GitLab discovery
+// @Description Enumerate projects in the configured GitLab groups and pre-register missing apps (admin only)
+// @Tags discovery
+// @Produce json
+// @Success 200 {object} models.DiscoveryReport
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /discovery/run [post]
+func (h *Handler) RunDiscovery(c *gin.Context) {
+	report, err := h.service.RunDiscovery(c.Request.Context())
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrDiscoveryDisabled):
+			h.errorResponse(c, http.StatusNotFound, "DISCOVERY_DISABLED", "GitLab discovery is not configured", "")
+		case errors.Is(err, services.ErrDiscoveryRunning):
+			h.errorResponse(c, http.StatusConflict, "DISCOVERY_RUNNING", "A discovery run is already in progress", "")
+		default:
+			h.logger.WithError(err).Error("GitLab discovery failed")
+			h.errorResponse(c, http.StatusInternalServerError, "DISCOVERY_FAILED", "GitLab discovery failed", err.Error())
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// PurgeCache godoc
+// @Summary Purge cached responses
+// @Description Drop cached GET responses tagged with the given surrogate keys (app:{app-id}, project:{project-id}, versions), or every cached response when no keys are given (admin only). Purges are forwarded to CACHE_PURGE_WEBHOOK_URL.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body models.CachePurgeRequest false "Surrogate keys to purge"
+// @Success 200 {object} models.CachePurgeResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/cache/purge [post]
+func (h *Handler) PurgeCache(c *gin.Context) {
+	if !h.cache.Enabled() {
+		h.errorResponse(c, http.StatusNotFound, "CACHE_DISABLED", "Response caching is not enabled", "")
+		return
+	}
+
+	var req models.CachePurgeRequest
+	if c.Request.ContentLength != 0 {
+		if !h.bindJSON(c, &req) {
+			return
+		}
+	}
+
+	if len(req.Keys) == 0 {
+		purged := h.cache.PurgeAll()
+		h.logger.WithField("purged", purged).Info("Response cache purged")
+		c.JSON(http.StatusOK, models.CachePurgeResponse{Purged: purged, All: true})
+		return
+	}
+
+	purged := h.cache.Purge(req.Keys...)
+	h.logger.WithFields(logrus.Fields{
+		"purged":         purged,
+		"surrogate_keys": req.Keys,
+	}).Info("Response cache purged")
+	c.JSON(http.StatusOK, models.CachePurgeResponse{Purged: purged, Keys: req.Keys})
+}
+
+// RebuildCache godoc
+// @Summary Rebuild the Redis cache
+// @Description Replace the Redis cache with the versions in Git, as on startup, after writes queued for Git have landed (admin only). Versions Redis holds newer than Git are written back to Git instead of being rolled back.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.CacheRebuildReport
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/cache/rebuild [post]
+func (h *Handler) RebuildCache(c *gin.Context) {
+	report, err := h.service.RebuildCache(c.Request.Context())
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to rebuild cache")
+		h.errorResponse(c, http.StatusInternalServerError, "CACHE_REBUILD_FAILED", "Failed to rebuild cache", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// ExportState godoc
+// @Summary Export service state
+// @Description Download a bundle of the complete service state: versions including tombstones, per-app Git history, project reservations and webhook subscriptions with secrets (admin only)
+// @Tags admin
+// @Produce json
+// @Param history query bool false "Include Git history (default true)"
+// @Success 200 {object} models.StateBundle
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/state [get]
+func (h *Handler) ExportState(c *gin.Context) {
+	includeHistory := true
+	if raw := c.Query("history"); raw != "" {
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_PARAMETER", "Invalid history parameter", "history must be true or false")
+			return
+		}
+		includeHistory = value
+	}
+
+	bundle, err := h.service.ExportState(c.Request.Context(), includeHistory)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to export service state")
+		h.errorResponse(c, http.StatusInternalServerError, "STATE_EXPORT_FAILED", "Failed to export service state", err.Error())
+		return
+	}
+
+	if bundle.Revision != "" {
+		c.Header("X-Git-Revision", bundle.Revision)
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="version-service-state-%s.json"`, bundle.ExportedAt.UTC().Format("20060102T150405Z")))
+	c.JSON(http.StatusOK, bundle)
+}
+
+// ImportState godoc
+// @Summary Import service state
+// @Description Restore a state bundle (admin only). On an empty versions branch the bundle's history is replayed; otherwise the versions file is replaced in one commit. Reservations in the bundle replace existing ones, webhook subscriptions are added.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body models.StateBundle true "State bundle from GET /admin/state"
+// @Success 200 {object} models.StateImportReport
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/state [put]
+func (h *Handler) ImportState(c *gin.Context) {
+	var bundle models.StateBundle
+	if !h.bindJSON(c, &bundle) {
+		return
+	}
+
+	report, err := h.service.ImportState(c.Request.Context(), &bundle)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidStateBundle) {
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_STATE_BUNDLE", "State bundle failed validation", err.Error())
+			return
 		}
+		h.logger.WithError(err).Error("Failed to import service state")
+		h.errorResponse(c, http.StatusInternalServerError, "STATE_IMPORT_FAILED", "Failed to import service state", err.Error())
+		return
+	}
 
-		if err := h.service.DeleteVersion(c.Request.Context(), id); err != nil {
-			h.logger.WithError(err).WithField("app_id", id).Error("Failed to delete version")
-			h.errorResponse(c, http.StatusInternalServerError, "DELETE_FAILED", "Failed to delete version", err.Error())
-			middleware.RecordVersionOperation("delete", id, "error")
+	if report.Revision != "" {
+		c.Header("X-Git-Revision", report.Revision)
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// MigrateProjects godoc
+// @Summary Migrate projects
+// @Description Remap project IDs, e.g. after a GitLab instance move (admin only). Apps of the old projects move to the app IDs of the new ones in a single commit, the Redis cache is rebuilt and project reservations and webhooks follow. With dry_run the moves and conflicts are only reported.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body models.ProjectMigrationRequest true "Old to new project IDs"
+// @Success 200 {object} models.ProjectMigrationReport
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/projects/migrate [post]
+func (h *Handler) MigrateProjects(c *gin.Context) {
+	var req models.ProjectMigrationRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	report, err := h.service.MigrateProjects(c.Request.Context(), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidMigration):
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_MIGRATION", "Invalid project migration", err.Error())
+		case errors.Is(err, services.ErrMigrationConflict):
+			h.errorResponse(c, http.StatusConflict, "MIGRATION_CONFLICT", "Migration would give apps the same ID", err.Error())
+		case errors.Is(err, services.ErrRevisionConflict):
+			h.errorResponse(c, http.StatusConflict, "REVISION_CONFLICT", "Versions file changed during the migration", err.Error())
+		default:
+			h.logger.WithError(err).Error("Failed to migrate projects")
+			h.errorResponse(c, http.StatusInternalServerError, "MIGRATION_FAILED", "Failed to migrate projects", err.Error())
+		}
+		return
+	}
+
+	if report.Revision != "" {
+		c.Header("X-Git-Revision", report.Revision)
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// ListDeferredCalls godoc
+// @Summary List deferred outbound calls
+// @Description List the GitLab lookups and webhook deliveries queued in the outbox while air-gapped, oldest first, without secrets or payloads (admin only)
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.OutboxResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/outbox [get]
+func (h *Handler) ListDeferredCalls(c *gin.Context) {
+	response, err := h.service.ListDeferredCalls(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, services.ErrAirGapDisabled) {
+			h.errorResponse(c, http.StatusNotFound, "AIR_GAP_DISABLED", "Air-gapped mode is not enabled", "")
 			return
 		}
+		h.logger.WithError(err).Error("Failed to list deferred calls")
+		h.errorResponse(c, http.StatusInternalServerError, "OUTBOX_FAILED", "Failed to list deferred calls", err.Error())
+		return
+	}
 
-		h.logger.WithField("app_id", id).Info("Version deleted successfully")
-		middleware.RecordVersionOperation("delete", id, "success")
-		c.JSON(http.StatusOK, map[string]string{
-			"message": "Version deleted successfully",
-			"app_id":  id,
-		})
-	} else {
-		// This looks like a project ID only
-		projectID := id
+	c.JSON(http.StatusOK, response)
+}
 
-		if err := h.service.DeleteProject(c.Request.Context(), projectID); err != nil {
-			h.logger.WithError(err).WithField("project_id", projectID).Error("Failed to delete project")
-			h.errorResponse(c, http.StatusInternalServerError, "DELETE_FAILED", "Failed to delete project", err.Error())
+// FlushDeferredCalls godoc
+// @Summary Flush deferred outbound calls
+// @Description Make the calls queued in the outbox once the service is connected again (admin only). Failed calls stay queued for the next flush.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.FlushReport
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/outbox/flush [post]
+func (h *Handler) FlushDeferredCalls(c *gin.Context) {
+	report, err := h.service.FlushDeferredCalls(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, services.ErrAirGapDisabled) {
+			h.errorResponse(c, http.StatusNotFound, "AIR_GAP_DISABLED", "Air-gapped mode is not enabled", "")
+			return
+		}
+		h.logger.WithError(err).Error("Failed to flush deferred calls")
+		h.errorResponse(c, http.StatusInternalServerError, "OUTBOX_FLUSH_FAILED", "Failed to flush deferred calls", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetProjectUsage godoc
+// @Summary Get project usage
+// @Description Summarize app count and increment activity for a project against its quotas
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param project-id path string true "Project ID"
+// @Param windows query string false "Comma-separated reporting windows (e.g. 1h,24h,7d)"
+// @Success 200 {object} models.ProjectUsage
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /projects/{project-id}/usage [get]
+func (h *Handler) GetProjectUsage(c *gin.Context) {
+	projectID := c.Param("project-id")
+	if projectID == "" {
+		h.errorResponse(c, http.StatusBadRequest, "PROJECT_ID_REQUIRED", "project ID is required", "")
+		return
+	}
+
+	if !h.authorizeProject(c, projectID, models.RoleRead) {
+		return
+	}
+
+	windows, err := services.ParseUsageWindows(c.Query("windows"))
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "INVALID_WINDOWS", "Invalid usage windows", err.Error())
+		return
+	}
+
+	usage, err := h.service.GetProjectUsage(c.Request.Context(), projectID, windows)
+	if err != nil {
+		h.logger.WithError(err).WithField("project_id", projectID).Error("Failed to get project usage")
+		h.errorResponse(c, http.StatusInternalServerError, "USAGE_FAILED", "Failed to get project usage", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
+
+// SimulateProject godoc
+// @Summary Simulate a release train
+// @Description Compute the versions proposed bumps would give a project's apps and the policies and constraints they would break, without writing anything
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param project-id path string true "Project ID"
+// @Param request body models.SimulationRequest true "Increment type per app ID"
+// @Success 200 {object} models.SimulationReport
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /projects/{project-id}/simulate [post]
+func (h *Handler) SimulateProject(c *gin.Context) {
+	projectID := c.Param("project-id")
+	if projectID == "" {
+		h.errorResponse(c, http.StatusBadRequest, "PROJECT_ID_REQUIRED", "project ID is required", "")
+		return
+	}
+
+	if !h.authorizeProject(c, projectID, models.RoleRead) {
+		return
+	}
+
+	var req models.SimulationRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	bumps := make(map[string]models.IncrementType, len(req.Bumps))
+	for appID, value := range req.Bumps {
+		incrementType, ok := h.validateIncrementType(c, string(value))
+		if !ok {
 			return
 		}
+		bumps[appID] = incrementType
+	}
+
+	report, err := h.service.SimulateProject(c.Request.Context(), projectID, bumps)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidBatch):
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_BATCH", "Invalid simulation request", err.Error())
+		default:
+			h.logger.WithError(err).WithField("project_id", projectID).Error("Failed to simulate project bumps")
+			h.errorResponse(c, http.StatusInternalServerError, "SIMULATION_FAILED", "Failed to simulate bumps", err.Error())
+		}
+		return
+	}
 
-		h.logger.WithField("project_id", projectID).Info("Project deleted successfully")
-		c.JSON(http.StatusOK, map[string]string{
-			"message":    "Project deleted successfully",
-			"project_id": projectID,
-		})
+	c.JSON(http.StatusOK, report)
+}
+
+// ListWebhooks godoc
+// @Summary List project webhooks
+// @Description List a project's webhook subscriptions. Secrets are never returned.
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param project-id path string true "Project ID"
+// @Success 200 {object} models.WebhookListResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /projects/{project-id}/webhooks [get]
+func (h *Handler) ListWebhooks(c *gin.Context) {
+	projectID := c.Param("project-id")
+	if !h.authorizeProject(c, projectID, models.RoleRead) {
+		return
+	}
+
+	response, err := h.service.ListWebhooks(c.Request.Context(), projectID)
+	if err != nil {
+		h.logger.WithError(err).WithField("project_id", projectID).Error("Failed to list webhooks")
+		h.errorResponse(c, http.StatusInternalServerError, "WEBHOOK_LIST_FAILED", "Failed to list webhooks", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateWebhook godoc
+// @Summary Subscribe a webhook
+// @Description Register a webhook for a project's events. Deliveries are signed with the secret in the X-Webhook-Signature header.
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param project-id path string true "Project ID"
+// @Param request body models.CreateWebhookRequest true "Webhook subscription"
+// @Success 201 {object} models.WebhookSubscription
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /projects/{project-id}/webhooks [post]
+func (h *Handler) CreateWebhook(c *gin.Context) {
+	projectID := c.Param("project-id")
+	if !h.authorizeProject(c, projectID, models.RoleAdmin) {
+		return
+	}
+
+	var req models.CreateWebhookRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	webhook, err := h.service.CreateWebhook(c.Request.Context(), projectID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidWebhook) {
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_WEBHOOK", "Invalid webhook subscription", err.Error())
+			return
+		}
+		h.logger.WithError(err).WithField("project_id", projectID).Error("Failed to create webhook")
+		h.errorResponse(c, http.StatusInternalServerError, "WEBHOOK_CREATE_FAILED", "Failed to create webhook", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, webhook)
+}
+
+// DeleteWebhook godoc
+// @Summary Unsubscribe a webhook
+// @Description Delete one of a project's webhook subscriptions
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param project-id path string true "Project ID"
+// @Param webhook-id path string true "Webhook ID"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /projects/{project-id}/webhooks/{webhook-id} [delete]
+func (h *Handler) DeleteWebhook(c *gin.Context) {
+	projectID := c.Param("project-id")
+	webhookID := c.Param("webhook-id")
+	if !h.authorizeProject(c, projectID, models.RoleAdmin) {
+		return
+	}
+
+	if err := h.service.DeleteWebhook(c.Request.Context(), projectID, webhookID); err != nil {
+		if errors.Is(err, services.ErrWebhookNotFound) {
+			h.errorResponse(c, http.StatusNotFound, "WEBHOOK_NOT_FOUND", "Webhook subscription not found", err.Error())
+			return
+		}
+		h.logger.WithError(err).WithField("project_id", projectID).Error("Failed to delete webhook")
+		h.errorResponse(c, http.StatusInternalServerError, "WEBHOOK_DELETE_FAILED", "Failed to delete webhook", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]string{
+		"message":    "Webhook subscription deleted",
+		"webhook_id": webhookID,
+	})
+}
+
+// paged reports whether a listing request asks for a page rather than the
+// full map
+func paged(c *gin.Context) bool {
+	_, hasLimit := c.GetQuery("limit")
+	_, hasCursor := c.GetQuery("cursor")
+	return hasLimit || hasCursor
+}
+
+// listVersionsPage answers a listing request with one page of versions
+func (h *Handler) listVersionsPage(c *gin.Context, filter models.VersionFilter, format string) {
+	limit := services.DefaultPageSize
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_PAGE", "Invalid page request", "limit must be an integer")
+			return
+		}
+		limit = parsed
+	}
+
+	page, err := h.service.ListVersionsPage(c.Request.Context(), c.Query("cursor"), limit, filter)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidPage) {
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_PAGE", "Invalid page request", err.Error())
+			return
+		}
+		h.logger.WithError(err).Error("Failed to list versions page")
+		h.errorResponse(c, http.StatusInternalServerError, "LIST_FAILED", "Failed to list versions", err.Error())
+		return
+	}
+
+	page.Versions = models.FormatVersions(page.Versions, format)
+	c.JSON(http.StatusOK, page)
+}
+
+// versionFilter builds a listing filter from query parameters. It answers
+// 400 and reports false when they are invalid.
+func (h *Handler) versionFilter(c *gin.Context) (models.VersionFilter, bool) {
+	filter := models.VersionFilter{
+		RepoName:  c.Query("repo"),
+		Lifecycle: c.Query("lifecycle"),
+	}
+
+	if filter.Lifecycle != "" {
+		for _, state := range strings.Split(filter.Lifecycle, ",") {
+			if !models.IsLifecycleState(state) || state == models.LifecycleDeleted {
+				h.errorResponse(c, http.StatusBadRequest, "INVALID_PARAMETER", "Invalid lifecycle filter",
+					fmt.Sprintf("unknown lifecycle state %q; use active, frozen, deprecated or archived", state))
+				return filter, false
+			}
+		}
+	}
+
+	return filter, true
+}
+
+// versionFormat reads the format query parameter. It answers 400 and
+// reports false for unknown formats.
+func (h *Handler) versionFormat(c *gin.Context) (string, bool) {
+	format := c.Query("format")
+	if format != "" && !models.IsVersionFormat(format) {
+		h.errorResponse(c, http.StatusBadRequest, "INVALID_FORMAT", "Invalid version format",
+			fmt.Sprintf("unknown format %q; use plain, v or docker", format))
+		return "", false
+	}
+	return format, true
+}
+
+// readContext returns the request context, marked for canary verification
+// against Git when the request carries X-Canary-Verify: true
+func (h *Handler) readContext(c *gin.Context) context.Context {
+	if verify, _ := strconv.ParseBool(c.GetHeader("X-Canary-Verify")); verify {
+		return services.WithReadVerification(c.Request.Context())
+	}
+	return c.Request.Context()
+}
+
+// parseIdempotencyKey reads the Idempotency-Key header, falling back to the
+// idempotency_key field of an optional JSON body
+func (h *Handler) parseIdempotencyKey(c *gin.Context) (string, bool) {
+	key := c.GetHeader("Idempotency-Key")
+	if key == "" && c.Request.ContentLength != 0 {
+		var req models.IncrementRequest
+		if !h.bindJSON(c, &req) {
+			return "", false
+		}
+		key = req.IdempotencyKey
+	}
+
+	if len(key) > services.MaxIdempotencyKeyLength {
+		h.errorResponse(c, http.StatusBadRequest, "INVALID_IDEMPOTENCY_KEY",
+			fmt.Sprintf("Idempotency key must be at most %d characters", services.MaxIdempotencyKeyLength), "")
+		return "", false
+	}
+
+	return key, true
+}
+
+// parseIncrementType reads the "type" query parameter, defaulting to patch.
+// It writes a 400 response and returns false for unknown types.
+func (h *Handler) parseIncrementType(c *gin.Context) (models.IncrementType, bool) {
+	return h.validateIncrementType(c, c.Query("type"))
+}
+
+func (h *Handler) validateIncrementType(c *gin.Context, value string) (models.IncrementType, bool) {
+	switch value {
+	case "":
+		return models.IncrementTypeDefault, true
+	case "patch":
+		return models.IncrementTypePatch, true
+	case "minor":
+		return models.IncrementTypeMinor, true
+	case "major":
+		return models.IncrementTypeMajor, true
+	case "rc":
+		return models.IncrementTypeRC, true
+	case "build":
+		return models.IncrementTypeBuild, true
+	default:
+		h.errorResponse(c, http.StatusBadRequest, "INVALID_INCREMENT_TYPE", "Invalid increment type", "Valid types: major, minor, patch, rc, build")
+		return "", false
+	}
+}
+
+// GetRawVersionsFile godoc
+// @Summary Get raw versions file
+// @Description Return the exact versions.json content stored in Git, with its revision in the X-Git-Revision header
+// @Tags versions
+// @Produce json
+// @Success 200 {object} models.VersionsFile
+// @Failure 500 {object} models.ErrorResponse
+// @Router /versions/raw [get]
+func (h *Handler) GetRawVersionsFile(c *gin.Context) {
+	if !h.authorizeProject(c, models.ProjectWildcard, models.RoleRead) {
+		return
+	}
+
+	data, revision, err := h.service.GetRawVersionsFile(c.Request.Context())
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to read raw versions file")
+		h.errorResponse(c, http.StatusInternalServerError, "RAW_READ_FAILED", "Failed to read versions file", err.Error())
+		return
+	}
+
+	if revision != "" {
+		c.Header("X-Git-Revision", revision)
+		c.Header("ETag", `"`+revision+`"`)
+	}
+	c.Data(http.StatusOK, "application/json", data)
+}
+
+// ReplaceVersionsFile godoc
+// @Summary Replace raw versions file
+// @Description Validate and atomically replace versions.json (admin only). Send If-Match with a revision to guard against concurrent changes.
+// @Tags versions
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer admin token"
+// @Param If-Match header string false "Expected current Git revision"
+// @Param request body models.VersionsFile true "Complete versions file"
+// @Success 200 {object} models.RawFileUpdateResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 412 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /versions/raw [put]
+func (h *Handler) ReplaceVersionsFile(c *gin.Context) {
+	data, err := c.GetRawData()
+	if h.bodyTooLarge(c, err) {
+		return
+	}
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Failed to read request body", err.Error())
+		return
+	}
+
+	expectedRevision := strings.Trim(c.GetHeader("If-Match"), `"`)
+
+	response, err := h.service.ReplaceVersionsFile(c.Request.Context(), data, expectedRevision)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidVersionsFile):
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_VERSIONS_FILE", "Versions file failed validation", err.Error())
+		case errors.Is(err, services.ErrRevisionConflict):
+			h.errorResponse(c, http.StatusPreconditionFailed, "REVISION_CONFLICT", "Versions file changed since the given revision", err.Error())
+		default:
+			h.logger.WithError(err).Error("Failed to replace raw versions file")
+			h.errorResponse(c, http.StatusInternalServerError, "RAW_REPLACE_FAILED", "Failed to replace versions file", err.Error())
+		}
+		return
+	}
+
+	c.Header("X-Git-Revision", response.Revision)
+	c.JSON(http.StatusOK, response)
+}
+
+// ResolveProjectPaths lets callers name apps and projects by GitLab project
+// path. An app-id route parameter of "{project-path}/{app-name}", or a
+// project-id given as a path, is replaced by the ID the service stores it
+// under before authorization, the response cache and the handler see it.
+func (h *Handler) ResolveProjectPaths() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for i, param := range c.Params {
+			var resolved string
+			var err error
+			switch param.Key {
+			case "app-id":
+				resolved, err = h.service.ResolveAppID(c.Request.Context(), param.Value)
+			case "project-id":
+				resolved, err = h.service.ResolveProjectID(c.Request.Context(), param.Value)
+			default:
+				continue
+			}
+
+			if err != nil {
+				switch {
+				case errors.Is(err, services.ErrProjectPathNotFound):
+					h.errorResponse(c, http.StatusNotFound, "PROJECT_NOT_FOUND", "GitLab project path not found", err.Error())
+				case errors.Is(err, services.ErrGitLabUnavailable):
+					h.errorResponse(c, http.StatusServiceUnavailable, "GITLAB_UNAVAILABLE", "GitLab is unavailable to resolve the project path, try again", err.Error())
+				default:
+					h.logger.WithError(err).WithField(param.Key, param.Value).Error("Failed to resolve project path")
+					h.errorResponse(c, http.StatusBadGateway, "PROJECT_RESOLUTION_FAILED", "Failed to resolve project path", err.Error())
+				}
+				c.Abort()
+				return
+			}
+			c.Params[i].Value = resolved
+		}
+		c.Next()
 	}
 }
 
@@ -2,12 +2,26 @@ package handlers
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/company/version-service/internal/clients"
+	"github.com/company/version-service/internal/middleware"
 	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/services"
+	"github.com/company/version-service/internal/storage"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -31,14 +45,198 @@ func (m *MockVersionService) GetVersion(ctx context.Context, appID string) (*mod
 	return args.Get(0).(*models.AppVersion), args.Error(1)
 }
 
-func (m *MockVersionService) IncrementVersion(ctx context.Context, appID string, incrementType models.IncrementType) (*models.VersionResponse, error) {
-	args := m.Called(ctx, appID, incrementType)
+func (m *MockVersionService) IncrementVersion(ctx context.Context, appID string, incrementType models.IncrementType, idempotencyKey string) (*models.VersionResponse, error) {
+	args := m.Called(ctx, appID, incrementType, idempotencyKey)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.VersionResponse), args.Error(1)
 }
 
+func (m *MockVersionService) PromoteVersion(ctx context.Context, appID string) (*models.PromoteResponse, error) {
+	args := m.Called(ctx, appID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PromoteResponse), args.Error(1)
+}
+
+func (m *MockVersionService) GraduateVersion(ctx context.Context, appID string) (*models.GraduateResponse, error) {
+	args := m.Called(ctx, appID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.GraduateResponse), args.Error(1)
+}
+
+func (m *MockVersionService) SetVersionLock(ctx context.Context, appID string, locked bool) (*models.AppVersion, error) {
+	args := m.Called(ctx, appID, locked)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AppVersion), args.Error(1)
+}
+
+func (m *MockVersionService) SetIncrementStrategy(ctx context.Context, appID, strategy string) (*models.AppVersion, error) {
+	args := m.Called(ctx, appID, strategy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AppVersion), args.Error(1)
+}
+
+func (m *MockVersionService) SetInitialDevelopment(ctx context.Context, appID string, enabled bool) (*models.AppVersion, error) {
+	args := m.Called(ctx, appID, enabled)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AppVersion), args.Error(1)
+}
+
+func (m *MockVersionService) SetLifecycle(ctx context.Context, appID, state string) (*models.AppVersion, error) {
+	args := m.Called(ctx, appID, state)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AppVersion), args.Error(1)
+}
+
+func (m *MockVersionService) ListDevVersions(ctx context.Context, appID, branch string) (*models.DevVersionsResponse, error) {
+	args := m.Called(ctx, appID, branch)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DevVersionsResponse), args.Error(1)
+}
+
+func (m *MockVersionService) SetVersionAlias(ctx context.Context, appID, alias, version string) (*models.VersionAlias, error) {
+	args := m.Called(ctx, appID, alias, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.VersionAlias), args.Error(1)
+}
+
+func (m *MockVersionService) CompareVersions(v1, v2 string) (*models.CompareResponse, error) {
+	args := m.Called(v1, v2)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CompareResponse), args.Error(1)
+}
+
+func (m *MockVersionService) GetFreshnessReport(ctx context.Context) (*models.FreshnessReport, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.FreshnessReport), args.Error(1)
+}
+
+func (m *MockVersionService) RuntimeStats(ctx context.Context) (*models.RuntimeStats, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RuntimeStats), args.Error(1)
+}
+
+func (m *MockVersionService) UpdateVersionMetadata(ctx context.Context, appID string, annotations map[string]*string) (*models.AppVersion, error) {
+	args := m.Called(ctx, appID, annotations)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AppVersion), args.Error(1)
+}
+
+func (m *MockVersionService) GetVersionAlias(ctx context.Context, appID, alias string) (*models.VersionAlias, error) {
+	args := m.Called(ctx, appID, alias)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.VersionAlias), args.Error(1)
+}
+
+func (m *MockVersionService) ExportState(ctx context.Context, includeHistory bool) (*models.StateBundle, error) {
+	args := m.Called(ctx, includeHistory)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.StateBundle), args.Error(1)
+}
+
+func (m *MockVersionService) RebuildCache(ctx context.Context) (*models.CacheRebuildReport, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CacheRebuildReport), args.Error(1)
+}
+
+func (m *MockVersionService) ImportState(ctx context.Context, bundle *models.StateBundle) (*models.StateImportReport, error) {
+	args := m.Called(ctx, bundle)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.StateImportReport), args.Error(1)
+}
+
+func (m *MockVersionService) MigrateProjects(ctx context.Context, req *models.ProjectMigrationRequest) (*models.ProjectMigrationReport, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ProjectMigrationReport), args.Error(1)
+}
+
+func (m *MockVersionService) ListDeferredCalls(ctx context.Context) (*models.OutboxResponse, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OutboxResponse), args.Error(1)
+}
+
+func (m *MockVersionService) FlushDeferredCalls(ctx context.Context) (*models.FlushReport, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.FlushReport), args.Error(1)
+}
+
+func (m *MockVersionService) RunDiscovery(ctx context.Context) (*models.DiscoveryReport, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DiscoveryReport), args.Error(1)
+}
+
+func (m *MockVersionService) GetDiscoveryReport(ctx context.Context) (*models.DiscoveryReport, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DiscoveryReport), args.Error(1)
+}
+
+func (m *MockVersionService) IncrementVersions(ctx context.Context, appIDs []string, incrementType models.IncrementType) (*models.BatchIncrementResponse, error) {
+	args := m.Called(ctx, appIDs, incrementType)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.BatchIncrementResponse), args.Error(1)
+}
+
+func (m *MockVersionService) PreviewNextVersion(ctx context.Context, appID string, incrementType models.IncrementType) (*models.NextVersionResponse, error) {
+	args := m.Called(ctx, appID, incrementType)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.NextVersionResponse), args.Error(1)
+}
+
 func (m *MockVersionService) GetDevVersion(ctx context.Context, appID string, req *models.DevVersionRequest) (*models.VersionResponse, error) {
 	args := m.Called(ctx, appID, req)
 	if args.Get(0) == nil {
@@ -63,6 +261,22 @@ func (m *MockVersionService) ListVersionsByProject(ctx context.Context, projectI
 	return args.Get(0).(map[string]*models.AppVersion), args.Error(1)
 }
 
+func (m *MockVersionService) ListVersionsPage(ctx context.Context, cursor string, limit int, filter models.VersionFilter) (*models.VersionPage, error) {
+	args := m.Called(ctx, cursor, limit, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.VersionPage), args.Error(1)
+}
+
+func (m *MockVersionService) RegisterApp(ctx context.Context, req *models.RegisterAppRequest) (*models.RegisterAppResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RegisterAppResponse), args.Error(1)
+}
+
 func (m *MockVersionService) DeleteVersion(ctx context.Context, appID string) error {
 	args := m.Called(ctx, appID)
 	return args.Error(0)
@@ -73,6 +287,167 @@ func (m *MockVersionService) DeleteProject(ctx context.Context, projectID string
 	return args.Error(0)
 }
 
+func (m *MockVersionService) RestoreVersion(ctx context.Context, appID string) (*models.AppVersion, error) {
+	args := m.Called(ctx, appID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AppVersion), args.Error(1)
+}
+
+func (m *MockVersionService) GetVersionHistory(ctx context.Context, appID string) ([]models.VersionHistoryEntry, error) {
+	args := m.Called(ctx, appID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.VersionHistoryEntry), args.Error(1)
+}
+
+func (m *MockVersionService) RollbackVersion(ctx context.Context, appID string) (*models.RollbackResponse, error) {
+	args := m.Called(ctx, appID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RollbackResponse), args.Error(1)
+}
+
+func (m *MockVersionService) DecrementVersion(ctx context.Context, appID string) (*models.DecrementResponse, error) {
+	args := m.Called(ctx, appID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DecrementResponse), args.Error(1)
+}
+
+func (m *MockVersionService) GetRawVersionsFile(ctx context.Context) ([]byte, string, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.String(1), args.Error(2)
+	}
+	return args.Get(0).([]byte), args.String(1), args.Error(2)
+}
+
+func (m *MockVersionService) ReplaceVersionsFile(ctx context.Context, data []byte, expectedRevision string) (*models.RawFileUpdateResponse, error) {
+	args := m.Called(ctx, data, expectedRevision)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RawFileUpdateResponse), args.Error(1)
+}
+
+func (m *MockVersionService) SimulateProject(ctx context.Context, projectID string, bumps map[string]models.IncrementType) (*models.SimulationReport, error) {
+	args := m.Called(ctx, projectID, bumps)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SimulationReport), args.Error(1)
+}
+
+func (m *MockVersionService) GetProjectUsage(ctx context.Context, projectID string, windows []time.Duration) (*models.ProjectUsage, error) {
+	args := m.Called(ctx, projectID, windows)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ProjectUsage), args.Error(1)
+}
+
+func (m *MockVersionService) RenameVersion(ctx context.Context, appID, newAppID string) (*models.RenameResponse, error) {
+	args := m.Called(ctx, appID, newAppID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RenameResponse), args.Error(1)
+}
+
+func (m *MockVersionService) ListStaleVersions(ctx context.Context, days int) (*models.StaleReport, error) {
+	args := m.Called(ctx, days)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.StaleReport), args.Error(1)
+}
+
+func (m *MockVersionService) GetChangelog(ctx context.Context, appID, from, to string) (*models.Changelog, error) {
+	args := m.Called(ctx, appID, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Changelog), args.Error(1)
+}
+
+func (m *MockVersionService) GetReservedVersions(ctx context.Context, appID string) (*models.ReservedVersions, error) {
+	args := m.Called(ctx, appID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ReservedVersions), args.Error(1)
+}
+
+func (m *MockVersionService) SetReservedVersions(ctx context.Context, appID string, versions []string) (*models.ReservedVersions, error) {
+	args := m.Called(ctx, appID, versions)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ReservedVersions), args.Error(1)
+}
+
+func (m *MockVersionService) GetProjectReservedVersions(ctx context.Context, projectID string) (*models.ReservedVersions, error) {
+	args := m.Called(ctx, projectID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ReservedVersions), args.Error(1)
+}
+
+func (m *MockVersionService) SetProjectReservedVersions(ctx context.Context, projectID string, versions []string) (*models.ReservedVersions, error) {
+	args := m.Called(ctx, projectID, versions)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ReservedVersions), args.Error(1)
+}
+
+func (m *MockVersionService) CanAccessProject(ctx context.Context, projectID string) (bool, error) {
+	args := m.Called(ctx, projectID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockVersionService) AppProjectID(ctx context.Context, appID string) (string, error) {
+	args := m.Called(ctx, appID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockVersionService) ResolveAppID(ctx context.Context, appID string) (string, error) {
+	args := m.Called(ctx, appID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockVersionService) ResolveProjectID(ctx context.Context, projectID string) (string, error) {
+	args := m.Called(ctx, projectID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockVersionService) CreateWebhook(ctx context.Context, projectID string, req *models.CreateWebhookRequest) (*models.WebhookSubscription, error) {
+	args := m.Called(ctx, projectID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockVersionService) ListWebhooks(ctx context.Context, projectID string) (*models.WebhookListResponse, error) {
+	args := m.Called(ctx, projectID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WebhookListResponse), args.Error(1)
+}
+
+func (m *MockVersionService) DeleteWebhook(ctx context.Context, projectID, id string) error {
+	args := m.Called(ctx, projectID, id)
+	return args.Error(0)
+}
+
 func TestHealth_Healthy(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -163,76 +538,2448 @@ func TestGetVersion_Success(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
-func TestGetVersion_InvalidAppID(t *testing.T) {
+func TestGetVersion_Format(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockService := new(MockVersionService)
 	handler := NewHandler(mockService, logrus.New())
 
+	mockService.On("GetVersion", mock.Anything, "1234-user-service").
+		Return(&models.AppVersion{Current: "1.0.0+build.7", ProjectID: "1234", AppName: "user-service"}, nil)
+
 	router := gin.New()
 	router.GET("/version/:app-id", handler.GetVersion)
 
-	req, _ := http.NewRequest("GET", "/version/", nil)
+	for format, want := range map[string]string{"v": "v1.0.0+build.7", "docker": "1.0.0_build.7"} {
+		req, _ := http.NewRequest("GET", "/version/1234-user-service?format="+format, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response models.AppVersion
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, want, response.Current)
+	}
+
+	req, _ := http.NewRequest("GET", "/version/1234-user-service?format=npm", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_FORMAT")
+}
+
+func TestGetVersion_InvalidAppID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	router := gin.New()
+	router.GET("/version/:app-id", handler.GetVersion)
+
+	req, _ := http.NewRequest("GET", "/version/", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestIncrementVersion_ReleaseCandidate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("IncrementVersion", mock.Anything, "1234-user-service", models.IncrementTypeRC, "").
+		Return(&models.VersionResponse{Version: "1.3.0-rc.1"}, nil)
+
+	router := gin.New()
+	router.POST("/version/:app-id/increment", handler.IncrementVersion)
+
+	req, _ := http.NewRequest("POST", "/version/1234-user-service/increment?type=rc", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	var response models.VersionResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.3.0-rc.1", response.Version)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestIncrementVersion_WriteThrough(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+	handler.SetWriteThrough(true)
+
+	mockService.On("IncrementVersion", mock.Anything, "1234-user-service", models.IncrementTypePatch, "").
+		Return(&models.VersionResponse{Version: "1.2.4"}, nil)
+
+	router := gin.New()
+	router.POST("/version/:app-id/increment", handler.IncrementVersion)
+
+	req, _ := http.NewRequest("POST", "/version/1234-user-service/increment?type=patch", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// The version is in Git once answered
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestIncrementVersion_Build(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("IncrementVersion", mock.Anything, "1234-legacy-tool", models.IncrementTypeBuild, "").
+		Return(&models.VersionResponse{Version: "4.2.0.8"}, nil)
+
+	router := gin.New()
+	router.POST("/version/:app-id/increment", handler.IncrementVersion)
+
+	req, _ := http.NewRequest("POST", "/version/1234-legacy-tool/increment?type=build", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	var response models.VersionResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "4.2.0.8", response.Version)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestIncrementVersion_IdempotencyKeyHeader(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("IncrementVersion", mock.Anything, "1234-user-service", models.IncrementTypeDefault, "pipeline-42").
+		Return(&models.VersionResponse{Version: "1.2.4", Replayed: true}, nil)
+
+	router := gin.New()
+	router.POST("/version/:app-id/increment", handler.IncrementVersion)
+
+	req, _ := http.NewRequest("POST", "/version/1234-user-service/increment", nil)
+	req.Header.Set("Idempotency-Key", "pipeline-42")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "true", w.Header().Get("Idempotent-Replayed"))
+
+	var response models.VersionResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.4", response.Version)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestIncrementVersion_IdempotencyKeyBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("IncrementVersion", mock.Anything, "1234-user-service", models.IncrementTypeMinor, "pipeline-43").
+		Return(&models.VersionResponse{Version: "1.3.0"}, nil)
+
+	router := gin.New()
+	router.POST("/version/:app-id/increment", handler.IncrementVersion)
+
+	body := strings.NewReader(`{"idempotency_key":"pipeline-43"}`)
+	req, _ := http.NewRequest("POST", "/version/1234-user-service/increment?type=minor", body)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+
+	mockService.AssertExpectations(t)
+}
+
+func TestIncrementVersion_Locked(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("IncrementVersion", mock.Anything, "1234-user-service", models.IncrementTypeDefault, "").
+		Return(nil, fmt.Errorf("%w: 1234-user-service", services.ErrVersionLocked))
+
+	router := gin.New()
+	router.POST("/version/:app-id/increment", handler.IncrementVersion)
+
+	req, _ := http.NewRequest("POST", "/version/1234-user-service/increment", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	var response models.ErrorResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "VERSION_LOCKED", response.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestIncrementVersion_RejectedByHook(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("IncrementVersion", mock.Anything, "1234-user-service", models.IncrementTypeMajor, "").
+		Return(nil, fmt.Errorf("%w: major bumps need a change ticket", services.ErrHookRejected))
+
+	router := gin.New()
+	router.POST("/version/:app-id/increment", handler.IncrementVersion)
+
+	req, _ := http.NewRequest("POST", "/version/1234-user-service/increment?type=major", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	var response models.ErrorResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "INCREMENT_REJECTED", response.Code)
+	assert.Contains(t, response.Details, "change ticket")
+
+	mockService.AssertExpectations(t)
+}
+
+func TestIncrementVersion_Reserved(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("IncrementVersion", mock.Anything, "1234-user-service", models.IncrementTypeMajor, "").
+		Return(nil, fmt.Errorf("%w: 2.0.0", services.ErrVersionReserved))
+
+	router := gin.New()
+	router.POST("/version/:app-id/increment", handler.IncrementVersion)
+
+	req, _ := http.NewRequest("POST", "/version/1234-user-service/increment?type=major", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	var response models.ErrorResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "VERSION_RESERVED", response.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestSetReservedVersions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	reserved := &models.ReservedVersions{
+		AppID:           "1234-user-service",
+		ProjectID:       "1234",
+		AppVersions:     []string{"2.0.0"},
+		ProjectVersions: []string{"13.0.0"},
+	}
+	mockService.On("SetReservedVersions", mock.Anything, "1234-user-service", []string{"2.0.0"}).Return(reserved, nil)
+	mockService.On("SetReservedVersions", mock.Anything, "1234-user-service", []string{"two"}).
+		Return(nil, fmt.Errorf("%w: two is not a semantic version", services.ErrInvalidReservedVersions))
+
+	router := gin.New()
+	router.PUT("/version/:app-id/reserved", handler.SetReservedVersions)
+
+	req, _ := http.NewRequest("PUT", "/version/1234-user-service/reserved", strings.NewReader(`{"versions":["2.0.0"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.ReservedVersions
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, *reserved, response)
+
+	req, _ = http.NewRequest("PUT", "/version/1234-user-service/reserved", strings.NewReader(`{"versions":["two"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var errResponse models.ErrorResponse
+	err = json.Unmarshal(w.Body.Bytes(), &errResponse)
+	assert.NoError(t, err)
+	assert.Equal(t, "INVALID_RESERVED_VERSIONS", errResponse.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestGetChangelog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	changelog := &models.Changelog{
+		AppID:     "1234-user-service",
+		ProjectID: "1234",
+		From:      "1.2.0",
+		To:        "1.4.0",
+		FromTag:   "v1.2.0",
+		ToTag:     "v1.4.0",
+		MergeRequests: []models.ChangelogMergeRequest{
+			{IID: 42, Reference: "platform/user-service!42", Title: "Add SSO login"},
+		},
+		Commits: []models.ChangelogCommit{},
+	}
+	mockService.On("GetChangelog", mock.Anything, "1234-user-service", "1.2.0", "1.4.0").Return(changelog, nil)
+	mockService.On("GetChangelog", mock.Anything, "1234-user-service", "0.9.0", "").
+		Return(nil, fmt.Errorf("%w: 0.9.0 in project 1234", services.ErrTagNotFound))
+
+	router := gin.New()
+	router.GET("/version/:app-id/changelog", handler.GetChangelog)
+
+	req, _ := http.NewRequest("GET", "/version/1234-user-service/changelog?from=1.2.0&to=1.4.0", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.Changelog
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.4.0", response.ToTag)
+	assert.Len(t, response.MergeRequests, 1)
+
+	req, _ = http.NewRequest("GET", "/version/1234-user-service/changelog?from=0.9.0", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	var errResponse models.ErrorResponse
+	err = json.Unmarshal(w.Body.Bytes(), &errResponse)
+	assert.NoError(t, err)
+	assert.Equal(t, "TAG_NOT_FOUND", errResponse.Code)
+
+	req, _ = http.NewRequest("GET", "/version/1234-user-service/changelog", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestPromoteVersion_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("PromoteVersion", mock.Anything, "1234-user-service").
+		Return(&models.PromoteResponse{Version: "1.4.0", PromotedFrom: "1.4.0-rc.2"}, nil)
+
+	router := gin.New()
+	router.POST("/version/:app-id/promote", handler.PromoteVersion)
+
+	req, _ := http.NewRequest("POST", "/version/1234-user-service/promote", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	var response models.PromoteResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.4.0", response.Version)
+	assert.Equal(t, "1.4.0-rc.2", response.PromotedFrom)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestPromoteVersion_NotPrerelease(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("PromoteVersion", mock.Anything, "1234-user-service").
+		Return(nil, fmt.Errorf("%w: 1234-user-service is at 1.4.0", services.ErrNotPrerelease))
+
+	router := gin.New()
+	router.POST("/version/:app-id/promote", handler.PromoteVersion)
+
+	req, _ := http.NewRequest("POST", "/version/1234-user-service/promote", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestLockVersion_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("SetVersionLock", mock.Anything, "1234-user-service", true).
+		Return(&models.AppVersion{Current: "1.2.3", ProjectID: "1234", AppName: "user-service", Locked: true}, nil)
+
+	router := gin.New()
+	router.POST("/version/:app-id/lock", handler.LockVersion)
+
+	req, _ := http.NewRequest("POST", "/version/1234-user-service/lock", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.AppVersion
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.True(t, response.Locked)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestUnlockVersion_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("SetVersionLock", mock.Anything, "1234-missing", false).
+		Return(nil, fmt.Errorf("%w: 1234-missing", services.ErrAppNotFound))
+
+	router := gin.New()
+	router.POST("/version/:app-id/unlock", handler.UnlockVersion)
+
+	req, _ := http.NewRequest("POST", "/version/1234-missing/unlock", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestIncrementVersions_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	appIDs := []string{"1234-user-service", "1234-payment-service"}
+	mockService.On("IncrementVersions", mock.Anything, appIDs, models.IncrementTypeMinor).
+		Return(&models.BatchIncrementResponse{Versions: map[string]string{
+			"1234-user-service":    "1.3.0",
+			"1234-payment-service": "2.1.0",
+		}}, nil)
+
+	router := gin.New()
+	router.POST("/versions/increment", handler.IncrementVersions)
+
+	body := strings.NewReader(`{"app_ids":["1234-user-service","1234-payment-service"],"type":"minor"}`)
+	req, _ := http.NewRequest("POST", "/versions/increment", body)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	var response models.BatchIncrementResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.3.0", response.Versions["1234-user-service"])
+	assert.Equal(t, "2.1.0", response.Versions["1234-payment-service"])
+
+	mockService.AssertExpectations(t)
+}
+
+func TestIncrementVersions_InvalidType(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	router := gin.New()
+	router.POST("/versions/increment", handler.IncrementVersions)
+
+	body := strings.NewReader(`{"app_ids":["1234-user-service"],"type":"huge"}`)
+	req, _ := http.NewRequest("POST", "/versions/increment", body)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertNotCalled(t, "IncrementVersions", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDeleteVersion_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	// Mock both GetVersion and DeleteVersion calls
+	mockService.On("GetVersion", mock.Anything, "1234-user-service").Return(&models.AppVersion{
+		Current:   "1.0.0",
+		ProjectID: "1234",
+		AppName:   "user-service",
+	}, nil)
+	mockService.On("DeleteVersion", mock.Anything, "1234-user-service").Return(nil)
+
+	router := gin.New()
+	router.DELETE("/delete/:id", handler.DeleteVersion)
+
+	req, _ := http.NewRequest("DELETE", "/delete/1234-user-service", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]string
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "Version deleted successfully", response["message"])
+	assert.Equal(t, "1234-user-service", response["app_id"])
+
+	mockService.AssertExpectations(t)
+}
+
+func TestRegisterApp_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	expected := &models.RegisterAppRequest{
+		ProjectID:      "1234",
+		AppName:        "user-service",
+		InitialVersion: "0.1.0",
+		Policy:         &models.AppPolicy{AllowedIncrements: []models.IncrementType{models.IncrementTypePatch}},
+	}
+	mockService.On("RegisterApp", mock.Anything, expected).Return(&models.RegisterAppResponse{
+		AppID:   "1234-user-service",
+		Version: &models.AppVersion{Current: "0.1.0", ProjectID: "1234", AppName: "user-service", Policy: expected.Policy},
+	}, nil)
+
+	router := gin.New()
+	router.POST("/apps", handler.RegisterApp)
+
+	body := `{"project_id":"1234","app_name":"user-service","initial_version":"0.1.0","policy":{"allowed_increments":["patch"]}}`
+	req, _ := http.NewRequest("POST", "/apps", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response models.RegisterAppResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "1234-user-service", response.AppID)
+	assert.Equal(t, "0.1.0", response.Version.Current)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestRegisterApp_InvalidBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+	handler.SetStrictJSON(true)
+
+	router := gin.New()
+	router.Use(middleware.BodyLimitMiddleware(128, 1024))
+	router.POST("/apps", handler.RegisterApp)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		fields []models.FieldError
+	}{
+		{"missing field", `{"project_id":"1234"}`, http.StatusBadRequest, []models.FieldError{{Field: "app_name", Message: "is required"}}},
+		{"unknown field", `{"project_id":"1234","app_name":"api","intial_version":"1.0.0"}`, http.StatusBadRequest, []models.FieldError{{Field: "intial_version", Message: "unknown field"}}},
+		{"wrong type", `{"project_id":1234,"app_name":"api"}`, http.StatusBadRequest, []models.FieldError{{Field: "project_id", Message: "cannot be a JSON number"}}},
+		{"trailing data", `{"project_id":"1234","app_name":"api"} {}`, http.StatusBadRequest, nil},
+		{"too large", `{"project_id":"1234","app_name":"` + strings.Repeat("a", 200) + `"}`, http.StatusRequestEntityTooLarge, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/apps", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			var response models.ErrorResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.fields, response.Fields)
+		})
+	}
+
+	// Bodies without a Content-Length are cut off while read
+	req, _ := http.NewRequest("POST", "/apps", io.MultiReader(strings.NewReader(`{"project_id":"`), strings.NewReader(strings.Repeat("1", 200)+`"}`)))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Contains(t, w.Body.String(), "REQUEST_TOO_LARGE")
+
+	mockService.AssertNotCalled(t, "RegisterApp", mock.Anything, mock.Anything)
+}
+
+func TestRegisterApp_Exists(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("RegisterApp", mock.Anything, mock.Anything).Return(nil, services.ErrAppExists)
+
+	router := gin.New()
+	router.POST("/apps", handler.RegisterApp)
+
+	req, _ := http.NewRequest("POST", "/apps", strings.NewReader(`{"project_id":"1234","app_name":"user-service"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "APP_EXISTS")
+
+	mockService.AssertExpectations(t)
+}
+
+func TestRegisterApp_MissingFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	router := gin.New()
+	router.POST("/apps", handler.RegisterApp)
+
+	req, _ := http.NewRequest("POST", "/apps", strings.NewReader(`{"project_id":"1234"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertNotCalled(t, "RegisterApp", mock.Anything, mock.Anything)
+}
+
+func TestRestoreVersion_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("RestoreVersion", mock.Anything, "1234-user-service").Return(&models.AppVersion{
+		Current:   "1.4.2",
+		ProjectID: "1234",
+		AppName:   "user-service",
+	}, nil)
+
+	router := gin.New()
+	router.POST("/version/:app-id/restore", handler.RestoreVersion)
+
+	req, _ := http.NewRequest("POST", "/version/1234-user-service/restore", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.AppVersion
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.4.2", response.Current)
+	assert.Nil(t, response.DeletedAt)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestRestoreVersion_NotDeleted(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("RestoreVersion", mock.Anything, "1234-user-service").Return(nil, services.ErrAppNotDeleted)
+
+	router := gin.New()
+	router.POST("/version/:app-id/restore", handler.RestoreVersion)
+
+	req, _ := http.NewRequest("POST", "/version/1234-user-service/restore", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "NOT_DELETED")
+
+	mockService.AssertExpectations(t)
+}
+
+func TestGetVersion_Deleted(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("GetVersion", mock.Anything, "1234-user-service").Return(nil, fmt.Errorf("%w: %w: 1234-user-service", services.ErrAppNotFound, services.ErrAppDeleted))
+
+	router := gin.New()
+	router.GET("/version/:app-id", handler.GetVersion)
+
+	req, _ := http.NewRequest("GET", "/version/1234-user-service", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "APP_DELETED")
+
+	mockService.AssertExpectations(t)
+}
+
+func TestDeleteProject_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("DeleteProject", mock.Anything, "1234").Return(nil)
+
+	router := gin.New()
+	router.DELETE("/delete/:id", handler.DeleteVersion)
+
+	req, _ := http.NewRequest("DELETE", "/delete/1234", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]string
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "Project deleted successfully", response["message"])
+	assert.Equal(t, "1234", response["project_id"])
+
+	mockService.AssertExpectations(t)
+}
+
+func TestListVersions_FilterByRepo(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	versions := map[string]*models.AppVersion{
+		"1234-user-service":    {Current: "1.0.0", ProjectID: "1234", AppName: "user-service", RepoName: "platform/user-service"},
+		"1234-payment-service": {Current: "2.0.0", ProjectID: "1234", AppName: "payment-service", RepoName: "payments/payment-service"},
+	}
+
+	mockService.On("ListVersions", mock.Anything).Return(versions, nil)
+
+	router := gin.New()
+	router.GET("/versions", handler.ListVersions)
+
+	req, _ := http.NewRequest("GET", "/versions?repo=Platform/", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]*models.AppVersion
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Len(t, response, 1)
+	assert.Contains(t, response, "1234-user-service")
+
+	mockService.AssertExpectations(t)
+}
+
+func TestListVersionsByProject_Paged(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	filter := models.VersionFilter{ProjectID: "1234", RepoName: "platform"}
+	mockService.On("ListVersionsPage", mock.Anything, "abc", 1, filter).Return(&models.VersionPage{
+		Versions: map[string]*models.AppVersion{
+			"1234-user-service": {Current: "1.0.0", ProjectID: "1234", AppName: "user-service"},
+		},
+		NextCursor: "MTIzNC11c2VyLXNlcnZpY2U",
+	}, nil)
+
+	router := gin.New()
+	router.GET("/versions/:project-id", handler.ListVersionsByProject)
+
+	req, _ := http.NewRequest("GET", "/versions/1234?limit=1&cursor=abc&repo=platform", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.VersionPage
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Len(t, response.Versions, 1)
+	assert.Equal(t, "MTIzNC11c2VyLXNlcnZpY2U", response.NextCursor)
+
+	mockService.AssertExpectations(t)
+	mockService.AssertNotCalled(t, "ListVersionsByProject", mock.Anything, mock.Anything)
+}
+
+func TestListVersions_InvalidLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	router := gin.New()
+	router.GET("/versions", handler.ListVersions)
+
+	req, _ := http.NewRequest("GET", "/versions?limit=ten", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_PAGE")
+	mockService.AssertNotCalled(t, "ListVersionsPage", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSimulateProject_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	bumps := map[string]models.IncrementType{"1234-api": models.IncrementTypeMinor, "1234-web": models.IncrementTypeDefault}
+	mockService.On("SimulateProject", mock.Anything, "1234", bumps).Return(&models.SimulationReport{
+		ProjectID: "1234",
+		Versions: map[string]models.SimulatedVersion{
+			"1234-api": {Current: "1.2.3", Next: "1.3.0", Type: models.IncrementTypeMinor},
+			"1234-web": {Current: "2.0.0", Type: models.IncrementTypePatch},
+		},
+		Violations: []models.SimulationViolation{{AppID: "1234-web", Code: "VERSION_LOCKED", Message: "version is locked: 1234-web"}},
+	}, nil)
+
+	router := gin.New()
+	router.POST("/projects/:project-id/simulate", handler.SimulateProject)
+
+	body := `{"bumps": {"1234-api": "minor", "1234-web": ""}}`
+	req, _ := http.NewRequest("POST", "/projects/1234/simulate", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.SimulationReport
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.3.0", response.Versions["1234-api"].Next)
+	assert.Len(t, response.Violations, 1)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestSimulateProject_InvalidIncrementType(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	router := gin.New()
+	router.POST("/projects/:project-id/simulate", handler.SimulateProject)
+
+	body := `{"bumps": {"1234-api": "huge"}}`
+	req, _ := http.NewRequest("POST", "/projects/1234/simulate", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_INCREMENT_TYPE")
+	mockService.AssertNotCalled(t, "SimulateProject", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGetProjectUsage_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	expectedUsage := &models.ProjectUsage{
+		ProjectID: "1234",
+		Apps:      models.QuotaUsage{Used: 8, Limit: 10, Utilization: 0.8},
+		Windows: []models.WindowUsage{
+			{Window: "1h", Increments: 3},
+		},
+	}
+
+	mockService.On("GetProjectUsage", mock.Anything, "1234", []time.Duration{time.Hour}).Return(expectedUsage, nil)
+
+	router := gin.New()
+	router.GET("/projects/:project-id/usage", handler.GetProjectUsage)
+
+	req, _ := http.NewRequest("GET", "/projects/1234/usage?windows=1h", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.ProjectUsage
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(8), response.Apps.Used)
+	assert.Equal(t, int64(3), response.Windows[0].Increments)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestGetProjectUsage_InvalidWindows(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	router := gin.New()
+	router.GET("/projects/:project-id/usage", handler.GetProjectUsage)
+
+	// Windows longer than increments are kept would undercount
+	for _, windows := range []string{"soon", "30d", "169h"} {
+		req, _ := http.NewRequest("GET", "/projects/1234/usage?windows="+windows, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, windows)
+		assert.Contains(t, w.Body.String(), "INVALID_WINDOWS", windows)
+	}
+}
+
+func TestRollbackVersion_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("RollbackVersion", mock.Anything, "1234-user-service").Return(&models.RollbackResponse{
+		Version:        "1.2.3",
+		RolledBackFrom: "1.2.4",
+		Commit:         "abc1234",
+	}, nil)
+
+	router := gin.New()
+	router.POST("/version/:app-id/rollback", handler.RollbackVersion)
+
+	req, _ := http.NewRequest("POST", "/version/1234-user-service/rollback", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	var response models.RollbackResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.3", response.Version)
+	assert.Equal(t, "1.2.4", response.RolledBackFrom)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestRollbackVersion_NoPreviousVersion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("RollbackVersion", mock.Anything, "1234-user-service").Return(nil, services.ErrNoPreviousVersion)
+
+	router := gin.New()
+	router.POST("/version/:app-id/rollback", handler.RollbackVersion)
+
+	req, _ := http.NewRequest("POST", "/version/1234-user-service/rollback", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestDecrementVersion_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("DecrementVersion", mock.Anything, "1234-user-service").Return(&models.DecrementResponse{
+		Version:         "1.2.3",
+		DecrementedFrom: "1.3.0",
+		Undone:          models.IncrementTypeMinor,
+		Commit:          "abc1234",
+	}, nil)
+
+	router := gin.New()
+	router.POST("/version/:app-id/decrement", handler.DecrementVersion)
+
+	req, _ := http.NewRequest("POST", "/version/1234-user-service/decrement", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	var response models.DecrementResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.3", response.Version)
+	assert.Equal(t, "1.3.0", response.DecrementedFrom)
+	assert.Equal(t, models.IncrementTypeMinor, response.Undone)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestDecrementVersion_NotAnIncrement(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("DecrementVersion", mock.Anything, "1234-user-service").Return(nil, services.ErrNotAnIncrement)
+
+	router := gin.New()
+	router.POST("/version/:app-id/decrement", handler.DecrementVersion)
+
+	req, _ := http.NewRequest("POST", "/version/1234-user-service/decrement", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "NOT_AN_INCREMENT")
+
+	mockService.AssertExpectations(t)
+}
+
+func TestGetVersionHistory_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("GetVersionHistory", mock.Anything, "1234-user-service").Return([]models.VersionHistoryEntry{
+		{Version: "1.0.0", Commit: "aaa1111"},
+		{Version: "1.0.1", Commit: "bbb2222"},
+	}, nil)
+
+	router := gin.New()
+	router.GET("/version/:app-id/history", handler.GetVersionHistory)
+
+	req, _ := http.NewRequest("GET", "/version/1234-user-service/history", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response []models.VersionHistoryEntry
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Len(t, response, 2)
+	assert.Equal(t, "1.0.1", response[1].Version)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestPreviewNextVersion_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("PreviewNextVersion", mock.Anything, "1234-user-service", models.IncrementTypeMinor).Return(&models.NextVersionResponse{
+		Current: "1.2.3",
+		Next:    "1.3.0",
+		Type:    models.IncrementTypeMinor,
+	}, nil)
+
+	router := gin.New()
+	router.GET("/version/:app-id/next", handler.PreviewNextVersion)
+
+	req, _ := http.NewRequest("GET", "/version/1234-user-service/next?type=minor", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.NextVersionResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.3.0", response.Next)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestPreviewNextVersion_InvalidType(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	router := gin.New()
+	router.GET("/version/:app-id/next", handler.PreviewNextVersion)
+
+	req, _ := http.NewRequest("GET", "/version/1234-user-service/next?type=huge", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetRawVersionsFile_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	raw := []byte(`{"versions":{},"last_updated":"2025-01-15T10:30:00Z"}`)
+	mockService.On("GetRawVersionsFile", mock.Anything).Return(raw, "abc1234", nil)
+
+	router := gin.New()
+	router.GET("/versions/raw", handler.GetRawVersionsFile)
+
+	req, _ := http.NewRequest("GET", "/versions/raw", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "abc1234", w.Header().Get("X-Git-Revision"))
+	assert.Equal(t, string(raw), w.Body.String())
+
+	mockService.AssertExpectations(t)
+}
+
+func TestReplaceVersionsFile_RevisionConflict(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	body := `{"versions":{}}`
+	mockService.On("ReplaceVersionsFile", mock.Anything, []byte(body), "abc1234").Return(nil, services.ErrRevisionConflict)
+
+	router := gin.New()
+	router.PUT("/versions/raw", handler.ReplaceVersionsFile)
+
+	req, _ := http.NewRequest("PUT", "/versions/raw", strings.NewReader(body))
+	req.Header.Set("If-Match", `"abc1234"`)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestRebuildCache(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("RebuildCache", mock.Anything).
+		Return(&models.CacheRebuildReport{Versions: 12, Recovered: 1}, nil).Once()
+	mockService.On("RebuildCache", mock.Anything).
+		Return(nil, fmt.Errorf("git unavailable")).Once()
+
+	router := gin.New()
+	router.POST("/admin/cache/rebuild", handler.RebuildCache)
+
+	req, _ := http.NewRequest("POST", "/admin/cache/rebuild", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var report models.CacheRebuildReport
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, 12, report.Versions)
+	assert.Equal(t, 1, report.Recovered)
+
+	req, _ = http.NewRequest("POST", "/admin/cache/rebuild", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "CACHE_REBUILD_FAILED")
+
+	mockService.AssertExpectations(t)
+}
+
+func TestResolveProjectPaths(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("ResolveAppID", mock.Anything, "platform/billing/api").Return("1234-api", nil)
+	mockService.On("ResolveAppID", mock.Anything, "platform/unknown/api").
+		Return("", fmt.Errorf("%w: platform/unknown", services.ErrProjectPathNotFound))
+	mockService.On("GetVersion", mock.Anything, "1234-api").
+		Return(&models.AppVersion{Current: "2.1.0", ProjectID: "1234", AppName: "api"}, nil)
+
+	router := gin.New()
+	router.UseRawPath = true
+	router.UnescapePathValues = true
+	group := router.Group("/")
+	group.Use(handler.ResolveProjectPaths())
+	group.GET("/version/:app-id", handler.GetVersion)
+
+	req, _ := http.NewRequest("GET", "/version/platform%2Fbilling%2Fapi", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "2.1.0")
+
+	req, _ = http.NewRequest("GET", "/version/platform%2Funknown%2Fapi", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "PROJECT_NOT_FOUND")
+
+	mockService.AssertExpectations(t)
+}
+
+func TestExportState_WithoutHistory(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	bundle := &models.StateBundle{
+		FormatVersion: models.StateBundleFormatVersion,
+		ExportedAt:    time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Revision:      "abc1234",
+		Versions: map[string]*models.AppVersion{
+			"1234-user-service": {Current: "1.2.3", ProjectID: "1234", AppName: "user-service"},
+		},
+	}
+	mockService.On("ExportState", mock.Anything, false).Return(bundle, nil)
+
+	router := gin.New()
+	router.GET("/admin/state", handler.ExportState)
+
+	req, _ := http.NewRequest("GET", "/admin/state?history=false", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "abc1234", w.Header().Get("X-Git-Revision"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "version-service-state-20240501T120000Z.json")
+
+	var response models.StateBundle
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.3", response.Versions["1234-user-service"].Current)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestMigrateProjects_Conflict(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	expected := &models.ProjectMigrationRequest{Projects: map[string]string{"1234": "5678"}}
+	mockService.On("MigrateProjects", mock.Anything, expected).
+		Return(nil, fmt.Errorf("%w: 1234-api and 5678-api would both be 5678-api", services.ErrMigrationConflict))
+
+	router := gin.New()
+	router.POST("/admin/projects/migrate", handler.MigrateProjects)
+
+	req, _ := http.NewRequest("POST", "/admin/projects/migrate", strings.NewReader(`{"projects":{"1234":"5678"}}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "MIGRATION_CONFLICT")
+	mockService.AssertExpectations(t)
+}
+
+func TestImportState_InvalidBundle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("ImportState", mock.Anything, mock.AnythingOfType("*models.StateBundle")).
+		Return(nil, fmt.Errorf("%w: unsupported format version 2 (expected 1)", services.ErrInvalidStateBundle))
+
+	router := gin.New()
+	router.PUT("/admin/state", handler.ImportState)
+
+	req, _ := http.NewRequest("PUT", "/admin/state", strings.NewReader(`{"format_version":2,"versions":{}}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response models.ErrorResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "INVALID_STATE_BUNDLE", response.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestRunDiscovery_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	report := &models.DiscoveryReport{
+		Groups:          []string{"platform"},
+		ProjectsScanned: 2,
+		Registered: []models.DiscoveredProject{
+			{ProjectID: "1234", AppID: "1234-user-service", RepoName: "platform/user-service", Version: "1.4.0"},
+		},
+		Unregistered: []models.DiscoveredProject{},
+	}
+
+	mockService.On("RunDiscovery", mock.Anything).Return(report, nil)
+
+	router := gin.New()
+	router.POST("/discovery/run", handler.RunDiscovery)
+
+	req, _ := http.NewRequest("POST", "/discovery/run", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.DiscoveryReport
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, response.ProjectsScanned)
+	assert.Equal(t, "1234-user-service", response.Registered[0].AppID)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestRunDiscovery_AlreadyRunning(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("RunDiscovery", mock.Anything).Return(nil, services.ErrDiscoveryRunning)
+
+	router := gin.New()
+	router.POST("/discovery/run", handler.RunDiscovery)
+
+	req, _ := http.NewRequest("POST", "/discovery/run", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestGetDiscoveryReport_NoRunYet(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("GetDiscoveryReport", mock.Anything).Return(nil, nil)
+
+	router := gin.New()
+	router.GET("/discovery", handler.GetDiscoveryReport)
+
+	req, _ := http.NewRequest("GET", "/discovery", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestListSchemas(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewHandler(new(MockVersionService), logrus.New())
+
+	router := gin.New()
+	router.GET("/schemas", handler.ListSchemas)
+
+	req, _ := http.NewRequest("GET", "/schemas", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response []models.SchemaInfo
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, response)
+}
+
+func TestGetSchema(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewHandler(new(MockVersionService), logrus.New())
+
+	router := gin.New()
+	router.GET("/schemas/:event", handler.GetSchema)
+	router.GET("/schemas/:event/:version", handler.GetSchema)
+
+	tests := []struct {
+		name string
+		path string
+		want int
+	}{
+		{"current version", "/schemas/quota_alert", http.StatusOK},
+		{"explicit version", "/schemas/quota_alert/1", http.StatusOK},
+		{"unknown version", "/schemas/quota_alert/99", http.StatusNotFound},
+		{"unknown event", "/schemas/nothing", http.StatusNotFound},
+		{"invalid version", "/schemas/quota_alert/latest", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want, w.Code)
+			if tt.want == http.StatusOK {
+				assert.Equal(t, "application/schema+json", w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestGetVersion_CachedUntilWrite(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+	cache := middleware.NewResponseCache(time.Minute, 100, nil, nil, logrus.New())
+
+	mockService.On("GetVersion", mock.Anything, "1234-user-service").
+		Return(&models.AppVersion{Current: "1.0.0", ProjectID: "1234", AppName: "user-service"}, nil).Twice()
+	mockService.On("IncrementVersion", mock.Anything, "1234-user-service", models.IncrementTypeDefault, "").
+		Return(&models.VersionResponse{Version: "1.0.1"}, nil)
+
+	router := gin.New()
+	router.GET("/version/:app-id", cache.Cache(), handler.GetVersion)
+	router.POST("/version/:app-id/increment", cache.PurgeOnWrite(), handler.IncrementVersion)
+
+	get := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/version/1234-user-service", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "MISS", w.Header().Get("X-Cache"))
+	assert.Equal(t, "app:1234-user-service project:1234", w.Header().Get("Surrogate-Key"))
+
+	w = get()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "HIT", w.Header().Get("X-Cache"))
+	assert.Contains(t, w.Body.String(), `"current":"1.0.0"`)
+
+	req, _ := http.NewRequest("POST", "/version/1234-user-service/increment", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	w = get()
+	assert.Equal(t, "MISS", w.Header().Get("X-Cache"))
+
+	mockService.AssertExpectations(t)
+}
+
+func TestPurgeCache_Keys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+	cache := middleware.NewResponseCache(time.Minute, 100, nil, nil, logrus.New())
+	handler.SetResponseCache(cache)
+
+	mockService.On("ListVersions", mock.Anything).Return(map[string]*models.AppVersion{}, nil).Twice()
+
+	router := gin.New()
+	router.GET("/versions", cache.Cache(), handler.ListVersions)
+	router.POST("/admin/cache/purge", handler.PurgeCache)
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("GET", "/versions", nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	req, _ := http.NewRequest("POST", "/admin/cache/purge", strings.NewReader(`{"keys":["versions"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response models.CachePurgeResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 1, response.Purged)
+	assert.Equal(t, []string{"versions"}, response.Keys)
+
+	req, _ = http.NewRequest("GET", "/versions", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestPurgeCache_Disabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewHandler(new(MockVersionService), logrus.New())
+
+	router := gin.New()
+	router.POST("/admin/cache/purge", handler.PurgeCache)
+
+	req, _ := http.NewRequest("POST", "/admin/cache/purge", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "CACHE_DISABLED")
+}
+
+func TestSetVersionAlias_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("SetVersionAlias", mock.Anything, "1234-user-service", "stable", "1.2.3").
+		Return(&models.VersionAlias{AppID: "1234-user-service", Alias: "stable", Version: "1.2.3"}, nil)
+
+	router := gin.New()
+	router.PUT("/version/:app-id/alias/:name", handler.SetVersionAlias)
+
+	req, _ := http.NewRequest("PUT", "/version/1234-user-service/alias/stable", strings.NewReader(`{"version":"1.2.3"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.VersionAlias
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "1.2.3", response.Version)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestSetVersionAlias_Invalid(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("SetVersionAlias", mock.Anything, "1234-user-service", "stable", "9.0.0").
+		Return(nil, fmt.Errorf("%w: 9.0.0 is ahead of current version 1.2.3", services.ErrInvalidAlias))
+
+	router := gin.New()
+	router.PUT("/version/:app-id/alias/:name", handler.SetVersionAlias)
+
+	req, _ := http.NewRequest("PUT", "/version/1234-user-service/alias/stable", strings.NewReader(`{"version":"9.0.0"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_ALIAS")
+
+	mockService.AssertExpectations(t)
+}
+
+func TestGetVersionAlias_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("GetVersionAlias", mock.Anything, "1234-user-service", "lts").
+		Return(nil, fmt.Errorf("%w: 1234-user-service has no alias \"lts\"", services.ErrAliasNotFound))
+
+	router := gin.New()
+	router.GET("/version/:app-id/alias/:name", handler.GetVersionAlias)
+
+	req, _ := http.NewRequest("GET", "/version/1234-user-service/alias/lts", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "ALIAS_NOT_FOUND")
+
+	mockService.AssertExpectations(t)
+}
+
+func TestGetFreshnessReport_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("GetFreshnessReport", mock.Anything).Return(&models.FreshnessReport{
+		Target:          "1m0s",
+		Objective:       0.99,
+		WorstLagSeconds: 95,
+		WorstApp:        "1234-user-service",
+		Pending:         1,
+		BurnRates:       []models.BurnRate{{Window: "1h0m0s", Good: 99, Bad: 1, Rate: 1, Threshold: 14.4}},
+		Apps:            []models.AppFreshness{{AppID: "1234-user-service", Pending: 1, PendingLagSeconds: 95}},
+	}, nil)
+
+	router := gin.New()
+	router.GET("/freshness", handler.GetFreshnessReport)
+
+	req, _ := http.NewRequest("GET", "/freshness", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.FreshnessReport
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "1234-user-service", response.WorstApp)
+	assert.Len(t, response.Apps, 1)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestGetRuntimeStats_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("RuntimeStats", mock.Anything).Return(&models.RuntimeStats{
+		Git:    models.GitStats{OperationsTotal: 4, OperationsSucceeded: 3, OperationsFailed: 1, SuccessRatePct: 75},
+		Redis:  models.RedisStats{Reads: 10, Hits: 8, Misses: 2, HitRatePct: 80},
+		GitLab: models.GitLabStats{RequestsTotal: 2, SuccessRatePct: 100},
+	}, nil)
+
+	router := gin.New()
+	router.GET("/stats/runtime", handler.GetRuntimeStats)
+
+	req, _ := http.NewRequest("GET", "/stats/runtime", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.RuntimeStats
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), response.Git.OperationsFailed)
+	assert.Equal(t, 80.0, response.Redis.HitRatePct)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestFlushDeferredCalls_AirGapDisabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("FlushDeferredCalls", mock.Anything).Return(nil, services.ErrAirGapDisabled)
+
+	router := gin.New()
+	router.POST("/admin/outbox/flush", handler.FlushDeferredCalls)
+
+	req, _ := http.NewRequest("POST", "/admin/outbox/flush", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	var response models.ErrorResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "AIR_GAP_DISABLED", response.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestCompareVersions_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("CompareVersions", "1.2.3", "1.4.0").Return(&models.CompareResponse{
+		V1:     "1.2.3",
+		V2:     "1.4.0",
+		Result: -1,
+		Order:  "lower",
+		Diff:   "minor",
+	}, nil)
+
+	router := gin.New()
+	router.GET("/version/compare", handler.CompareVersions)
+	router.GET("/version/:app-id", handler.GetVersion)
+
+	req, _ := http.NewRequest("GET", "/version/compare?v1=1.2.3&v2=1.4.0", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.CompareResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, -1, response.Result)
+	assert.Equal(t, "minor", response.Diff)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestCompareVersions_MissingParameter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	router := gin.New()
+	router.GET("/version/compare", handler.CompareVersions)
+
+	req, _ := http.NewRequest("GET", "/version/compare?v1=1.2.3", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "VERSIONS_REQUIRED")
+
+	mockService.AssertNotCalled(t, "CompareVersions", mock.Anything, mock.Anything)
+}
+
+func TestUpdateVersionMetadata_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	ticket := "REL-42"
+	updated := &models.AppVersion{
+		Current:     "1.2.3",
+		ProjectID:   "1234",
+		AppName:     "user-service",
+		Annotations: map[string]string{"jira_ticket": "REL-42"},
+	}
+	mockService.On("UpdateVersionMetadata", mock.Anything, "1234-user-service",
+		map[string]*string{"jira_ticket": &ticket, "released_by": nil}).Return(updated, nil)
+
+	router := gin.New()
+	router.PATCH("/version/:app-id/metadata", handler.UpdateVersionMetadata)
+
+	body := `{"annotations": {"jira_ticket": "REL-42", "released_by": null}}`
+	req, _ := http.NewRequest("PATCH", "/version/1234-user-service/metadata", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.AppVersion
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "REL-42", response.Annotations["jira_ticket"])
+
+	mockService.AssertExpectations(t)
+}
+
+func TestUpdateVersionMetadata_Invalid(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("UpdateVersionMetadata", mock.Anything, "1234-user-service", mock.Anything).
+		Return(nil, fmt.Errorf("%w: annotation key \"Jira\" must be lowercase letters, digits, '.', '_' or '-'", services.ErrInvalidMetadata))
+
+	router := gin.New()
+	router.PATCH("/version/:app-id/metadata", handler.UpdateVersionMetadata)
+
+	req, _ := http.NewRequest("PATCH", "/version/1234-user-service/metadata", strings.NewReader(`{"annotations": {"Jira": "REL-42"}}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_METADATA")
+
+	mockService.AssertExpectations(t)
+}
+
+func TestGetDevVersion_NextBase(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	expected := &models.DevVersionRequest{SHA: "abc1234567890", Branch: "main", Base: models.DevBaseNext}
+	mockService.On("GetDevVersion", mock.Anything, "1234-user-service", expected).
+		Return(&models.VersionResponse{Version: "1.2.4-main.abc1234"}, nil)
+
+	router := gin.New()
+	router.POST("/version/:app-id/dev", handler.GetDevVersion)
+
+	req, _ := http.NewRequest("POST", "/version/1234-user-service/dev?base=next", strings.NewReader(`{"sha":"abc1234567890","branch":"main"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "1.2.4-main.abc1234")
+	mockService.AssertExpectations(t)
+}
+
+func TestListDevVersions_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	issued := &models.DevVersionsResponse{
+		AppID:     "1234-user-service",
+		Retention: "720h0m0s",
+		Versions: []models.DevVersionRecord{
+			{Version: "1.2.3-dev-abc1234", SHA: "abc1234567", Branch: "feature/login", Counter: 2, IssuedAt: time.Now()},
+		},
+	}
+	mockService.On("ListDevVersions", mock.Anything, "1234-user-service", "feature/login").Return(issued, nil)
+
+	router := gin.New()
+	router.GET("/version/:app-id/dev/issued", handler.ListDevVersions)
+
+	req, _ := http.NewRequest("GET", "/version/1234-user-service/dev/issued?branch=feature/login", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.DevVersionsResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	if assert.Len(t, response.Versions, 1) {
+		assert.Equal(t, "1.2.3-dev-abc1234", response.Versions[0].Version)
+		assert.Equal(t, int64(2), response.Versions[0].Counter)
+	}
+
+	mockService.AssertExpectations(t)
+}
+
+func TestListDevVersions_Disabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("ListDevVersions", mock.Anything, "1234-user-service", "").Return(nil, services.ErrDevTrackingDisabled)
+
+	router := gin.New()
+	router.GET("/version/:app-id/dev/issued", handler.ListDevVersions)
+
+	req, _ := http.NewRequest("GET", "/version/1234-user-service/dev/issued", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "DEV_TRACKING_DISABLED")
+
+	mockService.AssertExpectations(t)
+}
+
+func TestCreateWebhook_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	expected := &models.CreateWebhookRequest{
+		URL:    "https://hooks.example.com/versions",
+		Secret: "s3cret",
+		Events: []string{models.EventPostIncrement},
+	}
+	mockService.On("CreateWebhook", mock.Anything, "1234", expected).Return(&models.WebhookSubscription{
+		ID:        "a1b2c3d4e5f60718",
+		ProjectID: "1234",
+		URL:       expected.URL,
+		HasSecret: true,
+		Events:    expected.Events,
+	}, nil)
+
+	router := gin.New()
+	router.POST("/projects/:project-id/webhooks", handler.CreateWebhook)
+
+	body := `{"url":"https://hooks.example.com/versions","secret":"s3cret","events":["post_increment"]}`
+	req, _ := http.NewRequest("POST", "/projects/1234/webhooks", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.NotContains(t, w.Body.String(), "s3cret")
+
+	var response models.WebhookSubscription
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "a1b2c3d4e5f60718", response.ID)
+	assert.True(t, response.HasSecret)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestCreateWebhook_Invalid(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("CreateWebhook", mock.Anything, "1234", mock.Anything).Return(nil, services.ErrInvalidWebhook)
+
+	router := gin.New()
+	router.POST("/projects/:project-id/webhooks", handler.CreateWebhook)
+
+	req, _ := http.NewRequest("POST", "/projects/1234/webhooks", strings.NewReader(`{"url":"ftp://example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_WEBHOOK")
+
+	mockService.AssertExpectations(t)
+}
+
+func TestDeleteWebhook_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("DeleteWebhook", mock.Anything, "1234", "missing").Return(services.ErrWebhookNotFound)
+
+	router := gin.New()
+	router.DELETE("/projects/:project-id/webhooks/:webhook-id", handler.DeleteWebhook)
+
+	req, _ := http.NewRequest("DELETE", "/projects/1234/webhooks/missing", nil)
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "WEBHOOK_NOT_FOUND")
+
+	mockService.AssertExpectations(t)
 }
 
-func TestDeleteVersion_Success(t *testing.T) {
+func TestListWebhooks_RequiresProjectAccess(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockService := new(MockVersionService)
 	handler := NewHandler(mockService, logrus.New())
 
-	// Mock both GetVersion and DeleteVersion calls
-	mockService.On("GetVersion", mock.Anything, "1234-user-service").Return(&models.AppVersion{
-		Current:   "1.0.0",
-		ProjectID: "1234",
-		AppName:   "user-service",
+	mockService.On("CanAccessProject", mock.Anything, "1234").Return(false, nil)
+
+	router := gin.New()
+	router.GET("/projects/:project-id/webhooks", middleware.ProjectAuthMiddleware("admin", mockService.CanAccessProject), handler.ListWebhooks)
+
+	req, _ := http.NewRequest("GET", "/projects/1234/webhooks", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	req, _ = http.NewRequest("GET", "/projects/1234/webhooks", nil)
+	req.Header.Set("JOB-TOKEN", "other-project-token")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	mockService.On("ListWebhooks", mock.Anything, "1234").Return(&models.WebhookListResponse{ProjectID: "1234"}, nil)
+	req, _ = http.NewRequest("GET", "/projects/1234/webhooks", nil)
+	req.Header.Set("Authorization", "Bearer admin")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestIncrementVersion_RequiresAPIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("GetVersion", mock.Anything, "1234-user-service").Return(&models.AppVersion{Current: "1.2.0"}, nil)
+	mockService.On("IncrementVersion", mock.Anything, "1234-user-service", models.IncrementTypeRC, "").
+		Return(&models.VersionResponse{Version: "1.3.0-rc.1"}, nil)
+
+	router := gin.New()
+	router.Use(middleware.APIKeyMiddleware(map[string]string{"ci-key": "release-bot"}, false, "admin", logrus.New()))
+	router.GET("/version/:app-id", handler.GetVersion)
+	router.POST("/version/:app-id/increment", handler.IncrementVersion)
+
+	req, _ := http.NewRequest("GET", "/version/1234-user-service", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, "reads stay open")
+
+	req, _ = http.NewRequest("POST", "/version/1234-user-service/increment?type=rc", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	req, _ = http.NewRequest("GET", "/version/1234-user-service", nil)
+	req.Header.Set("X-API-Key", "wrong-key")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "invalid keys are rejected on reads too")
+
+	for _, header := range [][2]string{{"X-API-Key", "ci-key"}, {"Authorization", "Bearer ci-key"}, {"Authorization", "Bearer admin"}} {
+		req, _ = http.NewRequest("POST", "/version/1234-user-service/increment?type=rc", nil)
+		req.Header.Set(header[0], header[1])
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusAccepted, w.Code, header[1])
+	}
+
+	mockService.AssertExpectations(t)
+}
+
+func TestIncrementVersion_RateLimited(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+	limiter, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logrus.New())
+	assert.NoError(t, err)
+
+	mockService.On("GetVersion", mock.Anything, "1234-user-service").Return(&models.AppVersion{Current: "1.2.0"}, nil)
+	mockService.On("IncrementVersion", mock.Anything, "1234-user-service", models.IncrementTypeRC, "").
+		Return(&models.VersionResponse{Version: "1.3.0-rc.1"}, nil)
+
+	router := gin.New()
+	router.Use(middleware.APIKeyMiddleware(map[string]string{"ci-key": "release-bot", "other-key": "deploy-bot"}, false, "admin", logrus.New()))
+	router.Use(middleware.RateLimitMiddleware(limiter.TakeToken, middleware.RateLimitOptions{WriteRequests: 2, Window: time.Minute}, "admin", logrus.New()))
+	router.GET("/version/:app-id", handler.GetVersion)
+	router.POST("/version/:app-id/increment", handler.IncrementVersion)
+
+	increment := func(key string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/version/1234-user-service/increment?type=rc", nil)
+		req.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusAccepted, increment("ci-key").Code)
+	assert.Equal(t, http.StatusAccepted, increment("ci-key").Code)
+	w := increment("ci-key")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "RATE_LIMITED")
+
+	assert.Equal(t, http.StatusAccepted, increment("other-key").Code, "keys have their own limits")
+	assert.Equal(t, http.StatusAccepted, increment("admin").Code, "the admin token isn't limited")
+
+	req, _ := http.NewRequest("GET", "/version/1234-user-service", nil)
+	req.Header.Set("X-API-Key", "ci-key")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, "reads aren't limited by the write limit")
+
+	mockService.AssertExpectations(t)
+}
+
+func TestIncrementVersion_RBAC(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+	handler.SetRBACPolicy(models.NewRBACPolicy([]models.RoleGrant{
+		{Subject: "api_key:team-a", Role: models.RoleWrite, ProjectID: "1234"},
+		{Subject: "api_key:team-b", Role: models.RoleWrite, ProjectID: "5678"},
+	}))
+
+	mockService.On("AppProjectID", mock.Anything, "1234-user-service").Return("1234", nil)
+	mockService.On("IncrementVersion", mock.Anything, "1234-user-service", models.IncrementType(""), "").
+		Return(&models.VersionResponse{Version: "1.2.1"}, nil).Once()
+
+	router := gin.New()
+	router.Use(middleware.APIKeyMiddleware(map[string]string{"key-a": "team-a", "key-b": "team-b"}, false, "admin", logrus.New()))
+	router.POST("/version/:app-id/increment", handler.IncrementVersion)
+	router.POST("/versions/increment", handler.IncrementVersions)
+
+	req, _ := http.NewRequest("POST", "/version/1234-user-service/increment", nil)
+	req.Header.Set("X-API-Key", "key-b")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code, "team B can't bump team A's app")
+	assert.Contains(t, w.Body.String(), "FORBIDDEN")
+
+	req, _ = http.NewRequest("POST", "/versions/increment", strings.NewReader(`{"app_ids":["1234-user-service"],"type":"patch"}`))
+	req.Header.Set("X-API-Key", "key-b")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code, "nor as part of a batch")
+
+	req, _ = http.NewRequest("POST", "/version/1234-user-service/increment", nil)
+	req.Header.Set("X-API-Key", "key-a")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestGetVersion_RBACCacheHit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+	handler.SetRBACPolicy(models.NewRBACPolicy([]models.RoleGrant{
+		{Subject: "api_key:team-a", Role: models.RoleRead, ProjectID: "1234"},
+		{Subject: "api_key:team-b", Role: models.RoleRead, ProjectID: "5678"},
+	}))
+	cache := middleware.NewResponseCache(time.Minute, 100, nil, nil, logrus.New())
+	cache.SetPrivate(true)
+
+	mockService.On("AppProjectID", mock.Anything, "1234-user-service").Return("1234", nil)
+	mockService.On("GetVersion", mock.Anything, "1234-user-service").
+		Return(&models.AppVersion{Current: "1.0.0", ProjectID: "1234", AppName: "user-service"}, nil).Once()
+
+	router := gin.New()
+	router.Use(middleware.APIKeyMiddleware(map[string]string{"key-a": "team-a", "key-b": "team-b"}, true, "admin", logrus.New()))
+	router.GET("/version/:app-id", handler.AuthorizeRead(), cache.Cache(), handler.GetVersion)
+
+	get := func(key string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/version/1234-user-service", nil)
+		req.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusForbidden, get("key-b").Code)
+
+	w := get("key-a")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "MISS", w.Header().Get("X-Cache"))
+	assert.Equal(t, "private, max-age=0", w.Header().Get("Cache-Control"), "shared caches must not serve it to other callers")
+	assert.Contains(t, w.Header().Get("Vary"), "X-API-Key")
+
+	w = get("key-b")
+	assert.Equal(t, http.StatusForbidden, w.Code, "a cached read is not served to a caller without the role")
+	assert.NotContains(t, w.Body.String(), "1.0.0")
+
+	w = get("key-a")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "HIT", w.Header().Get("X-Cache"))
+
+	mockService.AssertExpectations(t)
+}
+
+// signTestJWT signs claims as an ES256 JWT with the key ID "test"
+func signTestJWT(t *testing.T, key *ecdsa.PrivateKey, claims map[string]any) string {
+	header, _ := json.Marshal(map[string]string{"alg": "ES256", "kid": "test", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	digest := sha256.Sum256([]byte(signed))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature := append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestIncrementVersion_OIDCToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var provider *httptest.Server
+	provider = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(map[string]string{"issuer": provider.URL, "jwks_uri": provider.URL + "/oauth/discovery/keys"})
+		case "/oauth/discovery/keys":
+			json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+				"kty": "EC", "kid": "test", "use": "sig", "crv": "P-256",
+				"x": base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+				"y": base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+			}}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer provider.Close()
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	fromPipeline := mock.MatchedBy(func(ctx context.Context) bool {
+		identity, ok := models.IdentityFrom(ctx)
+		return ok && identity.Type == models.IdentityOIDC &&
+			identity.Subject == "project_path:group/api:ref_type:branch:ref:main" &&
+			identity.Claims["project_path"] == "group/api" && identity.Claims["pipeline_id"] == "4711"
+	})
+	mockService.On("IncrementVersion", fromPipeline, "1234-user-service", models.IncrementTypeRC, "").
+		Return(&models.VersionResponse{Version: "1.3.0-rc.1"}, nil)
+
+	verifier := clients.NewOIDCVerifier(provider.URL, []string{"version-service"}, "", time.Hour, time.Second)
+	router := gin.New()
+	router.Use(middleware.OIDCMiddleware(verifier.Verify, "sub", []string{"project_path", "pipeline_id"}, "admin", logrus.New()))
+	router.Use(middleware.APIKeyMiddleware(nil, false, "admin", logrus.New()))
+	router.POST("/version/:app-id/increment", handler.IncrementVersion)
+
+	claims := map[string]any{
+		"iss":          provider.URL,
+		"aud":          "version-service",
+		"sub":          "project_path:group/api:ref_type:branch:ref:main",
+		"project_path": "group/api",
+		"pipeline_id":  4711,
+		"exp":          time.Now().Add(5 * time.Minute).Unix(),
+	}
+	req, _ := http.NewRequest("POST", "/version/1234-user-service/increment?type=rc", nil)
+	req.Header.Set("Authorization", "Bearer "+signTestJWT(t, key, claims))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	for name, change := range map[string]func(){
+		"expired":        func() { claims["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"other audience": func() { claims["aud"] = []string{"other-service"} },
+		"other issuer":   func() { claims["iss"] = "https://gitlab.example.com" },
+	} {
+		original := maps.Clone(claims)
+		change()
+		req, _ = http.NewRequest("POST", "/version/1234-user-service/increment?type=rc", nil)
+		req.Header.Set("Authorization", "Bearer "+signTestJWT(t, key, claims))
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, name)
+		assert.Contains(t, w.Body.String(), "INVALID_TOKEN", name)
+		claims = original
+	}
+
+	req, _ = http.NewRequest("POST", "/version/1234-user-service/increment?type=rc", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "writes need a token")
+
+	mockService.AssertExpectations(t)
+}
+
+func TestRenameVersion_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("RenameVersion", mock.Anything, "1234-user-service", "1234-accounts").Return(&models.RenameResponse{
+		AppID:         "1234-accounts",
+		PreviousAppID: "1234-user-service",
+		Version: &models.AppVersion{
+			Current:     "1.4.0",
+			ProjectID:   "1234",
+			AppName:     "accounts",
+			RenamedFrom: []string{"1234-user-service"},
+		},
 	}, nil)
-	mockService.On("DeleteVersion", mock.Anything, "1234-user-service").Return(nil)
 
 	router := gin.New()
-	router.DELETE("/delete/:id", handler.DeleteVersion)
+	router.POST("/version/:app-id/rename", handler.RenameVersion)
 
-	req, _ := http.NewRequest("DELETE", "/delete/1234-user-service", nil)
+	req, _ := http.NewRequest("POST", "/version/1234-user-service/rename", strings.NewReader(`{"new_app_id":"1234-accounts"}`))
+	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
 
-	var response map[string]string
+	var response models.RenameResponse
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	assert.NoError(t, err)
-	assert.Equal(t, "Version deleted successfully", response["message"])
-	assert.Equal(t, "1234-user-service", response["app_id"])
+	assert.Equal(t, "1234-accounts", response.AppID)
+	assert.Equal(t, []string{"1234-user-service"}, response.Version.RenamedFrom)
 
 	mockService.AssertExpectations(t)
 }
 
-func TestDeleteProject_Success(t *testing.T) {
+func TestRenameVersion_TargetExists(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockService := new(MockVersionService)
 	handler := NewHandler(mockService, logrus.New())
 
-	mockService.On("DeleteProject", mock.Anything, "1234").Return(nil)
+	mockService.On("RenameVersion", mock.Anything, "1234-user-service", "1234-accounts").Return(nil, services.ErrAppExists)
 
 	router := gin.New()
-	router.DELETE("/delete/:id", handler.DeleteVersion)
+	router.POST("/version/:app-id/rename", handler.RenameVersion)
 
-	req, _ := http.NewRequest("DELETE", "/delete/1234", nil)
+	req, _ := http.NewRequest("POST", "/version/1234-user-service/rename", strings.NewReader(`{"new_app_id":"1234-accounts"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "APP_EXISTS")
+
+	mockService.AssertExpectations(t)
+}
+
+func TestListStaleVersions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("ListStaleVersions", mock.Anything, 30).Return(&models.StaleReport{
+		Days:  30,
+		Apps:  []models.StaleApp{{AppID: "1234-legacy-app", Version: "0.9.1", IdleDays: 412}},
+		Count: 1,
+	}, nil)
+
+	router := gin.New()
+	router.GET("/versions/stale", handler.ListStaleVersions)
+
+	req, _ := http.NewRequest("GET", "/versions/stale?days=30", nil)
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
 
-	var response map[string]string
+	var response models.StaleReport
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	assert.NoError(t, err)
-	assert.Equal(t, "Project deleted successfully", response["message"])
-	assert.Equal(t, "1234", response["project_id"])
+	assert.Equal(t, 1, response.Count)
+	assert.Equal(t, "1234-legacy-app", response.Apps[0].AppID)
+
+	req, _ = http.NewRequest("GET", "/versions/stale?days=0", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_DAYS")
+
+	mockService.AssertExpectations(t)
+}
+
+func TestSetIncrementStrategy_Invalid(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("SetIncrementStrategy", mock.Anything, "1234-user-service", "build").
+		Return(nil, fmt.Errorf("%w: build needs the four-part scheme", services.ErrInvalidStrategy))
+
+	router := gin.New()
+	router.PUT("/version/:app-id/strategy", handler.SetIncrementStrategy)
+
+	req, _ := http.NewRequest("PUT", "/version/1234-user-service/strategy", strings.NewReader(`{"strategy":"build"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_STRATEGY")
+	mockService.AssertExpectations(t)
+}
+
+func TestSetLifecycle_TransitionNotAllowed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("SetLifecycle", mock.Anything, "1234-user-service", models.LifecycleFrozen).
+		Return(nil, fmt.Errorf("%w: 1234-user-service cannot move from archived to frozen", services.ErrLifecycleTransition))
 
+	router := gin.New()
+	router.PUT("/version/:app-id/lifecycle", handler.SetLifecycle)
+
+	req, _ := http.NewRequest("PUT", "/version/1234-user-service/lifecycle", strings.NewReader(`{"state":"frozen"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "LIFECYCLE_TRANSITION_NOT_ALLOWED")
 	mockService.AssertExpectations(t)
-}
\ No newline at end of file
+}
+
+func TestListVersions_LifecycleFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockVersionService)
+	handler := NewHandler(mockService, logrus.New())
+
+	mockService.On("ListVersions", mock.Anything).Return(map[string]*models.AppVersion{
+		"1234-user-service":    {Current: "1.2.3"},
+		"1234-legacy-service":  {Current: "0.9.0", Lifecycle: models.LifecycleDeprecated},
+		"1234-billing-service": {Current: "2.0.0", Locked: true},
+	}, nil)
+
+	router := gin.New()
+	router.GET("/versions", handler.ListVersions)
+
+	req, _ := http.NewRequest("GET", "/versions?lifecycle=deprecated,frozen", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response map[string]*models.AppVersion
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response, 2)
+	assert.Contains(t, response, "1234-legacy-service")
+	assert.Contains(t, response, "1234-billing-service")
+
+	req, _ = http.NewRequest("GET", "/versions?lifecycle=retired", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// authorizeApp checks that the caller holds role on the project of appID
+// and answers 403 if not. Apps whose project can't be told, such as deleted
+// opaque IDs, need a grant on every project.
+func (h *Handler) authorizeApp(c *gin.Context, appID string, role models.Role) bool {
+	identity, ok := models.IdentityFrom(c.Request.Context())
+	if h.rbac == nil || !ok {
+		return true
+	}
+
+	projectID, err := h.service.AppProjectID(c.Request.Context(), appID)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid app ID") {
+			h.errorResponse(c, http.StatusBadRequest, "INVALID_APP_ID", "Invalid app ID format", err.Error())
+			return false
+		}
+		projectID = models.ProjectWildcard
+	}
+	if projectID == "" {
+		// Unregistered opaque IDs have nothing to protect yet
+		return true
+	}
+	return h.checkRole(c, identity, projectID, role)
+}
+
+// AuthorizeRead checks the caller's read role on the app, project or, for
+// listings, every project a route names. It runs ahead of the response
+// cache, which would otherwise answer hits before the handler checks roles.
+func (h *Handler) AuthorizeRead() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var allowed bool
+		switch {
+		case c.Param("app-id") != "":
+			allowed = h.authorizeApp(c, c.Param("app-id"), models.RoleRead)
+		case c.Param("project-id") != "":
+			allowed = h.authorizeProject(c, c.Param("project-id"), models.RoleRead)
+		default:
+			allowed = h.authorizeProject(c, models.ProjectWildcard, models.RoleRead)
+		}
+		if !allowed {
+			c.Abort()
+		}
+	}
+}
+
+// authorizeProject checks that the caller holds role on projectID, or on
+// every project for models.ProjectWildcard, and answers 403 if not
+func (h *Handler) authorizeProject(c *gin.Context, projectID string, role models.Role) bool {
+	identity, ok := models.IdentityFrom(c.Request.Context())
+	if h.rbac == nil || !ok {
+		return true
+	}
+	return h.checkRole(c, identity, projectID, role)
+}
+
+func (h *Handler) checkRole(c *gin.Context, identity models.Identity, projectID string, role models.Role) bool {
+	if h.rbac.Allows(identity, projectID, role) {
+		return true
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"identity":   identity.Type,
+		"subject":    identity.Subject,
+		"project_id": projectID,
+		"role":       role,
+		"method":     c.Request.Method,
+		"path":       c.Request.URL.Path,
+	}).Warn("Request denied by role")
+
+	scope := "project " + projectID
+	if projectID == models.ProjectWildcard {
+		scope = "every project"
+	}
+	h.errorResponse(c, http.StatusForbidden, "FORBIDDEN", fmt.Sprintf("The %s role on %s is required", role, scope), "")
+	return false
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAuthMiddleware guards administrative endpoints with a shared bearer
+// token. When no token is configured, admin endpoints are disabled entirely.
+func AdminAuthMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{
+				Error: "Admin API is disabled",
+				Code:  "ADMIN_DISABLED",
+			})
+			return
+		}
+
+		provided := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error: "Admin token required",
+				Code:  "UNAUTHORIZED",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
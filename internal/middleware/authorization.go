@@ -0,0 +1,210 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/company/version-service/internal/clients"
+	"github.com/company/version-service/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// authorizationActions names the action of each route sent to the policy
+// engine, keyed by method and route pattern
+var authorizationActions = map[string]string{
+	"GET /version/compare":                              "version.compare",
+	"GET /version/:app-id":                              "version.read",
+	"POST /version/:app-id/increment":                   "version.increment",
+	"GET /version/:app-id/next":                         "version.preview",
+	"POST /version/:app-id/dev":                         "version.dev",
+	"GET /version/:app-id/dev/issued":                   "version.dev.list",
+	"GET /version/:app-id/history":                      "version.history",
+	"GET /version/:app-id/alias/:name":                  "version.alias.read",
+	"PUT /version/:app-id/alias/:name":                  "version.alias.set",
+	"PATCH /version/:app-id/metadata":                   "version.metadata.update",
+	"GET /version/:app-id/changelog":                    "version.changelog",
+	"GET /version/:app-id/reserved":                     "version.reserved.read",
+	"PUT /version/:app-id/reserved":                     "version.reserved.set",
+	"POST /version/:app-id/rollback":                    "version.rollback",
+	"POST /version/:app-id/decrement":                   "version.decrement",
+	"POST /version/:app-id/promote":                     "version.promote",
+	"POST /version/:app-id/graduate":                    "version.graduate",
+	"POST /version/:app-id/lock":                        "version.lock",
+	"POST /version/:app-id/unlock":                      "version.unlock",
+	"PUT /version/:app-id/lifecycle":                    "version.lifecycle",
+	"PUT /version/:app-id/strategy":                     "version.strategy",
+	"PUT /version/:app-id/initial-development":          "version.initial_development",
+	"POST /version/:app-id/restore":                     "version.restore",
+	"POST /version/:app-id/rename":                      "version.rename",
+	"DELETE /delete/:id":                                "version.delete",
+	"POST /apps":                                        "app.register",
+	"GET /versions":                                     "versions.list",
+	"POST /versions/increment":                          "versions.increment",
+	"GET /versions/raw":                                 "versions.raw.read",
+	"PUT /versions/raw":                                 "versions.raw.replace",
+	"GET /versions/stale":                               "versions.stale",
+	"GET /versions/:project-id":                         "project.versions",
+	"GET /projects/:project-id/usage":                   "project.usage",
+	"POST /projects/:project-id/simulate":               "project.simulate",
+	"GET /projects/:project-id/reserved":                "project.reserved.read",
+	"PUT /projects/:project-id/reserved":                "project.reserved.set",
+	"GET /projects/:project-id/webhooks":                "project.webhooks.list",
+	"POST /projects/:project-id/webhooks":               "project.webhooks.create",
+	"DELETE /projects/:project-id/webhooks/:webhook-id": "project.webhooks.delete",
+	"GET /discovery":                                    "discovery.read",
+	"POST /discovery/run":                               "discovery.run",
+	"POST /admin/cache/purge":                           "cache.purge",
+	"POST /admin/cache/rebuild":                         "cache.rebuild",
+	"GET /admin/state":                                  "state.export",
+	"PUT /admin/state":                                  "state.import",
+	"POST /admin/projects/migrate":                      "projects.migrate",
+	"GET /admin/outbox":                                 "outbox.list",
+	"POST /admin/outbox/flush":                          "outbox.flush",
+}
+
+// AuthorizationAction returns the policy action of a route. Routes without a
+// name fall back to "METHOD /route".
+func AuthorizationAction(method, route string) string {
+	key := method + " " + route
+	if action, ok := authorizationActions[key]; ok {
+		return action
+	}
+	return key
+}
+
+// RequestIdentity reports who sent the request: the admin token holder, the
+// caller authenticated by an API key or OIDC token, a GitLab CI job whose
+// token DelegatedTokenMiddleware verified, or an anonymous caller. An
+// unverified job token header counts as anonymous.
+func RequestIdentity(c *gin.Context, adminToken string) models.Identity {
+	bearer := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if adminToken != "" && subtle.ConstantTimeCompare([]byte(bearer), []byte(adminToken)) == 1 {
+		return models.Identity{Type: models.IdentityAdmin}
+	}
+	if identity, ok := models.IdentityFrom(c.Request.Context()); ok {
+		return identity
+	}
+	if job := clients.DelegatedJob(c.Request.Context()); job != nil {
+		return models.Identity{
+			Type:    models.IdentityGitLabJob,
+			Subject: strconv.FormatInt(job.ID, 10),
+			Claims: map[string]string{
+				"project_id": strconv.FormatInt(job.Pipeline.ProjectID, 10),
+				"ref":        job.Ref,
+			},
+		}
+	}
+	return models.Identity{Type: models.IdentityAnonymous}
+}
+
+// AuthorizationMiddleware asks an external policy engine whether the caller
+// may perform the request's action on its app or project. It runs in
+// addition to the admin and project token checks. When the engine fails,
+// requests are rejected with 503 unless failOpen is set.
+func AuthorizationMiddleware(adminToken string, idScheme models.IDScheme, authorize func(ctx context.Context, input *models.AuthorizationInput) (*models.AuthorizationDecision, error), failOpen bool, logger *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		input := authorizationInput(c, adminToken, idScheme)
+
+		decision, err := authorize(c.Request.Context(), input)
+		if err != nil {
+			RecordAuthorizationDecision(input.Action, "error")
+			if failOpen {
+				logger.WithError(err).WithField("action", input.Action).Warn("Policy engine failed, allowing request")
+				c.Next()
+				return
+			}
+			logger.WithError(err).WithField("action", input.Action).Error("Policy engine failed")
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, models.ErrorResponse{
+				Error:   "Authorization is unavailable",
+				Code:    "AUTHORIZATION_UNAVAILABLE",
+				Details: err.Error(),
+			})
+			return
+		}
+
+		if !decision.Allow {
+			RecordAuthorizationDecision(input.Action, "denied")
+			logger.WithFields(logrus.Fields{
+				"action":   input.Action,
+				"identity": input.Identity.Type,
+				"app_id":   input.AppID,
+				"reason":   decision.Reason,
+			}).Info("Request denied by policy")
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "Request denied by policy",
+				Code:    "POLICY_DENIED",
+				Details: decision.Reason,
+			})
+			return
+		}
+
+		RecordAuthorizationDecision(input.Action, "allowed")
+		c.Next()
+	}
+}
+
+// authorizationInput collects what the policy engine is told about a request
+func authorizationInput(c *gin.Context, adminToken string, idScheme models.IDScheme) *models.AuthorizationInput {
+	input := &models.AuthorizationInput{
+		Identity:  RequestIdentity(c, adminToken),
+		Action:    AuthorizationAction(c.Request.Method, c.FullPath()),
+		ProjectID: c.Param("project-id"),
+		Method:    c.Request.Method,
+		Path:      c.Request.URL.Path,
+	}
+	if attribution, ok := models.AttributionFrom(c.Request.Context()); ok {
+		input.OnBehalfOf = attribution.Actor
+	}
+
+	input.AppID = c.Param("app-id")
+	if input.AppID == "" {
+		input.AppID = c.Param("id")
+	}
+	if input.AppID != "" {
+		// Opaque IDs carry no project; the policy sees the app ID only
+		if id, err := idScheme.Parse(input.AppID); err == nil {
+			input.ProjectID = id.ProjectID
+		}
+	}
+
+	input.IncrementType = models.IncrementType(c.Query("type"))
+	if c.Request.Method == http.MethodPost && c.FullPath() == "/versions/increment" {
+		readBatchIncrement(c, input)
+	}
+	if input.Action == "version.increment" && input.IncrementType == "" {
+		input.IncrementType = models.IncrementTypePatch
+	}
+
+	return input
+}
+
+// readBatchIncrement copies the apps and increment type of a batch increment
+// into input, leaving the body in place for the handler. Malformed bodies are
+// left to the handler to reject, and so are bodies that can't be read, such
+// as those over the size limit, whose reader keeps failing.
+func readBatchIncrement(c *gin.Context, input *models.AuthorizationInput) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	var req models.BatchIncrementRequest
+	if json.Unmarshal(body, &req) != nil {
+		return
+	}
+	input.AppIDs = req.AppIDs
+	if req.Type != "" {
+		input.IncrementType = req.Type
+	}
+	if input.IncrementType == "" {
+		input.IncrementType = models.IncrementTypePatch
+	}
+}
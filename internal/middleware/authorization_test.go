@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/company/version-service/internal/clients"
+	"github.com/company/version-service/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func authorizationRouter(authorize func(ctx context.Context, input *models.AuthorizationInput) (*models.AuthorizationDecision, error), failOpen bool) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	router := gin.New()
+	router.Use(AuthorizationMiddleware("admin-token", models.DefaultIDScheme(), authorize, failOpen, logger))
+	router.POST("/version/:app-id/increment", func(c *gin.Context) {
+		c.String(http.StatusOK, "incremented")
+	})
+	router.POST("/versions/increment", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, string(body))
+	})
+	return router
+}
+
+func TestAuthorizationMiddleware_Decisions(t *testing.T) {
+	var seen *models.AuthorizationInput
+	decide := func(allow bool, err error) func(context.Context, *models.AuthorizationInput) (*models.AuthorizationDecision, error) {
+		return func(ctx context.Context, input *models.AuthorizationInput) (*models.AuthorizationDecision, error) {
+			seen = input
+			if err != nil {
+				return nil, err
+			}
+			return &models.AuthorizationDecision{Allow: allow, Reason: "frozen"}, nil
+		}
+	}
+	engineDown := errors.New("opa unreachable")
+
+	tests := []struct {
+		name      string
+		authorize func(context.Context, *models.AuthorizationInput) (*models.AuthorizationDecision, error)
+		failOpen  bool
+		status    int
+		code      string
+	}{
+		{"allowed", decide(true, nil), false, http.StatusOK, ""},
+		{"denied", decide(false, nil), false, http.StatusForbidden, "POLICY_DENIED"},
+		{"engine error fails closed", decide(false, engineDown), false, http.StatusServiceUnavailable, "AUTHORIZATION_UNAVAILABLE"},
+		{"engine error fails open", decide(false, engineDown), true, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen = nil
+			router := authorizationRouter(tt.authorize, tt.failOpen)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/version/42-api/increment?type=minor", nil))
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.code != "" {
+				assert.Contains(t, w.Body.String(), tt.code)
+			}
+			require.NotNil(t, seen)
+			assert.Equal(t, "version.increment", seen.Action)
+			assert.Equal(t, "42-api", seen.AppID)
+			assert.Equal(t, "42", seen.ProjectID)
+			assert.Equal(t, models.IncrementTypeMinor, seen.IncrementType)
+			assert.Equal(t, models.IdentityAnonymous, seen.Identity.Type)
+		})
+	}
+}
+
+func TestAuthorizationMiddleware_BatchBodyStaysReadable(t *testing.T) {
+	var seen *models.AuthorizationInput
+	router := authorizationRouter(func(ctx context.Context, input *models.AuthorizationInput) (*models.AuthorizationDecision, error) {
+		seen = input
+		return &models.AuthorizationDecision{Allow: true}, nil
+	}, false)
+
+	body := `{"app_ids":["42-api","42-web"],"type":"major"}`
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/versions/increment", strings.NewReader(body)))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, body, w.Body.String())
+	require.NotNil(t, seen)
+	assert.Equal(t, "versions.increment", seen.Action)
+	assert.Equal(t, []string{"42-api", "42-web"}, seen.AppIDs)
+	assert.Equal(t, models.IncrementTypeMajor, seen.IncrementType)
+}
+
+func TestRequestIdentity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	identify := func(setup func(*http.Request) *http.Request) models.Identity {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = setup(httptest.NewRequest(http.MethodGet, "/", nil))
+		return RequestIdentity(c, "admin-token")
+	}
+
+	admin := identify(func(r *http.Request) *http.Request {
+		r.Header.Set("Authorization", "Bearer admin-token")
+		return r
+	})
+	assert.Equal(t, models.IdentityAdmin, admin.Type)
+
+	// A job token header alone proves nothing
+	unverified := identify(func(r *http.Request) *http.Request {
+		r.Header.Set("JOB-TOKEN", "forged")
+		return r
+	})
+	assert.Equal(t, models.IdentityAnonymous, unverified.Type)
+
+	verified := identify(func(r *http.Request) *http.Request {
+		job := &clients.GitLabJob{ID: 9, Ref: "main"}
+		job.Pipeline.ProjectID = 42
+		return r.WithContext(clients.WithDelegatedToken(r.Context(), "job-token", job))
+	})
+	assert.Equal(t, models.IdentityGitLabJob, verified.Type)
+	assert.Equal(t, "9", verified.Subject)
+	assert.Equal(t, "42", verified.Claims["project_id"])
+	assert.Equal(t, "main", verified.Claims["ref"])
+}
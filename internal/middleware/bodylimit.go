@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// BodyLimitMiddleware caps request bodies at limit bytes, or at bulkLimit
+// on bulkRoutes, such as uploads of the whole versions file. Bodies
+// declaring a larger Content-Length get 413 before any of them is read;
+// longer bodies without one fail once read past the limit, which handlers
+// answer with 413 too.
+func BodyLimitMiddleware(limit, bulkLimit int64, bulkRoutes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		maxBytes := limit
+		if slices.Contains(bulkRoutes, c.FullPath()) {
+			maxBytes = bulkLimit
+		}
+
+		if c.Request.ContentLength > maxBytes {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, models.ErrorResponse{
+				Error:   "Request body too large",
+				Code:    "REQUEST_TOO_LARGE",
+				Details: fmt.Sprintf("request bodies are limited to %d bytes", maxBytes),
+			})
+			return
+		}
+		if c.Request.Body != nil {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,428 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/company/version-service/internal/clients"
+	"github.com/company/version-service/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// Surrogate keys attached to cached responses. Writes purge the keys of the
+// app they touch, its project and every listing.
+const (
+	SurrogateKeyListings = "versions"
+	surrogateKeyApp      = "app:"
+	surrogateKeyProject  = "project:"
+)
+
+type cachedResponse struct {
+	status  int
+	header  http.Header
+	body    []byte
+	keys    []string
+	expires time.Time
+}
+
+// ResponseCache caches successful GET responses in memory, tags them with
+// surrogate keys and purges them when the resources behind those keys are
+// written. A zero TTL disables caching; every method is then a no-op.
+type ResponseCache struct {
+	ttl        time.Duration
+	maxEntries int
+	ids        models.IDScheme
+	notifier   *clients.WebhookClient
+	logger     *logrus.Logger
+	private    bool
+
+	mu      sync.Mutex
+	entries map[string]*cachedResponse
+	byKey   map[string]map[string]struct{}
+	// generation changes on every purge so responses rendered before a
+	// purge are not stored after it
+	generation uint64
+}
+
+// NewResponseCache creates a response cache. ids derives project keys from
+// app IDs (nil selects the default scheme). notifier, when set, is told about
+// every purge so a fronting CDN can drop the same surrogate keys.
+func NewResponseCache(ttl time.Duration, maxEntries int, ids models.IDScheme, notifier *clients.WebhookClient, logger *logrus.Logger) *ResponseCache {
+	if ids == nil {
+		ids = models.DefaultIDScheme()
+	}
+
+	return &ResponseCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		ids:        ids,
+		notifier:   notifier,
+		logger:     logger,
+		entries:    make(map[string]*cachedResponse),
+		byKey:      make(map[string]map[string]struct{}),
+	}
+}
+
+// SetPrivate marks responses as depending on the caller, as they do when
+// roles decide who may read them. Cache-Control then keeps shared caches
+// such as CDNs from storing them, and Vary names the credential headers.
+// Callers must check roles ahead of Cache, since hits skip the handler.
+func (rc *ResponseCache) SetPrivate(private bool) {
+	rc.private = private
+}
+
+// Enabled reports whether responses are cached
+func (rc *ResponseCache) Enabled() bool {
+	return rc != nil && rc.ttl > 0
+}
+
+// SurrogateKeys derives the surrogate keys of a request from its route
+// parameters: app:{app-id} and project:{project-id} for app routes,
+// project:{project-id} for project routes and "versions" for listings. The
+// project key of an app is omitted when the ID scheme is opaque.
+func (rc *ResponseCache) SurrogateKeys(c *gin.Context) []string {
+	keys, _ := rc.surrogateKeys(c)
+	return keys
+}
+
+// surrogateKeys also reports whether the request names an app whose project
+// cannot be derived from its ID
+func (rc *ResponseCache) surrogateKeys(c *gin.Context) ([]string, bool) {
+	var keys []string
+	opaque := false
+	if appID := c.Param("app-id"); appID != "" {
+		keys = append(keys, surrogateKeyApp+appID)
+		if id, err := rc.ids.Parse(appID); err == nil && !id.Opaque() {
+			keys = append(keys, surrogateKeyProject+id.ProjectID)
+		} else {
+			opaque = true
+		}
+	}
+	if projectID := c.Param("project-id"); projectID != "" {
+		keys = append(keys, surrogateKeyProject+projectID)
+	}
+	if appID := c.Param("id"); appID != "" {
+		// DELETE /delete/:id takes either an app ID or a project ID
+		keys = append(keys, surrogateKeyApp+appID, surrogateKeyProject+appID)
+		if id, err := rc.ids.Parse(appID); err == nil && !id.Opaque() {
+			keys = append(keys, surrogateKeyProject+id.ProjectID)
+		}
+	}
+	if len(keys) == 0 {
+		keys = append(keys, SurrogateKeyListings)
+	}
+	return keys, opaque
+}
+
+// Cache serves GET requests from the cache and stores successful responses,
+// tagged with SurrogateKeys. Responses carry Surrogate-Key and a shared-cache
+// max age so CDNs can cache them too, unless SetPrivate says they depend on
+// the caller.
+func (rc *ResponseCache) Cache() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !rc.Enabled() || c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+
+		cacheKey := c.Request.URL.RequestURI()
+		if entry := rc.lookup(cacheKey); entry != nil {
+			recordResponseCache("hit")
+			for name, values := range entry.header {
+				c.Writer.Header()[name] = values
+			}
+			c.Header("X-Cache", "HIT")
+			c.Data(entry.status, entry.header.Get("Content-Type"), entry.body)
+			c.Abort()
+			return
+		}
+		recordResponseCache("miss")
+
+		keys := rc.SurrogateKeys(c)
+		c.Header("Surrogate-Key", strings.Join(keys, " "))
+		if rc.private {
+			c.Header("Cache-Control", "private, max-age=0")
+			c.Header("Vary", "Authorization, X-API-Key")
+		} else {
+			c.Header("Cache-Control", fmt.Sprintf("public, max-age=0, s-maxage=%d", int(rc.ttl.Seconds())))
+		}
+		c.Header("X-Cache", "MISS")
+
+		generation := rc.currentGeneration()
+		writer := &cacheWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		if writer.Status() != http.StatusOK {
+			return
+		}
+
+		header := writer.Header().Clone()
+		header.Del("X-Cache")
+		rc.store(cacheKey, generation, &cachedResponse{
+			status:  writer.Status(),
+			header:  header,
+			body:    writer.body.Bytes(),
+			keys:    keys,
+			expires: time.Now().Add(rc.ttl),
+		})
+	}
+}
+
+// PurgeOnWrite purges the request's surrogate keys once a write succeeds
+func (rc *ResponseCache) PurgeOnWrite() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if !rc.Enabled() || c.Writer.Status() >= http.StatusBadRequest {
+			return
+		}
+
+		keys, opaque := rc.surrogateKeys(c)
+		if c.Param("app-id") != "" || c.Param("id") != "" {
+			keys = append(keys, SurrogateKeyListings)
+		}
+		if opaque {
+			// The app's project is unknown here, so drop every project listing
+			keys = append(keys, rc.projectKeys()...)
+		}
+		rc.Purge(keys...)
+	}
+}
+
+// PurgeAllOnWrite purges the whole cache once a write succeeds. Used for
+// writes spanning many apps (batch increments, raw file replacement).
+func (rc *ResponseCache) PurgeAllOnWrite() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if rc.Enabled() && c.Writer.Status() < http.StatusBadRequest {
+			rc.PurgeAll()
+		}
+	}
+}
+
+// Purge drops every cached response tagged with any of keys and returns the
+// number of responses removed.
+func (rc *ResponseCache) Purge(keys ...string) int {
+	if !rc.Enabled() || len(keys) == 0 {
+		return 0
+	}
+
+	purged := rc.purge(keys)
+	rc.notify(uniqueSorted(keys), false)
+	return purged
+}
+
+// PurgeChange drops the responses of the apps another replica changed, their
+// projects and every listing. The CDN webhook is not told: the replica that
+// wrote has already done so.
+func (rc *ResponseCache) PurgeChange(change models.VersionChange) {
+	if !rc.Enabled() {
+		return
+	}
+	if change.All {
+		rc.purgeAll()
+		return
+	}
+
+	keys := []string{SurrogateKeyListings}
+	opaque := false
+	for _, appID := range change.AppIDs {
+		keys = append(keys, surrogateKeyApp+appID)
+		if id, err := rc.ids.Parse(appID); err == nil && !id.Opaque() {
+			keys = append(keys, surrogateKeyProject+id.ProjectID)
+		} else {
+			opaque = true
+		}
+	}
+	if opaque {
+		keys = append(keys, rc.projectKeys()...)
+	}
+	rc.purge(keys)
+}
+
+// purge drops every cached response tagged with any of keys and returns the
+// number removed
+func (rc *ResponseCache) purge(keys []string) int {
+	rc.mu.Lock()
+	rc.generation++
+	purged := 0
+	for _, key := range keys {
+		for cacheKey := range rc.byKey[key] {
+			if _, ok := rc.entries[cacheKey]; ok {
+				rc.removeLocked(cacheKey)
+				purged++
+			}
+		}
+		delete(rc.byKey, key)
+	}
+	rc.mu.Unlock()
+	return purged
+}
+
+// PurgeAll empties the cache and returns the number of responses removed
+func (rc *ResponseCache) PurgeAll() int {
+	if !rc.Enabled() {
+		return 0
+	}
+
+	purged := rc.purgeAll()
+	rc.notify(nil, true)
+	return purged
+}
+
+// purgeAll empties the cache and returns the number of responses removed
+func (rc *ResponseCache) purgeAll() int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.generation++
+	purged := len(rc.entries)
+	rc.entries = make(map[string]*cachedResponse)
+	rc.byKey = make(map[string]map[string]struct{})
+	return purged
+}
+
+// projectKeys returns the project surrogate keys currently in use
+func (rc *ResponseCache) projectKeys() []string {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	var keys []string
+	for key := range rc.byKey {
+		if strings.HasPrefix(key, surrogateKeyProject) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (rc *ResponseCache) lookup(cacheKey string) *cachedResponse {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	entry, ok := rc.entries[cacheKey]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(entry.expires) {
+		rc.removeLocked(cacheKey)
+		return nil
+	}
+	return entry
+}
+
+func (rc *ResponseCache) currentGeneration() uint64 {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.generation
+}
+
+func (rc *ResponseCache) store(cacheKey string, generation uint64, entry *cachedResponse) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if generation != rc.generation {
+		return
+	}
+
+	if _, exists := rc.entries[cacheKey]; !exists && rc.maxEntries > 0 && len(rc.entries) >= rc.maxEntries {
+		rc.evictExpiredLocked()
+		if len(rc.entries) >= rc.maxEntries {
+			return
+		}
+	}
+
+	rc.removeLocked(cacheKey)
+	rc.entries[cacheKey] = entry
+	for _, key := range entry.keys {
+		if rc.byKey[key] == nil {
+			rc.byKey[key] = make(map[string]struct{})
+		}
+		rc.byKey[key][cacheKey] = struct{}{}
+	}
+}
+
+func (rc *ResponseCache) evictExpiredLocked() {
+	now := time.Now()
+	for cacheKey, entry := range rc.entries {
+		if now.After(entry.expires) {
+			rc.removeLocked(cacheKey)
+		}
+	}
+}
+
+func (rc *ResponseCache) removeLocked(cacheKey string) {
+	entry, ok := rc.entries[cacheKey]
+	if !ok {
+		return
+	}
+	delete(rc.entries, cacheKey)
+	for _, key := range entry.keys {
+		delete(rc.byKey[key], cacheKey)
+		if len(rc.byKey[key]) == 0 {
+			delete(rc.byKey, key)
+		}
+	}
+}
+
+// notify forwards a purge to the CDN webhook without blocking the request
+func (rc *ResponseCache) notify(keys []string, all bool) {
+	if rc.notifier == nil {
+		return
+	}
+
+	event := models.CachePurgeEvent{
+		EventMeta: models.NewEventMeta(models.EventCachePurge),
+		Keys:      keys,
+		All:       all,
+		Timestamp: time.Now(),
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := rc.notifier.Notify(ctx, event); err != nil {
+			rc.logger.WithError(err).WithField("surrogate_keys", keys).Warn("Failed to forward cache purge")
+		}
+	}()
+}
+
+func uniqueSorted(keys []string) []string {
+	seen := make(map[string]struct{}, len(keys))
+	unique := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if _, ok := seen[key]; !ok {
+			seen[key] = struct{}{}
+			unique = append(unique, key)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
+
+// cacheWriter tees the response body so it can be cached
+type cacheWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *cacheWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *cacheWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/company/version-service/internal/clients"
+	"github.com/company/version-service/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// DelegatedTokenMiddleware picks up a GitLab CI job token sent by the caller
+// (JOB-TOKEN header, as used by the GitLab API itself), has GitLab verify it
+// with verify and attaches it and its job to the request context, so GitLab
+// operations run with the pipeline's permissions. Tokens GitLab doesn't
+// accept get 401; when GitLab can't be asked, requests with tokens get 503.
+func DelegatedTokenMiddleware(verify func(ctx context.Context, token string) (*clients.GitLabJob, error), logger *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.GetHeader("JOB-TOKEN")
+		if token == "" {
+			token = c.GetHeader("X-GitLab-Job-Token")
+		}
+		if token == "" {
+			c.Next()
+			return
+		}
+
+		job, err := verify(c.Request.Context(), token)
+		if errors.Is(err, clients.ErrInvalidJobToken) {
+			logger.WithFields(logrus.Fields{
+				"method":    c.Request.Method,
+				"path":      c.Request.URL.Path,
+				"client_ip": c.ClientIP(),
+			}).Warn("Request with an invalid GitLab job token rejected")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error: "Invalid GitLab job token",
+				Code:  "INVALID_JOB_TOKEN",
+			})
+			return
+		}
+		if err != nil {
+			logger.WithError(err).Error("Failed to verify GitLab job token")
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, models.ErrorResponse{
+				Error:   "Job token verification is unavailable",
+				Code:    "AUTHENTICATION_UNAVAILABLE",
+				Details: err.Error(),
+			})
+			return
+		}
+
+		c.Request = c.Request.WithContext(clients.WithDelegatedToken(c.Request.Context(), token, job))
+		c.Next()
+	}
+}
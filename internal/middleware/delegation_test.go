@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/company/version-service/internal/clients"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func delegationRouter(verify func(ctx context.Context, token string) (*clients.GitLabJob, error)) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	router := gin.New()
+	router.Use(DelegatedTokenMiddleware(verify, logger))
+	router.GET("/", func(c *gin.Context) {
+		job := clients.DelegatedJob(c.Request.Context())
+		if job == nil {
+			c.String(http.StatusOK, "none")
+			return
+		}
+		c.String(http.StatusOK, job.Ref)
+	})
+	return router
+}
+
+func TestDelegatedTokenMiddleware(t *testing.T) {
+	verify := func(ctx context.Context, token string) (*clients.GitLabJob, error) {
+		switch token {
+		case "valid":
+			return &clients.GitLabJob{ID: 1, Ref: "main"}, nil
+		case "forged":
+			return nil, clients.ErrInvalidJobToken
+		default:
+			return nil, errors.New("gitlab unreachable")
+		}
+	}
+	router := delegationRouter(verify)
+
+	tests := []struct {
+		name   string
+		header string
+		token  string
+		status int
+		body   string
+	}{
+		{"no token", "", "", http.StatusOK, "none"},
+		{"verified", "JOB-TOKEN", "valid", http.StatusOK, "main"},
+		{"alternate header", "X-GitLab-Job-Token", "valid", http.StatusOK, "main"},
+		{"rejected", "JOB-TOKEN", "forged", http.StatusUnauthorized, "INVALID_JOB_TOKEN"},
+		{"gitlab down", "JOB-TOKEN", "other", http.StatusServiceUnavailable, "AUTHENTICATION_UNAVAILABLE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.token)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Contains(t, w.Body.String(), tt.body)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// FollowerProxy makes a replica read-only by forwarding every mutating request
+// to the primary and relaying its response. GET, HEAD and OPTIONS requests are
+// served locally, as are the routes listed in local (such as local cache
+// purges). Responses relayed from the primary carry X-Served-By: primary.
+func FollowerProxy(primary *url.URL, logger *logrus.Logger, local ...string) gin.HandlerFunc {
+	localRoutes := make(map[string]bool, len(local))
+	for _, route := range local {
+		localRoutes[route] = true
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(primary)
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		director(req)
+		req.Host = primary.Host
+	}
+	proxy.ModifyResponse = func(resp *http.Response) error {
+		resp.Header.Set("X-Served-By", "primary")
+		return nil
+	}
+	proxy.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+		logger.WithError(err).WithFields(logrus.Fields{
+			"method": req.Method,
+			"path":   req.URL.Path,
+		}).Error("Failed to proxy write to primary")
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Error:   "Primary unavailable",
+			Code:    "PRIMARY_UNAVAILABLE",
+			Details: err.Error(),
+		})
+	}
+
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		if localRoutes[c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		proxy.ServeHTTP(c.Writer, c.Request)
+		c.Abort()
+	}
+}
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// followerServer serves the follower over a real listener, since the reverse
+// proxy needs a ResponseWriter that supports CloseNotify
+func followerServer(t *testing.T, primaryURL string) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	primary, err := url.Parse(primaryURL)
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.Use(FollowerProxy(primary, logger, "/admin/cache/purge"))
+	local := func(c *gin.Context) { c.String(http.StatusOK, "local") }
+	router.GET("/version/:app-id", local)
+	router.POST("/version/:app-id/increment", local)
+	router.POST("/admin/cache/purge", local)
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFollowerProxy_ForwardsWrites(t *testing.T) {
+	var forwarded *http.Request
+	var forwardedBody string
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = r
+		body, _ := io.ReadAll(r.Body)
+		forwardedBody = string(body)
+		w.Header().Set("X-Primary", "yes")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"version":"1.0.1"}`))
+	}))
+	defer primary.Close()
+	follower := followerServer(t, primary.URL)
+
+	req, err := http.NewRequest(http.MethodPost, follower.URL+"/version/1-api/increment?type=minor", strings.NewReader(`{"sha":"abc"}`))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer token")
+	req.Header.Set("Idempotency-Key", "build-7")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, `{"version":"1.0.1"}`, string(body))
+	assert.Equal(t, "primary", resp.Header.Get("X-Served-By"))
+	assert.Equal(t, "yes", resp.Header.Get("X-Primary"))
+
+	require.NotNil(t, forwarded)
+	assert.Equal(t, http.MethodPost, forwarded.Method)
+	assert.Equal(t, "/version/1-api/increment", forwarded.URL.Path)
+	assert.Equal(t, "minor", forwarded.URL.Query().Get("type"))
+	assert.Equal(t, strings.TrimPrefix(primary.URL, "http://"), forwarded.Host)
+	assert.Equal(t, "Bearer token", forwarded.Header.Get("Authorization"))
+	assert.Equal(t, "build-7", forwarded.Header.Get("Idempotency-Key"))
+	assert.NotEmpty(t, forwarded.Header.Get("X-Forwarded-For"))
+	assert.Equal(t, `{"sha":"abc"}`, forwardedBody)
+}
+
+func TestFollowerProxy_ServesReadsAndLocalRoutes(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to primary: %s %s", r.Method, r.URL.Path)
+	}))
+	defer primary.Close()
+	follower := followerServer(t, primary.URL)
+
+	for _, route := range []struct{ method, path string }{
+		{http.MethodGet, "/version/1-api"},
+		{http.MethodPost, "/admin/cache/purge"},
+	} {
+		req, err := http.NewRequest(route.method, follower.URL+route.path, nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "local", string(body))
+		assert.Empty(t, resp.Header.Get("X-Served-By"))
+	}
+}
+
+func TestFollowerProxy_PrimaryUnavailable(t *testing.T) {
+	primary := httptest.NewServer(http.NotFoundHandler())
+	primaryURL := primary.URL
+	primary.Close()
+	follower := followerServer(t, primaryURL)
+
+	resp, err := http.Post(follower.URL+"/version/1-api/increment", "application/json", nil)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	assert.Contains(t, string(body), "PRIMARY_UNAVAILABLE")
+	assert.Empty(t, resp.Header.Get("X-Served-By"))
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// ImpersonationMiddleware lets admins act on behalf of a team or actor named
+// in the X-On-Behalf-Of header, for break-glass fixes. The admin may name
+// themselves in X-Impersonator; since the admin token is shared, the name is
+// taken on trust and defaults to the identity's subject or "admin". The
+// attribution travels in the request context to the Git commit trailers,
+// and every impersonated request gets an audit log entry of its own.
+// Callers other than admins are rejected when they send the header.
+func ImpersonationMiddleware(adminToken string, logger *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor := c.GetHeader("X-On-Behalf-Of")
+		if actor == "" {
+			c.Next()
+			return
+		}
+
+		identity := RequestIdentity(c, adminToken)
+		if identity.Type != models.IdentityAdmin {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{
+				Error: "Only admins may act on behalf of others",
+				Code:  "IMPERSONATION_FORBIDDEN",
+			})
+			return
+		}
+
+		impersonator := c.GetHeader("X-Impersonator")
+		if impersonator == "" {
+			impersonator = identity.Subject
+		}
+		if impersonator == "" {
+			impersonator = identity.Type
+		}
+		for _, name := range []string{actor, impersonator} {
+			if err := models.ValidateAttributionName(name); err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{
+					Error:   "Invalid impersonation header",
+					Code:    "INVALID_IMPERSONATION",
+					Details: err.Error(),
+				})
+				return
+			}
+		}
+
+		attribution := models.Attribution{Actor: actor, Impersonator: impersonator}
+		c.Request = c.Request.WithContext(models.WithAttribution(c.Request.Context(), attribution))
+
+		c.Next()
+
+		logger.WithFields(logrus.Fields{
+			"audit":        "impersonation",
+			"actor":        attribution.Actor,
+			"impersonator": attribution.Impersonator,
+			"method":       c.Request.Method,
+			"path":         c.Request.URL.Path,
+			"client_ip":    c.ClientIP(),
+			"status_code":  c.Writer.Status(),
+		}).Warn("Impersonated request")
+	}
+}
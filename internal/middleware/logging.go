@@ -3,6 +3,7 @@ package middleware
 import (
 	"time"
 
+	"github.com/company/version-service/internal/models"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
@@ -31,6 +32,12 @@ func LoggingMiddleware(logger *logrus.Logger) gin.HandlerFunc {
 			"path":        path,
 			"status_code": statusCode,
 		})
+		if identity, ok := models.IdentityFrom(c.Request.Context()); ok {
+			entry = entry.WithFields(logrus.Fields{
+				"identity": identity.Type,
+				"subject":  identity.Subject,
+			})
+		}
 
 		msg := "Request processed"
 
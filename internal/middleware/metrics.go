@@ -1,9 +1,12 @@
 package middleware
 
 import (
+	"context"
 	"strconv"
 	"time"
 
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/pkg/breaker"
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -24,6 +27,123 @@ var (
 		Name: "version_operations_total",
 		Help: "Total number of version operations",
 	}, []string{"operation", "app_id", "status"})
+
+	hookDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "increment_hook_duration_seconds",
+		Help: "Duration of increment hook calls",
+	}, []string{"phase", "hook", "outcome"})
+
+	responseCacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "response_cache_requests_total",
+		Help: "Total number of cacheable GET requests by cache result",
+	}, []string{"result"})
+
+	freshnessLag = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "git_freshness_lag_seconds",
+		Help:    "Lag between a version's Redis write and its confirmed Git push",
+		Buckets: []float64{0.5, 1, 2, 5, 10, 30, 60, 120, 300, 600, 1800, 3600},
+	})
+
+	freshnessWrites = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "git_freshness_writes_total",
+		Help: "Total number of writes by whether they became durable within the freshness target",
+	}, []string{"result"})
+
+	freshnessWorstLag = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "git_freshness_worst_lag_seconds",
+		Help: "Age of the oldest write not yet pushed to Git",
+	})
+
+	freshnessBurnRate = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "git_freshness_burn_rate",
+		Help: "Freshness error budget burn rate by window",
+	}, []string{"window"})
+
+	authorizationDecisions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "authorization_decisions_total",
+		Help: "Total number of policy engine decisions by action and result",
+	}, []string{"action", "result"})
+
+	devVersionCacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "dev_version_cache_requests_total",
+		Help: "Total number of dev version requests by parsed-version cache result",
+	}, []string{"result"})
+
+	actorJobs = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "app_actor_jobs",
+		Help: "Jobs queued or running on per-app actors",
+	}, []string{"queue"})
+
+	failoverActive = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "storage_failover_active",
+		Help: "Whether writes are failed over from Git to the failover journal (1) or not (0)",
+	})
+
+	failoverTransitions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "storage_failover_transitions_total",
+		Help: "Total number of failovers to the journal and recoveries back to Git",
+	}, []string{"event"})
+
+	failoverJournalWrites = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "storage_failover_journal_writes_total",
+		Help: "Total number of version writes journaled while Git was failed over",
+	})
+
+	circuitBreakerState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "circuit_breaker_state",
+		Help: "State of the circuit breaker around each dependency: closed (0), open (1) or half-open (2)",
+	}, []string{"dependency"})
+
+	circuitBreakerTransitions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "circuit_breaker_transitions_total",
+		Help: "Total number of circuit breaker transitions by dependency and the state entered",
+	}, []string{"dependency", "state"})
+
+	outboxFlushedCalls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "outbox_flushed_calls_total",
+		Help: "Total number of deferred outbound calls handled by outbox flushes, by kind and result",
+	}, []string{"kind", "result"})
+
+	canaryVerifications = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "canary_read_verifications_total",
+		Help: "Total number of reads verified against Git by read (version or listing) and result (match, mismatch, pending, error or skipped)",
+	}, []string{"read", "result"})
+
+	sliCompliance = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "sli_compliance",
+		Help: "Share of requests within the SLO window meeting the objective, by endpoint and SLI (availability or latency)",
+	}, []string{"endpoint", "sli"})
+
+	sloBudgetRemaining = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "slo_error_budget_remaining",
+		Help: "Share of the error budget left within the SLO window, by endpoint and SLI; negative once overspent",
+	}, []string{"endpoint", "sli"})
+
+	sloBurnRate = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "slo_error_budget_burn_rate",
+		Help: "Rate at which the error budget is spent within the SLO window, by endpoint and SLI; 1 spends it exactly",
+	}, []string{"endpoint", "sli"})
+
+	sloConservativeMode = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "slo_conservative_mode",
+		Help: "Whether the service sheds load because an error budget is nearly exhausted (1) or not (0)",
+	})
+
+	sloShedRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "slo_shed_requests_total",
+		Help: "Total number of requests shed in conservative mode",
+	}, []string{"endpoint"})
+
+	rateLimitedRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "rate_limited_requests_total",
+		Help: "Total number of requests rejected by a per-client rate limit",
+	}, []string{"scope"})
+
+	gitOperationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "git_operation_duration_seconds",
+		Help:    "Duration of Git storage operations",
+		Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"operation", "result"})
 )
 
 func MetricsMiddleware() gin.HandlerFunc {
@@ -41,11 +161,186 @@ func MetricsMiddleware() gin.HandlerFunc {
 
 		duration := time.Since(start).Seconds()
 
-		httpDuration.WithLabelValues(method, path, status).Observe(duration)
+		observeWithExemplar(c.Request.Context(), httpDuration.WithLabelValues(method, path, status), duration)
 		httpRequests.WithLabelValues(method, path, status).Inc()
 	}
 }
 
 func RecordVersionOperation(operation, appID, status string) {
 	versionOperations.WithLabelValues(operation, appID, status).Inc()
-}
\ No newline at end of file
+}
+
+// RecordHookCall records one increment hook invocation or webhook
+// subscription delivery. outcome is one of allowed, rejected, error, sent or
+// deferred.
+func RecordHookCall(phase, hook, outcome string, duration time.Duration) {
+	hookDuration.WithLabelValues(phase, hook, outcome).Observe(duration.Seconds())
+}
+
+// RecordGitOperation records one Git storage operation attempt. result is
+// one of success, push_failed or error. ctx links the sample to the trace of
+// the request that caused the operation.
+func RecordGitOperation(ctx context.Context, operation, result string, duration time.Duration) {
+	observeWithExemplar(ctx, gitOperationDuration.WithLabelValues(operation, result), duration.Seconds())
+}
+
+// observeWithExemplar records value with the request's trace ID as exemplar
+// when the request is traced
+func observeWithExemplar(ctx context.Context, observer prometheus.Observer, value float64) {
+	if traceID := TraceIDFromContext(ctx); traceID != "" {
+		if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok {
+			exemplarObserver.ObserveWithExemplar(value, prometheus.Labels{"trace_id": traceID})
+			return
+		}
+	}
+	observer.Observe(value)
+}
+
+func recordResponseCache(result string) {
+	responseCacheRequests.WithLabelValues(result).Inc()
+}
+
+// RecordFreshnessLag records the lag of a write confirmed durable
+func RecordFreshnessLag(lag time.Duration) {
+	freshnessLag.Observe(lag.Seconds())
+}
+
+// RecordFreshnessResult counts one write against the freshness SLO
+func RecordFreshnessResult(withinTarget bool) {
+	if withinTarget {
+		freshnessWrites.WithLabelValues("within_target").Inc()
+	} else {
+		freshnessWrites.WithLabelValues("breached").Inc()
+	}
+}
+
+// SetFreshnessWorstLag publishes the age of the oldest unpushed write
+func SetFreshnessWorstLag(lag time.Duration) {
+	freshnessWorstLag.Set(lag.Seconds())
+}
+
+// RecordAuthorizationDecision records a policy engine decision: allowed,
+// denied or error
+func RecordAuthorizationDecision(action, result string) {
+	authorizationDecisions.WithLabelValues(action, result).Inc()
+}
+
+// RecordDevVersionCache records a lookup in the dev version cache: hit or
+// miss
+func RecordDevVersionCache(result string) {
+	devVersionCacheRequests.WithLabelValues(result).Inc()
+}
+
+// AddActorJobs adjusts the number of jobs queued or running on a per-app
+// actor queue
+func AddActorJobs(queue string, delta int) {
+	actorJobs.WithLabelValues(queue).Add(float64(delta))
+}
+
+// SetFreshnessBurnRate publishes the burn rate of one window
+func SetFreshnessBurnRate(window string, rate float64) {
+	freshnessBurnRate.WithLabelValues(window).Set(rate)
+}
+
+// SetFailoverActive publishes whether writes are failed over to the journal
+func SetFailoverActive(active bool) {
+	if active {
+		failoverActive.Set(1)
+	} else {
+		failoverActive.Set(0)
+	}
+}
+
+// RecordFailoverTransition counts a failover or recovery
+func RecordFailoverTransition(event string) {
+	failoverTransitions.WithLabelValues(event).Inc()
+}
+
+// RecordFailoverJournalWrites counts version writes journaled while failed
+// over
+func RecordFailoverJournalWrites(count int) {
+	failoverJournalWrites.Add(float64(count))
+}
+
+// SetCircuitBreakerState publishes the state of a dependency's circuit
+// breaker, counting the transition unless it is the initial state
+func SetCircuitBreakerState(dependency string, state breaker.State, transition bool) {
+	circuitBreakerState.WithLabelValues(dependency).Set(float64(state))
+	if transition {
+		circuitBreakerTransitions.WithLabelValues(dependency, state.String()).Inc()
+	}
+}
+
+// RecordOutboxFlush counts a deferred call handled by an outbox flush.
+// result is one of sent, skipped or failed.
+func RecordOutboxFlush(kind, result string) {
+	outboxFlushedCalls.WithLabelValues(kind, result).Inc()
+}
+
+// setSLI publishes one SLI of an endpoint: its compliance and burn rate
+// within the SLO window
+func setSLI(endpoint, sli string, compliance, burnRate float64) {
+	sliCompliance.WithLabelValues(endpoint, sli).Set(compliance)
+	sloBurnRate.WithLabelValues(endpoint, sli).Set(burnRate)
+	sloBudgetRemaining.WithLabelValues(endpoint, sli).Set(1 - burnRate)
+}
+
+// deleteSLI drops the SLI series of an endpoint without requests in the
+// window
+func deleteSLI(endpoint string) {
+	for _, vec := range []*prometheus.GaugeVec{sliCompliance, sloBurnRate, sloBudgetRemaining} {
+		vec.DeletePartialMatch(prometheus.Labels{"endpoint": endpoint})
+	}
+}
+
+func setSLOConservativeMode(active bool) {
+	if active {
+		sloConservativeMode.Set(1)
+	} else {
+		sloConservativeMode.Set(0)
+	}
+}
+
+func recordSLOShed(endpoint string) {
+	sloShedRequests.WithLabelValues(endpoint).Inc()
+}
+
+func recordRateLimited(scope string) {
+	rateLimitedRequests.WithLabelValues(scope).Inc()
+}
+
+// RecordCanaryVerification records the outcome of verifying a read served
+// from Redis against Git
+func RecordCanaryVerification(read, result string) {
+	canaryVerifications.WithLabelValues(read, result).Inc()
+}
+
+// RegisterRedisPoolMetrics exports the Redis connection pool, read from stats
+// on every scrape. Hits, misses, timeouts and stale connections only grow
+// and are exported as counters; open and idle connections as gauges. Call it
+// once.
+func RegisterRedisPoolMetrics(stats func() models.PoolStats) {
+	counter := func(name, help string, value func(models.PoolStats) int64) {
+		promauto.NewCounterFunc(prometheus.CounterOpts{Name: name, Help: help}, func() float64 {
+			return float64(value(stats()))
+		})
+	}
+	gauge := func(name, help string, value func(models.PoolStats) int64) {
+		promauto.NewGaugeFunc(prometheus.GaugeOpts{Name: name, Help: help}, func() float64 {
+			return float64(value(stats()))
+		})
+	}
+
+	counter("redis_pool_hits_total", "Total number of Redis commands that found an idle pooled connection",
+		func(p models.PoolStats) int64 { return p.Hits })
+	counter("redis_pool_misses_total", "Total number of Redis commands that had to open a connection",
+		func(p models.PoolStats) int64 { return p.Misses })
+	counter("redis_pool_timeouts_total", "Total number of Redis commands that timed out waiting for a pooled connection",
+		func(p models.PoolStats) int64 { return p.Timeouts })
+	counter("redis_pool_stale_connections_total", "Total number of idle Redis connections closed as stale",
+		func(p models.PoolStats) int64 { return p.StaleConns })
+	gauge("redis_pool_connections", "Open Redis connections in the pool",
+		func(p models.PoolStats) int64 { return p.TotalConns })
+	gauge("redis_pool_idle_connections", "Idle Redis connections in the pool",
+		func(p models.PoolStats) int64 { return p.IdleConns })
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/company/version-service/internal/clients"
+	"github.com/company/version-service/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// OIDCMiddleware authenticates callers sending an OIDC ID token, such as a
+// GitLab CI job's, as a bearer token. The identity's subject is taken from
+// subjectClaim and the claims named in claimNames are copied to it; it is
+// recorded in the request context for handlers, policies and request logs.
+// Bearer tokens that aren't JWTs, including the admin token, are left to the
+// other checks. Invalid tokens get 401; when the signing keys can't be
+// fetched, requests with tokens get 503.
+func OIDCMiddleware(verify func(ctx context.Context, token string) (map[string]any, error), subjectClaim string, claimNames []string, adminToken string, logger *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bearer, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || strings.Count(bearer, ".") != 2 ||
+			(adminToken != "" && subtle.ConstantTimeCompare([]byte(bearer), []byte(adminToken)) == 1) {
+			c.Next()
+			return
+		}
+
+		claims, err := verify(c.Request.Context(), bearer)
+		if err == nil && claimString(claims[subjectClaim]) == "" {
+			err = fmt.Errorf("%w: %s claim missing", clients.ErrInvalidToken, subjectClaim)
+		}
+		if errors.Is(err, clients.ErrInvalidToken) {
+			logger.WithError(err).WithFields(logrus.Fields{
+				"method":    c.Request.Method,
+				"path":      c.Request.URL.Path,
+				"client_ip": c.ClientIP(),
+			}).Warn("Request with an invalid OIDC token rejected")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error:   "Invalid OIDC token",
+				Code:    "INVALID_TOKEN",
+				Details: err.Error(),
+			})
+			return
+		}
+		if err != nil {
+			logger.WithError(err).Error("Failed to verify OIDC token")
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, models.ErrorResponse{
+				Error:   "Token verification is unavailable",
+				Code:    "AUTHENTICATION_UNAVAILABLE",
+				Details: err.Error(),
+			})
+			return
+		}
+
+		identity := models.Identity{Type: models.IdentityOIDC, Subject: claimString(claims[subjectClaim])}
+		for _, name := range claimNames {
+			if value := claimString(claims[name]); value != "" {
+				if identity.Claims == nil {
+					identity.Claims = make(map[string]string)
+				}
+				identity.Claims[name] = value
+			}
+		}
+		c.Request = c.Request.WithContext(models.WithIdentity(c.Request.Context(), identity))
+
+		c.Next()
+	}
+}
+
+// claimString renders a string, number or boolean claim; other claims read
+// as ""
+func claimString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	case bool:
+		return fmt.Sprint(v)
+	default:
+		return ""
+	}
+}
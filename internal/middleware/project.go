@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// ProjectAuthMiddleware guards endpoints owned by the project in the
+// project-id path parameter. The admin token always passes; otherwise the
+// caller must send a GitLab CI job token and authorize must confirm it grants
+// access to the project.
+func ProjectAuthMiddleware(adminToken string, authorize func(ctx context.Context, projectID string) (bool, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bearer := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if adminToken != "" && subtle.ConstantTimeCompare([]byte(bearer), []byte(adminToken)) == 1 {
+			c.Next()
+			return
+		}
+
+		if c.GetHeader("JOB-TOKEN") == "" && c.GetHeader("X-GitLab-Job-Token") == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error: "Admin token or GitLab job token required",
+				Code:  "UNAUTHORIZED",
+			})
+			return
+		}
+
+		allowed, err := authorize(c.Request.Context(), c.Param("project-id"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadGateway, models.ErrorResponse{
+				Error:   "Failed to verify project access",
+				Code:    "AUTHORIZATION_FAILED",
+				Details: err.Error(),
+			})
+			return
+		}
+		if !allowed {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{
+				Error: "Job token does not grant access to this project",
+				Code:  "FORBIDDEN",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
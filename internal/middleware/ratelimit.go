@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// RateLimitOptions configures per-client rate limits. Each limit allows that
+// many requests per Window in a burst and refills continuously; zero
+// disables it.
+type RateLimitOptions struct {
+	// Requests limits all requests of a client
+	Requests int
+	// WriteRequests limits the writes of a client on top of Requests
+	WriteRequests int
+	Window        time.Duration
+}
+
+type rateLimit struct {
+	scope    string
+	requests int
+}
+
+// RateLimitMiddleware limits how fast each client may call the API, using
+// token buckets taken from with take. Clients are told apart by their
+// identity, such as the name of their API key, and otherwise by IP address,
+// so it must run after the authentication middleware. The admin token isn't
+// limited. Limited requests get 429 with a Retry-After header; when the
+// buckets can't be reached, requests are let through.
+func RateLimitMiddleware(take func(ctx context.Context, key string, rate float64, burst int, now time.Time) (bool, time.Duration, error), opts RateLimitOptions, adminToken string, logger *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client := "ip:" + c.ClientIP()
+		switch identity := RequestIdentity(c, adminToken); identity.Type {
+		case models.IdentityAdmin:
+			c.Next()
+			return
+		case models.IdentityAPIKey, models.IdentityOIDC:
+			client = identity.Type + ":" + identity.Subject
+		}
+
+		limits := []rateLimit{{"all", opts.Requests}}
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			limits = append(limits, rateLimit{"write", opts.WriteRequests})
+		}
+
+		for _, limit := range limits {
+			if limit.requests <= 0 {
+				continue
+			}
+			rate := float64(limit.requests) / opts.Window.Seconds()
+			allowed, wait, err := take(c.Request.Context(), limit.scope+":"+client, rate, limit.requests, time.Now())
+			if err != nil {
+				logger.WithError(err).Warn("Rate limit unavailable, letting request through")
+				continue
+			}
+			if allowed {
+				continue
+			}
+
+			recordRateLimited(limit.scope)
+			logger.WithFields(logrus.Fields{
+				"client": client,
+				"scope":  limit.scope,
+				"method": c.Request.Method,
+				"path":   c.Request.URL.Path,
+			}).Warn("Request rate limited")
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, models.ErrorResponse{
+				Error:   "Rate limit exceeded",
+				Code:    "RATE_LIMITED",
+				Details: fmt.Sprintf("%d %s requests per %s allowed", limit.requests, limit.scope, opts.Window),
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
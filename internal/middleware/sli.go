@@ -0,0 +1,309 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// SLObjective is the service level objective of one endpoint: Availability
+// is the share of requests that must not fail with a 5xx, and
+// LatencyObjective the share that must complete within Latency
+type SLObjective struct {
+	Availability     float64
+	Latency          time.Duration
+	LatencyObjective float64
+}
+
+// SLI defaults
+const (
+	DefaultSLOAvailability     = 0.999
+	DefaultSLOLatency          = 500 * time.Millisecond
+	DefaultSLOLatencyObjective = 0.99
+	DefaultSLOWindow           = time.Hour
+	DefaultSLOShedConcurrency  = 32
+)
+
+// sliEvaluateInterval is how often compliance gauges are refreshed and
+// conservative mode is re-evaluated
+const sliEvaluateInterval = 15 * time.Second
+
+// sliMinRequests is how many requests an endpoint needs within the window
+// before its error budget counts towards conservative mode, so a single
+// failure on a quiet endpoint doesn't throttle the whole service
+const sliMinRequests = 100
+
+// SLIOptions configures per-endpoint SLI tracking
+type SLIOptions struct {
+	// Default applies to endpoints without their own objective
+	Default SLObjective
+	// Endpoints holds per-endpoint objectives keyed by method and route as
+	// registered, e.g. "POST /version/:app-id/increment"
+	Endpoints map[string]SLObjective
+	// Window is the rolling window compliance and error budgets cover
+	Window time.Duration
+	// ConservativeBudget turns on conservative mode once an endpoint has
+	// less than this share of an error budget left; zero disables it
+	ConservativeBudget float64
+	// ShedConcurrency is how many requests are served at once in
+	// conservative mode; the rest are shed
+	ShedConcurrency int
+}
+
+type sliCounts struct {
+	total  int64
+	errors int64
+	slow   int64
+}
+
+type endpointSLI struct {
+	objective SLObjective
+	buckets   map[int64]*sliCounts
+}
+
+// SLITracker measures the success rate and latency of every endpoint
+// against its objective over a rolling window, kept in per-minute buckets.
+// When conservative mode is enabled and an error budget is nearly spent,
+// the service sheds requests beyond ShedConcurrency until the budgets
+// recover.
+type SLITracker struct {
+	opts   SLIOptions
+	logger *logrus.Logger
+
+	mu        sync.Mutex
+	endpoints map[string]*endpointSLI
+
+	conservative atomic.Bool
+	inFlight     chan struct{}
+}
+
+// NewSLITracker returns a tracker and starts evaluating it periodically
+func NewSLITracker(opts SLIOptions, logger *logrus.Logger) *SLITracker {
+	if opts.Default.Availability <= 0 || opts.Default.Availability >= 1 {
+		opts.Default.Availability = DefaultSLOAvailability
+	}
+	if opts.Default.Latency <= 0 {
+		opts.Default.Latency = DefaultSLOLatency
+	}
+	if opts.Default.LatencyObjective <= 0 || opts.Default.LatencyObjective >= 1 {
+		opts.Default.LatencyObjective = DefaultSLOLatencyObjective
+	}
+	if opts.Window < time.Minute {
+		opts.Window = DefaultSLOWindow
+	}
+	if opts.ShedConcurrency <= 0 {
+		opts.ShedConcurrency = DefaultSLOShedConcurrency
+	}
+
+	t := &SLITracker{
+		opts:      opts,
+		logger:    logger,
+		endpoints: make(map[string]*endpointSLI),
+		inFlight:  make(chan struct{}, opts.ShedConcurrency),
+	}
+	go t.monitor()
+	return t
+}
+
+// ParseSLOEndpoints parses per-endpoint objectives separated by semicolons,
+// each "METHOD /route=availability,latency[,latency objective]", e.g.
+// "POST /version/:app-id/increment=0.995,2s". Omitted or empty fields take
+// the value of def.
+func ParseSLOEndpoints(spec string, def SLObjective) (map[string]SLObjective, error) {
+	endpoints := make(map[string]SLObjective)
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		endpoint, values, ok := strings.Cut(entry, "=")
+		method, route, hasRoute := strings.Cut(strings.TrimSpace(endpoint), " ")
+		if !ok || !hasRoute || method != strings.ToUpper(method) || !strings.HasPrefix(strings.TrimSpace(route), "/") {
+			return nil, fmt.Errorf("invalid objective %q: want METHOD /route=availability,latency[,latency objective]", entry)
+		}
+
+		objective := def
+		fields := strings.Split(values, ",")
+		if len(fields) > 3 {
+			return nil, fmt.Errorf("invalid objective %q: too many fields", entry)
+		}
+		for i, field := range fields {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			var err error
+			switch i {
+			case 0:
+				objective.Availability, err = parseObjectiveShare(field)
+			case 1:
+				objective.Latency, err = time.ParseDuration(field)
+				if err == nil && objective.Latency <= 0 {
+					err = fmt.Errorf("latency must be positive")
+				}
+			case 2:
+				objective.LatencyObjective, err = parseObjectiveShare(field)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invalid objective %q: %v", entry, err)
+			}
+		}
+		endpoints[method+" "+strings.TrimSpace(route)] = objective
+	}
+	return endpoints, nil
+}
+
+func parseObjectiveShare(value string) (float64, error) {
+	share, err := strconv.ParseFloat(value, 64)
+	if err != nil || share <= 0 || share >= 1 {
+		return 0, fmt.Errorf("objective %q must be between 0 and 1 (exclusive)", value)
+	}
+	return share, nil
+}
+
+// Middleware records every routed request against its endpoint's SLIs and
+// sheds requests in conservative mode. Shed requests are not counted, so
+// shedding cannot keep the budgets exhausted by itself.
+func (t *SLITracker) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			c.Next()
+			return
+		}
+		endpoint := c.Request.Method + " " + route
+
+		if t.conservative.Load() {
+			select {
+			case t.inFlight <- struct{}{}:
+				defer func() { <-t.inFlight }()
+			default:
+				recordSLOShed(endpoint)
+				c.Header("Retry-After", "1")
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, models.ErrorResponse{
+					Error:   "Service is shedding load",
+					Code:    "LOAD_SHED",
+					Details: "an error budget is nearly exhausted; retry later",
+				})
+				return
+			}
+		}
+
+		start := time.Now()
+		c.Next()
+		t.record(endpoint, c.Writer.Status(), time.Since(start))
+	}
+}
+
+// Conservative reports whether the service is in conservative mode
+func (t *SLITracker) Conservative() bool {
+	return t.conservative.Load()
+}
+
+func (t *SLITracker) record(endpoint string, status int, duration time.Duration) {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	sli, ok := t.endpoints[endpoint]
+	if !ok {
+		objective, ok := t.opts.Endpoints[endpoint]
+		if !ok {
+			objective = t.opts.Default
+		}
+		sli = &endpointSLI{objective: objective, buckets: make(map[int64]*sliCounts)}
+		t.endpoints[endpoint] = sli
+	}
+
+	minute := now.Unix() / 60
+	counts, ok := sli.buckets[minute]
+	if !ok {
+		counts = &sliCounts{}
+		sli.buckets[minute] = counts
+	}
+	counts.total++
+	if status >= http.StatusInternalServerError {
+		counts.errors++
+	}
+	if duration > sli.objective.Latency {
+		counts.slow++
+	}
+}
+
+func (t *SLITracker) monitor() {
+	ticker := time.NewTicker(sliEvaluateInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		t.evaluate(time.Now())
+	}
+}
+
+// evaluate refreshes the SLI gauges and turns conservative mode on or off
+func (t *SLITracker) evaluate(now time.Time) {
+	oldest := now.Add(-t.opts.Window).Unix() / 60
+	var exhausted []string
+
+	t.mu.Lock()
+	for endpoint, sli := range t.endpoints {
+		var window sliCounts
+		for minute, counts := range sli.buckets {
+			if minute < oldest {
+				delete(sli.buckets, minute)
+				continue
+			}
+			window.total += counts.total
+			window.errors += counts.errors
+			window.slow += counts.slow
+		}
+		if window.total == 0 {
+			delete(t.endpoints, endpoint)
+			deleteSLI(endpoint)
+			continue
+		}
+
+		errorRate := float64(window.errors) / float64(window.total)
+		slowRate := float64(window.slow) / float64(window.total)
+		availabilityBurn := errorRate / (1 - sli.objective.Availability)
+		latencyBurn := slowRate / (1 - sli.objective.LatencyObjective)
+
+		setSLI(endpoint, "availability", 1-errorRate, availabilityBurn)
+		setSLI(endpoint, "latency", 1-slowRate, latencyBurn)
+
+		remaining := 1 - availabilityBurn
+		if latencyBurn > availabilityBurn {
+			remaining = 1 - latencyBurn
+		}
+		if t.opts.ConservativeBudget > 0 && window.total >= sliMinRequests && remaining < t.opts.ConservativeBudget {
+			exhausted = append(exhausted, endpoint)
+		}
+	}
+	t.mu.Unlock()
+
+	conservative := len(exhausted) > 0
+	if t.conservative.Swap(conservative) == conservative {
+		return
+	}
+	setSLOConservativeMode(conservative)
+
+	if conservative {
+		sort.Strings(exhausted)
+		t.logger.WithFields(logrus.Fields{
+			"endpoints":        exhausted,
+			"shed_concurrency": t.opts.ShedConcurrency,
+		}).Warn("Error budget nearly exhausted, entering conservative mode")
+	} else {
+		t.logger.Info("Error budgets recovered, leaving conservative mode")
+	}
+}
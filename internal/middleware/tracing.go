@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+type traceIDKey struct{}
+
+// traceparentPattern matches a W3C Trace Context traceparent header and
+// captures its trace ID
+var traceparentPattern = regexp.MustCompile(`^[0-9a-f]{2}-([0-9a-f]{32})-[0-9a-f]{16}-[0-9a-f]{2}$`)
+
+const zeroTraceID = "00000000000000000000000000000000"
+
+// TracingMiddleware picks up the trace ID of the distributed trace a request
+// belongs to from its W3C traceparent header and attaches it to the request
+// context. Duration histograms use it as an exemplar so slow samples link to
+// their trace.
+func TracingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		match := traceparentPattern.FindStringSubmatch(c.GetHeader("traceparent"))
+		if match != nil && match[1] != zeroTraceID {
+			c.Request = c.Request.WithContext(WithTraceID(c.Request.Context(), match[1]))
+		}
+
+		c.Next()
+	}
+}
+
+// WithTraceID returns a context carrying traceID
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey{}, traceID)
+}
+
+// TraceIDFromContext returns the trace ID attached to ctx, or "" when the
+// request is not traced
+func TraceIDFromContext(ctx context.Context) string {
+	traceID, _ := ctx.Value(traceIDKey{}).(string)
+	return traceID
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTracingMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(TracingMiddleware())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, TraceIDFromContext(c.Request.Context()))
+	})
+
+	tests := []struct {
+		name        string
+		traceparent string
+		traceID     string
+	}{
+		{"traced", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{"untraced", "", ""},
+		{"zero trace ID", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", ""},
+		{"malformed", "00-4BF92F35-00f067aa0ba902b7-01", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.traceID, w.Body.String())
+		})
+	}
+}
+
+func TestObserveWithExemplar(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "test_duration_seconds",
+		Help:    "Test durations",
+		Buckets: []float64{1},
+	})
+	registry.MustRegister(histogram)
+
+	observeWithExemplar(WithTraceID(context.Background(), "4bf92f3577b34da6a3ce929d0e0e4736"), histogram, 0.5)
+	observeWithExemplar(context.Background(), histogram, 2)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text")
+	w := httptest.NewRecorder()
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{EnableOpenMetrics: true}).ServeHTTP(w, req)
+	body, _ := io.ReadAll(w.Body)
+
+	// Only the traced sample carries an exemplar
+	assert.Contains(t, string(body), `test_duration_seconds_bucket{le="1.0"} 1 # {trace_id="4bf92f3577b34da6a3ce929d0e0e4736"} 0.5`)
+	assert.Contains(t, string(body), `test_duration_seconds_bucket{le="+Inf"} 2`+"\n")
+	assert.Contains(t, string(body), "test_duration_seconds_count 2")
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+)
+
+// Git commit trailers recording an impersonated change
+const (
+	TrailerOnBehalfOf     = "On-Behalf-Of"
+	TrailerImpersonatedBy = "Impersonated-By"
+)
+
+// attributionNamePattern limits actor and impersonator names to characters
+// that are safe in log fields and commit trailers
+var attributionNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._@/-]{0,127}$`)
+
+// Attribution records a change an admin made on behalf of a team or actor.
+// Actor is who the change is attributed to; Impersonator is who made it.
+type Attribution struct {
+	Actor        string `json:"actor"`
+	Impersonator string `json:"impersonator"`
+}
+
+// ValidateAttributionName checks an actor or impersonator name
+func ValidateAttributionName(name string) error {
+	if !attributionNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid name %q: use up to 128 letters, digits and . _ @ / -", name)
+	}
+	return nil
+}
+
+// Trailers returns the Git commit trailers recording the attribution
+func (a Attribution) Trailers() string {
+	return fmt.Sprintf("%s: %s\n%s: %s", TrailerOnBehalfOf, a.Actor, TrailerImpersonatedBy, a.Impersonator)
+}
+
+type attributionKey struct{}
+
+// WithAttribution returns a context recording that its changes are made by
+// an admin on behalf of another actor
+func WithAttribution(ctx context.Context, attribution Attribution) context.Context {
+	return context.WithValue(ctx, attributionKey{}, attribution)
+}
+
+// AttributionFrom returns the attribution of ctx, if its changes are
+// impersonated
+func AttributionFrom(ctx context.Context) (Attribution, bool) {
+	attribution, ok := ctx.Value(attributionKey{}).(Attribution)
+	return attribution, ok
+}
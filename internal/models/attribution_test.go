@@ -0,0 +1,27 @@
+package models
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateAttributionName(t *testing.T) {
+	for _, name := range []string{"team-payments", "alice@example.com", "group/team_a.b"} {
+		assert.NoError(t, ValidateAttributionName(name), name)
+	}
+	for _, name := range []string{"", "-team", "team a", "team\nImpersonated-By: bob", string(make([]byte, 129))} {
+		assert.Error(t, ValidateAttributionName(name), name)
+	}
+}
+
+func TestAttributionContext(t *testing.T) {
+	_, ok := AttributionFrom(context.Background())
+	assert.False(t, ok)
+
+	ctx := WithAttribution(context.Background(), Attribution{Actor: "team-payments", Impersonator: "alice"})
+	attribution, ok := AttributionFrom(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "On-Behalf-Of: team-payments\nImpersonated-By: alice", attribution.Trailers())
+}
@@ -0,0 +1,57 @@
+package models
+
+import "context"
+
+// Identity types reported to the policy engine
+const (
+	IdentityAdmin     = "admin"
+	IdentityAPIKey    = "api_key"
+	IdentityOIDC      = "oidc"
+	IdentityGitLabJob = "gitlab_job"
+	IdentityAnonymous = "anonymous"
+)
+
+// Identity is the caller of a request as far as the service can tell.
+// Credentials themselves are never included.
+type Identity struct {
+	Type    string `json:"type"`
+	Subject string `json:"subject,omitempty"`
+	// Claims holds the configured claims of an OIDC token, such as
+	// project_path or ref
+	Claims map[string]string `json:"claims,omitempty"`
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a context recording the authenticated caller of its
+// request
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFrom returns the caller authenticated by an API key or OIDC token,
+// if the request had one
+func IdentityFrom(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok
+}
+
+// AuthorizationInput describes a request to the external policy engine. It is
+// sent as the OPA input document.
+type AuthorizationInput struct {
+	Identity      Identity      `json:"identity"`
+	OnBehalfOf    string        `json:"on_behalf_of,omitempty"`
+	Action        string        `json:"action"`
+	AppID         string        `json:"app_id,omitempty"`
+	AppIDs        []string      `json:"app_ids,omitempty"`
+	ProjectID     string        `json:"project_id,omitempty"`
+	IncrementType IncrementType `json:"increment_type,omitempty"`
+	Method        string        `json:"method"`
+	Path          string        `json:"path"`
+}
+
+// AuthorizationDecision is the policy engine's verdict on a request
+type AuthorizationDecision struct {
+	Allow  bool   `json:"allow"`
+	Reason string `json:"reason,omitempty"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Sources of bootstrapped apps
+const (
+	BootstrapSourceFile   = "file"
+	BootstrapSourceGitLab = "gitlab"
+)
+
+// BootstrapEntry is one app in a bootstrap seed file and in the bootstrap
+// report
+type BootstrapEntry struct {
+	AppID      string   `json:"app_id"`
+	Version    string   `json:"version"`
+	ProjectID  string   `json:"project_id,omitempty"`
+	AppName    string   `json:"app_name,omitempty"`
+	RepoName   string   `json:"repo_name,omitempty"`
+	Source     string   `json:"source,omitempty"`
+	Normalized []string `json:"normalized,omitempty"`
+	// Record is the full record of an entry read from a versions file, so
+	// its aliases, lock, lifecycle and other fields are seeded too
+	Record *AppVersion `json:"-"`
+}
+
+// BootstrapReport summarizes a bootstrap run
+type BootstrapReport struct {
+	Revision    string           `json:"revision,omitempty"`
+	Apps        []BootstrapEntry `json:"apps"`
+	Count       int              `json:"count"`
+	Pushed      bool             `json:"pushed"`
+	CacheWarmed bool             `json:"cache_warmed"`
+	Warnings    []string         `json:"warnings,omitempty"`
+	StartedAt   time.Time        `json:"started_at"`
+	CompletedAt time.Time        `json:"completed_at"`
+}
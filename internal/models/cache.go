@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// CachePurgeRequest selects the surrogate keys to purge. No keys purges
+// every cached response.
+type CachePurgeRequest struct {
+	Keys []string `json:"keys,omitempty"`
+}
+
+type CachePurgeResponse struct {
+	Purged int      `json:"purged"`
+	Keys   []string `json:"keys,omitempty"`
+	All    bool     `json:"all,omitempty"`
+}
+
+// CacheRebuildReport describes a rebuild of the Redis cache from durable
+// storage
+type CacheRebuildReport struct {
+	// Versions is the number of apps cached
+	Versions int `json:"versions"`
+	// Recovered counts versions Redis held newer than Git, which were
+	// written back to Git instead of being rolled back
+	Recovered  int       `json:"recovered"`
+	DurationMs int64     `json:"duration_ms"`
+	RebuiltAt  time.Time `json:"rebuilt_at"`
+}
+
+// CachePurgeEvent is posted to CACHE_PURGE_WEBHOOK_URL whenever cached
+// responses are invalidated, so a fronting CDN or reverse proxy can purge the
+// same surrogate keys.
+type CachePurgeEvent struct {
+	EventMeta
+	Keys      []string  `json:"surrogate_keys,omitempty"`
+	All       bool      `json:"all,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// VersionChange is broadcast to every replica sharing the Redis cache when
+// one of them writes versions, so the others drop what they keep in memory
+// about those apps
+type VersionChange struct {
+	// Origin identifies the replica that wrote, so it can skip its own
+	// changes
+	Origin string   `json:"origin"`
+	AppIDs []string `json:"app_ids,omitempty"`
+	// All is set by writes replacing every app, such as imports
+	All bool `json:"all,omitempty"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// ChangelogCommit is a non-merge commit between two versions
+type ChangelogCommit struct {
+	ID         string    `json:"id"`
+	ShortID    string    `json:"short_id"`
+	Title      string    `json:"title"`
+	Author     string    `json:"author"`
+	AuthoredAt time.Time `json:"authored_at"`
+	WebURL     string    `json:"web_url,omitempty"`
+}
+
+// ChangelogMergeRequest is a merge request merged between two versions, as
+// recorded in its merge commit
+type ChangelogMergeRequest struct {
+	IID       int       `json:"iid"`
+	Reference string    `json:"reference"`
+	Title     string    `json:"title"`
+	Author    string    `json:"author"`
+	MergedAt  time.Time `json:"merged_at"`
+	CommitID  string    `json:"commit_id"`
+}
+
+// Changelog lists what changed in an app's project between two version
+// tags, newest first
+type Changelog struct {
+	AppID         string                  `json:"app_id"`
+	ProjectID     string                  `json:"project_id"`
+	From          string                  `json:"from"`
+	To            string                  `json:"to"`
+	FromTag       string                  `json:"from_tag"`
+	ToTag         string                  `json:"to_tag"`
+	MergeRequests []ChangelogMergeRequest `json:"merge_requests"`
+	Commits       []ChangelogCommit       `json:"commits"`
+	CompareURL    string                  `json:"compare_url,omitempty"`
+	GeneratedAt   time.Time               `json:"generated_at"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// DevVersionRecord is a dev version issued by POST /version/{app-id}/dev.
+// Counter numbers issuances per app; a re-issued version carries the counter
+// and time of its latest issuance. Build is the {build} number, if any.
+type DevVersionRecord struct {
+	Version  string    `json:"version"`
+	SHA      string    `json:"sha"`
+	Branch   string    `json:"branch"`
+	Counter  int64     `json:"counter"`
+	Build    int64     `json:"build,omitempty"`
+	IssuedAt time.Time `json:"issued_at"`
+}
+
+// DevVersionsResponse lists the dev versions issued for an app within the
+// retention window, newest first
+type DevVersionsResponse struct {
+	AppID     string             `json:"app_id"`
+	Retention string             `json:"retention"`
+	Versions  []DevVersionRecord `json:"versions"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// DiscoveredProject is a GitLab project found by the discovery job
+type DiscoveredProject struct {
+	ProjectID string `json:"project_id"`
+	AppID     string `json:"app_id"`
+	RepoName  string `json:"repo_name"`
+	Version   string `json:"version,omitempty"`
+}
+
+// DiscoveryReport summarizes a discovery run. Registered lists apps created
+// by the run; Unregistered lists GitLab projects that still have no app.
+type DiscoveryReport struct {
+	Groups          []string            `json:"groups"`
+	ProjectsScanned int                 `json:"projects_scanned"`
+	Registered      []DiscoveredProject `json:"registered"`
+	Unregistered    []DiscoveredProject `json:"unregistered"`
+	Errors          []string            `json:"errors,omitempty"`
+	StartedAt       time.Time           `json:"started_at"`
+	CompletedAt     time.Time           `json:"completed_at"`
+}
@@ -0,0 +1,120 @@
+package models
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Event names emitted by the service
+const (
+	EventQuotaAlert     = "quota_alert"
+	EventPreIncrement   = "pre_increment"
+	EventPostIncrement  = "post_increment"
+	EventCachePurge     = "cache_purge"
+	EventFreshnessAlert = "freshness_alert"
+)
+
+// currentSchemaVersions is the schema version each event is emitted with.
+// Bump an entry and add schemas/<event>.v<N>.json when an event changes
+// shape incompatibly; older schema files stay published.
+var currentSchemaVersions = map[string]int{
+	EventQuotaAlert:     1,
+	EventPreIncrement:   2,
+	EventPostIncrement:  2,
+	EventCachePurge:     1,
+	EventFreshnessAlert: 1,
+}
+
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+// EventMeta stamps an emitted payload with its event name and schema version
+type EventMeta struct {
+	Event         string `json:"event"`
+	SchemaVersion int    `json:"schema_version"`
+}
+
+// NewEventMeta returns the stamp for the current schema version of event
+func NewEventMeta(event string) EventMeta {
+	return EventMeta{
+		Event:         event,
+		SchemaVersion: currentSchemaVersions[event],
+	}
+}
+
+// SchemaInfo describes the published schema versions of one event
+type SchemaInfo struct {
+	Event    string `json:"event"`
+	Current  int    `json:"current"`
+	Versions []int  `json:"versions"`
+}
+
+// ListSchemas returns every published event schema, sorted by event name
+func ListSchemas() ([]SchemaInfo, error) {
+	entries, err := fs.ReadDir(schemaFiles, "schemas")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schemas: %w", err)
+	}
+
+	versions := make(map[string][]int)
+	for _, entry := range entries {
+		event, version, ok := parseSchemaFileName(entry.Name())
+		if !ok {
+			continue
+		}
+		versions[event] = append(versions[event], version)
+	}
+
+	schemas := make([]SchemaInfo, 0, len(versions))
+	for event, eventVersions := range versions {
+		sort.Ints(eventVersions)
+		schemas = append(schemas, SchemaInfo{
+			Event:    event,
+			Current:  currentSchemaVersions[event],
+			Versions: eventVersions,
+		})
+	}
+	sort.Slice(schemas, func(i, j int) bool {
+		return schemas[i].Event < schemas[j].Event
+	})
+
+	return schemas, nil
+}
+
+// GetSchema returns the JSON Schema document for an event. A zero version
+// selects the version currently emitted. ok is false when no such schema is
+// published.
+func GetSchema(event string, version int) (json.RawMessage, bool) {
+	if version == 0 {
+		version = currentSchemaVersions[event]
+	}
+
+	data, err := schemaFiles.ReadFile(path.Join("schemas", fmt.Sprintf("%s.v%d.json", event, version)))
+	if err != nil {
+		return nil, false
+	}
+
+	return data, true
+}
+
+// parseSchemaFileName splits "<event>.v<N>.json" into its parts
+func parseSchemaFileName(name string) (string, int, bool) {
+	base := strings.TrimSuffix(name, ".json")
+	idx := strings.LastIndex(base, ".v")
+	if base == name || idx <= 0 {
+		return "", 0, false
+	}
+
+	version, err := strconv.Atoi(base[idx+2:])
+	if err != nil || version <= 0 {
+		return "", 0, false
+	}
+
+	return base[:idx], version, true
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCurrentSchemasArePublished(t *testing.T) {
+	for event, version := range currentSchemaVersions {
+		data, ok := GetSchema(event, version)
+		if assert.True(t, ok, "missing schema for %s v%d", event, version) {
+			assert.True(t, json.Valid(data), "schema for %s v%d is not valid JSON", event, version)
+		}
+	}
+}
+
+func TestListSchemas(t *testing.T) {
+	schemas, err := ListSchemas()
+	assert.NoError(t, err)
+
+	found := false
+	for _, schema := range schemas {
+		if schema.Event == EventQuotaAlert {
+			found = true
+			assert.Equal(t, currentSchemaVersions[EventQuotaAlert], schema.Current)
+			assert.Contains(t, schema.Versions, schema.Current)
+		}
+	}
+	assert.True(t, found)
+}
+
+func TestGetSchema_Unknown(t *testing.T) {
+	_, ok := GetSchema("no_such_event", 0)
+	assert.False(t, ok)
+
+	_, ok = GetSchema(EventQuotaAlert, 99)
+	assert.False(t, ok)
+}
+
+func TestQuotaAlert_IsStamped(t *testing.T) {
+	alert := QuotaAlert{
+		EventMeta: NewEventMeta(EventQuotaAlert),
+		ProjectID: "1234",
+		Timestamp: time.Now(),
+	}
+
+	data, err := json.Marshal(alert)
+	assert.NoError(t, err)
+
+	var payload map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &payload))
+	assert.Equal(t, EventQuotaAlert, payload["event"])
+	assert.Equal(t, float64(1), payload["schema_version"])
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// JournalEntry is one version write recorded in the failover journal while
+// Git is failed over. A nil Version records the removal of the app's record,
+// as done by renames.
+type JournalEntry struct {
+	AppID      string      `json:"app_id"`
+	Version    *AppVersion `json:"version,omitempty"`
+	RecordedAt time.Time   `json:"recorded_at"`
+}
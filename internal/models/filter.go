@@ -0,0 +1,62 @@
+package models
+
+import "strings"
+
+// VersionFilter narrows version listings. Empty fields match everything.
+type VersionFilter struct {
+	// RepoName matches a case-insensitive substring of the repo name
+	RepoName string
+	// ProjectID matches apps of one project, see InProject
+	ProjectID string
+	// ExcludeDeleted drops tombstones of deleted apps
+	ExcludeDeleted bool
+	// Lifecycle matches apps in one of these comma-separated lifecycle
+	// states
+	Lifecycle string
+}
+
+func (f VersionFilter) Matches(version *AppVersion) bool {
+	if f.ExcludeDeleted && version.IsDeleted() {
+		return false
+	}
+	if f.RepoName != "" && !strings.Contains(strings.ToLower(version.RepoName), strings.ToLower(f.RepoName)) {
+		return false
+	}
+	if f.Lifecycle != "" && !strings.Contains(","+f.Lifecycle+",", ","+version.State()+",") {
+		return false
+	}
+	return true
+}
+
+// MatchesApp is Matches including the filters that depend on the app ID
+func (f VersionFilter) MatchesApp(appID string, version *AppVersion) bool {
+	if f.ProjectID != "" && !InProject(appID, version, f.ProjectID) {
+		return false
+	}
+	return f.Matches(version)
+}
+
+// Apply returns the subset of versions matching the filter
+func (f VersionFilter) Apply(versions map[string]*AppVersion) map[string]*AppVersion {
+	if f == (VersionFilter{}) {
+		return versions
+	}
+
+	filtered := make(map[string]*AppVersion)
+	for appID, version := range versions {
+		if f.MatchesApp(appID, version) {
+			filtered[appID] = version
+		}
+	}
+	return filtered
+}
+
+// InProject reports whether an app belongs to projectID. The project stored
+// with the app is authoritative, so this works for every app ID scheme;
+// records without one fall back to the project-app ID prefix.
+func InProject(appID string, version *AppVersion, projectID string) bool {
+	if version != nil && version.ProjectID != "" {
+		return version.ProjectID == projectID
+	}
+	return strings.HasPrefix(appID, projectID+"-")
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionFilter_Apply(t *testing.T) {
+	versions := map[string]*AppVersion{
+		"1234-user-service":    {Current: "1.0.0", RepoName: "platform/user-service"},
+		"1234-payment-service": {Current: "2.0.0", RepoName: "payments/Payment-Service"},
+		"5678-legacy":          {Current: "0.1.0", Lifecycle: LifecycleDeprecated},
+	}
+
+	tests := []struct {
+		name   string
+		filter VersionFilter
+		want   []string
+	}{
+		{
+			name:   "empty filter matches everything",
+			filter: VersionFilter{},
+			want:   []string{"1234-user-service", "1234-payment-service", "5678-legacy"},
+		},
+		{
+			name:   "repo name substring",
+			filter: VersionFilter{RepoName: "platform/"},
+			want:   []string{"1234-user-service"},
+		},
+		{
+			name:   "repo name is case-insensitive",
+			filter: VersionFilter{RepoName: "payment-service"},
+			want:   []string{"1234-payment-service"},
+		},
+		{
+			name:   "no match",
+			filter: VersionFilter{RepoName: "unknown"},
+			want:   []string{},
+		},
+		{
+			name:   "lifecycle states",
+			filter: VersionFilter{Lifecycle: "deprecated,frozen"},
+			want:   []string{"5678-legacy"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.filter.Apply(versions)
+			assert.Len(t, got, len(tt.want))
+			for _, appID := range tt.want {
+				assert.Contains(t, got, appID)
+			}
+		})
+	}
+}
+
+func TestVersionFilter_MatchesApp(t *testing.T) {
+	deletedAt := time.Now()
+	live := &AppVersion{Current: "1.0.0", ProjectID: "platform/billing"}
+	deleted := &AppVersion{Current: "1.0.0", ProjectID: "1234", DeletedAt: &deletedAt}
+	legacy := &AppVersion{Current: "1.0.0"}
+
+	assert.True(t, VersionFilter{ProjectID: "platform/billing"}.MatchesApp("platform/billing/api", live))
+	assert.False(t, VersionFilter{ProjectID: "platform"}.MatchesApp("platform/billing/api", live))
+	assert.True(t, VersionFilter{ProjectID: "1234"}.MatchesApp("1234-legacy", legacy))
+	assert.True(t, VersionFilter{ProjectID: "1234"}.MatchesApp("1234-old", deleted))
+	assert.False(t, VersionFilter{ProjectID: "1234", ExcludeDeleted: true}.MatchesApp("1234-old", deleted))
+}
@@ -0,0 +1,99 @@
+package models
+
+import "strings"
+
+// Output formats of versions, chosen with the format query parameter of
+// read endpoints. Stored versions are never changed.
+const (
+	// FormatPlain renders versions as stored, the default
+	FormatPlain = "plain"
+	// FormatV prefixes versions with v, as Git tags usually are: v1.2.3
+	FormatV = "v"
+	// FormatDocker renders versions as valid Docker tags: the + of build
+	// metadata becomes _, so 1.2.3+build.5 is 1.2.3_build.5
+	FormatDocker = "docker"
+)
+
+// maxDockerTagLength is the longest tag a Docker registry accepts
+const maxDockerTagLength = 128
+
+// IsVersionFormat reports whether format is a known output format
+func IsVersionFormat(format string) bool {
+	switch format {
+	case FormatPlain, FormatV, FormatDocker:
+		return true
+	default:
+		return false
+	}
+}
+
+// FormatVersion renders a version in format. Empty versions stay empty, and
+// versions already starting with v are not prefixed again.
+func FormatVersion(version, format string) string {
+	if version == "" {
+		return version
+	}
+
+	switch format {
+	case FormatV:
+		if strings.HasPrefix(version, "v") {
+			return version
+		}
+		return "v" + version
+	case FormatDocker:
+		return dockerTag(version)
+	default:
+		return version
+	}
+}
+
+// dockerTag replaces the characters Docker tags don't allow: + becomes _
+// and anything else outside [A-Za-z0-9_.-] becomes -. Tags are cut to
+// maxDockerTagLength.
+func dockerTag(version string) string {
+	tag := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '.', r == '-':
+			return r
+		case r == '+':
+			return '_'
+		default:
+			return '-'
+		}
+	}, version)
+	if len(tag) > maxDockerTagLength {
+		tag = tag[:maxDockerTagLength]
+	}
+	return tag
+}
+
+// Formatted returns v with its current version and alias targets rendered
+// in format. v itself is not changed.
+func (v *AppVersion) Formatted(format string) *AppVersion {
+	if format == "" || format == FormatPlain {
+		return v
+	}
+
+	formatted := *v
+	formatted.Current = FormatVersion(v.Current, format)
+	if v.Aliases != nil {
+		formatted.Aliases = make(map[string]string, len(v.Aliases))
+		for alias, version := range v.Aliases {
+			formatted.Aliases[alias] = FormatVersion(version, format)
+		}
+	}
+	return &formatted
+}
+
+// FormatVersions returns versions with every record rendered in format
+func FormatVersions(versions map[string]*AppVersion, format string) map[string]*AppVersion {
+	if format == "" || format == FormatPlain {
+		return versions
+	}
+
+	formatted := make(map[string]*AppVersion, len(versions))
+	for appID, version := range versions {
+		formatted[appID] = version.Formatted(format)
+	}
+	return formatted
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		format  string
+		want    string
+	}{
+		{"1.2.3", FormatPlain, "1.2.3"},
+		{"1.2.3", "", "1.2.3"},
+		{"1.2.3", FormatV, "v1.2.3"},
+		{"v1.2.3", FormatV, "v1.2.3"},
+		{"", FormatV, ""},
+		{"1.2.3-rc.1+build.5", FormatDocker, "1.2.3-rc.1_build.5"},
+		{"2026.01.3", FormatDocker, "2026.01.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version+"/"+tt.format, func(t *testing.T) {
+			assert.Equal(t, tt.want, FormatVersion(tt.version, tt.format))
+		})
+	}
+}
+
+func TestAppVersion_Formatted(t *testing.T) {
+	version := &AppVersion{Current: "1.2.3", Aliases: map[string]string{"stable": "1.2.2"}}
+
+	formatted := version.Formatted(FormatV)
+	assert.Equal(t, "v1.2.3", formatted.Current)
+	assert.Equal(t, "v1.2.2", formatted.Aliases["stable"])
+	assert.Equal(t, "1.2.3", version.Current)
+	assert.Equal(t, "1.2.2", version.Aliases["stable"])
+
+	assert.Same(t, version, version.Formatted(FormatPlain))
+}
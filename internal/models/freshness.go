@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// AppFreshness reports how long an app's writes took to become durable,
+// i.e. the lag between the Redis write and the confirmed Git push.
+// LastLagSeconds is the lag of the most recent write confirmed durable;
+// Pending counts writes not pushed yet and PendingLagSeconds is the age of
+// the oldest of them.
+type AppFreshness struct {
+	AppID             string     `json:"app_id"`
+	LastWrittenAt     time.Time  `json:"last_written_at"`
+	LastDurableAt     *time.Time `json:"last_durable_at,omitempty"`
+	LastLagSeconds    float64    `json:"last_lag_seconds"`
+	Pending           int        `json:"pending"`
+	PendingLagSeconds float64    `json:"pending_lag_seconds,omitempty"`
+}
+
+// BurnRate is the rate at which a window consumes the freshness error
+// budget; 1 spends exactly the budget over the SLO period
+type BurnRate struct {
+	Window    string  `json:"window"`
+	Good      int64   `json:"good"`
+	Bad       int64   `json:"bad"`
+	Rate      float64 `json:"rate"`
+	Threshold float64 `json:"threshold"`
+}
+
+// FreshnessReport summarizes write durability against the freshness SLO
+type FreshnessReport struct {
+	Target          string         `json:"target"`
+	Objective       float64        `json:"objective"`
+	WorstLagSeconds float64        `json:"worst_lag_seconds"`
+	WorstApp        string         `json:"worst_app,omitempty"`
+	Pending         int            `json:"pending"`
+	BurnRates       []BurnRate     `json:"burn_rates"`
+	Apps            []AppFreshness `json:"apps"`
+	GeneratedAt     time.Time      `json:"generated_at"`
+}
+
+// FreshnessAlert is sent when the freshness error budget burns faster than
+// a burn-rate rule allows. Text makes it directly postable to Slack.
+type FreshnessAlert struct {
+	EventMeta
+	Text      string    `json:"text"`
+	Window    string    `json:"window"`
+	BurnRate  float64   `json:"burn_rate"`
+	Threshold float64   `json:"threshold"`
+	Objective float64   `json:"objective"`
+	Target    string    `json:"target"`
+	WorstApp  string    `json:"worst_app,omitempty"`
+	WorstLag  float64   `json:"worst_lag_seconds"`
+	Timestamp time.Time `json:"timestamp"`
+}
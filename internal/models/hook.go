@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// IncrementHookEvent is posted to increment hooks. Pre-increment hooks receive
+// it before the new version is stored; post-increment hooks after.
+type IncrementHookEvent struct {
+	EventMeta
+	AppID          string        `json:"app_id"`
+	ProjectID      string        `json:"project_id"`
+	AppName        string        `json:"app_name"`
+	Type           IncrementType `json:"type"`
+	CurrentVersion string        `json:"current_version"`
+	NewVersion     string        `json:"new_version"`
+	Timestamp      time.Time     `json:"timestamp"`
+}
+
+// HookDecision is the optional response body of a pre-increment hook. An
+// empty body allows the increment.
+type HookDecision struct {
+	Allow  bool   `json:"allow"`
+	Reason string `json:"reason,omitempty"`
+}
@@ -0,0 +1,136 @@
+package models
+
+import (
+	"crypto/rand"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// App ID scheme names
+const (
+	IDSchemeProjectApp = "project-app"
+	IDSchemePath       = "path"
+	IDSchemeUUID       = "uuid"
+)
+
+// AppIdentifier is an app's ID together with the structured attributes it
+// stands for. Schemes whose IDs do not encode the attributes leave them empty
+// (see Opaque); they then come from the registration metadata stored with the
+// app (AppVersion.ProjectID and AppVersion.AppName).
+type AppIdentifier struct {
+	ID        string `json:"id"`
+	ProjectID string `json:"project_id,omitempty"`
+	AppName   string `json:"app_name,omitempty"`
+}
+
+// Opaque reports whether the ID carries no project or app attributes
+func (id AppIdentifier) Opaque() bool {
+	return id.ProjectID == ""
+}
+
+// IDScheme validates app IDs and derives their attributes. A deployment uses
+// a single scheme for all of its apps.
+type IDScheme interface {
+	// Name returns the scheme name used in configuration
+	Name() string
+	// Parse validates appID and returns whatever attributes it encodes
+	Parse(appID string) (AppIdentifier, error)
+	// Format returns the ID of a project's app
+	Format(projectID, appName string) (string, error)
+}
+
+// NewIDScheme returns the scheme registered under name; an empty name selects
+// the default project-app scheme
+func NewIDScheme(name string) (IDScheme, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", IDSchemeProjectApp:
+		return projectAppScheme{}, nil
+	case IDSchemePath:
+		return pathScheme{}, nil
+	case IDSchemeUUID:
+		return uuidScheme{}, nil
+	default:
+		return nil, fmt.Errorf("unknown app ID scheme: %s", name)
+	}
+}
+
+// DefaultIDScheme returns the project-app scheme
+func DefaultIDScheme() IDScheme {
+	return projectAppScheme{}
+}
+
+// projectAppScheme is the original "{project-id}-{app-name}" format: the
+// project ID is everything before the first dash
+type projectAppScheme struct{}
+
+func (projectAppScheme) Name() string { return IDSchemeProjectApp }
+
+func (projectAppScheme) Parse(appID string) (AppIdentifier, error) {
+	parts := strings.Split(appID, "-")
+	if len(parts) < 2 {
+		return AppIdentifier{}, fmt.Errorf("invalid app ID format: %s", appID)
+	}
+	return AppIdentifier{
+		ID:        appID,
+		ProjectID: parts[0],
+		AppName:   strings.Join(parts[1:], "-"),
+	}, nil
+}
+
+func (projectAppScheme) Format(projectID, appName string) (string, error) {
+	return fmt.Sprintf("%s-%s", projectID, appName), nil
+}
+
+// pathScheme uses "{project-path}/{app-name}": the last path segment is the
+// app and the rest is the GitLab project path, e.g. "platform/billing/api"
+type pathScheme struct{}
+
+func (pathScheme) Name() string { return IDSchemePath }
+
+func (pathScheme) Parse(appID string) (AppIdentifier, error) {
+	segments := strings.Split(appID, "/")
+	if len(segments) < 2 {
+		return AppIdentifier{}, fmt.Errorf("invalid app ID format: %s", appID)
+	}
+	for _, segment := range segments {
+		if segment == "" || segment == "." || segment == ".." {
+			return AppIdentifier{}, fmt.Errorf("invalid app ID format: %s", appID)
+		}
+	}
+	last := len(segments) - 1
+	return AppIdentifier{
+		ID:        appID,
+		ProjectID: strings.Join(segments[:last], "/"),
+		AppName:   segments[last],
+	}, nil
+}
+
+func (pathScheme) Format(projectID, appName string) (string, error) {
+	return strings.Trim(projectID, "/") + "/" + appName, nil
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// uuidScheme uses random UUIDs. IDs are opaque: project and app come from the
+// registration metadata stored with the app.
+type uuidScheme struct{}
+
+func (uuidScheme) Name() string { return IDSchemeUUID }
+
+func (uuidScheme) Parse(appID string) (AppIdentifier, error) {
+	if !uuidPattern.MatchString(appID) {
+		return AppIdentifier{}, fmt.Errorf("invalid app ID format: %s", appID)
+	}
+	return AppIdentifier{ID: appID}, nil
+}
+
+func (uuidScheme) Format(projectID, appName string) (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate app ID: %w", err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
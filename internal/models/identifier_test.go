@@ -0,0 +1,104 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIDScheme_Parse(t *testing.T) {
+	tests := []struct {
+		name    string
+		scheme  string
+		appID   string
+		want    AppIdentifier
+		wantErr bool
+	}{
+		{
+			name:   "project-app",
+			scheme: IDSchemeProjectApp,
+			appID:  "1234-user-service",
+			want:   AppIdentifier{ID: "1234-user-service", ProjectID: "1234", AppName: "user-service"},
+		},
+		{
+			name:    "project-app without dash",
+			scheme:  IDSchemeProjectApp,
+			appID:   "userservice",
+			wantErr: true,
+		},
+		{
+			name:   "path",
+			scheme: IDSchemePath,
+			appID:  "platform/billing/api",
+			want:   AppIdentifier{ID: "platform/billing/api", ProjectID: "platform/billing", AppName: "api"},
+		},
+		{
+			name:    "path with empty segment",
+			scheme:  IDSchemePath,
+			appID:   "platform//api",
+			wantErr: true,
+		},
+		{
+			name:    "path without project",
+			scheme:  IDSchemePath,
+			appID:   "api",
+			wantErr: true,
+		},
+		{
+			name:   "uuid is opaque",
+			scheme: IDSchemeUUID,
+			appID:  "3f2b8c1e-9d4a-4f6b-8e2a-1c5d7e9f0a3b",
+			want:   AppIdentifier{ID: "3f2b8c1e-9d4a-4f6b-8e2a-1c5d7e9f0a3b"},
+		},
+		{
+			name:    "invalid uuid",
+			scheme:  IDSchemeUUID,
+			appID:   "1234-user-service",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme, err := NewIDScheme(tt.scheme)
+			assert.NoError(t, err)
+
+			got, err := scheme.Parse(tt.appID)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.scheme == IDSchemeUUID, got.Opaque())
+		})
+	}
+}
+
+func TestIDScheme_FormatRoundTrip(t *testing.T) {
+	for _, name := range []string{IDSchemeProjectApp, IDSchemePath, IDSchemeUUID} {
+		t.Run(name, func(t *testing.T) {
+			scheme, err := NewIDScheme(name)
+			assert.NoError(t, err)
+
+			appID, err := scheme.Format("platform", "user-service")
+			assert.NoError(t, err)
+
+			id, err := scheme.Parse(appID)
+			assert.NoError(t, err)
+			if !id.Opaque() {
+				assert.Equal(t, "platform", id.ProjectID)
+				assert.Equal(t, "user-service", id.AppName)
+			}
+		})
+	}
+}
+
+func TestNewIDScheme_Unknown(t *testing.T) {
+	_, err := NewIDScheme("ulid")
+	assert.Error(t, err)
+
+	scheme, err := NewIDScheme("")
+	assert.NoError(t, err)
+	assert.Equal(t, IDSchemeProjectApp, scheme.Name())
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// ProjectMigrationRequest remaps project IDs, e.g. when projects move to
+// another GitLab instance. Projects maps each old project ID to its new one.
+type ProjectMigrationRequest struct {
+	Projects map[string]string `json:"projects" binding:"required"`
+	DryRun   bool              `json:"dry_run"`
+}
+
+// AppMigration is the move of one app to its new project
+type AppMigration struct {
+	AppID        string `json:"app_id"`
+	NewAppID     string `json:"new_app_id"`
+	ProjectID    string `json:"project_id"`
+	NewProjectID string `json:"new_project_id"`
+	Deleted      bool   `json:"deleted,omitempty"`
+}
+
+// ProjectMigrationReport lists the apps a project migration moves. Dry runs
+// report the same moves and conflicts without writing anything.
+type ProjectMigrationReport struct {
+	DryRun           bool           `json:"dry_run"`
+	Revision         string         `json:"revision,omitempty"`
+	Apps             []AppMigration `json:"apps"`
+	Conflicts        []string       `json:"conflicts,omitempty"`
+	ReservedProjects int            `json:"reserved_projects"`
+	Webhooks         int            `json:"webhooks"`
+	Pushed           bool           `json:"pushed"`
+	Warnings         []string       `json:"warnings,omitempty"`
+	StartedAt        time.Time      `json:"started_at"`
+	CompletedAt      time.Time      `json:"completed_at"`
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Kinds of outbound calls deferred in air-gapped mode
+const (
+	// DeferredWebhook is a notification posted to URL: a post-increment
+	// hook, alert, cache purge or project webhook delivery
+	DeferredWebhook = "webhook"
+	// DeferredGitLabSeed looks up the latest GitLab tag of an app created
+	// while air-gapped with the default version
+	DeferredGitLabSeed = "gitlab_seed"
+)
+
+// DeferredCall is an outbound call queued in the outbox instead of being
+// made, kept until a flush succeeds. Secret signs webhook deliveries and is
+// never returned by the API.
+type DeferredCall struct {
+	ID        string          `json:"id"`
+	Kind      string          `json:"kind"`
+	URL       string          `json:"url,omitempty"`
+	Secret    string          `json:"secret,omitempty"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	AppID     string          `json:"app_id,omitempty"`
+	ProjectID string          `json:"project_id,omitempty"`
+	// SeededVersion is the version an app was created with while
+	// air-gapped; the tag only replaces it if the app is still there
+	SeededVersion string    `json:"seeded_version,omitempty"`
+	QueuedAt      time.Time `json:"queued_at"`
+	Attempts      int       `json:"attempts,omitempty"`
+	LastError     string    `json:"last_error,omitempty"`
+}
+
+// Redacted returns a copy without the secret and the payload
+func (c DeferredCall) Redacted() DeferredCall {
+	c.Secret = ""
+	c.Payload = nil
+	return c
+}
+
+// OutboxResponse lists the calls waiting in the outbox, oldest first
+type OutboxResponse struct {
+	AirGapped bool           `json:"air_gapped"`
+	Pending   int            `json:"pending"`
+	Calls     []DeferredCall `json:"calls"`
+}
+
+// FlushReport summarizes an outbox flush. Skipped calls were dropped
+// without being made, such as seeds of apps that moved on; failed calls
+// stay queued for the next flush.
+type FlushReport struct {
+	Sent       int       `json:"sent"`
+	Skipped    int       `json:"skipped"`
+	Failed     int       `json:"failed"`
+	Remaining  int       `json:"remaining"`
+	Errors     []string  `json:"errors,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
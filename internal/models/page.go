@@ -0,0 +1,8 @@
+package models
+
+// VersionPage is one page of a version listing in app ID order. NextCursor
+// is empty on the last page.
+type VersionPage struct {
+	Versions   map[string]*AppVersion `json:"versions"`
+	NextCursor string                 `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import "strings"
+
+// Role is what a caller may do on a project; each role includes the ones
+// before it
+type Role string
+
+const (
+	RoleRead  Role = "read"
+	RoleWrite Role = "write"
+	RoleAdmin Role = "admin"
+)
+
+// ProjectWildcard grants a role on every project
+const ProjectWildcard = "*"
+
+var roleRanks = map[Role]int{RoleRead: 1, RoleWrite: 2, RoleAdmin: 3}
+
+// Includes reports whether r allows everything other does
+func (r Role) Includes(other Role) bool {
+	return roleRanks[r] >= roleRanks[other] && roleRanks[other] > 0
+}
+
+// RoleGrant gives the callers matching Subject a role on a project, or on
+// every project with ProjectWildcard. Subject is "{identity type}:{subject}",
+// such as "api_key:team-payments"; a trailing * matches any rest, as in
+// "oidc:project_path:payments/*".
+type RoleGrant struct {
+	Subject   string `json:"subject"`
+	Role      Role   `json:"role"`
+	ProjectID string `json:"project_id"`
+}
+
+// Matches reports whether the grant applies to identity
+func (g RoleGrant) Matches(identity Identity) bool {
+	subject := identity.Type + ":" + identity.Subject
+	if prefix, ok := strings.CutSuffix(g.Subject, "*"); ok {
+		return strings.HasPrefix(subject, prefix)
+	}
+	return subject == g.Subject
+}
+
+// RBACPolicy holds the roles granted to callers authenticated by API keys or
+// OIDC tokens. A nil policy grants everything.
+type RBACPolicy struct {
+	grants []RoleGrant
+}
+
+// NewRBACPolicy returns a policy with grants
+func NewRBACPolicy(grants []RoleGrant) *RBACPolicy {
+	return &RBACPolicy{grants: grants}
+}
+
+// Allows reports whether identity holds role on projectID. Only grants on
+// ProjectWildcard cover a projectID of ProjectWildcard, which stands for
+// every project at once.
+func (p *RBACPolicy) Allows(identity Identity, projectID string, role Role) bool {
+	if p == nil {
+		return true
+	}
+	for _, grant := range p.grants {
+		if grant.Role.Includes(role) && (grant.ProjectID == ProjectWildcard || grant.ProjectID == projectID) && grant.Matches(identity) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRBACPolicy_Allows(t *testing.T) {
+	policy := NewRBACPolicy([]RoleGrant{
+		{Subject: "api_key:team-a", Role: RoleWrite, ProjectID: "1234"},
+		{Subject: "api_key:auditor", Role: RoleRead, ProjectID: ProjectWildcard},
+		{Subject: "oidc:project_path:payments/*", Role: RoleAdmin, ProjectID: "5678"},
+	})
+	teamA := Identity{Type: IdentityAPIKey, Subject: "team-a"}
+	auditor := Identity{Type: IdentityAPIKey, Subject: "auditor"}
+	pipeline := Identity{Type: IdentityOIDC, Subject: "project_path:payments/api:ref_type:branch:ref:main"}
+
+	assert.True(t, policy.Allows(teamA, "1234", RoleRead), "write includes read")
+	assert.True(t, policy.Allows(teamA, "1234", RoleWrite))
+	assert.False(t, policy.Allows(teamA, "1234", RoleAdmin))
+	assert.False(t, policy.Allows(teamA, "5678", RoleWrite), "grants are scoped to their project")
+	assert.False(t, policy.Allows(teamA, ProjectWildcard, RoleRead))
+
+	assert.True(t, policy.Allows(auditor, "5678", RoleRead))
+	assert.True(t, policy.Allows(auditor, ProjectWildcard, RoleRead))
+	assert.False(t, policy.Allows(auditor, "5678", RoleWrite))
+
+	assert.True(t, policy.Allows(pipeline, "5678", RoleAdmin))
+	assert.False(t, policy.Allows(Identity{Type: IdentityAPIKey, Subject: "project_path:payments/api"}, "5678", RoleRead), "subjects are matched with their identity type")
+
+	var none *RBACPolicy
+	assert.True(t, none.Allows(teamA, "5678", RoleAdmin))
+}
@@ -0,0 +1,17 @@
+package models
+
+// ReservedVersionsRequest replaces a reserved version list; an empty list
+// clears it
+type ReservedVersionsRequest struct {
+	Versions []string `json:"versions"`
+}
+
+// ReservedVersions lists the versions that will not be issued. For an app,
+// AppVersions come from its policy and ProjectVersions apply to every app in
+// its project.
+type ReservedVersions struct {
+	AppID           string   `json:"app_id,omitempty"`
+	ProjectID       string   `json:"project_id"`
+	AppVersions     []string `json:"app_versions,omitempty"`
+	ProjectVersions []string `json:"project_versions"`
+}
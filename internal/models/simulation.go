@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// SimulationRequest proposes an increment type for apps of a project; an
+// empty type follows the app's strategy
+type SimulationRequest struct {
+	Bumps map[string]IncrementType `json:"bumps" binding:"required"`
+}
+
+// SimulatedVersion is an app's outcome in a simulation. Next is empty for
+// apps that are not bumped or whose bump breaks a constraint.
+type SimulatedVersion struct {
+	Current string        `json:"current"`
+	Next    string        `json:"next,omitempty"`
+	Type    IncrementType `json:"type,omitempty"`
+}
+
+// SimulationViolation is a policy or constraint that executing the bumps
+// would break, with the error code the increment would fail with. AppID is
+// empty for violations of the whole project, such as quotas.
+type SimulationViolation struct {
+	AppID   string `json:"app_id,omitempty"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// SimulationReport is the version map a set of bumps would produce for a
+// project, covering every app of the project and every app bumped. Valid is
+// false when any violation was found.
+type SimulationReport struct {
+	ProjectID   string                      `json:"project_id"`
+	Valid       bool                        `json:"valid"`
+	Versions    map[string]SimulatedVersion `json:"versions"`
+	Violations  []SimulationViolation       `json:"violations"`
+	GeneratedAt time.Time                   `json:"generated_at"`
+}
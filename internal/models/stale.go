@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// StaleApp is an app whose version has not changed within the stale
+// threshold
+type StaleApp struct {
+	AppID       string    `json:"app_id"`
+	ProjectID   string    `json:"project_id"`
+	AppName     string    `json:"app_name"`
+	Version     string    `json:"version"`
+	LastUpdated time.Time `json:"last_updated"`
+	IdleDays    int       `json:"idle_days"`
+}
+
+// StaleReport lists stale apps, least recently updated first. Action is set
+// on reports of the background stale check and names what it did to them.
+type StaleReport struct {
+	Days        int        `json:"days"`
+	Cutoff      time.Time  `json:"cutoff"`
+	Apps        []StaleApp `json:"apps"`
+	Count       int        `json:"count"`
+	Action      string     `json:"action,omitempty"`
+	GeneratedAt time.Time  `json:"generated_at"`
+}
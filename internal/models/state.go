@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// StateBundleFormatVersion is the format version of state bundles written by
+// this release; imports reject any other
+const StateBundleFormatVersion = 1
+
+// StateBundle is a complete copy of the service state: the versions file
+// including tombstones, the Git history of every app, project reservations
+// and webhook subscriptions. Locks, labels (annotations), aliases and
+// policies travel inside the version records.
+type StateBundle struct {
+	FormatVersion    int                              `json:"format_version"`
+	ExportedAt       time.Time                        `json:"exported_at"`
+	Revision         string                           `json:"revision,omitempty"`
+	Versions         map[string]*AppVersion           `json:"versions"`
+	History          map[string][]VersionHistoryEntry `json:"history,omitempty"`
+	ReservedVersions map[string][]string              `json:"reserved_versions,omitempty"`
+	Webhooks         []WebhookSubscription            `json:"webhooks,omitempty"`
+}
+
+// StateImportReport summarizes a state bundle import
+type StateImportReport struct {
+	Revision         string    `json:"revision,omitempty"`
+	Apps             int       `json:"apps"`
+	HistoryReplayed  bool      `json:"history_replayed"`
+	HistoryCommits   int       `json:"history_commits"`
+	ReservedProjects int       `json:"reserved_projects"`
+	Webhooks         int       `json:"webhooks"`
+	Pushed           bool      `json:"pushed"`
+	Warnings         []string  `json:"warnings,omitempty"`
+	StartedAt        time.Time `json:"started_at"`
+	CompletedAt      time.Time `json:"completed_at"`
+}
+
+// HistoryStep is one commit of a replayed history: the versions apps moved
+// to at that point in time
+type HistoryStep struct {
+	Timestamp time.Time
+	Versions  map[string]string
+}
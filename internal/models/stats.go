@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+// GitStats counts the service's Git persistence operations since startup.
+// AvgLatencyMs is a moving average weighted towards recent operations.
+type GitStats struct {
+	OperationsTotal     int64      `json:"operations_total"`
+	OperationsSucceeded int64      `json:"operations_succeeded"`
+	OperationsFailed    int64      `json:"operations_failed"`
+	SuccessRatePct      float64    `json:"success_rate_pct"`
+	RetriesTotal        int64      `json:"retries_total"`
+	AvgLatencyMs        float64    `json:"avg_latency_ms"`
+	LastOperationAt     *time.Time `json:"last_operation_at,omitempty"`
+}
+
+// RedisStats counts version reads and writes against the Redis cache since
+// startup. A read that finds nothing is a miss; errors are counted apart.
+type RedisStats struct {
+	Reads       int64   `json:"reads"`
+	Hits        int64   `json:"hits"`
+	Misses      int64   `json:"misses"`
+	ReadErrors  int64   `json:"read_errors"`
+	HitRatePct  float64 `json:"hit_rate_pct"`
+	Writes      int64   `json:"writes"`
+	WriteErrors int64   `json:"write_errors"`
+	// Pool is the client's connection pool, when Redis is used
+	Pool *PoolStats `json:"pool,omitempty"`
+}
+
+// PoolStats describes a client connection pool. Hits and misses count
+// commands that found an idle connection or had to open one, timeouts those
+// that gave up waiting for a free one, and stale connections those closed
+// for being idle too long; all four only grow. TotalConns and IdleConns are
+// the connections open now.
+type PoolStats struct {
+	Hits       int64 `json:"hits"`
+	Misses     int64 `json:"misses"`
+	Timeouts   int64 `json:"timeouts"`
+	StaleConns int64 `json:"stale_conns"`
+	TotalConns int64 `json:"total_conns"`
+	IdleConns  int64 `json:"idle_conns"`
+}
+
+// GitLabStats counts requests made to the GitLab API since startup. Not
+// found answers are expected and are not failures.
+type GitLabStats struct {
+	RequestsTotal  int64 `json:"requests_total"`
+	RequestsFailed int64 `json:"requests_failed"`
+	// Retries counts requests sent again after a network error, 429 or
+	// 5xx; RateLimited counts 429 answers
+	Retries        int64      `json:"retries"`
+	RateLimited    int64      `json:"rate_limited"`
+	SuccessRatePct float64    `json:"success_rate_pct"`
+	AvgLatencyMs   float64    `json:"avg_latency_ms"`
+	LastRequestAt  *time.Time `json:"last_request_at,omitempty"`
+}
+
+// RuntimeStats is a snapshot of the operational counters otherwise only
+// logged periodically, for consumers without Prometheus
+type RuntimeStats struct {
+	StartedAt     time.Time   `json:"started_at"`
+	UptimeSeconds float64     `json:"uptime_seconds"`
+	Git           GitStats    `json:"git"`
+	Redis         RedisStats  `json:"redis"`
+	GitLab        GitLabStats `json:"gitlab"`
+	GeneratedAt   time.Time   `json:"generated_at"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+type QuotaUsage struct {
+	Used        int64   `json:"used"`
+	Limit       int64   `json:"limit,omitempty"`
+	Utilization float64 `json:"utilization,omitempty"`
+}
+
+type WindowUsage struct {
+	Window     string `json:"window"`
+	Increments int64  `json:"increments"`
+}
+
+type ProjectUsage struct {
+	ProjectID     string        `json:"project_id"`
+	Apps          QuotaUsage    `json:"apps"`
+	IncrementRate QuotaUsage    `json:"increments_per_hour"`
+	Windows       []WindowUsage `json:"windows"`
+	Warnings      []string      `json:"warnings,omitempty"`
+	GeneratedAt   time.Time     `json:"generated_at"`
+}
+
+// QuotaAlert is the notification payload sent when a project crosses a soft
+// quota threshold. Text makes it directly postable to Slack incoming webhooks.
+type QuotaAlert struct {
+	EventMeta
+	Text        string    `json:"text"`
+	ProjectID   string    `json:"project_id"`
+	Quota       string    `json:"quota"`
+	Used        int64     `json:"used"`
+	Limit       int64     `json:"limit"`
+	Utilization float64   `json:"utilization"`
+	Timestamp   time.Time `json:"timestamp"`
+}
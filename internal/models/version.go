@@ -1,40 +1,289 @@
 package models
 
 import (
-	"fmt"
-	"strings"
 	"time"
 )
 
 type AppVersion struct {
-	Current     string    `json:"current"`
-	ProjectID   string    `json:"project_id"`
-	AppName     string    `json:"app_name"`
-	RepoName    string    `json:"repo_name,omitempty"`
+	Current     string            `json:"current"`
+	ProjectID   string            `json:"project_id"`
+	AppName     string            `json:"app_name"`
+	RepoName    string            `json:"repo_name,omitempty"`
+	Locked      bool              `json:"locked,omitempty"`
+	Aliases     map[string]string `json:"aliases,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Policy      *AppPolicy        `json:"policy,omitempty"`
+	// RenamedFrom lists the IDs the app was known by before, oldest first,
+	// so its history can be followed across renames
+	RenamedFrom []string `json:"renamed_from,omitempty"`
+	// DefaultBranch and WebURL describe the app's GitLab project, like
+	// RepoName; they are read from GitLab when the app is created
+	DefaultBranch string `json:"default_branch,omitempty"`
+	WebURL        string `json:"web_url,omitempty"`
+	// Lifecycle is the app's lifecycle state; empty means active, or frozen
+	// for records written before lifecycle states that only set Locked.
+	// Locked mirrors it and is set while the app is frozen or archived. Use
+	// State to read it.
+	Lifecycle   string    `json:"lifecycle,omitempty"`
 	LastUpdated time.Time `json:"last_updated"`
+	// DeletedAt marks a tombstone: the app was deleted but its record is
+	// kept so it can be restored
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Normalized lists the rewrites applied to a version entering the
+	// system; it is only set on responses and never stored
+	Normalized []string `json:"normalized,omitempty"`
+}
+
+// IsDeleted reports whether v is a tombstone
+func (v *AppVersion) IsDeleted() bool {
+	return v.DeletedAt != nil
+}
+
+// Lifecycle states of an app
+const (
+	// LifecycleActive apps are read and written freely, the default
+	LifecycleActive = "active"
+	// LifecycleFrozen apps can be read but their version cannot change
+	LifecycleFrozen = "frozen"
+	// LifecycleDeprecated apps still work normally but are flagged for
+	// retirement
+	LifecycleDeprecated = "deprecated"
+	// LifecycleArchived apps are kept read-only for reference
+	LifecycleArchived = "archived"
+	// LifecycleDeleted apps are tombstones; the state before deletion is
+	// kept and returns on restore
+	LifecycleDeleted = "deleted"
+)
+
+// LifecycleStates lists the lifecycle states
+var LifecycleStates = []string{LifecycleActive, LifecycleFrozen, LifecycleDeprecated, LifecycleArchived, LifecycleDeleted}
+
+// IsLifecycleState reports whether state is a known lifecycle state
+func IsLifecycleState(state string) bool {
+	for _, known := range LifecycleStates {
+		if state == known {
+			return true
+		}
+	}
+	return false
+}
+
+// State returns the app's lifecycle state. Tombstones are deleted whatever
+// their stored state, and records without one are frozen when locked.
+func (v *AppVersion) State() string {
+	switch {
+	case v.IsDeleted():
+		return LifecycleDeleted
+	case v.Lifecycle != "":
+		return v.Lifecycle
+	case v.Locked:
+		return LifecycleFrozen
+	default:
+		return LifecycleActive
+	}
+}
+
+// WithState returns a copy of v in a lifecycle state other than deleted,
+// with Locked set to match
+func (v *AppVersion) WithState(state string) *AppVersion {
+	updated := *v
+	updated.Lifecycle = state
+	if state == LifecycleActive {
+		updated.Lifecycle = ""
+	}
+	updated.Locked = state == LifecycleFrozen || state == LifecycleArchived
+	return &updated
+}
+
+// Version schemes an app can be registered with
+const (
+	// VersionSchemeSemVer is major.minor.patch with optional prerelease,
+	// the default
+	VersionSchemeSemVer = "semver"
+	// VersionSchemeCalVer is YYYY.0M.MICRO (2024.06.1)
+	VersionSchemeCalVer = "calver"
+	// VersionSchemeFourPart is major.minor.patch.build (1.2.3.4)
+	VersionSchemeFourPart = "four-part"
+)
+
+// VersionSchemes lists the supported version schemes
+var VersionSchemes = []string{VersionSchemeSemVer, VersionSchemeCalVer, VersionSchemeFourPart}
+
+// AppPolicy holds the versioning rules an app was registered with
+type AppPolicy struct {
+	// Scheme is the app's version format; empty means semver
+	Scheme string `json:"scheme,omitempty"`
+	// AllowedIncrements restricts the increment types; empty allows all
+	AllowedIncrements []IncrementType `json:"allowed_increments,omitempty"`
+	// ReservedVersions are never issued to the app
+	ReservedVersions []string `json:"reserved_versions,omitempty"`
+	// Strategy is the increment applied when a request names no type: an
+	// increment type or StrategyDatePatch; empty means patch
+	Strategy string `json:"strategy,omitempty"`
+	// InitialDevelopment applies pre-1.0 semantics while the app is below
+	// 1.0.0: major increments bump the minor version (0.4.0 → 0.5.0), and
+	// only graduating reaches 1.0.0. Semver only.
+	InitialDevelopment bool `json:"initial_development,omitempty"`
+}
+
+// StrategyDatePatch is an increment strategy stamping the UTC date into the
+// patch number (1.4.20240615); a second increment on the same day takes the
+// next number
+const StrategyDatePatch = "date-patch"
+
+// IncrementFor returns the increment type a request for requested performs:
+// requested itself, or the type of the policy's strategy when the request
+// names none. A nil policy or empty strategy means patch, and date-stamped
+// patches are patch increments.
+func (p *AppPolicy) IncrementFor(requested IncrementType) IncrementType {
+	if requested != IncrementTypeDefault {
+		return requested
+	}
+	if p == nil || p.Strategy == "" || p.Strategy == StrategyDatePatch {
+		return IncrementTypePatch
+	}
+	return IncrementType(p.Strategy)
+}
+
+// DatePatches reports whether increments without a type stamp the date into
+// the patch number
+func (p *AppPolicy) DatePatches() bool {
+	return p != nil && p.Strategy == StrategyDatePatch
+}
+
+// VersionScheme returns the policy's version scheme. A nil policy or empty
+// scheme means semver.
+func (p *AppPolicy) VersionScheme() string {
+	if p == nil || p.Scheme == "" {
+		return VersionSchemeSemVer
+	}
+	return p.Scheme
+}
+
+// AllowsIncrement reports whether the policy permits an increment type. A nil
+// policy permits everything.
+func (p *AppPolicy) AllowsIncrement(incrementType IncrementType) bool {
+	if p == nil || len(p.AllowedIncrements) == 0 {
+		return true
+	}
+	for _, allowed := range p.AllowedIncrements {
+		if allowed == incrementType {
+			return true
+		}
+	}
+	return false
+}
+
+// RegisterAppRequest registers an app explicitly. InitialVersion defaults to
+// the first version of the policy's scheme (1.0.0 for semver) and Policy to
+// semver without restrictions.
+type RegisterAppRequest struct {
+	ProjectID      string     `json:"project_id" binding:"required"`
+	AppName        string     `json:"app_name" binding:"required"`
+	InitialVersion string     `json:"initial_version"`
+	Policy         *AppPolicy `json:"policy"`
+}
+
+// RenameRequest is the body of POST /version/{app-id}/rename
+type RenameRequest struct {
+	NewAppID string `json:"new_app_id" binding:"required"`
+}
+
+// RenameResponse reports a renamed app under its new ID
+type RenameResponse struct {
+	AppID         string      `json:"app_id"`
+	PreviousAppID string      `json:"previous_app_id"`
+	Version       *AppVersion `json:"version"`
+}
+
+// RegisterAppResponse carries the ID assigned to a registered app
+type RegisterAppResponse struct {
+	AppID   string      `json:"app_id"`
+	Version *AppVersion `json:"version"`
 }
 
 type DevVersionRequest struct {
 	SHA    string `json:"sha" binding:"required"`
 	Branch string `json:"branch" binding:"required"`
+	// Template overrides the configured dev version template, e.g.
+	// "{next}-SNAPSHOT" or "{current}-snapshot.{timestamp}"
+	Template string `json:"template,omitempty"`
+	// Base is the version {current} stands for: DevBaseCurrent, or
+	// DevBaseNext for dev versions that sort above the current release.
+	// Empty uses the configured base.
+	Base string `json:"base,omitempty"`
 }
 
+// Dev version bases
+const (
+	DevBaseCurrent = "current"
+	DevBaseNext    = "next"
+)
+
 type IncrementType string
 
 const (
-	IncrementTypePatch IncrementType = "patch"
-	IncrementTypeMinor IncrementType = "minor"
-	IncrementTypeMajor IncrementType = "major"
+	// IncrementTypeDefault leaves the increment to the app's strategy
+	IncrementTypeDefault IncrementType = ""
+	IncrementTypePatch   IncrementType = "patch"
+	IncrementTypeMinor   IncrementType = "minor"
+	IncrementTypeMajor   IncrementType = "major"
+	// IncrementTypeRC cuts or bumps a release candidate (1.2.3 → 1.3.0-rc.1 → 1.3.0-rc.2)
+	IncrementTypeRC IncrementType = "rc"
+	// IncrementTypeBuild bumps the fourth segment of four-part versions (1.2.3.4 → 1.2.3.5)
+	IncrementTypeBuild IncrementType = "build"
 )
 
 type VersionResponse struct {
 	Version string `json:"version"`
+	// Replayed is set when the version was returned for a repeated idempotency key
+	Replayed bool `json:"replayed,omitempty"`
+}
+
+// IncrementRequest is the optional body of an increment request
+type IncrementRequest struct {
+	IdempotencyKey string `json:"idempotency_key"`
+}
+
+type BatchIncrementRequest struct {
+	AppIDs []string      `json:"app_ids" binding:"required"`
+	Type   IncrementType `json:"type"`
+}
+
+// BatchIncrementResponse maps each app ID to its new version
+type BatchIncrementResponse struct {
+	Versions map[string]string `json:"versions"`
+}
+
+type NextVersionResponse struct {
+	Current string        `json:"current"`
+	Next    string        `json:"next"`
+	Type    IncrementType `json:"type"`
+}
+
+// CompareResponse orders two versions. Result is -1, 0 or 1 as V1 is lower
+// than, equal to or higher than V2; Diff is the most significant component
+// in which they differ (major, minor, patch, prerelease or none).
+type CompareResponse struct {
+	V1     string `json:"v1"`
+	V2     string `json:"v2"`
+	Result int    `json:"result"`
+	Order  string `json:"order"`
+	Diff   string `json:"diff"`
 }
 
 type ErrorResponse struct {
 	Error   string `json:"error"`
 	Code    string `json:"code,omitempty"`
 	Details string `json:"details,omitempty"`
+	// Fields lists the request body fields that failed validation
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError names a request body field and what is wrong with it
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
 
 type HealthResponse struct {
@@ -47,16 +296,91 @@ type VersionsFile struct {
 	LastUpdated time.Time              `json:"last_updated"`
 }
 
+// ParseAppID splits an app ID in the default project-app scheme
 func ParseAppID(appID string) (projectID, appName string, err error) {
-	parts := strings.Split(appID, "-")
-	if len(parts) < 2 {
-		return "", "", fmt.Errorf("invalid app ID format: %s", appID)
+	id, err := projectAppScheme{}.Parse(appID)
+	if err != nil {
+		return "", "", err
 	}
-	projectID = parts[0]
-	appName = strings.Join(parts[1:], "-")
-	return projectID, appName, nil
+	return id.ProjectID, id.AppName, nil
 }
 
+// FormatAppID builds an app ID in the default project-app scheme
 func FormatAppID(projectID, appName string) string {
-	return fmt.Sprintf("%s-%s", projectID, appName)
-}
\ No newline at end of file
+	appID, _ := projectAppScheme{}.Format(projectID, appName)
+	return appID
+}
+
+type VersionHistoryEntry struct {
+	Version   string    `json:"version"`
+	Commit    string    `json:"commit"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type RawFileUpdateResponse struct {
+	Revision string `json:"revision"`
+	Apps     int    `json:"apps"`
+	Pushed   bool   `json:"pushed"`
+	// Normalized maps app IDs to the rewrites applied to their versions
+	Normalized map[string][]string `json:"normalized,omitempty"`
+}
+
+// SetLifecycleRequest is the body of PUT /version/{app-id}/lifecycle
+type SetLifecycleRequest struct {
+	State string `json:"state" binding:"required"`
+}
+
+// SetStrategyRequest is the body of PUT /version/{app-id}/strategy. An empty
+// strategy restores patch increments.
+type SetStrategyRequest struct {
+	Strategy string `json:"strategy"`
+}
+
+// SetInitialDevelopmentRequest is the body of PUT
+// /version/{app-id}/initial-development
+type SetInitialDevelopmentRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+// SetAliasRequest points an alias at a version
+type SetAliasRequest struct {
+	Version string `json:"version" binding:"required"`
+}
+
+// VersionAlias is a named pointer to one of an app's versions
+type VersionAlias struct {
+	AppID   string `json:"app_id"`
+	Alias   string `json:"alias"`
+	Version string `json:"version"`
+}
+
+// UpdateMetadataRequest patches an app's annotations: keys set to a string
+// are added or replaced, keys set to null are removed and others are kept
+type UpdateMetadataRequest struct {
+	Annotations map[string]*string `json:"annotations" binding:"required"`
+}
+
+type PromoteResponse struct {
+	Version      string `json:"version"`
+	PromotedFrom string `json:"promoted_from"`
+}
+
+// GraduateResponse reports an app graduated from 0.x to 1.0.0
+type GraduateResponse struct {
+	Version       string `json:"version"`
+	GraduatedFrom string `json:"graduated_from"`
+}
+
+type RollbackResponse struct {
+	Version        string `json:"version"`
+	RolledBackFrom string `json:"rolled_back_from"`
+	Commit         string `json:"commit"`
+}
+
+// DecrementResponse reports an undone increment
+type DecrementResponse struct {
+	Version         string        `json:"version"`
+	DecrementedFrom string        `json:"decremented_from"`
+	Undone          IncrementType `json:"undone"`
+	Commit          string        `json:"commit"`
+}
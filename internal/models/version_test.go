@@ -2,6 +2,7 @@ package models
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -85,4 +86,54 @@ func TestFormatAppID(t *testing.T) {
 			assert.Equal(t, tt.want, got)
 		})
 	}
-}
\ No newline at end of file
+}
+func TestAppPolicy_AllowsIncrement(t *testing.T) {
+	var none *AppPolicy
+	assert.True(t, none.AllowsIncrement(IncrementTypeMajor))
+	assert.True(t, (&AppPolicy{}).AllowsIncrement(IncrementTypeMajor))
+
+	policy := &AppPolicy{AllowedIncrements: []IncrementType{IncrementTypePatch, IncrementTypeRC}}
+	assert.True(t, policy.AllowsIncrement(IncrementTypePatch))
+	assert.True(t, policy.AllowsIncrement(IncrementTypeRC))
+	assert.False(t, policy.AllowsIncrement(IncrementTypeMajor))
+}
+
+func TestAppPolicy_IncrementFor(t *testing.T) {
+	var none *AppPolicy
+	assert.Equal(t, IncrementTypePatch, none.IncrementFor(IncrementTypeDefault))
+	assert.Equal(t, IncrementTypeMajor, none.IncrementFor(IncrementTypeMajor))
+
+	minor := &AppPolicy{Strategy: string(IncrementTypeMinor)}
+	assert.Equal(t, IncrementTypeMinor, minor.IncrementFor(IncrementTypeDefault))
+	assert.Equal(t, IncrementTypePatch, minor.IncrementFor(IncrementTypePatch))
+
+	datePatch := &AppPolicy{Strategy: StrategyDatePatch}
+	assert.Equal(t, IncrementTypePatch, datePatch.IncrementFor(IncrementTypeDefault))
+	assert.True(t, datePatch.DatePatches())
+	assert.False(t, minor.DatePatches())
+}
+
+func TestAppPolicy_VersionScheme(t *testing.T) {
+	var none *AppPolicy
+	assert.Equal(t, VersionSchemeSemVer, none.VersionScheme())
+	assert.Equal(t, VersionSchemeSemVer, (&AppPolicy{}).VersionScheme())
+	assert.Equal(t, VersionSchemeCalVer, (&AppPolicy{Scheme: VersionSchemeCalVer}).VersionScheme())
+}
+
+func TestAppVersion_State(t *testing.T) {
+	now := time.Now()
+
+	assert.Equal(t, LifecycleActive, (&AppVersion{}).State())
+	assert.Equal(t, LifecycleFrozen, (&AppVersion{Locked: true}).State())
+	assert.Equal(t, LifecycleDeprecated, (&AppVersion{Lifecycle: LifecycleDeprecated}).State())
+	assert.Equal(t, LifecycleDeleted, (&AppVersion{Lifecycle: LifecycleArchived, DeletedAt: &now}).State())
+
+	archived := (&AppVersion{}).WithState(LifecycleArchived)
+	assert.Equal(t, LifecycleArchived, archived.State())
+	assert.True(t, archived.Locked)
+
+	active := archived.WithState(LifecycleActive)
+	assert.Equal(t, LifecycleActive, active.State())
+	assert.Empty(t, active.Lifecycle)
+	assert.False(t, active.Locked)
+}
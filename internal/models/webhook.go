@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// SubscribableEvents are the events a project webhook subscription can
+// receive
+var SubscribableEvents = []string{EventPostIncrement, EventQuotaAlert}
+
+// WebhookSubscription is a webhook registered by a project's owners. Events
+// narrows the subscription to some events; empty means every subscribable
+// event. The secret signs deliveries and is never returned by the API.
+type WebhookSubscription struct {
+	ID        string    `json:"id"`
+	ProjectID string    `json:"project_id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	HasSecret bool      `json:"has_secret"`
+	Events    []string  `json:"events,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Wants reports whether the subscription receives event
+func (w *WebhookSubscription) Wants(event string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Redacted returns a copy of the subscription without its secret
+func (w WebhookSubscription) Redacted() WebhookSubscription {
+	w.HasSecret = w.Secret != ""
+	w.Secret = ""
+	return w
+}
+
+// CreateWebhookRequest is the body of POST /projects/{project-id}/webhooks
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" binding:"required"`
+	Secret string   `json:"secret,omitempty"`
+	Events []string `json:"events,omitempty"`
+}
+
+// WebhookListResponse lists the webhook subscriptions of a project
+type WebhookListResponse struct {
+	ProjectID string                `json:"project_id"`
+	Webhooks  []WebhookSubscription `json:"webhooks"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookSubscription_Wants(t *testing.T) {
+	all := &WebhookSubscription{}
+	assert.True(t, all.Wants(EventPostIncrement))
+	assert.True(t, all.Wants(EventQuotaAlert))
+
+	filtered := &WebhookSubscription{Events: []string{EventQuotaAlert}}
+	assert.True(t, filtered.Wants(EventQuotaAlert))
+	assert.False(t, filtered.Wants(EventPostIncrement))
+}
+
+func TestWebhookSubscription_Redacted(t *testing.T) {
+	webhook := WebhookSubscription{ID: "abc", Secret: "s3cret"}
+
+	redacted := webhook.Redacted()
+	assert.Empty(t, redacted.Secret)
+	assert.True(t, redacted.HasSecret)
+	assert.Equal(t, "s3cret", webhook.Secret)
+
+	assert.False(t, WebhookSubscription{ID: "abc"}.Redacted().HasSecret)
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+
+	"github.com/company/version-service/internal/middleware"
+	"github.com/sirupsen/logrus"
+)
+
+// Per-app actors serialize the work on each app through a mailbox drained by
+// one goroutine, so requests for different apps run in parallel while those
+// for the same app run one at a time and in arrival order. The service keeps
+// two kinds:
+//
+//   - request actors run single-app reads-modify-writes (increment, lock,
+//     alias, ...) under the shared side of s.mu
+//   - persistence actors write to Git in the order the writes were made, so
+//     an older record never lands after a newer one
+//
+// s.mu remains as a barrier: operations spanning several apps or creating
+// apps take it exclusively and thereby wait for every running single-app
+// request. Request actors take its shared side only once a job starts, not
+// while the job waits in the mailbox, so an exclusive operation waits for
+// the jobs already running rather than for whole mailboxes. Exclusive
+// holders must never wait on a request actor, whose job may be blocked on
+// s.mu; they may flush the persistence actors, which don't take it. Code
+// running on an actor or holding s.mu must call the unexported variants
+// (getVersion, lookupVersion, ...) rather than public methods that take s.mu
+// or queue on an actor again.
+//
+// A job that panics is recovered so its actor keeps serving the app: do
+// returns the panic as an error, and jobs nobody waits for are logged.
+
+// Mailbox sizes per app. A full mailbox blocks the sender, which pushes back
+// on callers hammering one app or writing faster than Git keeps up.
+const (
+	requestMailboxSize     = 32
+	persistenceMailboxSize = 64
+)
+
+// Queue labels of the app_actor_jobs metric
+const (
+	actorQueueRequests    = "requests"
+	actorQueuePersistence = "persistence"
+)
+
+// actorQueue runs jobs one at a time per key. A key's goroutine is started by
+// its first job and stops once no job is queued or running, so idle apps cost
+// nothing.
+type actorQueue struct {
+	name        string
+	mailboxSize int
+	logger      *logrus.Logger
+
+	mu     sync.Mutex
+	actors map[string]*actor
+}
+
+type actor struct {
+	mailbox chan func()
+	// refs counts jobs queued, running or about to be queued; the actor is
+	// stopped when it drops to zero
+	refs int
+}
+
+func newActorQueue(name string, mailboxSize int, logger *logrus.Logger) *actorQueue {
+	return &actorQueue{
+		name:        name,
+		mailboxSize: mailboxSize,
+		logger:      logger,
+		actors:      make(map[string]*actor),
+	}
+}
+
+func (q *actorQueue) acquire(key string) *actor {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	a, ok := q.actors[key]
+	if !ok {
+		a = &actor{mailbox: make(chan func(), q.mailboxSize)}
+		q.actors[key] = a
+		go q.run(key, a)
+	}
+	a.refs++
+	middleware.AddActorJobs(q.name, 1)
+	return a
+}
+
+func (q *actorQueue) release(key string, a *actor) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	middleware.AddActorJobs(q.name, -1)
+	a.refs--
+	if a.refs == 0 {
+		delete(q.actors, key)
+		close(a.mailbox)
+	}
+}
+
+func (q *actorQueue) run(key string, a *actor) {
+	for job := range a.mailbox {
+		if err := recoverJob(job); err != nil {
+			q.logPanic(key, err)
+		}
+	}
+}
+
+// recoverJob runs job, returning a panic in it as an error
+func recoverJob(job func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	job()
+	return nil
+}
+
+func (q *actorQueue) logPanic(key string, err error) {
+	q.logger.WithError(err).WithFields(logrus.Fields{
+		"queue": q.name,
+		"key":   key,
+	}).Error("Actor job panicked")
+}
+
+// do runs fn on key's actor and waits for it to finish. It gives up with the
+// context's error only while still waiting for room in the mailbox; once
+// queued, fn always runs. A panic in fn is returned as an error.
+func (q *actorQueue) do(ctx context.Context, key string, fn func()) error {
+	a := q.acquire(key)
+	defer q.release(key, a)
+
+	done := make(chan struct{})
+	var panicErr error
+	select {
+	case a.mailbox <- func() { defer close(done); panicErr = recoverJob(fn) }:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	<-done
+	if panicErr != nil {
+		return fmt.Errorf("%s actor of %s: %w", q.name, key, panicErr)
+	}
+	return nil
+}
+
+// push queues fn on key's actor without waiting for it to run. It blocks
+// while the mailbox is full.
+func (q *actorQueue) push(key string, fn func()) {
+	a := q.acquire(key)
+	a.mailbox <- func() {
+		defer q.release(key, a)
+		fn()
+	}
+}
+
+// pushAll queues fn on the actors of all keys. fn runs once every key's
+// earlier jobs are done, and later jobs of those keys wait for it. Callers
+// must hold s.mu exclusively so two pushAll calls never queue in different
+// orders on shared keys.
+func (q *actorQueue) pushAll(keys []string, fn func()) {
+	var arrived sync.WaitGroup
+	arrived.Add(len(keys))
+	finished := make(chan struct{})
+
+	for _, key := range keys {
+		q.push(key, func() {
+			arrived.Done()
+			<-finished
+		})
+	}
+
+	go func() {
+		arrived.Wait()
+		defer close(finished)
+		if err := recoverJob(fn); err != nil {
+			q.logPanic(fmt.Sprintf("%d keys", len(keys)), err)
+		}
+	}()
+}
+
+// flush waits until every job queued so far has run. Callers must hold s.mu
+// exclusively so no new jobs are queued meanwhile.
+func (q *actorQueue) flush() {
+	q.mu.Lock()
+	keys := make([]string, 0, len(q.actors))
+	for key := range q.actors {
+		keys = append(keys, key)
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	q.pushAll(keys, func() { close(done) })
+	<-done
+}
+
+// runOnApp runs fn on appID's request actor, after every earlier request for
+// the app and never at the same time as one. fn runs under the shared side
+// of s.mu so exclusive operations wait for it.
+func (s *VersionService) runOnApp(ctx context.Context, appID string, fn func() error) error {
+	var err error
+	queueErr := s.requests.do(ctx, appID, func() {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		err = fn()
+	})
+	if queueErr != nil {
+		return queueErr
+	}
+	return err
+}
+
+// onApp is runOnApp for functions returning a result
+func onApp[T any](ctx context.Context, s *VersionService, appID string, fn func() (T, error)) (T, error) {
+	var result T
+	err := s.runOnApp(ctx, appID, func() error {
+		var err error
+		result, err = fn()
+		return err
+	})
+	return result, err
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActorQueue_RecoversPanics(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	q := newActorQueue("test", 4, logger)
+	ctx := context.Background()
+
+	err := q.do(ctx, "app", func() { panic("boom") })
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "boom")
+
+	// The actor keeps serving the key
+	ran := false
+	require.NoError(t, q.do(ctx, "app", func() { ran = true }))
+	assert.True(t, ran)
+
+	// Panics nobody waits for are logged
+	q.push("app", func() { panic("lost") })
+	done := make(chan struct{})
+	q.pushAll([]string{"app", "other"}, func() { panic("barrier") })
+	q.push("app", func() { close(done) })
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("actor stopped after a panic")
+	}
+	require.NoError(t, q.do(ctx, "other", func() {}))
+
+	var logged []string
+	for _, entry := range hook.AllEntries() {
+		logged = append(logged, entry.Message)
+	}
+	assert.Equal(t, []string{"Actor job panicked", "Actor job panicked"}, logged)
+}
+
+func newActorTestService(t *testing.T, opts Options) (*VersionService, *storage.MemoryStorage, *storage.MemoryStorage) {
+	t.Helper()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	cache, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	durable, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	return NewVersionService(cache, durable, nil, logger, opts), cache, durable
+}
+
+// assertDurable waits for background writes and checks durable storage
+// holds what the cache does
+func assertDurable(t *testing.T, s *VersionService, cache, durable *storage.MemoryStorage) {
+	t.Helper()
+	s.mu.Lock()
+	s.persistence.flush()
+	s.mu.Unlock()
+
+	ctx := context.Background()
+	cached, err := cache.ListVersions(ctx)
+	require.NoError(t, err)
+	stored, err := durable.ListVersions(ctx)
+	require.NoError(t, err)
+	for appID, version := range cached {
+		require.Contains(t, stored, appID)
+		assert.Equal(t, version.Current, stored[appID].Current, appID)
+	}
+}
+
+func TestIncrementVersion_ConcurrentSameApp(t *testing.T) {
+	for _, writeThrough := range []bool{false, true} {
+		t.Run(fmt.Sprintf("write-through=%v", writeThrough), func(t *testing.T) {
+			s, cache, durable := newActorTestService(t, Options{WriteThrough: writeThrough})
+			ctx := context.Background()
+
+			const increments = 40
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			seen := make(map[string]bool)
+			for i := 0; i < increments; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					resp, err := s.IncrementVersion(ctx, "1-api", models.IncrementTypePatch, "")
+					if !assert.NoError(t, err) {
+						return
+					}
+					mu.Lock()
+					defer mu.Unlock()
+					assert.False(t, seen[resp.Version], "version %s issued twice", resp.Version)
+					seen[resp.Version] = true
+				}()
+			}
+			wg.Wait()
+
+			current, err := s.GetVersion(ctx, "1-api")
+			require.NoError(t, err)
+			assert.Equal(t, fmt.Sprintf("1.0.%d", increments), current.Current)
+			assertDurable(t, s, cache, durable)
+		})
+	}
+}
+
+func TestIncrementVersion_ConcurrentApps(t *testing.T) {
+	s, cache, durable := newActorTestService(t, Options{})
+	ctx := context.Background()
+
+	const apps, increments = 8, 10
+	var wg sync.WaitGroup
+	for app := 0; app < apps; app++ {
+		for i := 0; i < increments; i++ {
+			wg.Add(1)
+			go func(appID string) {
+				defer wg.Done()
+				_, err := s.IncrementVersion(ctx, appID, models.IncrementTypeMinor, "")
+				assert.NoError(t, err)
+			}(fmt.Sprintf("%d-api", app+1))
+		}
+	}
+	wg.Wait()
+
+	for app := 0; app < apps; app++ {
+		current, err := s.GetVersion(ctx, fmt.Sprintf("%d-api", app+1))
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("1.%d.0", increments), current.Current)
+	}
+	assertDurable(t, s, cache, durable)
+}
+
+func TestIncrementVersions_ConcurrentWithSingleIncrements(t *testing.T) {
+	for _, writeThrough := range []bool{false, true} {
+		t.Run(fmt.Sprintf("write-through=%v", writeThrough), func(t *testing.T) {
+			s, cache, durable := newActorTestService(t, Options{WriteThrough: writeThrough})
+			ctx := context.Background()
+
+			apps := []string{"1-api", "1-web", "1-worker"}
+			const rounds = 10
+			var wg sync.WaitGroup
+			for i := 0; i < rounds; i++ {
+				wg.Add(2)
+				go func(i int) {
+					defer wg.Done()
+					// Overlapping batches queue on shared persistence actors
+					batch := []string{apps[i%len(apps)], apps[(i+1)%len(apps)]}
+					_, err := s.IncrementVersions(ctx, batch, models.IncrementTypePatch)
+					assert.NoError(t, err)
+				}(i)
+				go func(i int) {
+					defer wg.Done()
+					_, err := s.IncrementVersion(ctx, apps[i%len(apps)], models.IncrementTypePatch, "")
+					assert.NoError(t, err)
+				}(i)
+			}
+			wg.Wait()
+
+			// Each round increments two apps in a batch and one alone
+			total := 0
+			for _, appID := range apps {
+				current, err := s.GetVersion(ctx, appID)
+				require.NoError(t, err)
+				var patch int
+				_, err = fmt.Sscanf(current.Current, "1.0.%d", &patch)
+				require.NoError(t, err)
+				total += patch
+			}
+			assert.Equal(t, rounds*3, total)
+			assertDurable(t, s, cache, durable)
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/company/version-service/internal/clients"
+	"github.com/company/version-service/internal/middleware"
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// AirGapOptions configures air-gapped mode, in which outbound calls are
+// queued to an outbox instead of being made until an admin flushes it
+type AirGapOptions struct {
+	// Outbox receives deferred calls; nil disables air-gapped mode
+	Outbox storage.Outbox
+}
+
+// airGapState serializes flushes
+type airGapState struct {
+	flushMu sync.Mutex
+}
+
+// airGapped reports whether outbound calls are deferred
+func (s *VersionService) airGapped() bool {
+	return s.airGapOpts.Outbox != nil
+}
+
+// sentOutcome is the hook metric outcome of a notification that did not
+// fail: queued rather than sent while air-gapped
+func (s *VersionService) sentOutcome() string {
+	if s.airGapped() {
+		return "deferred"
+	}
+	return "sent"
+}
+
+// deferSeed queues the tag lookup skipped while seeding an app, so a
+// flush can still move the app to its latest tag
+func (s *VersionService) deferSeed(ctx context.Context, appID string, seeded *models.AppVersion) {
+	if !s.airGapped() || s.tags == nil {
+		return
+	}
+
+	call := models.DeferredCall{
+		Kind:          models.DeferredGitLabSeed,
+		AppID:         appID,
+		ProjectID:     seeded.ProjectID,
+		SeededVersion: seeded.Current,
+		QueuedAt:      time.Now(),
+	}
+	if err := s.airGapOpts.Outbox.Append(context.WithoutCancel(ctx), []models.DeferredCall{call}); err != nil {
+		s.logger.WithError(err).WithField("app_id", appID).Error("Failed to defer GitLab seeding")
+	}
+}
+
+// ListDeferredCalls returns the calls waiting in the outbox without their
+// secrets and payloads
+func (s *VersionService) ListDeferredCalls(ctx context.Context) (*models.OutboxResponse, error) {
+	if !s.airGapped() {
+		return nil, ErrAirGapDisabled
+	}
+
+	calls, err := s.airGapOpts.Outbox.Entries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.OutboxResponse{
+		AirGapped: true,
+		Pending:   len(calls),
+		Calls:     make([]models.DeferredCall, 0, len(calls)),
+	}
+	for _, call := range calls {
+		response.Calls = append(response.Calls, call.Redacted())
+	}
+	return response, nil
+}
+
+// FlushDeferredCalls makes every call in the outbox, oldest first. Calls
+// that fail stay queued with their error; the rest are removed. Flushes run
+// one at a time.
+func (s *VersionService) FlushDeferredCalls(ctx context.Context) (*models.FlushReport, error) {
+	if !s.airGapped() {
+		return nil, ErrAirGapDisabled
+	}
+
+	s.airGap.flushMu.Lock()
+	defer s.airGap.flushMu.Unlock()
+
+	report := &models.FlushReport{StartedAt: time.Now()}
+
+	calls, err := s.airGapOpts.Outbox.Entries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = clients.WithOutbound(ctx)
+	var removed []string
+	var failed []models.DeferredCall
+	for _, call := range calls {
+		if ctx.Err() != nil {
+			// Leave the rest queued for the next flush
+			break
+		}
+
+		sent, err := s.makeDeferredCall(ctx, call)
+		switch {
+		case err != nil:
+			call.Attempts++
+			call.LastError = err.Error()
+			failed = append(failed, call)
+			report.Failed++
+			report.Errors = append(report.Errors, fmt.Sprintf("%s %s: %v", call.Kind, call.ID, err))
+			middleware.RecordOutboxFlush(call.Kind, "failed")
+		case sent:
+			removed = append(removed, call.ID)
+			report.Sent++
+			middleware.RecordOutboxFlush(call.Kind, "sent")
+		default:
+			removed = append(removed, call.ID)
+			report.Skipped++
+			middleware.RecordOutboxFlush(call.Kind, "skipped")
+		}
+	}
+
+	if err := s.airGapOpts.Outbox.Update(context.WithoutCancel(ctx), removed, failed); err != nil {
+		return nil, fmt.Errorf("failed to update outbox after flush: %w", err)
+	}
+
+	remaining, err := s.airGapOpts.Outbox.Entries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report.Remaining = len(remaining)
+	report.FinishedAt = time.Now()
+
+	s.logger.WithFields(logrus.Fields{
+		"sent":      report.Sent,
+		"skipped":   report.Skipped,
+		"failed":    report.Failed,
+		"remaining": report.Remaining,
+	}).Info("Outbox flushed")
+
+	return report, nil
+}
+
+// makeDeferredCall makes one deferred call. It reports false without error
+// for calls dropped without being made.
+func (s *VersionService) makeDeferredCall(ctx context.Context, call models.DeferredCall) (bool, error) {
+	switch call.Kind {
+	case models.DeferredWebhook:
+		ctx, cancel := context.WithTimeout(ctx, s.hookTimeout())
+		defer cancel()
+
+		client := clients.NewSignedWebhookClient(call.URL, call.Secret, s.logger)
+		if err := client.Post(ctx, json.RawMessage(call.Payload), nil); err != nil {
+			// The error can echo the URL, which may embed credentials
+			s.logger.WithError(err).WithField("host", hookName(client)).Warn("Deferred webhook failed")
+			return false, fmt.Errorf("webhook to %s failed", hookName(client))
+		}
+		return true, nil
+	case models.DeferredGitLabSeed:
+		return s.applyDeferredSeed(ctx, call)
+	default:
+		s.logger.WithField("kind", call.Kind).Warn("Dropping deferred call of unknown kind")
+		return false, nil
+	}
+}
+
+// applyDeferredSeed moves an app created while air-gapped to its latest
+// tag, as seeding would have. Apps that have moved on since are left
+// alone.
+func (s *VersionService) applyDeferredSeed(ctx context.Context, call models.DeferredCall) (bool, error) {
+	if s.tags == nil {
+		return false, nil
+	}
+
+	tag, err := s.tags.GetLatestTag(ctx, call.ProjectID)
+	if err != nil {
+		return false, err
+	}
+	if tag == "" {
+		return false, nil
+	}
+	version, _, err := s.normalizeVersion(tag)
+	if err != nil {
+		s.logger.WithError(err).WithFields(logrus.Fields{
+			"app_id": call.AppID,
+			"tag":    tag,
+		}).Warn("GitLab tag is not a usable version, keeping seeded version")
+		return false, nil
+	}
+	if version == call.SeededVersion {
+		return false, nil
+	}
+
+	return onApp(ctx, s, call.AppID, func() (bool, error) {
+		current, err := s.lookupVersion(ctx, call.AppID)
+		if err != nil {
+			// Deleted or renamed since
+			return false, nil
+		}
+		if current.Current != call.SeededVersion {
+			s.logger.WithFields(logrus.Fields{
+				"app_id":  call.AppID,
+				"current": current.Current,
+				"tag":     version,
+			}).Info("App moved on since it was seeded, ignoring its GitLab tag")
+			return false, nil
+		}
+
+		updated := *current
+		updated.Current = version
+		s.applyProjectMetadata(ctx, &updated)
+		updated.LastUpdated = time.Now()
+		if err := s.saveVersion(ctx, call.AppID, &updated); err != nil {
+			return false, err
+		}
+
+		s.logger.WithFields(logrus.Fields{
+			"app_id":  call.AppID,
+			"seeded":  call.SeededVersion,
+			"version": version,
+		}).Info("Applied deferred GitLab seed")
+		return true, nil
+	})
+}
+
+// airGapHealth reports the outbox backlog
+func (s *VersionService) airGapHealth(ctx context.Context) string {
+	if err := s.airGapOpts.Outbox.Health(ctx); err != nil {
+		return fmt.Sprintf("unhealthy: %v", err)
+	}
+
+	calls, err := s.airGapOpts.Outbox.Entries(ctx)
+	if err != nil {
+		return fmt.Sprintf("unhealthy: %v", err)
+	}
+	if len(calls) == 0 {
+		return "air-gapped: outbox empty"
+	}
+	return fmt.Sprintf("air-gapped: %d calls deferred since %s", len(calls), calls[0].QueuedAt.Format(time.RFC3339))
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/company/version-service/internal/clients"
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAirGappedMode(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	ctx := context.Background()
+
+	var gitLabCalls atomic.Int32
+	gitLab := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gitLabCalls.Add(1)
+		if r.URL.Path != "/projects/1/repository/tags" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode([]clients.GitLabTag{{Name: "v2.4.0"}})
+	}))
+	defer gitLab.Close()
+
+	var delivered atomic.Value
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		delivered.Store(r.Header.Get(clients.SignatureHeader))
+		assert.Equal(t, clients.Sign("s3cret", body), r.Header.Get(clients.SignatureHeader))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer hook.Close()
+
+	outbox, err := storage.NewFileOutbox(filepath.Join(t.TempDir(), "outbox.jsonl"), logger)
+	require.NoError(t, err)
+
+	gitLabClient := clients.NewGitLabClient(gitLab.URL, "token", logger)
+	gitLabClient.AirGapped = true
+
+	cache, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	durable, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	s := NewVersionService(cache, durable, gitLabClient, logger, Options{AirGap: AirGapOptions{Outbox: outbox}})
+
+	// New apps are seeded without asking GitLab
+	version, err := s.GetVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", version.Current)
+	assert.Zero(t, gitLabCalls.Load())
+
+	notifier := clients.NewSignedWebhookClient(hook.URL, "s3cret", logger).DeferTo(outbox)
+	require.NoError(t, notifier.Notify(ctx, map[string]string{"event": "test"}))
+	assert.Nil(t, delivered.Load())
+
+	listed, err := s.ListDeferredCalls(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, listed.Pending)
+	assert.Equal(t, models.DeferredGitLabSeed, listed.Calls[0].Kind)
+	assert.Empty(t, listed.Calls[1].Secret)
+	assert.Empty(t, listed.Calls[1].Payload)
+
+	report, err := s.FlushDeferredCalls(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Sent)
+	assert.Zero(t, report.Remaining)
+	assert.NotNil(t, delivered.Load())
+
+	version, err = s.GetVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Equal(t, "2.4.0", version.Current)
+}
+
+func TestAirGappedMode_Disabled(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	cache, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	durable, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	s := NewVersionService(cache, durable, nil, logger, Options{})
+
+	_, err = s.ListDeferredCalls(context.Background())
+	assert.ErrorIs(t, err, ErrAirGapDisabled)
+	_, err = s.FlushDeferredCalls(context.Background())
+	assert.ErrorIs(t, err, ErrAirGapDisabled)
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// aliasNamePattern restricts alias names to short lowercase identifiers
+// such as stable, latest or lts-2024
+var aliasNamePattern = regexp.MustCompile(`^[a-z][a-z0-9._-]{0,62}$`)
+
+// SetVersionAlias points a named alias of an existing app at one of its
+// versions. The version may not be ahead of the app's current version.
+func (s *VersionService) SetVersionAlias(ctx context.Context, appID, alias, version string) (*models.VersionAlias, error) {
+	return onApp(ctx, s, appID, func() (*models.VersionAlias, error) {
+		if _, err := s.parseAppID(appID); err != nil {
+			return nil, err
+		}
+
+		if !aliasNamePattern.MatchString(alias) {
+			return nil, fmt.Errorf("%w: name %q must be lowercase letters, digits, '.', '_' or '-'", ErrInvalidAlias, alias)
+		}
+
+		current, err := s.lookupVersion(ctx, appID)
+		if err != nil {
+			return nil, err
+		}
+
+		scheme := s.schemeOf(current.Policy)
+		target, _, err := scheme.canonical(version)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidAlias, err)
+		}
+
+		cmp, err := scheme.compare(target, current.Current)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compare versions: %w", err)
+		}
+		if cmp > 0 {
+			return nil, fmt.Errorf("%w: %s is ahead of current version %s", ErrInvalidAlias, target, current.Current)
+		}
+
+		result := &models.VersionAlias{AppID: appID, Alias: alias, Version: target}
+		if current.Aliases[alias] == target {
+			return result, nil
+		}
+
+		updated := *current
+		updated.Aliases = make(map[string]string, len(current.Aliases)+1)
+		for name, aliased := range current.Aliases {
+			updated.Aliases[name] = aliased
+		}
+		updated.Aliases[alias] = target
+		updated.LastUpdated = time.Now()
+
+		if err := s.saveVersion(ctx, appID, &updated); err != nil {
+			return nil, err
+		}
+
+		s.logger.WithFields(logrus.Fields{
+			"app_id":  appID,
+			"alias":   alias,
+			"version": target,
+		}).Info("Version alias set")
+
+		return result, nil
+	})
+}
+
+// GetVersionAlias resolves a named alias of an app to its version
+func (s *VersionService) GetVersionAlias(ctx context.Context, appID, alias string) (*models.VersionAlias, error) {
+	if _, err := s.parseAppID(appID); err != nil {
+		return nil, err
+	}
+
+	current, err := s.lookupVersion(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+
+	version, ok := current.Aliases[alias]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s has no alias %q", ErrAliasNotFound, appID, alias)
+	}
+
+	return &models.VersionAlias{AppID: appID, Alias: alias, Version: version}, nil
+}
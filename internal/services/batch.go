@@ -0,0 +1,274 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// MaxBatchSize bounds the number of apps in one batch increment
+const MaxBatchSize = 100
+
+// IncrementVersions bumps several apps at once. Every app is validated before
+// anything is written, so a bad or locked app fails the whole batch. Git
+// receives a single commit when the backend supports batch writes. A batch
+// losing a race with another writer is computed again from the winners'
+// versions, running pre-increment hooks again.
+func (s *VersionService) IncrementVersions(ctx context.Context, appIDs []string, incrementType models.IncrementType) (*models.BatchIncrementResponse, error) {
+	if len(appIDs) == 0 {
+		return nil, fmt.Errorf("%w: no app IDs given", ErrInvalidBatch)
+	}
+	if len(appIDs) > MaxBatchSize {
+		return nil, fmt.Errorf("%w: at most %d apps per batch", ErrInvalidBatch, MaxBatchSize)
+	}
+
+	seen := make(map[string]bool)
+	for _, appID := range appIDs {
+		if seen[appID] {
+			return nil, fmt.Errorf("%w: duplicate app ID %s", ErrInvalidBatch, appID)
+		}
+		seen[appID] = true
+	}
+
+	for attempt := 1; ; attempt++ {
+		response, err := s.incrementBatchOnce(ctx, appIDs, incrementType)
+		if !errors.Is(err, storage.ErrRevisionMismatch) {
+			return response, err
+		}
+		if attempt == maxIncrementAttempts {
+			return nil, fmt.Errorf("%w: apps kept changing during the batch increment", ErrRevisionConflict)
+		}
+		s.logger.WithError(err).WithField("count", len(appIDs)).Info("Apps changed during the batch increment, computing it again")
+	}
+}
+
+// batchIncrement is one app's part of a batch increment
+type batchIncrement struct {
+	current *models.AppVersion
+	next    *models.AppVersion
+	appType models.IncrementType
+}
+
+// incrementBatchOnce computes the batch and saves it. Pre-increment hooks
+// run in between without s.mu, so a slow hook doesn't stall every other
+// request; an app changed meanwhile fails the batch with
+// storage.ErrRevisionMismatch.
+func (s *VersionService) incrementBatchOnce(ctx context.Context, appIDs []string, incrementType models.IncrementType) (*models.BatchIncrementResponse, error) {
+	planned, err := s.planBatch(ctx, appIDs, incrementType)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, appID := range appIDs {
+		increment := planned[appID]
+		if err := s.runPreIncrementHooks(ctx, appID, increment.current, increment.appType, increment.next.Current); err != nil {
+			return nil, fmt.Errorf("%s: %w", appID, err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkBatchQuota(ctx, planned); err != nil {
+		return nil, err
+	}
+
+	updated := make(map[string]*models.AppVersion, len(planned))
+	for appID, increment := range planned {
+		current, err := s.getVersion(ctx, appID)
+		if err != nil {
+			return nil, err
+		}
+		if current.Current != increment.current.Current || !current.LastUpdated.Equal(increment.current.LastUpdated) {
+			return nil, fmt.Errorf("%w: %s is at %s", storage.ErrRevisionMismatch, appID, current.Current)
+		}
+		updated[appID] = increment.next
+	}
+
+	expected := make(map[string]*models.AppVersion, len(planned))
+	for appID, increment := range planned {
+		expected[appID] = increment.current
+	}
+	if err := s.saveIncrements(ctx, expected, updated); err != nil {
+		return nil, err
+	}
+
+	response := &models.BatchIncrementResponse{
+		Versions: make(map[string]string, len(updated)),
+	}
+	for appID, version := range updated {
+		s.recordIncrement(ctx, version.ProjectID, appID)
+		s.firePostIncrementHooks(appID, planned[appID].current, planned[appID].appType, version.Current)
+		response.Versions[appID] = version.Current
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"count": len(updated),
+		"type":  incrementType,
+	}).Info("Versions incremented in batch")
+
+	return response, nil
+}
+
+// planBatch validates every app of a batch and computes its next version.
+// It holds s.mu exclusively, as apps used for the first time are created.
+func (s *VersionService) planBatch(ctx context.Context, appIDs []string, incrementType models.IncrementType) (map[string]*batchIncrement, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	planned := make(map[string]*batchIncrement, len(appIDs))
+	for _, appID := range appIDs {
+		if _, err := s.identify(ctx, appID); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, appID := range appIDs {
+		current, err := s.getVersion(ctx, appID)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := checkWritable(appID, current); err != nil {
+			return nil, err
+		}
+
+		appType := effectiveIncrement(current.Policy, current.Current, incrementType)
+		if !current.Policy.AllowsIncrement(appType) {
+			return nil, fmt.Errorf("%w: %s increments are not allowed for %s", ErrIncrementNotAllowed, appType, appID)
+		}
+
+		newVersion, err := s.calculateNextVersion(current.Policy, current.Current, incrementType)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", appID, err)
+		}
+
+		if err := s.checkNotReserved(ctx, current.ProjectID, current.Policy, newVersion); err != nil {
+			return nil, fmt.Errorf("%s: %w", appID, err)
+		}
+
+		next := *current
+		next.Current = newVersion
+		next.LastUpdated = time.Now()
+		// Normalizations of a just-seeded app are not stored
+		next.Normalized = nil
+		s.applyProjectMetadata(ctx, &next)
+		planned[appID] = &batchIncrement{current: current, next: &next, appType: appType}
+	}
+
+	if err := s.checkBatchQuota(ctx, planned); err != nil {
+		return nil, err
+	}
+	return planned, nil
+}
+
+// checkBatchQuota checks that every app of a batch fits in its project's
+// hourly increment quota
+func (s *VersionService) checkBatchQuota(ctx context.Context, planned map[string]*batchIncrement) error {
+	projects := make(map[string]int64)
+	for _, increment := range planned {
+		projects[increment.current.ProjectID]++
+	}
+	for projectID, n := range projects {
+		if err := s.checkIncrementQuota(ctx, projectID, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveVersions caches every version in Redis, then persists them to Git in
+// one commit when supported, otherwise one commit per app. Write-through
+// reverses the order. Callers must hold s.mu exclusively.
+func (s *VersionService) saveVersions(ctx context.Context, versions map[string]*models.AppVersion) error {
+	return s.saveIncrements(ctx, nil, versions)
+}
+
+// saveIncrements is saveVersions for versions computed from the records in
+// expected. The batch is cached in Redis entirely or not at all, and fails
+// with storage.ErrRevisionMismatch when another replica changed one of its
+// apps since expected was read, so the batch can be computed again rather
+// than issue a version twice. Under write-through the versions are already
+// committed by then, so they are cached as is instead. A nil expected caches
+// them unconditionally.
+func (s *VersionService) saveIncrements(ctx context.Context, expected, versions map[string]*models.AppVersion) error {
+	batch, ok := s.git.(storage.BatchWriter)
+
+	if s.writingThrough() {
+		if !ok {
+			for appID, version := range versions {
+				one := map[string]*models.AppVersion{appID: version}
+				if err := s.writeThroughVersion(ctx, appID, version, func() error {
+					return s.cacheVersions(ctx, expected, one)
+				}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		return s.persistThenCache(ctx, versions, logrus.Fields{
+			"count": len(versions),
+		}, func(ctx context.Context) error {
+			return batch.SetVersions(ctx, versions)
+		}, func() error {
+			return s.cacheVersions(ctx, expected, versions)
+		})
+	}
+
+	appIDs := make([]string, 0, len(versions))
+	for appID := range versions {
+		appIDs = append(appIDs, appID)
+	}
+
+	// One broadcast covers the whole batch, including apps cached before a
+	// failure
+	err := s.cacheVersions(ctx, expected, versions)
+	s.changed(ctx, appIDs...)
+	if err != nil {
+		return fmt.Errorf("failed to save version to Redis: %w", err)
+	}
+
+	if !ok {
+		for appID, version := range versions {
+			s.persistVersion(ctx, appID, version)
+		}
+		return nil
+	}
+
+	writtenAt := s.freshness.written(appIDs)
+	deferred(ctx)
+	s.persistence.pushAll(appIDs, func() {
+		if s.journalWrites(ctx, journalEntries(versions), writtenAt) {
+			return
+		}
+		s.persistToGitWithRetry(ctx, appIDs, writtenAt, logrus.Fields{
+			"app_ids": strings.Join(appIDs, ","),
+			"count":   len(versions),
+		}, func(ctx context.Context) error {
+			return batch.SetVersions(ctx, versions)
+		})
+	})
+
+	return nil
+}
+
+// cacheVersions writes versions to Redis, only over the records in expected
+// unless it is nil
+func (s *VersionService) cacheVersions(ctx context.Context, expected, versions map[string]*models.AppVersion) error {
+	if expected != nil {
+		return s.cacheSetAllIf(ctx, expected, versions)
+	}
+	for appID, version := range versions {
+		if err := s.cacheSet(ctx, appID, version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
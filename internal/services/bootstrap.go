@@ -0,0 +1,277 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// ParseBootstrapSeed parses a bootstrap seed file. format is "csv" or "json".
+//
+// CSV rows are app_id,version[,repo_name]; a leading header row and lines
+// starting with # are skipped. JSON is either a flat {"app-id": "version"}
+// object or an existing versions file ({"versions": {...}}).
+func ParseBootstrapSeed(data []byte, format string) ([]models.BootstrapEntry, error) {
+	switch strings.ToLower(format) {
+	case "csv":
+		return parseCSVSeed(data)
+	case "json":
+		return parseJSONSeed(data)
+	default:
+		return nil, fmt.Errorf("unsupported seed format %q (use csv or json)", format)
+	}
+}
+
+func parseCSVSeed(data []byte) ([]models.BootstrapEntry, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var entries []models.BootstrapEntry
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV seed: %w", err)
+		}
+
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "app_id") {
+			continue
+		}
+		if len(record) < 2 || len(record) > 3 {
+			return nil, fmt.Errorf("CSV seed row %d: expected app_id,version[,repo_name]", line)
+		}
+
+		entry := models.BootstrapEntry{
+			AppID:   strings.TrimSpace(record[0]),
+			Version: strings.TrimSpace(record[1]),
+		}
+		if len(record) == 3 {
+			entry.RepoName = strings.TrimSpace(record[2])
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func parseJSONSeed(data []byte) ([]models.BootstrapEntry, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid JSON seed: %w", err)
+	}
+
+	var entries []models.BootstrapEntry
+	if _, ok := raw["versions"]; ok {
+		var vf models.VersionsFile
+		if err := json.Unmarshal(data, &vf); err != nil {
+			return nil, fmt.Errorf("invalid versions file seed: %w", err)
+		}
+		for appID, version := range vf.Versions {
+			if version == nil {
+				return nil, fmt.Errorf("JSON seed: %s has no version", appID)
+			}
+			entries = append(entries, models.BootstrapEntry{
+				AppID:     appID,
+				Version:   version.Current,
+				ProjectID: version.ProjectID,
+				AppName:   version.AppName,
+				RepoName:  version.RepoName,
+				Record:    version,
+			})
+		}
+	} else {
+		for appID, value := range raw {
+			var version string
+			if err := json.Unmarshal(value, &version); err != nil {
+				return nil, fmt.Errorf("JSON seed: version of %s must be a string", appID)
+			}
+			entries = append(entries, models.BootstrapEntry{AppID: appID, Version: version})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].AppID < entries[j].AppID })
+	return entries, nil
+}
+
+// Bootstrap seeds an empty deployment: it builds the initial versions file
+// from seed entries and the projects of the given GitLab groups, writes it to
+// the versions branch in one commit (creating the branch), and warms Redis.
+// Seed entries take precedence over discovered projects. Any invalid seed
+// entry aborts the run before anything is written.
+func (s *VersionService) Bootstrap(ctx context.Context, seed []models.BootstrapEntry, groups []string) (*models.BootstrapReport, error) {
+	bootstrapper, ok := s.git.(storage.Bootstrapper)
+	if !ok {
+		return nil, fmt.Errorf("git storage does not support bootstrapping")
+	}
+	if !bootstrapper.IsEmpty() {
+		return nil, ErrAlreadyBootstrapped
+	}
+
+	report := &models.BootstrapReport{
+		Apps:      []models.BootstrapEntry{},
+		StartedAt: time.Now(),
+	}
+
+	versions := make(map[string]*models.AppVersion)
+	for _, entry := range seed {
+		version, err := s.seedEntryVersion(&entry)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := versions[entry.AppID]; exists {
+			return nil, fmt.Errorf("%w: %s is listed more than once", ErrInvalidSeed, entry.AppID)
+		}
+		versions[entry.AppID] = version
+		entry.Source = models.BootstrapSourceFile
+		report.Apps = append(report.Apps, entry)
+	}
+
+	if len(groups) > 0 {
+		if s.gitLabClient == nil {
+			return nil, fmt.Errorf("GitLab groups given but no GitLab client configured")
+		}
+		report.Warnings = append(report.Warnings, s.bootstrapGroups(ctx, groups, versions, report)...)
+	}
+
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%w: nothing to bootstrap", ErrInvalidSeed)
+	}
+
+	vf := &models.VersionsFile{Versions: versions}
+	revision, err := bootstrapper.Bootstrap(ctx, vf, fmt.Sprintf("Bootstrap versions: %d apps", len(versions)))
+	if err != nil {
+		if errors.Is(err, storage.ErrAlreadyBootstrapped) {
+			return nil, fmt.Errorf("%w: %v", ErrAlreadyBootstrapped, err)
+		}
+		if revision == "" {
+			return nil, fmt.Errorf("failed to write versions file: %w", err)
+		}
+		// Committed locally but not pushed; the branch does not exist remotely
+		return nil, fmt.Errorf("failed to push bootstrap commit %s: %w", revision, err)
+	}
+	report.Revision = revision
+	report.Pushed = true
+
+	if err := s.redis.RebuildCache(ctx, versions); err != nil {
+		s.logger.WithError(err).Warn("Failed to warm Redis cache")
+		report.Warnings = append(report.Warnings, fmt.Sprintf("redis: %v", err))
+	} else {
+		report.CacheWarmed = true
+	}
+	s.changedAll(ctx)
+
+	sort.Slice(report.Apps, func(i, j int) bool { return report.Apps[i].AppID < report.Apps[j].AppID })
+	report.Count = len(report.Apps)
+	report.CompletedAt = time.Now()
+
+	s.logger.WithFields(logrus.Fields{
+		"revision":     report.Revision,
+		"count":        report.Count,
+		"cache_warmed": report.CacheWarmed,
+	}).Info("Bootstrap completed")
+
+	return report, nil
+}
+
+// seedEntryVersion validates and normalizes a seed entry into the version to
+// store, recording applied rewrites on the entry
+func (s *VersionService) seedEntryVersion(entry *models.BootstrapEntry) (*models.AppVersion, error) {
+	id, err := s.parseAppID(entry.AppID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSeed, err)
+	}
+	if id.Opaque() {
+		// Opaque IDs can only be seeded from a versions file, which carries
+		// the project and app name
+		if entry.ProjectID == "" || entry.AppName == "" {
+			return nil, fmt.Errorf("%w: %s: project_id and app_name are required for %s app IDs", ErrInvalidSeed, entry.AppID, s.idScheme.Name())
+		}
+		id.ProjectID, id.AppName = entry.ProjectID, entry.AppName
+	}
+
+	version, applied, err := s.normalizeVersion(entry.Version)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidSeed, entry.AppID, err)
+	}
+	entry.Version = version
+	entry.Normalized = applied
+
+	var record models.AppVersion
+	if entry.Record != nil {
+		record = *entry.Record
+	}
+	record.Current = version
+	record.ProjectID, record.AppName = id.ProjectID, id.AppName
+	record.RepoName = entry.RepoName
+	record.LastUpdated = time.Now()
+	record.Normalized = nil
+	return &record, nil
+}
+
+// bootstrapGroups adds an app for every project in the groups whose project
+// has no seeded app, named and seeded like discovery registrations
+func (s *VersionService) bootstrapGroups(ctx context.Context, groups []string, versions map[string]*models.AppVersion, report *models.BootstrapReport) []string {
+	var warnings []string
+
+	seededProjects := make(map[string]bool)
+	for _, version := range versions {
+		seededProjects[version.ProjectID] = true
+	}
+
+	for _, group := range groups {
+		projects, err := s.gitLabClient.ListGroupProjects(ctx, group)
+		if err != nil {
+			s.logger.WithError(err).WithField("group", group).Warn("Failed to list GitLab group projects")
+			warnings = append(warnings, fmt.Sprintf("group %s: %v", group, err))
+			continue
+		}
+
+		for _, project := range projects {
+			id, err := s.gitLabAppIdentifier(project)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("project %d: %v", project.ID, err))
+				continue
+			}
+			appID, projectID, appName := id.ID, id.ProjectID, id.AppName
+			if seededProjects[projectID] {
+				continue
+			}
+			seededProjects[projectID] = true
+
+			s.rememberProject(projectID, project)
+
+			version, applied, err := s.seedVersion(ctx, appID, projectID, appName)
+			if err != nil {
+				// Seeded lazily from its tags on first use instead
+				s.logger.WithError(err).WithField("app_id", appID).Warn("Skipping app, GitLab tags unavailable")
+				warnings = append(warnings, fmt.Sprintf("%s: %v", appID, err))
+				continue
+			}
+			versions[appID] = version
+			report.Apps = append(report.Apps, models.BootstrapEntry{
+				AppID:      appID,
+				Version:    version.Current,
+				RepoName:   version.RepoName,
+				Source:     models.BootstrapSourceGitLab,
+				Normalized: applied,
+			})
+		}
+	}
+
+	return warnings
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBootstrapService(t *testing.T) (*VersionService, *storage.MemoryStorage) {
+	t.Helper()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	cache, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	durable, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	return NewVersionService(cache, durable, nil, logger, Options{}), durable
+}
+
+func TestBootstrap_VersionsFileKeepsRecords(t *testing.T) {
+	s, durable := newBootstrapService(t)
+	ctx := context.Background()
+
+	seed, err := ParseBootstrapSeed([]byte(`{"versions": {"1-api": {
+		"current": "2.3.0",
+		"project_id": "1",
+		"app_name": "api",
+		"repo_name": "api-repo",
+		"locked": true,
+		"lifecycle": "frozen",
+		"aliases": {"stable": "2.2.0"},
+		"annotations": {"team": "payments"},
+		"policy": {"strategy": "minor"},
+		"renamed_from": ["1-legacy"]
+	}}}`), "json")
+	require.NoError(t, err)
+
+	_, err = s.Bootstrap(ctx, seed, nil)
+	require.NoError(t, err)
+
+	stored, err := durable.GetVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Equal(t, "2.3.0", stored.Current)
+	assert.True(t, stored.Locked)
+	assert.Equal(t, models.LifecycleFrozen, stored.Lifecycle)
+	assert.Equal(t, map[string]string{"stable": "2.2.0"}, stored.Aliases)
+	assert.Equal(t, map[string]string{"team": "payments"}, stored.Annotations)
+	assert.Equal(t, &models.AppPolicy{Strategy: "minor"}, stored.Policy)
+	assert.Equal(t, []string{"1-legacy"}, stored.RenamedFrom)
+	assert.Equal(t, "api-repo", stored.RepoName)
+	assert.Empty(t, stored.Normalized)
+}
+
+func TestBootstrap_RefusesNonEmptyRepository(t *testing.T) {
+	s, durable := newBootstrapService(t)
+	ctx := context.Background()
+
+	existing := &models.AppVersion{Current: "3.0.0", ProjectID: "1", AppName: "api"}
+	require.NoError(t, durable.SetVersion(ctx, "1-api", existing))
+
+	_, err := s.Bootstrap(ctx, []models.BootstrapEntry{{AppID: "2-web", Version: "1.0.0"}}, nil)
+	assert.ErrorIs(t, err, ErrAlreadyBootstrapped)
+
+	// Nothing was written
+	stored, err := durable.GetVersion(ctx, "2-web")
+	require.NoError(t, err)
+	assert.Nil(t, stored)
+	stored, err = durable.GetVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Equal(t, "3.0.0", stored.Current)
+}
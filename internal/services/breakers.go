@@ -0,0 +1,36 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/company/version-service/internal/storage"
+	"github.com/company/version-service/pkg/breaker"
+)
+
+// recordCache reports the outcome of a cache call to the Redis breaker. A
+// lost conditional write or a caller giving up says nothing about Redis.
+func (s *VersionService) recordCache(err error) {
+	switch {
+	case err == nil, errors.Is(err, storage.ErrRevisionMismatch):
+		s.redisBreaker.Success()
+	case errors.Is(err, context.Canceled):
+	default:
+		s.redisBreaker.Failure()
+	}
+}
+
+// breakerHealth describes a circuit breaker for /health
+func breakerHealth(snapshot breaker.Snapshot) string {
+	switch snapshot.State {
+	case breaker.Open:
+		return fmt.Sprintf("degraded: open since %s after %d consecutive failures",
+			snapshot.OpenedAt.Format(time.RFC3339), snapshot.Failures)
+	case breaker.HalfOpen:
+		return "degraded: half-open, probing"
+	default:
+		return "closed"
+	}
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+	"github.com/company/version-service/pkg/breaker"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// downCache is a cache failing every version read and write, counting them
+type downCache struct {
+	*storage.MemoryStorage
+	calls atomic.Int64
+}
+
+func (d *downCache) GetVersion(ctx context.Context, appID string) (*models.AppVersion, error) {
+	d.calls.Add(1)
+	return nil, errors.New("connection refused")
+}
+
+func (d *downCache) SetVersion(ctx context.Context, appID string, version *models.AppVersion) error {
+	d.calls.Add(1)
+	return errors.New("connection refused")
+}
+
+func TestGetVersion_RedisBreaker(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	ctx := context.Background()
+
+	memory, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	cache := &downCache{MemoryStorage: memory}
+	durable, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	require.NoError(t, durable.SetVersion(ctx, "1-api", &models.AppVersion{Current: "1.2.3", ProjectID: "1", AppName: "api"}))
+
+	redisBreaker := breaker.New("redis", 3, time.Minute)
+	s := NewVersionService(cache, durable, nil, logger, Options{
+		RedisBreaker: redisBreaker,
+		Breakers:     []*breaker.Breaker{redisBreaker},
+	})
+
+	for i := 0; i < 3; i++ {
+		version, err := s.GetVersion(ctx, "1-api")
+		require.NoError(t, err)
+		assert.Equal(t, "1.2.3", version.Current)
+	}
+	assert.Equal(t, breaker.Open, redisBreaker.State())
+
+	calls := cache.calls.Load()
+	version, err := s.GetVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.3", version.Current, "reads fall back to durable storage")
+	assert.Equal(t, calls, cache.calls.Load(), "an open breaker doesn't call Redis")
+
+	checks := s.Health(ctx)
+	assert.Contains(t, checks["circuit_redis"], "degraded: open since")
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// subscribeRetryDelay is how long a failed change subscription waits before
+// subscribing again
+const subscribeRetryDelay = 5 * time.Second
+
+// newInstanceID returns a random ID telling this replica's broadcasts apart
+// from those of other replicas
+func newInstanceID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// OnRemoteChange registers fn to be called with every change another replica
+// broadcasts, after the dev version cache has dropped the apps changed. Used
+// to purge the response cache.
+func (s *VersionService) OnRemoteChange(fn func(models.VersionChange)) {
+	s.changeListenersMu.Lock()
+	defer s.changeListenersMu.Unlock()
+	s.changeListeners = append(s.changeListeners, fn)
+}
+
+// changed drops the apps from the dev version cache and tells the other
+// replicas to do the same
+func (s *VersionService) changed(ctx context.Context, appIDs ...string) {
+	s.devCache.invalidate(appIDs...)
+	s.broadcastChange(ctx, models.VersionChange{AppIDs: appIDs})
+}
+
+// changedAll empties the dev version cache, here and on the other replicas,
+// after writes replacing every app
+func (s *VersionService) changedAll(ctx context.Context) {
+	s.devCache.invalidateAll()
+	s.broadcastChange(ctx, models.VersionChange{All: true})
+}
+
+// broadcastChange publishes change when the cache can broadcast. Failures
+// are only logged: the other replicas then notice the write once their
+// cache entries expire.
+func (s *VersionService) broadcastChange(ctx context.Context, change models.VersionChange) {
+	broadcaster, ok := s.redis.(storage.ChangeBroadcaster)
+	if !ok {
+		return
+	}
+
+	change.Origin = s.instanceID
+	if err := broadcaster.PublishChange(context.WithoutCancel(ctx), change); err != nil {
+		s.logger.WithError(err).WithFields(logrus.Fields{
+			"app_ids": change.AppIDs,
+			"all":     change.All,
+		}).Warn("Failed to broadcast version change")
+	}
+}
+
+// subscribeChanges applies the changes other replicas broadcast until the
+// process exits, subscribing again after failures
+func (s *VersionService) subscribeChanges(broadcaster storage.ChangeBroadcaster) {
+	for {
+		err := broadcaster.SubscribeChanges(context.Background(), s.applyRemoteChange)
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+		s.logger.WithError(err).Warn("Version change subscription failed, subscribing again")
+		time.Sleep(subscribeRetryDelay)
+	}
+}
+
+// applyRemoteChange drops what this replica keeps in memory about the apps
+// another replica wrote. Redis itself is shared, so nothing else needs
+// refreshing.
+func (s *VersionService) applyRemoteChange(change models.VersionChange) {
+	if change.Origin == s.instanceID {
+		return
+	}
+
+	if change.All {
+		s.devCache.invalidateAll()
+	} else {
+		s.devCache.invalidate(change.AppIDs...)
+	}
+
+	s.changeListenersMu.Lock()
+	listeners := append([]func(models.VersionChange){}, s.changeListeners...)
+	s.changeListenersMu.Unlock()
+	for _, fn := range listeners {
+		fn(change)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"origin":  change.Origin,
+		"app_ids": change.AppIDs,
+		"all":     change.All,
+	}).Debug("Versions changed by another replica")
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteChangeInvalidatesReplica(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Two replicas sharing one cache and one durable store
+	cache, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	durable, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	require.NoError(t, durable.SetVersion(ctx, "1-api", &models.AppVersion{Current: "1.0.0", ProjectID: "1", AppName: "api"}))
+
+	writer := NewVersionService(cache, durable, nil, logger, Options{})
+	reader := NewVersionService(cache, durable, nil, logger, Options{DevVersionCacheTTL: time.Hour})
+
+	changes := make(chan models.VersionChange, 10)
+	reader.OnRemoteChange(func(change models.VersionChange) { changes <- change })
+	go cache.SubscribeChanges(ctx, reader.applyRemoteChange)
+	require.Eventually(t, func() bool {
+		require.NoError(t, cache.PublishChange(ctx, models.VersionChange{Origin: "probe"}))
+		select {
+		case <-changes:
+			return true
+		default:
+			return false
+		}
+	}, time.Second, 10*time.Millisecond)
+
+	req := &models.DevVersionRequest{SHA: "abc1234567890", Branch: "main"}
+	dev, err := reader.GetDevVersion(ctx, "1-api", req)
+	require.NoError(t, err)
+	assert.Contains(t, dev.Version, "1.0.0")
+
+	_, err = writer.IncrementVersion(ctx, "1-api", models.IncrementTypeMinor, "")
+	require.NoError(t, err)
+
+	change := <-changes
+	assert.Equal(t, writer.instanceID, change.Origin)
+	assert.Equal(t, []string{"1-api"}, change.AppIDs)
+
+	// The reader's dev version cache no longer holds 1.0.0
+	dev, err = reader.GetDevVersion(ctx, "1-api", req)
+	require.NoError(t, err)
+	assert.Contains(t, dev.Version, "1.1.0")
+
+	// A replica ignores its own broadcasts
+	reader.changed(ctx, "1-api")
+	assert.Empty(t, changes)
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/company/version-service/internal/middleware"
+	"github.com/company/version-service/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// CanaryOptions configures canary read verification: reads are still
+// served from Redis, but the records returned are compared against Git in
+// the background
+type CanaryOptions struct {
+	// Enabled verifies every read; without it only reads asking for it
+	// through WithReadVerification are verified
+	Enabled bool
+}
+
+// canaryMaxInFlight bounds concurrent verifications so a burst of reads
+// cannot pile up Git reads; reads beyond it are skipped
+const canaryMaxInFlight = 4
+
+// canaryTimeout bounds one verification
+const canaryTimeout = 30 * time.Second
+
+// Verification results
+const (
+	canaryMatch    = "match"
+	canaryMismatch = "mismatch"
+	canaryPending  = "pending"
+	canaryError    = "error"
+	canarySkipped  = "skipped"
+)
+
+type readVerificationKey struct{}
+
+// WithReadVerification marks reads made with ctx for canary verification
+func WithReadVerification(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readVerificationKey{}, true)
+}
+
+func (s *VersionService) verifyingReads(ctx context.Context) bool {
+	requested, _ := ctx.Value(readVerificationKey{}).(bool)
+	return s.canary.Enabled || requested
+}
+
+// verifyRead compares an app's record as served against Git in the
+// background
+func (s *VersionService) verifyRead(ctx context.Context, appID string, served *models.AppVersion) {
+	if !s.verifyingReads(ctx) {
+		return
+	}
+	s.startVerification("version", logrus.Fields{"app_id": appID}, func(ctx context.Context) ([]string, bool, error) {
+		stored, err := s.git.GetVersion(ctx, appID)
+		if err != nil {
+			return nil, false, err
+		}
+		return diffRecords(served, stored), s.freshness.hasPending(appID), nil
+	})
+}
+
+// verifyListing compares a listing as served against Git in the
+// background. projectID is empty for the listing of all apps.
+func (s *VersionService) verifyListing(ctx context.Context, projectID string, served map[string]*models.AppVersion) {
+	if !s.verifyingReads(ctx) {
+		return
+	}
+	s.startVerification("listing", logrus.Fields{"project_id": projectID}, func(ctx context.Context) ([]string, bool, error) {
+		var stored map[string]*models.AppVersion
+		var err error
+		if projectID == "" {
+			stored, err = s.git.ListVersions(ctx)
+		} else {
+			stored, err = s.git.ListVersionsByProject(ctx, projectID)
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		stored = withoutTombstones(stored)
+
+		var diffs []string
+		pending := false
+		for _, appID := range unionKeys(served, stored) {
+			appDiffs := diffRecords(served[appID], stored[appID])
+			for _, diff := range appDiffs {
+				diffs = append(diffs, appID+": "+diff)
+			}
+			if len(appDiffs) > 0 && s.freshness.hasPending(appID) {
+				pending = true
+			}
+		}
+		return diffs, pending, nil
+	})
+}
+
+// startVerification runs verify in the background unless too many
+// verifications are running. verify returns the differences found and
+// whether the differing apps have writes not yet pushed to Git, which
+// explain them.
+func (s *VersionService) startVerification(read string, fields logrus.Fields, verify func(context.Context) ([]string, bool, error)) {
+	select {
+	case s.canaryInFlight <- struct{}{}:
+	default:
+		middleware.RecordCanaryVerification(read, canarySkipped)
+		return
+	}
+
+	go func() {
+		defer func() { <-s.canaryInFlight }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), canaryTimeout)
+		defer cancel()
+
+		logger := s.logger.WithFields(fields).WithField("read", read)
+		diffs, pending, err := verify(ctx)
+		switch {
+		case err != nil:
+			middleware.RecordCanaryVerification(read, canaryError)
+			logger.WithError(err).Warn("Canary read verification failed")
+		case len(diffs) == 0:
+			middleware.RecordCanaryVerification(read, canaryMatch)
+		case pending:
+			// Git trails Redis until queued writes are pushed
+			middleware.RecordCanaryVerification(read, canaryPending)
+			logger.WithField("diffs", diffs).Debug("Canary read differs from Git while writes are pending")
+		default:
+			middleware.RecordCanaryVerification(read, canaryMismatch)
+			logger.WithField("diffs", diffs).Warn("Canary read mismatch: Redis differs from Git")
+		}
+	}()
+}
+
+// diffRecords lists the fields in which a record served from Redis differs
+// from the one in Git, as "field: redis=... git=...". Response-only fields
+// are ignored.
+func diffRecords(cached, stored *models.AppVersion) []string {
+	switch {
+	case cached == nil && stored == nil:
+		return nil
+	case cached == nil:
+		return []string{"record: missing from Redis"}
+	case stored == nil:
+		return []string{"record: missing from Git"}
+	}
+
+	cachedFields, err := recordFields(cached)
+	if err != nil {
+		return []string{fmt.Sprintf("record: %v", err)}
+	}
+	storedFields, err := recordFields(stored)
+	if err != nil {
+		return []string{fmt.Sprintf("record: %v", err)}
+	}
+
+	var diffs []string
+	for _, field := range unionKeys(cachedFields, storedFields) {
+		if field == "normalized" || reflect.DeepEqual(cachedFields[field], storedFields[field]) {
+			continue
+		}
+		diffs = append(diffs, fmt.Sprintf("%s: redis=%s git=%s",
+			field, fieldValue(cachedFields, field), fieldValue(storedFields, field)))
+	}
+	return diffs
+}
+
+// recordFields decodes a record into its JSON fields, so records compare
+// the way they are stored
+func recordFields(version *models.AppVersion) (map[string]any, error) {
+	data, err := json.Marshal(version)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func fieldValue(fields map[string]any, field string) string {
+	value, ok := fields[field]
+	if !ok {
+		return "<unset>"
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// unionKeys returns the keys of both maps, sorted
+func unionKeys[V any](a, b map[string]V) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// canaryVerifications reads canary_read_verifications_total for a read and
+// result
+func canaryVerifications(t *testing.T, read, result string) float64 {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != "canary_read_verifications_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["read"] == read && labels["result"] == result {
+				return metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+func TestDiffRecords(t *testing.T) {
+	updated := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	record := &models.AppVersion{Current: "1.2.3", ProjectID: "1", AppName: "api", LastUpdated: updated}
+
+	assert.Empty(t, diffRecords(nil, nil))
+	assert.Equal(t, []string{"record: missing from Redis"}, diffRecords(nil, record))
+	assert.Equal(t, []string{"record: missing from Git"}, diffRecords(record, nil))
+
+	same := *record
+	assert.Empty(t, diffRecords(record, &same))
+
+	stale := *record
+	stale.Current = "1.2.2"
+	assert.Equal(t, []string{`current: redis="1.2.3" git="1.2.2"`}, diffRecords(record, &stale))
+
+	// Normalized versions are only part of responses
+	normalized := *record
+	normalized.Normalized = []string{"v1.2.3 -> 1.2.3"}
+	assert.Empty(t, diffRecords(&normalized, record))
+}
+
+func TestCanary_VerifiesReadsAgainstGit(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	ctx := context.Background()
+
+	cache, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	git, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	updated := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, store := range []*storage.MemoryStorage{cache, git} {
+		require.NoError(t, store.SetVersion(ctx, "1-api", &models.AppVersion{Current: "1.2.3", ProjectID: "1", AppName: "api", LastUpdated: updated}))
+	}
+	// Redis serves a version Git never got
+	require.NoError(t, cache.SetVersion(ctx, "1-web", &models.AppVersion{Current: "2.0.0", ProjectID: "1", AppName: "web", LastUpdated: updated}))
+	require.NoError(t, git.SetVersion(ctx, "1-web", &models.AppVersion{Current: "1.9.0", ProjectID: "1", AppName: "web", LastUpdated: updated}))
+
+	s := NewVersionService(cache, git, nil, logger, Options{Canary: CanaryOptions{Enabled: true}})
+
+	// eventually waits for the background verification of a read made by
+	// do to be counted as result
+	eventually := func(read, result string, do func()) {
+		t.Helper()
+		before := canaryVerifications(t, read, result)
+		do()
+		assert.Eventually(t, func() bool {
+			return canaryVerifications(t, read, result) == before+1
+		}, 5*time.Second, 10*time.Millisecond, "%s verification counted as %s", read, result)
+	}
+
+	eventually("version", canaryMatch, func() {
+		_, err := s.GetVersion(ctx, "1-api")
+		require.NoError(t, err)
+	})
+	eventually("version", canaryMismatch, func() {
+		served, err := s.GetVersion(ctx, "1-web")
+		require.NoError(t, err)
+		assert.Equal(t, "2.0.0", served.Current, "reads are still served from Redis")
+	})
+	eventually("listing", canaryMismatch, func() {
+		_, err := s.ListVersions(ctx)
+		require.NoError(t, err)
+	})
+}
+
+func TestCanary_VerifiesRequestedReads(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	ctx := context.Background()
+
+	memory, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	s := NewVersionService(memory, memory, nil, logger, Options{})
+
+	assert.False(t, s.verifyingReads(ctx))
+	assert.True(t, s.verifyingReads(WithReadVerification(ctx)))
+}
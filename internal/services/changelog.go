@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/company/version-service/internal/clients"
+	"github.com/company/version-service/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// mergeRequestPattern matches the trailer GitLab adds to merge commits
+var mergeRequestPattern = regexp.MustCompile(`See merge request (\S*)!(\d+)`)
+
+// GetChangelog lists the merge requests and commits between the tags of two
+// versions in the app's GitLab project. An empty to selects the app's current
+// version.
+func (s *VersionService) GetChangelog(ctx context.Context, appID, from, to string) (*models.Changelog, error) {
+	id, err := s.parseAppID(appID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.lookupVersion(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+
+	if id, err = identifierFromRecord(id, current); err != nil {
+		return nil, err
+	}
+
+	if to == "" {
+		to = current.Current
+	}
+
+	scheme := s.schemeOf(current.Policy)
+	from, _, err = scheme.canonical(from)
+	if err != nil {
+		return nil, fmt.Errorf("%w: from: %v", ErrInvalidVersion, err)
+	}
+	to, _, err = scheme.canonical(to)
+	if err != nil {
+		return nil, fmt.Errorf("%w: to: %v", ErrInvalidVersion, err)
+	}
+
+	cmp, err := scheme.compare(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidVersion, err)
+	}
+	if cmp >= 0 {
+		return nil, fmt.Errorf("%w: from %s must be lower than to %s", ErrInvalidVersion, from, to)
+	}
+
+	if s.gitLabClient == nil {
+		return nil, fmt.Errorf("%w: GitLab is not configured", ErrChangelogUnavailable)
+	}
+
+	fromTag, err := s.versionTag(ctx, id.ProjectID, from)
+	if err != nil {
+		return nil, err
+	}
+	toTag, err := s.versionTag(ctx, id.ProjectID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	comparison, err := s.gitLabClient.CompareRefs(ctx, id.ProjectID, fromTag, toTag)
+	if errors.Is(err, clients.ErrNoCredentials) || errors.Is(err, clients.ErrAirGapped) {
+		return nil, fmt.Errorf("%w: %v", ErrChangelogUnavailable, err)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to compare %s and %s: %w", fromTag, toTag, err)
+	}
+
+	changelog := buildChangelog(comparison.Commits)
+	changelog.AppID = appID
+	changelog.ProjectID = id.ProjectID
+	changelog.From, changelog.To = from, to
+	changelog.FromTag, changelog.ToTag = fromTag, toTag
+	changelog.CompareURL = comparison.WebURL
+	changelog.GeneratedAt = time.Now()
+
+	s.logger.WithFields(logrus.Fields{
+		"app_id":         appID,
+		"from":           fromTag,
+		"to":             toTag,
+		"merge_requests": len(changelog.MergeRequests),
+		"commits":        len(changelog.Commits),
+	}).Debug("Changelog generated")
+
+	return changelog, nil
+}
+
+// versionTag resolves the GitLab tag of a version
+func (s *VersionService) versionTag(ctx context.Context, projectID, version string) (string, error) {
+	tag, err := s.gitLabClient.FindVersionTag(ctx, projectID, version)
+	if errors.Is(err, clients.ErrNoCredentials) || errors.Is(err, clients.ErrAirGapped) {
+		return "", fmt.Errorf("%w: %v", ErrChangelogUnavailable, err)
+	} else if err != nil {
+		return "", fmt.Errorf("failed to look up tag of %s: %w", version, err)
+	}
+	if tag == "" {
+		return "", fmt.Errorf("%w: %s in project %s", ErrTagNotFound, version, projectID)
+	}
+	return tag, nil
+}
+
+// buildChangelog splits commits, oldest first as GitLab returns them, into
+// merge requests and the remaining commits, both newest first
+func buildChangelog(commits []clients.GitLabCommit) *models.Changelog {
+	changelog := &models.Changelog{
+		MergeRequests: []models.ChangelogMergeRequest{},
+		Commits:       []models.ChangelogCommit{},
+	}
+
+	for i := len(commits) - 1; i >= 0; i-- {
+		commit := commits[i]
+
+		if len(commit.ParentIDs) > 1 {
+			if mr, ok := mergeRequestFromCommit(commit); ok {
+				changelog.MergeRequests = append(changelog.MergeRequests, mr)
+			}
+			continue
+		}
+
+		changelog.Commits = append(changelog.Commits, models.ChangelogCommit{
+			ID:         commit.ID,
+			ShortID:    commit.ShortID,
+			Title:      commit.Title,
+			Author:     commit.AuthorName,
+			AuthoredAt: commit.AuthoredDate,
+			WebURL:     commit.WebURL,
+		})
+	}
+
+	return changelog
+}
+
+// mergeRequestFromCommit reads the merge request of a GitLab merge commit.
+// Its message is "Merge branch ... into ...", the merge request title and the
+// "See merge request group/project!iid" trailer.
+func mergeRequestFromCommit(commit clients.GitLabCommit) (models.ChangelogMergeRequest, bool) {
+	match := mergeRequestPattern.FindStringSubmatch(commit.Message)
+	if match == nil {
+		return models.ChangelogMergeRequest{}, false
+	}
+
+	iid, err := strconv.Atoi(match[2])
+	if err != nil {
+		return models.ChangelogMergeRequest{}, false
+	}
+
+	title := commit.Title
+	lines := strings.Split(commit.Message, "\n")
+	for _, line := range lines[1:] {
+		line = strings.TrimSpace(line)
+		if line != "" && !mergeRequestPattern.MatchString(line) {
+			title = line
+			break
+		}
+	}
+
+	return models.ChangelogMergeRequest{
+		IID:       iid,
+		Reference: match[1] + "!" + match[2],
+		Title:     title,
+		Author:    commit.AuthorName,
+		MergedAt:  commit.CommittedDate,
+		CommitID:  commit.ID,
+	}, true
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/pkg/semver"
+)
+
+// Orderings reported by CompareVersions
+const (
+	OrderLower  = "lower"
+	OrderEqual  = "equal"
+	OrderHigher = "higher"
+)
+
+// CompareVersions orders v1 against v2 and reports the level at which they
+// differ. Both versions are normalized like imported versions first.
+func (s *VersionService) CompareVersions(v1, v2 string) (*models.CompareResponse, error) {
+	normalized1, _, err := s.normalizeVersion(v1)
+	if err != nil {
+		return nil, fmt.Errorf("%w: v1: %v", ErrInvalidVersion, err)
+	}
+	normalized2, _, err := s.normalizeVersion(v2)
+	if err != nil {
+		return nil, fmt.Errorf("%w: v2: %v", ErrInvalidVersion, err)
+	}
+
+	cmp, err := semver.Compare(normalized1, normalized2)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidVersion, err)
+	}
+	diff, err := semver.Diff(normalized1, normalized2)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidVersion, err)
+	}
+
+	response := &models.CompareResponse{V1: normalized1, V2: normalized2, Diff: diff}
+	switch {
+	case cmp < 0:
+		response.Result, response.Order = -1, OrderLower
+	case cmp > 0:
+		response.Result, response.Order = 1, OrderHigher
+	default:
+		response.Result, response.Order = 0, OrderEqual
+	}
+	return response, nil
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// incrementTypes lists the increments DecrementVersion knows how to undo
+var incrementTypes = []models.IncrementType{
+	models.IncrementTypePatch,
+	models.IncrementTypeMinor,
+	models.IncrementTypeMajor,
+	models.IncrementTypeRC,
+	models.IncrementTypeBuild,
+}
+
+// DecrementVersion undoes the most recent increment of an app. The version it
+// steps back to is the one Git recorded before the current one, so an app
+// never drops below its last persisted state. Only a single increment can be
+// undone: if the current version is not one increment above the previous
+// one (after a raw edit, a promotion or several unpersisted increments) the
+// call fails with ErrNotAnIncrement and RollbackVersion should be used.
+func (s *VersionService) DecrementVersion(ctx context.Context, appID string) (*models.DecrementResponse, error) {
+	return onApp(ctx, s, appID, func() (*models.DecrementResponse, error) {
+		id, err := s.parseAppID(appID)
+		if err != nil {
+			return nil, err
+		}
+
+		history, ok := s.git.(storage.HistoryProvider)
+		if !ok {
+			return nil, fmt.Errorf("Git storage does not support version history")
+		}
+
+		current, err := s.lookupVersion(ctx, appID)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := checkWritable(appID, current); err != nil {
+			return nil, err
+		}
+
+		if id, err = identifierFromRecord(id, current); err != nil {
+			return nil, err
+		}
+
+		previous, commit, err := history.GetPreviousVersion(ctx, appID, current.Current)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read version history: %w", err)
+		}
+		if previous == nil {
+			return nil, fmt.Errorf("%w for %s", ErrNoPreviousVersion, appID)
+		}
+
+		undone, ok := s.incrementBetween(current.Policy, previous.Current, current.Current, current.LastUpdated)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is not one increment above %s", ErrNotAnIncrement, current.Current, previous.Current)
+		}
+
+		decremented := *current
+		decremented.Current = previous.Current
+		decremented.ProjectID, decremented.AppName = id.ProjectID, id.AppName
+		decremented.LastUpdated = time.Now()
+
+		if err := s.saveVersion(ctx, appID, &decremented); err != nil {
+			return nil, err
+		}
+
+		s.logger.WithFields(logrus.Fields{
+			"app_id":      appID,
+			"old_version": current.Current,
+			"new_version": previous.Current,
+			"undone":      undone,
+			"commit":      commit,
+		}).Info("Version decremented")
+
+		return &models.DecrementResponse{
+			Version:         previous.Current,
+			DecrementedFrom: current.Current,
+			Undone:          undone,
+			Commit:          commit,
+		}, nil
+	})
+}
+
+// incrementBetween reports which increment of the policy's scheme, made at
+// the given time, turns from into to, if any
+func (s *VersionService) incrementBetween(policy *models.AppPolicy, from, to string, at time.Time) (models.IncrementType, bool) {
+	for _, incrementType := range incrementTypes {
+		next, err := s.schemeOf(policy).next(from, incrementType, at)
+		if err != nil {
+			// Not an increment of this scheme, or from does not parse
+			continue
+		}
+		if next == to {
+			return incrementType, true
+		}
+	}
+	return "", false
+}
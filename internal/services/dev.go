@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+	"github.com/company/version-service/pkg/semver"
+	"github.com/sirupsen/logrus"
+)
+
+func (s *VersionService) devVersionTracker() storage.DevVersionTracker {
+	if s.devRetention <= 0 {
+		return nil
+	}
+	tracker, ok := s.redis.(storage.DevVersionTracker)
+	if !ok {
+		return nil
+	}
+	return tracker
+}
+
+// nextDevBuild returns the next build number of an app's branch. Branches
+// are counted by their sanitized name, the one dev versions carry.
+func (s *VersionService) nextDevBuild(ctx context.Context, appID, branch string) (int64, error) {
+	counter, ok := s.redis.(storage.DevBuildCounter)
+	if !ok {
+		return 0, fmt.Errorf("%w: {build} is not supported by the configured storage", ErrInvalidDevTemplate)
+	}
+
+	build, err := counter.NextDevBuild(ctx, appID, semver.SanitizeBranch(branch))
+	if err != nil {
+		return 0, fmt.Errorf("failed to number dev build: %w", err)
+	}
+	return build, nil
+}
+
+// trackDevVersion records an issued dev version. Failures are logged and
+// never fail the dev version request.
+func (s *VersionService) trackDevVersion(ctx context.Context, appID string, req *models.DevVersionRequest, version string, build int64) {
+	tracker := s.devVersionTracker()
+	if tracker == nil {
+		return
+	}
+
+	record := &models.DevVersionRecord{
+		Version:  version,
+		SHA:      req.SHA,
+		Branch:   req.Branch,
+		Build:    build,
+		IssuedAt: time.Now(),
+	}
+
+	if err := tracker.RecordDevVersion(ctx, appID, record, s.devRetention); err != nil {
+		s.logger.WithError(err).WithFields(logrus.Fields{
+			"app_id":  appID,
+			"version": version,
+		}).Warn("Failed to record issued dev version")
+	}
+}
+
+// ListDevVersions returns the dev versions issued for an app within the
+// retention window, newest first, optionally limited to one branch
+func (s *VersionService) ListDevVersions(ctx context.Context, appID, branch string) (*models.DevVersionsResponse, error) {
+	if _, err := s.parseAppID(appID); err != nil {
+		return nil, err
+	}
+
+	tracker := s.devVersionTracker()
+	if tracker == nil {
+		return nil, ErrDevTrackingDisabled
+	}
+
+	records, err := tracker.ListDevVersions(ctx, appID, time.Now().Add(-s.devRetention))
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.DevVersionsResponse{
+		AppID:     appID,
+		Retention: s.devRetention.String(),
+		Versions:  make([]models.DevVersionRecord, 0, len(records)),
+	}
+	for _, record := range records {
+		if branch == "" || record.Branch == branch {
+			response.Versions = append(response.Versions, record)
+		}
+	}
+
+	return response, nil
+}
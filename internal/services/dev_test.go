@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingStorage is an in-memory storage.Storage counting version reads
+type countingStorage struct {
+	*storage.MemoryStorage
+	reads atomic.Int64
+}
+
+func (c *countingStorage) GetVersion(ctx context.Context, appID string) (*models.AppVersion, error) {
+	c.reads.Add(1)
+	return c.MemoryStorage.GetVersion(ctx, appID)
+}
+
+// BenchmarkGetDevVersion compares dev version requests for one busy app with
+// and without the dev version cache, reporting storage reads per request
+func BenchmarkGetDevVersion(b *testing.B) {
+	for _, bc := range []struct {
+		name string
+		ttl  time.Duration
+	}{
+		{"uncached", 0},
+		{"cached", 5 * time.Second},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			logger := logrus.New()
+			logger.SetOutput(io.Discard)
+			memory, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+			if err != nil {
+				b.Fatal(err)
+			}
+			memory.SetVersion(context.Background(), "123-api", &models.AppVersion{Current: "1.2.3", ProjectID: "123", AppName: "api"})
+			store := &countingStorage{MemoryStorage: memory}
+			s := NewVersionService(store, store, nil, logger, Options{DevVersionCacheTTL: bc.ttl})
+			req := &models.DevVersionRequest{SHA: "abc1234567890", Branch: "feature/x"}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := s.GetDevVersion(context.Background(), "123-api", req); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+			b.ReportMetric(float64(store.reads.Load())/float64(b.N), "reads/op")
+		})
+	}
+}
+
+func TestGetDevVersion_NumbersBuilds(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	memory, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, memory.SetVersion(ctx, "123-api", &models.AppVersion{Current: "1.2.3", ProjectID: "123", AppName: "api"}))
+	s := NewVersionService(memory, memory, nil, logger, Options{})
+
+	// A rejected template must not use up a build number
+	_, err = s.GetDevVersion(ctx, "123-api", &models.DevVersionRequest{SHA: "abc1234", Branch: "main", Template: "{build}-{nope}"})
+	assert.ErrorIs(t, err, ErrInvalidDevTemplate)
+
+	req := &models.DevVersionRequest{SHA: "abc1234", Branch: "main", Template: "{current}-dev.{build}"}
+	first, err := s.GetDevVersion(ctx, "123-api", req)
+	require.NoError(t, err)
+	second, err := s.GetDevVersion(ctx, "123-api", req)
+	require.NoError(t, err)
+	other, err := s.GetDevVersion(ctx, "123-api", &models.DevVersionRequest{SHA: "abc1234", Branch: "feature", Template: req.Template})
+	require.NoError(t, err)
+
+	assert.Equal(t, "1.2.3-dev.1", first.Version)
+	assert.Equal(t, "1.2.3-dev.2", second.Version)
+	assert.Equal(t, "1.2.3-dev.1", other.Version, "each branch counts its own builds")
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/company/version-service/internal/middleware"
+	"github.com/company/version-service/pkg/semver"
+)
+
+// devGenerator produces the dev versions of one parsed version from a dev
+// template
+type devGenerator func(template string, info semver.DevInfo) (string, error)
+
+// devCache keeps the dev version generator of each app's current version for
+// a short time, so builds requesting dev versions of a busy app skip the Redis
+// read and the version parse. Writes through this instance invalidate the
+// app's entry immediately; the TTL bounds how long writes made by other
+// replicas go unnoticed.
+type devCache struct {
+	ttl time.Duration
+
+	mu      sync.RWMutex
+	entries map[string]devCacheEntry
+	// epoch counts invalidations. A fill only lands if no invalidation
+	// happened since its lookup, so a generator built from a record read
+	// before a write is never cached after that write.
+	epoch uint64
+}
+
+type devCacheEntry struct {
+	generate devGenerator
+	expires  time.Time
+}
+
+// newDevCache returns a cache keeping entries for ttl; zero disables it
+func newDevCache(ttl time.Duration) *devCache {
+	return &devCache{
+		ttl:     ttl,
+		entries: make(map[string]devCacheEntry),
+	}
+}
+
+// get returns the app's cached generator. On a miss it returns the epoch to
+// pass to put.
+func (c *devCache) get(appID string) (devGenerator, uint64, bool) {
+	if c.ttl <= 0 {
+		return nil, 0, false
+	}
+
+	c.mu.RLock()
+	entry, ok := c.entries[appID]
+	epoch := c.epoch
+	c.mu.RUnlock()
+
+	if ok && time.Now().Before(entry.expires) {
+		middleware.RecordDevVersionCache("hit")
+		return entry.generate, epoch, true
+	}
+	middleware.RecordDevVersionCache("miss")
+	return nil, epoch, false
+}
+
+// put caches the app's generator unless the cache was invalidated since the
+// lookup that returned epoch
+func (c *devCache) put(appID string, epoch uint64, generate devGenerator) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.epoch != epoch {
+		return
+	}
+
+	now := time.Now()
+	c.entries[appID] = devCacheEntry{generate: generate, expires: now.Add(c.ttl)}
+
+	// Drop expired entries now and then so apps no longer built don't
+	// accumulate
+	if len(c.entries)%256 == 0 {
+		for id, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, id)
+			}
+		}
+	}
+}
+
+// invalidate drops the entries of the given apps
+func (c *devCache) invalidate(appIDs ...string) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.epoch++
+	for _, appID := range appIDs {
+		delete(c.entries, appID)
+	}
+}
+
+// invalidateAll drops every entry, for writes replacing the whole cache
+func (c *devCache) invalidateAll() {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.epoch++
+	c.entries = make(map[string]devCacheEntry)
+}
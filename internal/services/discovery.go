@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/company/version-service/internal/clients"
+	"github.com/company/version-service/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// DiscoveryOptions configures the GitLab project discovery job. Discovery is
+// disabled when Groups is empty. Without Register, projects missing from the
+// version service are only reported.
+type DiscoveryOptions struct {
+	Groups   []string
+	Interval time.Duration
+	Register bool
+}
+
+func (s *VersionService) discoveryEnabled() bool {
+	return s.gitLabClient != nil && len(s.discovery.Groups) > 0
+}
+
+func (s *VersionService) periodicDiscovery() {
+	interval := s.discovery.Interval
+	if interval <= 0 {
+		interval = 6 * time.Hour
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.RunDiscovery(context.Background()); err != nil && !errors.Is(err, ErrDiscoveryRunning) {
+			s.logger.WithError(err).Error("GitLab project discovery failed")
+		}
+		<-ticker.C
+	}
+}
+
+// RunDiscovery enumerates projects in the configured GitLab groups and
+// pre-registers an app for every project that has none yet. The app name is
+// the project path, matching CI_PROJECT_NAME in GitLab pipelines.
+func (s *VersionService) RunDiscovery(ctx context.Context) (*models.DiscoveryReport, error) {
+	if !s.discoveryEnabled() {
+		return nil, ErrDiscoveryDisabled
+	}
+
+	s.discoveryMu.Lock()
+	if s.discoveryRunning {
+		s.discoveryMu.Unlock()
+		return nil, ErrDiscoveryRunning
+	}
+	s.discoveryRunning = true
+	s.discoveryMu.Unlock()
+
+	defer func() {
+		s.discoveryMu.Lock()
+		s.discoveryRunning = false
+		s.discoveryMu.Unlock()
+	}()
+
+	report := &models.DiscoveryReport{
+		Groups:       s.discovery.Groups,
+		Registered:   []models.DiscoveredProject{},
+		Unregistered: []models.DiscoveredProject{},
+		StartedAt:    time.Now(),
+	}
+
+	// Deleted apps count as known so discovery doesn't bring them back
+	versions, err := s.listRecords(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list versions: %w", err)
+	}
+
+	knownProjects := make(map[string]bool)
+	for _, version := range versions {
+		knownProjects[version.ProjectID] = true
+	}
+
+	seen := make(map[string]bool)
+	for _, group := range s.discovery.Groups {
+		projects, err := s.gitLabClient.ListGroupProjects(ctx, group)
+		if err != nil {
+			s.logger.WithError(err).WithField("group", group).Warn("Failed to list GitLab group projects")
+			report.Errors = append(report.Errors, fmt.Sprintf("group %s: %v", group, err))
+			continue
+		}
+
+		for _, project := range projects {
+			id, err := s.gitLabAppIdentifier(project)
+			if err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("project %d: %v", project.ID, err))
+				continue
+			}
+			if seen[id.ProjectID] {
+				continue
+			}
+			seen[id.ProjectID] = true
+			report.ProjectsScanned++
+
+			if knownProjects[id.ProjectID] {
+				continue
+			}
+
+			discovered := models.DiscoveredProject{
+				ProjectID: id.ProjectID,
+				AppID:     id.ID,
+				RepoName:  project.PathWithNamespace,
+			}
+
+			if !s.discovery.Register {
+				report.Unregistered = append(report.Unregistered, discovered)
+				continue
+			}
+
+			version, err := s.registerDiscoveredProject(ctx, project, id)
+			if err != nil {
+				s.logger.WithError(err).WithField("app_id", discovered.AppID).Warn("Failed to pre-register discovered project")
+				report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", discovered.AppID, err))
+				report.Unregistered = append(report.Unregistered, discovered)
+				continue
+			}
+
+			discovered.Version = version.Current
+			report.Registered = append(report.Registered, discovered)
+		}
+	}
+
+	report.CompletedAt = time.Now()
+
+	s.discoveryMu.Lock()
+	s.lastDiscovery = report
+	s.discoveryMu.Unlock()
+
+	s.logger.WithFields(logrus.Fields{
+		"projects_scanned": report.ProjectsScanned,
+		"registered":       len(report.Registered),
+		"unregistered":     len(report.Unregistered),
+		"errors":           len(report.Errors),
+	}).Info("GitLab project discovery completed")
+
+	return report, nil
+}
+
+// registerDiscoveredProject seeds and stores the app for a discovered project
+// unless it was created concurrently
+func (s *VersionService) registerDiscoveredProject(ctx context.Context, project clients.GitLabProject, id models.AppIdentifier) (*models.AppVersion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	appID, projectID, appName := id.ID, id.ProjectID, id.AppName
+
+	if existing, err := s.lookupVersion(ctx, appID); err == nil {
+		return existing, nil
+	} else if errors.Is(err, ErrAppDeleted) {
+		return nil, err
+	}
+
+	if err := s.checkAppQuota(ctx, projectID); err != nil {
+		return nil, err
+	}
+
+	// The listing already carries the project's metadata, so prime the cache
+	// to save a project lookup while seeding
+	s.rememberProject(projectID, project)
+
+	version, _, err := s.seedVersion(ctx, appID, projectID, appName)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.saveVersion(ctx, appID, version); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"app_id":    appID,
+		"version":   version.Current,
+		"repo_name": version.RepoName,
+	}).Info("Pre-registered app from GitLab discovery")
+
+	return version, nil
+}
+
+// GetDiscoveryReport returns the result of the most recent discovery run, or
+// nil if none has completed
+func (s *VersionService) GetDiscoveryReport(ctx context.Context) (*models.DiscoveryReport, error) {
+	if !s.discoveryEnabled() {
+		return nil, ErrDiscoveryDisabled
+	}
+
+	s.discoveryMu.Lock()
+	defer s.discoveryMu.Unlock()
+	return s.lastDiscovery, nil
+}
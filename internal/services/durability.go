@@ -0,0 +1,29 @@
+package services
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type durabilityKey struct{}
+
+// WithDurability returns a context that records whether the version writes
+// made with it were in Git when they returned, as reported by Durable
+func WithDurability(ctx context.Context) context.Context {
+	return context.WithValue(ctx, durabilityKey{}, new(atomic.Bool))
+}
+
+// Durable reports whether every version write made with ctx, which must
+// come from WithDurability, was in Git when it returned. Writes persisted in
+// the background or journaled while Git is failed over are not.
+func Durable(ctx context.Context) bool {
+	deferred, ok := ctx.Value(durabilityKey{}).(*atomic.Bool)
+	return ok && !deferred.Load()
+}
+
+// deferred records in ctx that a version write returned before it was in Git
+func deferred(ctx context.Context) {
+	if deferred, ok := ctx.Value(durabilityKey{}).(*atomic.Bool); ok {
+		deferred.Store(true)
+	}
+}
@@ -0,0 +1,200 @@
+package services
+
+import "errors"
+
+var (
+	// ErrQuotaExceeded is returned when an operation would exceed a configured
+	// project quota
+	ErrQuotaExceeded = errors.New("quota exceeded")
+
+	// ErrAppNotFound is returned by operations that require an existing app
+	// and must not lazily create one
+	ErrAppNotFound = errors.New("app not found")
+
+	// ErrAppDeleted is returned, alongside ErrAppNotFound, when an app has
+	// been deleted and only its tombstone remains
+	ErrAppDeleted = errors.New("app deleted")
+
+	// ErrAppNotDeleted is returned when restoring an app that is not deleted
+	ErrAppNotDeleted = errors.New("app is not deleted")
+
+	// ErrAppExists is returned when registering an app that already exists
+	ErrAppExists = errors.New("app already exists")
+
+	// ErrInvalidRename is returned when renaming an app to its own ID
+	ErrInvalidRename = errors.New("invalid rename")
+
+	// ErrInvalidRegistration is returned when an app registration breaks a
+	// validation rule
+	ErrInvalidRegistration = errors.New("invalid registration")
+
+	// ErrIncrementNotAllowed is returned when an increment type is excluded
+	// by the app's policy
+	ErrIncrementNotAllowed = errors.New("increment not allowed by policy")
+
+	// ErrVersionReserved is returned when an operation would issue a version
+	// reserved for the app or its project
+	ErrVersionReserved = errors.New("version is reserved")
+
+	// ErrInvalidReservedVersions is returned when a reserved version list
+	// contains an unparsable version
+	ErrInvalidReservedVersions = errors.New("invalid reserved versions")
+
+	// ErrAppNotRegistered is returned when an opaque app ID is used before
+	// the app was registered with its project and name
+	ErrAppNotRegistered = errors.New("app not registered")
+
+	// ErrInvalidWebhook is returned when a webhook subscription has a bad
+	// URL or event filter, or its project has too many subscriptions
+	ErrInvalidWebhook = errors.New("invalid webhook subscription")
+
+	// ErrWebhookNotFound is returned when deleting a subscription the
+	// project does not have
+	ErrWebhookNotFound = errors.New("webhook subscription not found")
+
+	// ErrInvalidPage is returned for a page request with a bad limit or a
+	// cursor that was not issued by a previous page
+	ErrInvalidPage = errors.New("invalid page request")
+
+	// ErrInvalidStaleThreshold is returned for a stale report with a
+	// non-positive number of days
+	ErrInvalidStaleThreshold = errors.New("invalid stale threshold")
+
+	// ErrInvalidMetadata is returned when a metadata update contains an
+	// invalid annotation key or value
+	ErrInvalidMetadata = errors.New("invalid metadata")
+
+	// ErrInvalidVersion is returned when a version given by the caller is not
+	// a valid semantic version
+	ErrInvalidVersion = errors.New("invalid version")
+
+	// ErrTagNotFound is returned when a version has no tag in the app's
+	// GitLab project
+	ErrTagNotFound = errors.New("version tag not found")
+
+	// ErrChangelogUnavailable is returned when changelogs are requested
+	// without GitLab access
+	ErrChangelogUnavailable = errors.New("changelog unavailable")
+
+	// ErrNoPreviousVersion is returned when a rollback finds no earlier
+	// version in history
+	ErrNoPreviousVersion = errors.New("no previous version recorded")
+
+	// ErrNotAnIncrement is returned when a decrement finds that the current
+	// version was not produced by a single increment of the previous one
+	ErrNotAnIncrement = errors.New("not an increment")
+
+	// ErrInvalidVersionsFile is returned when a replacement versions file
+	// fails validation
+	ErrInvalidVersionsFile = errors.New("invalid versions file")
+
+	// ErrRevisionConflict is returned when a conditional write targets a
+	// revision that is no longer current
+	ErrRevisionConflict = errors.New("revision conflict")
+
+	// ErrVersionLocked is returned when a write targets an app whose version
+	// is frozen
+	ErrVersionLocked = errors.New("version is locked")
+
+	// ErrNotPrerelease is returned when promoting an app whose current
+	// version is already a release
+	ErrNotPrerelease = errors.New("current version is not a prerelease")
+
+	// ErrAlreadyGraduated is returned when graduating an app that is
+	// already at 1.0.0 or above
+	ErrAlreadyGraduated = errors.New("app is already at 1.0.0 or above")
+
+	// ErrUnsupportedScheme is returned when an operation has no meaning under
+	// the app's version scheme
+	ErrUnsupportedScheme = errors.New("not supported by the app's version scheme")
+
+	// ErrHookRejected is returned when a pre-increment hook vetoes an
+	// increment
+	ErrHookRejected = errors.New("rejected by pre-increment hook")
+
+	// ErrHookFailed is returned when a pre-increment hook cannot be reached
+	// or errors and hooks fail closed
+	ErrHookFailed = errors.New("pre-increment hook failed")
+
+	// ErrInvalidBatch is returned when a batch request is empty, too large or
+	// contains duplicates
+	ErrInvalidBatch = errors.New("invalid batch")
+
+	// ErrDiscoveryDisabled is returned when GitLab discovery is not
+	// configured
+	ErrDiscoveryDisabled = errors.New("GitLab discovery is not configured")
+
+	// ErrDiscoveryRunning is returned when a discovery run is requested while
+	// another is in progress
+	ErrDiscoveryRunning = errors.New("GitLab discovery already running")
+
+	// ErrAlreadyBootstrapped is returned when bootstrapping a deployment whose
+	// versions branch already has commits
+	ErrAlreadyBootstrapped = errors.New("versions repository already bootstrapped")
+
+	// ErrInvalidSeed is returned when a bootstrap seed entry is malformed
+	ErrInvalidSeed = errors.New("invalid bootstrap seed")
+
+	// ErrInvalidAlias is returned when an alias name or target version is
+	// rejected
+	ErrInvalidAlias = errors.New("invalid alias")
+
+	// ErrAliasNotFound is returned when resolving an alias an app does not
+	// define
+	ErrAliasNotFound = errors.New("alias not found")
+
+	// ErrDevTrackingDisabled is returned when listing issued dev versions
+	// while tracking is turned off
+	ErrDevTrackingDisabled = errors.New("dev version tracking is disabled")
+
+	// ErrInvalidStateBundle is returned when a state bundle to import is
+	// malformed or from an unsupported format version
+	ErrInvalidStateBundle = errors.New("invalid state bundle")
+
+	// ErrInvalidDevTemplate is returned when a dev version template is
+	// malformed or produces a version the app's scheme rejects
+	ErrInvalidDevTemplate = errors.New("invalid dev version template")
+
+	// ErrInvalidDevBase is returned for dev version bases other than current
+	// and next
+	ErrInvalidDevBase = errors.New("invalid dev version base")
+
+	// ErrInvalidLifecycle is returned for unknown lifecycle states
+	ErrInvalidLifecycle = errors.New("invalid lifecycle state")
+
+	// ErrLifecycleTransition is returned when the lifecycle state machine
+	// doesn't allow moving an app to the requested state
+	ErrLifecycleTransition = errors.New("lifecycle transition not allowed")
+
+	// ErrAppArchived is returned, along with ErrVersionLocked, when a write
+	// targets an archived app
+	ErrAppArchived = errors.New("app is archived")
+
+	// ErrInvalidStrategy is returned for increment strategies that are
+	// unknown, don't fit the app's scheme or aren't allowed by its policy
+	ErrInvalidStrategy = errors.New("invalid increment strategy")
+
+	// ErrInvalidMigration is returned for malformed project migrations
+	ErrInvalidMigration = errors.New("invalid project migration")
+
+	// ErrMigrationConflict is returned when a project migration would give
+	// two apps the same ID
+	ErrMigrationConflict = errors.New("project migration conflict")
+
+	// ErrAppNotInProject is returned when an app named in a project
+	// operation belongs to another project
+	ErrAppNotInProject = errors.New("app is not in the project")
+
+	// ErrAirGapDisabled is returned by outbox operations when air-gapped
+	// mode is not configured
+	ErrAirGapDisabled = errors.New("air-gapped mode is not configured")
+
+	// ErrGitLabUnavailable is returned when a new app can't be seeded from
+	// its tags because GitLab, or the configured tag provider, kept failing,
+	// rather than being seeded with the default version for good
+	ErrGitLabUnavailable = errors.New("GitLab unavailable")
+
+	// ErrProjectPathNotFound is returned when a GitLab project path is
+	// neither in the project path table nor known to GitLab
+	ErrProjectPathNotFound = errors.New("project path not found")
+)
@@ -0,0 +1,282 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/company/version-service/internal/middleware"
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// FailoverOptions configures automatic failover of writes from Git to a
+// secondary durable backend
+type FailoverOptions struct {
+	// Journal receives version writes while Git is failed over; nil
+	// disables failover
+	Journal storage.Journal
+	// Threshold is how long Git must stay unhealthy before writes fail over
+	Threshold time.Duration
+	// CheckInterval is how often Git health is probed
+	CheckInterval time.Duration
+}
+
+// failoverState tracks whether writes go to Git or the journal. mu is held
+// across journal appends and the switch back to Git; the bulk of a replay
+// runs without it, so writes keep being journaled meanwhile.
+type failoverState struct {
+	mu             sync.Mutex
+	active         bool
+	activeSince    time.Time
+	unhealthySince time.Time
+	journaled      int
+	lastError      string
+	// committed counts the leading journal entries already committed to
+	// Git by a replay that failed afterwards, so the next replay pushes
+	// them instead of committing them again. Only checkFailover uses it.
+	committed int
+}
+
+// failoverEnabled reports whether a secondary backend is configured
+func (s *VersionService) failoverEnabled() bool {
+	return s.failoverOpts.Journal != nil
+}
+
+// journalWrites appends writes to the journal instead of Git while failed
+// over and reports whether it did. A failing journal leaves the writes to
+// Git and its retries.
+func (s *VersionService) journalWrites(ctx context.Context, entries []models.JournalEntry, writtenAt time.Time) bool {
+	if !s.failoverEnabled() {
+		return false
+	}
+
+	s.failover.mu.Lock()
+	defer s.failover.mu.Unlock()
+
+	if !s.failover.active {
+		return false
+	}
+
+	if err := s.failoverOpts.Journal.Append(context.WithoutCancel(ctx), entries); err != nil {
+		s.logger.WithError(err).Error("Failed to journal writes while failed over, writing to Git")
+		return false
+	}
+	s.failover.journaled += len(entries)
+	middleware.RecordFailoverJournalWrites(len(entries))
+
+	appIDs := make([]string, len(entries))
+	for i, entry := range entries {
+		appIDs[i] = entry.AppID
+	}
+	// Journaled writes are durable but not yet in Git; the replay confirms
+	// them like a push of local commits
+	s.freshness.committed(appIDs, writtenAt)
+
+	s.logger.WithField("app_ids", appIDs).Info("Writes journaled while Git is failed over")
+	return true
+}
+
+// journalEntries builds the journal entries of version writes
+func journalEntries(versions map[string]*models.AppVersion) []models.JournalEntry {
+	now := time.Now()
+	entries := make([]models.JournalEntry, 0, len(versions))
+	for appID, version := range versions {
+		entries = append(entries, models.JournalEntry{AppID: appID, Version: version, RecordedAt: now})
+	}
+	return entries
+}
+
+// recoverJournal applies journal entries left by a previous run to the
+// versions loaded from Git and keeps writes failed over until they are
+// replayed
+func (s *VersionService) recoverJournal(ctx context.Context, versions map[string]*models.AppVersion) {
+	if !s.failoverEnabled() {
+		return
+	}
+
+	entries := s.applyJournal(ctx, versions)
+	if entries == 0 {
+		return
+	}
+
+	s.failover.mu.Lock()
+	s.failover.active = true
+	s.failover.activeSince = time.Now()
+	s.failover.journaled = entries
+	s.failover.mu.Unlock()
+	middleware.SetFailoverActive(true)
+
+	s.logger.WithField("entries", entries).Warn("Failover journal holds writes not yet in Git; replaying once Git is healthy")
+}
+
+// applyJournal overlays the journaled writes on versions read from Git and
+// returns how many there were
+func (s *VersionService) applyJournal(ctx context.Context, versions map[string]*models.AppVersion) int {
+	entries, err := s.failoverOpts.Journal.Entries(ctx)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to read failover journal")
+		return 0
+	}
+
+	for _, entry := range entries {
+		if entry.Version == nil {
+			delete(versions, entry.AppID)
+		} else {
+			versions[entry.AppID] = entry.Version
+		}
+	}
+	return len(entries)
+}
+
+// monitorFailover probes Git health and fails writes over to the journal,
+// or back to Git, as it changes
+func (s *VersionService) monitorFailover() {
+	ticker := time.NewTicker(s.failoverOpts.CheckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.checkFailover()
+	}
+}
+
+func (s *VersionService) checkFailover() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	healthErr := s.git.Health(ctx)
+	now := time.Now()
+
+	if healthErr != nil {
+		s.recordGitUnhealthy(healthErr, now)
+		return
+	}
+
+	s.failover.mu.Lock()
+	s.failover.unhealthySince = time.Time{}
+	s.failover.lastError = ""
+	active := s.failover.active
+	s.failover.mu.Unlock()
+	if !active {
+		return
+	}
+
+	// Replay what is journaled so far while writes keep being journaled
+	replayed, err := s.replayJournal(ctx)
+	if err != nil {
+		s.logger.WithError(err).WithField("replayed", replayed).Warn("Failed to replay failover journal into Git, staying failed over")
+		return
+	}
+
+	// Replay the writes journaled meanwhile and switch back to Git
+	s.failover.mu.Lock()
+	defer s.failover.mu.Unlock()
+
+	tail, err := s.replayJournal(ctx)
+	replayed += tail
+	if err != nil {
+		s.logger.WithError(err).WithField("replayed", replayed).Warn("Failed to replay failover journal into Git, staying failed over")
+		return
+	}
+
+	failedOverFor := now.Sub(s.failover.activeSince)
+	s.failover.active = false
+	s.failover.activeSince = time.Time{}
+	s.failover.journaled = 0
+	s.freshness.pushed()
+	s.updateGitHealth(true)
+	middleware.SetFailoverActive(false)
+	middleware.RecordFailoverTransition("recovery")
+
+	s.logger.WithFields(logrus.Fields{
+		"replayed":        replayed,
+		"failed_over_for": failedOverFor.String(),
+	}).Info("Git recovered, failover journal replayed")
+}
+
+// recordGitUnhealthy fails writes over to the journal once Git has been
+// unhealthy for the threshold
+func (s *VersionService) recordGitUnhealthy(healthErr error, now time.Time) {
+	s.failover.mu.Lock()
+	defer s.failover.mu.Unlock()
+
+	if s.failover.unhealthySince.IsZero() {
+		s.failover.unhealthySince = now
+	}
+	s.failover.lastError = healthErr.Error()
+
+	if !s.failover.active && now.Sub(s.failover.unhealthySince) >= s.failoverOpts.Threshold {
+		s.failover.active = true
+		s.failover.activeSince = now
+		middleware.SetFailoverActive(true)
+		middleware.RecordFailoverTransition("failover")
+		s.logger.WithError(healthErr).WithField("unhealthy_for", now.Sub(s.failover.unhealthySince).String()).
+			Error("Git unhealthy beyond the failover threshold, writing to the failover journal")
+	}
+}
+
+// replayJournal writes the entries journaled so far into Git in order and
+// truncates them from the journal; entries appended meanwhile are left for
+// the next replay. Entries a failed replay already committed are pushed
+// rather than committed again. Entries are whole records, so replaying one
+// twice, say after a restart, is harmless. Only checkFailover runs it.
+func (s *VersionService) replayJournal(ctx context.Context) (int, error) {
+	entries, err := s.failoverOpts.Journal.Entries(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if s.failover.committed > 0 {
+		if pushable, ok := s.git.(storage.GitPushable); ok {
+			if err := pushable.PushPendingCommits(ctx); err != nil {
+				return 0, fmt.Errorf("failed to push replayed entries: %w", err)
+			}
+		}
+	}
+
+	for i := min(s.failover.committed, len(entries)); i < len(entries); i++ {
+		entry := entries[i]
+		if entry.Version == nil {
+			err = s.git.DeleteVersion(ctx, entry.AppID)
+		} else {
+			err = s.git.SetVersion(ctx, entry.AppID, entry.Version)
+		}
+		if err != nil {
+			if s.isPushFailure(err) {
+				// Committed locally; only the push is left
+				s.failover.committed = i + 1
+				s.markPushNeeded()
+			}
+			return i, fmt.Errorf("%s: %w", entry.AppID, err)
+		}
+		s.failover.committed = i + 1
+	}
+
+	if err := s.failoverOpts.Journal.Truncate(ctx, len(entries)); err != nil {
+		return len(entries), err
+	}
+	s.failover.committed = 0
+	return len(entries), nil
+}
+
+// failoverHealth describes where writes go for /health
+func (s *VersionService) failoverHealth(ctx context.Context) string {
+	if err := s.failoverOpts.Journal.Health(ctx); err != nil {
+		return fmt.Sprintf("unhealthy: %v", err)
+	}
+
+	s.failover.mu.Lock()
+	defer s.failover.mu.Unlock()
+
+	switch {
+	case s.failover.active:
+		return fmt.Sprintf("degraded: writes failed over to the journal since %s (%d journaled)",
+			s.failover.activeSince.Format(time.RFC3339), s.failover.journaled)
+	case !s.failover.unhealthySince.IsZero():
+		return fmt.Sprintf("standby: Git unhealthy since %s", s.failover.unhealthySince.Format(time.RFC3339))
+	default:
+		return "standby"
+	}
+}
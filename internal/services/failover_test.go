@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyGit stands in for Git storage whose remote can be taken down and
+// whose pushes can be rejected after the local commit
+type flakyGit struct {
+	*storage.MemoryStorage
+
+	mu      sync.Mutex
+	down    bool
+	rejects int
+	writes  map[string]int
+	pushes  int
+}
+
+func (g *flakyGit) Health(ctx context.Context) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.down {
+		return errors.New("remote unreachable")
+	}
+	return nil
+}
+
+func (g *flakyGit) SetVersion(ctx context.Context, appID string, version *models.AppVersion) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.down {
+		return errors.New("remote unreachable")
+	}
+	if err := g.MemoryStorage.SetVersion(ctx, appID, version); err != nil {
+		return err
+	}
+	g.writes[appID]++
+	if g.rejects > 0 {
+		g.rejects--
+		return storage.ErrPushRejected
+	}
+	return nil
+}
+
+func (g *flakyGit) PushPendingCommits(ctx context.Context) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.pushes++
+	return nil
+}
+
+func newFailoverService(t *testing.T, journalPath string) (*VersionService, *flakyGit, *storage.FileJournal) {
+	t.Helper()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	cache, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	durable, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	git := &flakyGit{MemoryStorage: durable, writes: make(map[string]int)}
+	journal, err := storage.NewFileJournal(journalPath, logger)
+	require.NoError(t, err)
+
+	s := NewVersionService(cache, git, nil, logger, Options{
+		WriteThrough: true,
+		Failover: FailoverOptions{
+			Journal:       journal,
+			Threshold:     0,
+			CheckInterval: time.Hour,
+		},
+	})
+	return s, git, journal
+}
+
+func TestFailover_JournalsAndReplays(t *testing.T) {
+	s, git, journal := newFailoverService(t, filepath.Join(t.TempDir(), "journal.jsonl"))
+	ctx := context.Background()
+
+	_, err := s.IncrementVersion(ctx, "1-api", models.IncrementTypePatch, "")
+	require.NoError(t, err)
+
+	// Git goes down and writes fail over to the journal
+	git.mu.Lock()
+	git.down = true
+	git.mu.Unlock()
+	s.checkFailover()
+	assert.Contains(t, s.failoverHealth(ctx), "degraded")
+
+	_, err = s.IncrementVersion(ctx, "1-api", models.IncrementTypePatch, "")
+	require.NoError(t, err)
+	_, err = s.IncrementVersion(ctx, "2-web", models.IncrementTypeMinor, "")
+	require.NoError(t, err)
+
+	entries, err := journal.Entries(ctx)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	stored, err := git.GetVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.1", stored.Current, "Git is not written while failed over")
+
+	// Git recovers; the journal is replayed and writes go back to Git
+	git.mu.Lock()
+	git.down = false
+	git.mu.Unlock()
+	s.checkFailover()
+	assert.Equal(t, "standby", s.failoverHealth(ctx))
+
+	entries, err = journal.Entries(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+	stored, err = git.GetVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.2", stored.Current)
+	stored, err = git.GetVersion(ctx, "2-web")
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", stored.Current)
+
+	_, err = s.IncrementVersion(ctx, "1-api", models.IncrementTypePatch, "")
+	require.NoError(t, err)
+	stored, err = git.GetVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.3", stored.Current)
+}
+
+func TestFailover_RejectedPushIsNotCommittedAgain(t *testing.T) {
+	s, git, journal := newFailoverService(t, filepath.Join(t.TempDir(), "journal.jsonl"))
+	ctx := context.Background()
+
+	git.mu.Lock()
+	git.down = true
+	git.mu.Unlock()
+	s.checkFailover()
+	for _, appID := range []string{"1-api", "2-web", "3-cli"} {
+		_, err := s.IncrementVersion(ctx, appID, models.IncrementTypePatch, "")
+		require.NoError(t, err)
+	}
+
+	// The first entry is committed but its push rejected
+	git.mu.Lock()
+	git.down = false
+	git.rejects = 1
+	git.writes = make(map[string]int)
+	git.mu.Unlock()
+
+	entries, err := journal.Entries(ctx)
+	require.NoError(t, err)
+	require.Len(t, entries, 6)
+	rejected := entries[0].AppID
+	journaledForRejected := 0
+	for _, entry := range entries {
+		if entry.AppID == rejected {
+			journaledForRejected++
+		}
+	}
+
+	s.checkFailover()
+	assert.Contains(t, s.failoverHealth(ctx), "degraded", "still failed over after the rejected push")
+
+	s.checkFailover()
+	assert.Equal(t, "standby", s.failoverHealth(ctx))
+
+	git.mu.Lock()
+	defer git.mu.Unlock()
+	assert.Equal(t, 1, git.pushes, "the committed entry is pushed")
+	assert.Equal(t, journaledForRejected, git.writes[rejected], "the committed entry is not committed again")
+	entries, err = journal.Entries(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestFailover_RecoversJournalAtStartup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	ctx := context.Background()
+
+	// A previous run journaled a write and stopped before replaying it
+	previous, _, _ := newFailoverService(t, path)
+	previous.checkFailover()
+	require.NoError(t, previous.failoverOpts.Journal.Append(ctx, []models.JournalEntry{{
+		AppID:      "1-api",
+		Version:    &models.AppVersion{Current: "4.2.0", ProjectID: "1", AppName: "api"},
+		RecordedAt: time.Now(),
+	}}))
+
+	s, git, journal := newFailoverService(t, path)
+	require.NoError(t, s.Initialize(ctx))
+
+	// The journaled write is served and writes stay failed over
+	current, err := s.GetVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Equal(t, "4.2.0", current.Current)
+	assert.Contains(t, s.failoverHealth(ctx), "degraded")
+	stored, err := git.GetVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Nil(t, stored)
+
+	s.checkFailover()
+	assert.Equal(t, "standby", s.failoverHealth(ctx))
+	stored, err = git.GetVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Equal(t, "4.2.0", stored.Current)
+	entries, err := journal.Entries(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
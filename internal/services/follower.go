@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// FollowerOptions configures read-only follower mode. A follower serves reads
+// from its own Redis and never writes to Git; mutations are proxied to the
+// primary by the HTTP layer.
+type FollowerOptions struct {
+	Enabled bool
+
+	// SyncInterval is how often Redis is rebuilt from a fresh Git pull; zero
+	// disables syncing for deployments that populate Redis by other means
+	SyncInterval time.Duration
+}
+
+// followerSyncStatus records the outcome of follower syncs for health checks
+type followerSyncStatus struct {
+	lastSuccess time.Time
+	lastError   error
+}
+
+// SyncFromGit pulls the versions file and rebuilds the Redis cache from it.
+// Followers call it periodically to pick up writes made on the primary.
+func (s *VersionService) SyncFromGit(ctx context.Context) error {
+	versions, err := s.git.ListVersions(ctx)
+	if err == nil {
+		err = s.redis.RebuildCache(ctx, versions)
+		s.devCache.invalidateAll()
+	}
+
+	s.followerMu.Lock()
+	defer s.followerMu.Unlock()
+	s.followerSync.lastError = err
+	if err != nil {
+		return fmt.Errorf("failed to sync versions from Git: %w", err)
+	}
+	s.followerSync.lastSuccess = time.Now()
+
+	s.logger.WithField("count", len(versions)).Debug("Follower synced from Git")
+	return nil
+}
+
+func (s *VersionService) periodicFollowerSync() {
+	ticker := time.NewTicker(s.follower.SyncInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		if err := s.SyncFromGit(ctx); err != nil {
+			s.logger.WithError(err).Error("Follower sync failed")
+		}
+		cancel()
+	}
+}
+
+// followerHealth reports follower sync status. Syncing is degraded once three
+// intervals pass without a successful sync; like freshness it never makes
+// the replica unhealthy, since stale reads beat no reads.
+func (s *VersionService) followerHealth() string {
+	if s.follower.SyncInterval <= 0 {
+		return "healthy"
+	}
+
+	s.followerMu.Lock()
+	status := s.followerSync
+	s.followerMu.Unlock()
+
+	if time.Since(status.lastSuccess) <= 3*s.follower.SyncInterval {
+		return "healthy"
+	}
+	if status.lastError != nil {
+		return fmt.Sprintf("degraded: last sync at %s, last error: %v", status.lastSuccess.Format(time.RFC3339), status.lastError)
+	}
+	return fmt.Sprintf("degraded: last sync at %s", status.lastSuccess.Format(time.RFC3339))
+}
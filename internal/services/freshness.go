@@ -0,0 +1,427 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/company/version-service/internal/middleware"
+	"github.com/company/version-service/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// FreshnessOptions configures the write freshness SLO: Objective is the share
+// of writes that must be pushed to Git within Target of their Redis write
+type FreshnessOptions struct {
+	Target    time.Duration
+	Objective float64
+}
+
+// Freshness SLO defaults
+const (
+	DefaultFreshnessTarget    = time.Minute
+	DefaultFreshnessObjective = 0.99
+)
+
+// freshnessCheckInterval is how often overdue writes are counted, gauges are
+// refreshed and burn-rate rules are evaluated
+const freshnessCheckInterval = 30 * time.Second
+
+// burnRateRule fires when both its long and short window burn the error
+// budget faster than threshold. The short window stops the alert quickly
+// once the problem is fixed.
+type burnRateRule struct {
+	long      time.Duration
+	short     time.Duration
+	threshold float64
+}
+
+// freshnessBurnRules are the standard fast-burn (2% of a 30-day budget in an
+// hour) and slow-burn (5% in six hours) rules
+var freshnessBurnRules = []burnRateRule{
+	{long: time.Hour, short: 5 * time.Minute, threshold: 14.4},
+	{long: 6 * time.Hour, short: 30 * time.Minute, threshold: 6},
+}
+
+type pendingWrite struct {
+	writtenAt time.Time
+	committed bool
+	breached  bool
+}
+
+type appFreshness struct {
+	pending       []*pendingWrite
+	lastWrittenAt time.Time
+	lastDurableAt time.Time
+	lastLag       time.Duration
+}
+
+type freshnessCounts struct {
+	good int64
+	bad  int64
+}
+
+// freshnessTracker follows every Redis write until it is pushed to Git and
+// counts each write once against the SLO: good when pushed within the
+// target, bad when confirmed late or still pending past it. Counts are kept
+// in per-minute buckets for the longest burn-rate window.
+type freshnessTracker struct {
+	opts FreshnessOptions
+
+	mu      sync.Mutex
+	apps    map[string]*appFreshness
+	buckets map[int64]*freshnessCounts
+}
+
+func newFreshnessTracker(opts FreshnessOptions) *freshnessTracker {
+	if opts.Target <= 0 {
+		opts.Target = DefaultFreshnessTarget
+	}
+	if opts.Objective <= 0 || opts.Objective >= 1 {
+		opts.Objective = DefaultFreshnessObjective
+	}
+
+	return &freshnessTracker{
+		opts:    opts,
+		apps:    make(map[string]*appFreshness),
+		buckets: make(map[int64]*freshnessCounts),
+	}
+}
+
+// written records Redis writes of appIDs and returns the write time, which
+// identifies them when they are confirmed
+func (f *freshnessTracker) written(appIDs []string) time.Time {
+	now := time.Now()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, appID := range appIDs {
+		app := f.app(appID)
+		app.pending = append(app.pending, &pendingWrite{writtenAt: now})
+		app.lastWrittenAt = now
+	}
+	return now
+}
+
+// committed marks writes of appIDs up to writtenAt as committed locally but
+// not pushed; the next successful push confirms them
+func (f *freshnessTracker) committed(appIDs []string, writtenAt time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, appID := range appIDs {
+		for _, write := range f.app(appID).pending {
+			if !write.writtenAt.After(writtenAt) {
+				write.committed = true
+			}
+		}
+	}
+}
+
+// durable confirms the writes of appIDs up to writtenAt as pushed
+func (f *freshnessTracker) durable(appIDs []string, writtenAt time.Time) {
+	now := time.Now()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, appID := range appIDs {
+		f.confirmLocked(f.app(appID), now, func(write *pendingWrite) bool {
+			return !write.writtenAt.After(writtenAt)
+		})
+	}
+}
+
+// discarded forgets writes of appIDs up to writtenAt that will never become
+// durable, without counting them for or against the objective
+func (f *freshnessTracker) discarded(appIDs []string, writtenAt time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, appID := range appIDs {
+		app := f.app(appID)
+		remaining := app.pending[:0]
+		for _, write := range app.pending {
+			if write.writtenAt.After(writtenAt) {
+				remaining = append(remaining, write)
+			}
+		}
+		app.pending = remaining
+	}
+}
+
+// pushed confirms every write that was committed locally before a
+// successful push of pending commits
+func (f *freshnessTracker) pushed() {
+	now := time.Now()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, app := range f.apps {
+		f.confirmLocked(app, now, func(write *pendingWrite) bool {
+			return write.committed
+		})
+	}
+}
+
+func (f *freshnessTracker) confirmLocked(app *appFreshness, now time.Time, confirmed func(*pendingWrite) bool) {
+	remaining := app.pending[:0]
+	for _, write := range app.pending {
+		if !confirmed(write) {
+			remaining = append(remaining, write)
+			continue
+		}
+
+		lag := now.Sub(write.writtenAt)
+		middleware.RecordFreshnessLag(lag)
+		if !write.breached {
+			f.countLocked(now, lag <= f.opts.Target)
+		}
+		app.lastDurableAt = now
+		app.lastLag = lag
+	}
+	app.pending = remaining
+}
+
+// hasPending reports whether an app has writes not yet pushed to Git
+func (f *freshnessTracker) hasPending(appID string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	app, ok := f.apps[appID]
+	return ok && len(app.pending) > 0
+}
+
+// checkOverdue counts writes pending past the target as bad, once each, and
+// returns the oldest pending write's age and app
+func (f *freshnessTracker) checkOverdue(now time.Time) (time.Duration, string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var worstLag time.Duration
+	var worstApp string
+	for appID, app := range f.apps {
+		for _, write := range app.pending {
+			lag := now.Sub(write.writtenAt)
+			if lag > f.opts.Target && !write.breached {
+				write.breached = true
+				f.countLocked(now, false)
+			}
+			if lag > worstLag {
+				worstLag, worstApp = lag, appID
+			}
+		}
+	}
+	f.pruneLocked(now)
+	return worstLag, worstApp
+}
+
+func (f *freshnessTracker) countLocked(at time.Time, good bool) {
+	middleware.RecordFreshnessResult(good)
+
+	minute := at.Unix() / 60
+	counts, ok := f.buckets[minute]
+	if !ok {
+		counts = &freshnessCounts{}
+		f.buckets[minute] = counts
+	}
+	if good {
+		counts.good++
+	} else {
+		counts.bad++
+	}
+}
+
+func (f *freshnessTracker) pruneLocked(now time.Time) {
+	oldest := now.Add(-f.longestWindow()).Unix() / 60
+	for minute := range f.buckets {
+		if minute < oldest {
+			delete(f.buckets, minute)
+		}
+	}
+}
+
+func (f *freshnessTracker) longestWindow() time.Duration {
+	var longest time.Duration
+	for _, rule := range freshnessBurnRules {
+		if rule.long > longest {
+			longest = rule.long
+		}
+	}
+	return longest
+}
+
+// burnRate returns the error budget burn rate over the window ending now
+func (f *freshnessTracker) burnRate(window time.Duration, now time.Time, threshold float64) models.BurnRate {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	since := now.Add(-window).Unix() / 60
+	result := models.BurnRate{Window: window.String(), Threshold: threshold}
+	for minute, counts := range f.buckets {
+		if minute >= since {
+			result.Good += counts.good
+			result.Bad += counts.bad
+		}
+	}
+	if total := result.Good + result.Bad; total > 0 {
+		result.Rate = float64(result.Bad) / float64(total) / (1 - f.opts.Objective)
+	}
+	return result
+}
+
+func (f *freshnessTracker) app(appID string) *appFreshness {
+	app, ok := f.apps[appID]
+	if !ok {
+		app = &appFreshness{}
+		f.apps[appID] = app
+	}
+	return app
+}
+
+// snapshot returns the per-app freshness, worst pending lag first
+func (f *freshnessTracker) snapshot(now time.Time) []models.AppFreshness {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	apps := make([]models.AppFreshness, 0, len(f.apps))
+	for appID, app := range f.apps {
+		entry := models.AppFreshness{
+			AppID:          appID,
+			LastWrittenAt:  app.lastWrittenAt,
+			LastLagSeconds: app.lastLag.Seconds(),
+			Pending:        len(app.pending),
+		}
+		if !app.lastDurableAt.IsZero() {
+			durableAt := app.lastDurableAt
+			entry.LastDurableAt = &durableAt
+		}
+		if len(app.pending) > 0 {
+			entry.PendingLagSeconds = now.Sub(app.pending[0].writtenAt).Seconds()
+		}
+		apps = append(apps, entry)
+	}
+
+	sort.Slice(apps, func(i, j int) bool {
+		if apps[i].PendingLagSeconds != apps[j].PendingLagSeconds {
+			return apps[i].PendingLagSeconds > apps[j].PendingLagSeconds
+		}
+		return apps[i].AppID < apps[j].AppID
+	})
+	return apps
+}
+
+// GetFreshnessReport reports write durability against the freshness SLO.
+// Figures cover writes handled by this replica since it started.
+func (s *VersionService) GetFreshnessReport(ctx context.Context) (*models.FreshnessReport, error) {
+	now := time.Now()
+	worstLag, worstApp := s.freshness.checkOverdue(now)
+
+	report := &models.FreshnessReport{
+		Target:          s.freshness.opts.Target.String(),
+		Objective:       s.freshness.opts.Objective,
+		WorstLagSeconds: worstLag.Seconds(),
+		WorstApp:        worstApp,
+		BurnRates:       make([]models.BurnRate, 0, 2*len(freshnessBurnRules)),
+		Apps:            s.freshness.snapshot(now),
+		GeneratedAt:     now,
+	}
+	for _, app := range report.Apps {
+		report.Pending += app.Pending
+	}
+	for _, rule := range freshnessBurnRules {
+		report.BurnRates = append(report.BurnRates,
+			s.freshness.burnRate(rule.long, now, rule.threshold),
+			s.freshness.burnRate(rule.short, now, rule.threshold))
+	}
+
+	return report, nil
+}
+
+// freshnessHealth summarizes the oldest unpushed write for the health check.
+// It reports degraded rather than unhealthy: restarting would not help and
+// could lose local commits.
+func (s *VersionService) freshnessHealth() string {
+	worstLag, worstApp := s.freshness.checkOverdue(time.Now())
+	if worstLag > s.freshness.opts.Target {
+		return fmt.Sprintf("degraded: oldest unpushed write (%s) is %s old, target %s",
+			worstApp, worstLag.Round(time.Second), s.freshness.opts.Target)
+	}
+	return fmt.Sprintf("healthy: worst lag %s", worstLag.Round(time.Second))
+}
+
+// monitorFreshness periodically refreshes the freshness gauges and fires
+// burn-rate alerts
+func (s *VersionService) monitorFreshness() {
+	ticker := time.NewTicker(freshnessCheckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		now := time.Now()
+		worstLag, worstApp := s.freshness.checkOverdue(now)
+		middleware.SetFreshnessWorstLag(worstLag)
+
+		for _, rule := range freshnessBurnRules {
+			long := s.freshness.burnRate(rule.long, now, rule.threshold)
+			short := s.freshness.burnRate(rule.short, now, rule.threshold)
+			middleware.SetFreshnessBurnRate(long.Window, long.Rate)
+			middleware.SetFreshnessBurnRate(short.Window, short.Rate)
+
+			if long.Rate >= rule.threshold && short.Rate >= rule.threshold {
+				s.alertFreshness(long, worstApp, worstLag)
+			}
+		}
+	}
+}
+
+// alertFreshness logs a burn-rate breach and notifies the alert webhook, at
+// most once per window per cooldown period
+func (s *VersionService) alertFreshness(burn models.BurnRate, worstApp string, worstLag time.Duration) {
+	key := "freshness:" + burn.Window
+	s.alertMu.Lock()
+	if last, ok := s.lastAlerts[key]; ok && time.Since(last) < alertCooldown {
+		s.alertMu.Unlock()
+		return
+	}
+	s.lastAlerts[key] = time.Now()
+	s.alertMu.Unlock()
+
+	fields := logrus.Fields{
+		"window":    burn.Window,
+		"burn_rate": fmt.Sprintf("%.1f", burn.Rate),
+		"threshold": burn.Threshold,
+		"worst_app": worstApp,
+		"worst_lag": worstLag.Round(time.Second).String(),
+	}
+	s.logger.WithFields(fields).Warn("Freshness error budget burning too fast")
+
+	if s.notifier == nil {
+		return
+	}
+
+	alert := &models.FreshnessAlert{
+		EventMeta: models.NewEventMeta(models.EventFreshnessAlert),
+		Text: fmt.Sprintf("Git freshness SLO burning at %.1fx over %s (threshold %.1fx): writes are not pushed within %s",
+			burn.Rate, burn.Window, burn.Threshold, s.freshness.opts.Target),
+		Window:    burn.Window,
+		BurnRate:  burn.Rate,
+		Threshold: burn.Threshold,
+		Objective: s.freshness.opts.Objective,
+		Target:    s.freshness.opts.Target.String(),
+		WorstApp:  worstApp,
+		WorstLag:  worstLag.Seconds(),
+		Timestamp: time.Now(),
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := s.notifier.Notify(ctx, alert); err != nil {
+			s.logger.WithError(err).WithFields(fields).Warn("Failed to send freshness alert")
+		}
+	}()
+}
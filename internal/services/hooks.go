@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/company/version-service/internal/clients"
+	"github.com/company/version-service/internal/middleware"
+	"github.com/company/version-service/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultHookTimeout = 5 * time.Second
+
+// HookOptions configures increment hooks. Pre-increment hooks are called in
+// order before a bump is stored and can veto it; post-increment hooks are
+// notified afterwards without waiting. With FailOpen, an unreachable or
+// failing pre-increment hook lets the increment through instead of failing it.
+type HookOptions struct {
+	PreIncrement  []*clients.WebhookClient
+	PostIncrement []*clients.WebhookClient
+	Timeout       time.Duration
+	FailOpen      bool
+}
+
+// hookName identifies a hook in logs and metrics by host only, since hook
+// URLs often embed credentials
+func hookName(hook *clients.WebhookClient) string {
+	parsed, err := url.Parse(hook.URL())
+	if err != nil || parsed.Host == "" {
+		return "invalid"
+	}
+	return parsed.Host
+}
+
+func (s *VersionService) hookTimeout() time.Duration {
+	if s.hooks.Timeout > 0 {
+		return s.hooks.Timeout
+	}
+	return defaultHookTimeout
+}
+
+func newIncrementHookEvent(event, appID string, current *models.AppVersion, incrementType models.IncrementType, newVersion string) *models.IncrementHookEvent {
+	return &models.IncrementHookEvent{
+		EventMeta:      models.NewEventMeta(event),
+		AppID:          appID,
+		ProjectID:      current.ProjectID,
+		AppName:        current.AppName,
+		Type:           incrementType,
+		CurrentVersion: current.Current,
+		NewVersion:     newVersion,
+		Timestamp:      time.Now(),
+	}
+}
+
+// runPreIncrementHooks asks every pre-increment hook to approve a bump. The
+// first veto wins and is returned as ErrHookRejected.
+func (s *VersionService) runPreIncrementHooks(ctx context.Context, appID string, current *models.AppVersion, incrementType models.IncrementType, newVersion string) error {
+	if len(s.hooks.PreIncrement) == 0 {
+		return nil
+	}
+
+	event := newIncrementHookEvent(models.EventPreIncrement, appID, current, incrementType, newVersion)
+
+	for _, hook := range s.hooks.PreIncrement {
+		hookCtx, cancel := context.WithTimeout(ctx, s.hookTimeout())
+		start := time.Now()
+
+		decision := models.HookDecision{Allow: true}
+		err := hook.Post(hookCtx, event, &decision)
+		cancel()
+
+		name := hookName(hook)
+		fields := logrus.Fields{
+			"app_id":      appID,
+			"hook":        name,
+			"new_version": newVersion,
+		}
+
+		if err != nil {
+			middleware.RecordHookCall("pre", name, "error", time.Since(start))
+			if s.hooks.FailOpen {
+				s.logger.WithError(err).WithFields(fields).Warn("Pre-increment hook failed, allowing increment")
+				continue
+			}
+			s.logger.WithError(err).WithFields(fields).Error("Pre-increment hook failed")
+			// The underlying error can echo the hook URL, so it is only logged
+			return fmt.Errorf("%w: %s", ErrHookFailed, name)
+		}
+
+		if !decision.Allow {
+			middleware.RecordHookCall("pre", name, "rejected", time.Since(start))
+			s.logger.WithFields(fields).WithField("reason", decision.Reason).Info("Increment rejected by pre-increment hook")
+			return fmt.Errorf("%w: %s", ErrHookRejected, decision.Reason)
+		}
+
+		middleware.RecordHookCall("pre", name, "allowed", time.Since(start))
+	}
+
+	return nil
+}
+
+// firePostIncrementHooks notifies every post-increment hook and the
+// project's webhook subscriptions in the background
+func (s *VersionService) firePostIncrementHooks(appID string, previous *models.AppVersion, incrementType models.IncrementType, newVersion string) {
+	event := newIncrementHookEvent(models.EventPostIncrement, appID, previous, incrementType, newVersion)
+	s.notifyProjectWebhooks(previous.ProjectID, models.EventPostIncrement, event)
+
+	for _, hook := range s.hooks.PostIncrement {
+		go func(hook *clients.WebhookClient) {
+			ctx, cancel := context.WithTimeout(context.Background(), s.hookTimeout())
+			defer cancel()
+
+			start := time.Now()
+			if err := hook.Notify(ctx, event); err != nil {
+				middleware.RecordHookCall("post", hookName(hook), "error", time.Since(start))
+				s.logger.WithError(err).WithFields(logrus.Fields{
+					"app_id": appID,
+					"hook":   hookName(hook),
+				}).Warn("Post-increment hook failed")
+				return
+			}
+			middleware.RecordHookCall("post", hookName(hook), s.sentOutcome(), time.Since(start))
+		}(hook)
+	}
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+
+	"github.com/company/version-service/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// MaxIdempotencyKeyLength bounds client-supplied idempotency keys
+const MaxIdempotencyKeyLength = 255
+
+func (s *VersionService) idempotencyStore() storage.IdempotencyStore {
+	store, ok := s.redis.(storage.IdempotencyStore)
+	if !ok {
+		return nil
+	}
+	return store
+}
+
+// replayedVersion returns the version previously computed for an idempotency
+// key. Lookup failures are logged and treated as a miss so Redis problems
+// never block increments.
+func (s *VersionService) replayedVersion(ctx context.Context, appID, key string) (string, bool) {
+	store := s.idempotencyStore()
+	if key == "" || store == nil {
+		return "", false
+	}
+
+	version, found, err := store.GetIdempotentResult(ctx, appID, key)
+	if err != nil {
+		s.logger.WithError(err).WithField("app_id", appID).Warn("Failed to look up idempotency key")
+		return "", false
+	}
+
+	return version, found
+}
+
+func (s *VersionService) rememberVersion(ctx context.Context, appID, key, version string) {
+	store := s.idempotencyStore()
+	if key == "" || store == nil || s.idempotencyTTL <= 0 {
+		return
+	}
+
+	if err := store.SetIdempotentResult(ctx, appID, key, version, s.idempotencyTTL); err != nil {
+		s.logger.WithError(err).WithFields(logrus.Fields{
+			"app_id":  appID,
+			"version": version,
+		}).Warn("Failed to store idempotency key")
+	}
+}
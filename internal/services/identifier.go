@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/company/version-service/internal/clients"
+	"github.com/company/version-service/internal/models"
+)
+
+// parseAppID validates appID against the configured scheme
+func (s *VersionService) parseAppID(appID string) (models.AppIdentifier, error) {
+	id, err := s.idScheme.Parse(appID)
+	if err != nil {
+		return id, fmt.Errorf("invalid app ID: %w", err)
+	}
+	return id, nil
+}
+
+// identify parses appID and, for opaque IDs, fills in the project and app
+// name from the registration metadata stored with the app. Unregistered
+// opaque IDs fail with ErrAppNotRegistered.
+func (s *VersionService) identify(ctx context.Context, appID string) (models.AppIdentifier, error) {
+	id, err := s.parseAppID(appID)
+	if err != nil || !id.Opaque() {
+		return id, err
+	}
+
+	stored, err := s.lookupVersion(ctx, appID)
+	if errors.Is(err, ErrAppNotFound) && !errors.Is(err, ErrAppDeleted) {
+		return id, fmt.Errorf("%w: %s", ErrAppNotRegistered, appID)
+	} else if err != nil {
+		return id, err
+	}
+
+	return identifierFromRecord(id, stored)
+}
+
+// AppProjectID returns the project appID belongs to: the one the ID encodes
+// or, for opaque IDs, the one the app was registered with. Opaque IDs of
+// apps that aren't registered belong to no project yet and return "".
+func (s *VersionService) AppProjectID(ctx context.Context, appID string) (string, error) {
+	id, err := s.identify(ctx, appID)
+	if errors.Is(err, ErrAppNotRegistered) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return id.ProjectID, nil
+}
+
+// identifierFromRecord completes an opaque identifier from a stored record
+func identifierFromRecord(id models.AppIdentifier, version *models.AppVersion) (models.AppIdentifier, error) {
+	if !id.Opaque() {
+		return id, nil
+	}
+	if version == nil || version.ProjectID == "" || version.AppName == "" {
+		return id, fmt.Errorf("%w: %s has no project or app name", ErrAppNotRegistered, id.ID)
+	}
+	id.ProjectID = version.ProjectID
+	id.AppName = version.AppName
+	return id, nil
+}
+
+// gitLabAppIdentifier returns the identifier of the app registered for a
+// GitLab project. The path scheme refers to projects by their full path, the
+// others by numeric ID.
+func (s *VersionService) gitLabAppIdentifier(project clients.GitLabProject) (models.AppIdentifier, error) {
+	projectID := strconv.Itoa(project.ID)
+	if s.idScheme.Name() == models.IDSchemePath {
+		projectID = project.PathWithNamespace
+	}
+
+	appID, err := s.idScheme.Format(projectID, project.Path)
+	if err != nil {
+		return models.AppIdentifier{}, err
+	}
+
+	return models.AppIdentifier{ID: appID, ProjectID: projectID, AppName: project.Path}, nil
+}
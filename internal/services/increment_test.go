@@ -0,0 +1,268 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/company/version-service/internal/clients"
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// racingCache stands in for a Redis cache shared with another replica, which
+// saves a patch release of its own just before each of the next races
+// conditional writes
+type racingCache struct {
+	*storage.MemoryStorage
+	races int
+}
+
+func (r *racingCache) SetVersionIf(ctx context.Context, appID string, expected, version *models.AppVersion) error {
+	if err := r.race(ctx, appID); err != nil {
+		return err
+	}
+	return r.MemoryStorage.SetVersionIf(ctx, appID, expected, version)
+}
+
+// SetVersionsIf races the last app of the batch, so the ones before it
+// would be written by a cache writing apps one at a time
+func (r *racingCache) SetVersionsIf(ctx context.Context, expected, versions map[string]*models.AppVersion) error {
+	appIDs := make([]string, 0, len(versions))
+	for appID := range versions {
+		appIDs = append(appIDs, appID)
+	}
+	sort.Strings(appIDs)
+	if err := r.race(ctx, appIDs[len(appIDs)-1]); err != nil {
+		return err
+	}
+	return r.MemoryStorage.SetVersionsIf(ctx, expected, versions)
+}
+
+// race saves the other replica's release of appID if a race is left
+func (r *racingCache) race(ctx context.Context, appID string) error {
+	if r.races == 0 {
+		return nil
+	}
+	r.races--
+	current, err := r.GetVersion(ctx, appID)
+	if err != nil {
+		return err
+	}
+	winner := *current
+	winner.Current = "1.0.1"
+	winner.LastUpdated = time.Now()
+	return r.SetVersion(ctx, appID, &winner)
+}
+
+func TestIncrementVersion_ConcurrentReplica(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	ctx := context.Background()
+
+	newService := func(races int) (*VersionService, *racingCache) {
+		memory, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+		require.NoError(t, err)
+		durable, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+		require.NoError(t, err)
+		cache := &racingCache{MemoryStorage: memory}
+		require.NoError(t, cache.SetVersion(ctx, "1-api", &models.AppVersion{Current: "1.0.0", ProjectID: "1", AppName: "api", LastUpdated: time.Now()}))
+		cache.races = races
+		return NewVersionService(cache, durable, nil, logger, Options{}), cache
+	}
+
+	t.Run("computed again from the winner", func(t *testing.T) {
+		s, cache := newService(1)
+		response, err := s.IncrementVersion(ctx, "1-api", models.IncrementTypeMinor, "")
+		require.NoError(t, err)
+		assert.Equal(t, "1.1.0", response.Version)
+		assert.Zero(t, cache.races)
+
+		stored, err := cache.GetVersion(ctx, "1-api")
+		require.NoError(t, err)
+		assert.Equal(t, "1.1.0", stored.Current)
+	})
+
+	t.Run("gives up when the app keeps changing", func(t *testing.T) {
+		s, cache := newService(maxIncrementAttempts)
+		_, err := s.IncrementVersion(ctx, "1-api", models.IncrementTypeMinor, "")
+		assert.ErrorIs(t, err, ErrRevisionConflict)
+
+		stored, err := cache.GetVersion(ctx, "1-api")
+		require.NoError(t, err)
+		assert.Equal(t, "1.0.1", stored.Current)
+	})
+}
+
+func TestIncrementVersions_ConcurrentReplica(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	ctx := context.Background()
+
+	newService := func(races int) (*VersionService, *racingCache) {
+		memory, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+		require.NoError(t, err)
+		durable, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+		require.NoError(t, err)
+		cache := &racingCache{MemoryStorage: memory}
+		for _, appID := range []string{"1-api", "1-web"} {
+			require.NoError(t, cache.SetVersion(ctx, appID, &models.AppVersion{Current: "1.0.0", ProjectID: "1", AppName: appID[2:], LastUpdated: time.Now()}))
+		}
+		cache.races = races
+		return NewVersionService(cache, durable, nil, logger, Options{}), cache
+	}
+
+	t.Run("computed again from the winner", func(t *testing.T) {
+		s, cache := newService(1)
+		response, err := s.IncrementVersions(ctx, []string{"1-api", "1-web"}, models.IncrementTypeMinor)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"1-api": "1.1.0", "1-web": "1.1.0"}, response.Versions)
+		assert.Zero(t, cache.races)
+
+		stored, err := cache.ListVersions(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "1.1.0", stored["1-api"].Current)
+		assert.Equal(t, "1.1.0", stored["1-web"].Current)
+	})
+
+	t.Run("gives up when an app keeps changing", func(t *testing.T) {
+		s, cache := newService(maxIncrementAttempts)
+		_, err := s.IncrementVersions(ctx, []string{"1-api", "1-web"}, models.IncrementTypeMinor)
+		assert.ErrorIs(t, err, ErrRevisionConflict)
+
+		stored, err := cache.ListVersions(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "1.0.0", stored["1-api"].Current, "apps of a failed batch are not written")
+		assert.Equal(t, "1.0.1", stored["1-web"].Current)
+	})
+}
+
+// TestIncrementVersions_HooksOutsideLock checks that other requests are
+// served while a batch waits on its pre-increment hook, and that an app
+// changed meanwhile is incremented from its new version
+func TestIncrementVersions_HooksOutsideLock(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	ctx := context.Background()
+
+	// The first hook call, made by the batch, waits until released
+	var called atomic.Bool
+	waiting, release := make(chan struct{}), make(chan struct{})
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if called.CompareAndSwap(false, true) {
+			close(waiting)
+			<-release
+		}
+		json.NewEncoder(w).Encode(models.HookDecision{Allow: true})
+	}))
+	defer hook.Close()
+
+	cache, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	durable, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	for _, appID := range []string{"1-api", "1-web", "1-db"} {
+		require.NoError(t, cache.SetVersion(ctx, appID, &models.AppVersion{Current: "1.0.0", ProjectID: "1", AppName: appID[2:], LastUpdated: time.Now()}))
+	}
+	s := NewVersionService(cache, durable, nil, logger, Options{
+		Hooks: HookOptions{PreIncrement: []*clients.WebhookClient{clients.NewWebhookClient(hook.URL, logger)}},
+	})
+
+	type result struct {
+		response *models.BatchIncrementResponse
+		err      error
+	}
+	done := make(chan result, 1)
+	go func() {
+		response, err := s.IncrementVersions(ctx, []string{"1-api", "1-web"}, models.IncrementTypePatch)
+		done <- result{response, err}
+	}()
+	<-waiting
+
+	single := make(chan error, 1)
+	go func() {
+		_, err := s.IncrementVersion(ctx, "1-db", models.IncrementTypePatch, "")
+		single <- err
+	}()
+	select {
+	case err := <-single:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Error("increment blocked while the batch waited on its hook")
+	}
+
+	// Another replica saves a release of 1-api while the hook runs
+	require.NoError(t, cache.SetVersion(ctx, "1-api", &models.AppVersion{Current: "1.0.7", ProjectID: "1", AppName: "api", LastUpdated: time.Now()}))
+	close(release)
+
+	batch := <-done
+	require.NoError(t, batch.err)
+	assert.Equal(t, map[string]string{"1-api": "1.0.8", "1-web": "1.0.1"}, batch.response.Versions)
+}
+
+func TestVersionChanges_KeepRecordFields(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	ctx := context.Background()
+
+	cache, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	durable, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	// Rollbacks read the history of durable storage, so it is written first
+	s := NewVersionService(cache, durable, nil, logger, Options{WriteThrough: true})
+
+	original := &models.AppVersion{
+		Current:       "1.0.0",
+		ProjectID:     "1",
+		AppName:       "api",
+		RepoName:      "api-repo",
+		Aliases:       map[string]string{"stable": "1.0.0"},
+		Annotations:   map[string]string{"team": "payments"},
+		Policy:        &models.AppPolicy{ReservedVersions: []string{"9.9.9"}},
+		RenamedFrom:   []string{"1-legacy"},
+		DefaultBranch: "main",
+		WebURL:        "https://gitlab.example.com/group/api",
+		Lifecycle:     models.LifecycleDeprecated,
+		LastUpdated:   time.Now(),
+	}
+	require.NoError(t, durable.SetVersion(ctx, "1-api", original))
+	require.NoError(t, cache.SetVersion(ctx, "1-api", original))
+
+	assertKept := func(t *testing.T, version string) {
+		t.Helper()
+		stored, err := durable.GetVersion(ctx, "1-api")
+		require.NoError(t, err)
+		expected := *original
+		expected.Current = version
+		expected.LastUpdated = stored.LastUpdated
+		assert.Equal(t, &expected, stored)
+	}
+
+	_, err = s.IncrementVersion(ctx, "1-api", models.IncrementTypePatch, "")
+	require.NoError(t, err)
+	assertKept(t, "1.0.1")
+
+	_, err = s.RollbackVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assertKept(t, "1.0.0")
+
+	_, err = s.IncrementVersion(ctx, "1-api", models.IncrementTypeMinor, "")
+	require.NoError(t, err)
+	_, err = s.DecrementVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assertKept(t, "1.0.0")
+
+	_, err = s.IncrementVersions(ctx, []string{"1-api"}, models.IncrementTypeMajor)
+	require.NoError(t, err)
+	assertKept(t, "2.0.0")
+}
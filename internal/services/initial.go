@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/pkg/semver"
+	"github.com/sirupsen/logrus"
+)
+
+// graduationVersion is the first stable version an app in initial
+// development graduates to
+const graduationVersion = "1.0.0"
+
+// effectiveIncrement returns the increment a request for requested performs
+// on current: the policy's strategy when the request names none, and a
+// minor bump instead of a major one while an app in initial development is
+// below 1.0.0 (0.4.0 → 0.5.0)
+func effectiveIncrement(policy *models.AppPolicy, current string, requested models.IncrementType) models.IncrementType {
+	incrementType := policy.IncrementFor(requested)
+	if incrementType != models.IncrementTypeMajor || policy == nil || !policy.InitialDevelopment {
+		return incrementType
+	}
+	if v, err := semver.Parse(current); err == nil && v.Major == 0 {
+		return models.IncrementTypeMinor
+	}
+	return incrementType
+}
+
+// validateInitialDevelopment checks that a policy only opts into initial
+// development semantics under the semver scheme
+func validateInitialDevelopment(policy *models.AppPolicy) error {
+	if policy == nil || !policy.InitialDevelopment || policy.VersionScheme() == models.VersionSchemeSemVer {
+		return nil
+	}
+	return fmt.Errorf("%w: initial development needs the %s scheme, not %s", ErrUnsupportedScheme, models.VersionSchemeSemVer, policy.Scheme)
+}
+
+// SetInitialDevelopment turns pre-1.0 semantics on or off for an app. While
+// on and below 1.0.0, major increments bump the minor version; the app
+// leaves 0.x only by graduating.
+func (s *VersionService) SetInitialDevelopment(ctx context.Context, appID string, enabled bool) (*models.AppVersion, error) {
+	return onApp(ctx, s, appID, func() (*models.AppVersion, error) {
+		if _, err := s.parseAppID(appID); err != nil {
+			return nil, err
+		}
+
+		current, err := s.lookupVersion(ctx, appID)
+		if err != nil {
+			return nil, err
+		}
+
+		policy := models.AppPolicy{}
+		if current.Policy != nil {
+			policy = *current.Policy
+		}
+		policy.InitialDevelopment = enabled
+		if err := validateInitialDevelopment(&policy); err != nil {
+			return nil, err
+		}
+
+		updated := *current
+		updated.Policy = &policy
+		updated.LastUpdated = time.Now()
+		if err := s.saveVersion(ctx, appID, &updated); err != nil {
+			return nil, err
+		}
+
+		s.logger.WithFields(logrus.Fields{
+			"app_id":  appID,
+			"enabled": enabled,
+		}).Info("Initial development mode updated")
+
+		return &updated, nil
+	})
+}
+
+// GraduateVersion moves an app below 1.0.0 to 1.0.0. Graduating is a major
+// increment as far as policy goes: the app must be writable, allow major
+// increments and not have 1.0.0 reserved, quotas apply and increment hooks
+// see it as a major increment.
+func (s *VersionService) GraduateVersion(ctx context.Context, appID string) (*models.GraduateResponse, error) {
+	return onApp(ctx, s, appID, func() (*models.GraduateResponse, error) {
+		id, err := s.identify(ctx, appID)
+		if err != nil {
+			return nil, err
+		}
+
+		current, err := s.lookupVersion(ctx, appID)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := checkWritable(appID, current); err != nil {
+			return nil, err
+		}
+
+		if scheme := current.Policy.VersionScheme(); scheme != models.VersionSchemeSemVer {
+			return nil, fmt.Errorf("%w: %s versions have no 1.0.0 to graduate to", ErrUnsupportedScheme, scheme)
+		}
+
+		parsed, err := semver.Parse(current.Current)
+		if err != nil {
+			return nil, fmt.Errorf("invalid current version: %w", err)
+		}
+		if parsed.Major > 0 {
+			return nil, fmt.Errorf("%w: %s is at %s", ErrAlreadyGraduated, appID, current.Current)
+		}
+
+		if !current.Policy.AllowsIncrement(models.IncrementTypeMajor) {
+			return nil, fmt.Errorf("%w: graduating is a major increment, which is not allowed for %s", ErrIncrementNotAllowed, appID)
+		}
+
+		if err := s.checkIncrementQuota(ctx, id.ProjectID, 1); err != nil {
+			return nil, err
+		}
+
+		if err := s.checkNotReserved(ctx, id.ProjectID, current.Policy, graduationVersion); err != nil {
+			return nil, err
+		}
+
+		if err := s.runPreIncrementHooks(ctx, appID, current, models.IncrementTypeMajor, graduationVersion); err != nil {
+			return nil, err
+		}
+
+		graduated := *current
+		graduated.Current = graduationVersion
+		graduated.LastUpdated = time.Now()
+		if err := s.saveVersion(ctx, appID, &graduated); err != nil {
+			return nil, err
+		}
+
+		s.recordIncrement(ctx, id.ProjectID, appID)
+		s.firePostIncrementHooks(appID, current, models.IncrementTypeMajor, graduationVersion)
+
+		s.logger.WithFields(logrus.Fields{
+			"app_id":      appID,
+			"old_version": current.Current,
+			"new_version": graduationVersion,
+		}).Info("App graduated to 1.0.0")
+
+		return &models.GraduateResponse{
+			Version:       graduationVersion,
+			GraduatedFrom: current.Current,
+		}, nil
+	})
+}
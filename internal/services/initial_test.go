@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEffectiveIncrement(t *testing.T) {
+	initial := &models.AppPolicy{InitialDevelopment: true}
+
+	tests := []struct {
+		name      string
+		policy    *models.AppPolicy
+		current   string
+		requested models.IncrementType
+		want      models.IncrementType
+	}{
+		{"no policy", nil, "0.4.0", models.IncrementTypeMajor, models.IncrementTypeMajor},
+		{"initial development below 1.0.0", initial, "0.4.0", models.IncrementTypeMajor, models.IncrementTypeMinor},
+		{"initial development after graduating", initial, "1.0.0", models.IncrementTypeMajor, models.IncrementTypeMajor},
+		{"initial development keeps patches", initial, "0.4.0", models.IncrementTypePatch, models.IncrementTypePatch},
+		{"major strategy below 1.0.0", &models.AppPolicy{InitialDevelopment: true, Strategy: "major"}, "0.4.0", models.IncrementTypeDefault, models.IncrementTypeMinor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, effectiveIncrement(tt.policy, tt.current, tt.requested))
+		})
+	}
+}
+
+func TestValidateInitialDevelopment(t *testing.T) {
+	assert.NoError(t, validateInitialDevelopment(&models.AppPolicy{InitialDevelopment: true}))
+	assert.NoError(t, validateInitialDevelopment(&models.AppPolicy{Scheme: models.VersionSchemeCalVer}))
+	assert.ErrorIs(t, validateInitialDevelopment(&models.AppPolicy{InitialDevelopment: true, Scheme: models.VersionSchemeCalVer}), ErrUnsupportedScheme)
+}
+
+func TestGraduateVersion(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	cache, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	durable, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	s := NewVersionService(cache, durable, nil, logger, Options{})
+	ctx := context.Background()
+
+	policy := &models.AppPolicy{InitialDevelopment: true}
+	require.NoError(t, durable.SetVersion(ctx, "1-api", &models.AppVersion{Current: "0.4.0", ProjectID: "1", AppName: "api", Policy: policy}))
+
+	incremented, err := s.IncrementVersion(ctx, "1-api", models.IncrementTypeMajor, "")
+	require.NoError(t, err)
+	assert.Equal(t, "0.5.0", incremented.Version)
+
+	graduated, err := s.GraduateVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", graduated.Version)
+	assert.Equal(t, "0.5.0", graduated.GraduatedFrom)
+
+	_, err = s.GraduateVersion(ctx, "1-api")
+	assert.ErrorIs(t, err, ErrAlreadyGraduated)
+
+	incremented, err = s.IncrementVersion(ctx, "1-api", models.IncrementTypeMajor, "")
+	require.NoError(t, err)
+	assert.Equal(t, "2.0.0", incremented.Version)
+}
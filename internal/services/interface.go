@@ -2,16 +2,65 @@ package services
 
 import (
 	"context"
+	"time"
+
 	"github.com/company/version-service/internal/models"
 )
 
 type VersionServiceInterface interface {
 	Health(ctx context.Context) map[string]string
 	GetVersion(ctx context.Context, appID string) (*models.AppVersion, error)
-	IncrementVersion(ctx context.Context, appID string, incrementType models.IncrementType) (*models.VersionResponse, error)
+	IncrementVersion(ctx context.Context, appID string, incrementType models.IncrementType, idempotencyKey string) (*models.VersionResponse, error)
+	IncrementVersions(ctx context.Context, appIDs []string, incrementType models.IncrementType) (*models.BatchIncrementResponse, error)
+	PreviewNextVersion(ctx context.Context, appID string, incrementType models.IncrementType) (*models.NextVersionResponse, error)
 	GetDevVersion(ctx context.Context, appID string, req *models.DevVersionRequest) (*models.VersionResponse, error)
 	ListVersions(ctx context.Context) (map[string]*models.AppVersion, error)
 	ListVersionsByProject(ctx context.Context, projectID string) (map[string]*models.AppVersion, error)
+	ListVersionsPage(ctx context.Context, cursor string, limit int, filter models.VersionFilter) (*models.VersionPage, error)
+	ListStaleVersions(ctx context.Context, days int) (*models.StaleReport, error)
 	DeleteVersion(ctx context.Context, appID string) error
 	DeleteProject(ctx context.Context, projectID string) error
-}
\ No newline at end of file
+	RestoreVersion(ctx context.Context, appID string) (*models.AppVersion, error)
+	RenameVersion(ctx context.Context, appID, newAppID string) (*models.RenameResponse, error)
+	RegisterApp(ctx context.Context, req *models.RegisterAppRequest) (*models.RegisterAppResponse, error)
+	GetVersionHistory(ctx context.Context, appID string) ([]models.VersionHistoryEntry, error)
+	RollbackVersion(ctx context.Context, appID string) (*models.RollbackResponse, error)
+	DecrementVersion(ctx context.Context, appID string) (*models.DecrementResponse, error)
+	PromoteVersion(ctx context.Context, appID string) (*models.PromoteResponse, error)
+	SetVersionLock(ctx context.Context, appID string, locked bool) (*models.AppVersion, error)
+	SetLifecycle(ctx context.Context, appID, state string) (*models.AppVersion, error)
+	SetIncrementStrategy(ctx context.Context, appID, strategy string) (*models.AppVersion, error)
+	SetInitialDevelopment(ctx context.Context, appID string, enabled bool) (*models.AppVersion, error)
+	GraduateVersion(ctx context.Context, appID string) (*models.GraduateResponse, error)
+	ListDevVersions(ctx context.Context, appID, branch string) (*models.DevVersionsResponse, error)
+	SetVersionAlias(ctx context.Context, appID, alias, version string) (*models.VersionAlias, error)
+	GetVersionAlias(ctx context.Context, appID, alias string) (*models.VersionAlias, error)
+	CompareVersions(v1, v2 string) (*models.CompareResponse, error)
+	GetChangelog(ctx context.Context, appID, from, to string) (*models.Changelog, error)
+	GetFreshnessReport(ctx context.Context) (*models.FreshnessReport, error)
+	RuntimeStats(ctx context.Context) (*models.RuntimeStats, error)
+	GetReservedVersions(ctx context.Context, appID string) (*models.ReservedVersions, error)
+	SetReservedVersions(ctx context.Context, appID string, versions []string) (*models.ReservedVersions, error)
+	GetProjectReservedVersions(ctx context.Context, projectID string) (*models.ReservedVersions, error)
+	SetProjectReservedVersions(ctx context.Context, projectID string, versions []string) (*models.ReservedVersions, error)
+	UpdateVersionMetadata(ctx context.Context, appID string, annotations map[string]*string) (*models.AppVersion, error)
+	GetRawVersionsFile(ctx context.Context) ([]byte, string, error)
+	ReplaceVersionsFile(ctx context.Context, data []byte, expectedRevision string) (*models.RawFileUpdateResponse, error)
+	ExportState(ctx context.Context, includeHistory bool) (*models.StateBundle, error)
+	ImportState(ctx context.Context, bundle *models.StateBundle) (*models.StateImportReport, error)
+	MigrateProjects(ctx context.Context, req *models.ProjectMigrationRequest) (*models.ProjectMigrationReport, error)
+	ListDeferredCalls(ctx context.Context) (*models.OutboxResponse, error)
+	FlushDeferredCalls(ctx context.Context) (*models.FlushReport, error)
+	RebuildCache(ctx context.Context) (*models.CacheRebuildReport, error)
+	RunDiscovery(ctx context.Context) (*models.DiscoveryReport, error)
+	GetDiscoveryReport(ctx context.Context) (*models.DiscoveryReport, error)
+	GetProjectUsage(ctx context.Context, projectID string, windows []time.Duration) (*models.ProjectUsage, error)
+	SimulateProject(ctx context.Context, projectID string, bumps map[string]models.IncrementType) (*models.SimulationReport, error)
+	CanAccessProject(ctx context.Context, projectID string) (bool, error)
+	AppProjectID(ctx context.Context, appID string) (string, error)
+	ResolveAppID(ctx context.Context, appID string) (string, error)
+	ResolveProjectID(ctx context.Context, projectID string) (string, error)
+	CreateWebhook(ctx context.Context, projectID string, req *models.CreateWebhookRequest) (*models.WebhookSubscription, error)
+	ListWebhooks(ctx context.Context, projectID string) (*models.WebhookListResponse, error)
+	DeleteWebhook(ctx context.Context, projectID, id string) error
+}
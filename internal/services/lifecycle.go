@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// lifecycleTransitions lists the states each lifecycle state may move to.
+// Any state may move to deleted through DeleteVersion, and deleted apps
+// return to their previous state through RestoreVersion.
+var lifecycleTransitions = map[string][]string{
+	models.LifecycleActive:     {models.LifecycleFrozen, models.LifecycleDeprecated, models.LifecycleArchived, models.LifecycleDeleted},
+	models.LifecycleFrozen:     {models.LifecycleActive, models.LifecycleDeprecated, models.LifecycleArchived, models.LifecycleDeleted},
+	models.LifecycleDeprecated: {models.LifecycleActive, models.LifecycleFrozen, models.LifecycleArchived, models.LifecycleDeleted},
+	models.LifecycleArchived:   {models.LifecycleActive, models.LifecycleDeleted},
+}
+
+// checkTransition rejects lifecycle changes the state machine doesn't allow
+func checkTransition(appID, from, to string) error {
+	for _, allowed := range lifecycleTransitions[from] {
+		if allowed == to {
+			return nil
+		}
+	}
+	if from == models.LifecycleDeleted {
+		return fmt.Errorf("%w: %s is deleted; restore it first", ErrLifecycleTransition, appID)
+	}
+	return fmt.Errorf("%w: %s cannot move from %s to %s", ErrLifecycleTransition, appID, from, to)
+}
+
+// checkWritable rejects version changes to frozen and archived apps. Both
+// match ErrVersionLocked.
+func checkWritable(appID string, current *models.AppVersion) error {
+	switch current.State() {
+	case models.LifecycleFrozen:
+		return fmt.Errorf("%w: %s", ErrVersionLocked, appID)
+	case models.LifecycleArchived:
+		return fmt.Errorf("%w: %w: %s", ErrVersionLocked, ErrAppArchived, appID)
+	default:
+		return nil
+	}
+}
+
+// SetLifecycle moves an app to another lifecycle state. Moving to deleted
+// is DeleteVersion; deleted apps must be restored with RestoreVersion first.
+// Moving to the current state is a no-op.
+func (s *VersionService) SetLifecycle(ctx context.Context, appID, state string) (*models.AppVersion, error) {
+	if !models.IsLifecycleState(state) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidLifecycle, state)
+	}
+
+	if state == models.LifecycleDeleted {
+		if err := s.DeleteVersion(ctx, appID); err != nil {
+			return nil, err
+		}
+		return s.lookupRecord(ctx, appID)
+	}
+
+	return onApp(ctx, s, appID, func() (*models.AppVersion, error) {
+		if _, err := s.parseAppID(appID); err != nil {
+			return nil, err
+		}
+
+		current, err := s.lookupRecord(ctx, appID)
+		if err != nil {
+			return nil, err
+		}
+
+		from := current.State()
+		if from == state {
+			return current, nil
+		}
+		if err := checkTransition(appID, from, state); err != nil {
+			return nil, err
+		}
+
+		updated := current.WithState(state)
+		updated.LastUpdated = time.Now()
+
+		if err := s.saveVersion(ctx, appID, updated); err != nil {
+			return nil, err
+		}
+
+		s.logger.WithFields(logrus.Fields{
+			"app_id":  appID,
+			"version": updated.Current,
+			"from":    from,
+			"to":      state,
+		}).Info("Lifecycle state changed")
+
+		return updated, nil
+	})
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"context"
+
+	"github.com/company/version-service/internal/models"
+)
+
+// SetVersionLock freezes or unfreezes an existing app. While locked, its
+// version cannot be incremented or rolled back. Locking is the move to the
+// frozen lifecycle state and unlocking the move back to active; apps that
+// are already read-only stay as they are when locked, and unlocked apps
+// when unlocked.
+func (s *VersionService) SetVersionLock(ctx context.Context, appID string, locked bool) (*models.AppVersion, error) {
+	if _, err := s.parseAppID(appID); err != nil {
+		return nil, err
+	}
+
+	current, err := s.lookupVersion(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+
+	if current.Locked == locked {
+		return current, nil
+	}
+
+	if locked {
+		return s.SetLifecycle(ctx, appID, models.LifecycleFrozen)
+	}
+	return s.SetLifecycle(ctx, appID, models.LifecycleActive)
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// Annotation limits keep version records small enough to live in the
+// versions file
+const (
+	MaxAnnotations           = 64
+	MaxAnnotationValueLength = 1024
+)
+
+// annotationKeyPattern allows keys such as jira_ticket or changelog_url
+var annotationKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9._-]{0,62}$`)
+
+// UpdateVersionMetadata merges annotations into an existing app's version
+// record: non-nil values are set and nil values remove the key. Locked apps
+// can still be annotated.
+func (s *VersionService) UpdateVersionMetadata(ctx context.Context, appID string, annotations map[string]*string) (*models.AppVersion, error) {
+	return onApp(ctx, s, appID, func() (*models.AppVersion, error) {
+		if _, err := s.parseAppID(appID); err != nil {
+			return nil, err
+		}
+
+		current, err := s.lookupVersion(ctx, appID)
+		if err != nil {
+			return nil, err
+		}
+
+		merged := make(map[string]string, len(current.Annotations)+len(annotations))
+		for key, value := range current.Annotations {
+			merged[key] = value
+		}
+		changed := false
+		for key, value := range annotations {
+			old, exists := merged[key]
+			if value == nil {
+				if exists {
+					delete(merged, key)
+					changed = true
+				}
+				continue
+			}
+			if !exists || old != *value {
+				merged[key] = *value
+				changed = true
+			}
+		}
+
+		if err := validateAnnotations(merged); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidMetadata, err)
+		}
+
+		if !changed {
+			return current, nil
+		}
+
+		updated := *current
+		updated.Annotations = merged
+		if len(merged) == 0 {
+			updated.Annotations = nil
+		}
+		updated.LastUpdated = time.Now()
+
+		if err := s.saveVersion(ctx, appID, &updated); err != nil {
+			return nil, err
+		}
+
+		keys := make([]string, 0, len(annotations))
+		for key := range annotations {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		s.logger.WithFields(logrus.Fields{
+			"app_id": appID,
+			"keys":   keys,
+		}).Info("Version metadata updated")
+
+		return &updated, nil
+	})
+}
+
+// validateAnnotations checks annotation keys, value lengths and count
+func validateAnnotations(annotations map[string]string) error {
+	if len(annotations) > MaxAnnotations {
+		return fmt.Errorf("at most %d annotations per app", MaxAnnotations)
+	}
+	for key, value := range annotations {
+		if !annotationKeyPattern.MatchString(key) {
+			return fmt.Errorf("annotation key %q must be lowercase letters, digits, '.', '_' or '-'", key)
+		}
+		if len(value) > MaxAnnotationValueLength {
+			return fmt.Errorf("annotation %s exceeds %d characters", key, MaxAnnotationValueLength)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,323 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// MigrateProjects remaps project IDs across the whole service, e.g. when
+// projects move to another GitLab instance. Every app of an old project,
+// tombstones included, moves to the app ID of the new project (opaque IDs
+// keep theirs) with the old ID appended to RenamedFrom, and the versions
+// file is rewritten in one commit at the revision it was read at. The Redis
+// cache is then rebuilt, and project reservations and webhook subscriptions
+// follow their projects. Nothing is written when two apps would end up with
+// the same ID, or on a dry run, which reports the same moves and conflicts.
+func (s *VersionService) MigrateProjects(ctx context.Context, req *models.ProjectMigrationRequest) (*models.ProjectMigrationReport, error) {
+	store, err := s.rawFileStore()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateMigration(req.Projects); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMigration, err)
+	}
+
+	report := &models.ProjectMigrationReport{
+		DryRun:    req.DryRun,
+		Apps:      []models.AppMigration{},
+		StartedAt: time.Now(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Let queued single-app writes land first so the file read below is
+	// complete and none of them overwrites the migrated file
+	s.persistence.flush()
+
+	data, revision, err := store.ReadVersionsFile(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read versions file: %w", err)
+	}
+	var vf models.VersionsFile
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &vf); err != nil {
+			return nil, fmt.Errorf("failed to parse versions file: %w", err)
+		}
+	}
+
+	migrated, err := s.migrateVersions(vf.Versions, req.Projects, report)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMigration, err)
+	}
+	if len(report.Conflicts) > 0 && !req.DryRun {
+		return nil, fmt.Errorf("%w: %s", ErrMigrationConflict, strings.Join(report.Conflicts, "; "))
+	}
+
+	reservedWrites, err := s.migrateReservedVersions(ctx, req.Projects, report)
+	if err != nil {
+		return nil, err
+	}
+	webhooks, err := s.migrateWebhooks(ctx, req.Projects, report)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.DryRun {
+		report.CompletedAt = time.Now()
+		return report, nil
+	}
+
+	report.Pushed = true
+	if len(report.Apps) > 0 {
+		message := fmt.Sprintf("Migrate %d projects (%d apps)", len(req.Projects), len(report.Apps))
+		report.Revision, err = store.ReplaceVersionsFile(ctx, &models.VersionsFile{Versions: migrated}, revision, message)
+		if err != nil {
+			switch {
+			case errors.Is(err, storage.ErrRevisionMismatch):
+				return nil, fmt.Errorf("%w: %v", ErrRevisionConflict, err)
+			case report.Revision != "" && s.isPushFailure(err):
+				report.Pushed = false
+				s.markPushNeeded()
+			default:
+				return nil, fmt.Errorf("failed to write versions file: %w", err)
+			}
+		}
+
+		// RebuildCache only rewrites the index, so drop the old IDs first
+		for _, app := range report.Apps {
+			if app.NewAppID == app.AppID {
+				continue
+			}
+			if err := s.redis.DeleteVersion(ctx, app.AppID); err != nil {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("redis: %s: %v", app.AppID, err))
+			}
+		}
+		if err := s.redis.RebuildCache(ctx, migrated); err != nil {
+			s.logger.WithError(err).Warn("Failed to rebuild Redis cache after project migration")
+			report.Warnings = append(report.Warnings, fmt.Sprintf("redis: %v", err))
+		}
+		s.changedAll(ctx)
+	}
+
+	report.Warnings = append(report.Warnings, s.writeReservedVersions(ctx, reservedWrites)...)
+	report.Warnings = append(report.Warnings, s.moveWebhooks(ctx, webhooks, req.Projects)...)
+	report.CompletedAt = time.Now()
+
+	s.logger.WithFields(logrus.Fields{
+		"revision": report.Revision,
+		"projects": len(req.Projects),
+		"apps":     len(report.Apps),
+		"webhooks": report.Webhooks,
+		"pushed":   report.Pushed,
+	}).Warn("Projects migrated")
+
+	return report, nil
+}
+
+// validateMigration checks that a migration maps at least one project and
+// only to different, well-formed project IDs
+func validateMigration(projects map[string]string) error {
+	if len(projects) == 0 {
+		return fmt.Errorf("no projects given")
+	}
+	for from, to := range projects {
+		if from == "" || to == "" || strings.ContainsAny(from+to, " \t\r\n") {
+			return fmt.Errorf("project IDs must be non-empty and contain no whitespace: %q → %q", from, to)
+		}
+		if from == to {
+			return fmt.Errorf("project %s is mapped to itself", from)
+		}
+	}
+	return nil
+}
+
+// migrateVersions returns the versions with every app of a migrated project
+// moved to its new project, recording the moves and ID collisions in the
+// report
+func (s *VersionService) migrateVersions(versions map[string]*models.AppVersion, projects map[string]string, report *models.ProjectMigrationReport) (map[string]*models.AppVersion, error) {
+	appIDs := make([]string, 0, len(versions))
+	for appID := range versions {
+		appIDs = append(appIDs, appID)
+	}
+	sort.Strings(appIDs)
+
+	migrated := make(map[string]*models.AppVersion, len(versions))
+	owners := make(map[string]string, len(versions))
+	put := func(appID, owner string, version *models.AppVersion) {
+		if previous, taken := owners[appID]; taken {
+			report.Conflicts = append(report.Conflicts, fmt.Sprintf("%s and %s would both be %s", previous, owner, appID))
+			return
+		}
+		owners[appID] = owner
+		migrated[appID] = version
+	}
+
+	now := time.Now()
+	for _, appID := range appIDs {
+		version := versions[appID]
+		parsed, err := s.parseAppID(appID)
+		if err != nil {
+			return nil, err
+		}
+		id, err := identifierFromRecord(parsed, version)
+		if err != nil {
+			return nil, err
+		}
+
+		newProjectID, ok := projects[id.ProjectID]
+		if !ok {
+			put(appID, appID, version)
+			continue
+		}
+
+		newAppID := appID
+		if !parsed.Opaque() {
+			if newAppID, err = s.idScheme.Format(newProjectID, id.AppName); err != nil {
+				return nil, fmt.Errorf("%s: %v", appID, err)
+			}
+		}
+
+		moved := *version
+		moved.ProjectID = newProjectID
+		moved.AppName = id.AppName
+		if newAppID != appID {
+			moved.RenamedFrom = append(append([]string(nil), version.RenamedFrom...), appID)
+		}
+		moved.LastUpdated = now
+
+		put(newAppID, appID, &moved)
+		report.Apps = append(report.Apps, models.AppMigration{
+			AppID:        appID,
+			NewAppID:     newAppID,
+			ProjectID:    id.ProjectID,
+			NewProjectID: newProjectID,
+			Deleted:      version.IsDeleted(),
+		})
+	}
+
+	return migrated, nil
+}
+
+// migrateReservedVersions computes the project reservations after a
+// migration: each new project gets the union of its own reservations, unless
+// it is migrated away itself, and those of the projects moving to it. The
+// returned writes include clearing old projects.
+func (s *VersionService) migrateReservedVersions(ctx context.Context, projects map[string]string, report *models.ProjectMigrationReport) (map[string][]string, error) {
+	store := s.reservedVersionStore()
+	if store == nil {
+		return nil, nil
+	}
+
+	writes := make(map[string][]string)
+	for from, to := range projects {
+		versions, err := store.GetReservedVersions(ctx, from)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read reserved versions of %s: %w", from, err)
+		}
+		if _, ok := writes[from]; !ok {
+			writes[from] = nil
+		}
+		if len(versions) == 0 {
+			continue
+		}
+		report.ReservedProjects++
+
+		if _, ok := writes[to]; !ok {
+			if _, migrating := projects[to]; !migrating {
+				existing, err := store.GetReservedVersions(ctx, to)
+				if err != nil {
+					return nil, fmt.Errorf("failed to read reserved versions of %s: %w", to, err)
+				}
+				writes[to] = existing
+			}
+		}
+		writes[to] = appendMissing(writes[to], versions)
+	}
+	return writes, nil
+}
+
+// appendMissing appends the versions not yet in list
+func appendMissing(list, versions []string) []string {
+	for _, version := range versions {
+		found := false
+		for _, existing := range list {
+			if existing == version {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, version)
+		}
+	}
+	return list
+}
+
+func (s *VersionService) writeReservedVersions(ctx context.Context, writes map[string][]string) []string {
+	store := s.reservedVersionStore()
+	if store == nil {
+		return nil
+	}
+
+	var warnings []string
+	for projectID, versions := range writes {
+		if err := store.SetReservedVersions(ctx, projectID, versions); err != nil {
+			warnings = append(warnings, fmt.Sprintf("reserved versions of %s: %v", projectID, err))
+		}
+	}
+	return warnings
+}
+
+// migrateWebhooks returns the webhook subscriptions of the migrated projects
+func (s *VersionService) migrateWebhooks(ctx context.Context, projects map[string]string, report *models.ProjectMigrationReport) ([]models.WebhookSubscription, error) {
+	store := s.webhookStore()
+	if store == nil {
+		return nil, nil
+	}
+
+	var webhooks []models.WebhookSubscription
+	for from := range projects {
+		subscriptions, err := store.ListWebhooks(ctx, from)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list webhooks of %s: %w", from, err)
+		}
+		webhooks = append(webhooks, subscriptions...)
+	}
+	report.Webhooks = len(webhooks)
+	return webhooks, nil
+}
+
+// moveWebhooks moves webhook subscriptions to their new projects. All are
+// removed before any is saved so chained migrations (A → B, B → C) don't
+// move a subscription twice.
+func (s *VersionService) moveWebhooks(ctx context.Context, webhooks []models.WebhookSubscription, projects map[string]string) []string {
+	store := s.webhookStore()
+	if store == nil {
+		return nil
+	}
+
+	var warnings []string
+	for _, webhook := range webhooks {
+		if _, err := store.DeleteWebhook(ctx, webhook.ProjectID, webhook.ID); err != nil {
+			warnings = append(warnings, fmt.Sprintf("webhook %s: %v", webhook.ID, err))
+		}
+	}
+	for i := range webhooks {
+		webhooks[i].ProjectID = projects[webhooks[i].ProjectID]
+		if err := store.SaveWebhook(ctx, &webhooks[i]); err != nil {
+			warnings = append(warnings, fmt.Sprintf("webhook %s: %v", webhooks[i].ID, err))
+		}
+	}
+	return warnings
+}
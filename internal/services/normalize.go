@@ -0,0 +1,49 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/company/version-service/pkg/semver"
+	"github.com/sirupsen/logrus"
+)
+
+// ParseNormalization parses a comma-separated list of version normalization
+// rules: strip_prefix, strip_leading_zeros and lowercase_prerelease. "none"
+// disables normalization apart from whitespace trimming.
+func ParseNormalization(spec string) (semver.NormalizeOptions, error) {
+	var opts semver.NormalizeOptions
+	for _, part := range strings.Split(spec, ",") {
+		switch strings.TrimSpace(part) {
+		case "", "none":
+		case semver.TransformStripPrefix:
+			opts.StripPrefix = true
+		case semver.TransformStripLeadingZeros:
+			opts.StripLeadingZeros = true
+		case semver.TransformLowercasePrerelease:
+			opts.LowercasePrerelease = true
+		default:
+			return opts, fmt.Errorf("unknown normalization rule %q", strings.TrimSpace(part))
+		}
+	}
+	return opts, nil
+}
+
+// normalizeVersion applies the configured normalization to a version entering
+// the system from outside (seeding, imports)
+func (s *VersionService) normalizeVersion(version string) (string, []string, error) {
+	normalized, applied, err := semver.Normalize(version, s.normalization)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(applied) > 0 {
+		s.logger.WithFields(logrus.Fields{
+			"original":        version,
+			"normalized":      normalized,
+			"transformations": applied,
+		}).Debug("Normalized version")
+	}
+
+	return normalized, applied, nil
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+)
+
+// Page sizes of version listings
+const (
+	DefaultPageSize = 100
+	MaxPageSize     = 1000
+)
+
+// ListVersionsPage returns one page of apps matching filter, in app ID order.
+// Deleted apps are never listed. The page is read from Redis and from Git
+// only when Redis fails.
+func (s *VersionService) ListVersionsPage(ctx context.Context, cursor string, limit int, filter models.VersionFilter) (*models.VersionPage, error) {
+	if limit < 1 || limit > MaxPageSize {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidPage, MaxPageSize)
+	}
+	filter.ExcludeDeleted = true
+
+	page, err := s.redis.ListVersionsPage(ctx, cursor, limit, filter)
+	if errors.Is(err, storage.ErrInvalidCursor) {
+		return nil, fmt.Errorf("%w: invalid cursor", ErrInvalidPage)
+	}
+	if err != nil {
+		s.logger.WithError(err).Warn("Failed to list versions from Redis, falling back to Git")
+		page, err = s.git.ListVersionsPage(ctx, cursor, limit, filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list versions: %w", err)
+		}
+	}
+
+	return page, nil
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/company/version-service/internal/clients"
+	"github.com/company/version-service/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+const projectPathTTL = time.Hour
+
+type projectPathEntry struct {
+	projectID string
+	fetchedAt time.Time
+}
+
+// ResolveAppID accepts app IDs given as "{project-path}/{app-name}", e.g.
+// "platform/billing/api", under the project-app scheme and returns the ID
+// stored for the app, e.g. "1234-api". Other IDs, and every ID under the path
+// and uuid schemes, are returned unchanged.
+func (s *VersionService) ResolveAppID(ctx context.Context, appID string) (string, error) {
+	if s.idScheme.Name() != models.IDSchemeProjectApp || !strings.Contains(appID, "/") {
+		return appID, nil
+	}
+
+	last := strings.LastIndex(appID, "/")
+	path, appName := appID[:last], appID[last+1:]
+	if path == "" || appName == "" {
+		// Not a path; left for parsing to reject
+		return appID, nil
+	}
+
+	projectID, err := s.ResolveProjectPath(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	return s.idScheme.Format(projectID, appName)
+}
+
+// ResolveProjectID accepts project IDs given as GitLab project paths under
+// the project-app and uuid schemes and returns the numeric project ID apps
+// are stored with. Under the path scheme project IDs are paths already.
+func (s *VersionService) ResolveProjectID(ctx context.Context, projectID string) (string, error) {
+	if s.idScheme.Name() == models.IDSchemePath || !strings.Contains(projectID, "/") {
+		return projectID, nil
+	}
+	return s.ResolveProjectPath(ctx, projectID)
+}
+
+// ResolveProjectPath returns the numeric ID of the GitLab project at path
+// ("group/subgroup/app"). Paths in the project path table resolve without
+// GitLab; others are looked up and cached for projectPathTTL so a project
+// moved to another path is picked up.
+func (s *VersionService) ResolveProjectPath(ctx context.Context, path string) (string, error) {
+	path = strings.Trim(path, "/")
+	if projectID, ok := s.projectPaths[path]; ok {
+		return projectID, nil
+	}
+
+	s.projectPathsMu.Lock()
+	entry, ok := s.projectPathCache[path]
+	s.projectPathsMu.Unlock()
+	if ok && time.Since(entry.fetchedAt) < projectPathTTL {
+		return entry.projectID, nil
+	}
+
+	if s.gitLabClient == nil {
+		return "", fmt.Errorf("%w: %s is not in the project path table", ErrProjectPathNotFound, path)
+	}
+
+	project, err := s.gitLabClient.GetProjectByPath(ctx, path)
+	if errors.Is(err, clients.ErrGitLabUnavailable) {
+		return "", fmt.Errorf("%w: cannot resolve project path %s: %w", ErrGitLabUnavailable, path, err)
+	} else if err != nil {
+		return "", fmt.Errorf("failed to resolve project path %s: %w", path, err)
+	}
+	if project == nil {
+		return "", fmt.Errorf("%w: %s", ErrProjectPathNotFound, path)
+	}
+
+	projectID := strconv.Itoa(project.ID)
+	s.projectPathsMu.Lock()
+	s.projectPathCache[path] = projectPathEntry{projectID: projectID, fetchedAt: time.Now()}
+	s.projectPathsMu.Unlock()
+
+	s.logger.WithFields(logrus.Fields{
+		"path":       path,
+		"project_id": projectID,
+	}).Debug("Resolved GitLab project path")
+
+	return projectID, nil
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/company/version-service/internal/clients"
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveAppID_ProjectPaths(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	ctx := context.Background()
+
+	var lookups atomic.Int32
+	gitLab := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/projects/platform%2Fbilling" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		lookups.Add(1)
+		json.NewEncoder(w).Encode(clients.GitLabProject{ID: 1234, Path: "billing", PathWithNamespace: "platform/billing"})
+	}))
+	defer gitLab.Close()
+
+	newService := func(scheme models.IDScheme) *VersionService {
+		cache, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+		require.NoError(t, err)
+		durable, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+		require.NoError(t, err)
+		return NewVersionService(cache, durable, clients.NewGitLabClient(gitLab.URL, "token", logger), logger, Options{
+			IDScheme:     scheme,
+			ProjectPaths: map[string]string{"platform/payments": "77"},
+		})
+	}
+
+	t.Run("resolved through GitLab once", func(t *testing.T) {
+		s := newService(nil)
+		for i := 0; i < 2; i++ {
+			appID, err := s.ResolveAppID(ctx, "platform/billing/api")
+			require.NoError(t, err)
+			assert.Equal(t, "1234-api", appID)
+		}
+		assert.EqualValues(t, 1, lookups.Load())
+	})
+
+	t.Run("lookup table", func(t *testing.T) {
+		s := newService(nil)
+		appID, err := s.ResolveAppID(ctx, "platform/payments/worker")
+		require.NoError(t, err)
+		assert.Equal(t, "77-worker", appID)
+
+		projectID, err := s.ResolveProjectID(ctx, "platform/payments")
+		require.NoError(t, err)
+		assert.Equal(t, "77", projectID)
+	})
+
+	t.Run("unknown path", func(t *testing.T) {
+		_, err := newService(nil).ResolveAppID(ctx, "platform/unknown/api")
+		require.ErrorIs(t, err, ErrProjectPathNotFound)
+	})
+
+	t.Run("numeric IDs unchanged", func(t *testing.T) {
+		appID, err := newService(nil).ResolveAppID(ctx, "1234-api")
+		require.NoError(t, err)
+		assert.Equal(t, "1234-api", appID)
+	})
+
+	t.Run("path scheme unchanged", func(t *testing.T) {
+		scheme, err := models.NewIDScheme(models.IDSchemePath)
+		require.NoError(t, err)
+		appID, err := newService(scheme).ResolveAppID(ctx, "platform/billing/api")
+		require.NoError(t, err)
+		assert.Equal(t, "platform/billing/api", appID)
+	})
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/pkg/semver"
+	"github.com/sirupsen/logrus"
+)
+
+// PromoteVersion turns an app's prerelease version into the matching release
+// by dropping the prerelease suffix (1.4.0-rc.2 → 1.4.0)
+func (s *VersionService) PromoteVersion(ctx context.Context, appID string) (*models.PromoteResponse, error) {
+	return onApp(ctx, s, appID, func() (*models.PromoteResponse, error) {
+		id, err := s.parseAppID(appID)
+		if err != nil {
+			return nil, err
+		}
+
+		current, err := s.lookupVersion(ctx, appID)
+		if err != nil {
+			return nil, err
+		}
+
+		if id, err = identifierFromRecord(id, current); err != nil {
+			return nil, err
+		}
+
+		if err := checkWritable(appID, current); err != nil {
+			return nil, err
+		}
+
+		if scheme := current.Policy.VersionScheme(); scheme != models.VersionSchemeSemVer {
+			return nil, fmt.Errorf("%w: %s versions have no prereleases", ErrNotPrerelease, scheme)
+		}
+
+		parsed, err := semver.Parse(current.Current)
+		if err != nil {
+			return nil, fmt.Errorf("invalid current version: %w", err)
+		}
+
+		if parsed.Prerelease == "" {
+			return nil, fmt.Errorf("%w: %s is at %s", ErrNotPrerelease, appID, current.Current)
+		}
+
+		released := parsed.Release().String()
+
+		if err := s.checkNotReserved(ctx, id.ProjectID, current.Policy, released); err != nil {
+			return nil, err
+		}
+
+		promoted := *current
+		promoted.Current = released
+		promoted.LastUpdated = time.Now()
+
+		if err := s.saveVersion(ctx, appID, &promoted); err != nil {
+			return nil, err
+		}
+
+		s.logger.WithFields(logrus.Fields{
+			"app_id":      appID,
+			"old_version": current.Current,
+			"new_version": released,
+		}).Info("Prerelease promoted")
+
+		return &models.PromoteResponse{
+			Version:      released,
+			PromotedFrom: current.Current,
+		}, nil
+	})
+}
@@ -0,0 +1,250 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+const alertCooldown = time.Hour
+
+// QuotaOptions configures per-project limits. A zero limit disables the
+// corresponding quota; WarnThreshold is the utilization ratio (0-1] at which
+// soft alerts fire.
+type QuotaOptions struct {
+	MaxAppsPerProject    int
+	MaxIncrementsPerHour int
+	WarnThreshold        float64
+	UsageWindows         []time.Duration
+}
+
+// ParseUsageWindows parses a comma-separated list of durations such as
+// "1h,24h,7d". In addition to time.ParseDuration units, "d" means days.
+// Windows can't be longer than storage.UsageRetention.
+func ParseUsageWindows(spec string) ([]time.Duration, error) {
+	var windows []time.Duration
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		var window time.Duration
+		if strings.HasSuffix(part, "d") {
+			days, err := strconv.Atoi(strings.TrimSuffix(part, "d"))
+			if err != nil {
+				return nil, fmt.Errorf("invalid window %q: %w", part, err)
+			}
+			window = time.Duration(days) * 24 * time.Hour
+		} else {
+			parsed, err := time.ParseDuration(part)
+			if err != nil {
+				return nil, fmt.Errorf("invalid window %q: %w", part, err)
+			}
+			window = parsed
+		}
+
+		if window <= 0 {
+			return nil, fmt.Errorf("invalid window %q: must be positive", part)
+		}
+		if window > storage.UsageRetention {
+			return nil, fmt.Errorf("invalid window %q: increments are only kept for %s", part, formatWindow(storage.UsageRetention))
+		}
+		windows = append(windows, window)
+	}
+	return windows, nil
+}
+
+func formatWindow(window time.Duration) string {
+	if window%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", int(window/(24*time.Hour)))
+	}
+	return window.String()
+}
+
+func (s *VersionService) usageTracker() storage.UsageTracker {
+	tracker, ok := s.redis.(storage.UsageTracker)
+	if !ok {
+		return nil
+	}
+	return tracker
+}
+
+// checkAppQuota is called before a new app is registered in a project.
+// Callers must hold s.mu exclusively until the app is saved, so concurrent
+// registrations can't all pass the check before any of them is counted.
+func (s *VersionService) checkAppQuota(ctx context.Context, projectID string) error {
+	limit := s.quotas.MaxAppsPerProject
+	if limit <= 0 {
+		return nil
+	}
+
+	versions, err := s.ListVersionsByProject(ctx, projectID)
+	if err != nil {
+		return err
+	}
+
+	used := int64(len(versions)) + 1
+	if used > int64(limit) {
+		return fmt.Errorf("%w: project %s already has %d apps (limit %d)", ErrQuotaExceeded, projectID, len(versions), limit)
+	}
+
+	s.maybeAlert(projectID, "apps_per_project", used, int64(limit))
+	return nil
+}
+
+// checkIncrementQuota is called before n increments are applied to a project
+func (s *VersionService) checkIncrementQuota(ctx context.Context, projectID string, n int64) error {
+	limit := s.quotas.MaxIncrementsPerHour
+	tracker := s.usageTracker()
+	if limit <= 0 || tracker == nil {
+		return nil
+	}
+
+	count, err := tracker.CountIncrements(ctx, projectID, time.Now().Add(-time.Hour))
+	if err != nil {
+		// Usage tracking is best-effort; never block increments on it
+		s.logger.WithError(err).WithField("project_id", projectID).Warn("Failed to check increment quota")
+		return nil
+	}
+
+	used := count + n
+	if used > int64(limit) {
+		if n > 1 {
+			return fmt.Errorf("%w: project %s has %d increments in the last hour, %d more exceed the limit of %d", ErrQuotaExceeded, projectID, count, n, limit)
+		}
+		return fmt.Errorf("%w: project %s reached %d increments in the last hour (limit %d)", ErrQuotaExceeded, projectID, count, limit)
+	}
+
+	s.maybeAlert(projectID, "increments_per_hour", used, int64(limit))
+	return nil
+}
+
+func (s *VersionService) recordIncrement(ctx context.Context, projectID, appID string) {
+	tracker := s.usageTracker()
+	if tracker == nil {
+		return
+	}
+
+	if err := tracker.RecordIncrement(ctx, projectID, appID, time.Now()); err != nil {
+		s.logger.WithError(err).WithField("app_id", appID).Warn("Failed to record increment usage")
+	}
+}
+
+// maybeAlert sends a soft-quota notification when utilization crosses the
+// warning threshold, at most once per project and quota per cooldown period
+func (s *VersionService) maybeAlert(projectID, quota string, used, limit int64) {
+	utilization := float64(used) / float64(limit)
+	if utilization < s.quotas.WarnThreshold {
+		return
+	}
+
+	key := projectID + ":" + quota
+	s.alertMu.Lock()
+	if last, ok := s.lastAlerts[key]; ok && time.Since(last) < alertCooldown {
+		s.alertMu.Unlock()
+		return
+	}
+	s.lastAlerts[key] = time.Now()
+	s.alertMu.Unlock()
+
+	fields := logrus.Fields{
+		"project_id":  projectID,
+		"quota":       quota,
+		"used":        used,
+		"limit":       limit,
+		"utilization": fmt.Sprintf("%.2f", utilization),
+	}
+	s.logger.WithFields(fields).Warn("Project approaching quota")
+
+	alert := &models.QuotaAlert{
+		EventMeta: models.NewEventMeta(models.EventQuotaAlert),
+		Text: fmt.Sprintf("Project %s is at %.0f%% of its %s quota (%d/%d)",
+			projectID, utilization*100, quota, used, limit),
+		ProjectID:   projectID,
+		Quota:       quota,
+		Used:        used,
+		Limit:       limit,
+		Utilization: utilization,
+		Timestamp:   time.Now(),
+	}
+
+	s.notifyProjectWebhooks(projectID, models.EventQuotaAlert, alert)
+
+	if s.notifier == nil {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := s.notifier.Notify(ctx, alert); err != nil {
+			s.logger.WithError(err).WithFields(fields).Warn("Failed to send quota alert")
+		}
+	}()
+}
+
+func (s *VersionService) GetProjectUsage(ctx context.Context, projectID string, windows []time.Duration) (*models.ProjectUsage, error) {
+	if len(windows) == 0 {
+		windows = s.quotas.UsageWindows
+	}
+
+	tracker := s.usageTracker()
+	if tracker == nil {
+		return nil, fmt.Errorf("usage tracking is not supported by the cache storage")
+	}
+
+	versions, err := s.ListVersionsByProject(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &models.ProjectUsage{
+		ProjectID:   projectID,
+		Apps:        quotaUsage(int64(len(versions)), s.quotas.MaxAppsPerProject),
+		Windows:     make([]models.WindowUsage, 0, len(windows)),
+		GeneratedAt: time.Now(),
+	}
+
+	now := time.Now()
+	lastHour, err := tracker.CountIncrements(ctx, projectID, now.Add(-time.Hour))
+	if err != nil {
+		return nil, fmt.Errorf("failed to count increments: %w", err)
+	}
+	usage.IncrementRate = quotaUsage(lastHour, s.quotas.MaxIncrementsPerHour)
+
+	for _, window := range windows {
+		count, err := tracker.CountIncrements(ctx, projectID, now.Add(-window))
+		if err != nil {
+			return nil, fmt.Errorf("failed to count increments: %w", err)
+		}
+		usage.Windows = append(usage.Windows, models.WindowUsage{
+			Window:     formatWindow(window),
+			Increments: count,
+		})
+	}
+
+	if usage.Apps.Limit > 0 && usage.Apps.Utilization >= s.quotas.WarnThreshold {
+		usage.Warnings = append(usage.Warnings, fmt.Sprintf("apps_per_project at %.0f%% of limit", usage.Apps.Utilization*100))
+	}
+	if usage.IncrementRate.Limit > 0 && usage.IncrementRate.Utilization >= s.quotas.WarnThreshold {
+		usage.Warnings = append(usage.Warnings, fmt.Sprintf("increments_per_hour at %.0f%% of limit", usage.IncrementRate.Utilization*100))
+	}
+
+	return usage, nil
+}
+
+func quotaUsage(used int64, limit int) models.QuotaUsage {
+	usage := models.QuotaUsage{Used: used}
+	if limit > 0 {
+		usage.Limit = int64(limit)
+		usage.Utilization = float64(used) / float64(limit)
+	}
+	return usage
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterApp_ConcurrentQuota(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	ctx := context.Background()
+
+	cache, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	durable, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	s := NewVersionService(cache, durable, nil, logger, Options{Quotas: QuotaOptions{MaxAppsPerProject: 3}})
+
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = s.RegisterApp(ctx, &models.RegisterAppRequest{ProjectID: "1", AppName: fmt.Sprintf("app-%d", i)})
+		}()
+	}
+	wg.Wait()
+
+	registered := 0
+	for _, err := range errs {
+		if err == nil {
+			registered++
+		} else {
+			assert.ErrorIs(t, err, ErrQuotaExceeded)
+		}
+	}
+	assert.Equal(t, 3, registered)
+
+	versions, err := s.ListVersionsByProject(ctx, "1")
+	require.NoError(t, err)
+	assert.Len(t, versions, 3)
+}
+
+func TestIncrementVersions_QuotaCountsEveryApp(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	ctx := context.Background()
+
+	memory, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	s := NewVersionService(memory, memory, nil, logger, Options{Quotas: QuotaOptions{MaxIncrementsPerHour: 5}})
+
+	for range 3 {
+		_, err := s.IncrementVersion(ctx, "1-a", models.IncrementTypePatch, "")
+		require.NoError(t, err)
+	}
+
+	// 3 used and 3 more requested exceed 5, so nothing is incremented
+	_, err = s.IncrementVersions(ctx, []string{"1-b", "1-c", "1-d"}, models.IncrementTypePatch)
+	require.ErrorIs(t, err, ErrQuotaExceeded)
+	versions, err := s.ListVersionsByProject(ctx, "1")
+	require.NoError(t, err)
+	for appID, version := range versions {
+		if appID != "1-a" {
+			assert.Equal(t, "1.0.0", version.Current, appID)
+		}
+	}
+
+	response, err := s.IncrementVersions(ctx, []string{"1-b", "1-c"}, models.IncrementTypePatch)
+	require.NoError(t, err)
+	assert.Len(t, response.Versions, 2)
+
+	_, err = s.IncrementVersion(ctx, "1-a", models.IncrementTypePatch, "")
+	assert.ErrorIs(t, err, ErrQuotaExceeded, "the batch used up the quota")
+}
+
+func TestParseUsageWindows(t *testing.T) {
+	windows, err := ParseUsageWindows("1h, 24h,7d")
+	require.NoError(t, err)
+	assert.Equal(t, []time.Duration{time.Hour, 24 * time.Hour, storage.UsageRetention}, windows)
+
+	_, err = ParseUsageWindows("1h,8d")
+	assert.ErrorContains(t, err, `invalid window "8d": increments are only kept for 7d`)
+	_, err = ParseUsageWindows("0h")
+	assert.ErrorContains(t, err, "must be positive")
+}
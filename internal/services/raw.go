@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+func (s *VersionService) rawFileStore() (storage.RawFileStore, error) {
+	store, ok := s.git.(storage.RawFileStore)
+	if !ok {
+		return nil, fmt.Errorf("Git storage does not support raw file access")
+	}
+	return store, nil
+}
+
+// GetRawVersionsFile returns every app as one versions file, as the durable
+// store holds or assembles it, and the revision it was read at
+func (s *VersionService) GetRawVersionsFile(ctx context.Context) ([]byte, string, error) {
+	store, err := s.rawFileStore()
+	if err != nil {
+		return nil, "", err
+	}
+
+	data, revision, err := store.ReadVersionsFile(ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read versions file: %w", err)
+	}
+
+	return data, revision, nil
+}
+
+// ReplaceVersionsFile validates data as a complete versions file and replaces
+// the stored file with it in one commit, then rebuilds the Redis cache. When
+// expectedRevision is set the replacement only happens if Git is still at
+// that revision.
+func (s *VersionService) ReplaceVersionsFile(ctx context.Context, data []byte, expectedRevision string) (*models.RawFileUpdateResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	store, err := s.rawFileStore()
+	if err != nil {
+		return nil, err
+	}
+
+	vf, normalized, err := s.parseVersionsFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidVersionsFile, err)
+	}
+
+	// Let queued single-app writes land first so none of them overwrites
+	// the new file
+	s.persistence.flush()
+
+	pushed := true
+	revision, err := store.ReplaceVersionsFile(ctx, vf, expectedRevision, fmt.Sprintf("Replace versions file (%d apps)", len(vf.Versions)))
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrRevisionMismatch):
+			return nil, fmt.Errorf("%w: %v", ErrRevisionConflict, err)
+		case revision != "" && s.isPushFailure(err):
+			pushed = false
+			s.markPushNeeded()
+		default:
+			return nil, fmt.Errorf("failed to replace versions file: %w", err)
+		}
+	}
+
+	if err := s.redis.RebuildCache(ctx, vf.Versions); err != nil {
+		s.logger.WithError(err).Warn("Failed to rebuild Redis cache after versions file replacement")
+	}
+	s.changedAll(ctx)
+
+	s.logger.WithFields(logrus.Fields{
+		"revision": revision,
+		"count":    len(vf.Versions),
+		"pushed":   pushed,
+	}).Warn("Versions file replaced via raw API")
+
+	return &models.RawFileUpdateResponse{
+		Revision:   revision,
+		Apps:       len(vf.Versions),
+		Pushed:     pushed,
+		Normalized: normalized,
+	}, nil
+}
+
+// parseVersionsFile decodes and validates a complete versions file
+func (s *VersionService) parseVersionsFile(data []byte) (*models.VersionsFile, map[string][]string, error) {
+	var vf models.VersionsFile
+	if err := json.Unmarshal(data, &vf); err != nil {
+		return nil, nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	normalized, err := s.validateVersionsFile(&vf)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &vf, normalized, nil
+}
+
+// validateVersionsFile checks and normalizes a complete versions file in
+// place. Every key must be a valid app ID matching its record and every
+// version valid under the app's scheme after normalization. The
+// normalizations applied are returned per app.
+func (s *VersionService) validateVersionsFile(vf *models.VersionsFile) (map[string][]string, error) {
+	if vf.Versions == nil {
+		return nil, fmt.Errorf("missing versions map")
+	}
+
+	normalized := make(map[string][]string)
+	for appID, version := range vf.Versions {
+		if version == nil {
+			return nil, fmt.Errorf("%s: empty version record", appID)
+		}
+
+		id, err := s.parseAppID(appID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", appID, err)
+		}
+
+		if id.Opaque() {
+			// Opaque IDs are registered through these attributes
+			if version.ProjectID == "" || version.AppName == "" {
+				return nil, fmt.Errorf("%s: project_id and app_name are required", appID)
+			}
+		} else if version.ProjectID != id.ProjectID || version.AppName != id.AppName {
+			return nil, fmt.Errorf("%s: project_id/app_name do not match app ID", appID)
+		}
+
+		if err := s.validatePolicy(version.Policy); err != nil {
+			return nil, fmt.Errorf("%s: %v", appID, err)
+		}
+		scheme := s.schemeOf(version.Policy)
+
+		// Deletion is recorded by deleted_at, never as the stored state
+		if version.Lifecycle != "" {
+			if !models.IsLifecycleState(version.Lifecycle) || version.Lifecycle == models.LifecycleDeleted {
+				return nil, fmt.Errorf("%s: invalid lifecycle state %q", appID, version.Lifecycle)
+			}
+			*version = *version.WithState(version.Lifecycle)
+		}
+
+		current, applied, err := scheme.canonical(version.Current)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid %s version %q", appID, scheme.name(), version.Current)
+		}
+		version.Current = current
+		version.Normalized = nil
+		if len(applied) > 0 {
+			normalized[appID] = applied
+		}
+
+		for alias, aliased := range version.Aliases {
+			if !aliasNamePattern.MatchString(alias) {
+				return nil, fmt.Errorf("%s: invalid alias name %q", appID, alias)
+			}
+			target, _, err := scheme.canonical(aliased)
+			if err != nil {
+				return nil, fmt.Errorf("%s: alias %s: invalid %s version %q", appID, alias, scheme.name(), aliased)
+			}
+			version.Aliases[alias] = target
+		}
+
+		if err := validateAnnotations(version.Annotations); err != nil {
+			return nil, fmt.Errorf("%s: %v", appID, err)
+		}
+	}
+
+	return normalized, nil
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// RebuildCache replaces the Redis cache with the versions in durable
+// storage, as on startup, so a flushed or drifted Redis recovers without a
+// restart. Writes still queued for Git land first, and versions Redis holds
+// newer than Git, or that are journaled while failed over, are kept rather
+// than rolled back. Writes wait until the rebuild is done.
+func (s *VersionService) RebuildCache(ctx context.Context) (*models.CacheRebuildReport, error) {
+	start := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Followers never write to Git, so they have nothing queued or newer
+	if !s.follower.Enabled {
+		s.persistence.flush()
+	}
+
+	versions, err := s.git.ListVersions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load versions from Git: %w", err)
+	}
+
+	recovered := 0
+	if !s.follower.Enabled {
+		recovered = s.recoverCachedWritesLocked(ctx, versions)
+		if s.failoverEnabled() {
+			s.applyJournal(ctx, versions)
+		}
+	}
+
+	if err := s.redis.RebuildCache(ctx, versions); err != nil {
+		return nil, fmt.Errorf("failed to rebuild Redis cache: %w", err)
+	}
+	s.changedAll(ctx)
+
+	report := &models.CacheRebuildReport{
+		Versions:   len(versions),
+		Recovered:  recovered,
+		DurationMs: time.Since(start).Milliseconds(),
+		RebuiltAt:  time.Now(),
+	}
+	s.logger.WithFields(logrus.Fields{
+		"count":       report.Versions,
+		"recovered":   report.Recovered,
+		"duration_ms": report.DurationMs,
+	}).Info("Redis cache rebuilt from Git")
+	return report, nil
+}
+
+// periodicCacheRebuild rebuilds the cache every CacheRebuildInterval
+func (s *VersionService) periodicCacheRebuild() {
+	ticker := time.NewTicker(s.cacheRebuildInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		if _, err := s.RebuildCache(ctx); err != nil {
+			s.logger.WithError(err).Error("Scheduled cache rebuild failed")
+		}
+		cancel()
+	}
+}
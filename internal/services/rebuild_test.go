@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRebuildCache(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	ctx := context.Background()
+
+	cache, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	durable, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	stored := time.Now().Add(-time.Hour)
+	require.NoError(t, durable.SetVersion(ctx, "1-api", &models.AppVersion{Current: "1.0.0", ProjectID: "1", AppName: "api", LastUpdated: stored}))
+	require.NoError(t, durable.SetVersion(ctx, "1-web", &models.AppVersion{Current: "2.0.0", ProjectID: "1", AppName: "web", LastUpdated: stored}))
+
+	s := NewVersionService(cache, durable, nil, logger, Options{})
+
+	// Redis was flushed, except for a write that never reached Git
+	require.NoError(t, cache.SetVersion(ctx, "1-web", &models.AppVersion{Current: "2.1.0", ProjectID: "1", AppName: "web", LastUpdated: time.Now()}))
+
+	report, err := s.RebuildCache(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Versions)
+	assert.Equal(t, 1, report.Recovered)
+
+	cached, err := cache.GetVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", cached.Current)
+	cached, err = cache.GetVersion(ctx, "1-web")
+	require.NoError(t, err)
+	assert.Equal(t, "2.1.0", cached.Current)
+
+	// The newer write is back in Git once its queued persistence ran
+	s.mu.Lock()
+	s.persistence.flush()
+	s.mu.Unlock()
+	written, err := durable.GetVersion(ctx, "1-web")
+	require.NoError(t, err)
+	assert.Equal(t, "2.1.0", written.Current)
+}
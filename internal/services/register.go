@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultInitialVersion is the version of apps registered without one
+const defaultInitialVersion = "1.0.0"
+
+// appNamePattern restricts registered app names to what GitLab allows in
+// project paths, so IDs stay unambiguous under every scheme
+var appNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,99}$`)
+
+// RegisterApp creates an app explicitly with its project, name, initial
+// version and policy. The app ID is derived with the configured scheme and
+// must round-trip to the same project and name; registering an existing or
+// deleted app fails with ErrAppExists.
+func (s *VersionService) RegisterApp(ctx context.Context, req *models.RegisterAppRequest) (*models.RegisterAppResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if req.ProjectID == "" || strings.TrimSpace(req.ProjectID) != req.ProjectID || strings.ContainsAny(req.ProjectID, " \t\r\n") {
+		return nil, fmt.Errorf("%w: project_id must be non-empty and contain no whitespace", ErrInvalidRegistration)
+	}
+	if !appNamePattern.MatchString(req.AppName) {
+		return nil, fmt.Errorf("%w: app_name must start with a letter or digit and contain only letters, digits, '.', '_' or '-'", ErrInvalidRegistration)
+	}
+
+	appID, err := s.idScheme.Format(req.ProjectID, req.AppName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRegistration, err)
+	}
+	id, err := s.idScheme.Parse(appID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRegistration, err)
+	}
+	if !id.Opaque() && (id.ProjectID != req.ProjectID || id.AppName != req.AppName) {
+		return nil, fmt.Errorf("%w: project_id %q is ambiguous under the %s app ID scheme", ErrInvalidRegistration, req.ProjectID, s.idScheme.Name())
+	}
+
+	if err := s.validatePolicy(req.Policy); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRegistration, err)
+	}
+	scheme := s.schemeOf(req.Policy)
+
+	policy := req.Policy
+	if policy != nil && len(policy.ReservedVersions) > 0 {
+		reserved, err := s.normalizeReservedVersions(scheme, policy.ReservedVersions)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRegistration, err)
+		}
+		normalizedPolicy := *policy
+		normalizedPolicy.ReservedVersions = reserved
+		policy = &normalizedPolicy
+	}
+
+	initial := req.InitialVersion
+	if initial == "" {
+		initial = scheme.initial(time.Now())
+	}
+	version, normalized, err := scheme.canonical(initial)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid initial_version %q for the %s scheme", ErrInvalidRegistration, initial, scheme.name())
+	}
+
+	if err := s.checkNotRegistered(ctx, appID, id, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkNotReserved(ctx, req.ProjectID, policy, version); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkAppQuota(ctx, req.ProjectID); err != nil {
+		return nil, err
+	}
+
+	record := &models.AppVersion{
+		Current:     version,
+		ProjectID:   req.ProjectID,
+		AppName:     req.AppName,
+		Policy:      policy,
+		LastUpdated: time.Now(),
+	}
+	s.applyProjectMetadata(ctx, record)
+
+	if err := s.saveVersion(ctx, appID, record); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"app_id":     appID,
+		"project_id": req.ProjectID,
+		"app_name":   req.AppName,
+		"version":    version,
+	}).Info("App registered")
+
+	created := *record
+	created.Normalized = normalized
+	return &models.RegisterAppResponse{AppID: appID, Version: &created}, nil
+}
+
+// checkNotRegistered fails with ErrAppExists when the app is already stored,
+// including as a tombstone. Opaque IDs are fresh on every registration, so
+// for them the project is searched for an app of the same name.
+func (s *VersionService) checkNotRegistered(ctx context.Context, appID string, id models.AppIdentifier, req *models.RegisterAppRequest) error {
+	if id.Opaque() {
+		versions, err := s.listRecords(ctx)
+		if err != nil {
+			return err
+		}
+		for existingID, existing := range versions {
+			if existing.ProjectID == req.ProjectID && existing.AppName == req.AppName {
+				return fmt.Errorf("%w: %s is registered as %s", ErrAppExists, req.AppName, existingID)
+			}
+		}
+		return nil
+	}
+
+	existing, err := s.lookupRecord(ctx, appID)
+	if errors.Is(err, ErrAppNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if existing.IsDeleted() {
+		return fmt.Errorf("%w: %s was deleted; restore it instead", ErrAppExists, appID)
+	}
+	return fmt.Errorf("%w: %s", ErrAppExists, appID)
+}
+
+// validatePolicy checks that a policy names a known version scheme and only
+// known increment types, that its reserved versions fit the scheme, that
+// initial development is only set for semver and that its increment
+// strategy is allowed
+func (s *VersionService) validatePolicy(policy *models.AppPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	if err := validateScheme(policy.Scheme); err != nil {
+		return err
+	}
+	for _, allowed := range policy.AllowedIncrements {
+		known := false
+		for _, incrementType := range incrementTypes {
+			if allowed == incrementType {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown increment type %q in allowed_increments", allowed)
+		}
+	}
+	scheme := s.schemeOf(policy)
+	for _, reserved := range policy.ReservedVersions {
+		if err := scheme.valid(reserved); err != nil {
+			return fmt.Errorf("invalid %s version %q in reserved_versions", scheme.name(), reserved)
+		}
+	}
+	if err := validateInitialDevelopment(policy); err != nil {
+		return err
+	}
+	return validateStrategy(policy)
+}
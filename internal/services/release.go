@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/company/version-service/internal/clients"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultReleaseTagTemplate names release tags after the version alone
+const DefaultReleaseTagTemplate = "v{version}"
+
+// ReleaseOptions configures GitLab releases for increments sent with a
+// delegated job token
+type ReleaseOptions struct {
+	// Enabled creates a release, and its tag at the job's commit, in the
+	// app's GitLab project for every increment sent with a job token of that
+	// project. Increments without one never create releases, so the
+	// service's own token isn't used to write to GitLab.
+	Enabled bool
+	// TagTemplate names the tag; {version} and {app} are replaced. Empty
+	// selects DefaultReleaseTagTemplate.
+	TagTemplate string
+}
+
+// VerifyJobToken asks GitLab which CI job a job token belongs to, failing
+// with clients.ErrInvalidJobToken for tokens GitLab doesn't accept
+func (s *VersionService) VerifyJobToken(ctx context.Context, token string) (*clients.GitLabJob, error) {
+	if s.gitLabClient == nil {
+		return nil, fmt.Errorf("GitLab is not configured")
+	}
+	return s.gitLabClient.VerifyJobToken(ctx, token)
+}
+
+// createRelease creates the GitLab release of a new version when the
+// request carries a job token of the app's project. Failures are logged;
+// the version stands either way.
+func (s *VersionService) createRelease(ctx context.Context, appID, version string) {
+	job := clients.DelegatedJob(ctx)
+	if !s.releases.Enabled || job == nil || s.gitLabClient == nil {
+		return
+	}
+
+	id, err := s.identify(ctx, appID)
+	if err != nil || id.ProjectID != strconv.FormatInt(job.Pipeline.ProjectID, 10) {
+		// A job token can only act on its own project
+		return
+	}
+
+	template := s.releases.TagTemplate
+	if template == "" {
+		template = DefaultReleaseTagTemplate
+	}
+	tag := strings.NewReplacer("{version}", version, "{app}", id.AppName).Replace(template)
+
+	fields := logrus.Fields{
+		"app_id":  appID,
+		"version": version,
+		"tag":     tag,
+		"job_id":  job.ID,
+	}
+	description := fmt.Sprintf("%s %s", id.AppName, version)
+	if err := s.gitLabClient.CreateRelease(ctx, id.ProjectID, tag, job.Pipeline.SHA, description); err != nil {
+		s.logger.WithError(err).WithFields(fields).Warn("Failed to create GitLab release")
+		return
+	}
+	s.logger.WithFields(fields).Info("GitLab release created")
+}
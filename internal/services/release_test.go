@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/company/version-service/internal/clients"
+	"github.com/company/version-service/internal/storage"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIncrementVersion_CreatesRelease(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	var mu sync.Mutex
+	var releases []string
+	gitLab := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/projects/42/repository/tags":
+			json.NewEncoder(w).Encode([]clients.GitLabTag{})
+		case "/projects/42/releases":
+			assert.Equal(t, "job-token", r.Header.Get("JOB-TOKEN"))
+			assert.Equal(t, "abc123", r.URL.Query().Get("ref"))
+			mu.Lock()
+			releases = append(releases, r.URL.Query().Get("tag_name"))
+			mu.Unlock()
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer gitLab.Close()
+
+	cache, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	durable, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	s := NewVersionService(cache, durable, clients.NewGitLabClient(gitLab.URL, "token", logger), logger, Options{
+		Releases: ReleaseOptions{Enabled: true, TagTemplate: "{app}-v{version}"},
+	})
+
+	job := &clients.GitLabJob{ID: 1}
+	job.Pipeline.ProjectID = 42
+	job.Pipeline.SHA = "abc123"
+	ctx := clients.WithDelegatedToken(context.Background(), "job-token", job)
+
+	resp, err := s.IncrementVersion(ctx, "42-api", "patch", "")
+	require.NoError(t, err)
+
+	// Without a job token, or with one of another project, nothing is released
+	_, err = s.IncrementVersion(context.Background(), "42-api", "patch", "")
+	require.NoError(t, err)
+	other := &clients.GitLabJob{ID: 2}
+	other.Pipeline.ProjectID = 7
+	_, err = s.IncrementVersion(clients.WithDelegatedToken(context.Background(), "job-token", other), "42-api", "patch", "")
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"api-v" + resp.Version}, releases)
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// RenameVersion moves an app's record to a new ID. Redis and Git each apply
+// the move as one change, and the old ID is appended to RenamedFrom so the
+// app's history and rollbacks reach back past the rename. The old ID is
+// freed; the new one must not exist, not even as a tombstone.
+func (s *VersionService) RenameVersion(ctx context.Context, appID, newAppID string) (*models.RenameResponse, error) {
+	redisRenamer, ok := s.redis.(storage.Renamer)
+	if !ok {
+		return nil, fmt.Errorf("renaming is not supported by the cache storage")
+	}
+	gitRenamer, ok := s.git.(storage.Renamer)
+	if !ok {
+		return nil, fmt.Errorf("renaming is not supported by the durable storage")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, err := s.parseAppID(appID)
+	if err != nil {
+		return nil, err
+	}
+	newID, err := s.parseAppID(newAppID)
+	if err != nil {
+		return nil, err
+	}
+	if appID == newAppID {
+		return nil, fmt.Errorf("%w: %s is already the app's ID", ErrInvalidRename, appID)
+	}
+
+	current, err := s.lookupVersion(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkWritable(appID, current); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.lookupRecord(ctx, newAppID); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrAppExists, newAppID)
+	} else if !errors.Is(err, ErrAppNotFound) {
+		return nil, err
+	}
+
+	if id, err = identifierFromRecord(id, current); err != nil {
+		return nil, err
+	}
+	// Opaque IDs carry no project or app name, so those stay as registered
+	if newID.Opaque() {
+		newID.ProjectID = id.ProjectID
+		newID.AppName = id.AppName
+	}
+
+	renamed := *current
+	renamed.ProjectID = newID.ProjectID
+	renamed.AppName = newID.AppName
+	if newID.ProjectID != id.ProjectID {
+		if err := s.checkAppQuota(ctx, newID.ProjectID); err != nil {
+			return nil, err
+		}
+		// The old project's metadata doesn't describe the new one
+		renamed.RepoName, renamed.DefaultBranch, renamed.WebURL = "", "", ""
+		s.applyProjectMetadata(ctx, &renamed)
+	}
+	renamed.RenamedFrom = append(append([]string(nil), current.RenamedFrom...), appID)
+	renamed.LastUpdated = time.Now()
+
+	err = redisRenamer.RenameVersion(ctx, appID, newAppID, &renamed)
+	s.changed(ctx, appID, newAppID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename version in Redis: %w", err)
+	}
+
+	appIDs := []string{appID, newAppID}
+	writtenAt := s.freshness.written(appIDs)
+	s.persistence.pushAll(appIDs, func() {
+		if s.journalWrites(ctx, []models.JournalEntry{
+			{AppID: appID, RecordedAt: time.Now()},
+			{AppID: newAppID, Version: &renamed, RecordedAt: time.Now()},
+		}, writtenAt) {
+			return
+		}
+		s.persistToGitWithRetry(ctx, appIDs, writtenAt, logrus.Fields{
+			"app_id":     appID,
+			"new_app_id": newAppID,
+		}, func(ctx context.Context) error {
+			return gitRenamer.RenameVersion(ctx, appID, newAppID, &renamed)
+		})
+	})
+
+	s.logger.WithFields(logrus.Fields{
+		"app_id":     appID,
+		"new_app_id": newAppID,
+		"version":    renamed.Current,
+	}).Info("App renamed")
+
+	return &models.RenameResponse{
+		AppID:         newAppID,
+		PreviousAppID: appID,
+		Version:       &renamed,
+	}, nil
+}
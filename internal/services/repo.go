@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/company/version-service/internal/clients"
+	"github.com/company/version-service/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+const repoNameTTL = time.Hour
+
+// repoNameEntry is a project as GitLab described it; an empty name means
+// GitLab didn't know the project
+type repoNameEntry struct {
+	name          string
+	defaultBranch string
+	webURL        string
+	fetchedAt     time.Time
+}
+
+// rememberProject caches a project GitLab already listed, saving a lookup
+// when its apps are created
+func (s *VersionService) rememberProject(projectID string, project clients.GitLabProject) {
+	s.repoNamesMu.Lock()
+	s.repoNames[projectID] = repoNameEntry{
+		name:          project.PathWithNamespace,
+		defaultBranch: project.DefaultBranch,
+		webURL:        project.WebURL,
+		fetchedAt:     time.Now(),
+	}
+	s.repoNamesMu.Unlock()
+}
+
+// lookupProject returns a project's path (group/subgroup/repo), default
+// branch and web URL. Lookups are cached per project for repoNameTTL so
+// renames are picked up without hitting GitLab on every write. ok is false
+// when GitLab has no answer.
+func (s *VersionService) lookupProject(ctx context.Context, projectID string) (entry repoNameEntry, ok bool) {
+	if s.gitLabClient == nil {
+		return repoNameEntry{}, false
+	}
+
+	s.repoNamesMu.Lock()
+	entry, ok = s.repoNames[projectID]
+	s.repoNamesMu.Unlock()
+	if ok && time.Since(entry.fetchedAt) < repoNameTTL {
+		return entry, true
+	}
+
+	project, err := s.gitLabClient.GetProject(ctx, projectID)
+	if errors.Is(err, clients.ErrAirGapped) {
+		return repoNameEntry{}, false
+	} else if err != nil {
+		s.logger.WithError(err).WithField("project_id", projectID).Warn("Failed to resolve repo name from GitLab")
+		return repoNameEntry{}, false
+	}
+
+	if project == nil {
+		project = &clients.GitLabProject{}
+	}
+	s.rememberProject(projectID, *project)
+
+	s.repoNamesMu.Lock()
+	defer s.repoNamesMu.Unlock()
+	return s.repoNames[projectID], true
+}
+
+// applyProjectMetadata sets an app's repo name, default branch and web URL
+// from its GitLab project. The app keeps the values it has whenever GitLab
+// has no answer.
+func (s *VersionService) applyProjectMetadata(ctx context.Context, version *models.AppVersion) {
+	entry, ok := s.lookupProject(ctx, version.ProjectID)
+	if !ok || entry.name == "" {
+		return
+	}
+
+	if entry.name != version.RepoName && version.RepoName != "" {
+		s.logger.WithFields(logrus.Fields{
+			"project_id": version.ProjectID,
+			"old_repo":   version.RepoName,
+			"new_repo":   entry.name,
+		}).Info("Repo name changed in GitLab")
+	}
+
+	version.RepoName = entry.name
+	version.DefaultBranch = entry.defaultBranch
+	version.WebURL = entry.webURL
+}
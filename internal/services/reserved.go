@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+	"github.com/company/version-service/pkg/semver"
+	"github.com/sirupsen/logrus"
+)
+
+func (s *VersionService) reservedVersionStore() storage.ReservedVersionStore {
+	store, ok := s.redis.(storage.ReservedVersionStore)
+	if !ok {
+		return nil
+	}
+	return store
+}
+
+// GetReservedVersions returns the versions reserved for an app by its policy
+// and by its project
+func (s *VersionService) GetReservedVersions(ctx context.Context, appID string) (*models.ReservedVersions, error) {
+	id, err := s.parseAppID(appID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.lookupVersion(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+
+	if id, err = identifierFromRecord(id, current); err != nil {
+		return nil, err
+	}
+
+	projectVersions, err := s.projectReservedVersions(ctx, id.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	reserved := &models.ReservedVersions{
+		AppID:           appID,
+		ProjectID:       id.ProjectID,
+		ProjectVersions: projectVersions,
+	}
+	if current.Policy != nil {
+		reserved.AppVersions = current.Policy.ReservedVersions
+	}
+	return reserved, nil
+}
+
+// SetReservedVersions replaces the versions reserved in an app's policy. They
+// must follow the app's version scheme.
+func (s *VersionService) SetReservedVersions(ctx context.Context, appID string, versions []string) (*models.ReservedVersions, error) {
+	return onApp(ctx, s, appID, func() (*models.ReservedVersions, error) {
+		id, err := s.parseAppID(appID)
+		if err != nil {
+			return nil, err
+		}
+
+		current, err := s.lookupVersion(ctx, appID)
+		if err != nil {
+			return nil, err
+		}
+
+		if id, err = identifierFromRecord(id, current); err != nil {
+			return nil, err
+		}
+
+		normalized, err := s.normalizeReservedVersions(s.schemeOf(current.Policy), versions)
+		if err != nil {
+			return nil, err
+		}
+
+		policy := models.AppPolicy{}
+		if current.Policy != nil {
+			policy = *current.Policy
+		}
+		policy.ReservedVersions = normalized
+
+		updated := *current
+		updated.Policy = &policy
+		if policy.Scheme == "" && len(policy.AllowedIncrements) == 0 && len(policy.ReservedVersions) == 0 {
+			updated.Policy = nil
+		}
+		updated.LastUpdated = time.Now()
+
+		if err := s.saveVersion(ctx, appID, &updated); err != nil {
+			return nil, err
+		}
+
+		s.logger.WithFields(logrus.Fields{
+			"app_id":   appID,
+			"reserved": strings.Join(normalized, ","),
+		}).Info("App reserved versions updated")
+
+		projectVersions, err := s.projectReservedVersions(ctx, id.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+
+		return &models.ReservedVersions{
+			AppID:           appID,
+			ProjectID:       id.ProjectID,
+			AppVersions:     normalized,
+			ProjectVersions: projectVersions,
+		}, nil
+	})
+}
+
+// GetProjectReservedVersions returns the versions reserved for every app in a
+// project
+func (s *VersionService) GetProjectReservedVersions(ctx context.Context, projectID string) (*models.ReservedVersions, error) {
+	versions, err := s.projectReservedVersions(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ReservedVersions{
+		ProjectID:       projectID,
+		ProjectVersions: versions,
+	}, nil
+}
+
+// SetProjectReservedVersions replaces the versions reserved for every app in
+// a project
+func (s *VersionService) SetProjectReservedVersions(ctx context.Context, projectID string, versions []string) (*models.ReservedVersions, error) {
+	store := s.reservedVersionStore()
+	if store == nil {
+		return nil, fmt.Errorf("reserved versions are not supported by the cache storage")
+	}
+
+	// Projects may mix schemes; their reservations are semantic versions
+	normalized, err := s.normalizeReservedVersions(semverScheme{s}, versions)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := store.SetReservedVersions(ctx, projectID, normalized); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"project_id": projectID,
+		"reserved":   strings.Join(normalized, ","),
+	}).Info("Project reserved versions updated")
+
+	return &models.ReservedVersions{
+		ProjectID:       projectID,
+		ProjectVersions: normalized,
+	}, nil
+}
+
+func (s *VersionService) projectReservedVersions(ctx context.Context, projectID string) ([]string, error) {
+	store := s.reservedVersionStore()
+	if store == nil {
+		return []string{}, nil
+	}
+	return store.GetReservedVersions(ctx, projectID)
+}
+
+// checkNotReserved fails with ErrVersionReserved when version is reserved by
+// the app's policy or its project
+func (s *VersionService) checkNotReserved(ctx context.Context, projectID string, policy *models.AppPolicy, version string) error {
+	if policy != nil && containsVersion(policy.ReservedVersions, version) {
+		return fmt.Errorf("%w: %s is reserved by the app's policy", ErrVersionReserved, version)
+	}
+
+	projectVersions, err := s.projectReservedVersions(ctx, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to check reserved versions: %w", err)
+	}
+	if containsVersion(projectVersions, version) {
+		return fmt.Errorf("%w: %s is reserved for project %s", ErrVersionReserved, version, projectID)
+	}
+
+	return nil
+}
+
+// normalizeReservedVersions validates and normalizes a reserved version list
+// under a version scheme and returns it deduplicated in version order
+func (s *VersionService) normalizeReservedVersions(scheme versionScheme, versions []string) ([]string, error) {
+	seen := make(map[string]bool, len(versions))
+	normalized := make([]string, 0, len(versions))
+	for _, version := range versions {
+		canonical, _, err := scheme.canonical(version)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q is not a %s version", ErrInvalidReservedVersions, version, scheme.name())
+		}
+		if seen[canonical] {
+			continue
+		}
+		seen[canonical] = true
+		normalized = append(normalized, canonical)
+	}
+
+	sort.Slice(normalized, func(i, j int) bool {
+		cmp, err := scheme.compare(normalized[i], normalized[j])
+		if err != nil {
+			return normalized[i] < normalized[j]
+		}
+		return cmp < 0
+	})
+
+	return normalized, nil
+}
+
+// containsVersion reports whether versions holds version, comparing by
+// semantic version precedence
+func containsVersion(versions []string, version string) bool {
+	for _, reserved := range versions {
+		if reserved == version {
+			return true
+		}
+		if cmp, err := semver.Compare(reserved, version); err == nil && cmp == 0 {
+			return true
+		}
+	}
+	return false
+}
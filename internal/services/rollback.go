@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// GetVersionHistory returns the chronological list of versions recorded for
+// appID in Git
+func (s *VersionService) GetVersionHistory(ctx context.Context, appID string) ([]models.VersionHistoryEntry, error) {
+	if _, err := s.parseAppID(appID); err != nil {
+		return nil, err
+	}
+
+	history, ok := s.git.(storage.HistoryProvider)
+	if !ok {
+		return nil, fmt.Errorf("Git storage does not support version history")
+	}
+
+	entries, err := history.GetVersionHistory(ctx, appID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read version history: %w", err)
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrAppNotFound, appID)
+	}
+
+	return entries, nil
+}
+
+// RollbackVersion reverts an app to the version it had before its current one,
+// as recorded in the Git history of the versions file
+func (s *VersionService) RollbackVersion(ctx context.Context, appID string) (*models.RollbackResponse, error) {
+	return onApp(ctx, s, appID, func() (*models.RollbackResponse, error) {
+		id, err := s.parseAppID(appID)
+		if err != nil {
+			return nil, err
+		}
+
+		history, ok := s.git.(storage.HistoryProvider)
+		if !ok {
+			return nil, fmt.Errorf("Git storage does not support version history")
+		}
+
+		current, err := s.lookupVersion(ctx, appID)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := checkWritable(appID, current); err != nil {
+			return nil, err
+		}
+
+		if id, err = identifierFromRecord(id, current); err != nil {
+			return nil, err
+		}
+
+		previous, commit, err := history.GetPreviousVersion(ctx, appID, current.Current)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read version history: %w", err)
+		}
+		if previous == nil {
+			return nil, fmt.Errorf("%w for %s", ErrNoPreviousVersion, appID)
+		}
+
+		rolledBack := *current
+		rolledBack.Current = previous.Current
+		rolledBack.ProjectID, rolledBack.AppName = id.ProjectID, id.AppName
+		rolledBack.LastUpdated = time.Now()
+
+		if err := s.saveVersion(ctx, appID, &rolledBack); err != nil {
+			return nil, err
+		}
+
+		s.logger.WithFields(logrus.Fields{
+			"app_id":      appID,
+			"old_version": current.Current,
+			"new_version": previous.Current,
+			"commit":      commit,
+		}).Info("Version rolled back")
+
+		return &models.RollbackResponse{
+			Version:        previous.Current,
+			RolledBackFrom: current.Current,
+			Commit:         commit,
+		}, nil
+	})
+}
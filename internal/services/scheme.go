@@ -0,0 +1,285 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/pkg/calver"
+	"github.com/company/version-service/pkg/semver"
+)
+
+// versionScheme is the format and increment rules of an app's versions,
+// selected by its policy
+type versionScheme interface {
+	name() string
+	// valid checks a version as stored, without normalizing it
+	valid(version string) error
+	// canonical validates a version entering the system and returns its
+	// canonical form with the rewrites applied
+	canonical(version string) (string, []string, error)
+	// next returns the version an increment produces at now. Increments the
+	// scheme has no meaning for fail with ErrIncrementNotAllowed.
+	next(current string, incrementType models.IncrementType, now time.Time) (string, error)
+	compare(v1, v2 string) (int, error)
+	// initial is the version of apps registered without one
+	initial(now time.Time) string
+	// dev validates current and returns the generator of its ephemeral dev
+	// versions, one per build. Generators are safe for concurrent use and
+	// cached per app.
+	dev(current string) (devGenerator, error)
+}
+
+// schemeOf returns the version scheme of an app's policy. Policies are
+// validated on the way in, so unknown schemes fall back to semver.
+func (s *VersionService) schemeOf(policy *models.AppPolicy) versionScheme {
+	switch policy.VersionScheme() {
+	case models.VersionSchemeCalVer:
+		return calverScheme{}
+	case models.VersionSchemeFourPart:
+		return fourPartScheme{}
+	default:
+		return semverScheme{s}
+	}
+}
+
+// validateScheme checks that a policy names a known version scheme
+func validateScheme(scheme string) error {
+	if scheme == "" {
+		return nil
+	}
+	for _, known := range models.VersionSchemes {
+		if scheme == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown version scheme %q", scheme)
+}
+
+// expandDev generates dev versions by expanding the template as is, for
+// schemes without a prerelease field of their own. next returns the version
+// {next} stands for at the build time.
+func expandDev(current string, next func(now time.Time) string) devGenerator {
+	return func(template string, info semver.DevInfo) (string, error) {
+		return semver.ExpandDevTemplate(template, current, next(info.Time), info)
+	}
+}
+
+type semverScheme struct {
+	s *VersionService
+}
+
+func (semverScheme) name() string { return models.VersionSchemeSemVer }
+
+func (semverScheme) valid(version string) error {
+	_, err := semver.Parse(version)
+	return err
+}
+
+func (scheme semverScheme) canonical(version string) (string, []string, error) {
+	return scheme.s.normalizeVersion(version)
+}
+
+func (semverScheme) next(current string, incrementType models.IncrementType, _ time.Time) (string, error) {
+	v, err := semver.Parse(current)
+	if err != nil {
+		return "", fmt.Errorf("invalid semantic version: %w", err)
+	}
+
+	var next *semver.Version
+	switch incrementType {
+	case models.IncrementTypeMajor:
+		next = v.IncrementMajor()
+	case models.IncrementTypeMinor:
+		next = v.IncrementMinor()
+	case models.IncrementTypePatch:
+		next = v.IncrementPatch()
+	case models.IncrementTypeRC:
+		next = v.IncrementRC()
+	case models.IncrementTypeBuild:
+		return "", fmt.Errorf("%w: build increments need the %s scheme", ErrIncrementNotAllowed, models.VersionSchemeFourPart)
+	default:
+		next = v.IncrementPatch()
+	}
+
+	return next.String(), nil
+}
+
+func (semverScheme) compare(v1, v2 string) (int, error) {
+	return semver.Compare(v1, v2)
+}
+
+func (semverScheme) initial(time.Time) string {
+	return defaultInitialVersion
+}
+
+func (semverScheme) dev(current string) (devGenerator, error) {
+	v, err := semver.Parse(current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse version: %w", err)
+	}
+	return func(template string, info semver.DevInfo) (string, error) {
+		dev, err := v.WithDevTemplate(template, info)
+		if err != nil {
+			return "", err
+		}
+		return dev.String(), nil
+	}, nil
+}
+
+// calverScheme issues YYYY.0M.MICRO versions. Every release increment moves
+// to the next release of the current month; the type only matters for
+// policies and hooks.
+type calverScheme struct{}
+
+func (calverScheme) name() string { return models.VersionSchemeCalVer }
+
+func (calverScheme) valid(version string) error {
+	_, err := calver.Parse(version)
+	return err
+}
+
+func (calverScheme) canonical(version string) (string, []string, error) {
+	v, err := calver.Parse(version)
+	if err != nil {
+		return "", nil, err
+	}
+	return v.String(), nil, nil
+}
+
+func (calverScheme) next(current string, incrementType models.IncrementType, now time.Time) (string, error) {
+	v, err := calver.Parse(current)
+	if err != nil {
+		return "", err
+	}
+
+	switch incrementType {
+	case models.IncrementTypeRC, models.IncrementTypeBuild:
+		return "", fmt.Errorf("%w: %s increments are not supported by the %s scheme", ErrIncrementNotAllowed, incrementType, models.VersionSchemeCalVer)
+	}
+
+	return v.Next(now.UTC()).String(), nil
+}
+
+func (calverScheme) compare(v1, v2 string) (int, error) {
+	return calver.Compare(v1, v2)
+}
+
+func (calverScheme) initial(now time.Time) string {
+	return calver.Initial(now.UTC()).String()
+}
+
+func (calverScheme) dev(current string) (devGenerator, error) {
+	v, err := calver.Parse(current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse version: %w", err)
+	}
+	return expandDev(current, func(now time.Time) string {
+		return v.Next(now.UTC()).String()
+	}), nil
+}
+
+var fourPartRegex = regexp.MustCompile(`^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)$`)
+
+// fourPartScheme issues major.minor.patch.build versions. Each increment
+// bumps its segment and resets the ones after it.
+type fourPartScheme struct{}
+
+func (fourPartScheme) name() string { return models.VersionSchemeFourPart }
+
+func parseFourPart(version string) ([4]int, error) {
+	var segments [4]int
+	matches := fourPartRegex.FindStringSubmatch(version)
+	if matches == nil {
+		return segments, fmt.Errorf("invalid four-part version: %s", version)
+	}
+	for i := range segments {
+		n, err := strconv.Atoi(matches[i+1])
+		if err != nil {
+			return segments, fmt.Errorf("invalid four-part version: %s", version)
+		}
+		segments[i] = n
+	}
+	return segments, nil
+}
+
+func formatFourPart(segments [4]int) string {
+	return fmt.Sprintf("%d.%d.%d.%d", segments[0], segments[1], segments[2], segments[3])
+}
+
+func (fourPartScheme) valid(version string) error {
+	_, err := parseFourPart(version)
+	return err
+}
+
+func (fourPartScheme) canonical(version string) (string, []string, error) {
+	if _, err := parseFourPart(version); err != nil {
+		return "", nil, err
+	}
+	return version, nil, nil
+}
+
+func (fourPartScheme) next(current string, incrementType models.IncrementType, _ time.Time) (string, error) {
+	segments, err := parseFourPart(current)
+	if err != nil {
+		return "", err
+	}
+
+	var bumped int
+	switch incrementType {
+	case models.IncrementTypeMajor:
+		bumped = 0
+	case models.IncrementTypeMinor:
+		bumped = 1
+	case models.IncrementTypePatch:
+		bumped = 2
+	case models.IncrementTypeBuild:
+		bumped = 3
+	default:
+		return "", fmt.Errorf("%w: %s increments are not supported by the %s scheme", ErrIncrementNotAllowed, incrementType, models.VersionSchemeFourPart)
+	}
+
+	segments[bumped]++
+	for i := bumped + 1; i < len(segments); i++ {
+		segments[i] = 0
+	}
+	return formatFourPart(segments), nil
+}
+
+func (fourPartScheme) compare(v1, v2 string) (int, error) {
+	a, err := parseFourPart(v1)
+	if err != nil {
+		return 0, err
+	}
+	b, err := parseFourPart(v2)
+	if err != nil {
+		return 0, err
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1, nil
+			}
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func (fourPartScheme) initial(time.Time) string {
+	return "1.0.0.0"
+}
+
+func (fourPartScheme) dev(current string) (devGenerator, error) {
+	segments, err := parseFourPart(current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse version: %w", err)
+	}
+	segments[3]++
+	next := formatFourPart(segments)
+	return expandDev(current, func(time.Time) string {
+		return next
+	}), nil
+}
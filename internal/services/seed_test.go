@@ -0,0 +1,298 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/company/version-service/internal/clients"
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetVersion_GitLabUnavailable(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	ctx := context.Background()
+
+	// GitLab fails twice, then rate limits once before answering
+	var tagCalls atomic.Int32
+	gitLab := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/projects/1/repository/tags" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch tagCalls.Add(1) {
+		case 1, 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 3:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			json.NewEncoder(w).Encode([]clients.GitLabTag{{Name: "v2.4.0"}})
+		}
+	}))
+	defer gitLab.Close()
+
+	gitLabClient := clients.NewGitLabClient(gitLab.URL, "token", logger)
+	gitLabClient.MaxRetries = 1
+	gitLabClient.RetryBaseDelay = time.Millisecond
+
+	cache, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	durable, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	s := NewVersionService(cache, durable, gitLabClient, logger, Options{})
+
+	// The app is not seeded with 1.0.0 while GitLab is down
+	_, err = s.GetVersion(ctx, "1-api")
+	require.ErrorIs(t, err, ErrGitLabUnavailable)
+	assert.EqualValues(t, 2, tagCalls.Load())
+	stored, err := cache.GetVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Nil(t, stored)
+
+	version, err := s.GetVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Equal(t, "2.4.0", version.Current)
+
+	stats := gitLabClient.Stats()
+	assert.EqualValues(t, 2, stats.Retries)
+	assert.EqualValues(t, 1, stats.RateLimited)
+}
+
+func TestGetVersion_GitHubTags(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	ctx := context.Background()
+
+	// Tags are listed by name over two pages; the latest version is on the
+	// second
+	var gitHub *httptest.Server
+	gitHub = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/acme/api/tags" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", `<`+gitHub.URL+`/repos/acme/api/tags?per_page=100&page=2>; rel="next"`)
+			json.NewEncoder(w).Encode([]clients.GitHubTag{{Name: "v1.9.0"}, {Name: "nightly"}})
+			return
+		}
+		json.NewEncoder(w).Encode([]clients.GitHubTag{{Name: "v1.10.2"}})
+	}))
+	defer gitHub.Close()
+
+	scheme, err := models.NewIDScheme(models.IDSchemePath)
+	require.NoError(t, err)
+	cache, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	durable, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	s := NewVersionService(cache, durable, nil, logger, Options{
+		IDScheme:    scheme,
+		TagProvider: clients.NewGitHubClient(gitHub.URL, "", logger),
+	})
+
+	version, err := s.GetVersion(ctx, "acme/api/server")
+	require.NoError(t, err)
+	assert.Equal(t, "1.10.2", version.Current)
+
+	version, err = s.GetVersion(ctx, "acme/missing/server")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", version.Current)
+}
+
+func TestGetVersion_GiteaTags(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	ctx := context.Background()
+
+	var gitea *httptest.Server
+	gitea = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v1/repositories/42":
+			json.NewEncoder(w).Encode(clients.GiteaRepository{ID: 42, FullName: "platform/api"})
+		case r.URL.Path == "/api/v1/repos/platform/api/tags" && r.URL.Query().Get("page") == "":
+			assert.Equal(t, "token secret", r.Header.Get("Authorization"))
+			w.Header().Set("Link", `<`+gitea.URL+`/api/v1/repos/platform/api/tags?limit=50&page=2>; rel="next"`)
+			json.NewEncoder(w).Encode([]clients.GiteaTag{{Name: "v3.0.0-rc.1"}})
+		case r.URL.Path == "/api/v1/repos/platform/api/tags":
+			json.NewEncoder(w).Encode([]clients.GiteaTag{{Name: "v2.7.1"}, {Name: "v3.0.0"}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer gitea.Close()
+
+	cache, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	durable, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	s := NewVersionService(cache, durable, nil, logger, Options{
+		TagProvider: clients.NewGiteaClient(gitea.URL+"/api/v1", "secret", logger),
+	})
+
+	// Numeric repository IDs are resolved to owner/repo
+	version, err := s.GetVersion(ctx, "42-worker")
+	require.NoError(t, err)
+	assert.Equal(t, "3.0.0", version.Current)
+
+	version, err = s.GetVersion(ctx, "43-worker")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", version.Current)
+}
+
+// staticTags is a tag provider answering the same tag for every project
+type staticTags string
+
+func (t staticTags) GetLatestTag(ctx context.Context, projectID string) (string, error) {
+	return string(t), nil
+}
+
+func TestGetVersion_BitbucketRoutes(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	ctx := context.Background()
+
+	bitbucket := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/latest/projects/PLAT/repos/billing/tags" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("start") == "0" {
+			w.Write([]byte(`{"values":[{"displayId":"v4.1.0"}],"isLastPage":false,"nextPageStart":1}`))
+			return
+		}
+		w.Write([]byte(`{"values":[{"displayId":"v4.2.0"}],"isLastPage":true}`))
+	}))
+	defer bitbucket.Close()
+
+	cache, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	durable, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	tags := clients.NewTagProviderRegistry(logger)
+	tags.Register(clients.TagProviderGitLab, staticTags("2.0.0"))
+	tags.Register(clients.TagProviderBitbucket, clients.NewBitbucketClient(bitbucket.URL, "token", logger))
+	require.NoError(t, tags.SetChain(clients.TagProviderGitLab))
+	require.NoError(t, tags.Route("7", clients.TagProviderBitbucket, "PLAT/billing"))
+	s := NewVersionService(cache, durable, nil, logger, Options{TagProvider: tags})
+
+	version, err := s.GetVersion(ctx, "7-billing")
+	require.NoError(t, err)
+	assert.Equal(t, "4.2.0", version.Current)
+
+	version, err = s.GetVersion(ctx, "8-api")
+	require.NoError(t, err)
+	assert.Equal(t, "2.0.0", version.Current)
+}
+
+// failingTags is a tag provider failing every lookup with err
+type failingTags struct{ err error }
+
+func (t failingTags) GetLatestTag(ctx context.Context, projectID string) (string, error) {
+	return "", t.err
+}
+
+func TestGetVersion_TagProviderChain(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	ctx := context.Background()
+
+	scheme, err := models.NewIDScheme(models.IDSchemePath)
+	require.NoError(t, err)
+	newService := func(chain ...clients.TagProvider) *VersionService {
+		tags := clients.NewTagProviderRegistry(logger)
+		var names []string
+		for i, provider := range chain {
+			name := fmt.Sprintf("provider-%d", i)
+			tags.Register(name, provider)
+			names = append(names, name)
+		}
+		require.NoError(t, tags.SetChain(names...))
+
+		cache, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+		require.NoError(t, err)
+		durable, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+		require.NoError(t, err)
+		return NewVersionService(cache, durable, nil, logger, Options{IDScheme: scheme, TagProvider: tags})
+	}
+
+	t.Run("first provider with a tag wins", func(t *testing.T) {
+		s := newService(staticTags(""), failingTags{errors.New("bad repository")}, staticTags("3.1.0"), staticTags("9.0.0"))
+		version, err := s.GetVersion(ctx, "acme/api/server")
+		require.NoError(t, err)
+		assert.Equal(t, "3.1.0", version.Current)
+	})
+
+	t.Run("unavailable forge blocks seeding", func(t *testing.T) {
+		s := newService(staticTags(""), failingTags{clients.ErrGitHubUnavailable})
+		_, err := s.GetVersion(ctx, "acme/api/server")
+		require.ErrorIs(t, err, ErrGitLabUnavailable)
+	})
+
+	t.Run("unavailable forge ignored once another has a tag", func(t *testing.T) {
+		s := newService(failingTags{clients.ErrGitLabUnavailable}, staticTags("1.4.0"))
+		version, err := s.GetVersion(ctx, "acme/api/server")
+		require.NoError(t, err)
+		assert.Equal(t, "1.4.0", version.Current)
+	})
+}
+
+func TestGetVersion_ProjectMetadata(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	ctx := context.Background()
+
+	var projectCalls atomic.Int32
+	gitLab := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/projects/1":
+			projectCalls.Add(1)
+			json.NewEncoder(w).Encode(clients.GitLabProject{
+				ID:                1,
+				PathWithNamespace: "platform/api",
+				DefaultBranch:     "main",
+				WebURL:            "https://gitlab.example.com/platform/api",
+			})
+		case "/projects/1/repository/tags":
+			json.NewEncoder(w).Encode([]clients.GitLabTag{{Name: "v1.2.0"}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer gitLab.Close()
+
+	cache, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	durable, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	s := NewVersionService(cache, durable, clients.NewGitLabClient(gitLab.URL, "token", logger), logger, Options{})
+
+	version, err := s.GetVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Equal(t, "platform/api", version.RepoName)
+	assert.Equal(t, "main", version.DefaultBranch)
+	assert.Equal(t, "https://gitlab.example.com/platform/api", version.WebURL)
+
+	// Increments keep the metadata, looked up once per project
+	_, err = s.IncrementVersion(ctx, "1-api", models.IncrementTypeMinor, "")
+	require.NoError(t, err)
+	versions, err := s.ListVersions(ctx)
+	require.NoError(t, err)
+	require.Contains(t, versions, "1-api")
+	assert.Equal(t, "main", versions["1-api"].DefaultBranch)
+	assert.Equal(t, "https://gitlab.example.com/platform/api", versions["1-api"].WebURL)
+	assert.EqualValues(t, 1, projectCalls.Load())
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+)
+
+// SimulateProject computes the versions a set of bumps would give a
+// project's apps and the policies and constraints executing them would
+// break, without writing anything. Pre-increment hooks are not called,
+// since they are external and may act on what they are sent.
+func (s *VersionService) SimulateProject(ctx context.Context, projectID string, bumps map[string]models.IncrementType) (*models.SimulationReport, error) {
+	if len(bumps) == 0 {
+		return nil, fmt.Errorf("%w: no bumps given", ErrInvalidBatch)
+	}
+	if len(bumps) > MaxBatchSize {
+		return nil, fmt.Errorf("%w: at most %d apps per simulation", ErrInvalidBatch, MaxBatchSize)
+	}
+
+	versions, err := s.ListVersionsByProject(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.SimulationReport{
+		ProjectID:   projectID,
+		Versions:    make(map[string]models.SimulatedVersion, len(versions)),
+		Violations:  []models.SimulationViolation{},
+		GeneratedAt: time.Now(),
+	}
+	for appID, version := range versions {
+		report.Versions[appID] = models.SimulatedVersion{Current: version.Current}
+	}
+
+	violate := func(appID string, err error) {
+		report.Violations = append(report.Violations, models.SimulationViolation{
+			AppID:   appID,
+			Code:    violationCode(err),
+			Message: err.Error(),
+		})
+	}
+
+	appIDs := make([]string, 0, len(bumps))
+	for appID := range bumps {
+		appIDs = append(appIDs, appID)
+	}
+	sort.Strings(appIDs)
+
+	for _, appID := range appIDs {
+		current, ok := versions[appID]
+		if !ok {
+			// Bumped apps of other projects or not listed yet are looked up
+			// one by one, without seeding unknown apps
+			current, err = s.lookupVersion(ctx, appID)
+			if errors.Is(err, ErrAppNotFound) {
+				violate(appID, err)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if !models.InProject(appID, current, projectID) {
+				violate(appID, fmt.Errorf("%w: %s is not an app of project %s", ErrAppNotInProject, appID, projectID))
+				continue
+			}
+		}
+
+		next, appType, err := s.simulateIncrement(ctx, projectID, appID, current, bumps[appID])
+		simulated := models.SimulatedVersion{Current: current.Current, Type: appType}
+		if err != nil {
+			violate(appID, err)
+		} else {
+			simulated.Next = next
+		}
+		report.Versions[appID] = simulated
+	}
+
+	if err := s.checkProjectedQuota(ctx, projectID, int64(len(bumps))); err != nil {
+		violate("", err)
+	}
+
+	report.Valid = len(report.Violations) == 0
+	return report, nil
+}
+
+// simulateIncrement runs the checks of an increment without its hooks and
+// returns the version it would issue and the increment type applied
+func (s *VersionService) simulateIncrement(ctx context.Context, projectID, appID string, current *models.AppVersion, requested models.IncrementType) (string, models.IncrementType, error) {
+	if err := checkWritable(appID, current); err != nil {
+		return "", "", err
+	}
+
+	appType := effectiveIncrement(current.Policy, current.Current, requested)
+	if !current.Policy.AllowsIncrement(appType) {
+		return "", appType, fmt.Errorf("%w: %s increments are not allowed for %s", ErrIncrementNotAllowed, appType, appID)
+	}
+
+	next, err := s.calculateNextVersion(current.Policy, current.Current, requested)
+	if err != nil {
+		return "", appType, fmt.Errorf("%w: %s: %w", ErrInvalidVersion, appID, err)
+	}
+
+	if err := s.checkNotReserved(ctx, projectID, current.Policy, next); err != nil {
+		return "", appType, err
+	}
+
+	return next, appType, nil
+}
+
+// checkProjectedQuota reports whether count more increments fit in the
+// project's hourly increment quota. Unlike checkIncrementQuota it never
+// alerts.
+func (s *VersionService) checkProjectedQuota(ctx context.Context, projectID string, count int64) error {
+	limit := s.quotas.MaxIncrementsPerHour
+	tracker := s.usageTracker()
+	if limit <= 0 || tracker == nil {
+		return nil
+	}
+
+	used, err := tracker.CountIncrements(ctx, projectID, time.Now().Add(-time.Hour))
+	if err != nil {
+		s.logger.WithError(err).WithField("project_id", projectID).Warn("Failed to check increment quota")
+		return nil
+	}
+
+	if used+count > int64(limit) {
+		return fmt.Errorf("%w: project %s has %d increments in the last hour, %d more exceed the limit of %d", ErrQuotaExceeded, projectID, used, count, limit)
+	}
+	return nil
+}
+
+// violationCode is the error code the API answers err with
+func violationCode(err error) string {
+	switch {
+	case errors.Is(err, ErrAppNotInProject):
+		return "APP_NOT_IN_PROJECT"
+	case errors.Is(err, ErrAppDeleted):
+		return "APP_DELETED"
+	case errors.Is(err, ErrAppNotFound):
+		return "APP_NOT_FOUND"
+	case errors.Is(err, ErrVersionLocked):
+		return "VERSION_LOCKED"
+	case errors.Is(err, ErrIncrementNotAllowed):
+		return "INCREMENT_NOT_ALLOWED"
+	case errors.Is(err, ErrVersionReserved):
+		return "VERSION_RESERVED"
+	case errors.Is(err, ErrInvalidVersion):
+		return "INVALID_VERSION"
+	case errors.Is(err, ErrQuotaExceeded):
+		return "QUOTA_EXCEEDED"
+	default:
+		return "SIMULATION_FAILED"
+	}
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulateProject(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	cache, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	durable, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	s := NewVersionService(cache, durable, nil, logger, Options{})
+	ctx := context.Background()
+
+	apps := map[string]*models.AppVersion{
+		"1-api":    {Current: "1.2.3", ProjectID: "1", AppName: "api"},
+		"1-web":    {Current: "2.0.0", ProjectID: "1", AppName: "web", Policy: &models.AppPolicy{AllowedIncrements: []models.IncrementType{models.IncrementTypePatch}}},
+		"1-worker": {Current: "0.9.0", ProjectID: "1", AppName: "worker", Lifecycle: models.LifecycleFrozen},
+		"1-docs":   {Current: "3.1.0", ProjectID: "1", AppName: "docs", Policy: &models.AppPolicy{ReservedVersions: []string{"3.2.0"}}},
+		"2-api":    {Current: "5.0.0", ProjectID: "2", AppName: "api"},
+	}
+	for appID, version := range apps {
+		require.NoError(t, durable.SetVersion(ctx, appID, version))
+	}
+
+	report, err := s.SimulateProject(ctx, "1", map[string]models.IncrementType{
+		"1-api":     models.IncrementTypeMinor,
+		"1-web":     models.IncrementTypeMajor,
+		"1-worker":  models.IncrementTypePatch,
+		"1-docs":    models.IncrementTypeMinor,
+		"2-api":     models.IncrementTypePatch,
+		"1-missing": models.IncrementTypePatch,
+	})
+	require.NoError(t, err)
+
+	assert.False(t, report.Valid)
+	assert.Equal(t, models.SimulatedVersion{Current: "1.2.3", Next: "1.3.0", Type: models.IncrementTypeMinor}, report.Versions["1-api"])
+	assert.Empty(t, report.Versions["1-web"].Next)
+	assert.NotContains(t, report.Versions, "2-api")
+
+	codes := make(map[string]string)
+	for _, violation := range report.Violations {
+		codes[violation.AppID] = violation.Code
+	}
+	assert.Equal(t, map[string]string{
+		"1-web":     "INCREMENT_NOT_ALLOWED",
+		"1-worker":  "VERSION_LOCKED",
+		"1-docs":    "VERSION_RESERVED",
+		"2-api":     "APP_NOT_IN_PROJECT",
+		"1-missing": "APP_NOT_FOUND",
+	}, codes)
+
+	// Nothing was written
+	version, err := durable.GetVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.3", version.Current)
+
+	report, err = s.SimulateProject(ctx, "1", map[string]models.IncrementType{"1-api": models.IncrementTypeDefault})
+	require.NoError(t, err)
+	assert.True(t, report.Valid)
+	assert.Equal(t, "1.2.4", report.Versions["1-api"].Next)
+
+	_, err = s.SimulateProject(ctx, "1", nil)
+	assert.ErrorIs(t, err, ErrInvalidBatch)
+}
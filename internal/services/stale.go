@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// Actions the background stale check takes on stale apps
+const (
+	// StaleActionFlag annotates stale apps with StaleAnnotation
+	StaleActionFlag = "flag"
+	// StaleActionArchive deletes stale apps, leaving restorable tombstones
+	StaleActionArchive = "archive"
+)
+
+// StaleAnnotation is set by the flag action to the date an app was first
+// found stale, and removed once the app is updated again
+const StaleAnnotation = "stale_since"
+
+// StaleOptions configures the background stale check. The check is disabled
+// when Days is zero.
+type StaleOptions struct {
+	Days     int
+	Action   string
+	Interval time.Duration
+}
+
+// ListStaleVersions returns the live apps whose LastUpdated is more than days
+// ago, least recently updated first
+func (s *VersionService) ListStaleVersions(ctx context.Context, days int) (*models.StaleReport, error) {
+	if days <= 0 {
+		return nil, fmt.Errorf("%w: days must be positive", ErrInvalidStaleThreshold)
+	}
+
+	versions, err := s.ListVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return staleReport(versions, days, time.Now()), nil
+}
+
+func staleReport(versions map[string]*models.AppVersion, days int, now time.Time) *models.StaleReport {
+	cutoff := now.AddDate(0, 0, -days)
+
+	apps := make([]models.StaleApp, 0)
+	for appID, version := range versions {
+		if !version.LastUpdated.Before(cutoff) {
+			continue
+		}
+		apps = append(apps, models.StaleApp{
+			AppID:       appID,
+			ProjectID:   version.ProjectID,
+			AppName:     version.AppName,
+			Version:     version.Current,
+			LastUpdated: version.LastUpdated,
+			IdleDays:    int(now.Sub(version.LastUpdated).Hours() / 24),
+		})
+	}
+
+	sort.Slice(apps, func(i, j int) bool {
+		if !apps[i].LastUpdated.Equal(apps[j].LastUpdated) {
+			return apps[i].LastUpdated.Before(apps[j].LastUpdated)
+		}
+		return apps[i].AppID < apps[j].AppID
+	})
+
+	return &models.StaleReport{
+		Days:        days,
+		Cutoff:      cutoff,
+		Apps:        apps,
+		Count:       len(apps),
+		GeneratedAt: now,
+	}
+}
+
+func (s *VersionService) periodicStaleCheck() {
+	interval := s.stale.Interval
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.RunStaleCheck(context.Background()); err != nil {
+			s.logger.WithError(err).Error("Stale app check failed")
+		}
+		<-ticker.C
+	}
+}
+
+// RunStaleCheck applies the configured action to every stale app. Flagging
+// keeps LastUpdated, so flagged apps stay stale until they are updated, and
+// clears the flag from apps updated since. All changes go to Git in one
+// commit.
+func (s *VersionService) RunStaleCheck(ctx context.Context) (*models.StaleReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	versions, err := s.ListVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	report := staleReport(versions, s.stale.Days, now)
+	report.Action = s.stale.Action
+
+	changed := make(map[string]*models.AppVersion)
+	stale := make(map[string]bool, len(report.Apps))
+	for _, app := range report.Apps {
+		stale[app.AppID] = true
+		current := versions[app.AppID]
+
+		switch s.stale.Action {
+		case StaleActionArchive:
+			archived := *current
+			archived.DeletedAt = &now
+			archived.LastUpdated = now
+			changed[app.AppID] = &archived
+		default:
+			if _, flagged := current.Annotations[StaleAnnotation]; flagged {
+				continue
+			}
+			changed[app.AppID] = withAnnotation(current, StaleAnnotation, now.Format("2006-01-02"))
+		}
+	}
+
+	for appID, version := range versions {
+		if _, flagged := version.Annotations[StaleAnnotation]; flagged && !stale[appID] {
+			changed[appID] = withoutAnnotation(version, StaleAnnotation)
+		}
+	}
+
+	if len(changed) > 0 {
+		if err := s.saveVersions(ctx, changed); err != nil {
+			return nil, err
+		}
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"stale":   report.Count,
+		"changed": len(changed),
+		"action":  report.Action,
+		"days":    report.Days,
+	}).Info("Stale app check completed")
+
+	return report, nil
+}
+
+// withAnnotation returns a copy of version with one annotation set
+func withAnnotation(version *models.AppVersion, key, value string) *models.AppVersion {
+	updated := *version
+	updated.Annotations = make(map[string]string, len(version.Annotations)+1)
+	for k, v := range version.Annotations {
+		updated.Annotations[k] = v
+	}
+	updated.Annotations[key] = value
+	return &updated
+}
+
+// withoutAnnotation returns a copy of version with one annotation removed
+func withoutAnnotation(version *models.AppVersion, key string) *models.AppVersion {
+	updated := *version
+	updated.Annotations = make(map[string]string, len(version.Annotations))
+	for k, v := range version.Annotations {
+		if k != key {
+			updated.Annotations[k] = v
+		}
+	}
+	if len(updated.Annotations) == 0 {
+		updated.Annotations = nil
+	}
+	return &updated
+}
@@ -0,0 +1,330 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// ExportState returns a bundle of the complete service state: the versions
+// file as stored in Git, tombstones included, the Git history of every app
+// when includeHistory is set, project reservations and webhook subscriptions
+// with their secrets.
+func (s *VersionService) ExportState(ctx context.Context, includeHistory bool) (*models.StateBundle, error) {
+	store, err := s.rawFileStore()
+	if err != nil {
+		return nil, err
+	}
+
+	// Let queued writes land so the exported file includes them
+	s.mu.Lock()
+	s.persistence.flush()
+	data, revision, err := store.ReadVersionsFile(ctx)
+	s.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read versions file: %w", err)
+	}
+
+	var vf models.VersionsFile
+	if err := json.Unmarshal(data, &vf); err != nil {
+		return nil, fmt.Errorf("failed to decode versions file: %w", err)
+	}
+	if vf.Versions == nil {
+		vf.Versions = make(map[string]*models.AppVersion)
+	}
+
+	bundle := &models.StateBundle{
+		FormatVersion: models.StateBundleFormatVersion,
+		ExportedAt:    time.Now(),
+		Revision:      revision,
+		Versions:      vf.Versions,
+	}
+
+	if includeHistory {
+		if transfer, ok := s.git.(storage.HistoryTransfer); ok {
+			if bundle.History, err = transfer.ExportHistory(ctx); err != nil {
+				return nil, fmt.Errorf("failed to export history: %w", err)
+			}
+		}
+	}
+
+	if bundle.ReservedVersions, err = s.exportReservedVersions(ctx); err != nil {
+		return nil, err
+	}
+	if bundle.Webhooks, err = s.exportWebhooks(ctx); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"revision": revision,
+		"apps":     len(bundle.Versions),
+		"history":  len(bundle.History),
+		"webhooks": len(bundle.Webhooks),
+	}).Info("Service state exported")
+
+	return bundle, nil
+}
+
+func (s *VersionService) exportReservedVersions(ctx context.Context) (map[string][]string, error) {
+	store := s.reservedVersionStore()
+	if store == nil {
+		return nil, nil
+	}
+
+	projects, err := store.ListReservedProjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reserved := make(map[string][]string, len(projects))
+	for _, projectID := range projects {
+		versions, err := store.GetReservedVersions(ctx, projectID)
+		if err != nil {
+			return nil, err
+		}
+		if len(versions) > 0 {
+			reserved[projectID] = versions
+		}
+	}
+	return reserved, nil
+}
+
+func (s *VersionService) exportWebhooks(ctx context.Context) ([]models.WebhookSubscription, error) {
+	store := s.webhookStore()
+	if store == nil {
+		return nil, nil
+	}
+
+	projects, err := store.ListWebhookProjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var webhooks []models.WebhookSubscription
+	for _, projectID := range projects {
+		subscriptions, err := store.ListWebhooks(ctx, projectID)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, subscriptions...)
+	}
+	return webhooks, nil
+}
+
+// ImportState restores a state bundle. The whole bundle is validated before
+// anything is written. On an empty versions branch the bundle's history is
+// replayed commit by commit before its versions file, giving a cold-started
+// deployment the same history; otherwise the versions file replaces the
+// current one in a single commit and the history is left out. The Redis
+// cache is rebuilt, project reservations in the bundle replace the current
+// ones and its webhook subscriptions are saved alongside existing ones.
+func (s *VersionService) ImportState(ctx context.Context, bundle *models.StateBundle) (*models.StateImportReport, error) {
+	store, err := s.rawFileStore()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validateStateBundle(bundle); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidStateBundle, err)
+	}
+
+	report := &models.StateImportReport{
+		Apps:      len(bundle.Versions),
+		StartedAt: time.Now(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Let queued single-app writes land first so none of them overwrites
+	// the imported file
+	s.persistence.flush()
+
+	vf := &models.VersionsFile{Versions: bundle.Versions}
+	message := fmt.Sprintf("Import service state (%d apps)", len(vf.Versions))
+
+	var revision string
+	bootstrapper, canBootstrap := s.git.(storage.Bootstrapper)
+	transfer, canReplay := s.git.(storage.HistoryTransfer)
+	switch {
+	case canBootstrap && canReplay && bootstrapper.IsEmpty() && len(bundle.History) > 0:
+		steps := historySteps(bundle.History)
+		revision, err = transfer.ReplayHistory(ctx, steps, vf, message)
+		report.HistoryReplayed = true
+		report.HistoryCommits = len(steps)
+	case canBootstrap && bootstrapper.IsEmpty():
+		revision, err = bootstrapper.Bootstrap(ctx, vf, message)
+	default:
+		if len(bundle.History) > 0 {
+			report.Warnings = append(report.Warnings, "history not replayed: the versions branch already has commits")
+		}
+		revision, err = store.ReplaceVersionsFile(ctx, vf, "", message)
+	}
+
+	report.Pushed = true
+	if err != nil {
+		if revision == "" || !s.isPushFailure(err) {
+			return nil, fmt.Errorf("failed to write versions file: %w", err)
+		}
+		report.Pushed = false
+		s.markPushNeeded()
+	}
+	report.Revision = revision
+
+	if err := s.redis.RebuildCache(ctx, vf.Versions); err != nil {
+		s.logger.WithError(err).Warn("Failed to rebuild Redis cache after state import")
+		report.Warnings = append(report.Warnings, fmt.Sprintf("redis: %v", err))
+	}
+	s.changedAll(ctx)
+
+	report.Warnings = append(report.Warnings, s.importReservedVersions(ctx, bundle.ReservedVersions, report)...)
+	report.Warnings = append(report.Warnings, s.importWebhooks(ctx, bundle.Webhooks, report)...)
+	report.CompletedAt = time.Now()
+
+	s.logger.WithFields(logrus.Fields{
+		"revision":         report.Revision,
+		"apps":             report.Apps,
+		"history_replayed": report.HistoryReplayed,
+		"history_commits":  report.HistoryCommits,
+		"webhooks":         report.Webhooks,
+		"pushed":           report.Pushed,
+	}).Warn("Service state imported")
+
+	return report, nil
+}
+
+// validateStateBundle checks a bundle as a whole and normalizes it in place
+func (s *VersionService) validateStateBundle(bundle *models.StateBundle) error {
+	if bundle.FormatVersion != models.StateBundleFormatVersion {
+		return fmt.Errorf("unsupported format version %d (expected %d)", bundle.FormatVersion, models.StateBundleFormatVersion)
+	}
+
+	if _, err := s.validateVersionsFile(&models.VersionsFile{Versions: bundle.Versions}); err != nil {
+		return fmt.Errorf("versions: %v", err)
+	}
+
+	for appID, entries := range bundle.History {
+		if strings.TrimSpace(appID) == "" {
+			return fmt.Errorf("history: empty app ID")
+		}
+		for _, entry := range entries {
+			if entry.Version == "" || entry.Timestamp.IsZero() {
+				return fmt.Errorf("history of %s: entries need a version and a timestamp", appID)
+			}
+		}
+	}
+
+	for projectID, versions := range bundle.ReservedVersions {
+		if strings.TrimSpace(projectID) == "" {
+			return fmt.Errorf("reserved versions: empty project ID")
+		}
+		normalized, err := s.normalizeReservedVersions(semverScheme{s}, versions)
+		if err != nil {
+			return fmt.Errorf("reserved versions of %s: %v", projectID, err)
+		}
+		bundle.ReservedVersions[projectID] = normalized
+	}
+
+	seen := make(map[string]bool, len(bundle.Webhooks))
+	for i := range bundle.Webhooks {
+		webhook := &bundle.Webhooks[i]
+		if webhook.ID == "" || strings.TrimSpace(webhook.ProjectID) == "" {
+			return fmt.Errorf("webhooks: id and project_id are required")
+		}
+		key := webhook.ProjectID + "/" + webhook.ID
+		if seen[key] {
+			return fmt.Errorf("webhooks: %s is listed more than once", key)
+		}
+		seen[key] = true
+
+		if err := validateWebhookURL(webhook.URL); err != nil {
+			return fmt.Errorf("webhook %s: %v", webhook.ID, err)
+		}
+		events, err := validateWebhookEvents(webhook.Events)
+		if err != nil {
+			return fmt.Errorf("webhook %s: %v", webhook.ID, err)
+		}
+		webhook.Events = events
+	}
+
+	return nil
+}
+
+// historySteps turns per-app histories into replay steps, one per original
+// commit, oldest first
+func historySteps(history map[string][]models.VersionHistoryEntry) []models.HistoryStep {
+	byCommit := make(map[string]*models.HistoryStep)
+	for appID, entries := range history {
+		for _, entry := range entries {
+			key := entry.Commit
+			if key == "" {
+				key = entry.Timestamp.UTC().Format(time.RFC3339Nano)
+			}
+
+			step, ok := byCommit[key]
+			if !ok {
+				step = &models.HistoryStep{Timestamp: entry.Timestamp, Versions: make(map[string]string)}
+				byCommit[key] = step
+			}
+			step.Versions[appID] = entry.Version
+		}
+	}
+
+	steps := make([]models.HistoryStep, 0, len(byCommit))
+	for _, step := range byCommit {
+		steps = append(steps, *step)
+	}
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].Timestamp.Before(steps[j].Timestamp)
+	})
+	return steps
+}
+
+func (s *VersionService) importReservedVersions(ctx context.Context, reserved map[string][]string, report *models.StateImportReport) []string {
+	if len(reserved) == 0 {
+		return nil
+	}
+
+	store := s.reservedVersionStore()
+	if store == nil {
+		return []string{"reserved versions not imported: not supported by the cache storage"}
+	}
+
+	var warnings []string
+	for projectID, versions := range reserved {
+		if err := store.SetReservedVersions(ctx, projectID, versions); err != nil {
+			warnings = append(warnings, fmt.Sprintf("reserved versions of %s: %v", projectID, err))
+			continue
+		}
+		report.ReservedProjects++
+	}
+	return warnings
+}
+
+func (s *VersionService) importWebhooks(ctx context.Context, webhooks []models.WebhookSubscription, report *models.StateImportReport) []string {
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	store := s.webhookStore()
+	if store == nil {
+		return []string{"webhooks not imported: not supported by the cache storage"}
+	}
+
+	var warnings []string
+	for i := range webhooks {
+		if err := store.SaveWebhook(ctx, &webhooks[i]); err != nil {
+			warnings = append(warnings, fmt.Sprintf("webhook %s: %v", webhooks[i].ID, err))
+			continue
+		}
+		report.Webhooks++
+	}
+	return warnings
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+)
+
+// cacheMetrics counts version reads and writes against the Redis cache
+type cacheMetrics struct {
+	hits        atomic.Int64
+	misses      atomic.Int64
+	readErrors  atomic.Int64
+	writes      atomic.Int64
+	writeErrors atomic.Int64
+}
+
+// cacheGet reads an app's version from Redis, counting the read
+func (s *VersionService) cacheGet(ctx context.Context, appID string) (*models.AppVersion, error) {
+	if err := s.redisBreaker.Allow(); err != nil {
+		s.cacheMetrics.readErrors.Add(1)
+		return nil, fmt.Errorf("cache unavailable: %w", err)
+	}
+
+	version, err := s.redis.GetVersion(ctx, appID)
+	s.recordCache(err)
+	switch {
+	case err != nil:
+		s.cacheMetrics.readErrors.Add(1)
+	case version == nil:
+		s.cacheMetrics.misses.Add(1)
+	default:
+		s.cacheMetrics.hits.Add(1)
+	}
+	return version, err
+}
+
+// cacheSet writes an app's version to Redis, counting the write
+func (s *VersionService) cacheSet(ctx context.Context, appID string, version *models.AppVersion) error {
+	s.cacheMetrics.writes.Add(1)
+	if err := s.redisBreaker.Allow(); err != nil {
+		s.cacheMetrics.writeErrors.Add(1)
+		return fmt.Errorf("cache unavailable: %w", err)
+	}
+
+	err := s.redis.SetVersion(ctx, appID, version)
+	s.recordCache(err)
+	if err != nil {
+		s.cacheMetrics.writeErrors.Add(1)
+	}
+	return err
+}
+
+// cacheSetIf writes an app's version to Redis unless another writer changed
+// it since expected was read, counting the write. Caches that can't check
+// are written unconditionally.
+func (s *VersionService) cacheSetIf(ctx context.Context, appID string, expected, version *models.AppVersion) error {
+	conditional, ok := s.redis.(storage.ConditionalSetter)
+	if !ok {
+		return s.cacheSet(ctx, appID, version)
+	}
+
+	s.cacheMetrics.writes.Add(1)
+	if err := s.redisBreaker.Allow(); err != nil {
+		s.cacheMetrics.writeErrors.Add(1)
+		return fmt.Errorf("cache unavailable: %w", err)
+	}
+
+	err := conditional.SetVersionIf(ctx, appID, expected, version)
+	s.recordCache(err)
+	if err != nil && !errors.Is(err, storage.ErrRevisionMismatch) {
+		s.cacheMetrics.writeErrors.Add(1)
+	}
+	return err
+}
+
+// cacheSetAllIf is cacheSetIf for a batch, with expected keyed by app ID.
+// Redis writes the whole batch or none of it.
+func (s *VersionService) cacheSetAllIf(ctx context.Context, expected, versions map[string]*models.AppVersion) error {
+	conditional, ok := s.redis.(storage.ConditionalSetter)
+	if !ok {
+		for appID, version := range versions {
+			if err := s.cacheSet(ctx, appID, version); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	s.cacheMetrics.writes.Add(1)
+	if err := s.redisBreaker.Allow(); err != nil {
+		s.cacheMetrics.writeErrors.Add(1)
+		return fmt.Errorf("cache unavailable: %w", err)
+	}
+
+	err := conditional.SetVersionsIf(ctx, expected, versions)
+	s.recordCache(err)
+	if err != nil && !errors.Is(err, storage.ErrRevisionMismatch) {
+		s.cacheMetrics.writeErrors.Add(1)
+	}
+	return err
+}
+
+// RuntimeStats returns the Git, Redis and GitLab counters since startup
+func (s *VersionService) RuntimeStats(ctx context.Context) (*models.RuntimeStats, error) {
+	now := time.Now()
+	stats := &models.RuntimeStats{
+		StartedAt:     s.startedAt,
+		UptimeSeconds: now.Sub(s.startedAt).Seconds(),
+		GeneratedAt:   now,
+	}
+
+	s.gitMetricsMu.RLock()
+	metrics := s.gitMetrics
+	s.gitMetricsMu.RUnlock()
+
+	stats.Git = models.GitStats{
+		OperationsTotal:     metrics.operationsTotal,
+		OperationsSucceeded: metrics.operationsSucceeded,
+		OperationsFailed:    metrics.operationsFailed,
+		RetriesTotal:        metrics.retriesTotal,
+		AvgLatencyMs:        metrics.avgLatencyMs,
+	}
+	if metrics.operationsTotal > 0 {
+		stats.Git.SuccessRatePct = float64(metrics.operationsSucceeded) / float64(metrics.operationsTotal) * 100
+	}
+	if !metrics.lastOperationTime.IsZero() {
+		last := metrics.lastOperationTime
+		stats.Git.LastOperationAt = &last
+	}
+
+	stats.Redis = models.RedisStats{
+		Hits:        s.cacheMetrics.hits.Load(),
+		Misses:      s.cacheMetrics.misses.Load(),
+		ReadErrors:  s.cacheMetrics.readErrors.Load(),
+		Writes:      s.cacheMetrics.writes.Load(),
+		WriteErrors: s.cacheMetrics.writeErrors.Load(),
+	}
+	stats.Redis.Reads = stats.Redis.Hits + stats.Redis.Misses + stats.Redis.ReadErrors
+	if stats.Redis.Reads > 0 {
+		stats.Redis.HitRatePct = float64(stats.Redis.Hits) / float64(stats.Redis.Reads) * 100
+	}
+	if pool, ok := s.redis.(storage.PoolStatter); ok {
+		poolStats := pool.PoolStats()
+		stats.Redis.Pool = &poolStats
+	}
+
+	if s.gitLabClient != nil {
+		stats.GitLab = s.gitLabClient.Stats()
+	}
+
+	return stats, nil
+}
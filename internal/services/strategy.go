@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// validateStrategy checks that a policy's increment strategy is an increment
+// type or date-stamped patches, fits the policy's scheme and is allowed by
+// the policy
+func validateStrategy(policy *models.AppPolicy) error {
+	if policy == nil || policy.Strategy == "" {
+		return nil
+	}
+
+	scheme := policy.VersionScheme()
+	switch policy.Strategy {
+	case models.StrategyDatePatch, string(models.IncrementTypeRC):
+		if scheme != models.VersionSchemeSemVer {
+			return fmt.Errorf("%w: %s needs the %s scheme", ErrInvalidStrategy, policy.Strategy, models.VersionSchemeSemVer)
+		}
+	case string(models.IncrementTypeBuild):
+		if scheme != models.VersionSchemeFourPart {
+			return fmt.Errorf("%w: %s needs the %s scheme", ErrInvalidStrategy, policy.Strategy, models.VersionSchemeFourPart)
+		}
+	case string(models.IncrementTypePatch), string(models.IncrementTypeMinor), string(models.IncrementTypeMajor):
+	default:
+		return fmt.Errorf("%w: unknown strategy %q", ErrInvalidStrategy, policy.Strategy)
+	}
+
+	if incrementType := policy.IncrementFor(models.IncrementTypeDefault); !policy.AllowsIncrement(incrementType) {
+		return fmt.Errorf("%w: %s increments are not in allowed_increments", ErrInvalidStrategy, incrementType)
+	}
+	return nil
+}
+
+// SetIncrementStrategy sets the increment an app gets when a request names no
+// type. An empty strategy restores patch increments.
+func (s *VersionService) SetIncrementStrategy(ctx context.Context, appID, strategy string) (*models.AppVersion, error) {
+	return onApp(ctx, s, appID, func() (*models.AppVersion, error) {
+		if _, err := s.parseAppID(appID); err != nil {
+			return nil, err
+		}
+
+		current, err := s.lookupVersion(ctx, appID)
+		if err != nil {
+			return nil, err
+		}
+
+		policy := models.AppPolicy{}
+		if current.Policy != nil {
+			policy = *current.Policy
+		}
+		policy.Strategy = strategy
+		if err := validateStrategy(&policy); err != nil {
+			return nil, err
+		}
+
+		updated := *current
+		updated.Policy = &policy
+		updated.LastUpdated = time.Now()
+		if err := s.saveVersion(ctx, appID, &updated); err != nil {
+			return nil, err
+		}
+
+		s.logger.WithFields(logrus.Fields{
+			"app_id":   appID,
+			"strategy": strategy,
+		}).Info("Increment strategy updated")
+
+		return &updated, nil
+	})
+}
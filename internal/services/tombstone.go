@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// RestoreVersion brings back a deleted app from its tombstone with the
+// version, aliases and annotations it had when it was deleted. The app
+// counts against its project's quota again.
+func (s *VersionService) RestoreVersion(ctx context.Context, appID string) (*models.AppVersion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, err := s.parseAppID(appID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.lookupRecord(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !current.IsDeleted() {
+		return nil, fmt.Errorf("%w: %s", ErrAppNotDeleted, appID)
+	}
+
+	if id, err = identifierFromRecord(id, current); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkAppQuota(ctx, id.ProjectID); err != nil {
+		return nil, err
+	}
+
+	restored := *current
+	restored.DeletedAt = nil
+	restored.LastUpdated = time.Now()
+
+	if err := s.saveVersion(ctx, appID, &restored); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"app_id":     appID,
+		"version":    restored.Current,
+		"deleted_at": current.DeletedAt,
+	}).Info("Version restored")
+
+	return &restored, nil
+}
+
+// deletedError is returned for reads and writes of a deleted app. It matches
+// both ErrAppNotFound and ErrAppDeleted.
+func deletedError(appID string) error {
+	return fmt.Errorf("%w: %w: %s", ErrAppNotFound, ErrAppDeleted, appID)
+}
+
+// withoutTombstones drops deleted apps from a listing
+func withoutTombstones(versions map[string]*models.AppVersion) map[string]*models.AppVersion {
+	live := make(map[string]*models.AppVersion, len(versions))
+	for appID, version := range versions {
+		if !version.IsDeleted() {
+			live[appID] = version
+		}
+	}
+	return live
+}
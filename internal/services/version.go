@@ -2,28 +2,182 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/company/version-service/internal/clients"
+	"github.com/company/version-service/internal/middleware"
 	"github.com/company/version-service/internal/models"
 	"github.com/company/version-service/internal/storage"
+	"github.com/company/version-service/pkg/breaker"
 	"github.com/company/version-service/pkg/semver"
 	"github.com/sirupsen/logrus"
 )
 
 type VersionService struct {
-	redis         storage.Storage
-	git           storage.Storage
-	gitLabClient  *clients.GitLabClient
-	logger        *logrus.Logger
-	mu            sync.RWMutex
-	gitHealth     gitHealthStatus
-	gitHealthMu   sync.RWMutex
-	gitMetrics    gitMetrics
-	gitMetricsMu  sync.RWMutex
-	pushNeeded    bool
+	redis        storage.Storage
+	git          storage.Storage
+	gitLabClient *clients.GitLabClient
+	tags         clients.TagProvider
+	notifier     *clients.WebhookClient
+	logger       *logrus.Logger
+	// mu is the barrier between single-app requests, which hold it shared
+	// while on their app's actor, and operations spanning or creating apps,
+	// which hold it exclusively (see actor.go)
+	mu           sync.RWMutex
+	requests     *actorQueue
+	persistence  *actorQueue
+	gitHealth    gitHealthStatus
+	gitHealthMu  sync.RWMutex
+	gitMetrics   gitMetrics
+	gitMetricsMu sync.RWMutex
+	cacheMetrics cacheMetrics
+	startedAt    time.Time
+	pushNeeded   atomic.Bool
+	quotas       QuotaOptions
+	lastAlerts   map[string]time.Time
+	alertMu      sync.Mutex
+	repoNames    map[string]repoNameEntry
+	repoNamesMu  sync.Mutex
+
+	// projectPaths maps GitLab project paths to project IDs without asking
+	// GitLab; projectPathCache remembers the paths GitLab resolved
+	projectPaths     map[string]string
+	projectPathCache map[string]projectPathEntry
+	projectPathsMu   sync.Mutex
+
+	idempotencyTTL time.Duration
+	devRetention   time.Duration
+	devCache       *devCache
+	devTemplate    string
+	devBase        string
+	idScheme       models.IDScheme
+	freshness      *freshnessTracker
+
+	// instanceID tells this replica's change broadcasts apart from others
+	instanceID        string
+	changeListeners   []func(models.VersionChange)
+	changeListenersMu sync.Mutex
+
+	requireRegistration  bool
+	writeThrough         bool
+	conservative         func() bool
+	cacheRebuildInterval time.Duration
+
+	discovery        DiscoveryOptions
+	normalization    semver.NormalizeOptions
+	hooks            HookOptions
+	discoveryMu      sync.Mutex
+	discoveryRunning bool
+	lastDiscovery    *models.DiscoveryReport
+
+	follower     FollowerOptions
+	followerMu   sync.Mutex
+	followerSync followerSyncStatus
+
+	stale StaleOptions
+
+	failoverOpts FailoverOptions
+	failover     failoverState
+
+	canary         CanaryOptions
+	canaryInFlight chan struct{}
+
+	airGapOpts AirGapOptions
+	airGap     airGapState
+
+	releases ReleaseOptions
+
+	redisBreaker *breaker.Breaker
+	breakers     []*breaker.Breaker
+}
+
+// Options holds optional service behaviour configured at startup
+type Options struct {
+	Quotas   QuotaOptions
+	Notifier *clients.WebhookClient
+
+	// IdempotencyTTL is how long increment idempotency keys are remembered
+	IdempotencyTTL time.Duration
+
+	Discovery DiscoveryOptions
+
+	// Normalization is applied to versions entering the system from outside
+	Normalization semver.NormalizeOptions
+
+	Hooks HookOptions
+
+	// DevVersionRetention is how long issued dev versions are tracked; zero
+	// disables tracking
+	DevVersionRetention time.Duration
+
+	// DevVersionCacheTTL is how long an app's parsed current version is
+	// reused for dev versions; zero disables the cache
+	DevVersionCacheTTL time.Duration
+
+	// DevVersionTemplate is the shape of dev versions when a request names
+	// none; empty selects semver.DefaultDevTemplate
+	DevVersionTemplate string
+
+	// DevVersionBase is the version {current} stands for in dev templates
+	// when a request names none: models.DevBaseCurrent (the default) or
+	// models.DevBaseNext
+	DevVersionBase string
+
+	// IDScheme parses and formats app IDs; nil selects the default
+	// project-app scheme
+	IDScheme models.IDScheme
+
+	Freshness FreshnessOptions
+
+	Follower FollowerOptions
+
+	// RequireRegistration turns off lazy creation of unknown apps on first
+	// read; apps must be registered through RegisterApp or discovery
+	RequireRegistration bool
+
+	Stale StaleOptions
+
+	Failover FailoverOptions
+
+	Canary CanaryOptions
+
+	AirGap AirGapOptions
+
+	Releases ReleaseOptions
+
+	// CacheRebuildInterval is how often the Redis cache is rebuilt from
+	// durable storage; zero rebuilds it on startup only
+	CacheRebuildInterval time.Duration
+
+	// WriteThrough persists versions to durable storage before caching them
+	// and returning; otherwise they are cached and persisted in the
+	// background
+	WriteThrough bool
+
+	// Conservative reports whether the service is shedding load to protect
+	// its error budgets, which turns write-through into write-back
+	Conservative func() bool
+
+	// TagProvider finds the latest tags new apps are seeded with; nil
+	// selects the GitLab client
+	TagProvider clients.TagProvider
+
+	// ProjectPaths maps GitLab project paths ("group/subgroup/app") to
+	// numeric project IDs, so path-based app IDs resolve without GitLab
+	ProjectPaths map[string]string
+
+	// RedisBreaker guards calls to the cache. While it is open, reads fall
+	// back to durable storage and writes fail at once.
+	RedisBreaker *breaker.Breaker
+
+	// Breakers are reported in Health, one check per dependency
+	Breakers []*breaker.Breaker
 }
 
 type gitHealthStatus struct {
@@ -41,16 +195,64 @@ type gitMetrics struct {
 	avgLatencyMs        float64
 }
 
+func NewVersionService(redis storage.Storage, git storage.Storage, gitLabClient *clients.GitLabClient, logger *logrus.Logger, opts Options) *VersionService {
+	idScheme := opts.IDScheme
+	if idScheme == nil {
+		idScheme = models.DefaultIDScheme()
+	}
+
+	tags := opts.TagProvider
+	if tags == nil && gitLabClient != nil {
+		tags = gitLabClient
+	}
 
-func NewVersionService(redis storage.Storage, git storage.Storage, gitLabClient *clients.GitLabClient, logger *logrus.Logger) *VersionService {
 	return &VersionService{
 		redis:        redis,
 		git:          git,
 		gitLabClient: gitLabClient,
+		tags:         tags,
+		notifier:     opts.Notifier,
 		logger:       logger,
+		requests:     newActorQueue(actorQueueRequests, requestMailboxSize, logger),
+		persistence:  newActorQueue(actorQueuePersistence, persistenceMailboxSize, logger),
 		gitHealth: gitHealthStatus{
 			lastSuccess: time.Now(),
 		},
+		startedAt:  time.Now(),
+		quotas:     opts.Quotas,
+		lastAlerts: make(map[string]time.Time),
+		repoNames:  make(map[string]repoNameEntry),
+
+		projectPaths:     opts.ProjectPaths,
+		projectPathCache: make(map[string]projectPathEntry),
+
+		idempotencyTTL: opts.IdempotencyTTL,
+		devRetention:   opts.DevVersionRetention,
+		devCache:       newDevCache(opts.DevVersionCacheTTL),
+		devTemplate:    opts.DevVersionTemplate,
+		devBase:        opts.DevVersionBase,
+		idScheme:       idScheme,
+		freshness:      newFreshnessTracker(opts.Freshness),
+		discovery:      opts.Discovery,
+		normalization:  opts.Normalization,
+		hooks:          opts.Hooks,
+		follower:       opts.Follower,
+		stale:          opts.Stale,
+		failoverOpts:   opts.Failover,
+		canary:         opts.Canary,
+		airGapOpts:     opts.AirGap,
+		releases:       opts.Releases,
+		canaryInFlight: make(chan struct{}, canaryMaxInFlight),
+		instanceID:     newInstanceID(),
+
+		requireRegistration: opts.RequireRegistration,
+		writeThrough:        opts.WriteThrough,
+		conservative:        opts.Conservative,
+
+		cacheRebuildInterval: opts.CacheRebuildInterval,
+
+		redisBreaker: opts.RedisBreaker,
+		breakers:     opts.Breakers,
 	}
 }
 
@@ -61,26 +263,174 @@ func (s *VersionService) Initialize(ctx context.Context) error {
 		return fmt.Errorf("failed to load versions from Git: %w", err)
 	}
 
+	// Writes cached or journaled before a restart may be newer than Git
+	if !s.follower.Enabled {
+		s.recoverCachedWrites(ctx, versions)
+		s.recoverJournal(ctx, versions)
+	}
+
 	if err := s.redis.RebuildCache(ctx, versions); err != nil {
 		s.logger.WithError(err).Warn("Failed to rebuild Redis cache")
 	}
 
-	s.logger.WithField("count", len(versions)).Info("Version service initialized")
+	s.logger.WithFields(logrus.Fields{
+		"count":    len(versions),
+		"follower": s.follower.Enabled,
+	}).Info("Version service initialized")
 
 	// Start background goroutines
 	go s.logMetricsPeriodically()
+
+	if watcher, ok := s.git.(storage.Watcher); ok {
+		go s.watchDurable(watcher)
+	}
+
+	if broadcaster, ok := s.redis.(storage.ChangeBroadcaster); ok {
+		go s.subscribeChanges(broadcaster)
+	}
+
+	// Followers never write to Git, so there is nothing to push, no write
+	// freshness to track and discovery is left to the primary
+	if s.follower.Enabled {
+		if s.follower.SyncInterval > 0 {
+			s.followerMu.Lock()
+			s.followerSync.lastSuccess = time.Now()
+			s.followerMu.Unlock()
+			go s.periodicFollowerSync()
+		}
+		return nil
+	}
+
+	// Commits left unpushed by the previous run are pushed on the first tick
+	s.markPushNeeded()
 	go s.periodicPushRetry()
+	go s.monitorFreshness()
+
+	if s.failoverEnabled() {
+		go s.monitorFailover()
+	}
+
+	if s.discoveryEnabled() {
+		go s.periodicDiscovery()
+	}
+
+	if s.stale.Days > 0 {
+		go s.periodicStaleCheck()
+	}
+
+	if s.cacheRebuildInterval > 0 {
+		go s.periodicCacheRebuild()
+	}
 
 	return nil
 }
 
+// recoverCachedWrites writes apps that Redis holds newer than Git back to
+// Git, so rebuilding the cache from Git doesn't roll them back. They were
+// cached before a restart whose commits never reached the remote.
+func (s *VersionService) recoverCachedWrites(ctx context.Context, versions map[string]*models.AppVersion) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recoverCachedWritesLocked(ctx, versions)
+}
+
+// recoverCachedWritesLocked is recoverCachedWrites for callers holding s.mu
+// exclusively. It returns the number of versions written back.
+func (s *VersionService) recoverCachedWritesLocked(ctx context.Context, versions map[string]*models.AppVersion) int {
+	cached, err := s.redis.ListVersions(ctx)
+	if err != nil {
+		s.logger.WithError(err).Warn("Failed to read Redis for writes missing from Git")
+		return 0
+	}
+
+	newer := make(map[string]*models.AppVersion)
+	for appID, version := range cached {
+		stored, ok := versions[appID]
+		if !ok || version.LastUpdated.After(stored.LastUpdated) {
+			newer[appID] = version
+		}
+	}
+	if len(newer) == 0 {
+		return 0
+	}
+
+	if err := s.saveVersions(ctx, newer); err != nil {
+		s.logger.WithError(err).Error("Failed to write back versions missing from Git")
+		return 0
+	}
+	for appID, version := range newer {
+		versions[appID] = version
+	}
+
+	s.logger.WithField("count", len(newer)).Warn("Redis held versions newer than Git; writing them back to Git")
+	return len(newer)
+}
+
+// errNotSeeded is returned by readVersion for a new app that may be created
+var errNotSeeded = errors.New("app not seeded")
+
+// GetVersion returns an app's version, creating it from its GitLab tags on
+// first use. Reads go straight to storage; creating an app takes s.mu
+// exclusively so concurrent first reads seed it once and quotas hold.
 func (s *VersionService) GetVersion(ctx context.Context, appID string) (*models.AppVersion, error) {
-	projectID, appName, err := models.ParseAppID(appID)
+	version, err := s.readVersion(ctx, appID)
+	if !errors.Is(err, errNotSeeded) {
+		if err == nil {
+			s.verifyRead(ctx, appID, version)
+		}
+		return version, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.getVersion(ctx, appID)
+}
+
+// getVersion is GetVersion for callers already holding s.mu exclusively
+func (s *VersionService) getVersion(ctx context.Context, appID string) (*models.AppVersion, error) {
+	version, err := s.readVersion(ctx, appID)
+	if !errors.Is(err, errNotSeeded) {
+		return version, err
+	}
+
+	id, err := s.parseAppID(appID)
 	if err != nil {
-		return nil, fmt.Errorf("invalid app ID: %w", err)
+		return nil, err
 	}
 
-	version, err := s.redis.GetVersion(ctx, appID)
+	if err := s.checkAppQuota(ctx, id.ProjectID); err != nil {
+		return nil, err
+	}
+
+	seeded, normalized, err := s.seedVersion(ctx, appID, id.ProjectID, id.AppName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.saveVersion(ctx, appID, seeded); err != nil {
+		return nil, err
+	}
+	if s.airGapped() {
+		s.deferSeed(ctx, appID, seeded)
+	}
+
+	// Report normalization on the response only; it is not stored
+	created := *seeded
+	created.Normalized = normalized
+	return &created, nil
+}
+
+// readVersion returns an app's stored version, caching it in Redis when it
+// was read from Git. It fails with errNotSeeded when the app does not exist
+// yet but may be created.
+func (s *VersionService) readVersion(ctx context.Context, appID string) (*models.AppVersion, error) {
+	id, err := s.parseAppID(appID)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := s.cacheGet(ctx, appID)
 	if err != nil {
 		s.logger.WithError(err).WithField("app_id", appID).Warn("Failed to get version from Redis")
 	}
@@ -92,43 +442,22 @@ func (s *VersionService) GetVersion(ctx context.Context, appID string) (*models.
 		}
 
 		if version == nil {
-			// Try to find existing tags from GitLab
-			var initialVersion string
-			if s.gitLabClient != nil {
-				gitLabTag, err := s.gitLabClient.GetLatestTag(ctx, projectID)
-				if err != nil {
-					s.logger.WithError(err).WithFields(logrus.Fields{
-						"app_id":     appID,
-						"project_id": projectID,
-					}).Warn("Failed to fetch tags from GitLab, using default version")
-				} else if gitLabTag != "" {
-					initialVersion = gitLabTag
-					s.logger.WithFields(logrus.Fields{
-						"app_id":     appID,
-						"project_id": projectID,
-						"version":    gitLabTag,
-					}).Info("Using latest tag from GitLab as initial version")
-				}
+			// Followers cannot create apps; they appear once the primary
+			// has registered them and the next sync has run
+			if s.follower.Enabled {
+				return nil, fmt.Errorf("%w: %s", ErrAppNotFound, appID)
 			}
 
-			// Use GitLab tag if found, otherwise default to 1.0.0
-			if initialVersion == "" {
-				initialVersion = "1.0.0"
+			// Opaque IDs carry no project to seed from; such apps must be
+			// registered first
+			if id.Opaque() || s.requireRegistration {
+				return nil, fmt.Errorf("%w: %s", ErrAppNotRegistered, appID)
 			}
 
-			version = &models.AppVersion{
-				Current:     initialVersion,
-				ProjectID:   projectID,
-				AppName:     appName,
-				LastUpdated: time.Now(),
-			}
-
-			if err := s.saveVersion(ctx, appID, version); err != nil {
-				return nil, err
-			}
+			return nil, errNotSeeded
 		} else {
 			// Cache in Redis synchronously when fetched from Git
-			if err := s.redis.SetVersion(ctx, appID, version); err != nil {
+			if err := s.cacheSet(ctx, appID, version); err != nil {
 				s.logger.WithError(err).WithField("app_id", appID).Warn("Failed to cache version in Redis")
 				// Non-fatal: continue even if caching fails
 			} else {
@@ -140,39 +469,236 @@ func (s *VersionService) GetVersion(ctx context.Context, appID string) (*models.
 		}
 	}
 
+	// Deleted apps keep their tombstone until restored rather than being
+	// seeded again
+	if version.IsDeleted() {
+		return nil, deletedError(appID)
+	}
+
 	return version, nil
 }
 
-func (s *VersionService) IncrementVersion(ctx context.Context, appID string, incrementType models.IncrementType) (*models.VersionResponse, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// lookupVersion returns the stored version for appID from Redis or Git
+// without creating it when missing. Deleted apps are not found.
+func (s *VersionService) lookupVersion(ctx context.Context, appID string) (*models.AppVersion, error) {
+	version, err := s.lookupRecord(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+
+	if version.IsDeleted() {
+		return nil, deletedError(appID)
+	}
+
+	return version, nil
+}
 
-	projectID, appName, err := models.ParseAppID(appID)
+// lookupRecord is lookupVersion including tombstones
+func (s *VersionService) lookupRecord(ctx context.Context, appID string) (*models.AppVersion, error) {
+	version, err := s.cacheGet(ctx, appID)
 	if err != nil {
-		return nil, fmt.Errorf("invalid app ID: %w", err)
+		s.logger.WithError(err).WithField("app_id", appID).Warn("Failed to get version from Redis")
+	}
+
+	if version == nil {
+		version, err = s.git.GetVersion(ctx, appID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get version from Git: %w", err)
+		}
+	}
+
+	if version == nil {
+		return nil, fmt.Errorf("%w: %s", ErrAppNotFound, appID)
+	}
+
+	return version, nil
+}
+
+// seedVersion builds the initial version record for a new app from the latest
+// tag of its project, defaulting to 1.0.0, and returns the normalizations
+// applied to the tag. Nothing is persisted. It fails with
+// ErrGitLabUnavailable when the tag provider kept failing, so the app is
+// seeded from its tags once the provider is back instead of starting over at
+// 1.0.0.
+func (s *VersionService) seedVersion(ctx context.Context, appID, projectID, appName string) (*models.AppVersion, []string, error) {
+	// Try to find existing tags from the configured forge
+	var initialVersion string
+	var normalized []string
+	if s.tags != nil {
+		latestTag, err := s.tags.GetLatestTag(ctx, projectID)
+		if errors.Is(err, clients.ErrAirGapped) {
+			s.logger.WithField("app_id", appID).Debug("Air-gapped, seeding with the default version")
+		} else if clients.TagProviderUnavailable(err) {
+			return nil, nil, fmt.Errorf("%w: cannot seed %s from its tags: %w", ErrGitLabUnavailable, appID, err)
+		} else if err != nil {
+			s.logger.WithError(err).WithFields(logrus.Fields{
+				"app_id":     appID,
+				"project_id": projectID,
+			}).Warn("Failed to fetch tags, using default version")
+		} else if latestTag != "" {
+			version, applied, err := s.normalizeVersion(latestTag)
+			if err != nil {
+				s.logger.WithError(err).WithFields(logrus.Fields{
+					"app_id":     appID,
+					"project_id": projectID,
+					"tag":        latestTag,
+				}).Warn("Latest tag is not a usable version, using default version")
+			} else {
+				initialVersion = version
+				normalized = applied
+				s.logger.WithFields(logrus.Fields{
+					"app_id":     appID,
+					"project_id": projectID,
+					"version":    version,
+				}).Info("Using latest tag as initial version")
+			}
+		}
+	}
+
+	// Use the latest tag if found, otherwise default to 1.0.0
+	if initialVersion == "" {
+		initialVersion = "1.0.0"
 	}
 
-	currentVersion, err := s.GetVersion(ctx, appID)
+	seeded := &models.AppVersion{
+		Current:     initialVersion,
+		ProjectID:   projectID,
+		AppName:     appName,
+		LastUpdated: time.Now(),
+	}
+	s.applyProjectMetadata(ctx, seeded)
+	return seeded, normalized, nil
+}
+
+// PreviewNextVersion computes the version an increment would produce without
+// persisting anything. Unknown apps are previewed from their would-be seed.
+func (s *VersionService) PreviewNextVersion(ctx context.Context, appID string, incrementType models.IncrementType) (*models.NextVersionResponse, error) {
+	id, err := s.parseAppID(appID)
 	if err != nil {
 		return nil, err
 	}
 
-	newVersion, err := s.calculateNextVersion(currentVersion.Current, incrementType)
+	current, err := s.lookupVersion(ctx, appID)
+	if errors.Is(err, ErrAppNotFound) && !errors.Is(err, ErrAppDeleted) {
+		if id.Opaque() || s.requireRegistration {
+			return nil, fmt.Errorf("%w: %s", ErrAppNotRegistered, appID)
+		}
+		current, _, err = s.seedVersion(ctx, appID, id.ProjectID, id.AppName)
+		if err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	next, err := s.calculateNextVersion(current.Policy, current.Current, incrementType)
 	if err != nil {
 		return nil, err
 	}
 
-	updatedVersion := &models.AppVersion{
-		Current:     newVersion,
-		ProjectID:   projectID,
-		AppName:     appName,
-		LastUpdated: time.Now(),
+	return &models.NextVersionResponse{
+		Current: current.Current,
+		Next:    next,
+		Type:    effectiveIncrement(current.Policy, current.Current, incrementType),
+	}, nil
+}
+
+// maxIncrementAttempts bounds how often an increment is computed again after
+// another replica changed the app first
+const maxIncrementAttempts = 3
+
+// IncrementVersion bumps an app's version. When idempotencyKey is set and was
+// already used for this app, the previously computed version is returned and
+// nothing is written. An increment losing a race with another replica is
+// computed again from the winner's version, running pre-increment hooks
+// again.
+func (s *VersionService) IncrementVersion(ctx context.Context, appID string, incrementType models.IncrementType, idempotencyKey string) (*models.VersionResponse, error) {
+	// Create the app on first use before queueing on its actor, since
+	// creating apps needs s.mu exclusively
+	if _, err := s.GetVersion(ctx, appID); err != nil {
+		return nil, err
+	}
+
+	response, err := onApp(ctx, s, appID, func() (*models.VersionResponse, error) {
+		for attempt := 1; ; attempt++ {
+			response, err := s.incrementOnce(ctx, appID, incrementType, idempotencyKey)
+			if !errors.Is(err, storage.ErrRevisionMismatch) {
+				return response, err
+			}
+			if attempt == maxIncrementAttempts {
+				return nil, fmt.Errorf("%w: %s kept changing during the increment", ErrRevisionConflict, appID)
+			}
+			s.logger.WithError(err).WithField("app_id", appID).Info("App changed during the increment, computing it again")
+		}
+	})
+	if err == nil && !response.Replayed {
+		s.createRelease(ctx, appID, response.Version)
 	}
+	return response, err
+}
 
-	if err := s.saveVersion(ctx, appID, updatedVersion); err != nil {
+// incrementOnce computes the next version from the current one and saves
+// it, failing with storage.ErrRevisionMismatch when another replica saved a
+// version in between
+func (s *VersionService) incrementOnce(ctx context.Context, appID string, requested models.IncrementType, idempotencyKey string) (*models.VersionResponse, error) {
+	id, err := s.identify(ctx, appID)
+	if err != nil {
 		return nil, err
 	}
 
+	if version, found := s.replayedVersion(ctx, appID, idempotencyKey); found {
+		s.logger.WithFields(logrus.Fields{
+			"app_id":  appID,
+			"version": version,
+		}).Info("Replaying idempotent increment")
+		return &models.VersionResponse{Version: version, Replayed: true}, nil
+	}
+
+	if err := s.checkIncrementQuota(ctx, id.ProjectID, 1); err != nil {
+		return nil, err
+	}
+
+	currentVersion, err := s.lookupVersion(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkWritable(appID, currentVersion); err != nil {
+		return nil, err
+	}
+
+	incrementType := effectiveIncrement(currentVersion.Policy, currentVersion.Current, requested)
+	if !currentVersion.Policy.AllowsIncrement(incrementType) {
+		return nil, fmt.Errorf("%w: %s increments are not allowed for %s", ErrIncrementNotAllowed, incrementType, appID)
+	}
+
+	newVersion, err := s.calculateNextVersion(currentVersion.Policy, currentVersion.Current, requested)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkNotReserved(ctx, id.ProjectID, currentVersion.Policy, newVersion); err != nil {
+		return nil, err
+	}
+
+	if err := s.runPreIncrementHooks(ctx, appID, currentVersion, incrementType, newVersion); err != nil {
+		return nil, err
+	}
+
+	updatedVersion := *currentVersion
+	updatedVersion.Current = newVersion
+	updatedVersion.ProjectID, updatedVersion.AppName = id.ProjectID, id.AppName
+	updatedVersion.LastUpdated = time.Now()
+	s.applyProjectMetadata(ctx, &updatedVersion)
+
+	if err := s.saveIncrement(ctx, appID, currentVersion, &updatedVersion); err != nil {
+		return nil, err
+	}
+
+	s.recordIncrement(ctx, id.ProjectID, appID)
+	s.rememberVersion(ctx, appID, idempotencyKey, newVersion)
+	s.firePostIncrementHooks(appID, currentVersion, incrementType, newVersion)
+
 	s.logger.WithFields(logrus.Fields{
 		"app_id":      appID,
 		"old_version": currentVersion.Current,
@@ -183,30 +709,94 @@ func (s *VersionService) IncrementVersion(ctx context.Context, appID string, inc
 	return &models.VersionResponse{Version: newVersion}, nil
 }
 
+// GetDevVersion returns a dev version of an app for one build, shaped by the
+// request's template or the configured one. With the next base, {current}
+// stands for the next version, so dev builds sort above the last release.
+// Templates with {build} number each build of the app's branch.
 func (s *VersionService) GetDevVersion(ctx context.Context, appID string, req *models.DevVersionRequest) (*models.VersionResponse, error) {
-	currentVersion, err := s.GetVersion(ctx, appID)
-	if err != nil {
-		return nil, err
+	base := req.Base
+	if base == "" {
+		base = s.devBase
+	}
+	if base != "" && base != models.DevBaseCurrent && base != models.DevBaseNext {
+		return nil, fmt.Errorf("%w: %q (use %s or %s)", ErrInvalidDevBase, base, models.DevBaseCurrent, models.DevBaseNext)
+	}
+
+	generate, epoch, ok := s.devCache.get(appID)
+	if !ok {
+		currentVersion, err := s.GetVersion(ctx, appID)
+		if err != nil {
+			return nil, err
+		}
+
+		if generate, err = s.schemeOf(currentVersion.Policy).dev(currentVersion.Current); err != nil {
+			return nil, err
+		}
+		s.devCache.put(appID, epoch, generate)
+	}
+
+	template := req.Template
+	if template == "" {
+		template = s.devTemplate
+	}
+	if template == "" {
+		template = semver.DefaultDevTemplate
+	}
+	if base == models.DevBaseNext {
+		template = strings.ReplaceAll(template, "{current}", "{next}")
+	}
+
+	info := semver.DevInfo{SHA: req.SHA, Branch: req.Branch, Time: time.Now()}
+	numbered := strings.Contains(template, "{build}")
+	if numbered {
+		// A stand-in number validates the template, so a rejected request
+		// doesn't use up a build number
+		info.Build = 1
 	}
 
-	v, err := semver.Parse(currentVersion.Current)
+	devVersion, err := generate(template, info)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse version: %w", err)
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDevTemplate, err)
 	}
 
-	devVersion := v.WithDevSuffix(req.SHA)
+	if numbered {
+		if info.Build, err = s.nextDevBuild(ctx, appID, req.Branch); err != nil {
+			return nil, err
+		}
+		if devVersion, err = generate(template, info); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidDevTemplate, err)
+		}
+	}
 
 	s.logger.WithFields(logrus.Fields{
-		"app_id":  appID,
-		"sha":     req.SHA,
-		"branch":  req.Branch,
-		"version": devVersion.String(),
+		"app_id":   appID,
+		"sha":      req.SHA,
+		"branch":   req.Branch,
+		"template": template,
+		"base":     base,
+		"build":    info.Build,
+		"version":  devVersion,
 	}).Debug("Dev version generated")
 
-	return &models.VersionResponse{Version: devVersion.String()}, nil
+	s.trackDevVersion(ctx, appID, req, devVersion, info.Build)
+
+	return &models.VersionResponse{Version: devVersion}, nil
 }
 
+// ListVersions returns every app that is not deleted
 func (s *VersionService) ListVersions(ctx context.Context) (map[string]*models.AppVersion, error) {
+	versions, err := s.listRecords(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	versions = withoutTombstones(versions)
+	s.verifyListing(ctx, "", versions)
+	return versions, nil
+}
+
+// listRecords is ListVersions including tombstones
+func (s *VersionService) listRecords(ctx context.Context) (map[string]*models.AppVersion, error) {
 	versions, err := s.redis.ListVersions(ctx)
 	if err != nil {
 		s.logger.WithError(err).Warn("Failed to list versions from Redis, falling back to Git")
@@ -233,77 +823,203 @@ func (s *VersionService) ListVersionsByProject(ctx context.Context, projectID st
 
 	// No need to calculate next versions anymore - simplified API
 
+	versions = withoutTombstones(versions)
+	s.verifyListing(ctx, projectID, versions)
 	return versions, nil
 }
 
-func (s *VersionService) calculateNextVersion(current string, incrementType models.IncrementType) (string, error) {
-	v, err := semver.Parse(current)
-	if err != nil {
-		return "", fmt.Errorf("invalid semantic version: %w", err)
-	}
-
-	var next *semver.Version
-	switch incrementType {
-	case models.IncrementTypeMajor:
-		next = v.IncrementMajor()
-	case models.IncrementTypeMinor:
-		next = v.IncrementMinor()
-	case models.IncrementTypePatch:
-		next = v.IncrementPatch()
-	default:
-		next = v.IncrementPatch()
+// calculateNextVersion applies an increment under the version scheme of the
+// app's policy. Requests without a type follow the policy's strategy, and
+// apps in initial development take major increments as minor ones.
+func (s *VersionService) calculateNextVersion(policy *models.AppPolicy, current string, incrementType models.IncrementType) (string, error) {
+	if incrementType == models.IncrementTypeDefault && policy.DatePatches() {
+		v, err := semver.Parse(current)
+		if err != nil {
+			return "", fmt.Errorf("invalid semantic version: %w", err)
+		}
+		return v.IncrementDatePatch(time.Now()).String(), nil
 	}
+	return s.schemeOf(policy).next(current, effectiveIncrement(policy, current, incrementType), time.Now())
+}
 
-	return next.String(), nil
+// writingThrough reports whether versions are persisted before they are
+// cached. In conservative mode they are written back, so requests don't wait
+// on Git while the service recovers.
+func (s *VersionService) writingThrough() bool {
+	return s.writeThrough && (s.conservative == nil || !s.conservative())
 }
 
 func (s *VersionService) saveVersion(ctx context.Context, appID string, version *models.AppVersion) error {
+	if s.writingThrough() {
+		return s.writeThroughVersion(ctx, appID, version, func() error {
+			return s.cacheSet(ctx, appID, version)
+		})
+	}
+
 	// Save to Redis first (synchronous - fast, critical path)
-	if err := s.redis.SetVersion(ctx, appID, version); err != nil {
+	err := s.cacheSet(ctx, appID, version)
+	s.changed(ctx, appID)
+	if err != nil {
+		return fmt.Errorf("failed to save version to Redis: %w", err)
+	}
+
+	s.persistVersion(ctx, appID, version)
+	return nil
+}
+
+// saveIncrement is saveVersion for a version computed from expected. The
+// Redis write fails with storage.ErrRevisionMismatch when another replica
+// changed the app since expected was read, so the increment can be computed
+// again rather than issue a version twice. Under write-through the version is
+// already committed by then, so it is cached as is instead.
+func (s *VersionService) saveIncrement(ctx context.Context, appID string, expected, version *models.AppVersion) error {
+	if s.writingThrough() {
+		return s.writeThroughVersion(ctx, appID, version, func() error {
+			return s.cacheSetIf(ctx, appID, expected, version)
+		})
+	}
+
+	err := s.cacheSetIf(ctx, appID, expected, version)
+	s.changed(ctx, appID)
+	if err != nil {
 		return fmt.Errorf("failed to save version to Redis: %w", err)
 	}
 
+	s.persistVersion(ctx, appID, version)
+	return nil
+}
+
+// persistVersion writes a version just cached in Redis to Git in the
+// background
+func (s *VersionService) persistVersion(ctx context.Context, appID string, version *models.AppVersion) {
 	s.logger.WithFields(logrus.Fields{
 		"app_id":  appID,
 		"version": version.Current,
 	}).Debug("Version cached in Redis")
 
-	// Save to Git asynchronously (slow, network I/O)
-	go func() {
-		s.saveVersionToGitWithRetry(appID, version)
-	}()
+	writtenAt := s.freshness.written([]string{appID})
+	deferred(ctx)
+
+	// Save to Git asynchronously (slow, network I/O), in order per app,
+	// or to the failover journal while Git is failed over
+	s.persistence.push(appID, func() {
+		if s.journalWrites(ctx, journalEntries(map[string]*models.AppVersion{appID: version}), writtenAt) {
+			return
+		}
+		s.saveVersionToGitWithRetry(ctx, appID, version, writtenAt)
+	})
+}
+
+// writeThroughVersion is saveVersion under the write-through policy
+func (s *VersionService) writeThroughVersion(ctx context.Context, appID string, version *models.AppVersion, cache func() error) error {
+	versions := map[string]*models.AppVersion{appID: version}
+	return s.persistThenCache(ctx, versions, logrus.Fields{
+		"app_id":  appID,
+		"version": version.Current,
+	}, func(ctx context.Context) error {
+		return s.git.SetVersion(ctx, appID, version)
+	}, cache)
+}
+
+// persistThenCache runs a durable write of versions on the apps' persistence
+// actors, after the writes queued before it, and waits for it. Only once it
+// landed are the versions cached, so Redis never serves a version Git may
+// lose; a failed durable write leaves Redis as it was. Once committed, the
+// versions are what Redis must serve: a revision conflict reported by cache
+// overwrites the cached records with them rather than compute increments
+// again, and other cache failures are only logged, as reads fall through to
+// durable storage. Writes journaled while Git is failed over are recorded as
+// deferred in ctx. Callers must hold s.mu exclusively when writing several
+// apps.
+func (s *VersionService) persistThenCache(ctx context.Context, versions map[string]*models.AppVersion, fields logrus.Fields, write func(ctx context.Context) error, cache func() error) error {
+	appIDs := make([]string, 0, len(versions))
+	for appID := range versions {
+		appIDs = append(appIDs, appID)
+	}
+
+	writtenAt := s.freshness.written(appIDs)
+	// Stays set when the write panics, so nothing is cached
+	err := fmt.Errorf("durable write of %d apps did not complete", len(appIDs))
+	done := make(chan struct{})
+	s.persistence.pushAll(appIDs, func() {
+		defer close(done)
+		if s.journalWrites(ctx, journalEntries(versions), writtenAt) {
+			deferred(ctx)
+			err = nil
+			return
+		}
+		err = s.persistToGitWithRetry(ctx, appIDs, writtenAt, fields, write)
+	})
+	<-done
+	if err != nil {
+		s.freshness.discarded(appIDs, writtenAt)
+		return fmt.Errorf("failed to save version to Git: %w", err)
+	}
 
+	err = cache()
+	if errors.Is(err, storage.ErrRevisionMismatch) {
+		s.logger.WithFields(fields).Warn("App changed in Redis while persisting, caching the persisted version")
+		for appID, version := range versions {
+			if err = s.cacheSet(ctx, appID, version); err != nil {
+				break
+			}
+		}
+	}
+	s.changed(ctx, appIDs...)
+	if err != nil {
+		s.logger.WithError(err).WithFields(fields).Warn("Version persisted but not cached in Redis")
+	}
 	return nil
 }
 
-func (s *VersionService) saveVersionToGitWithRetry(appID string, version *models.AppVersion) {
+func (s *VersionService) saveVersionToGitWithRetry(ctx context.Context, appID string, version *models.AppVersion, writtenAt time.Time) {
+	s.persistToGitWithRetry(ctx, []string{appID}, writtenAt, logrus.Fields{
+		"app_id":  appID,
+		"version": version.Current,
+	}, func(ctx context.Context) error {
+		return s.git.SetVersion(ctx, appID, version)
+	})
+}
+
+// persistToGitWithRetry runs a Git write with retries and exponential backoff,
+// falling back to the background push loop when it keeps failing. ctx is the
+// context of the originating request; its values, such as the trace ID, are
+// kept but its cancellation is not, since the write must land after the
+// request has been answered. Each attempt, including the wait for the Git
+// storage lock, is bounded by gitAttemptTimeout instead. appIDs and
+// writtenAt identify the Redis writes whose freshness the push confirms;
+// fields identify the write in logs. The error of the last attempt is
+// returned unless the write was committed, even if only locally.
+func (s *VersionService) persistToGitWithRetry(ctx context.Context, appIDs []string, writtenAt time.Time, fields logrus.Fields, write func(ctx context.Context) error) error {
 	const maxRetries = 3
 	const baseDelay = time.Second
+	const gitAttemptTimeout = 30 * time.Second
 	startTime := time.Now()
 
 	s.updateGitMetrics(true, 0, 0) // Start operation
 
+	var err error
 	for attempt := 0; attempt < maxRetries; attempt++ {
 		// Create a new context with timeout for each attempt
-		gitCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		gitCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), gitAttemptTimeout)
 		attemptStart := time.Now()
 
-		err := s.git.SetVersion(gitCtx, appID, version)
+		err = write(gitCtx)
 		attemptLatency := time.Since(attemptStart)
 		cancel()
+		middleware.RecordGitOperation(ctx, "write", s.gitOperationResult(err), attemptLatency)
 
 		if err == nil {
 			// Success - update health status and metrics
+			s.freshness.durable(appIDs, writtenAt)
 			totalLatency := time.Since(startTime)
 			s.updateGitHealth(true)
 			s.updateGitMetrics(false, attempt, totalLatency.Milliseconds())
-			s.logger.WithFields(logrus.Fields{
-				"app_id":     appID,
-				"version":    version.Current,
+			s.logger.WithFields(fields).WithFields(logrus.Fields{
 				"attempt":    attempt + 1,
 				"latency_ms": totalLatency.Milliseconds(),
 			}).Info("Version persisted to Git")
-			return
+			return nil
 		}
 
 		// Track retry
@@ -314,17 +1030,30 @@ func (s *VersionService) saveVersionToGitWithRetry(appID string, version *models
 		// Check if this is a push failure (commit succeeded but push failed)
 		if s.isPushFailure(err) {
 			// For push failures, mark that we need a push retry
+			s.freshness.committed(appIDs, writtenAt)
 			s.markPushNeeded()
 			totalLatency := time.Since(startTime)
 			s.updateGitHealth(false)
 			s.updateGitMetrics(false, attempt, totalLatency.Milliseconds())
-			s.logger.WithError(err).WithFields(logrus.Fields{
-				"app_id":     appID,
-				"version":    version.Current,
+			s.logger.WithError(err).WithFields(fields).WithFields(logrus.Fields{
 				"attempt":    attempt + 1,
 				"latency_ms": totalLatency.Milliseconds(),
 			}).Warn("Version committed locally but push failed - will retry push in background")
-			return
+			return nil
+		}
+
+		// Another writer changed the apps in the durable store first, so the
+		// cached versions lost; drop them so the next read loads the winner
+		if errors.Is(err, storage.ErrRevisionMismatch) {
+			s.freshness.discarded(appIDs, writtenAt)
+			s.dropConflictingWrites(ctx, appIDs)
+			totalLatency := time.Since(startTime)
+			s.updateGitMetrics(false, attempt, totalLatency.Milliseconds())
+			s.logger.WithError(err).WithFields(fields).WithFields(logrus.Fields{
+				"attempt":    attempt + 1,
+				"latency_ms": totalLatency.Milliseconds(),
+			}).Error("Version write lost a revision conflict, cached versions discarded")
+			return err
 		}
 
 		// Check if this is a retryable error
@@ -332,21 +1061,17 @@ func (s *VersionService) saveVersionToGitWithRetry(appID string, version *models
 			totalLatency := time.Since(startTime)
 			s.updateGitHealth(false)
 			s.updateGitMetrics(false, attempt, totalLatency.Milliseconds())
-			s.logger.WithError(err).WithFields(logrus.Fields{
-				"app_id":     appID,
-				"version":    version.Current,
+			s.logger.WithError(err).WithFields(fields).WithFields(logrus.Fields{
 				"attempt":    attempt + 1,
 				"latency_ms": totalLatency.Milliseconds(),
 			}).Error("Non-retryable error persisting version to Git")
-			return
+			return err
 		}
 
 		// Log the attempt
-		s.logger.WithError(err).WithFields(logrus.Fields{
-			"app_id":        appID,
-			"version":       version.Current,
-			"attempt":       attempt + 1,
-			"max_retries":   maxRetries,
+		s.logger.WithError(err).WithFields(fields).WithFields(logrus.Fields{
+			"attempt":            attempt + 1,
+			"max_retries":        maxRetries,
 			"attempt_latency_ms": attemptLatency.Milliseconds(),
 		}).Warn("Failed to persist version to Git, will retry")
 
@@ -365,63 +1090,54 @@ func (s *VersionService) saveVersionToGitWithRetry(appID string, version *models
 	// Mark that push is needed for background push process
 	s.markPushNeeded()
 
-	s.logger.WithFields(logrus.Fields{
-		"app_id":     appID,
-		"version":    version.Current,
+	s.logger.WithFields(fields).WithFields(logrus.Fields{
 		"attempts":   maxRetries,
 		"latency_ms": totalLatency.Milliseconds(),
 	}).Error("Failed to persist version to Git after all retries - will retry push in background")
+	return err
+}
+
+// dropConflictingWrites removes apps whose write lost a revision conflict
+// from Redis, so reads fall through to the durable store
+func (s *VersionService) dropConflictingWrites(ctx context.Context, appIDs []string) {
+	for _, appID := range appIDs {
+		if err := s.redis.DeleteVersion(context.WithoutCancel(ctx), appID); err != nil {
+			s.logger.WithError(err).WithField("app_id", appID).Warn("Failed to drop conflicting version from Redis")
+		}
+	}
+	s.changed(ctx, appIDs...)
 }
 
+// isRetryableError reports whether a failed write may succeed when tried
+// again. Only failures to reach the store are retried; rejected credentials
+// and errors of unknown cause are not.
 func (s *VersionService) isRetryableError(err error) bool {
-	// Consider network, timeout, and temporary Git service errors as retryable
-	// Authentication and permission errors are not retryable
-	errStr := err.Error()
-
-	// Non-retryable errors
-	if containsAny(errStr, []string{
-		"authentication failed",
-		"permission denied",
-		"access denied",
-		"unauthorized",
-		"forbidden",
-		"invalid credentials",
-	}) {
+	if errors.Is(err, storage.ErrAuthFailed) || errors.Is(err, storage.ErrEmptyRepo) {
 		return false
 	}
-
-	// Retryable errors (network, timeout, temporary issues)
-	return containsAny(errStr, []string{
-		"timeout",
-		"connection",
-		"network",
-		"temporary",
-		"unavailable",
-		"refused",
-		"reset",
-		"push failed",
-	}) || true // Default to retryable for unknown errors
+	// Other durable backends report network failures as they are
+	var netErr net.Error
+	return errors.Is(err, storage.ErrNetwork) ||
+		errors.As(err, &netErr) ||
+		errors.Is(err, context.DeadlineExceeded)
 }
 
-func (s *VersionService) isPushFailure(err error) bool {
-	errStr := err.Error()
-	return containsAny(errStr, []string{
-		"push failed",
-		"failed to push",
-	})
+// gitOperationResult classifies the outcome of a Git write for metrics
+func (s *VersionService) gitOperationResult(err error) string {
+	switch {
+	case err == nil:
+		return "success"
+	case s.isPushFailure(err):
+		return "push_failed"
+	default:
+		return "error"
+	}
 }
 
-func containsAny(str string, substrings []string) bool {
-	for _, substr := range substrings {
-		if len(str) >= len(substr) {
-			for i := 0; i <= len(str)-len(substr); i++ {
-				if str[i:i+len(substr)] == substr {
-					return true
-				}
-			}
-		}
-	}
-	return false
+// isPushFailure reports whether a write was committed locally but not
+// pushed, so the background push takes over
+func (s *VersionService) isPushFailure(err error) bool {
+	return errors.Is(err, storage.ErrPushRejected)
 }
 
 func (s *VersionService) updateGitHealth(success bool) {
@@ -498,9 +1214,7 @@ func (s *VersionService) logMetricsPeriodically() {
 }
 
 func (s *VersionService) markPushNeeded() {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.pushNeeded = true
+	s.pushNeeded.Store(true)
 }
 
 func (s *VersionService) periodicPushRetry() {
@@ -508,18 +1222,14 @@ func (s *VersionService) periodicPushRetry() {
 	defer ticker.Stop()
 
 	for range ticker.C {
-		s.mu.RLock()
-		pushNeeded := s.pushNeeded
-		s.mu.RUnlock()
-
-		if pushNeeded {
+		// Clear the flag before pushing so a failure flagged meanwhile is
+		// retried on the next tick
+		if s.pushNeeded.Swap(false) {
 			s.logger.Info("Starting periodic Git push retry")
 			if err := s.retryPendingPushes(); err != nil {
+				s.markPushNeeded()
 				s.logger.WithError(err).Error("Failed to push pending commits")
 			} else {
-				s.mu.Lock()
-				s.pushNeeded = false
-				s.mu.Unlock()
 				s.logger.Info("Successfully pushed pending commits")
 			}
 		}
@@ -537,10 +1247,14 @@ func (s *VersionService) retryPendingPushes() error {
 	}
 
 	// Try to push pending commits
+	start := time.Now()
 	if err := gitPushable.PushPendingCommits(ctx); err != nil {
+		middleware.RecordGitOperation(ctx, "push", "error", time.Since(start))
 		return fmt.Errorf("failed to push pending commits: %w", err)
 	}
+	middleware.RecordGitOperation(ctx, "push", "success", time.Since(start))
 
+	s.freshness.pushed()
 	s.updateGitHealth(true)
 	return nil
 }
@@ -559,7 +1273,10 @@ func (s *VersionService) Health(ctx context.Context) map[string]string {
 	gitHealth := s.gitHealth
 	s.gitHealthMu.RUnlock()
 
-	if err := s.git.Health(ctx); err != nil {
+	if err := s.git.Health(ctx); errors.Is(err, storage.ErrServingFromMirror) {
+		// Reads keep working from the mirror; writes wait for the primary
+		checks["git"] = fmt.Sprintf("degraded: %v; writes are queued", err)
+	} else if err != nil {
 		checks["git"] = fmt.Sprintf("unhealthy: %v", err)
 	} else {
 		// Check recent Git operation status (last 5 minutes)
@@ -584,34 +1301,66 @@ func (s *VersionService) Health(ctx context.Context) map[string]string {
 		}
 	}
 
-	return checks
-}
+	if s.follower.Enabled {
+		checks["follower"] = s.followerHealth()
+	} else {
+		checks["freshness"] = s.freshnessHealth()
+	}
 
-func (s *VersionService) DeleteVersion(ctx context.Context, appID string) error {
-	projectID, appName, err := models.ParseAppID(appID)
-	if err != nil {
-		return fmt.Errorf("invalid app ID: %w", err)
+	if s.failoverEnabled() && !s.follower.Enabled {
+		checks["failover"] = s.failoverHealth(ctx)
 	}
 
-	// Delete from Redis first (fast)
-	if err := s.redis.DeleteVersion(ctx, appID); err != nil {
-		s.logger.WithError(err).WithField("app_id", appID).Warn("Failed to delete version from Redis")
-		// Non-fatal: continue with Git deletion
+	if s.airGapped() {
+		checks["air_gap"] = s.airGapHealth(ctx)
 	}
 
-	// Delete from Git
-	if err := s.git.DeleteVersion(ctx, appID); err != nil {
-		s.logger.WithError(err).WithField("app_id", appID).Error("Failed to delete version from Git")
-		return fmt.Errorf("failed to delete version from Git: %w", err)
+	for _, b := range s.breakers {
+		if b != nil {
+			checks["circuit_"+b.Name()] = breakerHealth(b.Snapshot())
+		}
 	}
 
-	s.logger.WithFields(logrus.Fields{
-		"app_id":     appID,
-		"project_id": projectID,
-		"app_name":   appName,
-	}).Info("Version deleted successfully")
+	return checks
+}
 
-	return nil
+// DeleteVersion replaces an app's record with a tombstone. The version,
+// aliases and annotations are kept so RestoreVersion can bring the app back;
+// deleting an app that is already deleted is a no-op.
+func (s *VersionService) DeleteVersion(ctx context.Context, appID string) error {
+	return s.runOnApp(ctx, appID, func() error {
+		id, err := s.parseAppID(appID)
+		if err != nil {
+			return err
+		}
+
+		current, err := s.lookupRecord(ctx, appID)
+		if err != nil {
+			return err
+		}
+
+		if current.IsDeleted() {
+			return nil
+		}
+
+		now := time.Now()
+		tombstone := *current
+		tombstone.DeletedAt = &now
+		tombstone.LastUpdated = now
+
+		if err := s.saveVersion(ctx, appID, &tombstone); err != nil {
+			return err
+		}
+
+		s.logger.WithFields(logrus.Fields{
+			"app_id":     appID,
+			"project_id": id.ProjectID,
+			"app_name":   id.AppName,
+			"version":    current.Current,
+		}).Info("Version deleted successfully")
+
+		return nil
+	})
 }
 
 func (s *VersionService) DeleteProject(ctx context.Context, projectID string) error {
@@ -645,4 +1394,4 @@ func (s *VersionService) DeleteProject(ctx context.Context, projectID string) er
 	}).Info("Project deleted successfully")
 
 	return nil
-}
\ No newline at end of file
+}
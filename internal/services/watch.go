@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// watchRetryDelay is how long a failed durable storage watch waits before
+// starting again
+const watchRetryDelay = 5 * time.Second
+
+// watchDurable keeps Redis in step with writes other replicas make to a
+// durable store that can be watched, so replicas sharing it see each
+// other's writes without waiting for a sync
+func (s *VersionService) watchDurable(watcher storage.Watcher) {
+	for {
+		err := watcher.Watch(context.Background(), s.applyDurableChange)
+		if errors.Is(err, storage.ErrWatchUnsupported) {
+			s.logger.WithError(err).Debug("Durable storage cannot be watched")
+			return
+		}
+		s.logger.WithError(err).Warn("Durable storage watch failed, restarting")
+		time.Sleep(watchRetryDelay)
+	}
+}
+
+// applyDurableChange copies a change made by another writer into Redis, on
+// the app's actor so it is ordered with this replica's own requests. A
+// change older than the cached record is ignored: this replica has written
+// the app since, and that write will fail its revision check when it
+// reaches the durable store. It reports whether Redis matches the change.
+func (s *VersionService) applyDurableChange(appID string, version *models.AppVersion) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var applied bool
+	err := s.runOnApp(ctx, appID, func() error {
+		defer s.devCache.invalidate(appID)
+
+		if version == nil {
+			if err := s.redis.DeleteVersion(ctx, appID); err != nil {
+				return err
+			}
+			applied = true
+			return nil
+		}
+
+		cached, err := s.cacheGet(ctx, appID)
+		if err != nil {
+			return err
+		}
+		if cached != nil && cached.LastUpdated.After(version.LastUpdated) {
+			return nil
+		}
+		if err := s.cacheSet(ctx, appID, version); err != nil {
+			return err
+		}
+		applied = true
+		return nil
+	})
+	if err != nil {
+		s.logger.WithError(err).WithField("app_id", appID).Warn("Failed to apply durable storage change to Redis")
+		return false
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"app_id":  appID,
+		"applied": applied,
+	}).Debug("Durable storage changed by another writer")
+	return applied
+}
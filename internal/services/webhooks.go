@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/company/version-service/internal/clients"
+	"github.com/company/version-service/internal/middleware"
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// MaxWebhooksPerProject bounds the subscriptions a project can register
+const MaxWebhooksPerProject = 20
+
+func (s *VersionService) webhookStore() storage.WebhookStore {
+	store, ok := s.redis.(storage.WebhookStore)
+	if !ok {
+		return nil
+	}
+	return store
+}
+
+// CanAccessProject reports whether the caller may manage the project's
+// webhooks: the GitLab CI job token sent with the request must be able to
+// read the project. Requests without a job token never can, since the
+// service's own token would grant access to everything.
+func (s *VersionService) CanAccessProject(ctx context.Context, projectID string) (bool, error) {
+	if !clients.HasDelegatedToken(ctx) || s.gitLabClient == nil {
+		return false, nil
+	}
+
+	project, err := s.gitLabClient.GetProject(ctx, projectID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check project access: %w", err)
+	}
+
+	return project != nil, nil
+}
+
+// CreateWebhook registers a webhook subscription for a project
+func (s *VersionService) CreateWebhook(ctx context.Context, projectID string, req *models.CreateWebhookRequest) (*models.WebhookSubscription, error) {
+	store := s.webhookStore()
+	if store == nil {
+		return nil, fmt.Errorf("webhook subscriptions are not supported by the cache storage")
+	}
+
+	if strings.TrimSpace(projectID) == "" {
+		return nil, fmt.Errorf("%w: project ID is required", ErrInvalidWebhook)
+	}
+	if err := validateWebhookURL(req.URL); err != nil {
+		return nil, err
+	}
+	events, err := validateWebhookEvents(req.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := store.ListWebhooks(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= MaxWebhooksPerProject {
+		return nil, fmt.Errorf("%w: project %s already has %d subscriptions", ErrInvalidWebhook, projectID, len(existing))
+	}
+
+	id, err := newWebhookID()
+	if err != nil {
+		return nil, err
+	}
+
+	webhook := &models.WebhookSubscription{
+		ID:        id,
+		ProjectID: projectID,
+		URL:       req.URL,
+		Secret:    req.Secret,
+		Events:    events,
+		CreatedAt: time.Now(),
+	}
+	if err := store.SaveWebhook(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"project_id": projectID,
+		"webhook_id": id,
+		"host":       hookName(clients.NewWebhookClient(req.URL, s.logger)),
+	}).Info("Webhook subscription created")
+
+	redacted := webhook.Redacted()
+	return &redacted, nil
+}
+
+// ListWebhooks returns a project's webhook subscriptions without secrets
+func (s *VersionService) ListWebhooks(ctx context.Context, projectID string) (*models.WebhookListResponse, error) {
+	store := s.webhookStore()
+	if store == nil {
+		return nil, fmt.Errorf("webhook subscriptions are not supported by the cache storage")
+	}
+
+	webhooks, err := store.ListWebhooks(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range webhooks {
+		webhooks[i] = webhooks[i].Redacted()
+	}
+
+	return &models.WebhookListResponse{
+		ProjectID: projectID,
+		Webhooks:  webhooks,
+	}, nil
+}
+
+// DeleteWebhook removes a project's webhook subscription
+func (s *VersionService) DeleteWebhook(ctx context.Context, projectID, id string) error {
+	store := s.webhookStore()
+	if store == nil {
+		return fmt.Errorf("webhook subscriptions are not supported by the cache storage")
+	}
+
+	removed, err := store.DeleteWebhook(ctx, projectID, id)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return fmt.Errorf("%w: %s", ErrWebhookNotFound, id)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"project_id": projectID,
+		"webhook_id": id,
+	}).Info("Webhook subscription deleted")
+
+	return nil
+}
+
+// notifyProjectWebhooks delivers an event to the project's subscriptions in
+// the background. Deliveries are signed with the subscription's secret.
+func (s *VersionService) notifyProjectWebhooks(projectID, event string, payload interface{}) {
+	store := s.webhookStore()
+	if store == nil || projectID == "" {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), s.hookTimeout())
+		webhooks, err := store.ListWebhooks(ctx, projectID)
+		cancel()
+		if err != nil {
+			s.logger.WithError(err).WithField("project_id", projectID).Warn("Failed to load webhook subscriptions")
+			return
+		}
+
+		for _, webhook := range webhooks {
+			if !webhook.Wants(event) {
+				continue
+			}
+			go s.deliverWebhook(webhook, event, payload)
+		}
+	}()
+}
+
+func (s *VersionService) deliverWebhook(webhook models.WebhookSubscription, event string, payload interface{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.hookTimeout())
+	defer cancel()
+
+	client := clients.NewSignedWebhookClient(webhook.URL, webhook.Secret, s.logger)
+	if s.airGapped() {
+		client.DeferTo(s.airGapOpts.Outbox)
+	}
+	name := hookName(client)
+
+	start := time.Now()
+	if err := client.Notify(ctx, payload); err != nil {
+		middleware.RecordHookCall("subscription", name, "error", time.Since(start))
+		s.logger.WithError(err).WithFields(logrus.Fields{
+			"project_id": webhook.ProjectID,
+			"webhook_id": webhook.ID,
+			"event":      event,
+			"hook":       name,
+		}).Warn("Webhook delivery failed")
+		return
+	}
+	middleware.RecordHookCall("subscription", name, s.sentOutcome(), time.Since(start))
+}
+
+func validateWebhookURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http(s) URL", ErrInvalidWebhook)
+	}
+	return nil
+}
+
+// validateWebhookEvents checks an event filter against the subscribable
+// events and drops duplicates
+func validateWebhookEvents(events []string) ([]string, error) {
+	seen := make(map[string]bool, len(events))
+	valid := make([]string, 0, len(events))
+	for _, event := range events {
+		if seen[event] {
+			continue
+		}
+		seen[event] = true
+
+		subscribable := false
+		for _, e := range models.SubscribableEvents {
+			if e == event {
+				subscribable = true
+				break
+			}
+		}
+		if !subscribable {
+			return nil, fmt.Errorf("%w: unknown event %q (subscribable: %s)", ErrInvalidWebhook, event, strings.Join(models.SubscribableEvents, ", "))
+		}
+		valid = append(valid, event)
+	}
+	return valid, nil
+}
+
+func newWebhookID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
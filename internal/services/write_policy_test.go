@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rejectingStore is a durable store refusing writes while reject is set and
+// calling written after each write it takes
+type rejectingStore struct {
+	*storage.MemoryStorage
+	reject  bool
+	written func()
+}
+
+func (r *rejectingStore) SetVersion(ctx context.Context, appID string, version *models.AppVersion) error {
+	if r.reject {
+		return storage.ErrAuthFailed
+	}
+	if err := r.MemoryStorage.SetVersion(ctx, appID, version); err != nil {
+		return err
+	}
+	if r.written != nil {
+		r.written()
+	}
+	return nil
+}
+
+func TestIncrementVersion_WriteThrough(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	ctx := context.Background()
+
+	newService := func() (*VersionService, *storage.MemoryStorage, *rejectingStore) {
+		cache, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+		require.NoError(t, err)
+		memory, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+		require.NoError(t, err)
+		durable := &rejectingStore{MemoryStorage: memory}
+		version := &models.AppVersion{Current: "1.0.0", ProjectID: "1", AppName: "api"}
+		require.NoError(t, cache.SetVersion(ctx, "1-api", version))
+		require.NoError(t, durable.SetVersion(ctx, "1-api", version))
+		return NewVersionService(cache, durable, nil, logger, Options{WriteThrough: true}), cache, durable
+	}
+
+	t.Run("durable once answered", func(t *testing.T) {
+		s, cache, durable := newService()
+		ctx := WithDurability(ctx)
+		response, err := s.IncrementVersion(ctx, "1-api", models.IncrementTypeMinor, "")
+		require.NoError(t, err)
+		assert.Equal(t, "1.1.0", response.Version)
+		assert.True(t, Durable(ctx))
+
+		stored, err := durable.GetVersion(ctx, "1-api")
+		require.NoError(t, err)
+		assert.Equal(t, "1.1.0", stored.Current)
+		cached, err := cache.GetVersion(ctx, "1-api")
+		require.NoError(t, err)
+		assert.Equal(t, "1.1.0", cached.Current)
+	})
+
+	t.Run("failed durable write leaves the cache alone", func(t *testing.T) {
+		s, cache, durable := newService()
+		durable.reject = true
+		_, err := s.IncrementVersion(ctx, "1-api", models.IncrementTypeMinor, "")
+		require.ErrorIs(t, err, storage.ErrAuthFailed)
+
+		cached, err := cache.GetVersion(ctx, "1-api")
+		require.NoError(t, err)
+		assert.Equal(t, "1.0.0", cached.Current)
+	})
+	t.Run("committed version replaces a concurrent cache write", func(t *testing.T) {
+		s, cache, durable := newService()
+		// Another replica caches its version while this one commits
+		durable.written = func() {
+			durable.written = nil
+			require.NoError(t, cache.SetVersion(ctx, "1-api", &models.AppVersion{Current: "1.0.7", ProjectID: "1", AppName: "api"}))
+		}
+		response, err := s.IncrementVersion(ctx, "1-api", models.IncrementTypePatch, "")
+		require.NoError(t, err)
+		assert.Equal(t, "1.0.1", response.Version)
+
+		stored, err := durable.GetVersion(ctx, "1-api")
+		require.NoError(t, err)
+		assert.Equal(t, "1.0.1", stored.Current, "the increment is not computed again")
+		cached, err := cache.GetVersion(ctx, "1-api")
+		require.NoError(t, err)
+		assert.Equal(t, stored.Current, cached.Current)
+	})
+}
+
+func TestIncrementVersion_WriteThroughFailedOver(t *testing.T) {
+	s, git, journal := newFailoverService(t, filepath.Join(t.TempDir(), "journal.jsonl"))
+	git.mu.Lock()
+	git.down = true
+	git.mu.Unlock()
+	s.checkFailover()
+
+	ctx := WithDurability(context.Background())
+	_, err := s.IncrementVersion(ctx, "1-api", models.IncrementTypePatch, "")
+	require.NoError(t, err)
+
+	entries, err := journal.Entries(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+	assert.False(t, Durable(ctx), "journaled writes are not in Git yet")
+}
+
+func TestIncrementVersion_WriteBackIsNotDurable(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	memory, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	s := NewVersionService(memory, memory, nil, logger, Options{})
+
+	ctx := WithDurability(context.Background())
+	_, err = s.IncrementVersion(ctx, "1-api", models.IncrementTypePatch, "")
+	require.NoError(t, err)
+	assert.False(t, Durable(ctx))
+}
+
+func TestIncrementVersion_ConservativeWritesBack(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	memory, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	var conservative atomic.Bool
+	conservative.Store(true)
+	s := NewVersionService(memory, memory, nil, logger, Options{
+		WriteThrough: true,
+		Conservative: conservative.Load,
+	})
+
+	ctx := WithDurability(context.Background())
+	_, err = s.IncrementVersion(ctx, "1-api", models.IncrementTypePatch, "")
+	require.NoError(t, err)
+	assert.False(t, Durable(ctx), "conservative mode doesn't wait on Git")
+
+	conservative.Store(false)
+	ctx = WithDurability(context.Background())
+	_, err = s.IncrementVersion(ctx, "1-api", models.IncrementTypePatch, "")
+	require.NoError(t, err)
+	assert.True(t, Durable(ctx))
+}
@@ -0,0 +1,448 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/ugorji/go/codec"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Codec serializes the records RedisStorage caches: app versions and issued
+// dev versions. Values written by one codec stay readable after switching to
+// another, since every value records the codec that wrote it.
+type Codec interface {
+	Name() string
+	MarshalVersion(version *models.AppVersion) ([]byte, error)
+	UnmarshalVersion(data []byte, version *models.AppVersion) error
+	MarshalDevVersion(record *models.DevVersionRecord) ([]byte, error)
+	UnmarshalDevVersion(data []byte, record *models.DevVersionRecord) error
+}
+
+// Codec names
+const (
+	CodecJSON     = "json"
+	CodecMsgpack  = "msgpack"
+	CodecProtobuf = "protobuf"
+)
+
+// Binary values start with a header byte naming their codec. JSON values
+// have none, so values cached before codecs existed read as JSON; no JSON
+// value starts with these bytes.
+const (
+	msgpackHeader  byte = 0x01
+	protobufHeader byte = 0x02
+)
+
+// ParseCodec returns the codec with the given name; empty means JSON
+func ParseCodec(name string) (Codec, error) {
+	switch name {
+	case "", CodecJSON:
+		return jsonCodec{}, nil
+	case CodecMsgpack:
+		return msgpackCodec{}, nil
+	case CodecProtobuf:
+		return protobufCodec{}, nil
+	default:
+		return nil, fmt.Errorf("unknown codec %q: want json, msgpack or protobuf", name)
+	}
+}
+
+// codecFor returns the codec that wrote data
+func codecFor(data []byte) Codec {
+	if len(data) > 0 {
+		switch data[0] {
+		case msgpackHeader:
+			return msgpackCodec{}
+		case protobufHeader:
+			return protobufCodec{}
+		}
+	}
+	return jsonCodec{}
+}
+
+func decodeVersion(data []byte) (*models.AppVersion, error) {
+	var version models.AppVersion
+	if err := codecFor(data).UnmarshalVersion(data, &version); err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+func decodeDevVersion(data []byte) (models.DevVersionRecord, error) {
+	var record models.DevVersionRecord
+	err := codecFor(data).UnmarshalDevVersion(data, &record)
+	return record, err
+}
+
+// jsonCodec stores records as they appear in the API
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string { return CodecJSON }
+
+func (jsonCodec) MarshalVersion(version *models.AppVersion) ([]byte, error) {
+	return json.Marshal(version)
+}
+
+func (jsonCodec) UnmarshalVersion(data []byte, version *models.AppVersion) error {
+	return json.Unmarshal(data, version)
+}
+
+func (jsonCodec) MarshalDevVersion(record *models.DevVersionRecord) ([]byte, error) {
+	return json.Marshal(record)
+}
+
+func (jsonCodec) UnmarshalDevVersion(data []byte, record *models.DevVersionRecord) error {
+	return json.Unmarshal(data, record)
+}
+
+// msgpackCodec stores records as MessagePack maps keyed by their JSON field
+// names, so fields can be added like they are to JSON
+type msgpackCodec struct{}
+
+var msgpackHandle = &codec.MsgpackHandle{WriteExt: true}
+
+func (msgpackCodec) Name() string { return CodecMsgpack }
+
+func msgpackMarshal(v any) ([]byte, error) {
+	var data []byte
+	if err := codec.NewEncoderBytes(&data, msgpackHandle).Encode(v); err != nil {
+		return nil, err
+	}
+	return append([]byte{msgpackHeader}, data...), nil
+}
+
+func msgpackUnmarshal(data []byte, v any) error {
+	if len(data) == 0 || data[0] != msgpackHeader {
+		return fmt.Errorf("not a msgpack value")
+	}
+	return codec.NewDecoderBytes(data[1:], msgpackHandle).Decode(v)
+}
+
+func (msgpackCodec) MarshalVersion(version *models.AppVersion) ([]byte, error) {
+	return msgpackMarshal(version)
+}
+
+func (msgpackCodec) UnmarshalVersion(data []byte, version *models.AppVersion) error {
+	if err := msgpackUnmarshal(data, version); err != nil {
+		return err
+	}
+	// Timestamps decode as UTC; JSON keeps the writer's zone
+	version.LastUpdated = version.LastUpdated.Local()
+	if version.DeletedAt != nil {
+		deletedAt := version.DeletedAt.Local()
+		version.DeletedAt = &deletedAt
+	}
+	return nil
+}
+
+func (msgpackCodec) MarshalDevVersion(record *models.DevVersionRecord) ([]byte, error) {
+	return msgpackMarshal(record)
+}
+
+func (msgpackCodec) UnmarshalDevVersion(data []byte, record *models.DevVersionRecord) error {
+	if err := msgpackUnmarshal(data, record); err != nil {
+		return err
+	}
+	record.IssuedAt = record.IssuedAt.Local()
+	return nil
+}
+
+// protobufCodec stores records in the protobuf wire format. Field numbers
+// follow the messages below and must never be reused:
+//
+//	message AppVersion {
+//	  string current = 1; string project_id = 2; string app_name = 3;
+//	  string repo_name = 4; bool locked = 5;
+//	  map<string, string> aliases = 6; map<string, string> annotations = 7;
+//	  AppPolicy policy = 8; repeated string renamed_from = 9;
+//	  string lifecycle = 10; Timestamp last_updated = 11;
+//	  Timestamp deleted_at = 12; string default_branch = 13;
+//	  string web_url = 14;
+//	}
+//	message AppPolicy {
+//	  string scheme = 1; repeated string allowed_increments = 2;
+//	  repeated string reserved_versions = 3; string strategy = 4;
+//	}
+//	message DevVersionRecord {
+//	  string version = 1; string sha = 2; string branch = 3;
+//	  int64 counter = 4; int64 build = 5; Timestamp issued_at = 6;
+//	}
+//	message Timestamp { int64 seconds = 1; int32 nanos = 2; }
+//
+// Unknown fields are skipped, so older replicas read values written by
+// newer ones.
+type protobufCodec struct{}
+
+func (protobufCodec) Name() string { return CodecProtobuf }
+
+func (protobufCodec) MarshalVersion(version *models.AppVersion) ([]byte, error) {
+	b := []byte{protobufHeader}
+	b = appendString(b, 1, version.Current)
+	b = appendString(b, 2, version.ProjectID)
+	b = appendString(b, 3, version.AppName)
+	b = appendString(b, 4, version.RepoName)
+	if version.Locked {
+		b = protowire.AppendTag(b, 5, protowire.VarintType)
+		b = protowire.AppendVarint(b, 1)
+	}
+	b = appendStringMap(b, 6, version.Aliases)
+	b = appendStringMap(b, 7, version.Annotations)
+	if policy := version.Policy; policy != nil {
+		var p []byte
+		p = appendString(p, 1, policy.Scheme)
+		for _, increment := range policy.AllowedIncrements {
+			p = appendRepeatedString(p, 2, string(increment))
+		}
+		for _, reserved := range policy.ReservedVersions {
+			p = appendRepeatedString(p, 3, reserved)
+		}
+		p = appendString(p, 4, policy.Strategy)
+		b = protowire.AppendTag(b, 8, protowire.BytesType)
+		b = protowire.AppendBytes(b, p)
+	}
+	for _, id := range version.RenamedFrom {
+		b = appendRepeatedString(b, 9, id)
+	}
+	b = appendString(b, 10, version.Lifecycle)
+	b = appendTimestamp(b, 11, version.LastUpdated)
+	if deletedAt := version.DeletedAt; deletedAt != nil {
+		if deletedAt.IsZero() {
+			// Still a tombstone; appendTimestamp would drop the field
+			b = protowire.AppendTag(b, 12, protowire.BytesType)
+			b = protowire.AppendBytes(b, nil)
+		} else {
+			b = appendTimestamp(b, 12, *deletedAt)
+		}
+	}
+	b = appendString(b, 13, version.DefaultBranch)
+	b = appendString(b, 14, version.WebURL)
+	return b, nil
+}
+
+func (protobufCodec) UnmarshalVersion(data []byte, version *models.AppVersion) error {
+	if len(data) == 0 || data[0] != protobufHeader {
+		return fmt.Errorf("not a protobuf value")
+	}
+	return consumeFields(data[1:], func(num protowire.Number, typ protowire.Type, value []byte, n uint64) error {
+		var err error
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			version.Current = string(value)
+		case num == 2 && typ == protowire.BytesType:
+			version.ProjectID = string(value)
+		case num == 3 && typ == protowire.BytesType:
+			version.AppName = string(value)
+		case num == 4 && typ == protowire.BytesType:
+			version.RepoName = string(value)
+		case num == 5 && typ == protowire.VarintType:
+			version.Locked = n != 0
+		case num == 6 && typ == protowire.BytesType:
+			version.Aliases, err = consumeMapEntry(version.Aliases, value)
+		case num == 7 && typ == protowire.BytesType:
+			version.Annotations, err = consumeMapEntry(version.Annotations, value)
+		case num == 8 && typ == protowire.BytesType:
+			version.Policy, err = consumePolicy(value)
+		case num == 9 && typ == protowire.BytesType:
+			version.RenamedFrom = append(version.RenamedFrom, string(value))
+		case num == 10 && typ == protowire.BytesType:
+			version.Lifecycle = string(value)
+		case num == 11 && typ == protowire.BytesType:
+			version.LastUpdated, err = consumeTimestamp(value)
+		case num == 12 && typ == protowire.BytesType:
+			var deletedAt time.Time
+			if deletedAt, err = consumeTimestamp(value); err == nil {
+				version.DeletedAt = &deletedAt
+			}
+		case num == 13 && typ == protowire.BytesType:
+			version.DefaultBranch = string(value)
+		case num == 14 && typ == protowire.BytesType:
+			version.WebURL = string(value)
+		}
+		return err
+	})
+}
+
+func (protobufCodec) MarshalDevVersion(record *models.DevVersionRecord) ([]byte, error) {
+	b := []byte{protobufHeader}
+	b = appendString(b, 1, record.Version)
+	b = appendString(b, 2, record.SHA)
+	b = appendString(b, 3, record.Branch)
+	b = appendInt(b, 4, record.Counter)
+	b = appendInt(b, 5, record.Build)
+	b = appendTimestamp(b, 6, record.IssuedAt)
+	return b, nil
+}
+
+func (protobufCodec) UnmarshalDevVersion(data []byte, record *models.DevVersionRecord) error {
+	if len(data) == 0 || data[0] != protobufHeader {
+		return fmt.Errorf("not a protobuf value")
+	}
+	return consumeFields(data[1:], func(num protowire.Number, typ protowire.Type, value []byte, n uint64) error {
+		var err error
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			record.Version = string(value)
+		case num == 2 && typ == protowire.BytesType:
+			record.SHA = string(value)
+		case num == 3 && typ == protowire.BytesType:
+			record.Branch = string(value)
+		case num == 4 && typ == protowire.VarintType:
+			record.Counter = int64(n)
+		case num == 5 && typ == protowire.VarintType:
+			record.Build = int64(n)
+		case num == 6 && typ == protowire.BytesType:
+			record.IssuedAt, err = consumeTimestamp(value)
+		}
+		return err
+	})
+}
+
+// appendString appends a singular string field; empty strings are omitted
+// as proto3 does
+func appendString(b []byte, num protowire.Number, value string) []byte {
+	if value == "" {
+		return b
+	}
+	return appendRepeatedString(b, num, value)
+}
+
+func appendRepeatedString(b []byte, num protowire.Number, value string) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, value)
+}
+
+func appendInt(b []byte, num protowire.Number, value int64) []byte {
+	if value == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(value))
+}
+
+// appendStringMap appends a map<string, string> field, entries sorted by key
+// so equal maps encode to equal bytes
+func appendStringMap(b []byte, num protowire.Number, values map[string]string) []byte {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		var entry []byte
+		entry = appendRepeatedString(entry, 1, key)
+		entry = appendRepeatedString(entry, 2, values[key])
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		b = protowire.AppendBytes(b, entry)
+	}
+	return b
+}
+
+func appendTimestamp(b []byte, num protowire.Number, t time.Time) []byte {
+	if t.IsZero() {
+		return b
+	}
+	var ts []byte
+	ts = appendInt(ts, 1, t.Unix())
+	ts = appendInt(ts, 2, int64(t.Nanosecond()))
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, ts)
+}
+
+// consumeFields calls field for every field in data with its number, wire
+// type and value: the payload of length-delimited fields, the number of
+// varints. Other wire types are skipped.
+func consumeFields(data []byte, field func(num protowire.Number, typ protowire.Type, value []byte, n uint64) error) error {
+	for len(data) > 0 {
+		num, typ, length := protowire.ConsumeTag(data)
+		if length < 0 {
+			return protowire.ParseError(length)
+		}
+		data = data[length:]
+
+		var value []byte
+		var n uint64
+		switch typ {
+		case protowire.BytesType:
+			value, length = protowire.ConsumeBytes(data)
+		case protowire.VarintType:
+			n, length = protowire.ConsumeVarint(data)
+		default:
+			length = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if length < 0 {
+			return protowire.ParseError(length)
+		}
+		data = data[length:]
+
+		if err := field(num, typ, value, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func consumeMapEntry(values map[string]string, data []byte) (map[string]string, error) {
+	var key, value string
+	err := consumeFields(data, func(num protowire.Number, typ protowire.Type, field []byte, n uint64) error {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			key = string(field)
+		case num == 2 && typ == protowire.BytesType:
+			value = string(field)
+		}
+		return nil
+	})
+	if err != nil {
+		return values, err
+	}
+	if values == nil {
+		values = make(map[string]string)
+	}
+	values[key] = value
+	return values, nil
+}
+
+func consumePolicy(data []byte) (*models.AppPolicy, error) {
+	policy := &models.AppPolicy{}
+	err := consumeFields(data, func(num protowire.Number, typ protowire.Type, value []byte, n uint64) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1:
+			policy.Scheme = string(value)
+		case 2:
+			policy.AllowedIncrements = append(policy.AllowedIncrements, models.IncrementType(value))
+		case 3:
+			policy.ReservedVersions = append(policy.ReservedVersions, string(value))
+		case 4:
+			policy.Strategy = string(value)
+		}
+		return nil
+	})
+	return policy, err
+}
+
+// consumeTimestamp decodes a Timestamp in the local zone, as JSON does for
+// times written by this host. An empty Timestamp is the zero time.
+func consumeTimestamp(data []byte) (time.Time, error) {
+	if len(data) == 0 {
+		return time.Time{}, nil
+	}
+	var seconds, nanos int64
+	err := consumeFields(data, func(num protowire.Number, typ protowire.Type, value []byte, n uint64) error {
+		switch {
+		case num == 1 && typ == protowire.VarintType:
+			seconds = int64(n)
+		case num == 2 && typ == protowire.VarintType:
+			nanos = int64(n)
+		}
+		return nil
+	})
+	return time.Unix(seconds, nanos), err
+}
@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var codecNames = []string{CodecJSON, CodecMsgpack, CodecProtobuf}
+
+func sampleVersion() *models.AppVersion {
+	deletedAt := time.Date(2024, 6, 2, 8, 30, 0, 500, time.Local)
+	return &models.AppVersion{
+		Current:       "1.4.2",
+		ProjectID:     "1234",
+		AppName:       "user-service",
+		RepoName:      "user-service",
+		DefaultBranch: "main",
+		WebURL:        "https://gitlab.example.com/platform/user-service",
+		Locked:        true,
+		Aliases:       map[string]string{"stable": "1.4.1", "canary": "1.4.2"},
+		Annotations:   map[string]string{"owner": "team-a"},
+		Policy: &models.AppPolicy{
+			Scheme:            "semver",
+			AllowedIncrements: []models.IncrementType{"minor", "patch"},
+			ReservedVersions:  []string{"2.0.0"},
+			Strategy:          "patch",
+		},
+		RenamedFrom: []string{"1234-users"},
+		Lifecycle:   models.LifecycleFrozen,
+		LastUpdated: time.Date(2024, 6, 1, 12, 0, 0, 123456789, time.Local),
+		DeletedAt:   &deletedAt,
+	}
+}
+
+func sampleDevVersion() *models.DevVersionRecord {
+	return &models.DevVersionRecord{
+		Version:  "1.4.3-dev.42+a1b2c3d",
+		SHA:      "a1b2c3d",
+		Branch:   "feature-login",
+		Counter:  1042,
+		Build:    42,
+		IssuedAt: time.Date(2024, 6, 1, 12, 0, 0, 123456789, time.Local),
+	}
+}
+
+// assertSameJSON compares records the way the API and Git serialize them,
+// so timestamps in equal zones compare equal
+func assertSameJSON(t *testing.T, expected, actual any) {
+	t.Helper()
+	expectedJSON, err := json.Marshal(expected)
+	require.NoError(t, err)
+	actualJSON, err := json.Marshal(actual)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(expectedJSON), string(actualJSON))
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	for _, name := range codecNames {
+		t.Run(name, func(t *testing.T) {
+			codec, err := ParseCodec(name)
+			require.NoError(t, err)
+
+			data, err := codec.MarshalVersion(sampleVersion())
+			require.NoError(t, err)
+			version, err := decodeVersion(data)
+			require.NoError(t, err)
+			assertSameJSON(t, sampleVersion(), version)
+
+			data, err = codec.MarshalDevVersion(sampleDevVersion())
+			require.NoError(t, err)
+			record, err := decodeDevVersion(data)
+			require.NoError(t, err)
+			assertSameJSON(t, sampleDevVersion(), &record)
+		})
+	}
+}
+
+func TestCodecEmptyVersion(t *testing.T) {
+	for _, name := range codecNames {
+		t.Run(name, func(t *testing.T) {
+			codec, err := ParseCodec(name)
+			require.NoError(t, err)
+
+			data, err := codec.MarshalVersion(&models.AppVersion{Current: "0.1.0"})
+			require.NoError(t, err)
+			version, err := decodeVersion(data)
+			require.NoError(t, err)
+			assert.Equal(t, "0.1.0", version.Current)
+			assert.Nil(t, version.Policy)
+			assert.Nil(t, version.DeletedAt)
+			assert.True(t, version.LastUpdated.IsZero())
+		})
+	}
+}
+
+func TestParseCodec_Unknown(t *testing.T) {
+	_, err := ParseCodec("xml")
+	assert.Error(t, err)
+}
+
+// BenchmarkCodecs compares encoding and decoding an app version and a dev
+// version record with each codec, reporting the encoded size
+func BenchmarkCodecs(b *testing.B) {
+	for _, name := range codecNames {
+		codec, err := ParseCodec(name)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		version := sampleVersion()
+		versionData, _ := codec.MarshalVersion(version)
+		record := sampleDevVersion()
+		recordData, _ := codec.MarshalDevVersion(record)
+
+		b.Run(name+"/version/marshal", func(b *testing.B) {
+			b.ReportMetric(float64(len(versionData)), "bytes/value")
+			for i := 0; i < b.N; i++ {
+				if _, err := codec.MarshalVersion(version); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(name+"/version/unmarshal", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := decodeVersion(versionData); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(name+"/dev/marshal", func(b *testing.B) {
+			b.ReportMetric(float64(len(recordData)), "bytes/value")
+			for i := 0; i < b.N; i++ {
+				if _, err := codec.MarshalDevVersion(record); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(name+"/dev/unmarshal", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := decodeDevVersion(recordData); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,793 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// etcdMaxTxnOps is etcd's default limit on operations per transaction
+// (--max-txn-ops). Batches above it are split into several transactions.
+const etcdMaxTxnOps = 128
+
+// EtcdOptions configures EtcdStorage
+type EtcdOptions struct {
+	// Endpoints are the client URLs of the cluster, e.g.
+	// http://etcd-0:2379; requests fail over between them
+	Endpoints []string
+	// Prefix is prepended to every key, e.g. "/version-service/"
+	Prefix   string
+	Username string
+	Password string
+}
+
+// EtcdStorage keeps each app's version under its own key in etcd, talking to
+// the cluster's v3 JSON gateway. Writes are optimistic like PostgresStorage:
+// a write only applies when the key is still at the modification revision
+// this instance last read, wrote or took from the watch, and fails with
+// ErrRevisionMismatch otherwise. Watch reports writes made by other replicas
+// as they happen.
+type EtcdStorage struct {
+	opts   EtcdOptions
+	client *http.Client
+	logger *logrus.Logger
+
+	// revisions holds the last modification revision seen of each app; apps
+	// missing from it are expected not to exist yet
+	mu        sync.Mutex
+	revisions map[string]int64
+	// own holds the value of each key this instance is writing while it
+	// watches, nil for deletes, so the watch can skip its own writes
+	own      map[string][]byte
+	watching bool
+
+	authMu sync.Mutex
+	token  string
+	// endpoint is the index of the endpoint last answering
+	endpoint int
+}
+
+// NewEtcdStorage returns an EtcdStorage and checks the cluster is reachable
+func NewEtcdStorage(opts EtcdOptions, logger *logrus.Logger) (*EtcdStorage, error) {
+	if len(opts.Endpoints) == 0 {
+		return nil, fmt.Errorf("no etcd endpoints configured")
+	}
+
+	e := &EtcdStorage{
+		opts:      opts,
+		client:    &http.Client{Timeout: 30 * time.Second},
+		logger:    logger,
+		revisions: make(map[string]int64),
+		own:       make(map[string][]byte),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := e.Health(ctx); err != nil {
+		return nil, fmt.Errorf("failed to reach etcd: %w", err)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"endpoints": opts.Endpoints,
+		"prefix":    opts.Prefix,
+	}).Info("Connected to etcd storage")
+	return e, nil
+}
+
+// etcdKeyValue, etcdHeader and the request types below mirror the etcd v3
+// API as the JSON gateway encodes it: bytes as base64, int64 as strings
+type etcdKeyValue struct {
+	Key         []byte `json:"key"`
+	Value       []byte `json:"value,omitempty"`
+	ModRevision int64  `json:"mod_revision,string,omitempty"`
+}
+
+type etcdHeader struct {
+	Revision int64 `json:"revision,string,omitempty"`
+}
+
+type etcdRangeRequest struct {
+	Key       []byte `json:"key"`
+	RangeEnd  []byte `json:"range_end,omitempty"`
+	CountOnly bool   `json:"count_only,omitempty"`
+}
+
+type etcdRangeResponse struct {
+	Header etcdHeader     `json:"header"`
+	Kvs    []etcdKeyValue `json:"kvs"`
+	Count  int64          `json:"count,string,omitempty"`
+}
+
+type etcdCompare struct {
+	Key            []byte `json:"key"`
+	RangeEnd       []byte `json:"range_end,omitempty"`
+	Target         string `json:"target"`
+	Result         string `json:"result"`
+	ModRevision    int64  `json:"mod_revision,string"`
+	CreateRevision int64  `json:"create_revision,string"`
+}
+
+type etcdPutRequest struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+}
+
+type etcdDeleteRequest struct {
+	Key []byte `json:"key"`
+}
+
+type etcdRequestOp struct {
+	Put    *etcdPutRequest    `json:"request_put,omitempty"`
+	Delete *etcdDeleteRequest `json:"request_delete_range,omitempty"`
+}
+
+type etcdTxnRequest struct {
+	Compare []etcdCompare   `json:"compare"`
+	Success []etcdRequestOp `json:"success"`
+}
+
+type etcdTxnResponse struct {
+	Header    etcdHeader `json:"header"`
+	Succeeded bool       `json:"succeeded"`
+}
+
+type etcdEvent struct {
+	Type string       `json:"type"`
+	Kv   etcdKeyValue `json:"kv"`
+}
+
+type etcdWatchResponse struct {
+	Result struct {
+		Header          etcdHeader  `json:"header"`
+		Events          []etcdEvent `json:"events"`
+		CompactRevision int64       `json:"compact_revision,string,omitempty"`
+		Canceled        bool        `json:"canceled"`
+		CancelReason    string      `json:"cancel_reason"`
+	} `json:"result"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// appsPrefix returns the prefix of every app key
+func (e *EtcdStorage) appsPrefix() string {
+	return e.opts.Prefix + "apps/"
+}
+
+func (e *EtcdStorage) appKey(appID string) []byte {
+	return []byte(e.appsPrefix() + appID)
+}
+
+// prefixEnd returns the end of the key range holding every key starting
+// with prefix
+func prefixEnd(prefix string) []byte {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	// All 0xff: the range runs to the end of the keyspace
+	return []byte{0}
+}
+
+func (e *EtcdStorage) knownRevision(appID string) (int64, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	revision, ok := e.revisions[appID]
+	return revision, ok
+}
+
+// remember records the revisions read or written; a zero revision means the
+// app no longer exists
+func (e *EtcdStorage) remember(revisions map[string]int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for appID, revision := range revisions {
+		if revision == 0 {
+			delete(e.revisions, appID)
+		} else {
+			e.revisions[appID] = revision
+		}
+	}
+}
+
+// rangeApps returns every app and the cluster revision read at,
+// remembering the apps' revisions
+func (e *EtcdStorage) rangeApps(ctx context.Context) (map[string]*models.AppVersion, int64, error) {
+	prefix := e.appsPrefix()
+	var resp etcdRangeResponse
+	if err := e.call(ctx, "/v3/kv/range", etcdRangeRequest{Key: []byte(prefix), RangeEnd: prefixEnd(prefix)}, &resp); err != nil {
+		return nil, 0, err
+	}
+
+	versions := make(map[string]*models.AppVersion, len(resp.Kvs))
+	revisions := make(map[string]int64, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		appID := strings.TrimPrefix(string(kv.Key), prefix)
+		var version models.AppVersion
+		if err := json.Unmarshal(kv.Value, &version); err != nil {
+			return nil, 0, fmt.Errorf("failed to unmarshal version of %s: %w", appID, err)
+		}
+		versions[appID] = &version
+		revisions[appID] = kv.ModRevision
+	}
+	e.remember(revisions)
+	return versions, resp.Header.Revision, nil
+}
+
+func (e *EtcdStorage) GetVersion(ctx context.Context, appID string) (*models.AppVersion, error) {
+	var resp etcdRangeResponse
+	if err := e.call(ctx, "/v3/kv/range", etcdRangeRequest{Key: e.appKey(appID)}, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Kvs) == 0 {
+		e.remember(map[string]int64{appID: 0})
+		return nil, nil
+	}
+
+	var version models.AppVersion
+	if err := json.Unmarshal(resp.Kvs[0].Value, &version); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal version of %s: %w", appID, err)
+	}
+	e.remember(map[string]int64{appID: resp.Kvs[0].ModRevision})
+	return &version, nil
+}
+
+func (e *EtcdStorage) SetVersion(ctx context.Context, appID string, version *models.AppVersion) error {
+	if err := e.SetVersions(ctx, map[string]*models.AppVersion{appID: version}); err != nil {
+		return err
+	}
+
+	e.logger.WithFields(logrus.Fields{
+		"app_id":  appID,
+		"version": version.Current,
+	}).Info("Version persisted to etcd")
+	return nil
+}
+
+// SetVersions writes several app versions in one transaction per
+// etcdMaxTxnOps apps
+func (e *EtcdStorage) SetVersions(ctx context.Context, versions map[string]*models.AppVersion) error {
+	txn := &etcdTxn{storage: e}
+	for appID, version := range versions {
+		if err := txn.put(appID, version); err != nil {
+			return err
+		}
+		if len(txn.ops) == etcdMaxTxnOps {
+			if _, err := txn.commit(ctx); err != nil {
+				return err
+			}
+			txn = &etcdTxn{storage: e}
+		}
+	}
+	_, err := txn.commit(ctx)
+	return err
+}
+
+func (e *EtcdStorage) ListVersions(ctx context.Context) (map[string]*models.AppVersion, error) {
+	versions, _, err := e.rangeApps(ctx)
+	return versions, err
+}
+
+func (e *EtcdStorage) ListVersionsPage(ctx context.Context, cursor string, limit int, filter models.VersionFilter) (*models.VersionPage, error) {
+	versions, err := e.ListVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pageVersions(versions, cursor, limit, filter)
+}
+
+func (e *EtcdStorage) ListVersionsByProject(ctx context.Context, projectID string) (map[string]*models.AppVersion, error) {
+	allVersions, err := e.ListVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	projectVersions := make(map[string]*models.AppVersion)
+	for appID, version := range allVersions {
+		if models.InProject(appID, version, projectID) {
+			projectVersions[appID] = version
+		}
+	}
+	return projectVersions, nil
+}
+
+func (e *EtcdStorage) DeleteVersion(ctx context.Context, appID string) error {
+	txn := &etcdTxn{storage: e}
+	txn.delete(appID)
+	if _, err := txn.commit(ctx); err != nil {
+		return err
+	}
+
+	e.logger.WithField("app_id", appID).Info("Version deleted from etcd")
+	return nil
+}
+
+// RenameVersion moves an app's record to newAppID in one transaction
+func (e *EtcdStorage) RenameVersion(ctx context.Context, oldAppID, newAppID string, version *models.AppVersion) error {
+	txn := &etcdTxn{storage: e}
+	txn.delete(oldAppID)
+	if err := txn.put(newAppID, version); err != nil {
+		return err
+	}
+	if _, err := txn.commit(ctx); err != nil {
+		return err
+	}
+
+	e.logger.WithFields(logrus.Fields{
+		"app_id":     oldAppID,
+		"new_app_id": newAppID,
+	}).Info("Version renamed in etcd")
+	return nil
+}
+
+// etcdTxn collects writes guarded by the revisions last seen of their apps
+type etcdTxn struct {
+	storage *EtcdStorage
+	compare []etcdCompare
+	ops     []etcdRequestOp
+	// apps written, with zero for deletes
+	written map[string]bool
+}
+
+// guard makes the transaction require appID to be at its last seen
+// revision, or to not exist if none was seen
+func (t *etcdTxn) guard(appID string) {
+	key := t.storage.appKey(appID)
+	if revision, ok := t.storage.knownRevision(appID); ok {
+		t.compare = append(t.compare, etcdCompare{Key: key, Target: "MOD", Result: "EQUAL", ModRevision: revision})
+	} else {
+		t.compare = append(t.compare, etcdCompare{Key: key, Target: "CREATE", Result: "EQUAL", CreateRevision: 0})
+	}
+}
+
+func (t *etcdTxn) put(appID string, version *models.AppVersion) error {
+	value, err := json.Marshal(version)
+	if err != nil {
+		return fmt.Errorf("failed to marshal version of %s: %w", appID, err)
+	}
+	t.guard(appID)
+	t.ops = append(t.ops, etcdRequestOp{Put: &etcdPutRequest{Key: t.storage.appKey(appID), Value: value}})
+	t.record(appID, true)
+	return nil
+}
+
+// delete removes appID if it is still at the revision last seen. Deleting
+// an app this instance has never seen is unconditional.
+func (t *etcdTxn) delete(appID string) {
+	if _, ok := t.storage.knownRevision(appID); ok {
+		t.guard(appID)
+	}
+	t.ops = append(t.ops, etcdRequestOp{Delete: &etcdDeleteRequest{Key: t.storage.appKey(appID)}})
+	t.record(appID, false)
+}
+
+func (t *etcdTxn) record(appID string, exists bool) {
+	if t.written == nil {
+		t.written = make(map[string]bool)
+	}
+	t.written[appID] = exists
+}
+
+// commit runs the transaction and returns its revision, failing with
+// ErrRevisionMismatch when any app changed since it was last seen
+func (t *etcdTxn) commit(ctx context.Context) (int64, error) {
+	if len(t.ops) == 0 {
+		return 0, nil
+	}
+	e := t.storage
+
+	// Register the writes before sending them: their events may reach the
+	// watch before the response reaches this instance
+	e.expectOwn(t.ops, true)
+
+	var resp etcdTxnResponse
+	if err := e.call(ctx, "/v3/kv/txn", etcdTxnRequest{Compare: t.compare, Success: t.ops}, &resp); err != nil {
+		e.expectOwn(t.ops, false)
+		return 0, err
+	}
+	if !resp.Succeeded {
+		e.expectOwn(t.ops, false)
+		appIDs := make([]string, 0, len(t.written))
+		for appID := range t.written {
+			appIDs = append(appIDs, appID)
+		}
+		sort.Strings(appIDs)
+		return 0, fmt.Errorf("%w: %s changed in etcd since last read", ErrRevisionMismatch, strings.Join(appIDs, ", "))
+	}
+
+	revisions := make(map[string]int64, len(t.written))
+	for appID, exists := range t.written {
+		if exists {
+			revisions[appID] = resp.Header.Revision
+		} else {
+			revisions[appID] = 0
+		}
+	}
+
+	e.remember(revisions)
+	return resp.Header.Revision, nil
+}
+
+// Health checks the cluster answers
+func (e *EtcdStorage) Health(ctx context.Context) error {
+	var resp json.RawMessage
+	return e.call(ctx, "/v3/maintenance/status", struct{}{}, &resp)
+}
+
+// IsEmpty reports whether no app has been stored yet. Errors count as not
+// empty so a failing cluster is never bootstrapped over.
+func (e *EtcdStorage) IsEmpty() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	prefix := e.appsPrefix()
+	var resp etcdRangeResponse
+	if err := e.call(ctx, "/v3/kv/range", etcdRangeRequest{Key: []byte(prefix), RangeEnd: prefixEnd(prefix), CountOnly: true}, &resp); err != nil {
+		e.logger.WithError(err).Warn("Failed to check whether etcd storage is empty")
+		return false
+	}
+	return resp.Count == 0
+}
+
+// Bootstrap writes the initial versions and returns the cluster revision
+// after the last write. The first transaction requires the prefix to be
+// empty and fails with ErrAlreadyBootstrapped otherwise; with more apps than
+// fit one transaction, the rest follow in further transactions.
+func (e *EtcdStorage) Bootstrap(ctx context.Context, vf *models.VersionsFile, message string) (string, error) {
+	prefix := e.appsPrefix()
+	txn := &etcdTxn{storage: e, compare: []etcdCompare{{
+		Key: []byte(prefix), RangeEnd: prefixEnd(prefix), Target: "CREATE", Result: "EQUAL", CreateRevision: 0,
+	}}}
+
+	var revision int64
+	first := true
+	flush := func() error {
+		var err error
+		revision, err = txn.commit(ctx)
+		if first && errors.Is(err, ErrRevisionMismatch) {
+			return fmt.Errorf("%w: %s holds apps", ErrAlreadyBootstrapped, prefix)
+		}
+		first = false
+		txn = &etcdTxn{storage: e}
+		return err
+	}
+
+	for appID, version := range vf.Versions {
+		if err := txn.put(appID, version); err != nil {
+			return "", err
+		}
+		if len(txn.ops) == etcdMaxTxnOps {
+			if err := flush(); err != nil {
+				return "", err
+			}
+		}
+	}
+	if len(txn.ops) > 0 {
+		if err := flush(); err != nil {
+			return "", err
+		}
+	}
+	if first && !e.IsEmpty() {
+		return "", fmt.Errorf("%w: %s holds apps", ErrAlreadyBootstrapped, prefix)
+	}
+
+	e.logger.WithFields(logrus.Fields{
+		"apps":    len(vf.Versions),
+		"message": message,
+	}).Info("etcd storage bootstrapped")
+	return strconv.FormatInt(revision, 10), nil
+}
+
+// Watch calls handle with every app written or deleted by another writer
+// until ctx ends or the watch fails; version is nil for deletes. handle
+// reports whether the caller's view now matches the change, in which case
+// the change's revision counts as seen and later writes build on it.
+// Writes of this instance are skipped. When etcd has compacted revisions the
+// watch missed, every app is reported again from a fresh read.
+func (e *EtcdStorage) Watch(ctx context.Context, handle func(appID string, version *models.AppVersion) bool) error {
+	e.mu.Lock()
+	e.watching = true
+	e.mu.Unlock()
+	defer func() {
+		e.mu.Lock()
+		e.watching = false
+		e.own = make(map[string][]byte)
+		e.mu.Unlock()
+	}()
+
+	_, revision, err := e.rangeApps(ctx)
+	if err != nil {
+		return err
+	}
+
+	for {
+		_, err := e.watchFrom(ctx, revision+1, handle)
+		if err == nil || ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !errors.Is(err, errEtcdCompacted) {
+			return err
+		}
+
+		e.logger.WithError(err).Warn("etcd watch fell behind compaction, re-reading all apps")
+		versions, current, err := e.rangeApps(ctx)
+		if err != nil {
+			return err
+		}
+		for appID, version := range versions {
+			handle(appID, version)
+		}
+		revision = current
+	}
+}
+
+// errEtcdCompacted is returned by watchFrom when the revisions to watch
+// from have been compacted
+var errEtcdCompacted = errors.New("watched revision compacted")
+
+// watchFrom streams changes from revision on, returning the last revision
+// seen when the stream ends
+func (e *EtcdStorage) watchFrom(ctx context.Context, revision int64, handle func(appID string, version *models.AppVersion) bool) (int64, error) {
+	prefix := e.appsPrefix()
+	body := map[string]any{"create_request": map[string]any{
+		"key":            []byte(prefix),
+		"range_end":      prefixEnd(prefix),
+		"start_revision": strconv.FormatInt(revision, 10),
+	}}
+
+	// The stream stays open, so it can't share the client's timeout
+	resp, err := e.post(ctx, &http.Client{}, "/v3/watch", body)
+	if err != nil {
+		return revision, err
+	}
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var msg etcdWatchResponse
+		if err := decoder.Decode(&msg); err != nil {
+			if ctx.Err() != nil {
+				return revision, nil
+			}
+			return revision, fmt.Errorf("etcd watch stream ended: %w", err)
+		}
+		if msg.Error != nil {
+			return revision, fmt.Errorf("etcd watch failed: %s", msg.Error.Message)
+		}
+		if msg.Result.CompactRevision != 0 {
+			return msg.Result.CompactRevision, fmt.Errorf("%w: at %d", errEtcdCompacted, msg.Result.CompactRevision)
+		}
+		if msg.Result.Canceled {
+			return revision, fmt.Errorf("etcd watch canceled: %s", msg.Result.CancelReason)
+		}
+
+		for _, event := range msg.Result.Events {
+			revision = event.Kv.ModRevision
+			if e.skipOwn(event) {
+				continue
+			}
+
+			appID := strings.TrimPrefix(string(event.Kv.Key), prefix)
+			var version *models.AppVersion
+			if event.Type != "DELETE" {
+				version = &models.AppVersion{}
+				if err := json.Unmarshal(event.Kv.Value, version); err != nil {
+					e.logger.WithError(err).WithField("app_id", appID).Warn("Skipping unreadable version from etcd watch")
+					continue
+				}
+			}
+
+			if handle(appID, version) {
+				if version == nil {
+					e.remember(map[string]int64{appID: 0})
+				} else {
+					e.remember(map[string]int64{appID: revision})
+				}
+			}
+		}
+	}
+}
+
+// expectOwn registers the writes of ops with the watch, or withdraws them
+// when they didn't apply
+func (e *EtcdStorage) expectOwn(ops []etcdRequestOp, expect bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.watching {
+		return
+	}
+	for _, op := range ops {
+		key, value := "", []byte(nil)
+		if op.Put != nil {
+			key, value = string(op.Put.Key), op.Put.Value
+		} else {
+			key = string(op.Delete.Key)
+		}
+		if expect {
+			e.own[key] = value
+		} else if pending, ok := e.own[key]; ok && bytes.Equal(pending, value) {
+			delete(e.own, key)
+		}
+	}
+}
+
+// skipOwn reports whether event is a write of this instance, which it
+// stops expecting
+func (e *EtcdStorage) skipOwn(event etcdEvent) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	key := string(event.Kv.Key)
+	pending, ok := e.own[key]
+	if !ok || (event.Type == "DELETE") != (pending == nil) || !bytes.Equal(pending, event.Kv.Value) {
+		return false
+	}
+	delete(e.own, key)
+	return true
+}
+
+func (e *EtcdStorage) RebuildCache(ctx context.Context, versions map[string]*models.AppVersion) error {
+	// etcd storage doesn't use cache, so this is a no-op
+	return nil
+}
+
+// call posts req to an etcd API path and decodes the response into resp
+func (e *EtcdStorage) call(ctx context.Context, path string, req, resp any) error {
+	httpResp, err := e.post(ctx, e.client, path, req)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	if err := json.NewDecoder(httpResp.Body).Decode(resp); err != nil {
+		return fmt.Errorf("failed to decode etcd %s response: %w", path, err)
+	}
+	return nil
+}
+
+// post sends req to the first endpoint answering, authenticating when a
+// user is configured, and returns the successful response
+func (e *EtcdStorage) post(ctx context.Context, client *http.Client, path string, req any) (*http.Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal etcd request: %w", err)
+	}
+
+	resp, err := e.send(ctx, client, path, body)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized && e.opts.Username != "" {
+		// The token expired; authenticate again once
+		resp.Body.Close()
+		e.setToken("")
+		resp, err = e.send(ctx, client, path, body)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, etcdError(path, resp)
+	}
+	return resp, nil
+}
+
+// send tries each endpoint in turn, starting with the last one answering
+func (e *EtcdStorage) send(ctx context.Context, client *http.Client, path string, body []byte) (*http.Response, error) {
+	token, err := e.authToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	e.authMu.Lock()
+	start := e.endpoint
+	e.authMu.Unlock()
+
+	var lastErr error
+	for i := range e.opts.Endpoints {
+		index := (start + i) % len(e.opts.Endpoints)
+		endpoint := strings.TrimSuffix(e.opts.Endpoints[index], "/")
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+path, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to build etcd request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = err
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+
+		e.authMu.Lock()
+		e.endpoint = index
+		e.authMu.Unlock()
+		return resp, nil
+	}
+	return nil, fmt.Errorf("etcd %s failed: %w", path, lastErr)
+}
+
+// authToken returns the auth token to send, authenticating first if a user
+// is configured and no token is held
+func (e *EtcdStorage) authToken(ctx context.Context) (string, error) {
+	if e.opts.Username == "" {
+		return "", nil
+	}
+
+	e.authMu.Lock()
+	token := e.token
+	e.authMu.Unlock()
+	if token != "" {
+		return token, nil
+	}
+
+	body, _ := json.Marshal(map[string]string{"name": e.opts.Username, "password": e.opts.Password})
+	var lastErr error
+	for _, endpoint := range e.opts.Endpoints {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(endpoint, "/")+"/v3/auth/authenticate", bytes.NewReader(body))
+		if err != nil {
+			return "", fmt.Errorf("failed to build etcd request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := e.client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			err := etcdError("/v3/auth/authenticate", resp)
+			resp.Body.Close()
+			return "", err
+		}
+
+		var auth struct {
+			Token string `json:"token"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&auth)
+		resp.Body.Close()
+		if err != nil {
+			return "", fmt.Errorf("failed to decode etcd auth response: %w", err)
+		}
+		e.setToken(auth.Token)
+		return auth.Token, nil
+	}
+	return "", fmt.Errorf("etcd authentication failed: %w", lastErr)
+}
+
+func (e *EtcdStorage) setToken(token string) {
+	e.authMu.Lock()
+	defer e.authMu.Unlock()
+	e.token = token
+}
+
+func etcdError(path string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	var apiErr struct {
+		Message string `json:"message"`
+	}
+	message := strings.TrimSpace(string(body))
+	if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+		message = apiErr.Message
+	}
+	return fmt.Errorf("etcd %s failed with status %d: %s", path, resp.StatusCode, message)
+}
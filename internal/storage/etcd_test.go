@@ -0,0 +1,251 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEtcd serves the parts of the etcd v3 JSON gateway EtcdStorage uses
+type fakeEtcd struct {
+	mu       sync.Mutex
+	revision int64
+	keys     map[string]fakeEtcdKey
+	events   []etcdEvent
+	changed  chan struct{}
+}
+
+type fakeEtcdKey struct {
+	value  []byte
+	create int64
+	mod    int64
+}
+
+func newFakeEtcd() *fakeEtcd {
+	return &fakeEtcd{keys: make(map[string]fakeEtcdKey), changed: make(chan struct{})}
+}
+
+func inRange(key string, start, end []byte) bool {
+	if len(end) == 0 {
+		return key == string(start)
+	}
+	return key >= string(start) && key < string(end)
+}
+
+func (f *fakeEtcd) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/v3/maintenance/status":
+		w.Write([]byte(`{"version":"3.5.17"}`))
+	case "/v3/kv/range":
+		var req etcdRangeRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		f.mu.Lock()
+		resp := etcdRangeResponse{Header: etcdHeader{Revision: f.revision}}
+		for key, kv := range f.keys {
+			if inRange(key, req.Key, req.RangeEnd) {
+				resp.Count++
+				if !req.CountOnly {
+					resp.Kvs = append(resp.Kvs, etcdKeyValue{Key: []byte(key), Value: kv.value, ModRevision: kv.mod})
+				}
+			}
+		}
+		f.mu.Unlock()
+		json.NewEncoder(w).Encode(resp)
+	case "/v3/kv/txn":
+		var req etcdTxnRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		json.NewEncoder(w).Encode(f.txn(req))
+	case "/v3/watch":
+		f.watch(w, r)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeEtcd) txn(req etcdTxnRequest) etcdTxnResponse {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, c := range req.Compare {
+		matched := false
+		for key, kv := range f.keys {
+			if !inRange(key, c.Key, c.RangeEnd) {
+				continue
+			}
+			matched = true
+			if (c.Target == "MOD" && kv.mod != c.ModRevision) || (c.Target == "CREATE" && kv.create != c.CreateRevision) {
+				return etcdTxnResponse{Header: etcdHeader{Revision: f.revision}}
+			}
+		}
+		if !matched && c.Target == "MOD" && c.ModRevision != 0 {
+			return etcdTxnResponse{Header: etcdHeader{Revision: f.revision}}
+		}
+	}
+
+	f.revision++
+	for _, op := range req.Success {
+		switch {
+		case op.Put != nil:
+			kv := f.keys[string(op.Put.Key)]
+			if kv.create == 0 {
+				kv.create = f.revision
+			}
+			kv.value, kv.mod = op.Put.Value, f.revision
+			f.keys[string(op.Put.Key)] = kv
+			f.events = append(f.events, etcdEvent{Kv: etcdKeyValue{Key: op.Put.Key, Value: op.Put.Value, ModRevision: f.revision}})
+		case op.Delete != nil:
+			if _, ok := f.keys[string(op.Delete.Key)]; ok {
+				delete(f.keys, string(op.Delete.Key))
+				f.events = append(f.events, etcdEvent{Type: "DELETE", Kv: etcdKeyValue{Key: op.Delete.Key, ModRevision: f.revision}})
+			}
+		}
+	}
+	close(f.changed)
+	f.changed = make(chan struct{})
+	return etcdTxnResponse{Header: etcdHeader{Revision: f.revision}, Succeeded: true}
+}
+
+func (f *fakeEtcd) watch(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		CreateRequest struct {
+			StartRevision int64 `json:"start_revision,string"`
+		} `json:"create_request"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	next := req.CreateRequest.StartRevision
+
+	for {
+		f.mu.Lock()
+		var events []etcdEvent
+		for _, event := range f.events {
+			if event.Kv.ModRevision >= next {
+				events = append(events, event)
+			}
+		}
+		next = f.revision + 1
+		changed := f.changed
+		f.mu.Unlock()
+
+		if len(events) > 0 {
+			var msg etcdWatchResponse
+			msg.Result.Events = events
+			json.NewEncoder(w).Encode(msg)
+			w.(http.Flusher).Flush()
+		}
+
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func newTestEtcdStorage(t *testing.T, url string) *EtcdStorage {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	e, err := NewEtcdStorage(EtcdOptions{Endpoints: []string{url}, Prefix: "/vs/"}, logger)
+	require.NoError(t, err)
+	return e
+}
+
+func TestEtcdStorage_Writes(t *testing.T) {
+	server := httptest.NewServer(newFakeEtcd())
+	t.Cleanup(server.Close)
+	e := newTestEtcdStorage(t, server.URL)
+	ctx := context.Background()
+
+	assert.True(t, e.IsEmpty())
+	_, err := e.Bootstrap(ctx, &models.VersionsFile{Versions: map[string]*models.AppVersion{"1-api": {Current: "1.0.0"}}}, "seed")
+	require.NoError(t, err)
+	_, err = e.Bootstrap(ctx, &models.VersionsFile{}, "seed")
+	assert.ErrorIs(t, err, ErrAlreadyBootstrapped)
+
+	require.NoError(t, e.SetVersion(ctx, "1-api", &models.AppVersion{Current: "1.0.1"}))
+	require.NoError(t, e.SetVersions(ctx, map[string]*models.AppVersion{
+		"1-web": {Current: "2.0.0"},
+		"2-cli": {Current: "0.1.0"},
+	}))
+	require.NoError(t, e.RenameVersion(ctx, "2-cli", "2-tool", &models.AppVersion{Current: "0.1.0"}))
+	require.NoError(t, e.DeleteVersion(ctx, "1-web"))
+
+	versions, err := e.ListVersions(ctx)
+	require.NoError(t, err)
+	assert.Len(t, versions, 2)
+	assert.Equal(t, "1.0.1", versions["1-api"].Current)
+	assert.Equal(t, "0.1.0", versions["2-tool"].Current)
+}
+
+func TestEtcdStorage_RevisionConflict(t *testing.T) {
+	server := httptest.NewServer(newFakeEtcd())
+	t.Cleanup(server.Close)
+	first := newTestEtcdStorage(t, server.URL)
+	second := newTestEtcdStorage(t, server.URL)
+	ctx := context.Background()
+
+	require.NoError(t, first.SetVersion(ctx, "1-api", &models.AppVersion{Current: "1.0.0"}))
+	err := second.SetVersion(ctx, "1-api", &models.AppVersion{Current: "1.0.0"})
+	assert.ErrorIs(t, err, ErrRevisionMismatch)
+
+	_, err = second.GetVersion(ctx, "1-api")
+	require.NoError(t, err)
+	require.NoError(t, second.SetVersion(ctx, "1-api", &models.AppVersion{Current: "1.0.1"}))
+	err = first.SetVersion(ctx, "1-api", &models.AppVersion{Current: "1.0.1"})
+	assert.ErrorIs(t, err, ErrRevisionMismatch)
+}
+
+func TestEtcdStorage_WatchReportsOtherWriters(t *testing.T) {
+	server := httptest.NewServer(newFakeEtcd())
+	t.Cleanup(server.Close)
+	watched := newTestEtcdStorage(t, server.URL)
+	other := newTestEtcdStorage(t, server.URL)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan string, 10)
+	go watched.Watch(ctx, func(appID string, version *models.AppVersion) bool {
+		if version == nil {
+			changes <- appID + " deleted"
+		} else {
+			changes <- appID + " " + version.Current
+		}
+		return true
+	})
+	require.Eventually(t, func() bool {
+		watched.mu.Lock()
+		defer watched.mu.Unlock()
+		return watched.watching
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, watched.SetVersion(context.Background(), "1-own", &models.AppVersion{Current: "1.0.0"}))
+	require.NoError(t, other.SetVersion(context.Background(), "1-api", &models.AppVersion{Current: "1.0.0"}))
+	require.NoError(t, other.DeleteVersion(context.Background(), "1-api"))
+
+	var received bytes.Buffer
+	for i := 0; i < 2; i++ {
+		select {
+		case change := <-changes:
+			received.WriteString(change + "\n")
+		case <-time.After(2 * time.Second):
+			t.Fatalf("missing watch events, got %q", received.String())
+		}
+	}
+	assert.Equal(t, "1-api 1.0.0\n1-api deleted\n", received.String())
+}
+
+func TestPrefixEnd(t *testing.T) {
+	assert.Equal(t, []byte("/vs/apps0"), prefixEnd("/vs/apps/"))
+	assert.Equal(t, []byte{'a', 0x01}, prefixEnd(string([]byte{'a', 0x00})))
+	assert.Equal(t, []byte("b"), prefixEnd(string([]byte{'a', 0xff})))
+}
@@ -3,19 +3,20 @@ package storage
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/pkg/breaker"
+	"github.com/company/version-service/pkg/semver"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
-	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/sirupsen/logrus"
 )
 
@@ -25,272 +26,555 @@ const (
 	tempDirPrefix    = "version-service-"
 )
 
+// versionRecord is a single app version as recorded by a commit to the
+// versions file
+type versionRecord struct {
+	version *models.AppVersion
+	commit  string
+	when    time.Time
+}
+
 type GitStorage struct {
-	repoURL  string
-	branch   string
-	username string
-	token    string
-	localDir string
-	repo     *git.Repository
-	logger   *logrus.Logger
-	mu       sync.Mutex
+	repoURL   string
+	branch    string
+	auth      transport.AuthMethod
+	cloneOpts GitCloneOptions
+	localDir  string
+	repo      *git.Repository
+	logger    *logrus.Logger
+	// lock serializes all Git operations. It is a channel rather than a
+	// mutex so callers waiting for it can give up when their context ends.
+	lock chan struct{}
+	done chan struct{}
+	// TagIncrements creates an annotated tag, {app-id}/{version}, on the
+	// commit that first writes each version
+	TagIncrements bool
+	// Breaker, if set, opens after consecutive failures to reach the
+	// primary remote. While it is open pulls are skipped, serving the local
+	// clone or the mirror, and writes are committed locally and left to the
+	// background push.
+	Breaker *breaker.Breaker
+	// pendingTags holds the tags created since the last push
+	pendingTags  map[string]struct{}
+	health       remoteHealth
+	mirrorHealth remoteHealth
+	// cloneLocks holds the lock file of each clone directory, marking it
+	// as in use until the process exits
+	cloneLocks map[string]*os.File
 }
 
-func NewGitStorage(repoURL, branch, username, token string, logger *logrus.Logger) (*GitStorage, error) {
-	tempDir, err := os.MkdirTemp("", tempDirPrefix)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create temp dir: %w", err)
-	}
+// GitCloneOptions controls how much of the repository GitStorage keeps
+// locally
+type GitCloneOptions struct {
+	// Depth limits the clone to the latest commits of the branch; 0 clones
+	// the full history. History, rollback and undo only see the commits
+	// kept.
+	Depth int
+	// SparseCheckout checks out only the versions files, leaving the rest
+	// of the tree out of the worktree
+	SparseCheckout bool
+	// ReshallowInterval is how often a shallow clone is replaced by a fresh
+	// one at Depth, dropping the commits pulled since; 0 disables it
+	ReshallowInterval time.Duration
+	// MaintenanceInterval is how often the clone is checked, pruned and
+	// kept under MaxSize (see Maintain); 0 disables it
+	MaintenanceInterval time.Duration
+	// MaxSize caps the bytes the clone may take on disk; a larger clone is
+	// replaced by a fresh one. 0 disables the cap.
+	MaxSize int64
+	// MirrorURL is a read-only copy of the repository, reached with the
+	// same credentials. Clones and pulls fall back to it while the primary
+	// remote is unreachable; writes are committed locally and pushed to
+	// the primary once it is back.
+	MirrorURL string
+}
 
+// NewGitStorage clones the branch of repoURL, authenticating with auth (see
+// NewTokenAuth and NewSSHAuth)
+func NewGitStorage(repoURL, branch string, auth transport.AuthMethod, cloneOpts GitCloneOptions, logger *logrus.Logger) (*GitStorage, error) {
 	gs := &GitStorage{
-		repoURL:  repoURL,
-		branch:   branch,
-		username: username,
-		token:    token,
-		localDir: tempDir,
-		logger:   logger,
+		repoURL:   repoURL,
+		branch:    branch,
+		auth:      auth,
+		cloneOpts: cloneOpts,
+		logger:    logger,
+		lock:      make(chan struct{}, 1),
+		done:      make(chan struct{}),
 	}
 
-	if err := gs.clone(); err != nil {
+	localDir, repo, err := gs.clone(context.Background())
+	if err != nil {
 		return nil, err
 	}
+	gs.localDir, gs.repo = localDir, repo
+	gs.recoverOrphans(context.Background())
+
+	if cloneOpts.Depth > 0 && cloneOpts.ReshallowInterval > 0 {
+		go gs.reshallowLoop()
+	}
+	if cloneOpts.MaintenanceInterval > 0 {
+		go gs.maintenanceLoop()
+	}
 
 	return gs, nil
 }
 
-func (g *GitStorage) clone() error {
-	auth := &http.BasicAuth{
-		Username: g.username,
-		Password: g.token,
+// clone clones the branch into a new temporary directory
+func (g *GitStorage) clone(ctx context.Context) (string, *git.Repository, error) {
+	localDir, err := g.claimCloneDir()
+	if err != nil {
+		return "", nil, err
 	}
 
-	repo, err := git.PlainClone(g.localDir, false, &git.CloneOptions{
+	start := time.Now()
+	repo, err := git.PlainCloneContext(ctx, localDir, false, &git.CloneOptions{
 		URL:           g.repoURL,
-		Auth:          auth,
+		Auth:          g.auth,
 		ReferenceName: plumbing.NewBranchReferenceName(g.branch),
 		SingleBranch:  true,
+		Depth:         g.cloneOpts.Depth,
 		Progress:      nil,
 	})
+	if err != nil && !isMissingBranch(err) && g.cloneOpts.MirrorURL != "" && ctx.Err() == nil {
+		repo, err = g.cloneFromMirror(ctx, localDir, err)
+	}
 
 	if err != nil {
-		// Handle empty repository case
-		if err.Error() == "remote repository is empty" {
-			g.logger.Info("Repository is empty, initializing new repository")
+		if !isMissingBranch(err) {
+			g.removeClone(localDir)
+			g.logger.WithError(err).Error("Failed to clone repository")
+			return "", nil, fmt.Errorf("failed to clone repository: %w", classifyGitError(err))
+		}
 
-			// Initialize a new repository locally
-			repo, err = git.PlainInit(g.localDir, false)
-			if err != nil {
-				return fmt.Errorf("failed to initialize repository: %w", err)
-			}
+		// A failed clone can leave a partial repository behind
+		if err := os.RemoveAll(localDir); err != nil {
+			g.removeClone(localDir)
+			return "", nil, fmt.Errorf("failed to clean up clone: %w", err)
+		}
+		if err := os.MkdirAll(localDir, 0o700); err != nil {
+			g.removeClone(localDir)
+			return "", nil, fmt.Errorf("failed to create temp dir: %w", err)
+		}
 
-			// Add remote
-			_, err = repo.CreateRemote(&config.RemoteConfig{
-				Name: "origin",
-				URLs: []string{g.repoURL},
-			})
-			if err != nil {
-				return fmt.Errorf("failed to add remote: %w", err)
-			}
+		// The remote is empty or lacks the branch. Start from an empty local
+		// repository on the branch; Bootstrap or the first write creates it.
+		repo, err = git.PlainInitWithOptions(localDir, &git.PlainInitOptions{
+			InitOptions: git.InitOptions{DefaultBranch: plumbing.NewBranchReferenceName(g.branch)},
+		})
+		if err != nil {
+			g.removeClone(localDir)
+			return "", nil, fmt.Errorf("failed to initialize repository: %w", err)
+		}
 
-			// Create initial versions.json file
-			vf := &models.VersionsFile{
-				Versions:    make(map[string]*models.AppVersion),
-				LastUpdated: time.Now(),
-			}
+		if _, err := repo.CreateRemote(&config.RemoteConfig{
+			Name: "origin",
+			URLs: []string{g.repoURL},
+		}); err != nil {
+			g.removeClone(localDir)
+			return "", nil, fmt.Errorf("failed to add remote: %w", err)
+		}
 
-			// Write the file directly since writeVersionsFile might depend on g.repo
-			data, err := json.MarshalIndent(vf, "", "  ")
-			if err != nil {
-				return fmt.Errorf("failed to marshal versions file: %w", err)
-			}
-			filePath := filepath.Join(g.localDir, versionsFileName)
-			if err := os.WriteFile(filePath, data, 0644); err != nil {
-				return fmt.Errorf("failed to write initial versions file: %w", err)
-			}
+		g.logger.WithFields(logrus.Fields{
+			"repo":   g.repoURL,
+			"branch": g.branch,
+		}).Warn("Versions branch does not exist yet; run bootstrap or it is created on the first write")
+		return localDir, repo, nil
+	}
 
-			// Create initial commit
-			w, err := repo.Worktree()
-			if err != nil {
-				return fmt.Errorf("failed to get worktree: %w", err)
-			}
+	if g.cloneOpts.SparseCheckout {
+		if err := sparseCheckout(repo, g.branch); err != nil {
+			g.removeClone(localDir)
+			return "", nil, err
+		}
+	}
 
-			if _, err := w.Add(versionsFileName); err != nil {
-				return fmt.Errorf("failed to add versions file: %w", err)
-			}
+	g.logger.WithFields(logrus.Fields{
+		"repo":     g.repoURL,
+		"branch":   g.branch,
+		"depth":    g.cloneOpts.Depth,
+		"sparse":   g.cloneOpts.SparseCheckout,
+		"duration": time.Since(start).String(),
+	}).Info("Repository cloned successfully")
 
-			_, err = w.Commit("Initial commit", &git.CommitOptions{
-				Author: &object.Signature{
-					Name:  "Version Service",
-					Email: "version-service@company.com",
-					When:  time.Now(),
-				},
-			})
-			if err != nil {
-				return fmt.Errorf("failed to create initial commit: %w", err)
-			}
+	return localDir, repo, nil
+}
 
-			// Push to remote to create the branch
-			err = repo.Push(&git.PushOptions{
-				Auth:       auth,
-				RemoteName: "origin",
-				RefSpecs: []config.RefSpec{
-					config.RefSpec(fmt.Sprintf("refs/heads/%s:refs/heads/%s", g.branch, g.branch)),
-				},
-			})
-			if err != nil && err != git.NoErrAlreadyUpToDate {
-				g.logger.WithError(err).Warn("Failed to push initial commit, repository might stay empty")
-			}
+// sparseCheckout removes every file but the versions files from the
+// worktree. They stay in the index, so commits keep them.
+func sparseCheckout(repo *git.Repository, branch string) error {
+	w, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
 
-			g.repo = repo
-			g.logger.Info("Empty repository initialized successfully")
-			return nil
+	if err := w.Checkout(&git.CheckoutOptions{
+		Branch:                    plumbing.NewBranchReferenceName(branch),
+		SparseCheckoutDirectories: []string{versionsDir + "/", versionsFileName},
+	}); err != nil {
+		return fmt.Errorf("failed to make the checkout sparse: %w", err)
+	}
+	return nil
+}
+
+// isShallow reports whether the local repository lacks older history
+func (g *GitStorage) isShallow() bool {
+	shallow, err := g.repo.Storer.Shallow()
+	return err == nil && len(shallow) > 0
+}
+
+// logVersions returns the commits that may touch the versions files, newest
+// first. Path filtering compares each commit with its parent, which the
+// oldest commit of a shallow clone lacks, so shallow clones walk every
+// commit instead.
+func (g *GitStorage) logVersions() (object.CommitIter, error) {
+	opts := &git.LogOptions{PathFilter: isVersionsPath}
+	if g.isShallow() {
+		opts.PathFilter = nil
+	}
+	return g.repo.Log(opts)
+}
+
+// endOfHistory reports whether a history walk failed only because it reached
+// the oldest commit of a shallow clone
+func (g *GitStorage) endOfHistory(err error) bool {
+	return errors.Is(err, plumbing.ErrObjectNotFound) && g.isShallow()
+}
+
+// reshallowLoop replaces the clone every ReshallowInterval until Close
+func (g *GitStorage) reshallowLoop() {
+	ticker := time.NewTicker(g.cloneOpts.ReshallowInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-g.done:
+			return
+		case <-ticker.C:
+			if err := g.Reshallow(context.Background()); err != nil {
+				g.logger.WithError(err).Warn("Failed to re-shallow repository")
+			}
 		}
+	}
+}
 
-		g.logger.WithError(err).Error("Failed to clone repository")
-		return fmt.Errorf("failed to clone repository: %w", err)
+// Reshallow replaces a shallow clone with a fresh one at the configured
+// depth, dropping the commits pulled since it was made. Clones with
+// unpushed commits or tags are kept until they are pushed.
+func (g *GitStorage) Reshallow(ctx context.Context) error {
+	if g.cloneOpts.Depth <= 0 {
+		return nil
 	}
 
-	g.repo = repo
-	g.logger.WithFields(logrus.Fields{
-		"repo":   g.repoURL,
-		"branch": g.branch,
-	}).Info("Repository cloned successfully")
+	if err := g.acquire(ctx); err != nil {
+		return err
+	}
+	defer g.release()
+
+	unpushed, err := g.hasUnpushedChanges(ctx)
+	if err != nil {
+		return err
+	}
+	if unpushed {
+		g.logger.Info("Unpushed commits, re-shallowing postponed")
+		return nil
+	}
+
+	return g.reclone(ctx)
+}
 
+// hasUnpushedChanges reports whether the clone holds commits or tags that
+// only exist locally. Callers hold the lock.
+func (g *GitStorage) hasUnpushedChanges(ctx context.Context) (bool, error) {
+	if len(g.pendingTags) > 0 {
+		return true, nil
+	}
+	revision, err := g.headRevision()
+	if err != nil || revision == "" {
+		return false, err
+	}
+	unpushed, err := g.hasUnpushedCommits(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to check for unpushed commits: %w", err)
+	}
+	return unpushed, nil
+}
+
+// reclone replaces the local clone with a fresh one and removes the old
+// one. Callers hold the lock.
+func (g *GitStorage) reclone(ctx context.Context) error {
+	localDir, repo, err := g.clone(ctx)
+	if err != nil {
+		return err
+	}
+
+	oldDir := g.localDir
+	g.localDir, g.repo = localDir, repo
+	g.pendingTags = nil
+	if err := g.removeClone(oldDir); err != nil {
+		g.logger.WithError(err).WithField("dir", oldDir).Warn("Failed to remove previous clone")
+	}
 	return nil
 }
 
-func (g *GitStorage) pull() error {
-	auth := &http.BasicAuth{
-		Username: g.username,
-		Password: g.token,
+// isMissingBranch reports whether a clone or pull failed because the remote
+// has no commits on the versions branch
+func isMissingBranch(err error) bool {
+	return errors.Is(err, transport.ErrEmptyRemoteRepository) ||
+		errors.Is(err, plumbing.ErrReferenceNotFound) ||
+		errors.Is(err, git.NoMatchingRefSpecError{})
+}
+
+// IsEmpty reports whether the versions branch has no commits yet
+func (g *GitStorage) IsEmpty() bool {
+	if err := g.acquire(context.Background()); err != nil {
+		g.logger.WithError(err).Warn("Failed to lock the repository, treating it as not empty")
+		return false
+	}
+	defer g.release()
+
+	revision, err := g.headRevision()
+	if err != nil {
+		g.logger.WithError(err).Warn("Failed to read the versions branch, treating it as not empty")
+		return false
+	}
+	return revision == ""
+}
+
+// Bootstrap writes the initial versions file to an empty versions branch in a
+// single commit and pushes it, creating the branch. Unlike regular writes, a
+// failed push is an error: the branch must exist once bootstrap returns.
+func (g *GitStorage) Bootstrap(ctx context.Context, vf *models.VersionsFile, message string) (string, error) {
+	if err := g.acquire(ctx); err != nil {
+		return "", err
+	}
+	defer g.release()
+
+	if err := g.sync(ctx); err != nil {
+		return "", err
+	}
+
+	revision, err := g.headRevision()
+	if err != nil {
+		return "", err
+	}
+	if revision != "" {
+		return "", fmt.Errorf("%w: %s has commits at %s", ErrAlreadyBootstrapped, g.branch, revision)
+	}
+
+	if err := g.writeAllVersions(vf); err != nil {
+		return "", err
+	}
+
+	if err := g.commit(ctx, message); err != nil {
+		return "", fmt.Errorf("failed to commit changes: %w", err)
 	}
 
+	if revision, err = g.headRevision(); err != nil {
+		return "", err
+	}
+
+	if err := g.push(ctx); err != nil {
+		return revision, err
+	}
+
+	g.logger.WithFields(logrus.Fields{
+		"revision": revision,
+		"branch":   g.branch,
+		"count":    len(vf.Versions),
+	}).Info("Versions branch bootstrapped")
+
+	return revision, nil
+}
+
+// acquire takes the storage lock, giving up with the context's error when
+// ctx ends first
+func (g *GitStorage) acquire(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	select {
+	case g.lock <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (g *GitStorage) release() {
+	<-g.lock
+}
+
+// sync pulls the latest changes before an operation. A failed pull is only
+// logged since the local copy remains usable, unless ctx ended: the caller
+// has given up, so the operation is abandoned before it touches the
+// worktree.
+func (g *GitStorage) sync(ctx context.Context) error {
+	if err := g.pull(ctx); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if errors.Is(err, breaker.ErrOpen) {
+			g.logger.WithError(err).Debug("Skipped pulling latest changes")
+			return nil
+		}
+		g.logger.WithError(err).Warn("Failed to pull latest changes")
+	}
+	return nil
+}
+
+// pull fetches and merges the versions branch. The fetch is bound to ctx;
+// updating the worktree afterwards is not, so it is never left half-updated.
+// Unpushed local commits are rebased onto the remote branch rather than
+// overwritten by it, and uncommitted leftovers of a failed write are
+// discarded. While the primary remote can't be reached, the branch follows
+// the mirror instead, if one is configured.
+func (g *GitStorage) pull(ctx context.Context) error {
 	w, err := g.repo.Worktree()
 	if err != nil {
 		return fmt.Errorf("failed to get worktree: %w", err)
 	}
 
-	err = w.Pull(&git.PullOptions{
-		Auth:          auth,
+	pullOpts := &git.PullOptions{
+		Auth:          g.auth,
 		RemoteName:    "origin",
 		ReferenceName: plumbing.NewBranchReferenceName(g.branch),
 		SingleBranch:  true,
-		Force:         false,
-	})
-
-	if err != nil && err != git.NoErrAlreadyUpToDate {
-		if err.Error() == "remote repository is empty" {
-			g.logger.Debug("Repository is empty, no changes to pull")
-			return nil
+	}
+	pullPrimary := func() error {
+		if err := g.Breaker.Allow(); err != nil {
+			return fmt.Errorf("%w: %w", ErrNetwork, err)
 		}
-		g.logger.WithError(err).Warn("Pull failed, attempting reset and pull")
+		err := w.PullContext(ctx, pullOpts)
+		g.recordRemote(err)
+		return err
+	}
+	err = pullPrimary()
 
+	if errors.Is(err, git.ErrUnstagedChanges) {
+		// The caller gave up; leave the worktree for the next pull
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		g.logger.Warn("Discarding uncommitted changes before pulling")
 		ref, err := g.repo.Head()
 		if err != nil {
 			return fmt.Errorf("failed to get HEAD: %w", err)
 		}
-
 		if err := w.Reset(&git.ResetOptions{
 			Commit: ref.Hash(),
 			Mode:   git.HardReset,
 		}); err != nil {
 			return fmt.Errorf("failed to reset: %w", err)
 		}
+		err = pullPrimary()
+	}
 
-		err = w.Pull(&git.PullOptions{
-			Auth:          auth,
-			RemoteName:    "origin",
-			ReferenceName: plumbing.NewBranchReferenceName(g.branch),
-			SingleBranch:  true,
-			Force:         true,
-		})
-
-		if err != nil && err != git.NoErrAlreadyUpToDate {
-			return fmt.Errorf("failed to pull: %w", err)
+	switch {
+	case err == nil, errors.Is(err, git.NoErrAlreadyUpToDate):
+		return nil
+	case isMissingBranch(err):
+		g.logger.Debug("Versions branch does not exist yet, no changes to pull")
+		return nil
+	case errors.Is(err, git.ErrNonFastForwardUpdate):
+		// Both sides have commits the other lacks
+		remote, err := g.fetchRemote(ctx)
+		if err != nil {
+			return err
+		}
+		if remote.IsZero() {
+			return nil
+		}
+		return g.rebase(ctx, remote)
+	case g.cloneOpts.MirrorURL != "" && ctx.Err() == nil:
+		if mirrorErr := g.pullMirror(ctx); mirrorErr != nil {
+			return fmt.Errorf("failed to pull: %w; mirror: %v", classifyGitError(err), mirrorErr)
 		}
+		g.logger.WithError(err).Debug("Primary remote unreachable, pulled from the read-only mirror")
+		return nil
+	default:
+		return fmt.Errorf("failed to pull: %w", classifyGitError(err))
 	}
-
-	return nil
 }
 
-func (g *GitStorage) push() error {
-	auth := &http.BasicAuth{
-		Username: g.username,
-		Password: g.token,
+// push pushes the versions branch. Every push follows a local commit, so a
+// failure is an ErrPushRejected.
+func (g *GitStorage) push(ctx context.Context) error {
+	if err := g.Breaker.Allow(); err != nil {
+		return fmt.Errorf("%w: %w: %w", ErrPushRejected, ErrNetwork, err)
 	}
 
-	err := g.repo.Push(&git.PushOptions{
-		Auth:       auth,
+	err := g.repo.PushContext(ctx, &git.PushOptions{
+		Auth:       g.auth,
 		RemoteName: "origin",
 		RefSpecs: []config.RefSpec{
 			config.RefSpec(fmt.Sprintf("refs/heads/%s:refs/heads/%s", g.branch, g.branch)),
 		},
 	})
+	g.recordRemote(err)
 
 	if err != nil && err != git.NoErrAlreadyUpToDate {
-		return fmt.Errorf("failed to push: %w", err)
+		return fmt.Errorf("%w: %w", ErrPushRejected, classifyGitError(err))
 	}
 
 	return nil
 }
 
-func (g *GitStorage) readVersionsFile() (*models.VersionsFile, error) {
-	filePath := filepath.Join(g.localDir, versionsFileName)
-
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return &models.VersionsFile{
-				Versions:    make(map[string]*models.AppVersion),
-				LastUpdated: time.Now(),
-			}, nil
-		}
-		return nil, fmt.Errorf("failed to read versions file: %w", err)
-	}
-
-	var vf models.VersionsFile
-	if err := json.Unmarshal(data, &vf); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal versions file: %w", err)
+// recordRemote reports the outcome of a call to the primary remote to the
+// breaker. Only failures to reach it count; a remote that answered, even
+// with a rejection, is up.
+func (g *GitStorage) recordRemote(err error) {
+	if err != nil && errors.Is(classifyGitError(err), ErrNetwork) {
+		g.Breaker.Failure()
+	} else {
+		g.Breaker.Success()
 	}
-
-	if vf.Versions == nil {
-		vf.Versions = make(map[string]*models.AppVersion)
-	}
-
-	return &vf, nil
 }
 
-func (g *GitStorage) writeVersionsFile(vf *models.VersionsFile) error {
-	vf.LastUpdated = time.Now()
-
-	data, err := json.MarshalIndent(vf, "", "  ")
+// readVersionsFile returns every app in the worktree as one versions file
+func (g *GitStorage) readVersionsFile() (*models.VersionsFile, error) {
+	versions, err := loadVersions(g.readWorktreeFile, nil)
 	if err != nil {
-		return fmt.Errorf("failed to marshal versions file: %w", err)
+		return nil, err
 	}
+	return asVersionsFile(versions), nil
+}
 
-	filePath := filepath.Join(g.localDir, versionsFileName)
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write versions file: %w", err)
+// prepareWrite pulls the latest changes and moves a legacy versions file to
+// per-app files before a write
+func (g *GitStorage) prepareWrite(ctx context.Context) error {
+	if err := g.sync(ctx); err != nil {
+		return err
 	}
+	return g.migrateLayout(ctx)
+}
 
-	return nil
+func (g *GitStorage) commit(ctx context.Context, message string) error {
+	return g.commitAt(ctx, message, time.Now())
 }
 
-func (g *GitStorage) commit(message string) error {
+// commitAt commits every change in the worktree with the author date set to
+// when.
+// Changes an admin made on behalf of someone else carry trailers naming both.
+func (g *GitStorage) commitAt(ctx context.Context, message string, when time.Time) error {
+	if attribution, ok := models.AttributionFrom(ctx); ok {
+		message += "\n\n" + attribution.Trailers()
+	}
+
 	w, err := g.repo.Worktree()
 	if err != nil {
 		return fmt.Errorf("failed to get worktree: %w", err)
 	}
 
-	if _, err := w.Add(versionsFileName); err != nil {
-		return fmt.Errorf("failed to add file: %w", err)
+	if err := w.AddWithOptions(&git.AddOptions{All: true}); err != nil {
+		return fmt.Errorf("failed to add files: %w", err)
 	}
 
 	commit, err := w.Commit(message, &git.CommitOptions{
 		Author: &object.Signature{
 			Name:  "Version Service",
 			Email: "version-service@company.com",
-			When:  time.Now(),
+			When:  when,
 		},
 	})
 
@@ -302,37 +586,37 @@ func (g *GitStorage) commit(message string) error {
 	return nil
 }
 
-func (g *GitStorage) commitAndPush(message string) error {
-	if err := g.commit(message); err != nil {
+func (g *GitStorage) commitAndPush(ctx context.Context, message string) error {
+	if err := g.commit(ctx, message); err != nil {
 		return err
 	}
 
-	if err := g.push(); err != nil {
+	if err := g.pushWithRebase(ctx); err != nil {
 		return fmt.Errorf("failed to push changes: %w", err)
 	}
 
 	return nil
 }
 
-func (g *GitStorage) hasUnpushedCommits() (bool, error) {
+func (g *GitStorage) hasUnpushedCommits(ctx context.Context) (bool, error) {
 	// Get local head
 	localRef, err := g.repo.Head()
 	if err != nil {
 		return false, fmt.Errorf("failed to get local HEAD: %w", err)
 	}
 
+	// While the breaker isn't closed, the push itself probes the remote
+	if g.Breaker.State() != breaker.Closed {
+		return true, nil
+	}
+
 	// Get remote head
 	remote, err := g.repo.Remote("origin")
 	if err != nil {
 		return false, fmt.Errorf("failed to get remote: %w", err)
 	}
 
-	auth := &http.BasicAuth{
-		Username: g.username,
-		Password: g.token,
-	}
-
-	refs, err := remote.List(&git.ListOptions{Auth: auth})
+	refs, err := remote.ListContext(ctx, &git.ListOptions{Auth: g.auth})
 	if err != nil {
 		// If we can't list remote refs, assume we have unpushed commits
 		g.logger.WithError(err).Debug("Failed to list remote refs, assuming unpushed commits exist")
@@ -353,21 +637,24 @@ func (g *GitStorage) hasUnpushedCommits() (bool, error) {
 }
 
 func (g *GitStorage) PushPendingCommits(ctx context.Context) error {
-	g.mu.Lock()
-	defer g.mu.Unlock()
+	if err := g.acquire(ctx); err != nil {
+		return err
+	}
+	defer g.release()
 
-	hasUnpushed, err := g.hasUnpushedCommits()
+	hasUnpushed, err := g.hasUnpushedCommits(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to check for unpushed commits: %w", err)
 	}
 
 	if !hasUnpushed {
 		g.logger.Debug("No unpushed commits found")
+		g.pushPendingTags(ctx)
 		return nil
 	}
 
 	g.logger.Info("Pushing pending commits to remote")
-	if err := g.push(); err != nil {
+	if err := g.pushWithRebase(ctx); err != nil {
 		return fmt.Errorf("failed to push pending commits: %w", err)
 	}
 
@@ -376,62 +663,47 @@ func (g *GitStorage) PushPendingCommits(ctx context.Context) error {
 }
 
 func (g *GitStorage) GetVersion(ctx context.Context, appID string) (*models.AppVersion, error) {
-	g.mu.Lock()
-	defer g.mu.Unlock()
-
-	if err := g.pull(); err != nil {
-		if err.Error() == "remote repository is empty" {
-			g.logger.Debug("Repository is empty, no changes to pull")
-		} else {
-			g.logger.WithError(err).Warn("Failed to pull latest changes")
-		}
+	if err := g.acquire(ctx); err != nil {
+		return nil, err
 	}
+	defer g.release()
 
-	vf, err := g.readVersionsFile()
-	if err != nil {
+	if err := g.sync(ctx); err != nil {
 		return nil, err
 	}
 
-	version, exists := vf.Versions[appID]
-	if !exists {
-		return nil, nil
+	versions, err := loadVersions(g.readWorktreeFile, []string{appID})
+	if err != nil {
+		return nil, err
 	}
 
-	return version, nil
+	return versions[appID], nil
 }
 
 func (g *GitStorage) SetVersion(ctx context.Context, appID string, version *models.AppVersion) error {
-	g.mu.Lock()
-	defer g.mu.Unlock()
-
-	if err := g.pull(); err != nil {
-		if err.Error() == "remote repository is empty" {
-			g.logger.Debug("Repository is empty, no changes to pull")
-		} else {
-			g.logger.WithError(err).Warn("Failed to pull latest changes")
-		}
+	if err := g.acquire(ctx); err != nil {
+		return err
 	}
+	defer g.release()
 
-	vf, err := g.readVersionsFile()
-	if err != nil {
+	if err := g.prepareWrite(ctx); err != nil {
 		return err
 	}
 
-	vf.Versions[appID] = version
-
-	if err := g.writeVersionsFile(vf); err != nil {
+	if err := g.writeVersions(map[string]*models.AppVersion{appID: version}); err != nil {
 		return err
 	}
 
 	commitMsg := fmt.Sprintf("%s: Update %s to %s", commitMessage, appID, version.Current)
 
 	// Commit locally first
-	if err := g.commit(commitMsg); err != nil {
+	if err := g.commit(ctx, commitMsg); err != nil {
 		return fmt.Errorf("failed to commit changes: %w", err)
 	}
+	g.tagVersions(map[string]*models.AppVersion{appID: version})
 
 	// Try to push, but don't fail the entire operation if push fails
-	if err := g.push(); err != nil {
+	if err := g.pushWithRebase(ctx); err != nil {
 		g.logger.WithError(err).WithFields(logrus.Fields{
 			"app_id":  appID,
 			"version": version.Current,
@@ -447,16 +719,50 @@ func (g *GitStorage) SetVersion(ctx context.Context, appID string, version *mode
 	return nil
 }
 
+// SetVersions writes several app versions in a single commit and push
+func (g *GitStorage) SetVersions(ctx context.Context, versions map[string]*models.AppVersion) error {
+	if err := g.acquire(ctx); err != nil {
+		return err
+	}
+	defer g.release()
+
+	if err := g.prepareWrite(ctx); err != nil {
+		return err
+	}
+
+	if err := g.writeVersions(versions); err != nil {
+		return err
+	}
+
+	var body strings.Builder
+	for _, appID := range sortedKeys(versions) {
+		fmt.Fprintf(&body, "\n- %s to %s", appID, versions[appID].Current)
+	}
+	commitMsg := fmt.Sprintf("%s: Update %d apps\n%s", commitMessage, len(versions), body.String())
+
+	if err := g.commit(ctx, commitMsg); err != nil {
+		return fmt.Errorf("failed to commit changes: %w", err)
+	}
+	g.tagVersions(versions)
+
+	if err := g.pushWithRebase(ctx); err != nil {
+		g.logger.WithError(err).WithField("count", len(versions)).Warn("Failed to push to remote, commit saved locally")
+		return fmt.Errorf("push failed: %w", err)
+	}
+
+	g.logger.WithField("count", len(versions)).Info("Versions persisted to Git in one commit")
+
+	return nil
+}
+
 func (g *GitStorage) ListVersions(ctx context.Context) (map[string]*models.AppVersion, error) {
-	g.mu.Lock()
-	defer g.mu.Unlock()
+	if err := g.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer g.release()
 
-	if err := g.pull(); err != nil {
-		if err.Error() == "remote repository is empty" {
-			g.logger.Debug("Repository is empty, no changes to pull")
-		} else {
-			g.logger.WithError(err).Warn("Failed to pull latest changes")
-		}
+	if err := g.sync(ctx); err != nil {
+		return nil, err
 	}
 
 	vf, err := g.readVersionsFile()
@@ -467,6 +773,24 @@ func (g *GitStorage) ListVersions(ctx context.Context) (map[string]*models.AppVe
 	return vf.Versions, nil
 }
 
+func (g *GitStorage) ListVersionsPage(ctx context.Context, cursor string, limit int, filter models.VersionFilter) (*models.VersionPage, error) {
+	if err := g.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer g.release()
+
+	if err := g.sync(ctx); err != nil {
+		return nil, err
+	}
+
+	vf, err := g.readVersionsFile()
+	if err != nil {
+		return nil, err
+	}
+
+	return pageVersions(vf.Versions, cursor, limit, filter)
+}
+
 func (g *GitStorage) ListVersionsByProject(ctx context.Context, projectID string) (map[string]*models.AppVersion, error) {
 	allVersions, err := g.ListVersions(ctx)
 	if err != nil {
@@ -475,7 +799,7 @@ func (g *GitStorage) ListVersionsByProject(ctx context.Context, projectID string
 
 	projectVersions := make(map[string]*models.AppVersion)
 	for appID, version := range allVersions {
-		if strings.HasPrefix(appID, projectID+"-") {
+		if models.InProject(appID, version, projectID) {
 			projectVersions[appID] = version
 		}
 	}
@@ -484,30 +808,21 @@ func (g *GitStorage) ListVersionsByProject(ctx context.Context, projectID string
 }
 
 func (g *GitStorage) DeleteVersion(ctx context.Context, appID string) error {
-	g.mu.Lock()
-	defer g.mu.Unlock()
-
-	if err := g.pull(); err != nil {
-		if err.Error() == "remote repository is empty" {
-			g.logger.Debug("Repository is empty, no changes to pull")
-		} else {
-			g.logger.WithError(err).Warn("Failed to pull latest changes")
-		}
+	if err := g.acquire(ctx); err != nil {
+		return err
 	}
+	defer g.release()
 
-	vf, err := g.readVersionsFile()
-	if err != nil {
+	if err := g.prepareWrite(ctx); err != nil {
 		return err
 	}
 
-	delete(vf.Versions, appID)
-
-	if err := g.writeVersionsFile(vf); err != nil {
+	if err := g.writeVersions(map[string]*models.AppVersion{appID: nil}); err != nil {
 		return err
 	}
 
 	commitMsg := fmt.Sprintf("%s: Remove %s", commitMessage, appID)
-	if err := g.commitAndPush(commitMsg); err != nil {
+	if err := g.commitAndPush(ctx, commitMsg); err != nil {
 		return err
 	}
 
@@ -515,11 +830,414 @@ func (g *GitStorage) DeleteVersion(ctx context.Context, appID string) error {
 	return nil
 }
 
+// RenameVersion moves an app's record to newAppID in a single commit and push
+func (g *GitStorage) RenameVersion(ctx context.Context, oldAppID, newAppID string, version *models.AppVersion) error {
+	if err := g.acquire(ctx); err != nil {
+		return err
+	}
+	defer g.release()
+
+	if err := g.prepareWrite(ctx); err != nil {
+		return err
+	}
+
+	if err := g.writeVersions(map[string]*models.AppVersion{oldAppID: nil, newAppID: version}); err != nil {
+		return err
+	}
+
+	commitMsg := fmt.Sprintf("%s: Rename %s to %s", commitMessage, oldAppID, newAppID)
+	if err := g.commit(ctx, commitMsg); err != nil {
+		return fmt.Errorf("failed to commit changes: %w", err)
+	}
+
+	fields := logrus.Fields{
+		"app_id":     oldAppID,
+		"new_app_id": newAppID,
+	}
+	if err := g.pushWithRebase(ctx); err != nil {
+		g.logger.WithError(err).WithFields(fields).Warn("Failed to push to remote, commit saved locally")
+		return fmt.Errorf("push failed: %w", err)
+	}
+
+	g.logger.WithFields(fields).Info("Version renamed in Git")
+	return nil
+}
+
+// Health reports whether the remote can be reached by listing its refs. It
+// doesn't take the lock, so probes don't wait behind reads and writes, and
+// a result is reused for gitHealthInterval. While only the mirror can be
+// reached, it returns ErrServingFromMirror.
 func (g *GitStorage) Health(ctx context.Context) error {
-	g.mu.Lock()
-	defer g.mu.Unlock()
+	return checkRemotes(ctx, &g.health, &g.mirrorHealth, g.repoURL, g.cloneOpts.MirrorURL, g.auth)
+}
+
+// appHistory walks the commits touching versions, in either layout, and
+// returns the distinct versions recorded for appID, newest first. Each record
+// points at the commit that introduced that version. Commits from before a
+// rename are read under the app's former IDs.
+func (g *GitStorage) appHistory(appID string) ([]versionRecord, error) {
+	lineage, err := g.appLineage(appID)
+	if err != nil {
+		return nil, err
+	}
+
+	iter, err := g.logVersions()
+	if err != nil {
+		if err == plumbing.ErrReferenceNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read commit log: %w", err)
+	}
+	defer iter.Close()
+
+	var records []versionRecord
+	err = iter.ForEach(func(c *object.Commit) error {
+		versions, err := loadVersions(commitReader(c), lineage)
+		if err != nil {
+			g.logger.WithError(err).WithField("commit", c.Hash.String()).Warn("Skipping unreadable versions in history")
+			return nil
+		}
+
+		var version *models.AppVersion
+		for _, id := range lineage {
+			if version = versions[id]; version != nil {
+				break
+			}
+		}
+		if version == nil {
+			return nil
+		}
+
+		record := versionRecord{
+			version: version,
+			commit:  c.Hash.String(),
+			when:    c.Author.When,
+		}
+
+		// Older commits recording the same version move the record back to
+		// the commit that first introduced it
+		if n := len(records); n > 0 && records[n-1].version.Current == version.Current {
+			records[n-1] = record
+			return nil
+		}
+
+		records = append(records, record)
+		return nil
+	})
+	if err != nil && !g.endOfHistory(err) {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// appLineage returns appID followed by the IDs it was renamed from, most
+// recent first
+func (g *GitStorage) appLineage(appID string) ([]string, error) {
+	versions, err := loadVersions(g.readWorktreeFile, []string{appID})
+	if err != nil {
+		return nil, err
+	}
+
+	lineage := []string{appID}
+	if current := versions[appID]; current != nil {
+		for i := len(current.RenamedFrom) - 1; i >= 0; i-- {
+			lineage = append(lineage, current.RenamedFrom[i])
+		}
+	}
+	return lineage, nil
+}
+
+// GetPreviousVersion returns the most recent version recorded in Git history
+// for appID that sorts below current, along with the commit that recorded it.
+// Versions above current are skipped so repeated rollbacks keep moving back
+// instead of bouncing to the version that was just rolled back. It returns
+// nil when no earlier version exists.
+func (g *GitStorage) GetPreviousVersion(ctx context.Context, appID, current string) (*models.AppVersion, string, error) {
+	if err := g.acquire(ctx); err != nil {
+		return nil, "", err
+	}
+	defer g.release()
+
+	if err := g.sync(ctx); err != nil {
+		return nil, "", err
+	}
+
+	records, err := g.appHistory(appID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for _, record := range records {
+		cmp, err := semver.Compare(record.version.Current, current)
+		if err != nil {
+			// Unparseable versions can't be ordered; fall back to any change
+			if record.version.Current != current {
+				return record.version, record.commit, nil
+			}
+			continue
+		}
+		if cmp < 0 {
+			return record.version, record.commit, nil
+		}
+	}
+
+	return nil, "", nil
+}
+
+// GetVersionHistory returns the versions recorded for appID in Git history,
+// oldest first
+func (g *GitStorage) GetVersionHistory(ctx context.Context, appID string) ([]models.VersionHistoryEntry, error) {
+	if err := g.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer g.release()
+
+	if err := g.sync(ctx); err != nil {
+		return nil, err
+	}
+
+	records, err := g.appHistory(appID)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]models.VersionHistoryEntry, 0, len(records))
+	for i := len(records) - 1; i >= 0; i-- {
+		history = append(history, models.VersionHistoryEntry{
+			Version:   records[i].version.Current,
+			Commit:    records[i].commit,
+			Timestamp: records[i].when,
+		})
+	}
+
+	return history, nil
+}
 
-	return g.pull()
+// ExportHistory walks the commits touching versions once and returns the
+// distinct versions of every app, oldest first. Unlike
+// GetVersionHistory, entries stay under the ID they were recorded with, so
+// the history of a renamed app is split between its former and current IDs
+// just as it is in the repository.
+func (g *GitStorage) ExportHistory(ctx context.Context) (map[string][]models.VersionHistoryEntry, error) {
+	if err := g.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer g.release()
+
+	if err := g.sync(ctx); err != nil {
+		return nil, err
+	}
+
+	history := make(map[string][]models.VersionHistoryEntry)
+
+	iter, err := g.logVersions()
+	if err != nil {
+		if err == plumbing.ErrReferenceNotFound {
+			return history, nil
+		}
+		return nil, fmt.Errorf("failed to read commit log: %w", err)
+	}
+	defer iter.Close()
+
+	// Commits arrive newest first, so entries are collected newest first
+	// and reversed at the end
+	err = iter.ForEach(func(c *object.Commit) error {
+		versions, err := loadVersions(commitReader(c), nil)
+		if err != nil {
+			g.logger.WithError(err).WithField("commit", c.Hash.String()).Warn("Skipping unreadable versions in history")
+			return nil
+		}
+
+		for appID, version := range versions {
+			if version == nil {
+				continue
+			}
+
+			entry := models.VersionHistoryEntry{
+				Version:   version.Current,
+				Commit:    c.Hash.String(),
+				Timestamp: c.Author.When,
+			}
+
+			// As in appHistory, older commits recording the same version
+			// move the entry back to the commit that introduced it
+			entries := history[appID]
+			if n := len(entries); n > 0 && entries[n-1].Version == version.Current {
+				entries[n-1] = entry
+				continue
+			}
+			history[appID] = append(entries, entry)
+		}
+		return nil
+	})
+	if err != nil && !g.endOfHistory(err) {
+		return nil, err
+	}
+
+	for _, entries := range history {
+		for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+			entries[i], entries[j] = entries[j], entries[i]
+		}
+	}
+
+	return history, nil
+}
+
+// ReplayHistory rebuilds the history of an empty versions branch: every step
+// becomes a commit dated at its timestamp, holding the versions reached so
+// far, and final is committed on top. Replayed commits only carry each app's
+// version; the full records arrive with final. Everything is pushed at once;
+// on a failed push the new revision is returned along with the error.
+func (g *GitStorage) ReplayHistory(ctx context.Context, steps []models.HistoryStep, final *models.VersionsFile, message string) (string, error) {
+	if err := g.acquire(ctx); err != nil {
+		return "", err
+	}
+	defer g.release()
+
+	if err := g.sync(ctx); err != nil {
+		return "", err
+	}
+
+	revision, err := g.headRevision()
+	if err != nil {
+		return "", err
+	}
+	if revision != "" {
+		return "", fmt.Errorf("%w: %s has commits at %s", ErrAlreadyBootstrapped, g.branch, revision)
+	}
+
+	replayed := &models.VersionsFile{Versions: make(map[string]*models.AppVersion)}
+	for i, step := range steps {
+		for appID, version := range step.Versions {
+			replayed.Versions[appID] = &models.AppVersion{Current: version, LastUpdated: step.Timestamp}
+		}
+
+		if err := g.writeAllVersions(replayed); err != nil {
+			return "", err
+		}
+		stepMessage := fmt.Sprintf("%s (history %d/%d)", message, i+1, len(steps))
+		if err := g.commitAt(ctx, stepMessage, step.Timestamp); err != nil {
+			return "", fmt.Errorf("failed to commit history: %w", err)
+		}
+	}
+
+	if err := g.writeAllVersions(final); err != nil {
+		return "", err
+	}
+	if err := g.commit(ctx, message); err != nil {
+		return "", fmt.Errorf("failed to commit changes: %w", err)
+	}
+
+	if revision, err = g.headRevision(); err != nil {
+		return "", err
+	}
+
+	if err := g.push(ctx); err != nil {
+		return revision, fmt.Errorf("push failed: %w", err)
+	}
+
+	g.logger.WithFields(logrus.Fields{
+		"revision": revision,
+		"branch":   g.branch,
+		"steps":    len(steps),
+		"count":    len(final.Versions),
+	}).Info("Versions history replayed")
+
+	return revision, nil
+}
+
+// headRevision returns the commit hash of HEAD, or an empty string for a
+// repository without commits
+func (g *GitStorage) headRevision() (string, error) {
+	ref, err := g.repo.Head()
+	if err != nil {
+		if err == plumbing.ErrReferenceNotFound {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	return ref.Hash().String(), nil
+}
+
+// ReadVersionsFile returns every app serialized as one versions file, the
+// format of earlier releases, along with the commit it was read at
+func (g *GitStorage) ReadVersionsFile(ctx context.Context) ([]byte, string, error) {
+	if err := g.acquire(ctx); err != nil {
+		return nil, "", err
+	}
+	defer g.release()
+
+	if err := g.sync(ctx); err != nil {
+		return nil, "", err
+	}
+
+	revision, err := g.headRevision()
+	if err != nil {
+		return nil, "", err
+	}
+
+	vf, err := g.readVersionsFile()
+	if err != nil {
+		return nil, "", err
+	}
+
+	data, err := json.MarshalIndent(vf, "", "  ")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to marshal versions file: %w", err)
+	}
+
+	return data, revision, nil
+}
+
+// ReplaceVersionsFile replaces every app with those of vf in a single commit. When
+// expectedRevision is set, the write only happens if HEAD still matches it.
+// The new revision is returned even if the push fails, in which case the
+// error wraps the push failure.
+func (g *GitStorage) ReplaceVersionsFile(ctx context.Context, vf *models.VersionsFile, expectedRevision, message string) (string, error) {
+	if err := g.acquire(ctx); err != nil {
+		return "", err
+	}
+	defer g.release()
+
+	if err := g.sync(ctx); err != nil {
+		return "", err
+	}
+
+	if expectedRevision != "" {
+		revision, err := g.headRevision()
+		if err != nil {
+			return "", err
+		}
+		if revision != expectedRevision {
+			return "", fmt.Errorf("%w: expected %s, found %s", ErrRevisionMismatch, expectedRevision, revision)
+		}
+	}
+
+	if err := g.writeAllVersions(vf); err != nil {
+		return "", err
+	}
+
+	if err := g.commit(ctx, message); err != nil {
+		return "", fmt.Errorf("failed to commit changes: %w", err)
+	}
+
+	revision, err := g.headRevision()
+	if err != nil {
+		return "", err
+	}
+
+	if err := g.push(ctx); err != nil {
+		g.logger.WithError(err).Warn("Failed to push to remote, commit saved locally")
+		return revision, fmt.Errorf("push failed: %w", err)
+	}
+
+	g.logger.WithFields(logrus.Fields{
+		"revision": revision,
+		"count":    len(vf.Versions),
+	}).Info("Versions file replaced")
+
+	return revision, nil
 }
 
 func (g *GitStorage) RebuildCache(ctx context.Context, versions map[string]*models.AppVersion) error {
@@ -528,8 +1246,19 @@ func (g *GitStorage) RebuildCache(ctx context.Context, versions map[string]*mode
 }
 
 func (g *GitStorage) Close() error {
+	select {
+	case <-g.done:
+	default:
+		close(g.done)
+	}
+
+	if err := g.acquire(context.Background()); err != nil {
+		return err
+	}
+	defer g.release()
+
 	if g.localDir != "" {
-		return os.RemoveAll(g.localDir)
+		return g.removeClone(g.localDir)
 	}
 	return nil
 }
\ No newline at end of file
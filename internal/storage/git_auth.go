@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// SSHAuthOptions configures SSH key authentication for GitStorage
+type SSHAuthOptions struct {
+	// User is the SSH user, "git" when empty
+	User string
+	// Key is a PEM encoded private key; it takes precedence over KeyPath
+	Key []byte
+	// KeyPath is the file holding the private key
+	KeyPath string
+	// Passphrase decrypts an encrypted key
+	Passphrase string
+	// KnownHostsPath is the known_hosts file host keys are checked against.
+	// When empty, $SSH_KNOWN_HOSTS or ~/.ssh/known_hosts and
+	// /etc/ssh/ssh_known_hosts are used.
+	KnownHostsPath string
+	// InsecureIgnoreHostKey accepts any host key. Only meant for testing.
+	InsecureIgnoreHostKey bool
+}
+
+// NewTokenAuth authenticates HTTPS remotes with a username and access token
+func NewTokenAuth(username, token string) transport.AuthMethod {
+	return &http.BasicAuth{
+		Username: username,
+		Password: token,
+	}
+}
+
+// NewSSHAuth authenticates SSH remotes with a private key. The key and the
+// known hosts are loaded right away so a bad setup fails at startup rather
+// than on the first push.
+func NewSSHAuth(opts SSHAuthOptions) (transport.AuthMethod, error) {
+	user := opts.User
+	if user == "" {
+		user = ssh.DefaultUsername
+	}
+
+	var auth *ssh.PublicKeys
+	var err error
+	switch {
+	case len(opts.Key) > 0:
+		auth, err = ssh.NewPublicKeys(user, opts.Key, opts.Passphrase)
+	case opts.KeyPath != "":
+		auth, err = ssh.NewPublicKeysFromFile(user, opts.KeyPath, opts.Passphrase)
+	default:
+		return nil, fmt.Errorf("no SSH private key configured")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load SSH private key: %w", err)
+	}
+
+	if opts.InsecureIgnoreHostKey {
+		auth.HostKeyCallback = gossh.InsecureIgnoreHostKey()
+		return auth, nil
+	}
+
+	var files []string
+	if opts.KnownHostsPath != "" {
+		files = append(files, opts.KnownHostsPath)
+	}
+	callback, err := ssh.NewKnownHostsCallback(files...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load SSH known hosts: %w", err)
+	}
+	auth.HostKeyCallback = callback
+	return auth, nil
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+func newTestSSHKey(t *testing.T, passphrase string) []byte {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	var block *pem.Block
+	if passphrase == "" {
+		block, err = gossh.MarshalPrivateKey(key, "")
+	} else {
+		block, err = gossh.MarshalPrivateKeyWithPassphrase(key, "", []byte(passphrase))
+	}
+	require.NoError(t, err)
+	return pem.EncodeToMemory(block)
+}
+
+func TestNewSSHAuth(t *testing.T) {
+	dir := t.TempDir()
+	knownHosts := filepath.Join(dir, "known_hosts")
+	require.NoError(t, os.WriteFile(knownHosts, nil, 0600))
+
+	t.Run("key from file with known hosts", func(t *testing.T) {
+		keyPath := filepath.Join(dir, "id_ed25519")
+		require.NoError(t, os.WriteFile(keyPath, newTestSSHKey(t, ""), 0600))
+
+		auth, err := NewSSHAuth(SSHAuthOptions{KeyPath: keyPath, KnownHostsPath: knownHosts})
+		require.NoError(t, err)
+		keys := auth.(*ssh.PublicKeys)
+		assert.Equal(t, "git", keys.User)
+		assert.NotNil(t, keys.HostKeyCallback)
+	})
+
+	t.Run("encrypted key", func(t *testing.T) {
+		key := newTestSSHKey(t, "secret")
+
+		_, err := NewSSHAuth(SSHAuthOptions{Key: key, Passphrase: "wrong", KnownHostsPath: knownHosts})
+		assert.Error(t, err)
+
+		auth, err := NewSSHAuth(SSHAuthOptions{User: "deploy", Key: key, Passphrase: "secret", KnownHostsPath: knownHosts})
+		require.NoError(t, err)
+		assert.Equal(t, "deploy", auth.(*ssh.PublicKeys).User)
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		_, err := NewSSHAuth(SSHAuthOptions{KnownHostsPath: knownHosts})
+		assert.Error(t, err)
+	})
+
+	t.Run("missing known hosts", func(t *testing.T) {
+		_, err := NewSSHAuth(SSHAuthOptions{Key: newTestSSHKey(t, ""), KnownHostsPath: filepath.Join(dir, "absent")})
+		assert.Error(t, err)
+	})
+}
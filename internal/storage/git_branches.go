@@ -0,0 +1,392 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/pkg/breaker"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/sirupsen/logrus"
+)
+
+// ProjectBranchStorage keeps each project's versions on a branch of its
+// own, {prefix}{project-id}, so access to a project's history can be
+// granted branch by branch. Each branch is cloned into a GitStorage of its
+// own the first time one of its apps is used. Apps are placed by the project
+// in their app ID, as models.ParseAppID reads it; apps without one share the
+// "_" branch. Whole-file operations, history transfer and watches are
+// unavailable.
+type ProjectBranchStorage struct {
+	repoURL   string
+	prefix    string
+	auth      transport.AuthMethod
+	cloneOpts GitCloneOptions
+	logger    *logrus.Logger
+	// TagIncrements is passed on to the GitStorage of every branch
+	TagIncrements bool
+	// Breaker is shared by the GitStorage of every branch, as they all
+	// reach the same remote
+	Breaker *breaker.Breaker
+
+	mu           sync.Mutex
+	branches     map[string]*projectBranch
+	health       remoteHealth
+	mirrorHealth remoteHealth
+}
+
+// projectBranch is the clone of one project's branch; ready is closed once
+// the clone has finished or failed
+type projectBranch struct {
+	ready   chan struct{}
+	storage *GitStorage
+	err     error
+}
+
+// NewProjectBranchStorage returns a storage keeping the versions of each
+// project on the branch prefix+project ID of repoURL. Nothing is cloned until
+// a project is used.
+func NewProjectBranchStorage(repoURL, prefix string, auth transport.AuthMethod, cloneOpts GitCloneOptions, logger *logrus.Logger) *ProjectBranchStorage {
+	return &ProjectBranchStorage{
+		repoURL:   repoURL,
+		prefix:    prefix,
+		auth:      auth,
+		cloneOpts: cloneOpts,
+		logger:    logger,
+		branches:  make(map[string]*projectBranch),
+	}
+}
+
+// projectOf returns the project whose branch holds appID
+func projectOf(appID string) string {
+	projectID, _, err := models.ParseAppID(appID)
+	if err != nil {
+		return unassignedProject
+	}
+	return projectID
+}
+
+// branchName returns the branch holding the versions of projectID
+func (p *ProjectBranchStorage) branchName(projectID string) (string, error) {
+	branch := p.prefix + pathSegment(projectID)
+	if err := plumbing.NewBranchReferenceName(branch).Validate(); err != nil {
+		return "", fmt.Errorf("project %q has no valid branch name: %w", projectID, err)
+	}
+	return branch, nil
+}
+
+// project returns the storage of projectID's branch, cloning it on first use.
+// A clone that fails is attempted again by the next caller.
+func (p *ProjectBranchStorage) project(ctx context.Context, projectID string) (*GitStorage, error) {
+	p.mu.Lock()
+	branch, ok := p.branches[projectID]
+	if !ok {
+		branch = &projectBranch{ready: make(chan struct{})}
+		p.branches[projectID] = branch
+	}
+	p.mu.Unlock()
+
+	if !ok {
+		branch.storage, branch.err = p.open(projectID)
+		if branch.err != nil {
+			p.mu.Lock()
+			delete(p.branches, projectID)
+			p.mu.Unlock()
+		}
+		close(branch.ready)
+	}
+
+	select {
+	case <-branch.ready:
+		return branch.storage, branch.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// open clones projectID's branch; a branch that doesn't exist yet is created
+// by the first write
+func (p *ProjectBranchStorage) open(projectID string) (*GitStorage, error) {
+	branch, err := p.branchName(projectID)
+	if err != nil {
+		return nil, err
+	}
+	g, err := NewGitStorage(p.repoURL, branch, p.auth, p.cloneOpts, p.logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone branch %s: %w", branch, err)
+	}
+	g.TagIncrements = p.TagIncrements
+	g.Breaker = p.Breaker
+
+	p.logger.WithFields(logrus.Fields{
+		"project_id": projectID,
+		"branch":     branch,
+	}).Info("Project branch checked out")
+	return g, nil
+}
+
+// opened returns the storages of the branches cloned so far
+func (p *ProjectBranchStorage) opened() map[string]*GitStorage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	storages := make(map[string]*GitStorage, len(p.branches))
+	for projectID, branch := range p.branches {
+		select {
+		case <-branch.ready:
+			if branch.err == nil {
+				storages[projectID] = branch.storage
+			}
+		default:
+		}
+	}
+	return storages
+}
+
+// remoteProjects returns the projects with a branch on the remote
+func (p *ProjectBranchStorage) remoteProjects(ctx context.Context) ([]string, error) {
+	refs, err := listRemote(ctx, p.repoURL, p.auth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list remote branches: %w", err)
+	}
+
+	prefix := plumbing.NewBranchReferenceName(p.prefix).String()
+	var projects []string
+	for _, ref := range refs {
+		name := ref.Name().String()
+		if !ref.Name().IsBranch() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		projectID, err := url.PathUnescape(strings.TrimPrefix(name, prefix))
+		if err != nil || projectID == "" {
+			continue
+		}
+		projects = append(projects, projectID)
+	}
+	return projects, nil
+}
+
+// projects returns every project on the remote or cloned, sorted
+func (p *ProjectBranchStorage) projects(ctx context.Context) ([]string, error) {
+	remote, err := p.remoteProjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	for _, projectID := range remote {
+		seen[projectID] = true
+	}
+	for projectID := range p.opened() {
+		seen[projectID] = true
+	}
+	return sortedKeys(seen), nil
+}
+
+// byProject splits versions by the project whose branch holds them
+func byProject(versions map[string]*models.AppVersion) map[string]map[string]*models.AppVersion {
+	groups := make(map[string]map[string]*models.AppVersion)
+	for appID, version := range versions {
+		projectID := projectOf(appID)
+		if groups[projectID] == nil {
+			groups[projectID] = make(map[string]*models.AppVersion)
+		}
+		groups[projectID][appID] = version
+	}
+	return groups
+}
+
+func (p *ProjectBranchStorage) GetVersion(ctx context.Context, appID string) (*models.AppVersion, error) {
+	g, err := p.project(ctx, projectOf(appID))
+	if err != nil {
+		return nil, err
+	}
+	return g.GetVersion(ctx, appID)
+}
+
+func (p *ProjectBranchStorage) SetVersion(ctx context.Context, appID string, version *models.AppVersion) error {
+	g, err := p.project(ctx, projectOf(appID))
+	if err != nil {
+		return err
+	}
+	return g.SetVersion(ctx, appID, version)
+}
+
+// SetVersions writes the versions of each project in one commit to its
+// branch, project by project. A failure leaves the projects before it
+// written.
+func (p *ProjectBranchStorage) SetVersions(ctx context.Context, versions map[string]*models.AppVersion) error {
+	groups := byProject(versions)
+	for _, projectID := range sortedKeys(groups) {
+		g, err := p.project(ctx, projectID)
+		if err != nil {
+			return err
+		}
+		if err := g.SetVersions(ctx, groups[projectID]); err != nil {
+			return fmt.Errorf("project %s: %w", projectID, err)
+		}
+	}
+	return nil
+}
+
+// ListVersions reads every project's branch, cloning those not used yet
+func (p *ProjectBranchStorage) ListVersions(ctx context.Context) (map[string]*models.AppVersion, error) {
+	projects, err := p.projects(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(map[string]*models.AppVersion)
+	for _, projectID := range projects {
+		g, err := p.project(ctx, projectID)
+		if err != nil {
+			return nil, err
+		}
+		projectVersions, err := g.ListVersions(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("project %s: %w", projectID, err)
+		}
+		for appID, version := range projectVersions {
+			versions[appID] = version
+		}
+	}
+	return versions, nil
+}
+
+// ListVersionsByProject reads only projectID's branch
+func (p *ProjectBranchStorage) ListVersionsByProject(ctx context.Context, projectID string) (map[string]*models.AppVersion, error) {
+	if _, ok := p.opened()[projectID]; !ok {
+		remote, err := p.remoteProjects(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(remote, projectID) {
+			return map[string]*models.AppVersion{}, nil
+		}
+	}
+
+	g, err := p.project(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return g.ListVersionsByProject(ctx, projectID)
+}
+
+func (p *ProjectBranchStorage) ListVersionsPage(ctx context.Context, cursor string, limit int, filter models.VersionFilter) (*models.VersionPage, error) {
+	versions, err := p.ListVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pageVersions(versions, cursor, limit, filter)
+}
+
+func (p *ProjectBranchStorage) DeleteVersion(ctx context.Context, appID string) error {
+	g, err := p.project(ctx, projectOf(appID))
+	if err != nil {
+		return err
+	}
+	return g.DeleteVersion(ctx, appID)
+}
+
+// RenameVersion moves a record within its project's branch in one commit.
+// Moving it to another project writes the new branch first, then removes
+// it from the old one.
+func (p *ProjectBranchStorage) RenameVersion(ctx context.Context, oldAppID, newAppID string, version *models.AppVersion) error {
+	from, err := p.project(ctx, projectOf(oldAppID))
+	if err != nil {
+		return err
+	}
+	to, err := p.project(ctx, projectOf(newAppID))
+	if err != nil {
+		return err
+	}
+	if from == to {
+		return from.RenameVersion(ctx, oldAppID, newAppID, version)
+	}
+
+	if err := to.SetVersion(ctx, newAppID, version); err != nil {
+		return err
+	}
+	return from.DeleteVersion(ctx, oldAppID)
+}
+
+// Health reports whether the remote can be reached, reusing a result for
+// gitHealthInterval. While only the mirror can be reached, it returns
+// ErrServingFromMirror.
+func (p *ProjectBranchStorage) Health(ctx context.Context) error {
+	return checkRemotes(ctx, &p.health, &p.mirrorHealth, p.repoURL, p.cloneOpts.MirrorURL, p.auth)
+}
+
+func (p *ProjectBranchStorage) RebuildCache(ctx context.Context, versions map[string]*models.AppVersion) error {
+	// Git storage doesn't use cache, so this is a no-op
+	return nil
+}
+
+// PushPendingCommits pushes the pending commits of every cloned branch
+func (p *ProjectBranchStorage) PushPendingCommits(ctx context.Context) error {
+	opened := p.opened()
+	var errs []error
+	for _, projectID := range sortedKeys(opened) {
+		if err := opened[projectID].PushPendingCommits(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("project %s: %w", projectID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// IsEmpty reports whether no project has a branch yet
+func (p *ProjectBranchStorage) IsEmpty() bool {
+	projects, err := p.projects(context.Background())
+	return err == nil && len(projects) == 0
+}
+
+// Bootstrap creates the branch of every project in vf, one commit each, and
+// returns the revision of the last branch written
+func (p *ProjectBranchStorage) Bootstrap(ctx context.Context, vf *models.VersionsFile, message string) (string, error) {
+	var revision string
+	groups := byProject(vf.Versions)
+	for _, projectID := range sortedKeys(groups) {
+		g, err := p.project(ctx, projectID)
+		if err != nil {
+			return revision, err
+		}
+		rev, err := g.Bootstrap(ctx, &models.VersionsFile{Versions: groups[projectID]}, message)
+		if rev != "" {
+			revision = rev
+		}
+		if err != nil {
+			return revision, fmt.Errorf("project %s: %w", projectID, err)
+		}
+	}
+	return revision, nil
+}
+
+func (p *ProjectBranchStorage) GetVersionHistory(ctx context.Context, appID string) ([]models.VersionHistoryEntry, error) {
+	g, err := p.project(ctx, projectOf(appID))
+	if err != nil {
+		return nil, err
+	}
+	return g.GetVersionHistory(ctx, appID)
+}
+
+func (p *ProjectBranchStorage) GetPreviousVersion(ctx context.Context, appID, current string) (*models.AppVersion, string, error) {
+	g, err := p.project(ctx, projectOf(appID))
+	if err != nil {
+		return nil, "", err
+	}
+	return g.GetPreviousVersion(ctx, appID, current)
+}
+
+// Close removes the clone of every branch
+func (p *ProjectBranchStorage) Close() error {
+	var errs []error
+	for _, g := range p.opened() {
+		errs = append(errs, g.Close())
+	}
+	return errors.Join(errs...)
+}
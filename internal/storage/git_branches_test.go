@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestProjectBranchStorage(t *testing.T, remote string) *ProjectBranchStorage {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	p := NewProjectBranchStorage(remote, "project/", nil, GitCloneOptions{}, logger)
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+func TestProjectBranchStorage(t *testing.T) {
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	_, err := git.PlainInit(remote, true)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	p := newTestProjectBranchStorage(t, remote)
+	assert.True(t, p.IsEmpty())
+
+	require.NoError(t, p.SetVersion(ctx, "1-api", &models.AppVersion{Current: "1.0.0", ProjectID: "1", AppName: "api"}))
+	require.NoError(t, p.SetVersions(ctx, map[string]*models.AppVersion{
+		"1-api": {Current: "1.1.0", ProjectID: "1", AppName: "api"},
+		"2-web": {Current: "2.0.0", ProjectID: "2", AppName: "web"},
+	}))
+	assert.False(t, p.IsEmpty())
+
+	remoteRepo, err := git.PlainOpen(remote)
+	require.NoError(t, err)
+	for _, branch := range []string{"refs/heads/project/1", "refs/heads/project/2"} {
+		_, err := remoteRepo.Reference(plumbing.ReferenceName(branch), true)
+		assert.NoError(t, err, branch)
+	}
+
+	// Moving an app to another project moves it to that project's branch
+	require.NoError(t, p.RenameVersion(ctx, "2-web", "1-web", &models.AppVersion{Current: "2.0.0", ProjectID: "1", AppName: "web", RenamedFrom: []string{"2-web"}}))
+
+	fresh := newTestProjectBranchStorage(t, remote)
+	projectVersions, err := fresh.ListVersionsByProject(ctx, "1")
+	require.NoError(t, err)
+	assert.Len(t, projectVersions, 2)
+	assert.Len(t, fresh.opened(), 1, "only the listed project is checked out")
+
+	projectVersions, err = fresh.ListVersionsByProject(ctx, "3")
+	require.NoError(t, err)
+	assert.Empty(t, projectVersions)
+	assert.Len(t, fresh.opened(), 1)
+
+	versions, err := fresh.ListVersions(ctx)
+	require.NoError(t, err)
+	assert.Len(t, versions, 2)
+	assert.Equal(t, "1.1.0", versions["1-api"].Current)
+	assert.Equal(t, "2.0.0", versions["1-web"].Current)
+
+	history, err := fresh.GetVersionHistory(ctx, "1-api")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, "1.0.0", history[0].Version)
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Errors returned by GitStorage, alone or together, so callers can tell
+// failures apart with errors.Is rather than by their message
+var (
+	// ErrPushRejected is returned when a write was committed locally but
+	// couldn't be pushed; the commit is kept and pushed later. It is joined
+	// with ErrNetwork or ErrAuthFailed when the cause is known.
+	ErrPushRejected = errors.New("push rejected, commit saved locally")
+	// ErrAuthFailed is returned when the remote refused the credentials or
+	// the host key didn't match; retrying won't help
+	ErrAuthFailed = errors.New("Git authentication failed")
+	// ErrNetwork is returned when the remote couldn't be reached or failed
+	// on its side; retrying may help
+	ErrNetwork = errors.New("Git remote unreachable")
+	// ErrEmptyRepo is returned when the remote has no commits on the
+	// versions branch
+	ErrEmptyRepo = errors.New("Git remote has no versions branch")
+)
+
+// classifyGitError wraps an error of a Git remote operation in the error
+// above matching its cause. Errors of unknown cause are returned as they are.
+func classifyGitError(err error) error {
+	switch {
+	case err == nil,
+		errors.Is(err, ErrAuthFailed),
+		errors.Is(err, ErrNetwork),
+		errors.Is(err, ErrEmptyRepo):
+		return err
+	case isAuthError(err):
+		return fmt.Errorf("%w: %w", ErrAuthFailed, err)
+	case isMissingBranch(err):
+		return fmt.Errorf("%w: %w", ErrEmptyRepo, err)
+	case isNetworkError(err):
+		return fmt.Errorf("%w: %w", ErrNetwork, err)
+	}
+	return err
+}
+
+// isAuthError reports whether the remote refused the credentials, or the
+// SSH host key couldn't be verified
+func isAuthError(err error) bool {
+	var keyErr *knownhosts.KeyError
+	var revokedErr *knownhosts.RevokedError
+	return errors.Is(err, transport.ErrAuthenticationRequired) ||
+		errors.Is(err, transport.ErrAuthorizationFailed) ||
+		errors.Is(err, transport.ErrInvalidAuthMethod) ||
+		errors.As(err, &keyErr) ||
+		errors.As(err, &revokedErr)
+}
+
+// isNetworkError reports whether err came from the connection to the remote
+// or from a server error on its side, such as a maintenance window's 503
+func isNetworkError(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	// go-git reports unexpected HTTP statuses without unwrapping them
+	var unexpected *plumbing.UnexpectedError
+	var httpErr *githttp.Err
+	if errors.As(err, &unexpected) && errors.As(unexpected.Err, &httpErr) {
+		status := httpErr.StatusCode()
+		return status >= http.StatusInternalServerError || status == http.StatusTooManyRequests
+	}
+	return false
+}
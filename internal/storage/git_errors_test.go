@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyGitError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"authentication", transport.ErrAuthenticationRequired, ErrAuthFailed},
+		{"authorization", fmt.Errorf("failed: %w", transport.ErrAuthorizationFailed), ErrAuthFailed},
+		{"empty remote", transport.ErrEmptyRemoteRepository, ErrEmptyRepo},
+		{"connection refused", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, ErrNetwork},
+		{"deadline", context.DeadlineExceeded, ErrNetwork},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, classifyGitError(tt.err), tt.want)
+		})
+	}
+
+	unknown := errors.New("object not found")
+	assert.Equal(t, unknown, classifyGitError(unknown))
+}
+
+func TestClassifyGitError_HTTPStatus(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	// A maintenance window's 503 may pass
+	_, err := listRemote(context.Background(), server.URL+"/versions.git", nil)
+	assert.ErrorIs(t, classifyGitError(err), ErrNetwork)
+
+	// A 401 won't
+	status = http.StatusUnauthorized
+	_, err = listRemote(context.Background(), server.URL+"/versions.git", nil)
+	err = classifyGitError(err)
+	assert.ErrorIs(t, err, ErrAuthFailed)
+	assert.NotErrorIs(t, err, ErrNetwork)
+}
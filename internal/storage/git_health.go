@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+const (
+	// gitHealthTimeout bounds a single listing of the remote's refs
+	gitHealthTimeout = 5 * time.Second
+	// gitHealthInterval is how long a health result is reused, so frequent
+	// probes reach the remote at most this often
+	gitHealthInterval = 10 * time.Second
+)
+
+// listRemote lists the refs of repoURL without a local repository, so it
+// needs neither the storage lock nor a clone. An empty remote has no refs.
+func listRemote(ctx context.Context, repoURL string, auth transport.AuthMethod) ([]*plumbing.Reference, error) {
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{repoURL},
+	})
+	refs, err := remote.ListContext(ctx, &git.ListOptions{Auth: auth})
+	if err != nil {
+		if errors.Is(err, transport.ErrEmptyRemoteRepository) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return refs, nil
+}
+
+// remoteHealth caches whether a remote could be reached. Probes arriving
+// while a check runs wait for its result rather than starting their own.
+type remoteHealth struct {
+	mu        sync.Mutex
+	checkedAt time.Time
+	err       error
+}
+
+// check returns the last result if it is younger than gitHealthInterval,
+// otherwise lists the refs of repoURL again
+func (h *remoteHealth) check(ctx context.Context, repoURL string, auth transport.AuthMethod) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.checkedAt.IsZero() && time.Since(h.checkedAt) < gitHealthInterval {
+		return h.err
+	}
+
+	listCtx, cancel := context.WithTimeout(ctx, gitHealthTimeout)
+	defer cancel()
+	_, err := listRemote(listCtx, repoURL, auth)
+	if err != nil && ctx.Err() != nil {
+		// The caller gave up; that says nothing about the remote
+		return ctx.Err()
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to list remote refs: %w", classifyGitError(err))
+	}
+	h.checkedAt, h.err = time.Now(), err
+	return err
+}
@@ -0,0 +1,342 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// The repository keeps one file per app, versions/{project}/{app}.json, and
+// an index listing the file of every app. Version bumps of different apps
+// touch different files, so they never conflict; the index only changes
+// when apps are added, removed or move. Repositories still holding the
+// single versions.json of earlier releases are read as they are and
+// migrated on the first write.
+const (
+	versionsDir       = "versions"
+	versionsIndexName = versionsDir + "/index.json"
+	// unassignedProject holds apps whose ID has no project
+	unassignedProject = "_"
+)
+
+// versionsIndex maps each app ID to the path of its file
+type versionsIndex struct {
+	Apps map[string]string `json:"apps"`
+}
+
+// fileReader reads a file by its slash-separated repository path, returning
+// an error matching os.ErrNotExist when it doesn't exist
+type fileReader func(name string) ([]byte, error)
+
+func (g *GitStorage) readWorktreeFile(name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(g.localDir, filepath.FromSlash(name)))
+}
+
+// commitReader reads files as they were at commit c
+func commitReader(c *object.Commit) fileReader {
+	return func(name string) ([]byte, error) {
+		file, err := c.File(name)
+		if err != nil {
+			if errors.Is(err, object.ErrFileNotFound) {
+				return nil, os.ErrNotExist
+			}
+			return nil, err
+		}
+		contents, err := file.Contents()
+		if err != nil {
+			return nil, err
+		}
+		return []byte(contents), nil
+	}
+}
+
+// isVersionsPath reports whether a repository path holds versions in either
+// layout
+func isVersionsPath(name string) bool {
+	return name == versionsFileName || strings.HasPrefix(name, versionsDir+"/")
+}
+
+// readIndex returns the index, or false when the repository has none yet
+func readIndex(read fileReader) (*versionsIndex, bool, error) {
+	data, err := read(versionsIndexName)
+	if errors.Is(err, os.ErrNotExist) {
+		return &versionsIndex{Apps: make(map[string]string)}, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read versions index: %w", err)
+	}
+
+	var idx versionsIndex
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return nil, false, fmt.Errorf("failed to unmarshal versions index: %w", err)
+	}
+	if idx.Apps == nil {
+		idx.Apps = make(map[string]string)
+	}
+	return &idx, true, nil
+}
+
+// loadVersions reads the versions of appIDs, or of every app when appIDs is
+// nil, from either layout. Apps that don't exist are left out.
+func loadVersions(read fileReader, appIDs []string) (map[string]*models.AppVersion, error) {
+	idx, ok, err := readIndex(read)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		vf, err := loadLegacyVersions(read)
+		if err != nil {
+			return nil, err
+		}
+		return vf.Versions, nil
+	}
+
+	if appIDs == nil {
+		appIDs = make([]string, 0, len(idx.Apps))
+		for appID := range idx.Apps {
+			appIDs = append(appIDs, appID)
+		}
+	}
+
+	versions := make(map[string]*models.AppVersion, len(appIDs))
+	for _, appID := range appIDs {
+		name, ok := idx.Apps[appID]
+		if !ok {
+			continue
+		}
+		data, err := read(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s for %s: %w", name, appID, err)
+		}
+		var version models.AppVersion
+		if err := json.Unmarshal(data, &version); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal %s: %w", name, err)
+		}
+		versions[appID] = &version
+	}
+	return versions, nil
+}
+
+// loadLegacyVersions reads the single versions file of earlier releases; a
+// missing file is an empty one
+func loadLegacyVersions(read fileReader) (*models.VersionsFile, error) {
+	data, err := read(versionsFileName)
+	if errors.Is(err, os.ErrNotExist) {
+		return &models.VersionsFile{Versions: make(map[string]*models.AppVersion)}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read versions file: %w", err)
+	}
+
+	var vf models.VersionsFile
+	if err := json.Unmarshal(data, &vf); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal versions file: %w", err)
+	}
+	if vf.Versions == nil {
+		vf.Versions = make(map[string]*models.AppVersion)
+	}
+	return &vf, nil
+}
+
+// asVersionsFile presents versions as one versions file, last updated when
+// its most recently updated app was
+func asVersionsFile(versions map[string]*models.AppVersion) *models.VersionsFile {
+	vf := &models.VersionsFile{Versions: versions}
+	for _, version := range versions {
+		if version != nil && version.LastUpdated.After(vf.LastUpdated) {
+			vf.LastUpdated = version.LastUpdated
+		}
+	}
+	if vf.LastUpdated.IsZero() {
+		vf.LastUpdated = time.Now()
+	}
+	return vf
+}
+
+// appFilePath returns where an app's file goes: under its project, named
+// after the app. Records without a project or app name fall back to the
+// default app ID scheme, and IDs it can't parse to the unassigned project.
+func appFilePath(appID string, version *models.AppVersion) string {
+	projectID, appName := version.ProjectID, version.AppName
+	if projectID == "" || appName == "" {
+		var err error
+		if projectID, appName, err = models.ParseAppID(appID); err != nil {
+			projectID, appName = unassignedProject, appID
+		}
+	}
+	return path.Join(versionsDir, pathSegment(projectID), pathSegment(appName)+".json")
+}
+
+// pathSegment escapes s for use as one path element
+func pathSegment(s string) string {
+	escaped := url.PathEscape(s)
+	if escaped == "." || escaped == ".." {
+		return strings.ReplaceAll(escaped, ".", "%2E")
+	}
+	return escaped
+}
+
+// writeVersions applies changes to the per-app files in the worktree; a nil
+// version removes the app. The index is rewritten only when apps are added,
+// removed or move to another file.
+func (g *GitStorage) writeVersions(changes map[string]*models.AppVersion) error {
+	idx, _, err := readIndex(g.readWorktreeFile)
+	if err != nil {
+		return err
+	}
+
+	owners := make(map[string]string, len(idx.Apps))
+	for appID, name := range idx.Apps {
+		owners[name] = appID
+	}
+
+	indexChanged := false
+	// Removals go first so a file freed in the same change, as by a rename,
+	// can be taken over
+	for _, appID := range sortedKeys(changes) {
+		if changes[appID] != nil {
+			continue
+		}
+		if name, ok := idx.Apps[appID]; ok {
+			if err := g.removeWorktreeFile(name); err != nil {
+				return err
+			}
+			delete(idx.Apps, appID)
+			delete(owners, name)
+			indexChanged = true
+		}
+	}
+
+	for _, appID := range sortedKeys(changes) {
+		version := changes[appID]
+		if version == nil {
+			continue
+		}
+
+		name := appFilePath(appID, version)
+		if owner, ok := owners[name]; ok && owner != appID {
+			// Another app has the same project and name; fall back to the ID
+			name = path.Join(path.Dir(name), pathSegment(appID)+".json")
+		}
+
+		if previous, ok := idx.Apps[appID]; !ok || previous != name {
+			if ok {
+				if err := g.removeWorktreeFile(previous); err != nil {
+					return err
+				}
+				delete(owners, previous)
+			}
+			idx.Apps[appID] = name
+			owners[name] = appID
+			indexChanged = true
+		}
+
+		data, err := json.MarshalIndent(version, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal version of %s: %w", appID, err)
+		}
+		if err := g.writeWorktreeFile(name, data); err != nil {
+			return err
+		}
+	}
+
+	if !indexChanged {
+		return nil
+	}
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal versions index: %w", err)
+	}
+	return g.writeWorktreeFile(versionsIndexName, data)
+}
+
+// writeAllVersions replaces every app in the worktree with the apps of vf
+// and drops the legacy versions file
+func (g *GitStorage) writeAllVersions(vf *models.VersionsFile) error {
+	idx, _, err := readIndex(g.readWorktreeFile)
+	if err != nil {
+		return err
+	}
+
+	changes := make(map[string]*models.AppVersion, len(idx.Apps)+len(vf.Versions))
+	for appID := range idx.Apps {
+		if vf.Versions[appID] == nil {
+			changes[appID] = nil
+		}
+	}
+	for appID, version := range vf.Versions {
+		if version != nil {
+			changes[appID] = version
+		}
+	}
+
+	if err := g.writeVersions(changes); err != nil {
+		return err
+	}
+	if _, ok, err := readIndex(g.readWorktreeFile); err != nil {
+		return err
+	} else if !ok {
+		// No apps at all; an empty index still marks the new layout
+		if err := g.writeWorktreeFile(versionsIndexName, []byte("{\n  \"apps\": {}\n}")); err != nil {
+			return err
+		}
+	}
+	return g.removeWorktreeFile(versionsFileName)
+}
+
+func (g *GitStorage) writeWorktreeFile(name string, data []byte) error {
+	filePath := filepath.Join(g.localDir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", name, err)
+	}
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+func (g *GitStorage) removeWorktreeFile(name string) error {
+	err := os.Remove(filepath.Join(g.localDir, filepath.FromSlash(name)))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove %s: %w", name, err)
+	}
+	return nil
+}
+
+// migrateLayout moves the apps of a legacy versions file into per-app files
+// in a commit of its own. It does nothing once the repository has an index
+// or when it has no versions file.
+func (g *GitStorage) migrateLayout(ctx context.Context) error {
+	if _, ok, err := readIndex(g.readWorktreeFile); err != nil || ok {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(g.localDir, versionsFileName)); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	vf, err := loadLegacyVersions(g.readWorktreeFile)
+	if err != nil {
+		return err
+	}
+	if err := g.writeAllVersions(vf); err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("%s: Move %d apps from %s to per-app files", commitMessage, len(vf.Versions), versionsFileName)
+	if err := g.commit(ctx, message); err != nil {
+		return fmt.Errorf("failed to commit migration: %w", err)
+	}
+
+	g.logger.WithField("count", len(vf.Versions)).Info("Migrated versions file to per-app files")
+	return nil
+}
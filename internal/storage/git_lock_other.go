@@ -0,0 +1,14 @@
+//go:build !unix
+
+package storage
+
+import (
+	"errors"
+	"os"
+)
+
+// tryLockFile fails on platforms without flock, so no clone is ever taken
+// for orphaned
+func tryLockFile(f *os.File) error {
+	return errors.New("file locks are not supported on this platform")
+}
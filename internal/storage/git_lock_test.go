@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitStorage_CanceledContextStopsWaitingForLock(t *testing.T) {
+	remote := newLegacyRemote(t, &models.VersionsFile{Versions: map[string]*models.AppVersion{
+		"1-api": {Current: "1.0.0", ProjectID: "1", AppName: "api"},
+	}})
+	g := newTestGitStorage(t, remote)
+
+	// Another operation holds the lock
+	require.NoError(t, g.acquire(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := g.GetVersion(ctx, "1-api")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	err = g.SetVersion(ctx, "1-api", &models.AppVersion{Current: "1.1.0", ProjectID: "1", AppName: "api"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	g.release()
+
+	// A context canceled before the call never takes the lock, even when free
+	canceled, cancelNow := context.WithCancel(context.Background())
+	cancelNow()
+	_, err = g.GetVersion(canceled, "1-api")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	version, err := g.GetVersion(context.Background(), "1-api")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", version.Current)
+}
+
+// canceledAfterFetch is a context the caller gave up on while the fetch
+// was already done: it reports being canceled without interrupting I/O
+type canceledAfterFetch struct {
+	context.Context
+}
+
+func (canceledAfterFetch) Err() error { return context.Canceled }
+
+func TestGitStorage_PullKeepsWorktreeWhenCanceled(t *testing.T) {
+	remote := newLegacyRemote(t, &models.VersionsFile{Versions: map[string]*models.AppVersion{
+		"1-api": {Current: "1.0.0", ProjectID: "1", AppName: "api"},
+	}})
+	g := newTestGitStorage(t, remote)
+	other := newTestGitStorage(t, remote)
+	require.NoError(t, other.SetVersion(context.Background(), "1-api", &models.AppVersion{Current: "1.1.0", ProjectID: "1", AppName: "api"}))
+
+	// Leftovers of a write that failed before committing
+	leftover := filepath.Join(g.localDir, versionsFileName)
+	require.NoError(t, os.WriteFile(leftover, []byte(`{"versions": {}}`), 0644))
+
+	err := g.pull(canceledAfterFetch{context.Background()})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	data, err := os.ReadFile(leftover)
+	require.NoError(t, err)
+	assert.Equal(t, `{"versions": {}}`, string(data), "the worktree is not reset")
+}
@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/sirupsen/logrus"
+)
+
+// maintenanceTimeout bounds a single maintenance run, including the clone
+// replacing a corrupt or oversized one
+const maintenanceTimeout = 10 * time.Minute
+
+// maintenanceLoop maintains the clone every MaintenanceInterval until Close
+func (g *GitStorage) maintenanceLoop() {
+	ticker := time.NewTicker(g.cloneOpts.MaintenanceInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-g.done:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), maintenanceTimeout)
+			if err := g.Maintain(ctx); err != nil {
+				g.logger.WithError(err).Warn("Git maintenance failed")
+			}
+			cancel()
+		}
+	}
+}
+
+// Maintain keeps the local clone healthy and bounded:
+//   - a clone whose latest commit can't be read in full is replaced by a
+//     fresh one, dropping any commits that weren't pushed
+//   - objects no ref reaches any more, such as commits replaced by a rebase,
+//     are deleted and the rest repacked into a single pack; shallow clones
+//     are skipped, since their history ends in missing parents
+//   - a clone larger than MaxSize is replaced by a fresh one, unless it
+//     holds commits or tags that weren't pushed yet
+func (g *GitStorage) Maintain(ctx context.Context) error {
+	if err := g.acquire(ctx); err != nil {
+		return err
+	}
+	defer g.release()
+
+	if err := g.verifyClone(); err != nil {
+		g.logger.WithError(err).Error("Local clone is corrupt, cloning again; unpushed commits are lost")
+		return g.reclone(ctx)
+	}
+
+	if !g.isShallow() {
+		if err := g.repo.Prune(git.PruneOptions{Handler: g.repo.DeleteObject}); err != nil {
+			g.logger.WithError(err).Warn("Failed to prune unreachable objects")
+		} else if err := g.repo.RepackObjects(&git.RepackConfig{}); err != nil {
+			g.logger.WithError(err).Warn("Failed to repack objects")
+		}
+	}
+
+	size, err := dirSize(g.localDir)
+	if err != nil {
+		return fmt.Errorf("failed to measure clone: %w", err)
+	}
+	fields := logrus.Fields{"size_bytes": size, "max_bytes": g.cloneOpts.MaxSize}
+	if g.cloneOpts.MaxSize <= 0 || size <= g.cloneOpts.MaxSize {
+		g.logger.WithFields(fields).Debug("Git maintenance done")
+		return nil
+	}
+
+	unpushed, err := g.hasUnpushedChanges(ctx)
+	if err != nil {
+		return err
+	}
+	if unpushed {
+		g.logger.WithFields(fields).Warn("Clone exceeds its size cap but has unpushed commits, cloning again postponed")
+		return nil
+	}
+
+	g.logger.WithFields(fields).Info("Clone exceeds its size cap, cloning again")
+	if err := g.reclone(ctx); err != nil {
+		return err
+	}
+
+	if size, err := dirSize(g.localDir); err == nil && size > g.cloneOpts.MaxSize {
+		g.logger.WithField("size_bytes", size).Warn("Fresh clone exceeds its size cap; limit the clone depth or raise the cap")
+	}
+	return nil
+}
+
+// verifyClone reads the index and the latest commit with every file in it,
+// failing when an object is missing or damaged. A repository without commits
+// passes.
+func (g *GitStorage) verifyClone() error {
+	if _, err := g.repo.Storer.Index(); err != nil {
+		return fmt.Errorf("failed to read index: %w", err)
+	}
+
+	revision, err := g.headRevision()
+	if err != nil || revision == "" {
+		return err
+	}
+	head, err := g.repo.Head()
+	if err != nil {
+		return fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	commit, err := g.repo.CommitObject(head.Hash())
+	if err != nil {
+		return fmt.Errorf("failed to read HEAD commit: %w", err)
+	}
+	files, err := commit.Files()
+	if err != nil {
+		return fmt.Errorf("failed to read HEAD tree: %w", err)
+	}
+	return files.ForEach(func(f *object.File) error {
+		if _, err := f.Contents(); err != nil {
+			return fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+		return nil
+	})
+}
+
+// dirSize returns the bytes taken by the regular files under dir
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/sirupsen/logrus"
+)
+
+// mirrorRemoteName names the read-only mirror among the clone's remotes
+const mirrorRemoteName = "mirror"
+
+// ErrServingFromMirror is returned by Health while the primary remote can't
+// be reached but the read-only mirror can: reads are served from the mirror
+// and writes stay local until the primary is back
+var ErrServingFromMirror = errors.New("primary remote unreachable, reading from the mirror")
+
+// checkRemotes checks the primary remote and, when it is unreachable, the
+// mirror. A reachable mirror turns the failure into ErrServingFromMirror.
+func checkRemotes(ctx context.Context, primary, mirror *remoteHealth, repoURL, mirrorURL string, auth transport.AuthMethod) error {
+	err := primary.check(ctx, repoURL, auth)
+	if err == nil || mirrorURL == "" || ctx.Err() != nil {
+		return err
+	}
+	if mirrorErr := mirror.check(ctx, mirrorURL, auth); mirrorErr != nil {
+		return fmt.Errorf("%w; mirror: %v", err, mirrorErr)
+	}
+	return fmt.Errorf("%w: %v", ErrServingFromMirror, err)
+}
+
+// cloneFromMirror clones the branch from the mirror into dir after cloning
+// it from the primary remote failed with primaryErr. origin still points at
+// the primary, so pushes go there once it is back.
+func (g *GitStorage) cloneFromMirror(ctx context.Context, dir string, primaryErr error) (*git.Repository, error) {
+	// The failed clone can leave a partial repository behind
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, fmt.Errorf("failed to clean up clone: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+
+	repo, err := git.PlainCloneContext(ctx, dir, false, &git.CloneOptions{
+		URL:           g.cloneOpts.MirrorURL,
+		Auth:          g.auth,
+		RemoteName:    mirrorRemoteName,
+		ReferenceName: plumbing.NewBranchReferenceName(g.branch),
+		SingleBranch:  true,
+		Depth:         g.cloneOpts.Depth,
+	})
+	if err != nil {
+		// Whether the branch exists is only known to the primary, so a
+		// mirror without it doesn't let us start from an empty branch
+		return nil, fmt.Errorf("%w; mirror: %v", primaryErr, err)
+	}
+
+	if _, err := repo.CreateRemote(&config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{g.repoURL},
+	}); err != nil {
+		return nil, fmt.Errorf("failed to add remote: %w", err)
+	}
+
+	g.logger.WithError(primaryErr).WithFields(logrus.Fields{
+		"mirror": g.cloneOpts.MirrorURL,
+		"branch": g.branch,
+	}).Warn("Primary remote unreachable, cloned from the read-only mirror")
+	return repo, nil
+}
+
+// pullMirror brings the branch up to the mirror's tip while the primary
+// remote is unreachable. The mirror is only followed forward: local commits
+// it lacks, whether unpushed or pushed before it caught up, are kept as they
+// are and reconciled with the primary once it is back.
+func (g *GitStorage) pullMirror(ctx context.Context) error {
+	if _, err := g.repo.Remote(mirrorRemoteName); errors.Is(err, git.ErrRemoteNotFound) {
+		if _, err := g.repo.CreateRemote(&config.RemoteConfig{
+			Name: mirrorRemoteName,
+			URLs: []string{g.cloneOpts.MirrorURL},
+		}); err != nil {
+			return fmt.Errorf("failed to add mirror remote: %w", err)
+		}
+	}
+
+	tip, err := g.fetchBranch(ctx, mirrorRemoteName)
+	if err != nil {
+		return err
+	}
+	if tip.IsZero() {
+		return nil
+	}
+
+	head, err := g.repo.Head()
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		// No local commits yet; the branch is made by Bootstrap or the first
+		// write against the primary
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	if ahead, err := g.containsCommit(tip); err != nil || ahead {
+		return err
+	}
+
+	headCommit, err := g.repo.CommitObject(head.Hash())
+	if err != nil {
+		return fmt.Errorf("failed to read HEAD commit: %w", err)
+	}
+	tipCommit, err := g.repo.CommitObject(tip)
+	if err != nil {
+		return fmt.Errorf("failed to read commit %s: %w", tip, err)
+	}
+	behind, err := headCommit.IsAncestor(tipCommit)
+	if err != nil && !g.endOfHistory(err) {
+		return err
+	}
+	if !behind {
+		g.logger.WithField("mirror_head", tip.String()).Debug("Mirror lacks local commits, keeping the local branch")
+		return nil
+	}
+
+	w, err := g.repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+	if err := w.Reset(&git.ResetOptions{Commit: tip, Mode: git.HardReset}); err != nil {
+		return fmt.Errorf("failed to reset to %s: %w", tip, err)
+	}
+	return nil
+}
@@ -0,0 +1,387 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/sirupsen/logrus"
+)
+
+// maxPushAttempts bounds how often a write is rebased onto a remote branch
+// that keeps moving before its push is left to the background retry
+const maxPushAttempts = 3
+
+// ErrNoCommonHistory is returned when the local and remote branches share
+// no commit, as after the remote branch was rewritten
+var ErrNoCommonHistory = errors.New("local and remote branches have no common history")
+
+// pendingCommit is an unpushed commit reduced to the apps it changed, so it
+// can be applied again on top of another commit
+type pendingCommit struct {
+	hash    plumbing.Hash
+	message string
+	when    time.Time
+	// changes holds the new record of every app the commit changed; nil
+	// for apps it removed
+	changes map[string]*models.AppVersion
+}
+
+// pushWithRebase pushes the branch. When the push fails because another
+// replica pushed first, the unpushed commits are rebased onto the remote
+// branch and the push is retried.
+func (g *GitStorage) pushWithRebase(ctx context.Context) error {
+	for attempt := 1; ; attempt++ {
+		pushErr := g.push(ctx)
+		if pushErr == nil {
+			g.pushPendingTags(ctx)
+			return nil
+		}
+		if attempt == maxPushAttempts || ctx.Err() != nil {
+			return pushErr
+		}
+
+		remote, err := g.fetchRemote(ctx)
+		if err != nil {
+			g.logger.WithError(err).Warn("Failed to fetch after a rejected push")
+			return pushErr
+		}
+		if remote.IsZero() {
+			return pushErr
+		}
+		if ahead, err := g.containsCommit(remote); err != nil || ahead {
+			// The remote didn't move, so the push failed for another reason
+			return pushErr
+		}
+
+		if err := g.rebase(ctx, remote); err != nil {
+			return fmt.Errorf("%w; rebase failed: %v", pushErr, err)
+		}
+	}
+}
+
+// fetchRemote fetches the versions branch and returns its remote tip, zero
+// when the branch doesn't exist on the remote
+func (g *GitStorage) fetchRemote(ctx context.Context) (plumbing.Hash, error) {
+	if err := g.Breaker.Allow(); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to fetch: %w: %w", ErrNetwork, err)
+	}
+	tip, err := g.fetchBranch(ctx, "origin")
+	g.recordRemote(err)
+	return tip, err
+}
+
+// fetchBranch fetches the versions branch from the named remote and returns
+// its tip there, zero when the remote lacks the branch
+func (g *GitStorage) fetchBranch(ctx context.Context, remoteName string) (plumbing.Hash, error) {
+	remoteRef := plumbing.NewRemoteReferenceName(remoteName, g.branch)
+	err := g.repo.FetchContext(ctx, &git.FetchOptions{
+		RemoteName: remoteName,
+		Auth:       g.auth,
+		RefSpecs: []config.RefSpec{
+			config.RefSpec(fmt.Sprintf("+refs/heads/%s:%s", g.branch, remoteRef)),
+		},
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		if isMissingBranch(err) {
+			return plumbing.ZeroHash, nil
+		}
+		return plumbing.ZeroHash, fmt.Errorf("failed to fetch: %w", classifyGitError(err))
+	}
+
+	ref, err := g.repo.Reference(remoteRef, true)
+	if err != nil {
+		if errors.Is(err, plumbing.ErrReferenceNotFound) {
+			return plumbing.ZeroHash, nil
+		}
+		return plumbing.ZeroHash, fmt.Errorf("failed to resolve %s: %w", remoteRef, err)
+	}
+	return ref.Hash(), nil
+}
+
+// rebase moves the branch onto remote and commits the changes of every
+// unpushed commit again on top, with their messages and author dates. Apps
+// changed on both sides end up with the local record. The worktree is only
+// touched once every unpushed commit has been read.
+func (g *GitStorage) rebase(ctx context.Context, remote plumbing.Hash) error {
+	if ahead, err := g.containsCommit(remote); err != nil || ahead {
+		return err
+	}
+
+	pending, err := g.pendingCommits(remote)
+	if err != nil {
+		return err
+	}
+
+	w, err := g.repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+	if err := w.Reset(&git.ResetOptions{Commit: remote, Mode: git.HardReset}); err != nil {
+		return fmt.Errorf("failed to reset to %s: %w", remote, err)
+	}
+
+	applied, err := g.replay(ctx, pending)
+	if err != nil {
+		return err
+	}
+
+	g.logger.WithFields(logrus.Fields{
+		"onto":    remote.String(),
+		"pending": len(pending),
+		"applied": applied,
+	}).Info("Rebased unpushed commits onto the remote branch")
+	return nil
+}
+
+// replay commits the changes of pending on top of HEAD, with their messages
+// and author dates, and returns how many commits it made
+func (g *GitStorage) replay(ctx context.Context, pending []pendingCommit) (int, error) {
+	// HEAD may still have the legacy layout the pending commits moved away
+	// from
+	if err := g.migrateLayout(ctx); err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for _, commit := range pending {
+		if len(commit.changes) > 0 {
+			if err := g.writeVersions(commit.changes); err != nil {
+				return applied, err
+			}
+			// The message already carries the trailers of the original commit
+			if err := g.commitAt(context.Background(), commit.message, commit.when); err != nil {
+				return applied, fmt.Errorf("failed to commit %s again: %w", commit.hash, err)
+			}
+			applied++
+		}
+
+		head, err := g.repo.Head()
+		if err != nil {
+			return applied, fmt.Errorf("failed to get HEAD: %w", err)
+		}
+		if err := g.retag(commit.hash, head.Hash()); err != nil {
+			return applied, err
+		}
+	}
+	return applied, nil
+}
+
+// containsCommit reports whether hash is HEAD or one of its ancestors, so
+// there is nothing to rebase onto
+func (g *GitStorage) containsCommit(hash plumbing.Hash) (bool, error) {
+	head, err := g.repo.Head()
+	if err != nil {
+		return false, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	if head.Hash() == hash {
+		return true, nil
+	}
+	headCommit, err := g.repo.CommitObject(head.Hash())
+	if err != nil {
+		return false, fmt.Errorf("failed to read HEAD commit: %w", err)
+	}
+	commit, err := g.repo.CommitObject(hash)
+	if err != nil {
+		return false, fmt.Errorf("failed to read commit %s: %w", hash, err)
+	}
+	ahead, err := commit.IsAncestor(headCommit)
+	if err != nil && !g.endOfHistory(err) {
+		return false, err
+	}
+	return ahead, nil
+}
+
+// pendingCommits returns the commits of HEAD that remote lacks, oldest
+// first, reduced to the apps they changed
+func (g *GitStorage) pendingCommits(remote plumbing.Hash) ([]pendingCommit, error) {
+	onRemote, err := ancestors(g.repo, remote)
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk remote history: %w", err)
+	}
+
+	head, err := g.repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+
+	return commitsSince(g.repo, head.Hash(), func(hash plumbing.Hash) bool {
+		return onRemote[hash]
+	})
+}
+
+// ancestors returns hash and every commit before it in repo. The walk ends
+// quietly at the oldest commit of a shallow repository.
+func ancestors(repo *git.Repository, hash plumbing.Hash) (map[plumbing.Hash]bool, error) {
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[plumbing.Hash]bool)
+	iter := object.NewCommitPreorderIter(commit, nil, nil)
+	err = iter.ForEach(func(c *object.Commit) error {
+		seen[c.Hash] = true
+		return nil
+	})
+	if err != nil && !errors.Is(err, plumbing.ErrObjectNotFound) {
+		return nil, err
+	}
+	return seen, nil
+}
+
+// commitsSince follows the first parents of head in repo back to the first
+// commit base accepts, and returns the commits after it, oldest first,
+// reduced to the apps they changed
+func commitsSince(repo *git.Repository, head plumbing.Hash, base func(plumbing.Hash) bool) ([]pendingCommit, error) {
+	// Writes never merge, so the unpushed commits form a single line
+	var local []*object.Commit
+	for hash := head; !base(hash); {
+		c, err := repo.CommitObject(hash)
+		if err != nil {
+			if errors.Is(err, plumbing.ErrObjectNotFound) {
+				return nil, ErrNoCommonHistory
+			}
+			return nil, fmt.Errorf("failed to read commit %s: %w", hash, err)
+		}
+		if c.NumParents() == 0 {
+			return nil, ErrNoCommonHistory
+		}
+		local = append(local, c)
+		hash = c.ParentHashes[0]
+	}
+
+	pending := make([]pendingCommit, 0, len(local))
+	for i := len(local) - 1; i >= 0; i-- {
+		c := local[i]
+		parent, err := c.Parent(0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read parent of %s: %w", c.Hash, err)
+		}
+		changes, err := commitChanges(parent, c)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read changes of %s: %w", c.Hash, err)
+		}
+		pending = append(pending, pendingCommit{
+			hash:    c.Hash,
+			message: c.Message,
+			when:    c.Author.When,
+			changes: changes,
+		})
+	}
+	return pending, nil
+}
+
+// commitChanges returns the apps whose records differ between parent and c,
+// in either layout: the new record, or nil for apps c removed. Commits that
+// only move apps between layouts change nothing.
+func commitChanges(parent, c *object.Commit) (map[string]*models.AppVersion, error) {
+	candidates, err := changedAppIDs(parent, c)
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := loadVersions(commitReader(parent), candidates)
+	if err != nil {
+		return nil, err
+	}
+	after, err := loadVersions(commitReader(c), candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]*models.AppVersion)
+	for appID, version := range after {
+		if !sameRecord(before[appID], version) {
+			changes[appID] = version
+		}
+	}
+	for appID := range before {
+		if _, ok := after[appID]; !ok {
+			changes[appID] = nil
+		}
+	}
+	return changes, nil
+}
+
+// changedAppIDs returns the apps whose files or index entries c changed, or
+// nil, meaning every app, when it touched the legacy versions file
+func changedAppIDs(parent, c *object.Commit) ([]string, error) {
+	parentTree, err := parent.Tree()
+	if err != nil {
+		return nil, err
+	}
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, err
+	}
+	diff, err := object.DiffTree(parentTree, tree)
+	if err != nil {
+		return nil, err
+	}
+
+	idxBefore, _, err := readIndex(commitReader(parent))
+	if err != nil {
+		return nil, err
+	}
+	idxAfter, _, err := readIndex(commitReader(c))
+	if err != nil {
+		return nil, err
+	}
+
+	owners := make(map[string][]string)
+	for _, idx := range []*versionsIndex{idxBefore, idxAfter} {
+		for appID, name := range idx.Apps {
+			owners[name] = append(owners[name], appID)
+		}
+	}
+
+	seen := make(map[string]bool)
+	appIDs := []string{}
+	add := func(appID string) {
+		if !seen[appID] {
+			seen[appID] = true
+			appIDs = append(appIDs, appID)
+		}
+	}
+
+	for _, change := range diff {
+		for _, name := range []string{change.From.Name, change.To.Name} {
+			if name == versionsFileName {
+				return nil, nil
+			}
+			for _, appID := range owners[name] {
+				add(appID)
+			}
+		}
+	}
+	for appID, name := range idxBefore.Apps {
+		if idxAfter.Apps[appID] != name {
+			add(appID)
+		}
+	}
+	for appID := range idxAfter.Apps {
+		if _, ok := idxBefore.Apps[appID]; !ok {
+			add(appID)
+		}
+	}
+	return appIDs, nil
+}
+
+// sameRecord reports whether two app records serialize the same
+func sameRecord(a, b *models.AppVersion) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	dataA, errA := json.Marshal(a)
+	dataB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(dataA, dataB)
+}
@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/sirupsen/logrus"
+)
+
+// cloneLockSuffix names the lock file kept next to each clone directory.
+// The owning process holds a lock on it for as long as it uses the clone,
+// and the kernel drops the lock when the process dies, so a clone whose
+// lock can be taken was left behind by a crash.
+const cloneLockSuffix = ".lock"
+
+// errForeignClone is returned for clones of another repository or branch
+var errForeignClone = errors.New("clone of another repository or branch")
+
+// claimCloneDir creates a new temporary clone directory, locking its lock
+// file first so other processes never take it for orphaned
+func (g *GitStorage) claimCloneDir() (string, error) {
+	lock, err := os.CreateTemp("", tempDirPrefix+"*"+cloneLockSuffix)
+	if err != nil {
+		return "", fmt.Errorf("failed to create clone lock: %w", err)
+	}
+	if err := tryLockFile(lock); err != nil {
+		g.logger.WithError(err).Debug("Clone lock not taken, the clone won't be recovered after a crash")
+	}
+
+	dir := strings.TrimSuffix(lock.Name(), cloneLockSuffix)
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		lock.Close()
+		os.Remove(lock.Name())
+		return "", fmt.Errorf("failed to create temp dir: %w", err)
+	}
+
+	if g.cloneLocks == nil {
+		g.cloneLocks = make(map[string]*os.File)
+	}
+	g.cloneLocks[dir] = lock
+	return dir, nil
+}
+
+// removeClone deletes a clone directory, then its lock file
+func (g *GitStorage) removeClone(dir string) error {
+	err := os.RemoveAll(dir)
+	if lock, ok := g.cloneLocks[dir]; ok && err == nil {
+		os.Remove(lock.Name())
+		lock.Close()
+		delete(g.cloneLocks, dir)
+	}
+	return err
+}
+
+// recoverOrphans looks for clones of this repository and branch that a
+// previous process left in the temp directory, typically by crashing
+// between a commit and its push. Their unpushed commits are committed again
+// on top of the fresh clone and pushed, and the orphaned clones removed.
+// Clones made by releases without lock files are left alone.
+func (g *GitStorage) recoverOrphans(ctx context.Context) {
+	locks, err := filepath.Glob(filepath.Join(os.TempDir(), tempDirPrefix+"*"+cloneLockSuffix))
+	if err != nil {
+		return
+	}
+
+	recovered := 0
+	for _, lockPath := range locks {
+		dir := strings.TrimSuffix(lockPath, cloneLockSuffix)
+		if _, ours := g.cloneLocks[dir]; ours {
+			continue
+		}
+
+		lock, err := os.OpenFile(lockPath, os.O_RDWR, 0)
+		if err != nil {
+			continue
+		}
+		if err := tryLockFile(lock); err != nil {
+			// In use by a live process
+			lock.Close()
+			continue
+		}
+
+		fields := logrus.Fields{"dir": dir}
+		applied, err := g.recoverClone(ctx, dir)
+		switch {
+		case errors.Is(err, errForeignClone):
+			lock.Close()
+			continue
+		case err != nil:
+			g.logger.WithError(err).WithFields(fields).Error("Failed to recover orphaned clone; it is kept for inspection")
+			lock.Close()
+			continue
+		}
+
+		if err := os.RemoveAll(dir); err != nil {
+			g.logger.WithError(err).WithFields(fields).Warn("Failed to remove orphaned clone")
+		} else {
+			os.Remove(lockPath)
+		}
+		lock.Close()
+
+		recovered += applied
+		g.logger.WithFields(fields).WithField("commits", applied).Info("Orphaned clone recovered")
+	}
+
+	if recovered == 0 {
+		return
+	}
+	if err := g.pushWithRebase(ctx); err != nil {
+		g.logger.WithError(err).Warn("Failed to push recovered commits, commits saved locally")
+	}
+}
+
+// recoverClone commits the unpushed commits of the clone in dir again on top
+// of HEAD and returns how many commits it made. Commits already on the
+// remote, or in this clone, are skipped. It fails with errForeignClone for
+// clones of another repository or branch.
+func (g *GitStorage) recoverClone(ctx context.Context, dir string) (int, error) {
+	orphan, err := git.PlainOpen(dir)
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		// A clone that never finished holds no commits
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to open clone: %w", err)
+	}
+
+	remote, err := orphan.Remote("origin")
+	if err != nil || len(remote.Config().URLs) == 0 || remote.Config().URLs[0] != g.repoURL {
+		return 0, errForeignClone
+	}
+	head, err := orphan.Head()
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	if head.Name() != plumbing.NewBranchReferenceName(g.branch) {
+		return 0, errForeignClone
+	}
+
+	// The remote-tracking branch is where the orphan last saw the remote
+	pushed := map[plumbing.Hash]bool{}
+	if ref, err := orphan.Reference(plumbing.NewRemoteReferenceName("origin", g.branch), true); err == nil {
+		if pushed, err = ancestors(orphan, ref.Hash()); err != nil {
+			return 0, fmt.Errorf("failed to walk remote history: %w", err)
+		}
+	}
+	pending, err := commitsSince(orphan, head.Hash(), func(hash plumbing.Hash) bool {
+		if pushed[hash] {
+			return true
+		}
+		_, err := g.repo.CommitObject(hash)
+		return err == nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if len(pending) == 0 {
+		return 0, nil
+	}
+
+	return g.replay(ctx, pending)
+}
@@ -0,0 +1,262 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// RoutedGitStorage spreads projects over several versions repositories,
+// such as one per business unit. A routing table maps project IDs to
+// repository URLs; every other project, and apps without a project in their
+// ID, stay in the default repository. Each repository is opened once and
+// shared by all projects routed to it. Whole-file operations, history
+// transfer and watches are unavailable.
+type RoutedGitStorage struct {
+	defaultRepo string
+	routes      map[string]string
+	// repos holds the storage of every repository, by URL
+	repos  map[string]Storage
+	logger *logrus.Logger
+}
+
+// NewRoutedGitStorage opens defaultRepo and every repository in routes, a
+// map of project IDs to repository URLs, with open. Repositories that fail
+// to open fail the whole storage.
+func NewRoutedGitStorage(defaultRepo string, routes map[string]string, open func(repoURL string) (Storage, error), logger *logrus.Logger) (*RoutedGitStorage, error) {
+	r := &RoutedGitStorage{
+		defaultRepo: defaultRepo,
+		routes:      routes,
+		repos:       make(map[string]Storage),
+		logger:      logger,
+	}
+
+	urls := map[string]bool{defaultRepo: true}
+	for _, repoURL := range routes {
+		urls[repoURL] = true
+	}
+	for _, repoURL := range sortedKeys(urls) {
+		s, err := open(repoURL)
+		if err != nil {
+			r.Close()
+			return nil, fmt.Errorf("failed to open repository %s: %w", repoURL, err)
+		}
+		r.repos[repoURL] = s
+	}
+
+	logger.WithFields(logrus.Fields{
+		"repositories": len(r.repos),
+		"routes":       len(routes),
+	}).Info("Routing projects to versions repositories")
+	return r, nil
+}
+
+// repoOf returns the URL of the repository holding appID
+func (r *RoutedGitStorage) repoOf(appID string) string {
+	return r.repoOfProject(projectOf(appID))
+}
+
+// repoOfProject returns the URL of the repository holding projectID
+func (r *RoutedGitStorage) repoOfProject(projectID string) string {
+	if repoURL, ok := r.routes[projectID]; ok {
+		return repoURL
+	}
+	return r.defaultRepo
+}
+
+// byRepo splits versions by the repository holding them
+func (r *RoutedGitStorage) byRepo(versions map[string]*models.AppVersion) map[string]map[string]*models.AppVersion {
+	groups := make(map[string]map[string]*models.AppVersion)
+	for appID, version := range versions {
+		repoURL := r.repoOf(appID)
+		if groups[repoURL] == nil {
+			groups[repoURL] = make(map[string]*models.AppVersion)
+		}
+		groups[repoURL][appID] = version
+	}
+	return groups
+}
+
+func (r *RoutedGitStorage) GetVersion(ctx context.Context, appID string) (*models.AppVersion, error) {
+	return r.repos[r.repoOf(appID)].GetVersion(ctx, appID)
+}
+
+func (r *RoutedGitStorage) SetVersion(ctx context.Context, appID string, version *models.AppVersion) error {
+	return r.repos[r.repoOf(appID)].SetVersion(ctx, appID, version)
+}
+
+// SetVersions writes the versions of each repository in one change,
+// repository by repository. A failure leaves the repositories before it
+// written.
+func (r *RoutedGitStorage) SetVersions(ctx context.Context, versions map[string]*models.AppVersion) error {
+	groups := r.byRepo(versions)
+	for _, repoURL := range sortedKeys(groups) {
+		if err := setVersions(ctx, r.repos[repoURL], groups[repoURL]); err != nil {
+			return fmt.Errorf("repository %s: %w", repoURL, err)
+		}
+	}
+	return nil
+}
+
+// ListVersions reads every repository. Apps a repository holds but that are
+// routed elsewhere, such as those written before their project was moved,
+// are left out.
+func (r *RoutedGitStorage) ListVersions(ctx context.Context) (map[string]*models.AppVersion, error) {
+	versions := make(map[string]*models.AppVersion)
+	for _, repoURL := range sortedKeys(r.repos) {
+		repoVersions, err := r.repos[repoURL].ListVersions(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("repository %s: %w", repoURL, err)
+		}
+		for appID, version := range repoVersions {
+			if r.repoOf(appID) == repoURL {
+				versions[appID] = version
+			}
+		}
+	}
+	return versions, nil
+}
+
+// ListVersionsByProject reads only the repository projectID is routed to
+func (r *RoutedGitStorage) ListVersionsByProject(ctx context.Context, projectID string) (map[string]*models.AppVersion, error) {
+	return r.repos[r.repoOfProject(projectID)].ListVersionsByProject(ctx, projectID)
+}
+
+func (r *RoutedGitStorage) ListVersionsPage(ctx context.Context, cursor string, limit int, filter models.VersionFilter) (*models.VersionPage, error) {
+	versions, err := r.ListVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pageVersions(versions, cursor, limit, filter)
+}
+
+func (r *RoutedGitStorage) DeleteVersion(ctx context.Context, appID string) error {
+	return r.repos[r.repoOf(appID)].DeleteVersion(ctx, appID)
+}
+
+// RenameVersion moves a record within its repository in one change. Moving
+// it to another repository writes the new one first, then removes it from
+// the old one.
+func (r *RoutedGitStorage) RenameVersion(ctx context.Context, oldAppID, newAppID string, version *models.AppVersion) error {
+	from, to := r.repos[r.repoOf(oldAppID)], r.repos[r.repoOf(newAppID)]
+	if from == to {
+		return renameVersion(ctx, from, oldAppID, newAppID, version)
+	}
+
+	if err := to.SetVersion(ctx, newAppID, version); err != nil {
+		return err
+	}
+	return from.DeleteVersion(ctx, oldAppID)
+}
+
+// Health checks every repository. While the only failures are repositories
+// serving reads from their mirror, the result is an ErrServingFromMirror.
+func (r *RoutedGitStorage) Health(ctx context.Context) error {
+	var down, mirrored []error
+	for _, repoURL := range sortedKeys(r.repos) {
+		err := r.repos[repoURL].Health(ctx)
+		switch {
+		case err == nil:
+		case errors.Is(err, ErrServingFromMirror):
+			mirrored = append(mirrored, fmt.Errorf("repository %s: %w", repoURL, err))
+		default:
+			down = append(down, fmt.Errorf("repository %s: %w", repoURL, err))
+		}
+	}
+	if len(down) > 0 {
+		return errors.Join(down...)
+	}
+	return errors.Join(mirrored...)
+}
+
+func (r *RoutedGitStorage) RebuildCache(ctx context.Context, versions map[string]*models.AppVersion) error {
+	// Git storage doesn't use cache, so this is a no-op
+	return nil
+}
+
+// PushPendingCommits pushes the pending commits of every repository
+func (r *RoutedGitStorage) PushPendingCommits(ctx context.Context) error {
+	var errs []error
+	for _, repoURL := range sortedKeys(r.repos) {
+		pushable, ok := r.repos[repoURL].(GitPushable)
+		if !ok {
+			continue
+		}
+		if err := pushable.PushPendingCommits(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("repository %s: %w", repoURL, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// IsEmpty reports whether every repository is empty
+func (r *RoutedGitStorage) IsEmpty() bool {
+	for _, s := range r.repos {
+		bootstrapper, ok := s.(Bootstrapper)
+		if !ok || !bootstrapper.IsEmpty() {
+			return false
+		}
+	}
+	return true
+}
+
+// Bootstrap seeds every repository with the apps routed to it, and returns
+// the revision of the last repository written
+func (r *RoutedGitStorage) Bootstrap(ctx context.Context, vf *models.VersionsFile, message string) (string, error) {
+	var revision string
+	groups := r.byRepo(vf.Versions)
+	for _, repoURL := range sortedKeys(groups) {
+		bootstrapper, ok := r.repos[repoURL].(Bootstrapper)
+		if !ok {
+			return revision, fmt.Errorf("repository %s cannot be bootstrapped", repoURL)
+		}
+		rev, err := bootstrapper.Bootstrap(ctx, &models.VersionsFile{Versions: groups[repoURL]}, message)
+		if rev != "" {
+			revision = rev
+		}
+		if err != nil {
+			return revision, fmt.Errorf("repository %s: %w", repoURL, err)
+		}
+	}
+	return revision, nil
+}
+
+// history returns the history of the repository holding appID
+func (r *RoutedGitStorage) history(appID string) (HistoryProvider, error) {
+	repoURL := r.repoOf(appID)
+	history, ok := r.repos[repoURL].(HistoryProvider)
+	if !ok {
+		return nil, fmt.Errorf("repository %s does not keep history", repoURL)
+	}
+	return history, nil
+}
+
+func (r *RoutedGitStorage) GetVersionHistory(ctx context.Context, appID string) ([]models.VersionHistoryEntry, error) {
+	history, err := r.history(appID)
+	if err != nil {
+		return nil, err
+	}
+	return history.GetVersionHistory(ctx, appID)
+}
+
+func (r *RoutedGitStorage) GetPreviousVersion(ctx context.Context, appID, current string) (*models.AppVersion, string, error) {
+	history, err := r.history(appID)
+	if err != nil {
+		return nil, "", err
+	}
+	return history.GetPreviousVersion(ctx, appID, current)
+}
+
+// Close closes every repository opened
+func (r *RoutedGitStorage) Close() error {
+	var errs []error
+	for _, s := range r.repos {
+		if closer, ok := s.(interface{ Close() error }); ok {
+			errs = append(errs, closer.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
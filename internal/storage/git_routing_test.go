@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/go-git/go-git/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoutedGitStorage(t *testing.T) {
+	remotes := map[string]string{}
+	for _, name := range []string{"shared", "payments"} {
+		remotes[name] = filepath.Join(t.TempDir(), name+".git")
+		_, err := git.PlainInit(remotes[name], true)
+		require.NoError(t, err)
+	}
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	ctx := context.Background()
+
+	opened := map[string]*GitStorage{}
+	r, err := NewRoutedGitStorage(remotes["shared"], map[string]string{"2": remotes["payments"]}, func(repoURL string) (Storage, error) {
+		g, err := NewGitStorage(repoURL, "main", nil, GitCloneOptions{}, logger)
+		opened[repoURL] = g
+		return g, err
+	}, logger)
+	require.NoError(t, err)
+	t.Cleanup(func() { r.Close() })
+	require.Len(t, opened, 2)
+	shared, payments := opened[remotes["shared"]], opened[remotes["payments"]]
+
+	require.NoError(t, r.SetVersions(ctx, map[string]*models.AppVersion{
+		"1-api":     {Current: "1.0.0", ProjectID: "1", AppName: "api"},
+		"2-billing": {Current: "2.0.0", ProjectID: "2", AppName: "billing"},
+	}))
+
+	// Each project lands in its own repository
+	version, err := shared.GetVersion(ctx, "2-billing")
+	require.NoError(t, err)
+	assert.Nil(t, version)
+	version, err = payments.GetVersion(ctx, "2-billing")
+	require.NoError(t, err)
+	assert.Equal(t, "2.0.0", version.Current)
+
+	// A copy left in the default repository before the project moved is
+	// ignored
+	require.NoError(t, shared.SetVersion(ctx, "2-legacy", &models.AppVersion{Current: "0.1.0", ProjectID: "2", AppName: "legacy"}))
+	versions, err := r.ListVersions(ctx)
+	require.NoError(t, err)
+	assert.Len(t, versions, 2)
+
+	byProject, err := r.ListVersionsByProject(ctx, "2")
+	require.NoError(t, err)
+	assert.Contains(t, byProject, "2-billing")
+
+	// Renames across repositories move the record
+	require.NoError(t, r.RenameVersion(ctx, "1-api", "2-api", &models.AppVersion{Current: "1.0.0", ProjectID: "2", AppName: "api", RenamedFrom: []string{"1-api"}}))
+	version, err = shared.GetVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Nil(t, version)
+	version, err = r.GetVersion(ctx, "2-api")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", version.Current)
+
+	assert.NoError(t, r.Health(ctx))
+	assert.NoError(t, r.PushPendingCommits(ctx))
+
+	history, err := r.GetVersionHistory(ctx, "2-billing")
+	require.NoError(t, err)
+	assert.Len(t, history, 1)
+}
@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/sirupsen/logrus"
+)
+
+// versionTagName returns the tag marking the commit that wrote version of
+// appID
+func versionTagName(appID, version string) string {
+	return appID + "/" + version
+}
+
+// tagVersions tags HEAD with every version in versions that isn't tagged
+// yet, so versions written again by a rollback keep the tag of their first
+// write. A tag that can't be created is only logged: the write itself has
+// been committed.
+func (g *GitStorage) tagVersions(versions map[string]*models.AppVersion) {
+	if !g.TagIncrements {
+		return
+	}
+
+	head, err := g.repo.Head()
+	if err != nil {
+		g.logger.WithError(err).Warn("Failed to get HEAD for version tags")
+		return
+	}
+
+	for _, appID := range sortedKeys(versions) {
+		version := versions[appID]
+		if version == nil {
+			continue
+		}
+		name := versionTagName(appID, version.Current)
+		if _, err := g.repo.Tag(name); err == nil {
+			continue
+		}
+
+		_, err := g.repo.CreateTag(name, head.Hash(), &git.CreateTagOptions{
+			Tagger: &object.Signature{
+				Name:  "Version Service",
+				Email: "version-service@company.com",
+				When:  time.Now(),
+			},
+			Message: fmt.Sprintf("%s %s", appID, version.Current),
+		})
+		if err != nil {
+			g.logger.WithError(err).WithField("tag", name).Warn("Failed to create version tag")
+			continue
+		}
+		if g.pendingTags == nil {
+			g.pendingTags = make(map[string]struct{})
+		}
+		g.pendingTags[name] = struct{}{}
+	}
+}
+
+// retag moves the unpushed tags of commit from to commit to, after a rebase
+// replaced it
+func (g *GitStorage) retag(from, to plumbing.Hash) error {
+	for name := range g.pendingTags {
+		ref, err := g.repo.Tag(name)
+		if err != nil {
+			delete(g.pendingTags, name)
+			continue
+		}
+		tag, err := g.repo.TagObject(ref.Hash())
+		if err != nil || tag.Target != from {
+			continue
+		}
+
+		if err := g.repo.DeleteTag(name); err != nil {
+			return fmt.Errorf("failed to move tag %s: %w", name, err)
+		}
+		_, err = g.repo.CreateTag(name, to, &git.CreateTagOptions{
+			Tagger:  &tag.Tagger,
+			Message: tag.Message,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to move tag %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// pushTags pushes the tags created since the last push. Tags another
+// replica pushed first are left as they are on the remote.
+func (g *GitStorage) pushTags(ctx context.Context) error {
+	if len(g.pendingTags) == 0 {
+		return nil
+	}
+
+	remote, err := g.repo.Remote("origin")
+	if err != nil {
+		return fmt.Errorf("failed to get remote: %w", err)
+	}
+	refs, err := remote.ListContext(ctx, &git.ListOptions{Auth: g.auth})
+	if err != nil {
+		return fmt.Errorf("failed to list remote refs: %w", err)
+	}
+	onRemote := make(map[plumbing.ReferenceName]plumbing.Hash)
+	for _, ref := range refs {
+		if ref.Name().IsTag() {
+			onRemote[ref.Name()] = ref.Hash()
+		}
+	}
+
+	var refSpecs []config.RefSpec
+	for _, name := range sortedKeys(g.pendingTags) {
+		refName := plumbing.NewTagReferenceName(name)
+		local, err := g.repo.Reference(refName, false)
+		if err != nil {
+			delete(g.pendingTags, name)
+			continue
+		}
+		if hash, ok := onRemote[refName]; ok {
+			if hash != local.Hash() {
+				g.logger.WithField("tag", name).Warn("Version tag already exists on the remote, keeping the remote tag")
+			}
+			delete(g.pendingTags, name)
+			continue
+		}
+		refSpecs = append(refSpecs, config.RefSpec(fmt.Sprintf("%s:%s", refName, refName)))
+	}
+	if len(refSpecs) == 0 {
+		return nil
+	}
+
+	err = g.repo.PushContext(ctx, &git.PushOptions{
+		Auth:       g.auth,
+		RemoteName: "origin",
+		RefSpecs:   refSpecs,
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("failed to push tags: %w", classifyGitError(err))
+	}
+
+	g.logger.WithField("count", len(refSpecs)).Debug("Version tags pushed")
+	for _, refSpec := range refSpecs {
+		delete(g.pendingTags, plumbing.ReferenceName(refSpec.Src()).Short())
+	}
+	return nil
+}
+
+// pushPendingTags pushes the tags created since the last push, logging a
+// failure; the tags are pushed again with the next write
+func (g *GitStorage) pushPendingTags(ctx context.Context) {
+	if err := g.pushTags(ctx); err != nil {
+		g.logger.WithError(err).WithFields(logrus.Fields{
+			"pending": len(g.pendingTags),
+		}).Warn("Failed to push version tags, will retry with the next push")
+	}
+}
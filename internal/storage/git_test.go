@@ -0,0 +1,453 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/pkg/breaker"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLegacyRemote returns a bare repository whose main branch holds vf as a
+// single versions.json, as written by earlier releases
+func newLegacyRemote(t *testing.T, vf *models.VersionsFile) string {
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	_, err := git.PlainInit(remote, true)
+	require.NoError(t, err)
+
+	local := t.TempDir()
+	repo, err := git.PlainInit(local, false)
+	require.NoError(t, err)
+	data, err := json.MarshalIndent(vf, "", "  ")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(local, versionsFileName), data, 0644))
+
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+	_, err = w.Add(versionsFileName)
+	require.NoError(t, err)
+	_, err = w.Commit("Seed", &git.CommitOptions{Author: &object.Signature{Name: "test", When: time.Now()}})
+	require.NoError(t, err)
+
+	_, err = repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{remote}})
+	require.NoError(t, err)
+	require.NoError(t, repo.Push(&git.PushOptions{
+		RemoteName: "origin",
+		RefSpecs:   []config.RefSpec{"refs/heads/master:refs/heads/main"},
+	}))
+	return remote
+}
+
+func newTestGitStorage(t *testing.T, remote string) *GitStorage {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	g, err := NewGitStorage(remote, "main", nil, GitCloneOptions{}, logger)
+	require.NoError(t, err)
+	t.Cleanup(func() { g.Close() })
+	return g
+}
+
+func TestGitStorage_MigratesToPerAppFiles(t *testing.T) {
+	remote := newLegacyRemote(t, &models.VersionsFile{Versions: map[string]*models.AppVersion{
+		"1-api": {Current: "1.0.0", ProjectID: "1", AppName: "api"},
+		"2-web": {Current: "2.0.0"},
+	}})
+	g := newTestGitStorage(t, remote)
+	ctx := context.Background()
+
+	// Legacy repositories are readable before they are migrated
+	version, err := g.GetVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", version.Current)
+
+	require.NoError(t, g.SetVersion(ctx, "1-api", &models.AppVersion{Current: "1.1.0", ProjectID: "1", AppName: "api"}))
+
+	_, err = os.Stat(filepath.Join(g.localDir, versionsFileName))
+	assert.True(t, os.IsNotExist(err), "versions.json is removed")
+	for _, name := range []string{versionsIndexName, "versions/1/api.json", "versions/2/web.json"} {
+		_, err := os.Stat(filepath.Join(g.localDir, filepath.FromSlash(name)))
+		assert.NoError(t, err, name)
+	}
+
+	// A fresh clone reads the migrated layout
+	other := newTestGitStorage(t, remote)
+	versions, err := other.ListVersions(ctx)
+	require.NoError(t, err)
+	assert.Len(t, versions, 2)
+	assert.Equal(t, "1.1.0", versions["1-api"].Current)
+
+	history, err := other.GetVersionHistory(ctx, "1-api")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, "1.0.0", history[0].Version)
+	assert.Equal(t, "1.1.0", history[1].Version)
+
+	raw, _, err := other.ReadVersionsFile(ctx)
+	require.NoError(t, err)
+	var vf models.VersionsFile
+	require.NoError(t, json.Unmarshal(raw, &vf))
+	assert.Len(t, vf.Versions, 2)
+}
+
+func TestGitStorage_PerAppWrites(t *testing.T) {
+	remote := newLegacyRemote(t, &models.VersionsFile{Versions: map[string]*models.AppVersion{}})
+	g := newTestGitStorage(t, remote)
+	ctx := context.Background()
+
+	require.NoError(t, g.SetVersions(ctx, map[string]*models.AppVersion{
+		"1-api": {Current: "1.0.0", ProjectID: "1", AppName: "api"},
+		"1-web": {Current: "1.0.0", ProjectID: "1", AppName: "web"},
+	}))
+	require.NoError(t, g.RenameVersion(ctx, "1-web", "1-site", &models.AppVersion{Current: "1.0.0", ProjectID: "1", AppName: "site", RenamedFrom: []string{"1-web"}}))
+	require.NoError(t, g.SetVersion(ctx, "1-site", &models.AppVersion{Current: "1.0.1", ProjectID: "1", AppName: "site", RenamedFrom: []string{"1-web"}}))
+	require.NoError(t, g.DeleteVersion(ctx, "1-api"))
+
+	idx, ok, err := readIndex(g.readWorktreeFile)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, map[string]string{"1-site": "versions/1/site.json"}, idx.Apps)
+
+	history, err := g.GetVersionHistory(ctx, "1-site")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, "1.0.0", history[0].Version)
+}
+
+func TestAppFilePath(t *testing.T) {
+	assert.Equal(t, "versions/1/api.json", appFilePath("1-api", &models.AppVersion{}))
+	assert.Equal(t, "versions/group%2Fsub/api.json", appFilePath("x", &models.AppVersion{ProjectID: "group/sub", AppName: "api"}))
+	assert.Equal(t, "versions/%2E%2E/api.json", appFilePath("x", &models.AppVersion{ProjectID: "..", AppName: "api"}))
+	assert.Equal(t, "versions/_/noproject.json", appFilePath("noproject", &models.AppVersion{}))
+}
+
+func TestGitStorage_ShallowSparseClone(t *testing.T) {
+	remote := newLegacyRemote(t, &models.VersionsFile{Versions: map[string]*models.AppVersion{
+		"1-api": {Current: "1.0.0", ProjectID: "1", AppName: "api"},
+	}})
+	ctx := context.Background()
+
+	// Give the remote some history and a file outside the versions files
+	writer := newTestGitStorage(t, remote)
+	for _, version := range []string{"1.1.0", "1.2.0", "1.3.0"} {
+		require.NoError(t, writer.SetVersion(ctx, "1-api", &models.AppVersion{Current: version, ProjectID: "1", AppName: "api"}))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(writer.localDir, "README.md"), []byte("versions"), 0644))
+	require.NoError(t, writer.commitAndPush(ctx, "Add README"))
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	g, err := NewGitStorage(remote, "main", nil, GitCloneOptions{Depth: 2, SparseCheckout: true}, logger)
+	require.NoError(t, err)
+	t.Cleanup(func() { g.Close() })
+
+	assert.True(t, g.isShallow())
+	_, err = os.Stat(filepath.Join(g.localDir, "README.md"))
+	assert.True(t, os.IsNotExist(err), "README.md is not checked out")
+
+	version, err := g.GetVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Equal(t, "1.3.0", version.Current)
+
+	// History stops at the oldest commit kept
+	history, err := g.GetVersionHistory(ctx, "1-api")
+	require.NoError(t, err)
+	assert.NotEmpty(t, history)
+	assert.Less(t, len(history), 4)
+
+	require.NoError(t, g.SetVersion(ctx, "1-api", &models.AppVersion{Current: "1.4.0", ProjectID: "1", AppName: "api"}))
+
+	// Files left out of the checkout are kept by commits
+	full := newTestGitStorage(t, remote)
+	_, err = os.Stat(filepath.Join(full.localDir, "README.md"))
+	assert.NoError(t, err)
+	version, err = full.GetVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Equal(t, "1.4.0", version.Current)
+
+	oldDir := g.localDir
+	require.NoError(t, g.Reshallow(ctx))
+	assert.NotEqual(t, oldDir, g.localDir)
+	_, err = os.Stat(oldDir)
+	assert.True(t, os.IsNotExist(err), "previous clone is removed")
+	version, err = g.GetVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Equal(t, "1.4.0", version.Current)
+}
+
+func TestGitStorage_RebasesOnRejectedPush(t *testing.T) {
+	remote := newLegacyRemote(t, &models.VersionsFile{Versions: map[string]*models.AppVersion{
+		"1-api": {Current: "1.0.0", ProjectID: "1", AppName: "api"},
+		"2-web": {Current: "2.0.0", ProjectID: "2", AppName: "web"},
+	}})
+	a := newTestGitStorage(t, remote)
+	b := newTestGitStorage(t, remote)
+	ctx := context.Background()
+
+	// b commits while a pushes, so b's push is rejected
+	require.NoError(t, b.migrateLayout(ctx))
+	require.NoError(t, b.writeVersions(map[string]*models.AppVersion{"2-web": {Current: "2.1.0", ProjectID: "2", AppName: "web"}}))
+	require.NoError(t, b.commit(ctx, "web"))
+	require.NoError(t, b.writeVersions(map[string]*models.AppVersion{"1-api": {Current: "1.0.1", ProjectID: "1", AppName: "api"}}))
+	require.NoError(t, b.commit(ctx, "api"))
+
+	require.NoError(t, a.SetVersion(ctx, "1-api", &models.AppVersion{Current: "1.1.0", ProjectID: "1", AppName: "api"}))
+	require.NoError(t, b.pushWithRebase(ctx))
+
+	fresh := newTestGitStorage(t, remote)
+	versions, err := fresh.ListVersions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.1", versions["1-api"].Current, "the rebased write wins")
+	assert.Equal(t, "2.1.0", versions["2-web"].Current)
+
+	history, err := fresh.GetVersionHistory(ctx, "1-api")
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Equal(t, "1.1.0", history[1].Version)
+	assert.Equal(t, "1.0.1", history[2].Version)
+
+	// a's next pull rebases its unpushed commit instead of dropping it
+	require.NoError(t, a.writeVersions(map[string]*models.AppVersion{"3-cli": {Current: "3.0.0", ProjectID: "3", AppName: "cli"}}))
+	require.NoError(t, a.commit(ctx, "cli"))
+	require.NoError(t, fresh.SetVersion(ctx, "2-web", &models.AppVersion{Current: "2.2.0", ProjectID: "2", AppName: "web"}))
+
+	versions, err = a.ListVersions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "2.2.0", versions["2-web"].Current)
+	assert.Equal(t, "3.0.0", versions["3-cli"].Current)
+
+	require.NoError(t, a.PushPendingCommits(ctx))
+	versions, err = fresh.ListVersions(ctx)
+	require.NoError(t, err)
+	assert.Len(t, versions, 3)
+	assert.Equal(t, "3.0.0", versions["3-cli"].Current)
+}
+
+func TestGitStorage_TagsIncrements(t *testing.T) {
+	remote := newLegacyRemote(t, &models.VersionsFile{Versions: map[string]*models.AppVersion{
+		"1-api": {Current: "1.0.0", ProjectID: "1", AppName: "api"},
+	}})
+	g := newTestGitStorage(t, remote)
+	g.TagIncrements = true
+	ctx := context.Background()
+
+	require.NoError(t, g.SetVersion(ctx, "1-api", &models.AppVersion{Current: "1.1.0", ProjectID: "1", AppName: "api"}))
+	first, err := g.repo.Head()
+	require.NoError(t, err)
+	require.NoError(t, g.SetVersions(ctx, map[string]*models.AppVersion{
+		"1-api": {Current: "1.2.0", ProjectID: "1", AppName: "api"},
+		"2-web": {Current: "2.0.0", ProjectID: "2", AppName: "web"},
+	}))
+	// A rollback keeps the tag of the first write
+	require.NoError(t, g.SetVersion(ctx, "1-api", &models.AppVersion{Current: "1.1.0", ProjectID: "1", AppName: "api"}))
+	assert.Empty(t, g.pendingTags)
+
+	remoteRepo, err := git.PlainOpen(remote)
+	require.NoError(t, err)
+	for _, name := range []string{"1-api/1.1.0", "1-api/1.2.0", "2-web/2.0.0"} {
+		ref, err := remoteRepo.Tag(name)
+		require.NoError(t, err, name)
+		tag, err := remoteRepo.TagObject(ref.Hash())
+		require.NoError(t, err, name)
+		if name == "1-api/1.1.0" {
+			assert.Equal(t, first.Hash(), tag.Target)
+		}
+	}
+
+	// Tags of rebased commits move to the commits replacing them
+	other := newTestGitStorage(t, remote)
+	other.TagIncrements = true
+	version := map[string]*models.AppVersion{"3-cli": {Current: "3.0.0", ProjectID: "3", AppName: "cli"}}
+	require.NoError(t, other.writeVersions(version))
+	require.NoError(t, other.commit(ctx, "cli"))
+	other.tagVersions(version)
+	require.NoError(t, g.SetVersion(ctx, "2-web", &models.AppVersion{Current: "2.1.0", ProjectID: "2", AppName: "web"}))
+	require.NoError(t, other.pushWithRebase(ctx))
+
+	ref, err := remoteRepo.Tag("3-cli/3.0.0")
+	require.NoError(t, err)
+	tag, err := remoteRepo.TagObject(ref.Hash())
+	require.NoError(t, err)
+	branch, err := remoteRepo.Reference("refs/heads/main", true)
+	require.NoError(t, err)
+	assert.Equal(t, branch.Hash(), tag.Target)
+}
+
+func TestGitStorage_Maintain(t *testing.T) {
+	remote := newLegacyRemote(t, &models.VersionsFile{Versions: map[string]*models.AppVersion{
+		"1-api": {Current: "1.0.0", ProjectID: "1", AppName: "api"},
+	}})
+	g := newTestGitStorage(t, remote)
+	ctx := context.Background()
+
+	require.NoError(t, g.SetVersion(ctx, "1-api", &models.AppVersion{Current: "1.1.0", ProjectID: "1", AppName: "api"}))
+	require.NoError(t, g.Maintain(ctx))
+	dir := g.localDir
+
+	// Over the size cap, the clone is replaced
+	g.cloneOpts.MaxSize = 1
+	require.NoError(t, g.Maintain(ctx))
+	assert.NotEqual(t, dir, g.localDir)
+	_, err := os.Stat(dir)
+	assert.True(t, os.IsNotExist(err), "the old clone is removed")
+	g.cloneOpts.MaxSize = 0
+
+	// A clone missing objects is replaced
+	packs, err := filepath.Glob(filepath.Join(g.localDir, ".git", "objects", "pack", "*"))
+	require.NoError(t, err)
+	require.NotEmpty(t, packs)
+	for _, pack := range packs {
+		require.NoError(t, os.Remove(pack))
+	}
+	dir = g.localDir
+	require.NoError(t, g.Maintain(ctx))
+	assert.NotEqual(t, dir, g.localDir)
+
+	version, err := g.GetVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", version.Current)
+}
+
+func TestGitStorage_HealthWithoutLock(t *testing.T) {
+	remote := newLegacyRemote(t, &models.VersionsFile{Versions: map[string]*models.AppVersion{}})
+	g := newTestGitStorage(t, remote)
+
+	// Probes answer while a write holds the lock
+	require.NoError(t, g.acquire(context.Background()))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, g.Health(ctx))
+	g.release()
+
+	// Results are reused for a while, then the remote is checked again
+	require.NoError(t, os.RemoveAll(remote))
+	assert.NoError(t, g.Health(context.Background()))
+	g.health.checkedAt = time.Now().Add(-gitHealthInterval)
+	assert.Error(t, g.Health(context.Background()))
+}
+
+func TestGitStorage_RecoversOrphanedClone(t *testing.T) {
+	remote := newLegacyRemote(t, &models.VersionsFile{Versions: map[string]*models.AppVersion{
+		"1-api": {Current: "1.0.0", ProjectID: "1", AppName: "api"},
+	}})
+	ctx := context.Background()
+
+	// A process crashes between committing and pushing
+	crashed := newTestGitStorage(t, remote)
+	require.NoError(t, crashed.migrateLayout(ctx))
+	require.NoError(t, crashed.writeVersions(map[string]*models.AppVersion{"1-api": {Current: "1.1.0", ProjectID: "1", AppName: "api"}}))
+	require.NoError(t, crashed.commit(ctx, "api"))
+	orphan := crashed.localDir
+
+	// A live clone of the same branch is left alone
+	live := newTestGitStorage(t, remote)
+	assert.DirExists(t, orphan)
+	assert.DirExists(t, live.localDir)
+
+	require.NoError(t, crashed.cloneLocks[orphan].Close())
+	g := newTestGitStorage(t, remote)
+	assert.NoDirExists(t, orphan)
+	assert.NoFileExists(t, orphan+cloneLockSuffix)
+	assert.DirExists(t, live.localDir)
+
+	fresh := newTestGitStorage(t, remote)
+	version, err := fresh.GetVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", version.Current)
+
+	history, err := g.GetVersionHistory(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Len(t, history, 2)
+}
+
+func TestGitStorage_FallsBackToMirror(t *testing.T) {
+	remote := newLegacyRemote(t, &models.VersionsFile{Versions: map[string]*models.AppVersion{
+		"1-api": {Current: "1.0.0", ProjectID: "1", AppName: "api"},
+	}})
+	mirror := filepath.Join(t.TempDir(), "mirror.git")
+	syncMirror := func() {
+		require.NoError(t, os.RemoveAll(mirror))
+		require.NoError(t, os.CopyFS(mirror, os.DirFS(remote)))
+	}
+	down := remote + ".down"
+	ctx := context.Background()
+	syncMirror()
+
+	// The primary is down at startup
+	require.NoError(t, os.Rename(remote, down))
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	g, err := NewGitStorage(remote, "main", nil, GitCloneOptions{MirrorURL: mirror}, logger)
+	require.NoError(t, err)
+	t.Cleanup(func() { g.Close() })
+
+	version, err := g.GetVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", version.Current)
+	assert.ErrorIs(t, g.Health(ctx), ErrServingFromMirror)
+
+	// Changes reach the mirror while the primary is down
+	require.NoError(t, os.Rename(down, remote))
+	writer := newTestGitStorage(t, remote)
+	require.NoError(t, writer.SetVersion(ctx, "1-api", &models.AppVersion{Current: "1.1.0", ProjectID: "1", AppName: "api"}))
+	syncMirror()
+	require.NoError(t, os.Rename(remote, down))
+
+	version, err = g.GetVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", version.Current)
+
+	// Writes stay local until the primary is back
+	assert.Error(t, g.SetVersion(ctx, "1-api", &models.AppVersion{Current: "1.2.0", ProjectID: "1", AppName: "api"}))
+	version, err = g.GetVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.0", version.Current)
+
+	require.NoError(t, os.Rename(down, remote))
+	require.NoError(t, g.PushPendingCommits(ctx))
+	g.health.checkedAt = time.Time{}
+	assert.NoError(t, g.Health(ctx))
+
+	version, err = newTestGitStorage(t, remote).GetVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.0", version.Current)
+}
+
+func TestGitStorage_OpenBreakerSkipsRemote(t *testing.T) {
+	remote := newLegacyRemote(t, &models.VersionsFile{Versions: map[string]*models.AppVersion{
+		"1-api": {Current: "1.0.0", ProjectID: "1", AppName: "api"},
+	}})
+	ctx := context.Background()
+	g := newTestGitStorage(t, remote)
+	g.Breaker = breaker.New("git", 1, time.Hour)
+	g.Breaker.Failure()
+
+	// Writes are committed locally without trying the remote
+	err := g.SetVersion(ctx, "1-api", &models.AppVersion{Current: "1.1.0", ProjectID: "1", AppName: "api"})
+	assert.ErrorIs(t, err, ErrPushRejected)
+	assert.ErrorIs(t, err, breaker.ErrOpen)
+	version, err := g.GetVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", version.Current)
+
+	version, err = newTestGitStorage(t, remote).GetVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", version.Current)
+
+	// Once the breaker closes, the background push delivers them
+	g.Breaker.Success()
+	require.NoError(t, g.PushPendingCommits(ctx))
+	version, err = newTestGitStorage(t, remote).GetVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", version.Current)
+}
@@ -2,14 +2,32 @@ package storage
 
 import (
 	"context"
+	"errors"
+	"time"
+
 	"github.com/company/version-service/internal/models"
 )
 
+// ErrRevisionMismatch is returned when a conditional write expected a
+// different revision than the one currently stored
+var ErrRevisionMismatch = errors.New("revision mismatch")
+
+// ErrAlreadyBootstrapped is returned when bootstrapping a store that already
+// holds data
+var ErrAlreadyBootstrapped = errors.New("storage already bootstrapped")
+
+// ErrWatchUnsupported is returned by Watch when the storage behind it cannot
+// be watched
+var ErrWatchUnsupported = errors.New("storage cannot be watched")
+
 type Storage interface {
 	GetVersion(ctx context.Context, appID string) (*models.AppVersion, error)
 	SetVersion(ctx context.Context, appID string, version *models.AppVersion) error
 	ListVersions(ctx context.Context) (map[string]*models.AppVersion, error)
 	ListVersionsByProject(ctx context.Context, projectID string) (map[string]*models.AppVersion, error)
+	// ListVersionsPage returns up to limit apps matching filter in app ID
+	// order, starting after cursor; an empty cursor starts at the beginning
+	ListVersionsPage(ctx context.Context, cursor string, limit int, filter models.VersionFilter) (*models.VersionPage, error)
 	DeleteVersion(ctx context.Context, appID string) error
 	Health(ctx context.Context) error
 	RebuildCache(ctx context.Context, versions map[string]*models.AppVersion) error
@@ -18,4 +36,179 @@ type Storage interface {
 // GitStorage specific interface for push operations
 type GitPushable interface {
 	PushPendingCommits(ctx context.Context) error
-}
\ No newline at end of file
+}
+
+// BatchWriter is implemented by storage backends that can persist several
+// versions as one atomic change
+type BatchWriter interface {
+	SetVersions(ctx context.Context, versions map[string]*models.AppVersion) error
+}
+
+// Renamer is implemented by storage backends that can move an app's record
+// to a new ID as one atomic change
+type Renamer interface {
+	RenameVersion(ctx context.Context, oldAppID, newAppID string, version *models.AppVersion) error
+}
+
+// ConditionalSetter is implemented by caches that can write an app's version
+// only while they still hold the record the write was based on, so replicas
+// sharing the cache can't overwrite each other's increments
+type ConditionalSetter interface {
+	// SetVersionIf writes version unless the stored record differs from
+	// expected in its current version or update time; no stored record
+	// matches too. It fails with ErrRevisionMismatch otherwise.
+	SetVersionIf(ctx context.Context, appID string, expected, version *models.AppVersion) error
+	// SetVersionsIf writes every version of a batch under the same condition,
+	// with expected keyed by app ID. Nothing is written when one app fails it.
+	SetVersionsIf(ctx context.Context, expected, versions map[string]*models.AppVersion) error
+}
+
+// sameRevision reports whether stored is the record expected, comparing the
+// current version and update time
+func sameRevision(stored, expected *models.AppVersion) bool {
+	if stored == nil || expected == nil {
+		return stored == nil && expected == nil
+	}
+	return stored.Current == expected.Current && stored.LastUpdated.Equal(expected.LastUpdated)
+}
+
+// Bootstrapper is implemented by durable storage backends that can be seeded
+// with their initial content in one step
+type Bootstrapper interface {
+	IsEmpty() bool
+	Bootstrap(ctx context.Context, vf *models.VersionsFile, message string) (string, error)
+}
+
+// HistoryProvider is implemented by storage backends that retain previously
+// recorded versions of each app
+type HistoryProvider interface {
+	GetVersionHistory(ctx context.Context, appID string) ([]models.VersionHistoryEntry, error)
+	GetPreviousVersion(ctx context.Context, appID, current string) (*models.AppVersion, string, error)
+}
+
+// RawFileStore exposes the serialized versions file for direct inspection and
+// replacement
+type RawFileStore interface {
+	ReadVersionsFile(ctx context.Context) ([]byte, string, error)
+	ReplaceVersionsFile(ctx context.Context, vf *models.VersionsFile, expectedRevision, message string) (string, error)
+}
+
+// Watcher is implemented by durable storage backends that report writes
+// made by other writers, such as other replicas sharing the backend. Watch
+// calls handle with each app changed until ctx ends or the watch fails;
+// version is nil for deletes. handle reports whether the change was taken
+// in, so later writes may build on it.
+type Watcher interface {
+	Watch(ctx context.Context, handle func(appID string, version *models.AppVersion) bool) error
+}
+
+// ChangeBroadcaster is implemented by caches shared between replicas that
+// can tell every replica about writes, so each can drop what it keeps in
+// process memory about the apps written
+type ChangeBroadcaster interface {
+	// PublishChange tells every subscriber, including the publisher's own,
+	// about change
+	PublishChange(ctx context.Context, change models.VersionChange) error
+	// SubscribeChanges calls handle with each change published until ctx
+	// ends or the subscription fails
+	SubscribeChanges(ctx context.Context, handle func(models.VersionChange)) error
+}
+
+// PoolStatter is implemented by storage talking to its server through a
+// connection pool
+type PoolStatter interface {
+	PoolStats() models.PoolStats
+}
+
+// UsageTracker records increment events so quotas and usage reports can be
+// evaluated over sliding windows
+type UsageTracker interface {
+	RecordIncrement(ctx context.Context, projectID, appID string, at time.Time) error
+	CountIncrements(ctx context.Context, projectID string, since time.Time) (int64, error)
+}
+
+// RateLimiter keeps token buckets for rate limits. Buckets kept in a cache
+// shared between replicas limit clients across all of them.
+type RateLimiter interface {
+	// TakeToken takes a token from the bucket at key, which holds up to
+	// burst tokens and refills at rate tokens per second, starting full.
+	// When the bucket is empty it reports false and how long until a token
+	// is available.
+	TakeToken(ctx context.Context, key string, rate float64, burst int, now time.Time) (bool, time.Duration, error)
+}
+
+// DevVersionTracker records issued dev versions so cleanup jobs can tell
+// which pre-release builds exist
+type DevVersionTracker interface {
+	RecordDevVersion(ctx context.Context, appID string, record *models.DevVersionRecord, retention time.Duration) error
+	ListDevVersions(ctx context.Context, appID string, since time.Time) ([]models.DevVersionRecord, error)
+}
+
+// DevBuildCounter numbers dev builds per app and branch. Numbers only grow,
+// so repeated builds of one commit get distinct, sortable versions.
+type DevBuildCounter interface {
+	NextDevBuild(ctx context.Context, appID, branch string) (int64, error)
+}
+
+// IdempotencyStore remembers the outcome of keyed requests so retries can be
+// answered without repeating the operation
+type IdempotencyStore interface {
+	GetIdempotentResult(ctx context.Context, scope, key string) (string, bool, error)
+	SetIdempotentResult(ctx context.Context, scope, key, result string, ttl time.Duration) error
+}
+
+// WebhookStore persists per-project webhook subscriptions
+type WebhookStore interface {
+	SaveWebhook(ctx context.Context, webhook *models.WebhookSubscription) error
+	ListWebhooks(ctx context.Context, projectID string) ([]models.WebhookSubscription, error)
+	// DeleteWebhook reports false when the project has no such subscription
+	DeleteWebhook(ctx context.Context, projectID, id string) (bool, error)
+	// ListWebhookProjects returns the projects with webhook subscriptions
+	ListWebhookProjects(ctx context.Context) ([]string, error)
+}
+
+// ReservedVersionStore persists the versions reserved for a whole project
+type ReservedVersionStore interface {
+	GetReservedVersions(ctx context.Context, projectID string) ([]string, error)
+	SetReservedVersions(ctx context.Context, projectID string, versions []string) error
+	// ListReservedProjects returns the projects with reserved versions
+	ListReservedProjects(ctx context.Context) ([]string, error)
+}
+
+// Journal is a durable, append-only log of version writes. It is the
+// secondary backend writes fail over to while Git is unhealthy, replayed
+// into Git once it recovers.
+type Journal interface {
+	Append(ctx context.Context, entries []models.JournalEntry) error
+	// Entries returns every entry in the order appended
+	Entries(ctx context.Context) ([]models.JournalEntry, error)
+	// Truncate removes the first n entries once they have been replayed,
+	// keeping entries appended since they were read
+	Truncate(ctx context.Context, n int) error
+	Health(ctx context.Context) error
+}
+
+// Outbox is a durable queue of outbound calls deferred in air-gapped mode,
+// made when an admin flushes it
+type Outbox interface {
+	// Append queues calls, assigning an ID to calls without one
+	Append(ctx context.Context, calls []models.DeferredCall) error
+	// Entries returns every queued call in the order appended
+	Entries(ctx context.Context) ([]models.DeferredCall, error)
+	// Update drops the calls with the removed IDs and replaces queued calls
+	// with the updated ones of the same ID. Calls appended since they were
+	// read are kept.
+	Update(ctx context.Context, removed []string, updated []models.DeferredCall) error
+	Health(ctx context.Context) error
+}
+
+// HistoryTransfer is implemented by storage backends whose version history
+// can be exported and replayed into an empty store
+type HistoryTransfer interface {
+	// ExportHistory returns the history of every app recorded in the store,
+	// oldest first, keyed by the app ID each entry was recorded under
+	ExportHistory(ctx context.Context) (map[string][]models.VersionHistoryEntry, error)
+	// ReplayHistory seeds an empty store with one commit per step, dated at
+	// the step's timestamp, followed by final
+	ReplayHistory(ctx context.Context, steps []models.HistoryStep, final *models.VersionsFile, message string) (string, error)
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// FileJournal is a Journal kept as a JSON lines file, e.g. on a persistent
+// volume or a mounted bucket. Every append is synced before it returns.
+type FileJournal struct {
+	path   string
+	logger *logrus.Logger
+	mu     sync.Mutex
+}
+
+// NewFileJournal opens the journal at path, creating the file and its
+// directory when missing
+func NewFileJournal(path string, logger *logrus.Logger) (*FileJournal, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create journal directory: %w", err)
+	}
+
+	j := &FileJournal{path: path, logger: logger}
+	if err := j.Health(context.Background()); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+func (j *FileJournal) Append(ctx context.Context, entries []models.JournalEntry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := appendJSONLines(j.path, entries); err != nil {
+		return fmt.Errorf("failed to append to journal: %w", err)
+	}
+	return nil
+}
+
+// Entries reads the journal. A torn last line, left by a crash during an
+// append, is skipped; that write was never acknowledged.
+func (j *FileJournal) Entries(ctx context.Context) ([]models.JournalEntry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entries, err := readJSONLines[models.JournalEntry](j.path, j.logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+	return entries, nil
+}
+
+// Truncate rewrites the journal without its first n entries. The rewrite
+// replaces the file atomically, so a crash leaves either journal whole.
+func (j *FileJournal) Truncate(ctx context.Context, n int) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entries, err := readJSONLines[models.JournalEntry](j.path, j.logger)
+	if err != nil {
+		return fmt.Errorf("failed to read journal: %w", err)
+	}
+	if n > len(entries) {
+		n = len(entries)
+	}
+	if err := writeJSONLines(j.path, entries[n:]); err != nil {
+		return fmt.Errorf("failed to truncate journal: %w", err)
+	}
+	return nil
+}
+
+// Health checks that the journal can be opened for appending
+func (j *FileJournal) Health(ctx context.Context) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	file, err := os.OpenFile(j.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("journal not writable: %w", err)
+	}
+	return file.Close()
+}
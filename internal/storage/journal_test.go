@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileJournal(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	path := filepath.Join(t.TempDir(), "journal", "journal.jsonl")
+	ctx := context.Background()
+
+	journal, err := NewFileJournal(path, logger)
+	require.NoError(t, err)
+	require.NoError(t, journal.Health(ctx))
+
+	entry := func(appID, version string) models.JournalEntry {
+		e := models.JournalEntry{AppID: appID, RecordedAt: time.Now()}
+		if version != "" {
+			e.Version = &models.AppVersion{Current: version}
+		}
+		return e
+	}
+	require.NoError(t, journal.Append(ctx, []models.JournalEntry{entry("1-api", "1.0.1"), entry("2-web", "")}))
+	require.NoError(t, journal.Append(ctx, []models.JournalEntry{entry("1-api", "1.0.2")}))
+
+	entries, err := journal.Entries(ctx)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "1.0.1", entries[0].Version.Current)
+	assert.Nil(t, entries[1].Version, "deletions are journaled without a version")
+	assert.Equal(t, "1.0.2", entries[2].Version.Current)
+
+	// A crash during an append leaves a torn line, which is skipped; the
+	// next append starts on a new line
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"app_id":"3-cli","ver`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	require.NoError(t, journal.Append(ctx, []models.JournalEntry{entry("4-db", "4.0.0")}))
+
+	entries, err = journal.Entries(ctx)
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+	assert.Equal(t, "4-db", entries[3].AppID)
+
+	// Truncating the replayed entries keeps those appended since
+	require.NoError(t, journal.Truncate(ctx, 2))
+	entries, err = journal.Entries(ctx)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "1.0.2", entries[0].Version.Current)
+	assert.Equal(t, "4-db", entries[1].AppID)
+
+	require.NoError(t, journal.Truncate(ctx, 10))
+	entries, err = journal.Entries(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+	require.NoError(t, journal.Health(ctx))
+}
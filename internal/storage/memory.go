@@ -0,0 +1,638 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/pkg/semver"
+	"github.com/sirupsen/logrus"
+)
+
+// MemoryOptions configures MemoryStorage
+type MemoryOptions struct {
+	// SnapshotPath, when set, is a versions file loaded on start and
+	// rewritten after every version write, so apps survive restarts.
+	// History and the other records are not snapshotted.
+	SnapshotPath string
+}
+
+// MemoryStorage keeps everything in process memory, for local development
+// and tests. Besides versions it implements the stores Redis provides
+// (usage, idempotency, dev versions, webhooks and reserved versions), so it
+// can stand in for both the cache and the durable store.
+type MemoryStorage struct {
+	snapshotPath string
+	logger       *logrus.Logger
+
+	mu sync.Mutex
+	// versions is replaced, never modified in place, on every write
+	versions map[string]*models.AppVersion
+	// history holds every version written per app ID, oldest first
+	history     map[string][]versionRecord
+	revision    int64
+	usage       map[string][]time.Time
+	idempotency map[string]memoryResult
+	devBuilds   map[string]int64
+	devCounters map[string]int64
+	devVersions map[string]map[string]models.DevVersionRecord
+	webhooks    map[string]map[string]models.WebhookSubscription
+	reserved    map[string][]string
+	rateBuckets map[string]tokenBucket
+	// subscribers are the handlers of SubscribeChanges calls in progress
+	subscribers map[int]func(models.VersionChange)
+	nextSub     int
+}
+
+// tokenBucket holds the tokens left in a rate limit bucket when it was last
+// taken from
+type tokenBucket struct {
+	tokens float64
+	at     time.Time
+}
+
+type memoryResult struct {
+	value   string
+	expires time.Time
+}
+
+// NewMemoryStorage returns an empty store, or one loaded from the snapshot
+// when it exists
+func NewMemoryStorage(opts MemoryOptions, logger *logrus.Logger) (*MemoryStorage, error) {
+	m := &MemoryStorage{
+		snapshotPath: opts.SnapshotPath,
+		logger:       logger,
+		versions:     make(map[string]*models.AppVersion),
+		history:      make(map[string][]versionRecord),
+		usage:        make(map[string][]time.Time),
+		idempotency:  make(map[string]memoryResult),
+		devBuilds:    make(map[string]int64),
+		devCounters:  make(map[string]int64),
+		devVersions:  make(map[string]map[string]models.DevVersionRecord),
+		webhooks:     make(map[string]map[string]models.WebhookSubscription),
+		reserved:     make(map[string][]string),
+		rateBuckets:  make(map[string]tokenBucket),
+		subscribers:  make(map[int]func(models.VersionChange)),
+	}
+
+	if m.snapshotPath == "" {
+		return m, nil
+	}
+
+	data, err := os.ReadFile(m.snapshotPath)
+	if errors.Is(err, os.ErrNotExist) {
+		if err := os.MkdirAll(filepath.Dir(m.snapshotPath), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+		}
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	var vf models.VersionsFile
+	if err := json.Unmarshal(data, &vf); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot: %w", err)
+	}
+	for appID, version := range vf.Versions {
+		m.versions[appID] = version
+		m.record(appID, version, vf.LastUpdated)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"path": m.snapshotPath,
+		"apps": len(m.versions),
+	}).Info("Memory storage loaded from snapshot")
+	return m, nil
+}
+
+// copyVersion returns a deep copy of version, so callers never share a
+// record with the store
+func copyVersion(version *models.AppVersion) *models.AppVersion {
+	data, err := json.Marshal(version)
+	if err != nil {
+		copied := *version
+		return &copied
+	}
+	var copied models.AppVersion
+	json.Unmarshal(data, &copied)
+	return &copied
+}
+
+func copyVersions(versions map[string]*models.AppVersion) map[string]*models.AppVersion {
+	copied := make(map[string]*models.AppVersion, len(versions))
+	for appID, version := range versions {
+		copied[appID] = copyVersion(version)
+	}
+	return copied
+}
+
+// record appends version to appID's history. Callers hold mu.
+func (m *MemoryStorage) record(appID string, version *models.AppVersion, when time.Time) {
+	m.revision++
+	m.history[appID] = append(m.history[appID], versionRecord{
+		version: version,
+		commit:  strconv.FormatInt(m.revision, 10),
+		when:    when,
+	})
+}
+
+// apply writes the given versions, nil deleting an app, to the snapshot
+// and then to memory. Nothing changes when the snapshot fails. Callers
+// hold mu.
+func (m *MemoryStorage) apply(changes map[string]*models.AppVersion) error {
+	next := maps.Clone(m.versions)
+	for appID, version := range changes {
+		if version == nil {
+			delete(next, appID)
+		} else {
+			next[appID] = copyVersion(version)
+		}
+	}
+
+	now := time.Now()
+	if err := m.snapshot(next, now); err != nil {
+		return err
+	}
+
+	m.versions = next
+	for appID, version := range changes {
+		if version != nil {
+			m.record(appID, next[appID], now)
+		}
+	}
+	return nil
+}
+
+// snapshot rewrites the snapshot file with versions. The file is replaced
+// by a rename so a crash never leaves it half written.
+func (m *MemoryStorage) snapshot(versions map[string]*models.AppVersion, now time.Time) error {
+	if m.snapshotPath == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(models.VersionsFile{Versions: versions, LastUpdated: now}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+
+	tmp := m.snapshotPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp, m.snapshotPath); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+	return nil
+}
+
+func (m *MemoryStorage) GetVersion(ctx context.Context, appID string) (*models.AppVersion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	version, ok := m.versions[appID]
+	if !ok {
+		return nil, nil
+	}
+	return copyVersion(version), nil
+}
+
+func (m *MemoryStorage) SetVersion(ctx context.Context, appID string, version *models.AppVersion) error {
+	return m.SetVersions(ctx, map[string]*models.AppVersion{appID: version})
+}
+
+// SetVersions writes several app versions at once
+func (m *MemoryStorage) SetVersions(ctx context.Context, versions map[string]*models.AppVersion) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.apply(versions)
+}
+
+// SetVersionIf writes version unless the stored record is no longer expected
+func (m *MemoryStorage) SetVersionIf(ctx context.Context, appID string, expected, version *models.AppVersion) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if stored := m.versions[appID]; stored != nil && !sameRevision(stored, expected) {
+		return fmt.Errorf("%w: %s is at %s", ErrRevisionMismatch, appID, stored.Current)
+	}
+	return m.apply(map[string]*models.AppVersion{appID: version})
+}
+
+// SetVersionsIf writes every version unless one of the stored records is no
+// longer expected, in which case nothing is written
+func (m *MemoryStorage) SetVersionsIf(ctx context.Context, expected, versions map[string]*models.AppVersion) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for appID := range versions {
+		if stored := m.versions[appID]; stored != nil && !sameRevision(stored, expected[appID]) {
+			return fmt.Errorf("%w: %s is at %s", ErrRevisionMismatch, appID, stored.Current)
+		}
+	}
+	return m.apply(versions)
+}
+
+func (m *MemoryStorage) ListVersions(ctx context.Context) (map[string]*models.AppVersion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return copyVersions(m.versions), nil
+}
+
+func (m *MemoryStorage) ListVersionsPage(ctx context.Context, cursor string, limit int, filter models.VersionFilter) (*models.VersionPage, error) {
+	versions, err := m.ListVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pageVersions(versions, cursor, limit, filter)
+}
+
+func (m *MemoryStorage) ListVersionsByProject(ctx context.Context, projectID string) (map[string]*models.AppVersion, error) {
+	allVersions, err := m.ListVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	projectVersions := make(map[string]*models.AppVersion)
+	for appID, version := range allVersions {
+		if models.InProject(appID, version, projectID) {
+			projectVersions[appID] = version
+		}
+	}
+	return projectVersions, nil
+}
+
+func (m *MemoryStorage) DeleteVersion(ctx context.Context, appID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.apply(map[string]*models.AppVersion{appID: nil})
+}
+
+// RenameVersion stores version under newAppID and drops oldAppID at once
+func (m *MemoryStorage) RenameVersion(ctx context.Context, oldAppID, newAppID string, version *models.AppVersion) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.apply(map[string]*models.AppVersion{oldAppID: nil, newAppID: version})
+}
+
+func (m *MemoryStorage) Health(ctx context.Context) error {
+	return nil
+}
+
+// RebuildCache replaces every stored version, as when the store is the
+// cache in front of another backend
+func (m *MemoryStorage) RebuildCache(ctx context.Context, versions map[string]*models.AppVersion) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	changes := make(map[string]*models.AppVersion, len(m.versions)+len(versions))
+	for appID := range m.versions {
+		changes[appID] = nil
+	}
+	maps.Copy(changes, versions)
+	return m.apply(changes)
+}
+
+// IsEmpty reports whether no app has been stored yet
+func (m *MemoryStorage) IsEmpty() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.versions) == 0
+}
+
+// Bootstrap stores the initial versions and returns the last history entry
+// written as the revision. It fails with ErrAlreadyBootstrapped when any
+// app is stored.
+func (m *MemoryStorage) Bootstrap(ctx context.Context, vf *models.VersionsFile, message string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.versions) > 0 {
+		return "", ErrAlreadyBootstrapped
+	}
+	if err := m.apply(vf.Versions); err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(m.revision, 10), nil
+}
+
+// appHistory returns the distinct versions recorded for appID, newest first,
+// each pointing at the history entry that introduced it. Entries from before
+// a rename are read under the app's former IDs.
+func (m *MemoryStorage) appHistory(appID string) []versionRecord {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	lineage := []string{appID}
+	if current, ok := m.versions[appID]; ok {
+		lineage = append(lineage, current.RenamedFrom...)
+	}
+	var entries []versionRecord
+	for _, id := range lineage {
+		entries = append(entries, m.history[id]...)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, _ := strconv.ParseInt(entries[i].commit, 10, 64)
+		b, _ := strconv.ParseInt(entries[j].commit, 10, 64)
+		return a > b
+	})
+
+	var records []versionRecord
+	for _, entry := range entries {
+		// An unchanged version moves back to the entry that introduced it
+		if n := len(records); n > 0 && records[n-1].version.Current == entry.version.Current {
+			records[n-1] = entry
+			continue
+		}
+		records = append(records, entry)
+	}
+	return records
+}
+
+// GetPreviousVersion returns the most recent version recorded for appID that
+// sorts below current, along with the history entry that recorded it, or nil
+// when no earlier version exists
+func (m *MemoryStorage) GetPreviousVersion(ctx context.Context, appID, current string) (*models.AppVersion, string, error) {
+	for _, record := range m.appHistory(appID) {
+		cmp, err := semver.Compare(record.version.Current, current)
+		if err != nil {
+			// Unparseable versions can't be ordered; fall back to any change
+			if record.version.Current != current {
+				return copyVersion(record.version), record.commit, nil
+			}
+			continue
+		}
+		if cmp < 0 {
+			return copyVersion(record.version), record.commit, nil
+		}
+	}
+	return nil, "", nil
+}
+
+// GetVersionHistory returns the versions recorded for appID, oldest first.
+// Commit holds the number of the history entry.
+func (m *MemoryStorage) GetVersionHistory(ctx context.Context, appID string) ([]models.VersionHistoryEntry, error) {
+	records := m.appHistory(appID)
+
+	history := make([]models.VersionHistoryEntry, 0, len(records))
+	for i := len(records) - 1; i >= 0; i-- {
+		history = append(history, models.VersionHistoryEntry{
+			Version:   records[i].version.Current,
+			Commit:    records[i].commit,
+			Timestamp: records[i].when,
+		})
+	}
+	return history, nil
+}
+
+func (m *MemoryStorage) RecordIncrement(ctx context.Context, projectID, appID string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cutoff := at.Add(-UsageRetention)
+	kept := m.usage[projectID][:0]
+	for _, t := range m.usage[projectID] {
+		if !t.Before(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	m.usage[projectID] = append(kept, at)
+	return nil
+}
+
+func (m *MemoryStorage) TakeToken(ctx context.Context, key string, rate float64, burst int, now time.Time) (bool, time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	bucket, ok := m.rateBuckets[key]
+	if !ok {
+		bucket = tokenBucket{tokens: float64(burst), at: now}
+	}
+	if now.After(bucket.at) {
+		bucket.tokens = min(float64(burst), bucket.tokens+now.Sub(bucket.at).Seconds()*rate)
+		bucket.at = now
+	}
+
+	if bucket.tokens < 1 {
+		m.rateBuckets[key] = bucket
+		return false, time.Duration(math.Ceil((1 - bucket.tokens) / rate * float64(time.Second))), nil
+	}
+	bucket.tokens--
+	m.rateBuckets[key] = bucket
+	return true, 0, nil
+}
+
+func (m *MemoryStorage) CountIncrements(ctx context.Context, projectID string, since time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var count int64
+	for _, t := range m.usage[projectID] {
+		if !t.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *MemoryStorage) GetIdempotentResult(ctx context.Context, scope, key string) (string, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result, ok := m.idempotency[scope+":"+key]
+	if !ok || time.Now().After(result.expires) {
+		delete(m.idempotency, scope+":"+key)
+		return "", false, nil
+	}
+	return result.value, true, nil
+}
+
+func (m *MemoryStorage) SetIdempotentResult(ctx context.Context, scope, key, result string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.idempotency[scope+":"+key] = memoryResult{value: result, expires: time.Now().Add(ttl)}
+	return nil
+}
+
+// NextDevBuild increments the build counter of an app's branch
+func (m *MemoryStorage) NextDevBuild(ctx context.Context, appID, branch string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.devBuilds[appID+":"+branch]++
+	return m.devBuilds[appID+":"+branch], nil
+}
+
+// RecordDevVersion stores an issued dev version, assigning record.Counter from
+// a per-app sequence. Records older than retention are dropped.
+func (m *MemoryStorage) RecordDevVersion(ctx context.Context, appID string, record *models.DevVersionRecord, retention time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.devCounters[appID]++
+	record.Counter = m.devCounters[appID]
+
+	m.pruneDevVersions(appID, record.IssuedAt.Add(-retention))
+	if m.devVersions[appID] == nil {
+		m.devVersions[appID] = make(map[string]models.DevVersionRecord)
+	}
+	m.devVersions[appID][record.Version] = *record
+	return nil
+}
+
+// ListDevVersions returns the dev versions issued for an app since the given
+// time, newest first
+func (m *MemoryStorage) ListDevVersions(ctx context.Context, appID string, since time.Time) ([]models.DevVersionRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pruneDevVersions(appID, since)
+	records := make([]models.DevVersionRecord, 0, len(m.devVersions[appID]))
+	for _, record := range m.devVersions[appID] {
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].IssuedAt.After(records[j].IssuedAt)
+	})
+	return records, nil
+}
+
+// pruneDevVersions drops dev version records issued before cutoff. Callers
+// hold mu.
+func (m *MemoryStorage) pruneDevVersions(appID string, cutoff time.Time) {
+	for version, record := range m.devVersions[appID] {
+		if record.IssuedAt.Before(cutoff) {
+			delete(m.devVersions[appID], version)
+		}
+	}
+}
+
+func (m *MemoryStorage) SaveWebhook(ctx context.Context, webhook *models.WebhookSubscription) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.webhooks[webhook.ProjectID] == nil {
+		m.webhooks[webhook.ProjectID] = make(map[string]models.WebhookSubscription)
+	}
+	m.webhooks[webhook.ProjectID][webhook.ID] = *webhook
+	return nil
+}
+
+// ListWebhooks returns a project's webhook subscriptions, oldest first
+func (m *MemoryStorage) ListWebhooks(ctx context.Context, projectID string) ([]models.WebhookSubscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	webhooks := make([]models.WebhookSubscription, 0, len(m.webhooks[projectID]))
+	for _, webhook := range m.webhooks[projectID] {
+		webhooks = append(webhooks, webhook)
+	}
+	sort.Slice(webhooks, func(i, j int) bool {
+		return webhooks[i].CreatedAt.Before(webhooks[j].CreatedAt)
+	})
+	return webhooks, nil
+}
+
+func (m *MemoryStorage) DeleteWebhook(ctx context.Context, projectID, id string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.webhooks[projectID][id]; !ok {
+		return false, nil
+	}
+	delete(m.webhooks[projectID], id)
+	if len(m.webhooks[projectID]) == 0 {
+		delete(m.webhooks, projectID)
+	}
+	return true, nil
+}
+
+// ListWebhookProjects returns the projects with webhook subscriptions, sorted
+func (m *MemoryStorage) ListWebhookProjects(ctx context.Context) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return sortedKeys(m.webhooks), nil
+}
+
+// GetReservedVersions returns the versions reserved for a project, sorted
+func (m *MemoryStorage) GetReservedVersions(ctx context.Context, projectID string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	versions := append([]string(nil), m.reserved[projectID]...)
+	sort.Strings(versions)
+	return versions, nil
+}
+
+// SetReservedVersions replaces the versions reserved for a project
+func (m *MemoryStorage) SetReservedVersions(ctx context.Context, projectID string, versions []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(versions) == 0 {
+		delete(m.reserved, projectID)
+		return nil
+	}
+	m.reserved[projectID] = append([]string(nil), versions...)
+	return nil
+}
+
+// ListReservedProjects returns the projects with reserved versions, sorted
+func (m *MemoryStorage) ListReservedProjects(ctx context.Context) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return sortedKeys(m.reserved), nil
+}
+
+// PublishChange calls the handler of every subscriber before returning
+func (m *MemoryStorage) PublishChange(ctx context.Context, change models.VersionChange) error {
+	m.mu.Lock()
+	handlers := make([]func(models.VersionChange), 0, len(m.subscribers))
+	for _, handle := range m.subscribers {
+		handlers = append(handlers, handle)
+	}
+	m.mu.Unlock()
+
+	for _, handle := range handlers {
+		handle(change)
+	}
+	return nil
+}
+
+// SubscribeChanges passes changes published on this store to handle until
+// ctx ends
+func (m *MemoryStorage) SubscribeChanges(ctx context.Context, handle func(models.VersionChange)) error {
+	m.mu.Lock()
+	id := m.nextSub
+	m.nextSub++
+	m.subscribers[id] = handle
+	m.mu.Unlock()
+
+	<-ctx.Done()
+
+	m.mu.Lock()
+	delete(m.subscribers, id)
+	m.mu.Unlock()
+	return ctx.Err()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
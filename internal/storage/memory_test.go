@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMemoryStorage(t *testing.T, snapshotPath string) *MemoryStorage {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	m, err := NewMemoryStorage(MemoryOptions{SnapshotPath: snapshotPath}, logger)
+	require.NoError(t, err)
+	return m
+}
+
+func TestMemoryStorage_Writes(t *testing.T) {
+	m := newTestMemoryStorage(t, "")
+	ctx := context.Background()
+
+	assert.True(t, m.IsEmpty())
+	_, err := m.Bootstrap(ctx, &models.VersionsFile{Versions: map[string]*models.AppVersion{"1-api": {Current: "1.0.0"}}}, "seed")
+	require.NoError(t, err)
+	_, err = m.Bootstrap(ctx, &models.VersionsFile{}, "seed")
+	assert.ErrorIs(t, err, ErrAlreadyBootstrapped)
+
+	require.NoError(t, m.SetVersion(ctx, "1-api", &models.AppVersion{Current: "1.1.0"}))
+	require.NoError(t, m.RenameVersion(ctx, "1-api", "1-svc", &models.AppVersion{Current: "1.1.0", RenamedFrom: []string{"1-api"}}))
+
+	version, err := m.GetVersion(ctx, "1-svc")
+	require.NoError(t, err)
+	version.Current = "9.9.9"
+	version, err = m.GetVersion(ctx, "1-svc")
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", version.Current, "records are copied out of the store")
+
+	history, err := m.GetVersionHistory(ctx, "1-svc")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, "1.0.0", history[0].Version)
+	assert.Equal(t, "1.1.0", history[1].Version)
+
+	previous, _, err := m.GetPreviousVersion(ctx, "1-svc", "1.1.0")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", previous.Current)
+
+	require.NoError(t, m.DeleteVersion(ctx, "1-svc"))
+	versions, err := m.ListVersions(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, versions)
+}
+
+func TestMemoryStorage_SetVersionsIf(t *testing.T) {
+	m := newTestMemoryStorage(t, "")
+	ctx := context.Background()
+
+	read := &models.AppVersion{Current: "1.0.0", LastUpdated: time.Now()}
+	require.NoError(t, m.SetVersion(ctx, "1-api", read))
+	require.NoError(t, m.SetVersion(ctx, "1-web", read))
+	expected := map[string]*models.AppVersion{"1-api": read, "1-web": read, "1-new": nil}
+
+	// Another writer changes one app, so nothing of the batch is written
+	require.NoError(t, m.SetVersion(ctx, "1-web", &models.AppVersion{Current: "1.0.1", LastUpdated: time.Now()}))
+	err := m.SetVersionsIf(ctx, expected, map[string]*models.AppVersion{
+		"1-api": {Current: "1.1.0"},
+		"1-web": {Current: "1.1.0"},
+	})
+	assert.ErrorIs(t, err, ErrRevisionMismatch)
+	version, err := m.GetVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", version.Current)
+
+	require.NoError(t, m.SetVersionsIf(ctx, expected, map[string]*models.AppVersion{
+		"1-api": {Current: "1.1.0"},
+		"1-new": {Current: "0.1.0"},
+	}))
+	versions, err := m.ListVersions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", versions["1-api"].Current)
+	assert.Equal(t, "0.1.0", versions["1-new"].Current)
+}
+
+func TestMemoryStorage_Snapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "versions.json")
+	ctx := context.Background()
+
+	first := newTestMemoryStorage(t, path)
+	require.NoError(t, first.SetVersions(ctx, map[string]*models.AppVersion{
+		"1-api": {Current: "1.0.0"},
+		"1-web": {Current: "2.0.0"},
+	}))
+	require.NoError(t, first.DeleteVersion(ctx, "1-web"))
+
+	second := newTestMemoryStorage(t, path)
+	versions, err := second.ListVersions(ctx)
+	require.NoError(t, err)
+	assert.Len(t, versions, 1)
+	assert.Equal(t, "1.0.0", versions["1-api"].Current)
+}
+
+func TestMemoryStorage_CacheStores(t *testing.T) {
+	m := newTestMemoryStorage(t, "")
+	ctx := context.Background()
+	now := time.Now()
+
+	require.NoError(t, m.RecordIncrement(ctx, "1", "1-api", now.Add(-2*time.Hour)))
+	require.NoError(t, m.RecordIncrement(ctx, "1", "1-api", now))
+	count, err := m.CountIncrements(ctx, "1", now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	require.NoError(t, m.SetIdempotentResult(ctx, "1-api", "key", "1.0.1", time.Hour))
+	require.NoError(t, m.SetIdempotentResult(ctx, "1-api", "expired", "1.0.2", -time.Second))
+	result, ok, err := m.GetIdempotentResult(ctx, "1-api", "key")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "1.0.1", result)
+	_, ok, err = m.GetIdempotentResult(ctx, "1-api", "expired")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	old := &models.DevVersionRecord{Version: "1.0.0-dev.1", IssuedAt: now.Add(-48 * time.Hour)}
+	recent := &models.DevVersionRecord{Version: "1.0.0-dev.2", IssuedAt: now}
+	require.NoError(t, m.RecordDevVersion(ctx, "1-api", old, 72*time.Hour))
+	require.NoError(t, m.RecordDevVersion(ctx, "1-api", recent, 24*time.Hour))
+	assert.Equal(t, int64(2), recent.Counter)
+	records, err := m.ListDevVersions(ctx, "1-api", now.Add(-time.Hour))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "1.0.0-dev.2", records[0].Version)
+
+	require.NoError(t, m.SetReservedVersions(ctx, "1", []string{"2.0.0", "1.5.0"}))
+	reserved, err := m.GetReservedVersions(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1.5.0", "2.0.0"}, reserved)
+	require.NoError(t, m.SetReservedVersions(ctx, "1", nil))
+	projects, err := m.ListReservedProjects(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, projects)
+}
+
+func TestMemoryStorage_TakeToken(t *testing.T) {
+	m := newTestMemoryStorage(t, "")
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+
+	for i := 0; i < 3; i++ {
+		ok, _, err := m.TakeToken(ctx, "write:ip:10.0.0.1", 0.5, 3, now)
+		require.NoError(t, err)
+		assert.True(t, ok, "buckets start full")
+	}
+	ok, wait, err := m.TakeToken(ctx, "write:ip:10.0.0.1", 0.5, 3, now)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, 2*time.Second, wait)
+
+	ok, _, err = m.TakeToken(ctx, "write:ip:10.0.0.2", 0.5, 3, now)
+	require.NoError(t, err)
+	assert.True(t, ok, "clients have their own buckets")
+
+	ok, _, err = m.TakeToken(ctx, "write:ip:10.0.0.1", 0.5, 3, now.Add(2*time.Second))
+	require.NoError(t, err)
+	assert.True(t, ok, "a token is refilled after the wait")
+}
@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// NamedStorage is a storage backend with the name it was configured by
+type NamedStorage struct {
+	Name    string
+	Storage Storage
+}
+
+// MirroredStorage serves reads from a primary backend and copies every
+// successful write to its mirrors. The primary alone decides whether a write
+// succeeded: mirror failures are logged and otherwise ignored, so a mirror
+// may trail the primary until the app is written again. Batch writes,
+// renames, bootstrapping, history and watches come from the primary; whole-file
+// operations such as raw file access are unavailable.
+type MirroredStorage struct {
+	primary NamedStorage
+	mirrors []NamedStorage
+	logger  *logrus.Logger
+}
+
+// NewMirroredStorage returns a storage writing to primary and mirrors
+func NewMirroredStorage(primary NamedStorage, mirrors []NamedStorage, logger *logrus.Logger) *MirroredStorage {
+	return &MirroredStorage{primary: primary, mirrors: mirrors, logger: logger}
+}
+
+// mirror applies a successful primary write to every mirror
+func (m *MirroredStorage) mirror(ctx context.Context, operation string, write func(Storage) error) {
+	for _, mirror := range m.mirrors {
+		if err := write(mirror.Storage); err != nil {
+			m.logger.WithError(err).WithFields(logrus.Fields{
+				"backend":   mirror.Name,
+				"operation": operation,
+			}).Warn("Failed to mirror write")
+		}
+	}
+}
+
+func (m *MirroredStorage) GetVersion(ctx context.Context, appID string) (*models.AppVersion, error) {
+	return m.primary.Storage.GetVersion(ctx, appID)
+}
+
+func (m *MirroredStorage) SetVersion(ctx context.Context, appID string, version *models.AppVersion) error {
+	if err := m.primary.Storage.SetVersion(ctx, appID, version); err != nil {
+		return err
+	}
+	m.mirror(ctx, "set", func(s Storage) error {
+		return s.SetVersion(ctx, appID, version)
+	})
+	return nil
+}
+
+func (m *MirroredStorage) SetVersions(ctx context.Context, versions map[string]*models.AppVersion) error {
+	if err := setVersions(ctx, m.primary.Storage, versions); err != nil {
+		return err
+	}
+	m.mirror(ctx, "set", func(s Storage) error {
+		return setVersions(ctx, s, versions)
+	})
+	return nil
+}
+
+// setVersions writes versions in one change where the backend supports it
+func setVersions(ctx context.Context, s Storage, versions map[string]*models.AppVersion) error {
+	if batch, ok := s.(BatchWriter); ok {
+		return batch.SetVersions(ctx, versions)
+	}
+	for appID, version := range versions {
+		if err := s.SetVersion(ctx, appID, version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MirroredStorage) ListVersions(ctx context.Context) (map[string]*models.AppVersion, error) {
+	return m.primary.Storage.ListVersions(ctx)
+}
+
+func (m *MirroredStorage) ListVersionsByProject(ctx context.Context, projectID string) (map[string]*models.AppVersion, error) {
+	return m.primary.Storage.ListVersionsByProject(ctx, projectID)
+}
+
+func (m *MirroredStorage) ListVersionsPage(ctx context.Context, cursor string, limit int, filter models.VersionFilter) (*models.VersionPage, error) {
+	return m.primary.Storage.ListVersionsPage(ctx, cursor, limit, filter)
+}
+
+func (m *MirroredStorage) DeleteVersion(ctx context.Context, appID string) error {
+	if err := m.primary.Storage.DeleteVersion(ctx, appID); err != nil {
+		return err
+	}
+	m.mirror(ctx, "delete", func(s Storage) error {
+		return s.DeleteVersion(ctx, appID)
+	})
+	return nil
+}
+
+func (m *MirroredStorage) RenameVersion(ctx context.Context, oldAppID, newAppID string, version *models.AppVersion) error {
+	if err := renameVersion(ctx, m.primary.Storage, oldAppID, newAppID, version); err != nil {
+		return err
+	}
+	m.mirror(ctx, "rename", func(s Storage) error {
+		return renameVersion(ctx, s, oldAppID, newAppID, version)
+	})
+	return nil
+}
+
+// renameVersion moves a record in one change where the backend supports it
+func renameVersion(ctx context.Context, s Storage, oldAppID, newAppID string, version *models.AppVersion) error {
+	if renamer, ok := s.(Renamer); ok {
+		return renamer.RenameVersion(ctx, oldAppID, newAppID, version)
+	}
+	if err := s.SetVersion(ctx, newAppID, version); err != nil {
+		return err
+	}
+	return s.DeleteVersion(ctx, oldAppID)
+}
+
+// Health reports the health of the primary; mirrors can't fail writes, so
+// they don't fail health either
+func (m *MirroredStorage) Health(ctx context.Context) error {
+	return m.primary.Storage.Health(ctx)
+}
+
+func (m *MirroredStorage) RebuildCache(ctx context.Context, versions map[string]*models.AppVersion) error {
+	return m.primary.Storage.RebuildCache(ctx, versions)
+}
+
+// PushPendingCommits pushes the pending commits of every backend that
+// commits locally, primary first
+func (m *MirroredStorage) PushPendingCommits(ctx context.Context) error {
+	for _, backend := range append([]NamedStorage{m.primary}, m.mirrors...) {
+		pushable, ok := backend.Storage.(GitPushable)
+		if !ok {
+			continue
+		}
+		if err := pushable.PushPendingCommits(ctx); err != nil {
+			return fmt.Errorf("%s: %w", backend.Name, err)
+		}
+	}
+	return nil
+}
+
+// IsEmpty reports whether the primary is empty
+func (m *MirroredStorage) IsEmpty() bool {
+	bootstrapper, ok := m.primary.Storage.(Bootstrapper)
+	return ok && bootstrapper.IsEmpty()
+}
+
+// Bootstrap seeds the primary, then every mirror that is empty
+func (m *MirroredStorage) Bootstrap(ctx context.Context, vf *models.VersionsFile, message string) (string, error) {
+	bootstrapper, ok := m.primary.Storage.(Bootstrapper)
+	if !ok {
+		return "", fmt.Errorf("%s storage cannot be bootstrapped", m.primary.Name)
+	}
+	revision, err := bootstrapper.Bootstrap(ctx, vf, message)
+	if err != nil {
+		return "", err
+	}
+	m.mirror(ctx, "bootstrap", func(s Storage) error {
+		if mirror, ok := s.(Bootstrapper); ok && mirror.IsEmpty() {
+			_, err := mirror.Bootstrap(ctx, vf, message)
+			return err
+		}
+		return setVersions(ctx, s, vf.Versions)
+	})
+	return revision, nil
+}
+
+func (m *MirroredStorage) GetVersionHistory(ctx context.Context, appID string) ([]models.VersionHistoryEntry, error) {
+	history, ok := m.primary.Storage.(HistoryProvider)
+	if !ok {
+		return nil, fmt.Errorf("%s storage does not keep history", m.primary.Name)
+	}
+	return history.GetVersionHistory(ctx, appID)
+}
+
+// Watch reports the changes other writers make to the primary
+func (m *MirroredStorage) Watch(ctx context.Context, handle func(appID string, version *models.AppVersion) bool) error {
+	watcher, ok := m.primary.Storage.(Watcher)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrWatchUnsupported, m.primary.Name)
+	}
+	return watcher.Watch(ctx, handle)
+}
+
+func (m *MirroredStorage) GetPreviousVersion(ctx context.Context, appID, current string) (*models.AppVersion, string, error) {
+	history, ok := m.primary.Storage.(HistoryProvider)
+	if !ok {
+		return nil, "", fmt.Errorf("%s storage does not keep history", m.primary.Name)
+	}
+	return history.GetPreviousVersion(ctx, appID, current)
+}
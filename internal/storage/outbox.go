@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// FileOutbox is an Outbox kept as a JSON lines file. It holds the signing
+// secrets of deferred webhook deliveries, so the file is only readable by
+// its owner.
+type FileOutbox struct {
+	path   string
+	logger *logrus.Logger
+	mu     sync.Mutex
+}
+
+// NewFileOutbox opens the outbox at path, creating the file and its
+// directory when missing
+func NewFileOutbox(path string, logger *logrus.Logger) (*FileOutbox, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create outbox directory: %w", err)
+	}
+
+	o := &FileOutbox{path: path, logger: logger}
+	if err := o.Health(context.Background()); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func (o *FileOutbox) Append(ctx context.Context, calls []models.DeferredCall) error {
+	calls = append([]models.DeferredCall(nil), calls...)
+	for i := range calls {
+		if calls[i].ID != "" {
+			continue
+		}
+		b := make([]byte, 8)
+		if _, err := rand.Read(b); err != nil {
+			return fmt.Errorf("failed to generate outbox ID: %w", err)
+		}
+		calls[i].ID = hex.EncodeToString(b)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if err := appendJSONLines(o.path, calls); err != nil {
+		return fmt.Errorf("failed to append to outbox: %w", err)
+	}
+	return nil
+}
+
+func (o *FileOutbox) Entries(ctx context.Context) ([]models.DeferredCall, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	calls, err := readJSONLines[models.DeferredCall](o.path, o.logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outbox: %w", err)
+	}
+	return calls, nil
+}
+
+func (o *FileOutbox) Update(ctx context.Context, removed []string, updated []models.DeferredCall) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	calls, err := readJSONLines[models.DeferredCall](o.path, o.logger)
+	if err != nil {
+		return fmt.Errorf("failed to read outbox: %w", err)
+	}
+
+	drop := make(map[string]bool, len(removed))
+	for _, id := range removed {
+		drop[id] = true
+	}
+	replace := make(map[string]models.DeferredCall, len(updated))
+	for _, call := range updated {
+		replace[call.ID] = call
+	}
+
+	kept := make([]models.DeferredCall, 0, len(calls))
+	for _, call := range calls {
+		if drop[call.ID] {
+			continue
+		}
+		if replacement, ok := replace[call.ID]; ok {
+			call = replacement
+		}
+		kept = append(kept, call)
+	}
+
+	if err := writeJSONLines(o.path, kept); err != nil {
+		return fmt.Errorf("failed to rewrite outbox: %w", err)
+	}
+	return nil
+}
+
+// Health checks that the outbox can be opened for appending
+func (o *FileOutbox) Health(ctx context.Context) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	file, err := os.OpenFile(o.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("outbox not writable: %w", err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileOutbox(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	path := filepath.Join(t.TempDir(), "outbox", "outbox.jsonl")
+	ctx := context.Background()
+
+	outbox, err := NewFileOutbox(path, logger)
+	require.NoError(t, err)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	require.NoError(t, outbox.Append(ctx, []models.DeferredCall{
+		{Kind: models.DeferredWebhook, URL: "https://hooks.example.com/a", Secret: "s3cret", QueuedAt: time.Now()},
+		{Kind: models.DeferredGitLabSeed, AppID: "1-api", ProjectID: "1", SeededVersion: "1.0.0", QueuedAt: time.Now()},
+	}))
+
+	calls, err := outbox.Entries(ctx)
+	require.NoError(t, err)
+	require.Len(t, calls, 2)
+	assert.NotEmpty(t, calls[0].ID)
+	assert.NotEqual(t, calls[0].ID, calls[1].ID)
+	assert.Equal(t, "s3cret", calls[0].Secret)
+
+	// Calls queued while a flush was running survive its update
+	require.NoError(t, outbox.Append(ctx, []models.DeferredCall{{Kind: models.DeferredWebhook, URL: "https://hooks.example.com/b"}}))
+
+	failed := calls[1]
+	failed.Attempts = 1
+	failed.LastError = "GitLab unreachable"
+	require.NoError(t, outbox.Update(ctx, []string{calls[0].ID}, []models.DeferredCall{failed}))
+
+	calls, err = outbox.Entries(ctx)
+	require.NoError(t, err)
+	require.Len(t, calls, 2)
+	assert.Equal(t, "GitLab unreachable", calls[0].LastError)
+	assert.Equal(t, 1, calls[0].Attempts)
+	assert.Equal(t, "https://hooks.example.com/b", calls[1].URL)
+
+	info, err = os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	require.NoError(t, outbox.Update(ctx, []string{calls[0].ID, calls[1].ID}, nil))
+	calls, err = outbox.Entries(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, calls)
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"encoding/base64"
+	"errors"
+	"sort"
+
+	"github.com/company/version-service/internal/models"
+)
+
+// ErrInvalidCursor is returned when a page cursor was not issued by
+// ListVersionsPage
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursors are opaque to clients; they encode the last app ID of the previous
+// page, so pages stay stable while apps are added or removed
+func encodeCursor(appID string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(appID))
+}
+
+func decodeCursor(cursor string) (string, error) {
+	if cursor == "" {
+		return "", nil
+	}
+	appID, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(appID) == 0 {
+		return "", ErrInvalidCursor
+	}
+	return string(appID), nil
+}
+
+// pageVersions returns the page of versions after the cursor's app ID, in app
+// ID order, holding at most limit apps that match filter
+func pageVersions(versions map[string]*models.AppVersion, cursor string, limit int, filter models.VersionFilter) (*models.VersionPage, error) {
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	appIDs := make([]string, 0, len(versions))
+	for appID := range versions {
+		if appID > after {
+			appIDs = append(appIDs, appID)
+		}
+	}
+	sort.Strings(appIDs)
+
+	page := &models.VersionPage{Versions: make(map[string]*models.AppVersion)}
+	last := after
+	for _, appID := range appIDs {
+		version := versions[appID]
+		if !filter.MatchesApp(appID, version) {
+			continue
+		}
+		if len(page.Versions) == limit {
+			// A further match exists, so there is another page
+			page.NextCursor = encodeCursor(last)
+			break
+		}
+		page.Versions[appID] = version
+		last = appID
+	}
+
+	return page, nil
+}
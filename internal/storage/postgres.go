@@ -0,0 +1,508 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/pkg/semver"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/sirupsen/logrus"
+)
+
+// postgresDriver is the database/sql driver PostgresStorage opens,
+// registered by pgx
+const postgresDriver = "pgx"
+
+// postgresSchema is applied on startup. Every write to app_versions bumps
+// revision, the optimistic-locking column, and appends the record written
+// to app_version_history.
+const postgresSchema = `
+CREATE TABLE IF NOT EXISTS app_versions (
+	app_id     TEXT PRIMARY KEY,
+	record     JSONB NOT NULL,
+	revision   BIGINT NOT NULL DEFAULT 1,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE TABLE IF NOT EXISTS app_version_history (
+	id          BIGSERIAL PRIMARY KEY,
+	app_id      TEXT NOT NULL,
+	record      JSONB NOT NULL,
+	recorded_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS app_version_history_app_id ON app_version_history (app_id, id);
+`
+
+// PostgresStorage keeps app versions in PostgreSQL. Writes are optimistic:
+// each app's row carries a revision, and a write only applies when the row
+// is still at the revision this instance last read or wrote. A write racing
+// another instance fails with ErrRevisionMismatch instead of overwriting it.
+type PostgresStorage struct {
+	db     *sql.DB
+	logger *logrus.Logger
+
+	// revisions holds the last revision seen of each app; apps missing from
+	// it are expected not to exist yet
+	mu        sync.Mutex
+	revisions map[string]int64
+}
+
+// NewPostgresStorage connects to dsn and creates the schema if needed
+func NewPostgresStorage(dsn string, logger *logrus.Logger) (*PostgresStorage, error) {
+	db, err := sql.Open(postgresDriver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open postgres: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+	if _, err := db.ExecContext(ctx, postgresSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create postgres schema: %w", err)
+	}
+
+	logger.Info("Connected to PostgreSQL storage")
+	return &PostgresStorage{
+		db:        db,
+		logger:    logger,
+		revisions: make(map[string]int64),
+	}, nil
+}
+
+// queryer is implemented by both *sql.DB and *sql.Tx
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func (p *PostgresStorage) knownRevision(appID string) (int64, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	revision, ok := p.revisions[appID]
+	return revision, ok
+}
+
+// remember records the revisions read or written; a zero revision means the
+// app no longer exists
+func (p *PostgresStorage) remember(revisions map[string]int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for appID, revision := range revisions {
+		if revision == 0 {
+			delete(p.revisions, appID)
+		} else {
+			p.revisions[appID] = revision
+		}
+	}
+}
+
+// listWhere returns the apps matching the condition, remembering their
+// revisions
+func (p *PostgresStorage) listWhere(ctx context.Context, condition string, args ...any) (map[string]*models.AppVersion, error) {
+	query := "SELECT app_id, record, revision FROM app_versions"
+	if condition != "" {
+		query += " WHERE " + condition
+	}
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query versions: %w", err)
+	}
+	defer rows.Close()
+
+	versions := make(map[string]*models.AppVersion)
+	revisions := make(map[string]int64)
+	for rows.Next() {
+		var appID string
+		var record []byte
+		var revision int64
+		if err := rows.Scan(&appID, &record, &revision); err != nil {
+			return nil, fmt.Errorf("failed to read version: %w", err)
+		}
+		var version models.AppVersion
+		if err := json.Unmarshal(record, &version); err != nil {
+			return nil, fmt.Errorf("failed to parse version of %s: %w", appID, err)
+		}
+		versions[appID] = &version
+		revisions[appID] = revision
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query versions: %w", err)
+	}
+
+	p.remember(revisions)
+	return versions, nil
+}
+
+func (p *PostgresStorage) GetVersion(ctx context.Context, appID string) (*models.AppVersion, error) {
+	versions, err := p.listWhere(ctx, "app_id = $1", appID)
+	if err != nil {
+		return nil, err
+	}
+	if versions[appID] == nil {
+		// Deleted elsewhere; the next write creates it again
+		p.remember(map[string]int64{appID: 0})
+	}
+	return versions[appID], nil
+}
+
+func (p *PostgresStorage) SetVersion(ctx context.Context, appID string, version *models.AppVersion) error {
+	if err := p.SetVersions(ctx, map[string]*models.AppVersion{appID: version}); err != nil {
+		return err
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"app_id":  appID,
+		"version": version.Current,
+	}).Info("Version persisted to PostgreSQL")
+	return nil
+}
+
+// SetVersions writes several app versions in one transaction; none is
+// written if any of them changed since it was last read
+func (p *PostgresStorage) SetVersions(ctx context.Context, versions map[string]*models.AppVersion) error {
+	return p.inTx(ctx, func(tx *sql.Tx, revisions map[string]int64) error {
+		for appID, version := range versions {
+			if err := p.write(ctx, tx, appID, version, revisions); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (p *PostgresStorage) ListVersions(ctx context.Context) (map[string]*models.AppVersion, error) {
+	return p.listWhere(ctx, "")
+}
+
+func (p *PostgresStorage) ListVersionsPage(ctx context.Context, cursor string, limit int, filter models.VersionFilter) (*models.VersionPage, error) {
+	versions, err := p.ListVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pageVersions(versions, cursor, limit, filter)
+}
+
+func (p *PostgresStorage) ListVersionsByProject(ctx context.Context, projectID string) (map[string]*models.AppVersion, error) {
+	allVersions, err := p.ListVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	projectVersions := make(map[string]*models.AppVersion)
+	for appID, version := range allVersions {
+		if models.InProject(appID, version, projectID) {
+			projectVersions[appID] = version
+		}
+	}
+	return projectVersions, nil
+}
+
+func (p *PostgresStorage) DeleteVersion(ctx context.Context, appID string) error {
+	err := p.inTx(ctx, func(tx *sql.Tx, revisions map[string]int64) error {
+		return p.delete(ctx, tx, appID, revisions)
+	})
+	if err != nil {
+		return err
+	}
+
+	p.logger.WithField("app_id", appID).Info("Version deleted from PostgreSQL")
+	return nil
+}
+
+// RenameVersion moves an app's record to newAppID in one transaction
+func (p *PostgresStorage) RenameVersion(ctx context.Context, oldAppID, newAppID string, version *models.AppVersion) error {
+	err := p.inTx(ctx, func(tx *sql.Tx, revisions map[string]int64) error {
+		if err := p.delete(ctx, tx, oldAppID, revisions); err != nil {
+			return err
+		}
+		return p.write(ctx, tx, newAppID, version, revisions)
+	})
+	if err != nil {
+		return err
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"app_id":     oldAppID,
+		"new_app_id": newAppID,
+	}).Info("Version renamed in PostgreSQL")
+	return nil
+}
+
+// inTx runs fn in a transaction. fn collects the revisions it writes, which
+// are remembered once the transaction commits.
+func (p *PostgresStorage) inTx(ctx context.Context, fn func(tx *sql.Tx, revisions map[string]int64) error) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	revisions := make(map[string]int64)
+	if err := fn(tx, revisions); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	p.remember(revisions)
+	return nil
+}
+
+// write stores version as appID if the row is still at the revision last
+// seen, or still absent if none was, and records it in the history
+func (p *PostgresStorage) write(ctx context.Context, q queryer, appID string, version *models.AppVersion, revisions map[string]int64) error {
+	record, err := json.Marshal(version)
+	if err != nil {
+		return fmt.Errorf("failed to marshal version of %s: %w", appID, err)
+	}
+
+	// A zero revision in this transaction means the app was deleted by it
+	known, seen := revisions[appID]
+	if !seen {
+		known, seen = p.knownRevision(appID)
+	}
+	exists := seen && known != 0
+
+	var revision int64
+	if exists {
+		err = q.QueryRowContext(ctx,
+			`UPDATE app_versions SET record = $2, revision = revision + 1, updated_at = now()
+			WHERE app_id = $1 AND revision = $3 RETURNING revision`,
+			appID, record, known).Scan(&revision)
+	} else {
+		err = q.QueryRowContext(ctx,
+			`INSERT INTO app_versions (app_id, record) VALUES ($1, $2)
+			ON CONFLICT (app_id) DO NOTHING RETURNING revision`,
+			appID, record).Scan(&revision)
+	}
+	switch {
+	case errors.Is(err, sql.ErrNoRows) && exists:
+		return fmt.Errorf("%w: %s changed since revision %d", ErrRevisionMismatch, appID, known)
+	case errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("%w: %s was created by another writer", ErrRevisionMismatch, appID)
+	case err != nil:
+		return fmt.Errorf("failed to write version of %s: %w", appID, err)
+	}
+
+	if _, err := q.ExecContext(ctx,
+		"INSERT INTO app_version_history (app_id, record) VALUES ($1, $2)", appID, record); err != nil {
+		return fmt.Errorf("failed to record history of %s: %w", appID, err)
+	}
+
+	revisions[appID] = revision
+	return nil
+}
+
+// delete removes appID if its row is still at the revision last seen.
+// Deleting an app that doesn't exist succeeds.
+func (p *PostgresStorage) delete(ctx context.Context, q queryer, appID string, revisions map[string]int64) error {
+	known, seen := revisions[appID]
+	if !seen {
+		known, seen = p.knownRevision(appID)
+	}
+
+	result, err := q.ExecContext(ctx,
+		"DELETE FROM app_versions WHERE app_id = $1 AND ($2 = 0 OR revision = $2)", appID, known)
+	if err != nil {
+		return fmt.Errorf("failed to delete version of %s: %w", appID, err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete version of %s: %w", appID, err)
+	}
+
+	if deleted == 0 && seen && known != 0 {
+		var exists bool
+		if err := q.QueryRowContext(ctx,
+			"SELECT EXISTS (SELECT 1 FROM app_versions WHERE app_id = $1)", appID).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to delete version of %s: %w", appID, err)
+		}
+		if exists {
+			return fmt.Errorf("%w: %s changed since revision %d", ErrRevisionMismatch, appID, known)
+		}
+	}
+
+	revisions[appID] = 0
+	return nil
+}
+
+func (p *PostgresStorage) Health(ctx context.Context) error {
+	return p.db.PingContext(ctx)
+}
+
+// IsEmpty reports whether no app has been stored yet. Errors count as not
+// empty so a failing database is never bootstrapped over.
+func (p *PostgresStorage) IsEmpty() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var empty bool
+	if err := p.db.QueryRowContext(ctx, "SELECT NOT EXISTS (SELECT 1 FROM app_versions)").Scan(&empty); err != nil {
+		p.logger.WithError(err).Warn("Failed to check whether PostgreSQL storage is empty")
+		return false
+	}
+	return empty
+}
+
+// Bootstrap writes the initial versions in one transaction and returns the
+// last history entry written as the revision. It fails with
+// ErrAlreadyBootstrapped when any app is stored.
+func (p *PostgresStorage) Bootstrap(ctx context.Context, vf *models.VersionsFile, message string) (string, error) {
+	var revision int64
+	err := p.inTx(ctx, func(tx *sql.Tx, revisions map[string]int64) error {
+		// Serialize concurrent bootstraps; the check below would otherwise
+		// pass for both
+		if _, err := tx.ExecContext(ctx, "LOCK TABLE app_versions IN EXCLUSIVE MODE"); err != nil {
+			return fmt.Errorf("failed to lock versions: %w", err)
+		}
+
+		var exists bool
+		if err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM app_versions)").Scan(&exists); err != nil {
+			return fmt.Errorf("failed to check versions: %w", err)
+		}
+		if exists {
+			return ErrAlreadyBootstrapped
+		}
+
+		for appID, version := range vf.Versions {
+			revisions[appID] = 0
+			if err := p.write(ctx, tx, appID, version, revisions); err != nil {
+				return err
+			}
+		}
+		return tx.QueryRowContext(ctx, "SELECT COALESCE(MAX(id), 0) FROM app_version_history").Scan(&revision)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"apps":    len(vf.Versions),
+		"message": message,
+	}).Info("PostgreSQL storage bootstrapped")
+	return strconv.FormatInt(revision, 10), nil
+}
+
+// appHistory returns the distinct versions recorded for appID, newest first,
+// each pointing at the history entry that introduced it. Entries from before
+// a rename are read under the app's former IDs.
+func (p *PostgresStorage) appHistory(ctx context.Context, appID string) ([]versionRecord, error) {
+	lineage := []string{appID}
+	current, err := p.GetVersion(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+	if current != nil {
+		for i := len(current.RenamedFrom) - 1; i >= 0; i-- {
+			lineage = append(lineage, current.RenamedFrom[i])
+		}
+	}
+
+	placeholders := make([]string, len(lineage))
+	args := make([]any, len(lineage))
+	for i, id := range lineage {
+		placeholders[i] = "$" + strconv.Itoa(i+1)
+		args[i] = id
+	}
+	rows, err := p.db.QueryContext(ctx,
+		"SELECT id, record, recorded_at FROM app_version_history WHERE app_id IN ("+
+			strings.Join(placeholders, ", ")+") ORDER BY id DESC", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history: %w", err)
+	}
+	defer rows.Close()
+
+	var records []versionRecord
+	for rows.Next() {
+		var id int64
+		var data []byte
+		var when time.Time
+		if err := rows.Scan(&id, &data, &when); err != nil {
+			return nil, fmt.Errorf("failed to read history: %w", err)
+		}
+		var version models.AppVersion
+		if err := json.Unmarshal(data, &version); err != nil {
+			return nil, fmt.Errorf("failed to parse history entry %d: %w", id, err)
+		}
+
+		record := versionRecord{version: &version, commit: strconv.FormatInt(id, 10), when: when}
+		// Entries are read newest first, so an unchanged version moves back
+		// to the entry that introduced it
+		if n := len(records); n > 0 && records[n-1].version.Current == version.Current {
+			records[n-1] = record
+			continue
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query history: %w", err)
+	}
+	return records, nil
+}
+
+// GetPreviousVersion returns the most recent version recorded for appID that
+// sorts below current, along with the history entry that recorded it, or nil
+// when no earlier version exists
+func (p *PostgresStorage) GetPreviousVersion(ctx context.Context, appID, current string) (*models.AppVersion, string, error) {
+	records, err := p.appHistory(ctx, appID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for _, record := range records {
+		cmp, err := semver.Compare(record.version.Current, current)
+		if err != nil {
+			// Unparseable versions can't be ordered; fall back to any change
+			if record.version.Current != current {
+				return record.version, record.commit, nil
+			}
+			continue
+		}
+		if cmp < 0 {
+			return record.version, record.commit, nil
+		}
+	}
+	return nil, "", nil
+}
+
+// GetVersionHistory returns the versions recorded for appID, oldest first.
+// Commit holds the ID of the history entry.
+func (p *PostgresStorage) GetVersionHistory(ctx context.Context, appID string) ([]models.VersionHistoryEntry, error) {
+	records, err := p.appHistory(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]models.VersionHistoryEntry, 0, len(records))
+	for i := len(records) - 1; i >= 0; i-- {
+		history = append(history, models.VersionHistoryEntry{
+			Version:   records[i].version.Current,
+			Commit:    records[i].commit,
+			Timestamp: records[i].when,
+		})
+	}
+	return history, nil
+}
+
+func (p *PostgresStorage) RebuildCache(ctx context.Context, versions map[string]*models.AppVersion) error {
+	// PostgreSQL storage doesn't use cache, so this is a no-op
+	return nil
+}
+
+func (p *PostgresStorage) Close() error {
+	return p.db.Close()
+}
@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/company/version-service/internal/models"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Statements PostgresStorage runs, as sqlmock patterns
+var (
+	pgSelect  = regexp.QuoteMeta("SELECT app_id, record, revision FROM app_versions WHERE app_id = $1")
+	pgUpdate  = regexp.QuoteMeta("UPDATE app_versions SET record = $2")
+	pgInsert  = regexp.QuoteMeta("INSERT INTO app_versions (app_id, record)")
+	pgHistory = regexp.QuoteMeta("INSERT INTO app_version_history (app_id, record)")
+	pgDelete  = regexp.QuoteMeta("DELETE FROM app_versions WHERE app_id = $1")
+	pgExists  = regexp.QuoteMeta("SELECT EXISTS (SELECT 1 FROM app_versions WHERE app_id = $1)")
+)
+
+func newMockPostgres(t *testing.T) (*PostgresStorage, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return &PostgresStorage{db: db, logger: logger, revisions: make(map[string]int64)}, mock
+}
+
+func revisionRow(revision int64) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"revision"}).AddRow(revision)
+}
+
+func TestPostgresStorage_ConditionalWrites(t *testing.T) {
+	p, mock := newMockPostgres(t)
+	ctx := context.Background()
+	version := &models.AppVersion{Current: "1.0.0", ProjectID: "1", AppName: "api"}
+
+	// An app never read is created, and only if it is still absent
+	mock.ExpectBegin()
+	mock.ExpectQuery(pgInsert).WithArgs("1-api", sqlmock.AnyArg()).WillReturnRows(revisionRow(1))
+	mock.ExpectExec(pgHistory).WithArgs("1-api", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	require.NoError(t, p.SetVersion(ctx, "1-api", version))
+
+	// The next write updates the revision it created
+	mock.ExpectBegin()
+	mock.ExpectQuery(pgUpdate).WithArgs("1-api", sqlmock.AnyArg(), int64(1)).WillReturnRows(revisionRow(2))
+	mock.ExpectExec(pgHistory).WithArgs("1-api", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+	require.NoError(t, p.SetVersion(ctx, "1-api", &models.AppVersion{Current: "1.0.1", ProjectID: "1", AppName: "api"}))
+
+	// Reads move the expected revision to the one read
+	mock.ExpectQuery(pgSelect).WithArgs("1-api").WillReturnRows(
+		sqlmock.NewRows([]string{"app_id", "record", "revision"}).AddRow("1-api", []byte(`{"current":"1.0.4"}`), 5))
+	read, err := p.GetVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.4", read.Current)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(pgUpdate).WithArgs("1-api", sqlmock.AnyArg(), int64(5)).WillReturnRows(revisionRow(6))
+	mock.ExpectExec(pgHistory).WithArgs("1-api", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectCommit()
+	require.NoError(t, p.SetVersion(ctx, "1-api", &models.AppVersion{Current: "1.0.5", ProjectID: "1", AppName: "api"}))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStorage_RevisionConflicts(t *testing.T) {
+	ctx := context.Background()
+	version := &models.AppVersion{Current: "1.0.0", ProjectID: "1", AppName: "api"}
+
+	t.Run("update of a changed row", func(t *testing.T) {
+		p, mock := newMockPostgres(t)
+		p.remember(map[string]int64{"1-api": 3})
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(pgUpdate).WithArgs("1-api", sqlmock.AnyArg(), int64(3)).WillReturnRows(sqlmock.NewRows([]string{"revision"}))
+		mock.ExpectRollback()
+		err := p.SetVersion(ctx, "1-api", version)
+		assert.ErrorIs(t, err, ErrRevisionMismatch)
+		assert.Contains(t, err.Error(), "changed since revision 3")
+
+		revision, _ := p.knownRevision("1-api")
+		assert.Equal(t, int64(3), revision, "a failed write keeps the revision last seen")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("create of an app another writer created", func(t *testing.T) {
+		p, mock := newMockPostgres(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(pgInsert).WithArgs("1-api", sqlmock.AnyArg()).WillReturnRows(sqlmock.NewRows([]string{"revision"}))
+		mock.ExpectRollback()
+		err := p.SetVersion(ctx, "1-api", version)
+		assert.ErrorIs(t, err, ErrRevisionMismatch)
+		assert.Contains(t, err.Error(), "created by another writer")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("batch with one changed row writes nothing", func(t *testing.T) {
+		p, mock := newMockPostgres(t)
+		p.remember(map[string]int64{"1-api": 1, "1-web": 1})
+
+		mock.MatchExpectationsInOrder(false)
+		mock.ExpectBegin()
+		mock.ExpectQuery(pgUpdate).WithArgs("1-api", sqlmock.AnyArg(), int64(1)).WillReturnRows(revisionRow(2))
+		mock.ExpectExec(pgHistory).WithArgs("1-api", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(pgUpdate).WithArgs("1-web", sqlmock.AnyArg(), int64(1)).WillReturnRows(sqlmock.NewRows([]string{"revision"}))
+		mock.ExpectRollback()
+		err := p.SetVersions(ctx, map[string]*models.AppVersion{
+			"1-api": version,
+			"1-web": {Current: "2.0.0", ProjectID: "1", AppName: "web"},
+		})
+		assert.ErrorIs(t, err, ErrRevisionMismatch)
+
+		// The revision written in the rolled back transaction is not kept
+		revision, _ := p.knownRevision("1-api")
+		assert.Equal(t, int64(1), revision)
+	})
+
+	t.Run("delete of a changed row", func(t *testing.T) {
+		p, mock := newMockPostgres(t)
+		p.remember(map[string]int64{"1-api": 2})
+
+		mock.ExpectBegin()
+		mock.ExpectExec(pgDelete).WithArgs("1-api", int64(2)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(pgExists).WithArgs("1-api").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectRollback()
+		assert.ErrorIs(t, p.DeleteVersion(ctx, "1-api"), ErrRevisionMismatch)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("delete of a row already deleted", func(t *testing.T) {
+		p, mock := newMockPostgres(t)
+		p.remember(map[string]int64{"1-api": 2})
+
+		mock.ExpectBegin()
+		mock.ExpectExec(pgDelete).WithArgs("1-api", int64(2)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(pgExists).WithArgs("1-api").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectCommit()
+		require.NoError(t, p.DeleteVersion(ctx, "1-api"))
+
+		_, known := p.knownRevision("1-api")
+		assert.False(t, known, "the next write creates the app")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -2,8 +2,14 @@ package storage
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
+	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -13,23 +19,148 @@ import (
 )
 
 const (
-	versionKeyPrefix = "version:"
-	allVersionsKey   = "versions:all"
-	defaultTTL       = 24 * time.Hour
+	// Versions are kept in a hash per project, listed in a set, plus a
+	// lexicographic index of every app for paging
+	projectKeyPrefix = "project:"
+	projectsKey      = "versions:projects"
+	versionIndexKey  = "versions:index"
+	// A string key per app and a set of every app, as cached by earlier
+	// releases; read until the next RebuildCache
+	legacyVersionKeyPrefix = "version:"
+	legacyVersionsKey      = "versions:all"
+	usageKeyPrefix         = "usage:increments:"
+	idempotencyKeyPrefix   = "idempotency:"
+	devIssuedKeyPrefix     = "dev:issued:"
+	devIndexKeyPrefix      = "dev:issued:index:"
+	devCounterKeyPrefix    = "dev:counter:"
+	devBuildKeyPrefix      = "dev:build:"
+	webhookKeyPrefix       = "webhooks:"
+	reservedKeyPrefix      = "reserved:"
+	rateLimitKeyPrefix     = "ratelimit:"
+	changesChannel         = "versions:changes"
+	defaultTTL             = 24 * time.Hour
+	pageBatchSize          = 100
 )
 
+// UsageRetention is how long increments are kept for usage reports and
+// quotas; longer windows would undercount
+const UsageRetention = 7 * 24 * time.Hour
+
 type RedisStorage struct {
 	client *redis.Client
+	// codec serializes cached versions and dev version records; values
+	// written by other codecs are still read
+	codec Codec
+	// ids finds the project hash of an app from its ID
+	ids    models.IDScheme
 	logger *logrus.Logger
 }
 
-func NewRedisStorage(redisURL string, logger *logrus.Logger) (*RedisStorage, error) {
-	opts, err := redis.ParseURL(redisURL)
+// RedisOptions configures the connection to Redis. Settings other than URL
+// override what the URL carries.
+type RedisOptions struct {
+	// URL is a redis:// or, for TLS, rediss:// URL
+	URL string
+	// Username and Password authenticate with a Redis ACL user
+	Username string
+	Password string
+	// TLS enables TLS for redis:// URLs too. Setting any of the TLS options
+	// below enables it as well.
+	TLS bool
+	// TLSCAFile is a PEM bundle of the CAs trusted to sign the server's
+	// certificate, in place of the system's
+	TLSCAFile string
+	// TLSCertFile and TLSKeyFile hold a client certificate presented to
+	// servers requiring one
+	TLSCertFile string
+	TLSKeyFile  string
+	// TLSInsecureSkipVerify accepts any server certificate. Only use it in
+	// tests.
+	TLSInsecureSkipVerify bool
+	// PoolSize and MinIdleConns size the connection pool, and DialTimeout,
+	// ReadTimeout and WriteTimeout bound each connection attempt and
+	// command. Zero keeps the value from URL, or go-redis' default.
+	PoolSize     int
+	MinIdleConns int
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	// IDs parses app IDs into the project whose hash holds them; nil
+	// selects the default project-app scheme
+	IDs models.IDScheme
+}
+
+// clientOptions returns the go-redis options for opts
+func (opts RedisOptions) clientOptions() (*redis.Options, error) {
+	clientOpts, err := redis.ParseURL(opts.URL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse redis URL: %w", err)
 	}
+	if opts.Username != "" {
+		clientOpts.Username = opts.Username
+	}
+	if opts.Password != "" {
+		clientOpts.Password = opts.Password
+	}
+	if opts.PoolSize > 0 {
+		clientOpts.PoolSize = opts.PoolSize
+	}
+	if opts.MinIdleConns > 0 {
+		clientOpts.MinIdleConns = opts.MinIdleConns
+	}
+	if opts.DialTimeout > 0 {
+		clientOpts.DialTimeout = opts.DialTimeout
+	}
+	if opts.ReadTimeout > 0 {
+		clientOpts.ReadTimeout = opts.ReadTimeout
+	}
+	if opts.WriteTimeout > 0 {
+		clientOpts.WriteTimeout = opts.WriteTimeout
+	}
+
+	if !opts.TLS && opts.TLSCAFile == "" && opts.TLSCertFile == "" && !opts.TLSInsecureSkipVerify {
+		return clientOpts, nil
+	}
+	if clientOpts.TLSConfig == nil {
+		host, _, err := net.SplitHostPort(clientOpts.Addr)
+		if err != nil {
+			host = clientOpts.Addr
+		}
+		clientOpts.TLSConfig = &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+	}
+
+	if opts.TLSCAFile != "" {
+		pem, err := os.ReadFile(opts.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Redis CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in Redis CA file %s", opts.TLSCAFile)
+		}
+		clientOpts.TLSConfig.RootCAs = pool
+	}
+	if opts.TLSCertFile != "" || opts.TLSKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.TLSCertFile, opts.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load Redis client certificate: %w", err)
+		}
+		clientOpts.TLSConfig.Certificates = []tls.Certificate{cert}
+	}
+	clientOpts.TLSConfig.InsecureSkipVerify = opts.TLSInsecureSkipVerify
+	return clientOpts, nil
+}
+
+func NewRedisStorage(opts RedisOptions, codec Codec, logger *logrus.Logger) (*RedisStorage, error) {
+	clientOpts, err := opts.clientOptions()
+	if err != nil {
+		return nil, err
+	}
+	if opts.TLSInsecureSkipVerify {
+		logger.Warn("Redis server certificate is not verified")
+	}
 
-	client := redis.NewClient(opts)
+	client := redis.NewClient(clientOpts)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -38,16 +169,31 @@ func NewRedisStorage(redisURL string, logger *logrus.Logger) (*RedisStorage, err
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
+	ids := opts.IDs
+	if ids == nil {
+		ids = models.DefaultIDScheme()
+	}
+
 	return &RedisStorage{
 		client: client,
+		codec:  codec,
+		ids:    ids,
 		logger: logger,
 	}, nil
 }
 
-func (r *RedisStorage) GetVersion(ctx context.Context, appID string) (*models.AppVersion, error) {
-	key := versionKeyPrefix + appID
+// projectKey returns the hash holding appID's version: the project its ID
+// names, or unassignedProject for IDs naming none
+func (r *RedisStorage) projectKey(appID string) string {
+	id, err := r.ids.Parse(appID)
+	if err != nil || id.Opaque() {
+		return projectKeyPrefix + unassignedProject
+	}
+	return projectKeyPrefix + id.ProjectID
+}
 
-	data, err := r.client.Get(ctx, key).Result()
+func (r *RedisStorage) GetVersion(ctx context.Context, appID string) (*models.AppVersion, error) {
+	data, err := r.client.HGet(ctx, r.projectKey(appID), appID).Result()
 	if err == redis.Nil {
 		return nil, nil
 	}
@@ -56,28 +202,24 @@ func (r *RedisStorage) GetVersion(ctx context.Context, appID string) (*models.Ap
 		return nil, fmt.Errorf("failed to get version: %w", err)
 	}
 
-	var version models.AppVersion
-	if err := json.Unmarshal([]byte(data), &version); err != nil {
+	version, err := decodeVersion([]byte(data))
+	if err != nil {
 		r.logger.WithError(err).WithField("app_id", appID).Error("Failed to unmarshal version")
 		return nil, fmt.Errorf("failed to unmarshal version: %w", err)
 	}
 
-	return &version, nil
+	return version, nil
 }
 
 func (r *RedisStorage) SetVersion(ctx context.Context, appID string, version *models.AppVersion) error {
-	key := versionKeyPrefix + appID
-
-	data, err := json.Marshal(version)
+	data, err := r.codec.MarshalVersion(version)
 	if err != nil {
 		r.logger.WithError(err).WithField("app_id", appID).Error("Failed to marshal version")
 		return fmt.Errorf("failed to marshal version: %w", err)
 	}
 
 	pipe := r.client.TxPipeline()
-	pipe.Set(ctx, key, data, defaultTTL)
-	pipe.SAdd(ctx, allVersionsKey, appID)
-	pipe.Expire(ctx, allVersionsKey, defaultTTL)
+	r.queueSetVersion(ctx, pipe, appID, data)
 
 	if _, err := pipe.Exec(ctx); err != nil {
 		r.logger.WithError(err).WithField("app_id", appID).Error("Failed to set version in Redis")
@@ -92,67 +234,315 @@ func (r *RedisStorage) SetVersion(ctx context.Context, appID string, version *mo
 	return nil
 }
 
+// queueSetVersion queues the commands caching an app's encoded version
+func (r *RedisStorage) queueSetVersion(ctx context.Context, pipe redis.Pipeliner, appID string, data []byte) {
+	key := r.projectKey(appID)
+	pipe.HSet(ctx, key, appID, data)
+	pipe.Expire(ctx, key, defaultTTL)
+	pipe.SAdd(ctx, projectsKey, key)
+	pipe.Expire(ctx, projectsKey, defaultTTL)
+	pipe.ZAdd(ctx, versionIndexKey, redis.Z{Member: appID})
+	pipe.Expire(ctx, versionIndexKey, defaultTTL)
+}
+
+// setVersionsIfScript writes every app of a batch, or none of them. KEYS[1]
+// and KEYS[2] are the project set and the version index, and KEYS[2+i] is
+// the hash of the i-th app. ARGV[1] is the TTL, followed by the app ID, the
+// value its field must still hold (empty while missing) and the value to
+// write, for each app. Apps are indexed like queueSetVersion. It returns 0
+// when a field changed.
+var setVersionsIfScript = redis.NewScript(`
+local apps = #KEYS - 2
+for i = 1, apps do
+	local current = redis.call('HGET', KEYS[2 + i], ARGV[3 * i - 1])
+	if (current or '') ~= ARGV[3 * i] then
+		return 0
+	end
+end
+for i = 1, apps do
+	redis.call('HSET', KEYS[2 + i], ARGV[3 * i - 1], ARGV[3 * i + 1])
+	redis.call('EXPIRE', KEYS[2 + i], ARGV[1])
+	redis.call('SADD', KEYS[1], KEYS[2 + i])
+	redis.call('ZADD', KEYS[2], 0, ARGV[3 * i - 1])
+end
+redis.call('EXPIRE', KEYS[1], ARGV[1])
+redis.call('EXPIRE', KEYS[2], ARGV[1])
+return 1
+`)
+
+// SetVersionIf caches version unless the cached record is no longer
+// expected. The record is decoded and checked here, then a script writes
+// only if its encoded value is still the one checked, so when replicas race
+// to write over the same record only the first succeeds. Writes to other
+// apps of the project don't interfere.
+func (r *RedisStorage) SetVersionIf(ctx context.Context, appID string, expected, version *models.AppVersion) error {
+	return r.SetVersionsIf(ctx,
+		map[string]*models.AppVersion{appID: expected},
+		map[string]*models.AppVersion{appID: version},
+	)
+}
+
+// SetVersionsIf caches every version of a batch unless one of the cached
+// records is no longer expected, checked like SetVersionIf. A single script
+// writes the whole batch, so a batch racing another replica is written
+// entirely or not at all.
+func (r *RedisStorage) SetVersionsIf(ctx context.Context, expected, versions map[string]*models.AppVersion) error {
+	keys := []string{projectsKey, versionIndexKey}
+	args := []interface{}{int(defaultTTL.Seconds())}
+
+	for appID, version := range versions {
+		key := r.projectKey(appID)
+
+		data, err := r.codec.MarshalVersion(version)
+		if err != nil {
+			r.logger.WithError(err).WithField("app_id", appID).Error("Failed to marshal version")
+			return fmt.Errorf("failed to marshal version: %w", err)
+		}
+
+		raw, err := r.client.HGet(ctx, key, appID).Result()
+		switch {
+		case err == redis.Nil:
+		case err != nil:
+			r.logger.WithError(err).WithField("app_id", appID).Error("Failed to get version from Redis")
+			return fmt.Errorf("failed to get version: %w", err)
+		default:
+			stored, err := decodeVersion([]byte(raw))
+			if err != nil {
+				return fmt.Errorf("failed to unmarshal version: %w", err)
+			}
+			if !sameRevision(stored, expected[appID]) {
+				return fmt.Errorf("%w: %s is at %s", ErrRevisionMismatch, appID, stored.Current)
+			}
+		}
+
+		keys = append(keys, key)
+		args = append(args, appID, raw, data)
+	}
+
+	written, err := setVersionsIfScript.Run(ctx, r.client, keys, args...).Int()
+	if err != nil {
+		r.logger.WithError(err).WithField("count", len(versions)).Error("Failed to set versions in Redis")
+		return fmt.Errorf("failed to set versions: %w", err)
+	}
+	if written == 0 {
+		return fmt.Errorf("%w: an app changed while being written", ErrRevisionMismatch)
+	}
+
+	for appID, version := range versions {
+		r.logger.WithFields(logrus.Fields{
+			"app_id":  appID,
+			"version": version.Current,
+		}).Debug("Version cached in Redis")
+	}
+	return nil
+}
+
+// ListVersions reads every project hash. Versions cached under the string
+// keys of earlier releases are included until the next RebuildCache, so
+// writes cached before an upgrade are recovered on startup.
 func (r *RedisStorage) ListVersions(ctx context.Context) (map[string]*models.AppVersion, error) {
-	appIDs, err := r.client.SMembers(ctx, allVersionsKey).Result()
+	keys, err := r.client.SMembers(ctx, projectsKey).Result()
 	if err != nil {
-		r.logger.WithError(err).Error("Failed to list version keys")
-		return nil, fmt.Errorf("failed to list version keys: %w", err)
+		r.logger.WithError(err).Error("Failed to list project keys")
+		return nil, fmt.Errorf("failed to list project keys: %w", err)
+	}
+
+	versions := make(map[string]*models.AppVersion)
+	for _, key := range keys {
+		if err := r.scanProject(ctx, key, versions, nil); err != nil {
+			return nil, err
+		}
 	}
 
+	legacy, err := r.legacyVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for appID, version := range legacy {
+		if cached, ok := versions[appID]; !ok || version.LastUpdated.After(cached.LastUpdated) {
+			versions[appID] = version
+		}
+	}
+	return versions, nil
+}
+
+// scanProject adds the versions of a project hash to versions with HSCAN, so
+// large projects don't block Redis. keep, when set, selects the apps added.
+func (r *RedisStorage) scanProject(ctx context.Context, key string, versions map[string]*models.AppVersion, keep func(appID string, version *models.AppVersion) bool) error {
+	iter := r.client.HScan(ctx, key, 0, "", pageBatchSize).Iterator()
+	for iter.Next(ctx) {
+		appID := iter.Val()
+		if !iter.Next(ctx) {
+			break
+		}
+		version, err := decodeVersion([]byte(iter.Val()))
+		if err != nil {
+			r.logger.WithError(err).WithField("app_id", appID).Warn("Failed to unmarshal version")
+			continue
+		}
+		if keep == nil || keep(appID, version) {
+			versions[appID] = version
+		}
+	}
+	if err := iter.Err(); err != nil {
+		r.logger.WithError(err).WithField("key", key).Error("Failed to scan project versions")
+		return fmt.Errorf("failed to scan project versions: %w", err)
+	}
+	return nil
+}
+
+// legacyVersions reads the versions cached by earlier releases, one string
+// key per app listed in a global set
+func (r *RedisStorage) legacyVersions(ctx context.Context) (map[string]*models.AppVersion, error) {
+	versions := make(map[string]*models.AppVersion)
+	appIDs, err := r.client.SMembers(ctx, legacyVersionsKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list legacy version keys: %w", err)
+	}
 	if len(appIDs) == 0 {
-		return make(map[string]*models.AppVersion), nil
+		return versions, nil
 	}
 
 	keys := make([]string, len(appIDs))
 	for i, appID := range appIDs {
-		keys[i] = versionKeyPrefix + appID
+		keys[i] = legacyVersionKeyPrefix + appID
 	}
-
 	values, err := r.client.MGet(ctx, keys...).Result()
 	if err != nil {
-		r.logger.WithError(err).Error("Failed to get multiple versions")
-		return nil, fmt.Errorf("failed to get versions: %w", err)
+		return nil, fmt.Errorf("failed to get legacy versions: %w", err)
 	}
-
-	versions := make(map[string]*models.AppVersion)
 	for i, val := range values {
 		if val == nil {
 			continue
 		}
-
-		var version models.AppVersion
-		if err := json.Unmarshal([]byte(val.(string)), &version); err != nil {
-			r.logger.WithError(err).WithField("app_id", appIDs[i]).Warn("Failed to unmarshal version")
+		version, err := decodeVersion([]byte(val.(string)))
+		if err != nil {
+			r.logger.WithError(err).WithField("app_id", appIDs[i]).Warn("Failed to unmarshal legacy version")
 			continue
 		}
-		versions[appIDs[i]] = &version
+		versions[appIDs[i]] = version
+	}
+	return versions, nil
+}
+
+// getVersions loads the versions of several apps in one round trip, with an
+// HMGET per project, skipping apps no longer cached
+func (r *RedisStorage) getVersions(ctx context.Context, appIDs []string) (map[string]*models.AppVersion, error) {
+	versions := make(map[string]*models.AppVersion)
+	if len(appIDs) == 0 {
+		return versions, nil
+	}
+
+	byKey := make(map[string][]string)
+	for _, appID := range appIDs {
+		key := r.projectKey(appID)
+		byKey[key] = append(byKey[key], appID)
+	}
+
+	pipe := r.client.Pipeline()
+	cmds := make(map[string]*redis.SliceCmd, len(byKey))
+	for key, ids := range byKey {
+		cmds[key] = pipe.HMGet(ctx, key, ids...)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		r.logger.WithError(err).Error("Failed to get multiple versions")
+		return nil, fmt.Errorf("failed to get versions: %w", err)
+	}
+
+	for key, cmd := range cmds {
+		for i, val := range cmd.Val() {
+			if val == nil {
+				continue
+			}
+			appID := byKey[key][i]
+			version, err := decodeVersion([]byte(val.(string)))
+			if err != nil {
+				r.logger.WithError(err).WithField("app_id", appID).Warn("Failed to unmarshal version")
+				continue
+			}
+			versions[appID] = version
+		}
 	}
 
 	return versions, nil
 }
 
-func (r *RedisStorage) ListVersionsByProject(ctx context.Context, projectID string) (map[string]*models.AppVersion, error) {
-	allVersions, err := r.ListVersions(ctx)
+// ListVersionsPage walks the lexicographic app index from the cursor and
+// loads versions in batches until the page is full, so only about one page of
+// versions is read per request
+func (r *RedisStorage) ListVersionsPage(ctx context.Context, cursor string, limit int, filter models.VersionFilter) (*models.VersionPage, error) {
+	after, err := decodeCursor(cursor)
 	if err != nil {
 		return nil, err
 	}
 
-	projectVersions := make(map[string]*models.AppVersion)
-	for appID, version := range allVersions {
-		if strings.HasPrefix(appID, projectID+"-") {
-			projectVersions[appID] = version
+	batch := int64(limit + 1)
+	if batch < pageBatchSize {
+		batch = pageBatchSize
+	}
+
+	page := &models.VersionPage{Versions: make(map[string]*models.AppVersion)}
+	last := after
+	min := "-"
+	if after != "" {
+		min = "(" + after
+	}
+
+	for {
+		appIDs, err := r.client.ZRangeByLex(ctx, versionIndexKey, &redis.ZRangeBy{Min: min, Max: "+", Count: batch}).Result()
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to read version index")
+			return nil, fmt.Errorf("failed to read version index: %w", err)
+		}
+
+		versions, err := r.getVersions(ctx, appIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, appID := range appIDs {
+			version, ok := versions[appID]
+			if !ok || !filter.MatchesApp(appID, version) {
+				continue
+			}
+			if len(page.Versions) == limit {
+				page.NextCursor = encodeCursor(last)
+				return page, nil
+			}
+			page.Versions[appID] = version
+			last = appID
+		}
+
+		if int64(len(appIDs)) < batch {
+			return page, nil
 		}
+		min = "(" + appIDs[len(appIDs)-1]
 	}
+}
 
-	return projectVersions, nil
+// ListVersionsByProject scans the project's hash, and the hash of apps whose
+// ID names no project for those recorded in it
+func (r *RedisStorage) ListVersionsByProject(ctx context.Context, projectID string) (map[string]*models.AppVersion, error) {
+	inProject := func(appID string, version *models.AppVersion) bool {
+		return models.InProject(appID, version, projectID)
+	}
+
+	versions := make(map[string]*models.AppVersion)
+	if err := r.scanProject(ctx, projectKeyPrefix+projectID, versions, inProject); err != nil {
+		return nil, err
+	}
+	if projectID != unassignedProject {
+		if err := r.scanProject(ctx, projectKeyPrefix+unassignedProject, versions, inProject); err != nil {
+			return nil, err
+		}
+	}
+	return versions, nil
 }
 
 func (r *RedisStorage) DeleteVersion(ctx context.Context, appID string) error {
-	key := versionKeyPrefix + appID
-
 	pipe := r.client.TxPipeline()
-	pipe.Del(ctx, key)
-	pipe.SRem(ctx, allVersionsKey, appID)
+	pipe.HDel(ctx, r.projectKey(appID), appID)
+	pipe.ZRem(ctx, versionIndexKey, appID)
 
 	if _, err := pipe.Exec(ctx); err != nil {
 		r.logger.WithError(err).WithField("app_id", appID).Error("Failed to delete version from Redis")
@@ -163,37 +553,474 @@ func (r *RedisStorage) DeleteVersion(ctx context.Context, appID string) error {
 	return nil
 }
 
+// RenameVersion stores version under newAppID and drops oldAppID in one
+// transaction, moving it between project hashes if needed
+func (r *RedisStorage) RenameVersion(ctx context.Context, oldAppID, newAppID string, version *models.AppVersion) error {
+	data, err := r.codec.MarshalVersion(version)
+	if err != nil {
+		r.logger.WithError(err).WithField("app_id", newAppID).Error("Failed to marshal version")
+		return fmt.Errorf("failed to marshal version: %w", err)
+	}
+
+	pipe := r.client.TxPipeline()
+	pipe.HDel(ctx, r.projectKey(oldAppID), oldAppID)
+	pipe.ZRem(ctx, versionIndexKey, oldAppID)
+	r.queueSetVersion(ctx, pipe, newAppID, data)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		r.logger.WithError(err).WithFields(logrus.Fields{
+			"app_id":     oldAppID,
+			"new_app_id": newAppID,
+		}).Error("Failed to rename version in Redis")
+		return fmt.Errorf("failed to rename version: %w", err)
+	}
+
+	return nil
+}
+
 func (r *RedisStorage) Health(ctx context.Context) error {
 	return r.client.Ping(ctx).Err()
 }
 
+// RebuildCache replaces every project hash, and drops the string keys of
+// earlier releases
 func (r *RedisStorage) RebuildCache(ctx context.Context, versions map[string]*models.AppVersion) error {
+	oldKeys, err := r.client.SMembers(ctx, projectsKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to list project keys: %w", err)
+	}
+	legacyIDs, err := r.client.SMembers(ctx, legacyVersionsKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to list legacy version keys: %w", err)
+	}
+
 	pipe := r.client.TxPipeline()
 
-	pipe.Del(ctx, allVersionsKey)
+	pipe.Del(ctx, projectsKey, versionIndexKey, legacyVersionsKey)
+	for _, key := range oldKeys {
+		pipe.Del(ctx, key)
+	}
+	for _, appID := range legacyIDs {
+		pipe.Del(ctx, legacyVersionKeyPrefix+appID)
+	}
 
+	byKey := make(map[string][]any)
 	for appID, version := range versions {
-		key := versionKeyPrefix + appID
-		data, err := json.Marshal(version)
+		data, err := r.codec.MarshalVersion(version)
 		if err != nil {
 			r.logger.WithError(err).WithField("app_id", appID).Warn("Failed to marshal version for cache rebuild")
 			continue
 		}
-		pipe.Set(ctx, key, data, defaultTTL)
-		pipe.SAdd(ctx, allVersionsKey, appID)
+		key := r.projectKey(appID)
+		byKey[key] = append(byKey[key], appID, data)
+		pipe.ZAdd(ctx, versionIndexKey, redis.Z{Member: appID})
+	}
+	for key, fields := range byKey {
+		pipe.HSet(ctx, key, fields...)
+		pipe.Expire(ctx, key, defaultTTL)
+		pipe.SAdd(ctx, projectsKey, key)
 	}
 
-	pipe.Expire(ctx, allVersionsKey, defaultTTL)
+	pipe.Expire(ctx, projectsKey, defaultTTL)
+	pipe.Expire(ctx, versionIndexKey, defaultTTL)
 
 	if _, err := pipe.Exec(ctx); err != nil {
 		r.logger.WithError(err).Error("Failed to rebuild Redis cache")
 		return fmt.Errorf("failed to rebuild cache: %w", err)
 	}
 
-	r.logger.WithField("count", len(versions)).Info("Redis cache rebuilt")
+	r.logger.WithFields(logrus.Fields{
+		"count":    len(versions),
+		"projects": len(byKey),
+	}).Info("Redis cache rebuilt")
 	return nil
 }
 
 func (r *RedisStorage) Close() error {
 	return r.client.Close()
-}
\ No newline at end of file
+}
+
+// PoolStats returns the connection pool's counters since the client was
+// created and its current connections
+func (r *RedisStorage) PoolStats() models.PoolStats {
+	stats := r.client.PoolStats()
+	return models.PoolStats{
+		Hits:       int64(stats.Hits),
+		Misses:     int64(stats.Misses),
+		Timeouts:   int64(stats.Timeouts),
+		StaleConns: int64(stats.StaleConns),
+		TotalConns: int64(stats.TotalConns),
+		IdleConns:  int64(stats.IdleConns),
+	}
+}
+
+// PublishChange publishes change on the versions:changes channel
+func (r *RedisStorage) PublishChange(ctx context.Context, change models.VersionChange) error {
+	data, err := json.Marshal(change)
+	if err != nil {
+		return fmt.Errorf("failed to marshal version change: %w", err)
+	}
+	if err := r.client.Publish(ctx, changesChannel, data).Err(); err != nil {
+		return fmt.Errorf("failed to publish version change: %w", err)
+	}
+	return nil
+}
+
+// SubscribeChanges subscribes to the versions:changes channel. Changes
+// published while the connection is being re-established are lost.
+func (r *RedisStorage) SubscribeChanges(ctx context.Context, handle func(models.VersionChange)) error {
+	sub := r.client.Subscribe(ctx, changesChannel)
+	defer sub.Close()
+
+	// Wait for the subscription to be confirmed so failures surface here
+	if _, err := sub.Receive(ctx); err != nil {
+		return fmt.Errorf("failed to subscribe to version changes: %w", err)
+	}
+
+	messages := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-messages:
+			if !ok {
+				return errors.New("version change subscription closed")
+			}
+			var change models.VersionChange
+			if err := json.Unmarshal([]byte(msg.Payload), &change); err != nil {
+				r.logger.WithError(err).Warn("Ignoring malformed version change")
+				continue
+			}
+			handle(change)
+		}
+	}
+}
+
+func (r *RedisStorage) RecordIncrement(ctx context.Context, projectID, appID string, at time.Time) error {
+	key := usageKeyPrefix + projectID
+	member := fmt.Sprintf("%s:%d", appID, at.UnixNano())
+
+	pipe := r.client.TxPipeline()
+	pipe.ZAdd(ctx, key, redis.Z{Score: float64(at.UnixNano()), Member: member})
+	pipe.ZRemRangeByScore(ctx, key, "-inf", fmt.Sprintf("(%d", at.Add(-UsageRetention).UnixNano()))
+	pipe.Expire(ctx, key, UsageRetention)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		r.logger.WithError(err).WithField("project_id", projectID).Error("Failed to record increment usage")
+		return fmt.Errorf("failed to record increment: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RedisStorage) CountIncrements(ctx context.Context, projectID string, since time.Time) (int64, error) {
+	key := usageKeyPrefix + projectID
+
+	count, err := r.client.ZCount(ctx, key, fmt.Sprintf("%d", since.UnixNano()), "+inf").Result()
+	if err != nil {
+		r.logger.WithError(err).WithField("project_id", projectID).Error("Failed to count increment usage")
+		return 0, fmt.Errorf("failed to count increments: %w", err)
+	}
+
+	return count, nil
+}
+
+// takeTokenScript refills the token bucket in hash KEYS[1] at ARGV[1] tokens
+// per second up to ARGV[2] tokens since it was last taken from at ARGV[3]
+// milliseconds, then takes a token if one is left. It returns 1 and 0, or 0
+// and the milliseconds until a token is available.
+var takeTokenScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'at')
+local tokens = tonumber(bucket[1]) or burst
+local at = tonumber(bucket[2]) or now
+if now > at then
+	tokens = math.min(burst, tokens + (now - at) * rate / 1000)
+	at = now
+end
+local taken, wait = 0, 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	taken = 1
+else
+	wait = math.ceil((1 - tokens) * 1000 / rate)
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'at', at)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return {taken, wait}
+`)
+
+// TakeToken takes a token from a bucket shared by every replica. The
+// refill is computed by a script, so concurrent requests can't take the
+// same token.
+func (r *RedisStorage) TakeToken(ctx context.Context, key string, rate float64, burst int, now time.Time) (bool, time.Duration, error) {
+	result, err := takeTokenScript.Run(ctx, r.client,
+		[]string{rateLimitKeyPrefix + key},
+		rate, burst, now.UnixMilli(),
+	).Int64Slice()
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+	if len(result) != 2 {
+		return false, 0, fmt.Errorf("failed to take rate limit token: unexpected script result %v", result)
+	}
+	return result[0] == 1, time.Duration(result[1]) * time.Millisecond, nil
+}
+
+func (r *RedisStorage) GetIdempotentResult(ctx context.Context, scope, key string) (string, bool, error) {
+	result, err := r.client.Get(ctx, idempotencyKeyPrefix+scope+":"+key).Result()
+	if err == redis.Nil {
+		return "", false, nil
+	}
+	if err != nil {
+		r.logger.WithError(err).WithField("scope", scope).Error("Failed to get idempotency key from Redis")
+		return "", false, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	return result, true, nil
+}
+
+func (r *RedisStorage) SetIdempotentResult(ctx context.Context, scope, key, result string, ttl time.Duration) error {
+	if err := r.client.Set(ctx, idempotencyKeyPrefix+scope+":"+key, result, ttl).Err(); err != nil {
+		r.logger.WithError(err).WithField("scope", scope).Error("Failed to set idempotency key in Redis")
+		return fmt.Errorf("failed to set idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// NextDevBuild increments the build counter of an app's branch. Counters
+// never expire, so numbers are not reused.
+func (r *RedisStorage) NextDevBuild(ctx context.Context, appID, branch string) (int64, error) {
+	build, err := r.client.Incr(ctx, devBuildKeyPrefix+appID+":"+branch).Result()
+	if err != nil {
+		r.logger.WithError(err).WithFields(logrus.Fields{
+			"app_id": appID,
+			"branch": branch,
+		}).Error("Failed to increment dev build counter")
+		return 0, fmt.Errorf("failed to increment dev build counter: %w", err)
+	}
+	return build, nil
+}
+
+// RecordDevVersion stores an issued dev version, assigning record.Counter from
+// a per-app sequence. Records older than retention are dropped.
+func (r *RedisStorage) RecordDevVersion(ctx context.Context, appID string, record *models.DevVersionRecord, retention time.Duration) error {
+	counter, err := r.client.Incr(ctx, devCounterKeyPrefix+appID).Result()
+	if err != nil {
+		r.logger.WithError(err).WithField("app_id", appID).Error("Failed to increment dev version counter")
+		return fmt.Errorf("failed to increment dev version counter: %w", err)
+	}
+	record.Counter = counter
+
+	data, err := r.codec.MarshalDevVersion(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal dev version: %w", err)
+	}
+
+	if err := r.pruneDevVersions(ctx, appID, record.IssuedAt.Add(-retention)); err != nil {
+		return err
+	}
+
+	pipe := r.client.TxPipeline()
+	pipe.HSet(ctx, devIssuedKeyPrefix+appID, record.Version, data)
+	pipe.ZAdd(ctx, devIndexKeyPrefix+appID, redis.Z{Score: float64(record.IssuedAt.UnixNano()), Member: record.Version})
+	pipe.Expire(ctx, devIssuedKeyPrefix+appID, retention)
+	pipe.Expire(ctx, devIndexKeyPrefix+appID, retention)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		r.logger.WithError(err).WithField("app_id", appID).Error("Failed to record dev version")
+		return fmt.Errorf("failed to record dev version: %w", err)
+	}
+
+	return nil
+}
+
+// ListDevVersions returns the dev versions issued for an app since the given
+// time, newest first
+func (r *RedisStorage) ListDevVersions(ctx context.Context, appID string, since time.Time) ([]models.DevVersionRecord, error) {
+	if err := r.pruneDevVersions(ctx, appID, since); err != nil {
+		return nil, err
+	}
+
+	versions, err := r.client.ZRevRange(ctx, devIndexKeyPrefix+appID, 0, -1).Result()
+	if err != nil {
+		r.logger.WithError(err).WithField("app_id", appID).Error("Failed to list dev versions")
+		return nil, fmt.Errorf("failed to list dev versions: %w", err)
+	}
+
+	records := make([]models.DevVersionRecord, 0, len(versions))
+	if len(versions) == 0 {
+		return records, nil
+	}
+
+	values, err := r.client.HMGet(ctx, devIssuedKeyPrefix+appID, versions...).Result()
+	if err != nil {
+		r.logger.WithError(err).WithField("app_id", appID).Error("Failed to get dev versions")
+		return nil, fmt.Errorf("failed to get dev versions: %w", err)
+	}
+
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+
+		record, err := decodeDevVersion([]byte(data))
+		if err != nil {
+			r.logger.WithError(err).WithField("version", versions[i]).Warn("Skipping unreadable dev version record")
+			continue
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// pruneDevVersions drops dev version records issued before cutoff
+func (r *RedisStorage) pruneDevVersions(ctx context.Context, appID string, cutoff time.Time) error {
+	max := fmt.Sprintf("(%d", cutoff.UnixNano())
+
+	expired, err := r.client.ZRangeByScore(ctx, devIndexKeyPrefix+appID, &redis.ZRangeBy{Min: "-inf", Max: max}).Result()
+	if err != nil {
+		return fmt.Errorf("failed to find expired dev versions: %w", err)
+	}
+	if len(expired) == 0 {
+		return nil
+	}
+
+	pipe := r.client.TxPipeline()
+	pipe.HDel(ctx, devIssuedKeyPrefix+appID, expired...)
+	pipe.ZRemRangeByScore(ctx, devIndexKeyPrefix+appID, "-inf", max)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to prune dev versions: %w", err)
+	}
+
+	return nil
+}
+
+// SaveWebhook stores a webhook subscription in its project's hash. Unlike
+// cached versions, subscriptions only live in Redis and don't expire.
+func (r *RedisStorage) SaveWebhook(ctx context.Context, webhook *models.WebhookSubscription) error {
+	data, err := json.Marshal(webhook)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook: %w", err)
+	}
+
+	if err := r.client.HSet(ctx, webhookKeyPrefix+webhook.ProjectID, webhook.ID, data).Err(); err != nil {
+		r.logger.WithError(err).WithField("project_id", webhook.ProjectID).Error("Failed to save webhook")
+		return fmt.Errorf("failed to save webhook: %w", err)
+	}
+
+	return nil
+}
+
+// ListWebhooks returns a project's webhook subscriptions, oldest first
+func (r *RedisStorage) ListWebhooks(ctx context.Context, projectID string) ([]models.WebhookSubscription, error) {
+	values, err := r.client.HGetAll(ctx, webhookKeyPrefix+projectID).Result()
+	if err != nil {
+		r.logger.WithError(err).WithField("project_id", projectID).Error("Failed to list webhooks")
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+
+	webhooks := make([]models.WebhookSubscription, 0, len(values))
+	for id, data := range values {
+		var webhook models.WebhookSubscription
+		if err := json.Unmarshal([]byte(data), &webhook); err != nil {
+			r.logger.WithError(err).WithField("webhook_id", id).Warn("Skipping unreadable webhook")
+			continue
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	sort.Slice(webhooks, func(i, j int) bool {
+		return webhooks[i].CreatedAt.Before(webhooks[j].CreatedAt)
+	})
+
+	return webhooks, nil
+}
+
+// DeleteWebhook removes a webhook subscription
+func (r *RedisStorage) DeleteWebhook(ctx context.Context, projectID, id string) (bool, error) {
+	removed, err := r.client.HDel(ctx, webhookKeyPrefix+projectID, id).Result()
+	if err != nil {
+		r.logger.WithError(err).WithField("project_id", projectID).Error("Failed to delete webhook")
+		return false, fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	return removed > 0, nil
+}
+
+// GetReservedVersions returns the versions reserved for a project, sorted
+func (r *RedisStorage) GetReservedVersions(ctx context.Context, projectID string) ([]string, error) {
+	versions, err := r.client.SMembers(ctx, reservedKeyPrefix+projectID).Result()
+	if err != nil {
+		r.logger.WithError(err).WithField("project_id", projectID).Error("Failed to get reserved versions")
+		return nil, fmt.Errorf("failed to get reserved versions: %w", err)
+	}
+
+	sort.Strings(versions)
+	return versions, nil
+}
+
+// SetReservedVersions replaces the versions reserved for a project. Like
+// webhook subscriptions, they only live in Redis and don't expire.
+func (r *RedisStorage) SetReservedVersions(ctx context.Context, projectID string, versions []string) error {
+	key := reservedKeyPrefix + projectID
+
+	pipe := r.client.TxPipeline()
+	pipe.Del(ctx, key)
+	if len(versions) > 0 {
+		members := make([]interface{}, len(versions))
+		for i, version := range versions {
+			members[i] = version
+		}
+		pipe.SAdd(ctx, key, members...)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		r.logger.WithError(err).WithField("project_id", projectID).Error("Failed to set reserved versions")
+		return fmt.Errorf("failed to set reserved versions: %w", err)
+	}
+
+	return nil
+}
+
+// ListWebhookProjects returns the projects with webhook subscriptions, sorted
+func (r *RedisStorage) ListWebhookProjects(ctx context.Context) ([]string, error) {
+	projects, err := r.scanKeySuffixes(ctx, webhookKeyPrefix)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list webhook projects")
+		return nil, fmt.Errorf("failed to list webhook projects: %w", err)
+	}
+	return projects, nil
+}
+
+// ListReservedProjects returns the projects with reserved versions, sorted
+func (r *RedisStorage) ListReservedProjects(ctx context.Context) ([]string, error) {
+	projects, err := r.scanKeySuffixes(ctx, reservedKeyPrefix)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list reserved version projects")
+		return nil, fmt.Errorf("failed to list reserved version projects: %w", err)
+	}
+	return projects, nil
+}
+
+// scanKeySuffixes returns the part after prefix of every key starting with
+// it, sorted. SCAN is used rather than KEYS so large keyspaces don't block
+// the server.
+func (r *RedisStorage) scanKeySuffixes(ctx context.Context, prefix string) ([]string, error) {
+	var suffixes []string
+	iter := r.client.Scan(ctx, 0, prefix+"*", pageBatchSize).Iterator()
+	for iter.Next(ctx) {
+		suffixes = append(suffixes, strings.TrimPrefix(iter.Val(), prefix))
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(suffixes)
+	return suffixes, nil
+}
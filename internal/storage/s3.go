@@ -0,0 +1,497 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// s3WriteAttempts bounds how often a write is retried after losing a
+// conditional put to another writer
+const s3WriteAttempts = 5
+
+// S3Options configures S3Storage
+type S3Options struct {
+	// Endpoint is the S3 API base URL, e.g. https://s3.eu-west-1.amazonaws.com
+	// or a MinIO server
+	Endpoint string
+	Region   string
+	Bucket   string
+	// Prefix is prepended to the object key, e.g. "version-service/"
+	Prefix          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// PathStyle addresses the bucket in the path instead of the host name,
+	// as MinIO and most S3-compatible stores expect
+	PathStyle bool
+}
+
+// S3Storage keeps the versions file as one object in an S3-compatible
+// bucket. Every write reads the object, changes it and puts it back on the
+// condition that its ETag is unchanged (If-Match), or that it still doesn't
+// exist (If-None-Match: *); a write losing to another writer is re-applied
+// to the new object. The store must support conditional puts, as AWS S3 and
+// MinIO do.
+type S3Storage struct {
+	opts   S3Options
+	key    string
+	client *http.Client
+	logger *logrus.Logger
+}
+
+// NewS3Storage returns an S3Storage and checks the bucket is reachable
+func NewS3Storage(opts S3Options, logger *logrus.Logger) (*S3Storage, error) {
+	if _, err := url.Parse(opts.Endpoint); err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint: %w", err)
+	}
+
+	s := &S3Storage{
+		opts:   opts,
+		key:    opts.Prefix + versionsFileName,
+		client: &http.Client{Timeout: 30 * time.Second},
+		logger: logger,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := s.Health(ctx); err != nil {
+		return nil, fmt.Errorf("failed to reach S3 bucket %s: %w", opts.Bucket, err)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"bucket": opts.Bucket,
+		"key":    s.key,
+	}).Info("Connected to S3 storage")
+	return s, nil
+}
+
+// errS3NotFound is returned by getObject when the versions object doesn't
+// exist yet
+var errS3NotFound = errors.New("object not found")
+
+// errS3PreconditionFailed is returned by putObject when the object changed
+// since it was read
+var errS3PreconditionFailed = errors.New("precondition failed")
+
+// objectURL returns the URL of the versions object
+func (s *S3Storage) objectURL() string {
+	endpoint := strings.TrimSuffix(s.opts.Endpoint, "/")
+	if s.opts.PathStyle {
+		return endpoint + "/" + s.opts.Bucket + "/" + s3EscapePath(s.key)
+	}
+	u, _ := url.Parse(endpoint)
+	u.Host = s.opts.Bucket + "." + u.Host
+	return u.String() + "/" + s3EscapePath(s.key)
+}
+
+// getObject returns the versions object and its ETag
+func (s *S3Storage) getObject(ctx context.Context) ([]byte, string, error) {
+	resp, err := s.do(ctx, http.MethodGet, nil, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read versions object: %w", err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return body, resp.Header.Get("ETag"), nil
+	case http.StatusNotFound:
+		return nil, "", errS3NotFound
+	default:
+		return nil, "", s3Error("get", resp.StatusCode, body)
+	}
+}
+
+// putObject writes the versions object if its ETag is still etag, or if it
+// doesn't exist when etag is empty, and returns the new ETag
+func (s *S3Storage) putObject(ctx context.Context, data []byte, etag string) (string, error) {
+	headers := map[string]string{"Content-Type": "application/json"}
+	if etag == "" {
+		headers["If-None-Match"] = "*"
+	} else {
+		headers["If-Match"] = etag
+	}
+
+	resp, err := s.do(ctx, http.MethodPut, data, headers)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Header.Get("ETag"), nil
+	case http.StatusPreconditionFailed, http.StatusConflict:
+		// 409 is returned when a concurrent conditional put is in flight
+		return "", errS3PreconditionFailed
+	default:
+		return "", s3Error("put", resp.StatusCode, body)
+	}
+}
+
+func s3Error(operation string, status int, body []byte) error {
+	message := strings.TrimSpace(string(body))
+	if len(message) > 200 {
+		message = message[:200]
+	}
+	return fmt.Errorf("S3 %s failed with status %d: %s", operation, status, message)
+}
+
+// readVersionsFile returns the parsed versions file and its ETag; a missing
+// object reads as an empty file with no ETag
+func (s *S3Storage) readVersionsFile(ctx context.Context) (*models.VersionsFile, string, error) {
+	data, etag, err := s.getObject(ctx)
+	if errors.Is(err, errS3NotFound) {
+		return &models.VersionsFile{Versions: make(map[string]*models.AppVersion)}, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	var vf models.VersionsFile
+	if err := json.Unmarshal(data, &vf); err != nil {
+		return nil, "", fmt.Errorf("failed to unmarshal versions file: %w", err)
+	}
+	if vf.Versions == nil {
+		vf.Versions = make(map[string]*models.AppVersion)
+	}
+	return &vf, etag, nil
+}
+
+func (s *S3Storage) writeVersionsFile(ctx context.Context, vf *models.VersionsFile, etag string) (string, error) {
+	vf.LastUpdated = time.Now()
+	data, err := json.MarshalIndent(vf, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal versions file: %w", err)
+	}
+	return s.putObject(ctx, data, etag)
+}
+
+// update applies change to the versions file and writes it back, re-reading
+// and re-applying it when another writer got there first
+func (s *S3Storage) update(ctx context.Context, change func(vf *models.VersionsFile)) error {
+	for attempt := 1; ; attempt++ {
+		vf, etag, err := s.readVersionsFile(ctx)
+		if err != nil {
+			return err
+		}
+		change(vf)
+
+		_, err = s.writeVersionsFile(ctx, vf, etag)
+		if !errors.Is(err, errS3PreconditionFailed) {
+			return err
+		}
+		if attempt == s3WriteAttempts {
+			return fmt.Errorf("%w: versions object kept changing after %d attempts", ErrRevisionMismatch, attempt)
+		}
+		s.logger.WithField("attempt", attempt).Debug("Versions object changed concurrently, retrying write")
+	}
+}
+
+func (s *S3Storage) GetVersion(ctx context.Context, appID string) (*models.AppVersion, error) {
+	vf, _, err := s.readVersionsFile(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return vf.Versions[appID], nil
+}
+
+func (s *S3Storage) SetVersion(ctx context.Context, appID string, version *models.AppVersion) error {
+	err := s.update(ctx, func(vf *models.VersionsFile) {
+		vf.Versions[appID] = version
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"app_id":  appID,
+		"version": version.Current,
+	}).Info("Version persisted to S3")
+	return nil
+}
+
+// SetVersions writes several app versions in one put
+func (s *S3Storage) SetVersions(ctx context.Context, versions map[string]*models.AppVersion) error {
+	err := s.update(ctx, func(vf *models.VersionsFile) {
+		for appID, version := range versions {
+			vf.Versions[appID] = version
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logger.WithField("count", len(versions)).Info("Versions persisted to S3 in one put")
+	return nil
+}
+
+func (s *S3Storage) ListVersions(ctx context.Context) (map[string]*models.AppVersion, error) {
+	vf, _, err := s.readVersionsFile(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return vf.Versions, nil
+}
+
+func (s *S3Storage) ListVersionsPage(ctx context.Context, cursor string, limit int, filter models.VersionFilter) (*models.VersionPage, error) {
+	versions, err := s.ListVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pageVersions(versions, cursor, limit, filter)
+}
+
+func (s *S3Storage) ListVersionsByProject(ctx context.Context, projectID string) (map[string]*models.AppVersion, error) {
+	allVersions, err := s.ListVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	projectVersions := make(map[string]*models.AppVersion)
+	for appID, version := range allVersions {
+		if models.InProject(appID, version, projectID) {
+			projectVersions[appID] = version
+		}
+	}
+	return projectVersions, nil
+}
+
+func (s *S3Storage) DeleteVersion(ctx context.Context, appID string) error {
+	err := s.update(ctx, func(vf *models.VersionsFile) {
+		delete(vf.Versions, appID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logger.WithField("app_id", appID).Info("Version deleted from S3")
+	return nil
+}
+
+// RenameVersion moves an app's record to newAppID in one put
+func (s *S3Storage) RenameVersion(ctx context.Context, oldAppID, newAppID string, version *models.AppVersion) error {
+	err := s.update(ctx, func(vf *models.VersionsFile) {
+		delete(vf.Versions, oldAppID)
+		vf.Versions[newAppID] = version
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"app_id":     oldAppID,
+		"new_app_id": newAppID,
+	}).Info("Version renamed in S3")
+	return nil
+}
+
+// Health checks the versions object can be read; a missing object is
+// healthy
+func (s *S3Storage) Health(ctx context.Context) error {
+	resp, err := s.do(ctx, http.MethodHead, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("S3 head failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// IsEmpty reports whether the versions object doesn't exist yet
+func (s *S3Storage) IsEmpty() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	_, _, err := s.getObject(ctx)
+	return errors.Is(err, errS3NotFound)
+}
+
+// Bootstrap creates the versions object, failing with
+// ErrAlreadyBootstrapped if it exists. It returns the object's ETag.
+func (s *S3Storage) Bootstrap(ctx context.Context, vf *models.VersionsFile, message string) (string, error) {
+	etag, err := s.writeVersionsFile(ctx, vf, "")
+	if errors.Is(err, errS3PreconditionFailed) {
+		return "", fmt.Errorf("%w: %s exists", ErrAlreadyBootstrapped, s.key)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"etag":    etag,
+		"count":   len(vf.Versions),
+		"message": message,
+	}).Info("S3 versions object bootstrapped")
+	return etag, nil
+}
+
+// ReadVersionsFile returns the raw versions object and its ETag as the
+// revision
+func (s *S3Storage) ReadVersionsFile(ctx context.Context) ([]byte, string, error) {
+	data, etag, err := s.getObject(ctx)
+	if errors.Is(err, errS3NotFound) {
+		// Not bootstrapped yet: present the empty file the first write creates
+		data, err = json.MarshalIndent(&models.VersionsFile{Versions: map[string]*models.AppVersion{}}, "", "  ")
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return data, etag, nil
+}
+
+// ReplaceVersionsFile overwrites the versions object. When expectedRevision
+// is set, the write only happens if the object's ETag still matches it.
+func (s *S3Storage) ReplaceVersionsFile(ctx context.Context, vf *models.VersionsFile, expectedRevision, message string) (string, error) {
+	etag := expectedRevision
+	if etag == "" {
+		// Unconditional: overwrite whatever is there now
+		var err error
+		if _, etag, err = s.getObject(ctx); err != nil && !errors.Is(err, errS3NotFound) {
+			return "", err
+		}
+	}
+
+	revision, err := s.writeVersionsFile(ctx, vf, etag)
+	if errors.Is(err, errS3PreconditionFailed) {
+		return "", fmt.Errorf("%w: expected %s", ErrRevisionMismatch, expectedRevision)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"revision": revision,
+		"count":    len(vf.Versions),
+		"message":  message,
+	}).Info("Versions file replaced")
+	return revision, nil
+}
+
+func (s *S3Storage) RebuildCache(ctx context.Context, versions map[string]*models.AppVersion) error {
+	// S3 storage doesn't use cache, so this is a no-op
+	return nil
+}
+
+// do sends a signed request for the versions object
+func (s *S3Storage) do(ctx context.Context, method string, body []byte, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build S3 request: %w", err)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	s.sign(req, body, time.Now().UTC())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("S3 %s failed: %w", strings.ToLower(method), err)
+	}
+	return resp, nil
+}
+
+// sign adds an AWS Signature Version 4 to req
+func (s *S3Storage) sign(req *http.Request, body []byte, now time.Time) {
+	payloadHash := sha256Hex(body)
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	// Sign the host and every x-amz-* and conditional header
+	signed := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || strings.HasPrefix(lower, "if-") || lower == "content-type" {
+			signed[lower] = strings.TrimSpace(req.Header.Get(name))
+		}
+	}
+	names := make([]string, 0, len(signed))
+	for name := range signed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + signed[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.opts.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.opts.SecretAccessKey), date)
+	key = hmacSHA256(key, s.opts.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.opts.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// s3EscapePath escapes an object key for a URL path as SigV4 canonical
+// requests expect: everything but unreserved characters and slashes
+func s3EscapePath(key string) string {
+	var escaped strings.Builder
+	for _, b := range []byte(key) {
+		switch {
+		case b >= 'A' && b <= 'Z', b >= 'a' && b <= 'z', b >= '0' && b <= '9',
+			b == '-', b == '_', b == '.', b == '~', b == '/':
+			escaped.WriteByte(b)
+		default:
+			fmt.Fprintf(&escaped, "%%%02X", b)
+		}
+	}
+	return escaped.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
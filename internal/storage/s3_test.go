@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/company/version-service/internal/models"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 serves one bucket with conditional puts. conflicts makes that many
+// puts fail as if another writer got there first.
+type fakeS3 struct {
+	mu        sync.Mutex
+	objects   map[string][]byte
+	etags     map[string]string
+	puts      int
+	conflicts int
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	key := r.URL.Path
+	data, exists := f.objects[key]
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("ETag", f.etags[key])
+		w.Write(data)
+	case http.MethodPut:
+		if f.conflicts > 0 {
+			f.conflicts--
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		if match := r.Header.Get("If-Match"); match != "" && (!exists || match != f.etags[key]) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		if r.Header.Get("If-None-Match") == "*" && exists {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		f.puts++
+		f.objects[key] = body
+		f.etags[key] = fmt.Sprintf(`"%d"`, f.puts)
+		w.Header().Set("ETag", f.etags[key])
+	}
+}
+
+func newTestS3Storage(t *testing.T) (*S3Storage, *fakeS3) {
+	fake := &fakeS3{objects: make(map[string][]byte), etags: make(map[string]string)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	s, err := NewS3Storage(S3Options{
+		Endpoint:        server.URL,
+		Region:          "us-east-1",
+		Bucket:          "versions",
+		Prefix:          "service/",
+		AccessKeyID:     "key",
+		SecretAccessKey: "secret",
+		PathStyle:       true,
+	}, logger)
+	require.NoError(t, err)
+	return s, fake
+}
+
+func TestS3Storage_Writes(t *testing.T) {
+	s, fake := newTestS3Storage(t)
+	ctx := context.Background()
+
+	assert.True(t, s.IsEmpty())
+	require.NoError(t, s.SetVersion(ctx, "1-api", &models.AppVersion{Current: "1.0.0"}))
+	require.NoError(t, s.SetVersions(ctx, map[string]*models.AppVersion{
+		"1-web": {Current: "2.0.0"},
+		"2-cli": {Current: "0.1.0"},
+	}))
+	require.NoError(t, s.RenameVersion(ctx, "2-cli", "2-tool", &models.AppVersion{Current: "0.1.0"}))
+	require.NoError(t, s.DeleteVersion(ctx, "1-web"))
+
+	versions, err := s.ListVersions(ctx)
+	require.NoError(t, err)
+	assert.Len(t, versions, 2)
+	assert.Equal(t, "1.0.0", versions["1-api"].Current)
+	assert.Equal(t, "0.1.0", versions["2-tool"].Current)
+	assert.False(t, s.IsEmpty())
+	assert.Contains(t, fake.objects, "/versions/service/versions.json")
+}
+
+func TestS3Storage_RetriesConflicts(t *testing.T) {
+	s, fake := newTestS3Storage(t)
+	ctx := context.Background()
+
+	fake.conflicts = s3WriteAttempts - 1
+	require.NoError(t, s.SetVersion(ctx, "1-api", &models.AppVersion{Current: "1.0.0"}))
+
+	fake.conflicts = s3WriteAttempts
+	err := s.SetVersion(ctx, "1-api", &models.AppVersion{Current: "1.0.1"})
+	assert.ErrorIs(t, err, ErrRevisionMismatch)
+
+	version, err := s.GetVersion(ctx, "1-api")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", version.Current)
+}
+
+func TestS3Storage_ConditionalReplace(t *testing.T) {
+	s, _ := newTestS3Storage(t)
+	ctx := context.Background()
+
+	_, err := s.Bootstrap(ctx, &models.VersionsFile{Versions: map[string]*models.AppVersion{"1-api": {Current: "1.0.0"}}}, "seed")
+	require.NoError(t, err)
+	_, err = s.Bootstrap(ctx, &models.VersionsFile{}, "seed")
+	assert.ErrorIs(t, err, ErrAlreadyBootstrapped)
+
+	_, revision, err := s.ReadVersionsFile(ctx)
+	require.NoError(t, err)
+	require.NoError(t, s.SetVersion(ctx, "1-web", &models.AppVersion{Current: "2.0.0"}))
+
+	_, err = s.ReplaceVersionsFile(ctx, &models.VersionsFile{}, revision, "replace")
+	assert.ErrorIs(t, err, ErrRevisionMismatch)
+
+	_, revision, err = s.ReadVersionsFile(ctx)
+	require.NoError(t, err)
+	_, err = s.ReplaceVersionsFile(ctx, &models.VersionsFile{Versions: map[string]*models.AppVersion{}}, revision, "replace")
+	assert.NoError(t, err)
+}
+
+func TestS3EscapePath(t *testing.T) {
+	assert.Equal(t, "team%20a/versions.json", s3EscapePath("team a/versions.json"))
+	assert.Equal(t, "a%2Bb~c/v.json", s3EscapePath("a+b~c/v.json"))
+}
@@ -0,0 +1,222 @@
+// Read-only dashboard over the service's public GET endpoints. API data is
+// only ever rendered through textContent.
+(function () {
+  "use strict";
+
+  // The UI is served under /ui/, the API from the service root
+  var api = "../";
+  var refreshInterval = 30000;
+
+  var versions = {};
+
+  function $(id) {
+    return document.getElementById(id);
+  }
+
+  function el(tag, text, className) {
+    var node = document.createElement(tag);
+    if (text !== undefined && text !== null) {
+      node.textContent = text;
+    }
+    if (className) {
+      node.className = className;
+    }
+    return node;
+  }
+
+  function fetchJSON(path) {
+    return fetch(api + path, { headers: { Accept: "application/json" } }).then(function (resp) {
+      return resp.json().then(function (body) {
+        if (!resp.ok && path !== "health") {
+          throw new Error((body && body.message) || resp.statusText);
+        }
+        return body;
+      });
+    });
+  }
+
+  function formatTime(value) {
+    if (!value) {
+      return "";
+    }
+    return new Date(value).toLocaleString();
+  }
+
+  function formatDuration(seconds) {
+    if (seconds < 60) {
+      return Math.round(seconds) + "s";
+    }
+    if (seconds < 3600) {
+      return Math.round(seconds / 60) + "m";
+    }
+    return (seconds / 3600).toFixed(1) + "h";
+  }
+
+  function loadHealth() {
+    var badge = $("health");
+    fetchJSON("health").then(function (health) {
+      var status = health.status;
+      Object.keys(health.checks || {}).forEach(function (name) {
+        if (status === "healthy" && health.checks[name].indexOf("degraded") === 0) {
+          status = "degraded";
+        }
+      });
+      badge.textContent = status;
+      badge.title = Object.keys(health.checks || {}).map(function (name) {
+        return name + ": " + health.checks[name];
+      }).join("\n");
+      badge.className = "badge " + status;
+    }).catch(function () {
+      badge.textContent = "unreachable";
+      badge.className = "badge unhealthy";
+    });
+  }
+
+  function loadPersistence() {
+    fetchJSON("freshness").then(function (report) {
+      var pending = (report.apps || []).filter(function (app) {
+        return app.pending > 0;
+      });
+      var rows = $("pending");
+      rows.replaceChildren();
+      pending.forEach(function (app) {
+        var row = el("tr");
+        row.appendChild(el("td", app.app_id));
+        row.appendChild(el("td", app.pending));
+        row.appendChild(el("td", formatDuration(app.pending_lag_seconds || 0)));
+        rows.appendChild(row);
+      });
+      $("persistence").hidden = pending.length === 0;
+    }).catch(function () {
+      $("persistence").hidden = true;
+    });
+  }
+
+  function loadVersions() {
+    fetchJSON("versions").then(function (data) {
+      versions = data || {};
+      renderVersions();
+    }).catch(function (err) {
+      var container = $("projects");
+      container.replaceChildren(el("p", "Failed to load versions: " + err.message, "error"));
+    });
+  }
+
+  function matches(appID, version, filter) {
+    if (!filter) {
+      return true;
+    }
+    return [appID, version.project_id, version.app_name, version.repo_name].some(function (field) {
+      return field && field.toLowerCase().indexOf(filter) !== -1;
+    });
+  }
+
+  function renderVersions() {
+    var filter = $("filter").value.trim().toLowerCase();
+    var projects = {};
+
+    Object.keys(versions).sort().forEach(function (appID) {
+      var version = versions[appID];
+      if (!matches(appID, version, filter)) {
+        return;
+      }
+      var project = version.project_id || "(no project)";
+      (projects[project] = projects[project] || []).push(appID);
+    });
+
+    var container = $("projects");
+    container.replaceChildren();
+
+    var names = Object.keys(projects).sort();
+    if (names.length === 0) {
+      container.appendChild(el("p", "No apps found.", "hint"));
+      return;
+    }
+
+    names.forEach(function (project) {
+      var first = versions[projects[project][0]];
+      var heading = container.appendChild(el("h3", first.repo_name ? project + " — " : project));
+      if (first.repo_name && /^https?:\/\//.test(first.web_url || "")) {
+        var repoLink = heading.appendChild(el("a", first.repo_name));
+        repoLink.href = first.web_url;
+      } else if (first.repo_name) {
+        heading.appendChild(document.createTextNode(first.repo_name));
+      }
+
+      var table = el("table");
+      var head = el("tr");
+      ["App", "Version", "Next", "Last updated"].forEach(function (title) {
+        head.appendChild(el("th", title));
+      });
+      table.appendChild(el("thead")).appendChild(head);
+
+      var body = table.appendChild(el("tbody"));
+      projects[project].forEach(function (appID) {
+        body.appendChild(versionRow(appID, versions[appID]));
+      });
+      container.appendChild(table);
+    });
+  }
+
+  function versionRow(appID, version) {
+    var row = el("tr");
+
+    var name = el("td");
+    var link = el("a", version.app_name || appID);
+    link.title = appID;
+    link.addEventListener("click", function () {
+      showHistory(appID);
+    });
+    name.appendChild(link);
+    if (version.locked) {
+      name.appendChild(el("span", "locked", "tag locked"));
+    }
+    Object.keys(version.aliases || {}).sort().forEach(function (alias) {
+      name.appendChild(el("span", alias + " " + version.aliases[alias], "tag"));
+    });
+    row.appendChild(name);
+
+    row.appendChild(el("td", version.current, "version"));
+    row.appendChild(el("td", version.next || "", "version"));
+    row.appendChild(el("td", formatTime(version.last_updated)));
+    return row;
+  }
+
+  function showHistory(appID) {
+    var rows = $("history-rows");
+    $("history-title").textContent = "History of " + appID;
+    rows.replaceChildren();
+    $("history").hidden = false;
+
+    fetchJSON("version/" + encodeURIComponent(appID) + "/history").then(function (entries) {
+      (entries || []).slice().reverse().forEach(function (entry) {
+        var row = el("tr");
+        row.appendChild(el("td", entry.version, "version"));
+        row.appendChild(el("td", formatTime(entry.timestamp)));
+        row.appendChild(el("td", (entry.commit || "").substring(0, 8), "commit"));
+        rows.appendChild(row);
+      });
+      $("history").scrollIntoView({ behavior: "smooth" });
+    }).catch(function (err) {
+      var row = el("tr");
+      var cell = el("td", "No history: " + err.message, "error");
+      cell.colSpan = 3;
+      row.appendChild(cell);
+      rows.appendChild(row);
+    });
+  }
+
+  function refresh() {
+    loadHealth();
+    loadPersistence();
+    loadVersions();
+  }
+
+  $("filter").addEventListener("input", renderVersions);
+  $("history-close").addEventListener("click", function () {
+    $("history").hidden = true;
+  });
+
+  refresh();
+  setInterval(refresh, refreshInterval);
+})();
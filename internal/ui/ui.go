@@ -0,0 +1,40 @@
+// Package ui serves the embedded read-only web UI
+package ui
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Path is where the UI is mounted
+const Path = "/ui"
+
+// contentSecurityPolicy keeps the UI to its own scripts and the service's
+// API; it never renders API data as HTML
+const contentSecurityPolicy = "default-src 'self'; object-src 'none'; frame-ancestors 'none'; base-uri 'none'"
+
+//go:embed static
+var staticFiles embed.FS
+
+// Register mounts the UI on router. The pages only read from the public GET
+// endpoints, so they need no credentials.
+func Register(router gin.IRoutes) {
+	static, _ := fs.Sub(staticFiles, "static")
+	files := http.FileServer(http.FS(static))
+
+	router.GET(Path, func(c *gin.Context) {
+		c.Redirect(http.StatusMovedPermanently, Path+"/")
+	})
+	router.GET(Path+"/*filepath", func(c *gin.Context) {
+		c.Header("Content-Security-Policy", contentSecurityPolicy)
+		c.Header("X-Content-Type-Options", "nosniff")
+		c.Header("Cache-Control", "no-cache")
+
+		req := c.Request.Clone(c.Request.Context())
+		req.URL.Path = c.Param("filepath")
+		files.ServeHTTP(c.Writer, req)
+	})
+}
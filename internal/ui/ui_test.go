@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	Register(router)
+	return router
+}
+
+func TestRegister_Routes(t *testing.T) {
+	router := newRouter()
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		status      int
+		contentType string
+	}{
+		{"index", http.MethodGet, "/ui/", http.StatusOK, "text/html"},
+		{"script", http.MethodGet, "/ui/app.js", http.StatusOK, "javascript"},
+		{"stylesheet", http.MethodGet, "/ui/style.css", http.StatusOK, "text/css"},
+		{"missing asset", http.MethodGet, "/ui/missing.js", http.StatusNotFound, ""},
+		{"no writes", http.MethodPost, "/ui/", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusOK {
+				assert.Contains(t, w.Header().Get("Content-Type"), tt.contentType)
+				assert.Equal(t, contentSecurityPolicy, w.Header().Get("Content-Security-Policy"))
+				assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ui", nil))
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/ui/", w.Header().Get("Location"))
+}
+
+// TestStaticFiles_ReadOnly keeps the UI read-only: its scripts may only send
+// plain GET requests, without credentials
+func TestStaticFiles_ReadOnly(t *testing.T) {
+	writes := regexp.MustCompile(`(?i)method\s*:|\b(POST|PUT|PATCH|DELETE)\b|Authorization|JOB-TOKEN|XMLHttpRequest|credentials`)
+
+	err := fs.WalkDir(staticFiles, "static", func(path string, d fs.DirEntry, err error) error {
+		require.NoError(t, err)
+		if d.IsDir() {
+			return nil
+		}
+		content, err := staticFiles.ReadFile(path)
+		require.NoError(t, err)
+		assert.Empty(t, writes.FindAllString(string(content), -1), path)
+		return nil
+	})
+	require.NoError(t, err)
+}
@@ -3,8 +3,11 @@ package main
 import (
 	"context"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"slices"
+	"strings"
 	"syscall"
 	"time"
 
@@ -12,10 +15,13 @@ import (
 	"github.com/company/version-service/internal/config"
 	"github.com/company/version-service/internal/handlers"
 	"github.com/company/version-service/internal/middleware"
+	"github.com/company/version-service/internal/models"
 	"github.com/company/version-service/internal/services"
 	"github.com/company/version-service/internal/storage"
+	"github.com/company/version-service/internal/ui"
+	"github.com/company/version-service/pkg/breaker"
+	"github.com/company/version-service/pkg/semver"
 	"github.com/gin-gonic/gin"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
 
 	swaggerFiles "github.com/swaggo/files"
@@ -40,6 +46,10 @@ import (
 // @BasePath  /
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "bootstrap" {
+		os.Exit(runBootstrap(os.Args[2:]))
+	}
+
 	logger := setupLogger()
 
 	cfg, err := config.Load()
@@ -47,40 +57,228 @@ func main() {
 		logger.WithError(err).Fatal("Failed to load configuration")
 	}
 
-	redisStorage, err := storage.NewRedisStorage(cfg.RedisURL, logger)
+	idScheme, err := models.NewIDScheme(cfg.AppIDScheme)
+	if err != nil {
+		logger.WithError(err).Fatal("Invalid APP_ID_SCHEME")
+	}
+
+	var gitBreaker, redisBreaker *breaker.Breaker
+	gitLabBreaker := newBreaker("gitlab", cfg, logger)
+	breakers := []*breaker.Breaker{gitLabBreaker}
+	if slices.Contains(cfg.StorageBackends, "git") {
+		gitBreaker = newBreaker("git", cfg, logger)
+		breakers = append(breakers, gitBreaker)
+	}
+	if !cfg.InMemory() {
+		redisBreaker = newBreaker("redis", cfg, logger)
+		breakers = append(breakers, redisBreaker)
+	}
+
+	cacheStorage, closeCache, err := newCacheStorage(cfg, idScheme, logger)
 	if err != nil {
-		logger.WithError(err).Fatal("Failed to initialize Redis storage")
+		logger.WithError(err).Fatal("Failed to initialize cache storage")
+	}
+	defer closeCache()
+	if pool, ok := cacheStorage.(storage.PoolStatter); ok {
+		middleware.RegisterRedisPoolMetrics(pool.PoolStats)
 	}
-	defer redisStorage.Close()
 
-	gitStorage, err := storage.NewGitStorage(cfg.GitRepoURL, cfg.GitBranch, cfg.GitUsername, cfg.GitToken, logger)
+	durableStorage, closeStorage, err := newDurableStorage(cfg, gitBreaker, logger)
 	if err != nil {
-		logger.WithError(err).Fatal("Failed to initialize Git storage")
+		logger.WithError(err).Fatal("Failed to initialize durable storage")
 	}
-	defer gitStorage.Close()
+	defer closeStorage()
 
 	gitLabClient := clients.NewGitLabClient(cfg.GitLabBaseURL, cfg.GitLabAccessToken, logger)
+	gitLabClient.LenientTags = cfg.GitLabLenientTags
+	gitLabClient.MaxRetries = cfg.GitLabMaxRetries
+	gitLabClient.RetryBaseDelay = cfg.GitLabRetryBaseDelay
+	gitLabClient.RetryMaxDelay = cfg.GitLabRetryMaxDelay
+	gitLabClient.Breaker = gitLabBreaker
+	tagProvider := newTagProvider(cfg, gitLabClient, logger)
+
+	usageWindows, err := services.ParseUsageWindows(cfg.UsageWindows)
+	if err != nil {
+		logger.WithError(err).Fatal("Invalid USAGE_WINDOWS")
+	}
+
+	normalization, err := services.ParseNormalization(cfg.VersionNormalization)
+	if err != nil {
+		logger.WithError(err).Fatal("Invalid VERSION_NORMALIZATION")
+	}
+
+	if cfg.DevVersionTemplate != "" {
+		if err := semver.ValidateDevTemplate(cfg.DevVersionTemplate); err != nil {
+			logger.WithError(err).Fatal("Invalid DEV_VERSION_TEMPLATE")
+		}
+	}
+
+	serviceOpts := services.Options{
+		Quotas: services.QuotaOptions{
+			MaxAppsPerProject:    cfg.QuotaMaxAppsPerProject,
+			MaxIncrementsPerHour: cfg.QuotaMaxIncrementsPerHour,
+			WarnThreshold:        cfg.QuotaWarnThreshold,
+			UsageWindows:         usageWindows,
+		},
+		IdempotencyTTL:      cfg.IdempotencyTTL,
+		DevVersionRetention: cfg.DevVersionRetention,
+		DevVersionCacheTTL:  cfg.DevVersionCacheTTL,
+		DevVersionTemplate:  cfg.DevVersionTemplate,
+		DevVersionBase:      cfg.DevVersionBase,
+		Discovery: services.DiscoveryOptions{
+			Groups:   cfg.DiscoveryGroups,
+			Interval: cfg.DiscoveryInterval,
+			Register: cfg.DiscoveryRegister,
+		},
+		Normalization:        normalization,
+		IDScheme:             idScheme,
+		TagProvider:          tagProvider,
+		ProjectPaths:         cfg.ProjectPaths,
+		RedisBreaker:         redisBreaker,
+		Breakers:             breakers,
+		RequireRegistration:  cfg.RequireAppRegistration,
+		WriteThrough:         cfg.WriteThrough(),
+		CacheRebuildInterval: cfg.CacheRebuildInterval,
+		Freshness: services.FreshnessOptions{
+			Target:    cfg.FreshnessTarget,
+			Objective: cfg.FreshnessObjective,
+		},
+		Stale: services.StaleOptions{
+			Days:     cfg.StaleAppDays,
+			Action:   cfg.StaleAppAction,
+			Interval: cfg.StaleCheckInterval,
+		},
+		Follower: services.FollowerOptions{
+			Enabled:      cfg.Follower(),
+			SyncInterval: cfg.FollowerSyncInterval,
+		},
+		Hooks: services.HookOptions{
+			Timeout:  cfg.HookTimeout,
+			FailOpen: cfg.HookFailOpen,
+		},
+		Canary: services.CanaryOptions{
+			Enabled: cfg.CanaryReads,
+		},
+		Releases: services.ReleaseOptions{
+			Enabled:     cfg.GitLabCreateReleases,
+			TagTemplate: cfg.GitLabReleaseTag,
+		},
+	}
+	for _, url := range cfg.PreIncrementHookURLs {
+		serviceOpts.Hooks.PreIncrement = append(serviceOpts.Hooks.PreIncrement, clients.NewWebhookClient(url, logger))
+	}
+	for _, url := range cfg.PostIncrementHookURLs {
+		serviceOpts.Hooks.PostIncrement = append(serviceOpts.Hooks.PostIncrement, clients.NewWebhookClient(url, logger))
+	}
+	if cfg.AlertWebhookURL != "" {
+		serviceOpts.Notifier = clients.NewWebhookClient(cfg.AlertWebhookURL, logger)
+	}
+	if cfg.FailoverJournalPath != "" {
+		journal, err := storage.NewFileJournal(cfg.FailoverJournalPath, logger)
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to initialize failover journal")
+		}
+		serviceOpts.Failover = services.FailoverOptions{
+			Journal:       journal,
+			Threshold:     cfg.FailoverThreshold,
+			CheckInterval: cfg.FailoverCheckInterval,
+		}
+	}
 
-	versionService := services.NewVersionService(redisStorage, gitStorage, gitLabClient, logger)
+	var outbox storage.Outbox
+	if cfg.AirGapped {
+		outbox, err = storage.NewFileOutbox(cfg.AirGapOutboxPath, logger)
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to initialize air-gap outbox")
+		}
+		gitLabClient.AirGapped = true
+		for _, hook := range serviceOpts.Hooks.PostIncrement {
+			hook.DeferTo(outbox)
+		}
+		if serviceOpts.Notifier != nil {
+			serviceOpts.Notifier.DeferTo(outbox)
+		}
+		serviceOpts.AirGap.Outbox = outbox
+		logger.WithField("outbox", cfg.AirGapOutboxPath).Info("Air-gapped mode enabled, outbound calls are deferred")
+	}
+
+	sloDefault := middleware.SLObjective{
+		Availability:     cfg.SLOAvailability,
+		Latency:          cfg.SLOLatency,
+		LatencyObjective: cfg.SLOLatencyObjective,
+	}
+	sloEndpoints, err := middleware.ParseSLOEndpoints(cfg.SLOEndpoints, sloDefault)
+	if err != nil {
+		logger.WithError(err).Fatal("Invalid SLO_ENDPOINTS")
+	}
+	sli := middleware.NewSLITracker(middleware.SLIOptions{
+		Default:            sloDefault,
+		Endpoints:          sloEndpoints,
+		Window:             cfg.SLOWindow,
+		ConservativeBudget: cfg.SLOConservativeBudget,
+		ShedConcurrency:    cfg.SLOShedConcurrency,
+	}, logger)
+
+	// Conservative mode stops waiting on Git under write-through
+	serviceOpts.Conservative = sli.Conservative
+
+	versionService := services.NewVersionService(cacheStorage, durableStorage, gitLabClient, logger, serviceOpts)
 
 	ctx := context.Background()
 	if err := versionService.Initialize(ctx); err != nil {
 		logger.WithError(err).Error("Failed to initialize version service")
 	}
 
-	router := setupRouter(versionService, logger)
+	// Rate limits are kept in Redis when it is used, so they hold across
+	// replicas
+	limiter, _ := cacheStorage.(storage.RateLimiter)
+	router := setupRouter(cfg, versionService, idScheme, sli, outbox, limiter, logger)
+
+	tlsConfig, err := serverTLSConfig(cfg)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to configure TLS")
+	}
 
 	srv := &http.Server{
 		Addr:         ":" + cfg.Port,
 		Handler:      router,
+		TLSConfig:    tlsConfig,
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
 
+	// Probes can't present client certificates, so health and metrics are
+	// also served over plain HTTP when asked for
+	var healthSrv *http.Server
+	if cfg.HealthPort != "" {
+		healthSrv = &http.Server{
+			Addr:         ":" + cfg.HealthPort,
+			Handler:      healthRouter(versionService, logger),
+			ReadTimeout:  15 * time.Second,
+			WriteTimeout: 15 * time.Second,
+			IdleTimeout:  60 * time.Second,
+		}
+		go func() {
+			logger.WithField("port", cfg.HealthPort).Info("Starting health server")
+			if err := healthSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.WithError(err).Fatal("Failed to start health server")
+			}
+		}()
+	}
+
 	go func() {
-		logger.WithField("port", cfg.Port).Info("Starting server")
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		logger.WithFields(logrus.Fields{
+			"port":        cfg.Port,
+			"tls":         tlsConfig != nil,
+			"client_auth": cfg.TLSClientCAFile != "",
+		}).Info("Starting server")
+		serve := srv.ListenAndServe
+		if tlsConfig != nil {
+			// The certificate is already loaded into TLSConfig
+			serve = func() error { return srv.ListenAndServeTLS("", "") }
+		}
+		if err := serve(); err != nil && err != http.ErrServerClosed {
 			logger.WithError(err).Fatal("Failed to start server")
 		}
 	}()
@@ -97,10 +295,79 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		logger.WithError(err).Error("Server forced to shutdown")
 	}
+	if healthSrv != nil {
+		if err := healthSrv.Shutdown(ctx); err != nil {
+			logger.WithError(err).Error("Health server forced to shutdown")
+		}
+	}
 
 	logger.Info("Server exited")
 }
 
+// newBreaker returns the circuit breaker around a dependency, publishing its
+// state as a metric and logging its transitions; nil when
+// CIRCUIT_BREAKER_THRESHOLD is 0
+func newBreaker(name string, cfg *config.Config, logger *logrus.Logger) *breaker.Breaker {
+	b := breaker.New(name, cfg.CircuitBreakerThreshold, cfg.CircuitBreakerCooldown)
+	if b == nil {
+		return nil
+	}
+
+	middleware.SetCircuitBreakerState(name, breaker.Closed, false)
+	b.OnStateChange = func(name string, from, to breaker.State) {
+		middleware.SetCircuitBreakerState(name, to, true)
+		entry := logger.WithFields(logrus.Fields{
+			"dependency": name,
+			"from":       from.String(),
+			"to":         to.String(),
+		})
+		if to == breaker.Open {
+			entry.Warn("Circuit breaker opened, failing calls fast")
+		} else {
+			entry.Info("Circuit breaker state changed")
+		}
+	}
+	return b
+}
+
+// newTagProvider returns the registry new apps' initial versions are looked
+// up with: the providers of TAG_PROVIDER as its chain, and the projects of
+// TAG_PROVIDER_ROUTES and BITBUCKET_REPOS routed to theirs. Validated in
+// config.Load.
+func newTagProvider(cfg *config.Config, gitLabClient *clients.GitLabClient, logger *logrus.Logger) clients.TagProvider {
+	registry := clients.NewTagProviderRegistry(logger)
+	registry.Register(clients.TagProviderGitLab, gitLabClient)
+
+	gitHubClient := clients.NewGitHubClient(cfg.GitHubBaseURL, cfg.GitHubToken, logger)
+	gitHubClient.LenientTags = cfg.GitLabLenientTags
+	gitHubClient.AirGapped = cfg.AirGapped
+	registry.Register(clients.TagProviderGitHub, gitHubClient)
+
+	if cfg.GiteaBaseURL != "" {
+		giteaClient := clients.NewGiteaClient(cfg.GiteaBaseURL, cfg.GiteaToken, logger)
+		giteaClient.LenientTags = cfg.GitLabLenientTags
+		giteaClient.AirGapped = cfg.AirGapped
+		registry.Register(clients.TagProviderGitea, giteaClient)
+	}
+	if cfg.BitbucketBaseURL != "" {
+		bitbucketClient := clients.NewBitbucketClient(cfg.BitbucketBaseURL, cfg.BitbucketToken, logger)
+		bitbucketClient.LenientTags = cfg.GitLabLenientTags
+		bitbucketClient.AirGapped = cfg.AirGapped
+		registry.Register(clients.TagProviderBitbucket, bitbucketClient)
+	}
+
+	registry.SetChain(cfg.TagProviders...)
+	for projectID, route := range cfg.TagProviderRoutes {
+		registry.Route(projectID, route.Provider, route.Repository)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"chain":  strings.Join(cfg.TagProviders, ","),
+		"routes": len(cfg.TagProviderRoutes),
+	}).Info("Tag providers configured")
+	return registry
+}
+
 func setupLogger() *logrus.Logger {
 	logger := logrus.New()
 	logger.SetFormatter(&logrus.JSONFormatter{})
@@ -122,37 +389,174 @@ func setupLogger() *logrus.Logger {
 	return logger
 }
 
-func setupRouter(service *services.VersionService, logger *logrus.Logger) *gin.Engine {
+func setupRouter(cfg *config.Config, service *services.VersionService, idScheme models.IDScheme, sli *middleware.SLITracker, outbox storage.Outbox, limiter storage.RateLimiter, logger *logrus.Logger) *gin.Engine {
 	if os.Getenv("GIN_MODE") == "" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
 	router := gin.New()
+	// Match routes on the escaped path so app IDs of the path scheme can be
+	// sent with %2F-encoded slashes
+	router.UseRawPath = true
+	router.UnescapePathValues = true
+	// Validated in config.Load; no proxies are trusted unless configured
+	_ = router.SetTrustedProxies(cfg.TrustedProxies)
 	router.Use(gin.Recovery())
 	router.Use(middleware.LoggingMiddleware(logger))
+	if cfg.TracingEnabled {
+		router.Use(middleware.TracingMiddleware())
+	}
 	router.Use(middleware.MetricsMiddleware())
+	if cfg.GitLabDelegatedTokens {
+		router.Use(middleware.DelegatedTokenMiddleware(service.VerifyJobToken, logger))
+	}
 
 	router.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("X-Version-Service", "1.0.0")
 		c.Next()
 	})
 
+	if cfg.Follower() {
+		// Validated in config.Load
+		primary, _ := url.Parse(cfg.PrimaryURL)
+		router.Use(middleware.FollowerProxy(primary, logger, "/admin/cache/purge", "/admin/cache/rebuild"))
+	}
+	router.Use(middleware.ImpersonationMiddleware(cfg.AdminToken, logger))
+
+	var purgeNotifier *clients.WebhookClient
+	if cfg.CachePurgeWebhookURL != "" {
+		purgeNotifier = clients.NewWebhookClient(cfg.CachePurgeWebhookURL, logger)
+		if outbox != nil {
+			purgeNotifier.DeferTo(outbox)
+		}
+	}
+	cache := middleware.NewResponseCache(cfg.ResponseCacheTTL, cfg.ResponseCacheMaxEntries, idScheme, purgeNotifier, logger)
+	purge := cache.PurgeOnWrite()
+	purgeAll := cache.PurgeAllOnWrite()
+
 	handler := handlers.NewHandler(service, logger)
+	handler.SetResponseCache(cache)
+	handler.SetWriteThrough(cfg.WriteThrough())
+	handler.SetStrictJSON(cfg.StrictJSON)
+	if len(cfg.RBACGrants) > 0 {
+		grants := make([]models.RoleGrant, 0, len(cfg.RBACGrants))
+		for _, grant := range cfg.RBACGrants {
+			grants = append(grants, models.RoleGrant{Subject: grant.Subject, Role: models.Role(grant.Role), ProjectID: grant.ProjectID})
+		}
+		handler.SetRBACPolicy(models.NewRBACPolicy(grants))
+		cache.SetPrivate(true)
+		logger.WithField("grants", len(grants)).Info("Role-based authorization enabled")
+	}
+	// Roles are checked before the cache answers, as hits skip the handlers
+	authorizeRead, cacheResponse := handler.AuthorizeRead(), cache.Cache()
+	cached := func(c *gin.Context) {
+		if authorizeRead(c); !c.IsAborted() {
+			cacheResponse(c)
+		}
+	}
+	// Writes through other replicas purge this replica's cache as well
+	service.OnRemoteChange(cache.PurgeChange)
 
 	router.GET("/health", handler.Health)
-	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	router.GET("/metrics", gin.WrapH(metricsHandler()))
+	router.GET("/freshness", handler.GetFreshnessReport)
+	router.GET("/stats/runtime", handler.GetRuntimeStats)
+	router.GET("/schemas", handler.ListSchemas)
+	router.GET("/schemas/:event", handler.GetSchema)
+	router.GET("/schemas/:event/:version", handler.GetSchema)
 
 	// Swagger documentation
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	if cfg.UIEnabled {
+		ui.Register(router)
+	}
+
 	v1 := router.Group("/")
+	// Health, metrics and docs above are neither measured nor shed
+	v1.Use(sli.Middleware())
+	v1.Use(middleware.BodyLimitMiddleware(int64(cfg.MaxRequestBodyBytes), int64(cfg.MaxBulkRequestBodyBytes), "/versions/raw", "/admin/state"))
+	if cfg.OIDCIssuerURL != "" {
+		oidc := clients.NewOIDCVerifier(cfg.OIDCIssuerURL, cfg.OIDCAudiences, cfg.OIDCJWKSURL, cfg.OIDCJWKSRefresh, 10*time.Second)
+		v1.Use(middleware.OIDCMiddleware(oidc.Verify, cfg.OIDCSubjectClaim, cfg.OIDCClaims, cfg.AdminToken, logger))
+		logger.WithField("issuer", oidc.Issuer()).Info("OIDC token authentication enabled")
+	}
+	// Writes need an API key or OIDC token once either is configured
+	if len(cfg.APIKeys) > 0 || cfg.OIDCIssuerURL != "" {
+		v1.Use(middleware.APIKeyMiddleware(cfg.APIKeys, cfg.APIKeysRequireReads, cfg.AdminToken, logger))
+	}
+	if (cfg.RateLimitRequests > 0 || cfg.RateLimitWriteRequests > 0) && limiter != nil {
+		v1.Use(middleware.RateLimitMiddleware(limiter.TakeToken, middleware.RateLimitOptions{
+			Requests:      cfg.RateLimitRequests,
+			WriteRequests: cfg.RateLimitWriteRequests,
+			Window:        cfg.RateLimitWindow,
+		}, cfg.AdminToken, logger))
+		logger.WithFields(logrus.Fields{
+			"requests":       cfg.RateLimitRequests,
+			"write_requests": cfg.RateLimitWriteRequests,
+			"window":         cfg.RateLimitWindow,
+		}).Info("Per-client rate limiting enabled")
+	}
+	// Apps and projects may be named by GitLab project path
+	v1.Use(handler.ResolveProjectPaths())
+	if cfg.OPAURL != "" {
+		opa := clients.NewOPAClient(cfg.OPAURL, cfg.OPAPolicyPath, cfg.OPATimeout)
+		v1.Use(middleware.AuthorizationMiddleware(cfg.AdminToken, idScheme, opa.Authorize, cfg.OPAFailOpen, logger))
+		logger.WithField("url", opa.URL()).Info("Authorization delegated to OPA")
+	}
 	{
-		v1.GET("/version/:app-id", handler.GetVersion)
-		v1.POST("/version/:app-id/increment", handler.IncrementVersion)
+		v1.GET("/version/compare", handler.CompareVersions)
+		v1.GET("/version/:app-id", cached, handler.GetVersion)
+		v1.POST("/version/:app-id/increment", purge, handler.IncrementVersion)
+		v1.GET("/version/:app-id/next", cached, handler.PreviewNextVersion)
 		v1.POST("/version/:app-id/dev", handler.GetDevVersion)
-		v1.GET("/versions", handler.ListVersions)
-		v1.GET("/versions/:project-id", handler.ListVersionsByProject)
-		v1.DELETE("/delete/:id", handler.DeleteVersion)
+		v1.GET("/version/:app-id/dev/issued", handler.ListDevVersions)
+		v1.GET("/version/:app-id/history", handler.GetVersionHistory)
+		v1.GET("/version/:app-id/alias/:name", cached, handler.GetVersionAlias)
+		v1.PUT("/version/:app-id/alias/:name", purge, handler.SetVersionAlias)
+		v1.PATCH("/version/:app-id/metadata", purge, handler.UpdateVersionMetadata)
+		v1.GET("/version/:app-id/changelog", handler.GetChangelog)
+		v1.GET("/version/:app-id/reserved", handler.GetReservedVersions)
+		v1.PUT("/version/:app-id/reserved", purge, handler.SetReservedVersions)
+		v1.POST("/version/:app-id/rollback", purge, handler.RollbackVersion)
+		v1.POST("/version/:app-id/decrement", purge, handler.DecrementVersion)
+		v1.POST("/version/:app-id/promote", purge, handler.PromoteVersion)
+		v1.POST("/version/:app-id/graduate", purge, handler.GraduateVersion)
+		v1.POST("/version/:app-id/lock", middleware.AdminAuthMiddleware(cfg.AdminToken), purge, handler.LockVersion)
+		v1.POST("/version/:app-id/unlock", middleware.AdminAuthMiddleware(cfg.AdminToken), purge, handler.UnlockVersion)
+		v1.PUT("/version/:app-id/lifecycle", middleware.AdminAuthMiddleware(cfg.AdminToken), purge, handler.SetLifecycle)
+		v1.PUT("/version/:app-id/strategy", middleware.AdminAuthMiddleware(cfg.AdminToken), purge, handler.SetIncrementStrategy)
+		v1.PUT("/version/:app-id/initial-development", middleware.AdminAuthMiddleware(cfg.AdminToken), purge, handler.SetInitialDevelopment)
+		v1.POST("/apps", purgeAll, handler.RegisterApp)
+		v1.GET("/versions", cached, handler.ListVersions)
+		v1.POST("/versions/increment", purgeAll, handler.IncrementVersions)
+		v1.GET("/versions/raw", cached, handler.GetRawVersionsFile)
+		v1.GET("/versions/stale", handler.ListStaleVersions)
+		v1.PUT("/versions/raw", middleware.AdminAuthMiddleware(cfg.AdminToken), purgeAll, handler.ReplaceVersionsFile)
+		v1.GET("/versions/:project-id", cached, handler.ListVersionsByProject)
+		v1.DELETE("/delete/:id", purge, handler.DeleteVersion)
+		v1.POST("/version/:app-id/restore", purge, handler.RestoreVersion)
+		v1.POST("/version/:app-id/rename", purgeAll, handler.RenameVersion)
+		// Usage feeds quota decisions and alerts, so it is never served stale
+		v1.GET("/projects/:project-id/usage", handler.GetProjectUsage)
+		v1.POST("/projects/:project-id/simulate", handler.SimulateProject)
+
+		projectAuth := middleware.ProjectAuthMiddleware(cfg.AdminToken, service.CanAccessProject)
+		v1.GET("/projects/:project-id/reserved", handler.GetProjectReservedVersions)
+		v1.PUT("/projects/:project-id/reserved", projectAuth, handler.SetProjectReservedVersions)
+		v1.GET("/projects/:project-id/webhooks", projectAuth, handler.ListWebhooks)
+		v1.POST("/projects/:project-id/webhooks", projectAuth, handler.CreateWebhook)
+		v1.DELETE("/projects/:project-id/webhooks/:webhook-id", projectAuth, handler.DeleteWebhook)
+
+		v1.GET("/discovery", handler.GetDiscoveryReport)
+		v1.POST("/discovery/run", middleware.AdminAuthMiddleware(cfg.AdminToken), purgeAll, handler.RunDiscovery)
+		v1.POST("/admin/cache/purge", middleware.AdminAuthMiddleware(cfg.AdminToken), handler.PurgeCache)
+		v1.POST("/admin/cache/rebuild", middleware.AdminAuthMiddleware(cfg.AdminToken), purgeAll, handler.RebuildCache)
+		v1.GET("/admin/state", middleware.AdminAuthMiddleware(cfg.AdminToken), handler.ExportState)
+		v1.PUT("/admin/state", middleware.AdminAuthMiddleware(cfg.AdminToken), purgeAll, handler.ImportState)
+		v1.POST("/admin/projects/migrate", middleware.AdminAuthMiddleware(cfg.AdminToken), purgeAll, handler.MigrateProjects)
+		v1.GET("/admin/outbox", middleware.AdminAuthMiddleware(cfg.AdminToken), handler.ListDeferredCalls)
+		v1.POST("/admin/outbox/flush", middleware.AdminAuthMiddleware(cfg.AdminToken), purgeAll, handler.FlushDeferredCalls)
 	}
 
 	router.NoRoute(func(c *gin.Context) {
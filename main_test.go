@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/company/version-service/internal/config"
+	"github.com/company/version-service/internal/middleware"
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/services"
+	"github.com/company/version-service/internal/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRouter_AuthorizationActionsNamed sends a request to every route and
+// fails on any the policy engine would see under the "METHOD /route"
+// fallback, so new routes can't ship without an action name
+func TestRouter_AuthorizationActionsNamed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	var mu sync.Mutex
+	actions := map[string]string{}
+	opa := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Input models.AuthorizationInput `json:"input"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		actions[body.Input.Method+" "+body.Input.Path] = body.Input.Action
+		mu.Unlock()
+		// Deny everything so no handler runs
+		w.Write([]byte(`{"result": false}`))
+	}))
+	defer opa.Close()
+
+	cache, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	durable, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	service := services.NewVersionService(cache, durable, nil, logger, services.Options{})
+	cfg := &config.Config{
+		OPAURL:                  opa.URL,
+		OPAPolicyPath:           "versions/allow",
+		MaxRequestBodyBytes:     1 << 20,
+		MaxBulkRequestBodyBytes: 1 << 20,
+	}
+	sli := middleware.NewSLITracker(middleware.SLIOptions{}, logger)
+	router := setupRouter(cfg, service, models.DefaultIDScheme(), sli, nil, nil, logger)
+
+	checked := 0
+	for _, route := range router.Routes() {
+		path := route.Path
+		segments := strings.Split(path, "/")
+		for i, segment := range segments {
+			switch {
+			case strings.HasPrefix(segment, ":app-id"), strings.HasPrefix(segment, ":id"):
+				segments[i] = "1-api"
+			case strings.HasPrefix(segment, ":"), strings.HasPrefix(segment, "*"):
+				segments[i] = "1"
+			}
+		}
+		path = strings.Join(segments, "/")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(route.Method, path, strings.NewReader("{}")))
+
+		mu.Lock()
+		action, ok := actions[route.Method+" "+path]
+		mu.Unlock()
+		if !ok {
+			// Health, metrics, docs and the UI aren't authorized
+			continue
+		}
+		checked++
+		assert.NotEqual(t, route.Method+" "+route.Path, action, "route has no authorization action name")
+	}
+	assert.NotZero(t, checked)
+}
+
+// TestRouter_TrustedProxies checks that X-Forwarded-For only picks the rate
+// limit bucket when it comes from a trusted proxy
+func TestRouter_TrustedProxies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	send := func(router *gin.Engine, forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodGet, "/version/1-api", nil)
+		req.RemoteAddr = "10.0.0.1:4321"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	for name, tc := range map[string]struct {
+		proxies []string
+		limited bool
+	}{
+		"spoofed header ignored by default": {nil, true},
+		"header of a trusted proxy":         {[]string{"10.0.0.0/8"}, false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			memory, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+			require.NoError(t, err)
+			service := services.NewVersionService(memory, memory, nil, logger, services.Options{})
+			cfg := &config.Config{
+				RateLimitRequests:       1,
+				RateLimitWindow:         time.Hour,
+				TrustedProxies:          tc.proxies,
+				MaxRequestBodyBytes:     1 << 20,
+				MaxBulkRequestBodyBytes: 1 << 20,
+			}
+			sli := middleware.NewSLITracker(middleware.SLIOptions{}, logger)
+			router := setupRouter(cfg, service, models.DefaultIDScheme(), sli, nil, memory, logger)
+
+			assert.NotEqual(t, http.StatusTooManyRequests, send(router, "203.0.113.1"))
+			assert.Equal(t, tc.limited, send(router, "203.0.113.2") == http.StatusTooManyRequests)
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrOpen is returned by Allow while the breaker is open: the dependency
+// failed too often to be called again yet
+var ErrOpen = errors.New("circuit breaker open")
+
+// State is the state of a breaker
+type State int
+
+const (
+	// Closed lets every call through
+	Closed State = iota
+	// Open refuses every call until the cooldown has passed
+	Open
+	// HalfOpen lets a single probe through; its outcome closes or reopens
+	// the breaker
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// Breaker guards calls to one dependency. It opens after threshold
+// consecutive failures and then refuses calls with ErrOpen, so callers fall
+// back at once instead of waiting for timeouts. After the cooldown one probe
+// is let through; it closes the breaker on success and reopens it on
+// failure.
+//
+// Every call Allow lets through should be followed by Success or Failure; a
+// probe that reports neither is given up after another cooldown. A nil
+// Breaker lets every call through.
+type Breaker struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+
+	// OnStateChange, if set, is called after every transition, outside the
+	// breaker's lock
+	OnStateChange func(name string, from, to State)
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	// probeAt is when the outstanding probe of a half-open breaker was let
+	// through; zero when none is
+	probeAt time.Time
+	now     func() time.Time
+}
+
+// New creates a closed breaker for the named dependency. It opens after
+// threshold consecutive failures and probes again after cooldown. A
+// threshold below 1 returns nil, which never opens.
+func New(name string, threshold int, cooldown time.Duration) *Breaker {
+	if threshold < 1 {
+		return nil
+	}
+	return &Breaker{
+		name:      name,
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// Name returns the dependency the breaker guards
+func (b *Breaker) Name() string {
+	if b == nil {
+		return ""
+	}
+	return b.name
+}
+
+// Allow reports whether a call may go ahead. It returns ErrOpen while the
+// breaker is open, and while a probe of a half-open breaker is outstanding.
+func (b *Breaker) Allow() error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	from, now := b.state, b.now()
+	switch b.state {
+	case Open:
+		if now.Sub(b.openedAt) < b.cooldown {
+			b.mu.Unlock()
+			return ErrOpen
+		}
+		b.state, b.probeAt = HalfOpen, now
+	case HalfOpen:
+		if !b.probeAt.IsZero() && now.Sub(b.probeAt) < b.cooldown {
+			b.mu.Unlock()
+			return ErrOpen
+		}
+		b.probeAt = now
+	}
+	to := b.state
+	b.mu.Unlock()
+
+	b.changed(from, to)
+	return nil
+}
+
+// Success records a call that reached the dependency, closing the breaker
+func (b *Breaker) Success() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	from := b.state
+	b.state, b.failures, b.probeAt = Closed, 0, time.Time{}
+	b.mu.Unlock()
+
+	b.changed(from, Closed)
+}
+
+// Failure records a call the dependency failed. It opens the breaker after
+// threshold consecutive failures, or at once after a failed probe.
+func (b *Breaker) Failure() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	from := b.state
+	b.failures++
+	if b.state == HalfOpen || b.failures >= b.threshold {
+		b.state, b.openedAt, b.probeAt = Open, b.now(), time.Time{}
+	}
+	to := b.state
+	b.mu.Unlock()
+
+	b.changed(from, to)
+}
+
+// Snapshot describes a breaker at one moment
+type Snapshot struct {
+	Name  string
+	State State
+	// Failures counts the consecutive failures since the last success
+	Failures int
+	// OpenedAt is when the breaker last opened; zero if it never did
+	OpenedAt time.Time
+}
+
+// Snapshot returns the breaker's current state. A breaker whose cooldown
+// has passed still reports Open until the next call probes.
+func (b *Breaker) Snapshot() Snapshot {
+	if b == nil {
+		return Snapshot{}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return Snapshot{
+		Name:     b.name,
+		State:    b.state,
+		Failures: b.failures,
+		OpenedAt: b.openedAt,
+	}
+}
+
+// State returns the breaker's current state
+func (b *Breaker) State() State {
+	return b.Snapshot().State
+}
+
+func (b *Breaker) changed(from, to State) {
+	if from != to && b.OnStateChange != nil {
+		b.OnStateChange(b.name, from, to)
+	}
+}
@@ -0,0 +1,84 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestBreaker(threshold int, cooldown time.Duration) (*Breaker, *time.Time) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	b := New("test", threshold, cooldown)
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+func TestBreaker_OpensAfterConsecutiveFailures(t *testing.T) {
+	b, _ := newTestBreaker(3, time.Minute)
+
+	b.Failure()
+	b.Failure()
+	b.Success()
+	b.Failure()
+	b.Failure()
+	assert.Equal(t, Closed, b.State(), "a success resets the count")
+	assert.NoError(t, b.Allow())
+
+	b.Failure()
+	assert.Equal(t, Open, b.State())
+	assert.ErrorIs(t, b.Allow(), ErrOpen)
+}
+
+func TestBreaker_HalfOpenProbe(t *testing.T) {
+	b, now := newTestBreaker(1, time.Minute)
+	b.Failure()
+	assert.ErrorIs(t, b.Allow(), ErrOpen)
+
+	*now = now.Add(time.Minute)
+	assert.NoError(t, b.Allow(), "the first call after the cooldown probes")
+	assert.Equal(t, HalfOpen, b.State())
+	assert.ErrorIs(t, b.Allow(), ErrOpen, "only one probe at a time")
+
+	b.Failure()
+	assert.Equal(t, Open, b.State(), "a failed probe reopens")
+	assert.ErrorIs(t, b.Allow(), ErrOpen)
+
+	*now = now.Add(time.Minute)
+	assert.NoError(t, b.Allow())
+	b.Success()
+	assert.Equal(t, Closed, b.State())
+	assert.NoError(t, b.Allow())
+	assert.NoError(t, b.Allow())
+}
+
+func TestBreaker_OnStateChange(t *testing.T) {
+	b, now := newTestBreaker(1, time.Second)
+	var transitions []string
+	b.OnStateChange = func(name string, from, to State) {
+		transitions = append(transitions, name+": "+from.String()+" -> "+to.String())
+	}
+
+	b.Failure()
+	b.Failure()
+	*now = now.Add(time.Second)
+	assert.NoError(t, b.Allow())
+	b.Success()
+	b.Success()
+
+	assert.Equal(t, []string{
+		"test: closed -> open",
+		"test: open -> half-open",
+		"test: half-open -> closed",
+	}, transitions)
+}
+
+func TestBreaker_Disabled(t *testing.T) {
+	b := New("test", 0, time.Minute)
+	assert.Nil(t, b)
+
+	b.Failure()
+	b.Failure()
+	assert.NoError(t, b.Allow())
+	assert.Equal(t, Closed, b.State())
+}
@@ -0,0 +1,93 @@
+package calver
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var calverRegex = regexp.MustCompile(`^(\d{4})\.(\d{2})\.(0|[1-9]\d*)$`)
+
+// Version is a calendar version in the YYYY.0M.MICRO format (2024.06.1):
+// the year and zero-padded month of the release and a counter of releases
+// within that month
+type Version struct {
+	Year  int
+	Month int
+	Micro int
+}
+
+func Parse(version string) (*Version, error) {
+	matches := calverRegex.FindStringSubmatch(version)
+	if matches == nil {
+		return nil, fmt.Errorf("invalid calendar version: %s", version)
+	}
+
+	year, _ := strconv.Atoi(matches[1])
+	month, _ := strconv.Atoi(matches[2])
+	micro, err := strconv.Atoi(matches[3])
+	if err != nil {
+		return nil, fmt.Errorf("invalid calendar version: %s", version)
+	}
+	if month < 1 || month > 12 {
+		return nil, fmt.Errorf("invalid month in calendar version: %s", version)
+	}
+
+	return &Version{Year: year, Month: month, Micro: micro}, nil
+}
+
+func (v *Version) String() string {
+	return fmt.Sprintf("%04d.%02d.%d", v.Year, v.Month, v.Micro)
+}
+
+// Initial returns the version of an app without releases yet: the month of
+// now with micro 0, so its first release is MICRO 1
+func Initial(now time.Time) *Version {
+	return &Version{Year: now.Year(), Month: int(now.Month()), Micro: 0}
+}
+
+// Next returns the release following v at now. A release in the same month
+// bumps the micro counter; the first release of a new month starts it at 1.
+// A clock behind v's month never moves the version backwards.
+func (v *Version) Next(now time.Time) *Version {
+	period := Initial(now)
+	if period.Year < v.Year || (period.Year == v.Year && period.Month <= v.Month) {
+		return &Version{Year: v.Year, Month: v.Month, Micro: v.Micro + 1}
+	}
+	period.Micro = 1
+	return period
+}
+
+// Compare orders v against other: by year, month and micro counter
+func (v *Version) Compare(other *Version) int {
+	if v.Year != other.Year {
+		return compareInt(v.Year, other.Year)
+	}
+	if v.Month != other.Month {
+		return compareInt(v.Month, other.Month)
+	}
+	return compareInt(v.Micro, other.Micro)
+}
+
+func Compare(v1, v2 string) (int, error) {
+	version1, err := Parse(v1)
+	if err != nil {
+		return 0, err
+	}
+	version2, err := Parse(v2)
+	if err != nil {
+		return 0, err
+	}
+	return version1.Compare(version2), nil
+}
+
+func compareInt(a, b int) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
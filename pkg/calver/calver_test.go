@@ -0,0 +1,91 @@
+package calver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    *Version
+		wantErr bool
+	}{
+		{input: "2024.06.1", want: &Version{Year: 2024, Month: 6, Micro: 1}},
+		{input: "2024.12.0", want: &Version{Year: 2024, Month: 12, Micro: 0}},
+		{input: "2024.06.15", want: &Version{Year: 2024, Month: 6, Micro: 15}},
+		{input: "2024.6.1", wantErr: true},
+		{input: "24.06.1", wantErr: true},
+		{input: "2024.13.1", wantErr: true},
+		{input: "2024.00.1", wantErr: true},
+		{input: "2024.06.01", wantErr: true},
+		{input: "2024.06.1-rc.1", wantErr: true},
+		{input: "1.2.3", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.input, got.String())
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	june := time.Date(2024, time.June, 20, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		current string
+		now     time.Time
+		want    string
+	}{
+		{name: "same month", current: "2024.06.1", now: june, want: "2024.06.2"},
+		{name: "first release of the month", current: "2024.06.0", now: june, want: "2024.06.1"},
+		{name: "new month", current: "2024.05.7", now: june, want: "2024.06.1"},
+		{name: "new year", current: "2023.12.3", now: june, want: "2024.06.1"},
+		{name: "clock behind", current: "2024.07.2", now: june, want: "2024.07.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := Parse(tt.current)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, v.Next(tt.now).String())
+		})
+	}
+}
+
+func TestInitial(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, "2024.03.0", Initial(now).String())
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		v1, v2 string
+		want   int
+	}{
+		{"2024.06.1", "2024.06.1", 0},
+		{"2024.06.1", "2024.06.2", -1},
+		{"2024.06.10", "2024.06.9", 1},
+		{"2024.05.9", "2024.06.1", -1},
+		{"2025.01.1", "2024.12.9", 1},
+	}
+
+	for _, tt := range tests {
+		got, err := Compare(tt.v1, tt.v2)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, got, "%s vs %s", tt.v1, tt.v2)
+	}
+
+	_, err := Compare("2024.06.1", "1.2.3")
+	assert.Error(t, err)
+}
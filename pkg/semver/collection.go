@@ -0,0 +1,166 @@
+package semver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Sort returns the valid versions among versions in ascending precedence.
+// Invalid versions are dropped; versions of equal precedence, such as ones
+// differing only in build metadata, keep their input order.
+func Sort(versions []string) []string {
+	parsed := parseAll(versions)
+	sort.SliceStable(parsed, func(i, j int) bool {
+		return parsed[i].version.Compare(parsed[j].version) < 0
+	})
+
+	sorted := make([]string, len(parsed))
+	for i, p := range parsed {
+		sorted[i] = p.raw
+	}
+	return sorted
+}
+
+// Latest returns the valid version of highest precedence among versions, or
+// an empty string when none is valid. Of equal versions the first wins.
+func Latest(versions []string) string {
+	var latest *parsedVersion
+	for _, p := range parseAll(versions) {
+		if latest == nil || p.version.Compare(latest.version) > 0 {
+			p := p
+			latest = &p
+		}
+	}
+
+	if latest == nil {
+		return ""
+	}
+	return latest.raw
+}
+
+// Filter returns the valid versions satisfying constraint, in input order.
+// See ParseConstraint for the constraint syntax.
+func Filter(versions []string, constraint string) ([]string, error) {
+	c, err := ParseConstraint(constraint)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([]string, 0, len(versions))
+	for _, p := range parseAll(versions) {
+		if c.Check(p.version) {
+			matched = append(matched, p.raw)
+		}
+	}
+	return matched, nil
+}
+
+type parsedVersion struct {
+	raw     string
+	version *Version
+}
+
+func parseAll(versions []string) []parsedVersion {
+	parsed := make([]parsedVersion, 0, len(versions))
+	for _, raw := range versions {
+		if version, err := Parse(raw); err == nil {
+			parsed = append(parsed, parsedVersion{raw: raw, version: version})
+		}
+	}
+	return parsed
+}
+
+// Constraint is a set of comparisons a version must all satisfy
+type Constraint struct {
+	comparisons []comparison
+}
+
+type comparison struct {
+	op      string
+	version *Version
+}
+
+// constraintOperators are the supported operators, longest first so prefixes
+// match correctly
+var constraintOperators = []string{">=", "<=", "!=", ">", "<", "=", "~", "^"}
+
+// ParseConstraint parses comparisons separated by commas or spaces, all of
+// which must hold, such as ">=1.2.0, <2.0.0". Operators are =, !=, >, >=, <,
+// <=, ~ (same major and minor, at least the given version) and ^ (same major,
+// at least the given version; for 0.x the same minor). A bare version means
+// =. Comparisons use SemVer precedence, so prereleases are ordered before
+// their release and build metadata is ignored.
+func ParseConstraint(constraint string) (*Constraint, error) {
+	fields := strings.FieldsFunc(constraint, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty version constraint")
+	}
+
+	c := &Constraint{}
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+
+		op := "="
+		for _, candidate := range constraintOperators {
+			if strings.HasPrefix(field, candidate) {
+				op = candidate
+				field = field[len(candidate):]
+				break
+			}
+		}
+
+		// Allow a space between operator and version (">= 1.2.0")
+		if field == "" && i+1 < len(fields) {
+			i++
+			field = fields[i]
+		}
+
+		version, err := Parse(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version constraint %q: %w", constraint, err)
+		}
+		c.comparisons = append(c.comparisons, comparison{op: op, version: version})
+	}
+
+	return c, nil
+}
+
+// Check reports whether v satisfies every comparison of the constraint
+func (c *Constraint) Check(v *Version) bool {
+	for _, cmp := range c.comparisons {
+		if !cmp.check(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c comparison) check(v *Version) bool {
+	order := v.Compare(c.version)
+	switch c.op {
+	case "=":
+		return order == 0
+	case "!=":
+		return order != 0
+	case ">":
+		return order > 0
+	case ">=":
+		return order >= 0
+	case "<":
+		return order < 0
+	case "<=":
+		return order <= 0
+	case "~":
+		return order >= 0 && v.Major == c.version.Major && v.Minor == c.version.Minor
+	case "^":
+		if c.version.Major == 0 {
+			return order >= 0 && v.Major == 0 && v.Minor == c.version.Minor
+		}
+		return order >= 0 && v.Major == c.version.Major
+	default:
+		return false
+	}
+}
@@ -0,0 +1,142 @@
+package semver
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultDevTemplate is the default dev version shape, e.g.
+// 1.2.3-feature-login.abc1234
+const DefaultDevTemplate = "{current}-{branch}.{sha}"
+
+// LegacyDevTemplate is the dev version shape of WithDevSuffix, the default
+// before branches were included
+const LegacyDevTemplate = "{current}-dev-{sha}"
+
+// BuildDevTemplate numbers repeated builds of a branch so each gets its own,
+// sortable version, e.g. 1.2.4-dev.42+abc1234
+const BuildDevTemplate = "{next}-dev.{build}+{sha}"
+
+// MaxDevBranchLength caps the sanitized branch so dev versions stay well
+// within the 128 characters of an OCI tag
+const MaxDevBranchLength = 40
+
+// DevTimestampFormat is the layout of the {timestamp} placeholder, in UTC
+const DevTimestampFormat = "20060102150405"
+
+// DevInfo is the build a dev version is generated for. Build is the number
+// of the build on its app and branch, for the {build} placeholder.
+type DevInfo struct {
+	SHA    string
+	Branch string
+	Time   time.Time
+	Build  int64
+}
+
+var devPlaceholderRegex = regexp.MustCompile(`\{[^{}]*\}`)
+
+// devPlaceholders are the placeholders a dev template may use
+var devPlaceholders = map[string]bool{
+	"{current}":   true,
+	"{next}":      true,
+	"{sha}":       true,
+	"{branch}":    true,
+	"{timestamp}": true,
+	"{build}":     true,
+}
+
+// invalidIdentifierChars matches runs of characters not allowed in prerelease
+// identifiers, hyphens included so runs collapse into one
+var invalidIdentifierChars = regexp.MustCompile(`[^0-9a-z]+`)
+
+// SanitizeBranch turns a branch name into one prerelease identifier that is
+// also valid in an OCI tag: a refs/heads/ prefix is dropped, letters are
+// lowercased, every run of other characters becomes a single "-" and
+// leading and trailing hyphens are trimmed, so "feature/Login_page" becomes
+// "feature-login-page". The result is cut to MaxDevBranchLength. Branches
+// with nothing left become "branch", and numeric ones with a leading zero,
+// which SemVer forbids, are prefixed with "branch-".
+func SanitizeBranch(branch string) string {
+	branch = strings.TrimPrefix(branch, "refs/heads/")
+	sanitized := strings.Trim(invalidIdentifierChars.ReplaceAllString(strings.ToLower(branch), "-"), "-")
+	if len(sanitized) > MaxDevBranchLength {
+		sanitized = strings.TrimRight(sanitized[:MaxDevBranchLength], "-")
+	}
+
+	switch {
+	case sanitized == "":
+		return "branch"
+	case isNumeric(sanitized) && len(sanitized) > 1 && sanitized[0] == '0':
+		return "branch-" + sanitized
+	default:
+		return sanitized
+	}
+}
+
+// sanitizeSHA abbreviates a commit to 7 lowercase alphanumerics. An
+// abbreviation of only digits with a leading zero, which SemVer forbids as
+// an identifier, gets a "g" prefix as in git describe.
+func sanitizeSHA(sha string) string {
+	short := invalidIdentifierChars.ReplaceAllString(strings.ToLower(sha), "")
+	if len(short) > 7 {
+		short = short[:7]
+	}
+	if isNumeric(short) && len(short) > 1 && short[0] == '0' {
+		return "g" + short
+	}
+	return short
+}
+
+// ValidateDevTemplate checks that a dev template only uses known placeholders
+// and is based on the current or next version
+func ValidateDevTemplate(template string) error {
+	for _, placeholder := range devPlaceholderRegex.FindAllString(template, -1) {
+		if !devPlaceholders[placeholder] {
+			return fmt.Errorf("unknown placeholder %s in dev template %q", placeholder, template)
+		}
+	}
+	if !strings.Contains(template, "{current}") && !strings.Contains(template, "{next}") {
+		return fmt.Errorf("dev template %q must contain {current} or {next}", template)
+	}
+	return nil
+}
+
+// ExpandDevTemplate fills in a dev template. {current} and {next} are the
+// given versions, {sha} the commit abbreviated to 7 characters, {branch} the
+// branch as sanitized by SanitizeBranch, {timestamp} the build time in
+// DevTimestampFormat and {build} the build number. It does not check that
+// the result is a valid version.
+func ExpandDevTemplate(template, current, next string, info DevInfo) (string, error) {
+	if err := ValidateDevTemplate(template); err != nil {
+		return "", err
+	}
+
+	return strings.NewReplacer(
+		"{current}", current,
+		"{next}", next,
+		"{sha}", sanitizeSHA(info.SHA),
+		"{branch}", SanitizeBranch(info.Branch),
+		"{timestamp}", info.Time.UTC().Format(DevTimestampFormat),
+		"{build}", strconv.FormatInt(info.Build, 10),
+	).Replace(template), nil
+}
+
+// WithDevTemplate returns the dev version described by template, with
+// {current} being v without prerelease and build metadata, as in
+// WithDevSuffix, and {next} its next patch version. The result must be a
+// valid semantic version.
+func (v *Version) WithDevTemplate(template string, info DevInfo) (*Version, error) {
+	expanded, err := ExpandDevTemplate(template, v.Release().String(), v.IncrementPatch().String(), info)
+	if err != nil {
+		return nil, err
+	}
+
+	dev, err := Parse(expanded)
+	if err != nil {
+		return nil, fmt.Errorf("dev template %q produced an invalid version: %s", template, expanded)
+	}
+	return dev, nil
+}
@@ -0,0 +1,36 @@
+package semver
+
+// Diff levels reported by Diff, from most to least significant
+const (
+	DiffMajor      = "major"
+	DiffMinor      = "minor"
+	DiffPatch      = "patch"
+	DiffPrerelease = "prerelease"
+	DiffNone       = "none"
+)
+
+// Diff returns the most significant component in which two versions differ:
+// major, minor, patch, prerelease or none when they are equal
+func Diff(v1, v2 string) (string, error) {
+	version1, err := Parse(v1)
+	if err != nil {
+		return "", err
+	}
+	version2, err := Parse(v2)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case version1.Major != version2.Major:
+		return DiffMajor, nil
+	case version1.Minor != version2.Minor:
+		return DiffMinor, nil
+	case version1.Patch != version2.Patch:
+		return DiffPatch, nil
+	case version1.Prerelease != version2.Prerelease:
+		return DiffPrerelease, nil
+	default:
+		return DiffNone, nil
+	}
+}
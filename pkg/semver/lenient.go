@@ -0,0 +1,53 @@
+package semver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseLenient parses version like Parse but first coerces common sloppy
+// forms into canonical SemVer: surrounding whitespace and a leading "v" or
+// "V" are dropped, a missing minor or patch counts as 0 ("1.2" → "1.2.0",
+// "v1" → "1.0.0"), numeric parts past the patch are dropped ("1.2.3.4" →
+// "1.2.3") and zero padding is removed ("01.02.03" → "1.2.3"). A prerelease
+// or build metadata suffix is kept. The returned flag reports whether any
+// coercion was applied, that is whether the canonical form differs from the
+// input.
+func ParseLenient(version string) (*Version, bool, error) {
+	if v, err := Parse(version); err == nil && v.String() == version {
+		return v, false, nil
+	}
+
+	trimmed := strings.TrimSpace(version)
+	if strings.HasPrefix(trimmed, "v") || strings.HasPrefix(trimmed, "V") {
+		trimmed = trimmed[1:]
+	}
+
+	core, suffix := trimmed, ""
+	if i := strings.IndexAny(trimmed, "-+"); i >= 0 {
+		core, suffix = trimmed[:i], trimmed[i:]
+	}
+
+	parts := strings.Split(core, ".")
+	numbers := [3]int{}
+	for i, part := range parts {
+		if part == "" || !isNumeric(part) {
+			return nil, false, fmt.Errorf("invalid semantic version: %s", version)
+		}
+		if i >= len(numbers) {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid semantic version: %s", version)
+		}
+		numbers[i] = n
+	}
+
+	v, err := Parse(fmt.Sprintf("%d.%d.%d%s", numbers[0], numbers[1], numbers[2], suffix))
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid semantic version: %s", version)
+	}
+	return v, v.String() != version, nil
+}
@@ -0,0 +1,84 @@
+package semver
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Transformations reported by Normalize
+const (
+	TransformTrimSpace           = "trim_space"
+	TransformStripPrefix         = "strip_prefix"
+	TransformStripLeadingZeros   = "strip_leading_zeros"
+	TransformLowercasePrerelease = "lowercase_prerelease"
+)
+
+// NormalizeOptions selects the rewrites Normalize may apply
+type NormalizeOptions struct {
+	// StripPrefix removes a leading "v" or "V" ("v1.2.3" → "1.2.3")
+	StripPrefix bool
+	// StripLeadingZeros drops zero padding from numeric parts ("01.02.03" → "1.2.3")
+	StripLeadingZeros bool
+	// LowercasePrerelease lowercases the prerelease ("1.2.3-RC1" → "1.2.3-rc1")
+	LowercasePrerelease bool
+}
+
+// DefaultNormalizeOptions enables every rewrite
+func DefaultNormalizeOptions() NormalizeOptions {
+	return NormalizeOptions{
+		StripPrefix:         true,
+		StripLeadingZeros:   true,
+		LowercasePrerelease: true,
+	}
+}
+
+// Normalize rewrites version into canonical form according to opts and
+// returns the transformations that changed it. Surrounding whitespace is
+// always trimmed and build metadata is kept as is. An error is returned if the result is not a valid version.
+func Normalize(version string, opts NormalizeOptions) (string, []string, error) {
+	var applied []string
+
+	normalized := strings.TrimSpace(version)
+	if normalized != version {
+		applied = append(applied, TransformTrimSpace)
+	}
+
+	if opts.StripPrefix && (strings.HasPrefix(normalized, "v") || strings.HasPrefix(normalized, "V")) {
+		normalized = normalized[1:]
+		applied = append(applied, TransformStripPrefix)
+	}
+
+	parsed, err := Parse(normalized)
+	if err != nil {
+		return "", applied, err
+	}
+
+	core, prerelease := normalized, parsed.Prerelease
+	if parsed.Build != "" {
+		core = strings.TrimSuffix(core, "+"+parsed.Build)
+	}
+	if prerelease != "" {
+		core = strings.TrimSuffix(core, "-"+prerelease)
+	}
+
+	if opts.StripLeadingZeros {
+		canonical := fmt.Sprintf("%d.%d.%d", parsed.Major, parsed.Minor, parsed.Patch)
+		if core != canonical {
+			core = canonical
+			applied = append(applied, TransformStripLeadingZeros)
+		}
+	}
+
+	if opts.LowercasePrerelease && prerelease != strings.ToLower(prerelease) {
+		prerelease = strings.ToLower(prerelease)
+		applied = append(applied, TransformLowercasePrerelease)
+	}
+
+	if prerelease != "" {
+		core += "-" + prerelease
+	}
+	if parsed.Build != "" {
+		core += "+" + parsed.Build
+	}
+	return core, applied, nil
+}
@@ -5,15 +5,23 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
-var semverRegex = regexp.MustCompile(`^(\d+)\.(\d+)\.(\d+)(?:-(.+))?$`)
+var semverRegex = regexp.MustCompile(`^(\d+)\.(\d+)\.(\d+)(?:-([^+]+))?(?:\+([^+]*))?$`)
+
+// buildRegex matches build metadata: dot-separated, non-empty identifiers of
+// ASCII alphanumerics and hyphens
+var buildRegex = regexp.MustCompile(`^[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*$`)
 
 type Version struct {
 	Major      int
 	Minor      int
 	Patch      int
 	Prerelease string
+	// Build is the build metadata after "+" (1.2.3+build.45). It is kept
+	// and emitted but, per SemVer 2.0, ignored for precedence.
+	Build string
 }
 
 func Parse(version string) (*Version, error) {
@@ -27,22 +35,46 @@ func Parse(version string) (*Version, error) {
 	patch, _ := strconv.Atoi(matches[3])
 	prerelease := matches[4]
 
+	build := matches[5]
+	if strings.Contains(version, "+") && !buildRegex.MatchString(build) {
+		return nil, fmt.Errorf("invalid build metadata in semantic version: %s", version)
+	}
+
 	return &Version{
 		Major:      major,
 		Minor:      minor,
 		Patch:      patch,
 		Prerelease: prerelease,
+		Build:      build,
 	}, nil
 }
 
 func (v *Version) String() string {
 	base := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
 	if v.Prerelease != "" {
-		return fmt.Sprintf("%s-%s", base, v.Prerelease)
+		base = fmt.Sprintf("%s-%s", base, v.Prerelease)
+	}
+	if v.Build != "" {
+		base = fmt.Sprintf("%s+%s", base, v.Build)
 	}
 	return base
 }
 
+// WithBuildMetadata returns a copy of the version carrying build metadata;
+// an empty build removes it. Invalid metadata is rejected.
+func (v *Version) WithBuildMetadata(build string) (*Version, error) {
+	if build != "" && !buildRegex.MatchString(build) {
+		return nil, fmt.Errorf("invalid build metadata: %s", build)
+	}
+	return &Version{
+		Major:      v.Major,
+		Minor:      v.Minor,
+		Patch:      v.Patch,
+		Prerelease: v.Prerelease,
+		Build:      build,
+	}, nil
+}
+
 func (v *Version) IncrementPatch() *Version {
 	return &Version{
 		Major: v.Major,
@@ -51,6 +83,23 @@ func (v *Version) IncrementPatch() *Version {
 	}
 }
 
+// IncrementDatePatch returns the next patch stamped with the UTC date of now
+// as YYYYMMDD (1.4.2 → 1.4.20240615). A version already stamped today or
+// later gets its patch bumped instead (1.4.20240615 → 1.4.20240616), so
+// versions keep increasing.
+func (v *Version) IncrementDatePatch(now time.Time) *Version {
+	utc := now.UTC()
+	date := utc.Year()*10000 + int(utc.Month())*100 + utc.Day()
+	if v.Patch >= date {
+		return v.IncrementPatch()
+	}
+	return &Version{
+		Major: v.Major,
+		Minor: v.Minor,
+		Patch: date,
+	}
+}
+
 func (v *Version) IncrementMinor() *Version {
 	return &Version{
 		Major: v.Major,
@@ -67,6 +116,50 @@ func (v *Version) IncrementMajor() *Version {
 	}
 }
 
+// IncrementRC returns the next release candidate. A version that is already
+// an rc (1.3.0-rc.1) gets its rc number bumped (1.3.0-rc.2); any other
+// prerelease becomes the first rc of the same release (1.3.0-beta → 1.3.0-rc.1);
+// a release starts the first rc of the next minor (1.2.3 → 1.3.0-rc.1).
+func (v *Version) IncrementRC() *Version {
+	if n, ok := v.rcNumber(); ok {
+		return &Version{
+			Major:      v.Major,
+			Minor:      v.Minor,
+			Patch:      v.Patch,
+			Prerelease: fmt.Sprintf("rc.%d", n+1),
+		}
+	}
+
+	base := v.Release()
+	if v.Prerelease == "" {
+		base = v.IncrementMinor()
+	}
+	base.Prerelease = "rc.1"
+	return base
+}
+
+// rcNumber returns N for an "rc.N" prerelease
+func (v *Version) rcNumber() (int, bool) {
+	if !strings.HasPrefix(v.Prerelease, "rc.") {
+		return 0, false
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(v.Prerelease, "rc."))
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// Release returns the version without its prerelease suffix and build
+// metadata
+func (v *Version) Release() *Version {
+	return &Version{
+		Major: v.Major,
+		Minor: v.Minor,
+		Patch: v.Patch,
+	}
+}
+
 func (v *Version) WithDevSuffix(sha string) *Version {
 	shortSHA := sha
 	if len(sha) > 7 {
@@ -85,6 +178,7 @@ func IsValid(version string) bool {
 	return err == nil
 }
 
+// Compare orders v1 against v2 by precedence; build metadata is ignored
 func Compare(v1, v2 string) (int, error) {
 	version1, err := Parse(v1)
 	if err != nil {
@@ -95,22 +189,87 @@ func Compare(v1, v2 string) (int, error) {
 		return 0, err
 	}
 
-	if version1.Major != version2.Major {
-		return version1.Major - version2.Major, nil
+	return version1.Compare(version2), nil
+}
+
+// Compare orders v against other by precedence: negative when v is lower,
+// zero when equal and positive when higher. Build metadata is ignored.
+func (v *Version) Compare(other *Version) int {
+	if v.Major != other.Major {
+		return v.Major - other.Major
 	}
-	if version1.Minor != version2.Minor {
-		return version1.Minor - version2.Minor, nil
+	if v.Minor != other.Minor {
+		return v.Minor - other.Minor
 	}
-	if version1.Patch != version2.Patch {
-		return version1.Patch - version2.Patch, nil
+	if v.Patch != other.Patch {
+		return v.Patch - other.Patch
 	}
 
-	if version1.Prerelease == "" && version2.Prerelease != "" {
-		return 1, nil
+	if v.Prerelease == "" && other.Prerelease != "" {
+		return 1
 	}
-	if version1.Prerelease != "" && version2.Prerelease == "" {
-		return -1, nil
+	if v.Prerelease != "" && other.Prerelease == "" {
+		return -1
 	}
 
-	return strings.Compare(version1.Prerelease, version2.Prerelease), nil
+	return comparePrerelease(v.Prerelease, other.Prerelease)
+}
+
+// comparePrerelease orders two prereleases by SemVer 2.0 precedence:
+// dot-separated identifiers are compared left to right, numeric identifiers
+// numerically and below alphanumeric ones, others in ASCII order, and a
+// shorter list of otherwise equal identifiers sorts first
+func comparePrerelease(p1, p2 string) int {
+	ids1, ids2 := strings.Split(p1, "."), strings.Split(p2, ".")
+
+	for i := 0; i < len(ids1) && i < len(ids2); i++ {
+		if cmp := compareIdentifier(ids1[i], ids2[i]); cmp != 0 {
+			return cmp
+		}
+	}
+
+	switch {
+	case len(ids1) < len(ids2):
+		return -1
+	case len(ids1) > len(ids2):
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareIdentifier(id1, id2 string) int {
+	numeric1, numeric2 := isNumeric(id1), isNumeric(id2)
+
+	switch {
+	case numeric1 && numeric2:
+		// Compare by length first so identifiers of any size are ordered
+		// without overflowing an int
+		n1, n2 := strings.TrimLeft(id1, "0"), strings.TrimLeft(id2, "0")
+		if len(n1) != len(n2) {
+			if len(n1) < len(n2) {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(n1, n2)
+	case numeric1:
+		return -1
+	case numeric2:
+		return 1
+	default:
+		return strings.Compare(id1, id2)
+	}
+}
+
+func isNumeric(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, r := range id {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
 }
\ No newline at end of file
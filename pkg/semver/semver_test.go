@@ -1,7 +1,9 @@
 package semver
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -34,6 +36,41 @@ func TestParse(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name:  "valid version with build metadata",
+			input: "1.2.3+build.45",
+			want: &Version{
+				Major: 1,
+				Minor: 2,
+				Patch: 3,
+				Build: "build.45",
+			},
+			wantErr: false,
+		},
+		{
+			name:  "valid version with prerelease and build metadata",
+			input: "1.2.3-rc.1+sha.5114f85",
+			want: &Version{
+				Major:      1,
+				Minor:      2,
+				Patch:      3,
+				Prerelease: "rc.1",
+				Build:      "sha.5114f85",
+			},
+			wantErr: false,
+		},
+		{
+			name:    "empty build metadata",
+			input:   "1.2.3+",
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "invalid build metadata",
+			input:   "1.2.3+build..45",
+			want:    nil,
+			wantErr: true,
+		},
 		{
 			name:    "invalid version",
 			input:   "invalid",
@@ -86,6 +123,17 @@ func TestVersion_String(t *testing.T) {
 			},
 			want: "1.2.3-dev-abc1234",
 		},
+		{
+			name: "version with prerelease and build metadata",
+			version: &Version{
+				Major:      1,
+				Minor:      2,
+				Patch:      3,
+				Prerelease: "rc.1",
+				Build:      "build.45",
+			},
+			want: "1.2.3-rc.1+build.45",
+		},
 	}
 
 	for _, tt := range tests {
@@ -117,6 +165,56 @@ func TestVersion_IncrementMajor(t *testing.T) {
 	assert.Equal(t, "", result.Prerelease)
 }
 
+func TestVersion_IncrementRC(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"release starts rc of next minor", "1.2.3", "1.3.0-rc.1"},
+		{"rc number increments", "1.3.0-rc.1", "1.3.0-rc.2"},
+		{"multi-digit rc", "1.3.0-rc.9", "1.3.0-rc.10"},
+		{"other prerelease becomes first rc", "1.3.0-beta", "1.3.0-rc.1"},
+		{"dev version becomes first rc", "1.3.0-dev-abc1234", "1.3.0-rc.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := Parse(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, v.IncrementRC().String())
+		})
+	}
+}
+
+func TestVersion_IncrementDatePatch(t *testing.T) {
+	now := time.Date(2024, 6, 15, 23, 30, 0, 0, time.FixedZone("UTC-2", -2*60*60))
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"stamps the UTC date", "1.4.2", "1.4.20240616"},
+		{"bumps a version stamped today", "1.4.20240616", "1.4.20240617"},
+		{"drops prerelease", "1.4.2-rc.1", "1.4.20240616"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := Parse(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, v.IncrementDatePatch(now).String())
+		})
+	}
+}
+
+func TestVersion_Release(t *testing.T) {
+	v := &Version{Major: 1, Minor: 4, Patch: 0, Prerelease: "rc.2"}
+	result := v.Release()
+	assert.Equal(t, "1.4.0", result.String())
+	assert.Equal(t, "rc.2", v.Prerelease)
+}
+
 func TestVersion_WithDevSuffix(t *testing.T) {
 	tests := []struct {
 		name string
@@ -144,6 +242,24 @@ func TestVersion_WithDevSuffix(t *testing.T) {
 	}
 }
 
+func TestVersion_WithBuildMetadata(t *testing.T) {
+	v := &Version{Major: 1, Minor: 2, Patch: 3, Prerelease: "rc.1"}
+
+	result, err := v.WithBuildMetadata("build.45")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.3-rc.1+build.45", result.String())
+	assert.Empty(t, v.Build)
+
+	cleared, err := result.WithBuildMetadata("")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.3-rc.1", cleared.String())
+
+	_, err = v.WithBuildMetadata("build_45")
+	assert.Error(t, err)
+
+	assert.Equal(t, "1.2.4", (&Version{Major: 1, Minor: 2, Patch: 3, Build: "build.45"}).IncrementPatch().String())
+}
+
 func TestIsValid(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -181,6 +297,14 @@ func TestCompare(t *testing.T) {
 		{"v1 lesser patch", "1.1.1", "1.1.2", -1, false},
 		{"release vs prerelease", "1.2.3", "1.2.3-dev", 1, false},
 		{"prerelease vs release", "1.2.3-dev", "1.2.3", -1, false},
+		{"build metadata ignored", "1.2.3+build.1", "1.2.3+build.2", 0, false},
+		{"numeric prerelease identifiers", "1.0.0-rc.10", "1.0.0-rc.9", 1, false},
+		{"numeric below alphanumeric", "1.0.0-1", "1.0.0-alpha", -1, false},
+		{"fewer identifiers first", "1.0.0-alpha", "1.0.0-alpha.1", -1, false},
+		{"alphanumeric identifiers", "1.0.0-alpha.beta", "1.0.0-beta", -1, false},
+		{"large numeric identifiers", "1.0.0-rc.99999999999999999999", "1.0.0-rc.100000000000000000000", -1, false},
+		{"leading zeros", "1.0.0-rc.010", "1.0.0-rc.9", 1, false},
+		{"build metadata on prerelease ignored", "1.2.3-rc.1+a", "1.2.3-rc.1", 0, false},
 		{"invalid v1", "invalid", "1.2.3", 0, true},
 		{"invalid v2", "1.2.3", "invalid", 0, true},
 	}
@@ -202,4 +326,275 @@ func TestCompare(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestCompare_SpecPrecedence(t *testing.T) {
+	// Example from the SemVer 2.0 spec, lowest first
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+	}
+
+	for i := 0; i < len(ordered)-1; i++ {
+		got, err := Compare(ordered[i], ordered[i+1])
+		assert.NoError(t, err)
+		assert.Less(t, got, 0, "%s < %s", ordered[i], ordered[i+1])
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		opts        NormalizeOptions
+		want        string
+		wantApplied []string
+		wantErr     bool
+	}{
+		{"already canonical", "1.2.3", DefaultNormalizeOptions(), "1.2.3", nil, false},
+		{"v prefix", "v1.2.3", DefaultNormalizeOptions(), "1.2.3", []string{TransformStripPrefix}, false},
+		{"uppercase V prefix", "V1.2.3", DefaultNormalizeOptions(), "1.2.3", []string{TransformStripPrefix}, false},
+		{"zero padded", "01.02.003", DefaultNormalizeOptions(), "1.2.3", []string{TransformStripLeadingZeros}, false},
+		{"uppercase prerelease", "1.2.3-RC1", DefaultNormalizeOptions(), "1.2.3-rc1", []string{TransformLowercasePrerelease}, false},
+		{
+			name:        "everything at once",
+			input:       " v01.2.3-Beta ",
+			opts:        DefaultNormalizeOptions(),
+			want:        "1.2.3-beta",
+			wantApplied: []string{TransformTrimSpace, TransformStripPrefix, TransformStripLeadingZeros, TransformLowercasePrerelease},
+		},
+		{"prefix kept when disabled", "v1.2.3", NormalizeOptions{}, "", nil, true},
+		{"zeros kept when disabled", "01.2.3-RC", NormalizeOptions{LowercasePrerelease: true}, "01.2.3-rc", []string{TransformLowercasePrerelease}, false},
+		{"build metadata kept", "v01.2.3-RC.1+Build.45", DefaultNormalizeOptions(), "1.2.3-rc.1+Build.45", []string{TransformStripPrefix, TransformStripLeadingZeros, TransformLowercasePrerelease}, false},
+		{"invalid", "latest", DefaultNormalizeOptions(), "", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, applied, err := Normalize(tt.input, tt.opts)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantApplied, applied)
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name    string
+		v1      string
+		v2      string
+		want    string
+		wantErr bool
+	}{
+		{"equal versions", "1.2.3", "1.2.3", DiffNone, false},
+		{"major", "1.2.3", "2.0.0", DiffMajor, false},
+		{"minor", "1.2.3", "1.4.0", DiffMinor, false},
+		{"patch", "1.2.4", "1.2.3", DiffPatch, false},
+		{"prerelease", "1.3.0-rc.1", "1.3.0", DiffPrerelease, false},
+		{"build metadata only", "1.3.0+build.1", "1.3.0+build.2", DiffNone, false},
+		{"invalid", "1.2", "1.2.3", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Diff(tt.v1, tt.v2)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSort(t *testing.T) {
+	got := Sort([]string{"1.10.0", "1.2.0", "latest", "1.2.0-rc.10", "1.2.0-rc.2", "0.9.0"})
+	assert.Equal(t, []string{"0.9.0", "1.2.0-rc.2", "1.2.0-rc.10", "1.2.0", "1.10.0"}, got)
+
+	assert.Empty(t, Sort(nil))
+}
+
+func TestLatest(t *testing.T) {
+	tests := []struct {
+		name     string
+		versions []string
+		want     string
+	}{
+		{"release beats prerelease", []string{"1.3.0-rc.1", "1.3.0", "1.2.9"}, "1.3.0"},
+		{"numeric ordering", []string{"1.9.0", "1.10.0"}, "1.10.0"},
+		{"invalid skipped", []string{"latest", "2.0", "0.1.0"}, "0.1.0"},
+		{"equal precedence keeps first", []string{"1.0.0+b", "1.0.0+a"}, "1.0.0+b"},
+		{"none valid", []string{"main"}, ""},
+		{"empty", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Latest(tt.versions))
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	versions := []string{"0.9.1", "1.0.0", "1.2.0-rc.1", "1.2.0", "1.2.5", "1.4.0", "2.0.0", "bogus"}
+
+	tests := []struct {
+		name       string
+		constraint string
+		want       []string
+		wantErr    bool
+	}{
+		{"range", ">=1.2.0, <2.0.0", []string{"1.2.0", "1.2.5", "1.4.0"}, false},
+		{"space after operator", ">= 1.2.0 < 1.4.0", []string{"1.2.0", "1.2.5"}, false},
+		{"exact", "1.0.0", []string{"1.0.0"}, false},
+		{"not equal", "!=1.0.0 <1.2.0", []string{"0.9.1", "1.2.0-rc.1"}, false},
+		{"tilde", "~1.2.0", []string{"1.2.0", "1.2.5"}, false},
+		{"caret", "^1.2.0", []string{"1.2.0", "1.2.5", "1.4.0"}, false},
+		{"caret on 0.x", "^0.9.0", []string{"0.9.1"}, false},
+		{"prerelease by precedence", ">1.0.0 <1.2.0", []string{"1.2.0-rc.1"}, false},
+		{"empty", "", nil, true},
+		{"invalid version", ">=1.2", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Filter(versions, tt.constraint)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseLenient(t *testing.T) {
+	tests := []struct {
+		input       string
+		want        string
+		wantCoerced bool
+		wantErr     bool
+	}{
+		{"1.2.3", "1.2.3", false, false},
+		{"1.2.3-rc.1+build.5", "1.2.3-rc.1+build.5", false, false},
+		{"v1.2.3", "1.2.3", true, false},
+		{"1.2", "1.2.0", true, false},
+		{"v1", "1.0.0", true, false},
+		{"1.2.3.4", "1.2.3", true, false},
+		{"01.02.03", "1.2.3", true, false},
+		{" V2.1-beta ", "2.1.0-beta", true, false},
+		{"1.2+build.7", "1.2.0+build.7", true, false},
+		{"release-1.2", "", false, true},
+		{"1..2", "", false, true},
+		{"v", "", false, true},
+		{"1.2-", "", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			v, coerced, err := ParseLenient(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, v.String())
+			assert.Equal(t, tt.wantCoerced, coerced)
+		})
+	}
+}
+
+func TestWithDevTemplate(t *testing.T) {
+	info := DevInfo{
+		SHA:    "abc1234567890",
+		Branch: "feature/Login_page",
+		Time:   time.Date(2026, 1, 15, 9, 30, 0, 0, time.UTC),
+		Build:  42,
+	}
+
+	tests := []struct {
+		name     string
+		current  string
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{"default", "1.2.3-rc.1", DefaultDevTemplate, "1.2.3-feature-login-page.abc1234", false},
+		{"legacy matches WithDevSuffix", "1.2.3-rc.1", LegacyDevTemplate, "1.2.3-dev-abc1234", false},
+		{"maven snapshot", "1.2.3", "{next}-SNAPSHOT", "1.2.4-SNAPSHOT", false},
+		{"branch and sha", "1.2.3", "{next}-{branch}.{sha}", "1.2.4-feature-login-page.abc1234", false},
+		{"timestamp", "1.2.3+build.5", "{current}-snapshot.{timestamp}", "1.2.3-snapshot.20260115093000", false},
+		{"build counter", "1.2.3", BuildDevTemplate, "1.2.4-dev.42+abc1234", false},
+		{"unknown placeholder", "1.2.3", "{current}-{user}", "", true},
+		{"no base version", "1.2.3", "dev-{sha}", "", true},
+		{"invalid result", "1.2.3", "{current}.{sha}", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := Parse(tt.current)
+			assert.NoError(t, err)
+
+			dev, err := v.WithDevTemplate(tt.template, info)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, dev.String())
+		})
+	}
+}
+
+func TestSanitizeBranch(t *testing.T) {
+	tests := []struct {
+		branch string
+		want   string
+	}{
+		{"feature/login", "feature-login"},
+		{"refs/heads/Feature/Login_Page", "feature-login-page"},
+		{"release/1.2.x", "release-1-2-x"},
+		{"--fix//double--slash--", "fix-double-slash"},
+		{"JIRA-123: add (stuff)!", "jira-123-add-stuff"},
+		{"0042", "branch-0042"},
+		{"42", "42"},
+		{"///", "branch"},
+		{"feature/" + strings.Repeat("a", 33) + "-tail", "feature-" + strings.Repeat("a", 32)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.branch, func(t *testing.T) {
+			got := SanitizeBranch(tt.branch)
+			assert.Equal(t, tt.want, got)
+			assert.LessOrEqual(t, len(got), MaxDevBranchLength)
+
+			v, err := Parse("1.2.3-" + got + ".abc1234")
+			assert.NoError(t, err)
+			assert.Equal(t, "1.2.3-"+got+".abc1234", v.String())
+		})
+	}
+}
+
+func TestWithDevTemplate_NumericSHA(t *testing.T) {
+	v, _ := Parse("1.2.3")
+
+	dev, err := v.WithDevTemplate(DefaultDevTemplate, DevInfo{SHA: "0123456789", Branch: "main"})
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.3-main.g0123456", dev.String())
+
+	dev, err = v.WithDevTemplate(DefaultDevTemplate, DevInfo{SHA: "ABC1234DEF", Branch: "main"})
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.3-main.abc1234", dev.String())
+}
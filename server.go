@@ -0,0 +1,73 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/company/version-service/internal/config"
+	"github.com/company/version-service/internal/handlers"
+	"github.com/company/version-service/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+)
+
+// serverTLSConfig returns the TLS settings of the HTTP server, or nil to
+// serve plain HTTP. With a client CA, client certificates are verified
+// against it and, unless TLS_CLIENT_AUTH is optional, required.
+func serverTLSConfig(cfg *config.Config) (*tls.Config, error) {
+	if cfg.TLSCertFile == "" {
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server certificate: %w", err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if cfg.TLSClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.TLSClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in client CA file %s", cfg.TLSClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		if cfg.TLSClientAuth == "optional" {
+			tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+	}
+	return tlsConfig, nil
+}
+
+// metricsHandler serves the Prometheus metrics. OpenMetrics is needed to
+// expose exemplars.
+func metricsHandler() http.Handler {
+	return promhttp.InstrumentMetricHandler(
+		prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true}),
+	)
+}
+
+// healthRouter serves only /health and /metrics, for the plain HTTP listener
+// on HEALTH_PORT that probes and scrapers reach without a client certificate
+func healthRouter(service services.VersionServiceInterface, logger *logrus.Logger) *gin.Engine {
+	router := gin.New()
+	router.Use(gin.Recovery())
+
+	handler := handlers.NewHandler(service, logger)
+	router.GET("/health", handler.Health)
+	router.GET("/metrics", gin.WrapH(metricsHandler()))
+	return router
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/company/version-service/internal/config"
+	"github.com/company/version-service/internal/services"
+	"github.com/company/version-service/internal/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCertificate writes a self-signed certificate and its key as PEM files
+// into dir and returns their paths
+func writeCertificate(t *testing.T, dir string) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func TestServerTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCertificate(t, dir)
+
+	t.Run("plain HTTP without a certificate", func(t *testing.T) {
+		tlsConfig, err := serverTLSConfig(&config.Config{})
+		require.NoError(t, err)
+		assert.Nil(t, tlsConfig)
+	})
+
+	t.Run("no client certificates without a client CA", func(t *testing.T) {
+		tlsConfig, err := serverTLSConfig(&config.Config{TLSCertFile: certFile, TLSKeyFile: keyFile, TLSClientAuth: "require"})
+		require.NoError(t, err)
+		assert.Len(t, tlsConfig.Certificates, 1)
+		assert.Equal(t, uint16(tls.VersionTLS12), tlsConfig.MinVersion)
+		assert.Equal(t, tls.NoClientCert, tlsConfig.ClientAuth)
+		assert.Nil(t, tlsConfig.ClientCAs)
+	})
+
+	for mode, want := range map[string]tls.ClientAuthType{
+		"require":  tls.RequireAndVerifyClientCert,
+		"optional": tls.VerifyClientCertIfGiven,
+	} {
+		t.Run("client auth "+mode, func(t *testing.T) {
+			tlsConfig, err := serverTLSConfig(&config.Config{
+				TLSCertFile:     certFile,
+				TLSKeyFile:      keyFile,
+				TLSClientCAFile: certFile,
+				TLSClientAuth:   mode,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, want, tlsConfig.ClientAuth)
+			assert.NotNil(t, tlsConfig.ClientCAs)
+			assert.Equal(t, uint16(tls.VersionTLS12), tlsConfig.MinVersion)
+		})
+	}
+
+	t.Run("missing client CA file", func(t *testing.T) {
+		_, err := serverTLSConfig(&config.Config{
+			TLSCertFile:     certFile,
+			TLSKeyFile:      keyFile,
+			TLSClientCAFile: filepath.Join(dir, "missing.crt"),
+		})
+		assert.ErrorContains(t, err, "failed to read client CA file")
+	})
+
+	t.Run("client CA file without certificates", func(t *testing.T) {
+		_, err := serverTLSConfig(&config.Config{
+			TLSCertFile:     certFile,
+			TLSKeyFile:      keyFile,
+			TLSClientCAFile: keyFile,
+		})
+		assert.ErrorContains(t, err, "no certificates found in client CA file")
+	})
+
+	t.Run("key not matching the certificate", func(t *testing.T) {
+		_, otherKey := writeCertificate(t, t.TempDir())
+		_, err := serverTLSConfig(&config.Config{TLSCertFile: certFile, TLSKeyFile: otherKey})
+		assert.ErrorContains(t, err, "failed to load server certificate")
+	})
+}
+
+// TestServerTLSConfig_MinVersion checks that clients limited to TLS 1.1 are
+// refused during the handshake
+func TestServerTLSConfig_MinVersion(t *testing.T) {
+	certFile, keyFile := writeCertificate(t, t.TempDir())
+	tlsConfig, err := serverTLSConfig(&config.Config{TLSCertFile: certFile, TLSKeyFile: keyFile})
+	require.NoError(t, err)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.TLS = tlsConfig
+	srv.StartTLS()
+	defer srv.Close()
+
+	client := func(maxVersion uint16) *http.Client {
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: true,
+			MaxVersion:         maxVersion,
+		}}}
+	}
+	_, err = client(tls.VersionTLS11).Get(srv.URL)
+	assert.Error(t, err)
+	resp, err := client(tls.VersionTLS12).Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+}
+
+func TestHealthRouter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	memory, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+	require.NoError(t, err)
+	service := services.NewVersionService(memory, memory, nil, logger, services.Options{})
+	router := healthRouter(service, logger)
+
+	for path, want := range map[string]int{
+		"/health":         http.StatusOK,
+		"/metrics":        http.StatusOK,
+		"/version/1-api":  http.StatusNotFound,
+		"/admin/snapshot": http.StatusNotFound,
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, want, w.Code, path)
+	}
+}
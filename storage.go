@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/company/version-service/internal/config"
+	"github.com/company/version-service/internal/models"
+	"github.com/company/version-service/internal/storage"
+	"github.com/company/version-service/pkg/breaker"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/sirupsen/logrus"
+)
+
+// gitAuth returns the credentials GIT_AUTH_METHOD selects for the Git
+// backend
+func gitAuth(cfg *config.Config) (transport.AuthMethod, error) {
+	if cfg.GitAuthMethod != "ssh" {
+		return storage.NewTokenAuth(cfg.GitUsername, cfg.GitToken), nil
+	}
+
+	auth, err := storage.NewSSHAuth(storage.SSHAuthOptions{
+		User:                  cfg.GitSSHUser,
+		Key:                   []byte(cfg.GitSSHKey),
+		KeyPath:               cfg.GitSSHKeyPath,
+		Passphrase:            cfg.GitSSHKeyPassphrase,
+		KnownHostsPath:        cfg.GitSSHKnownHosts,
+		InsecureIgnoreHostKey: cfg.GitSSHInsecureIgnoreHostKey,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up Git SSH authentication: %w", err)
+	}
+	return auth, nil
+}
+
+// gitBackend is a Git storage holding clones to remove on shutdown
+type gitBackend interface {
+	storage.Storage
+	Close() error
+}
+
+// newGitStorage opens the versions repository at repoURL: one branch, or a
+// branch per project with GIT_BRANCH_PER_PROJECT. The mirror only applies
+// to GIT_REPO_URL; the breaker is shared by every repository.
+func newGitStorage(cfg *config.Config, repoURL string, auth transport.AuthMethod, gitBreaker *breaker.Breaker, logger *logrus.Logger) (gitBackend, error) {
+	cloneOpts := storage.GitCloneOptions{
+		Depth:               cfg.GitCloneDepth,
+		SparseCheckout:      cfg.GitSparseCheckout,
+		ReshallowInterval:   cfg.GitReshallowInterval,
+		MaintenanceInterval: cfg.GitMaintenanceInterval,
+		MaxSize:             int64(cfg.GitMaxCloneSizeMB) << 20,
+	}
+	if repoURL == cfg.GitRepoURL {
+		cloneOpts.MirrorURL = cfg.GitMirrorURL
+	}
+
+	if cfg.GitBranchPerProject {
+		branches := storage.NewProjectBranchStorage(repoURL, cfg.GitProjectBranchPrefix, auth, cloneOpts, logger)
+		branches.TagIncrements = cfg.GitTagIncrements
+		branches.Breaker = gitBreaker
+		return branches, nil
+	}
+	gitStorage, err := storage.NewGitStorage(repoURL, cfg.GitBranch, auth, cloneOpts, logger)
+	if err != nil {
+		return nil, err
+	}
+	gitStorage.TagIncrements = cfg.GitTagIncrements
+	gitStorage.Breaker = gitBreaker
+	return gitStorage, nil
+}
+
+// newDurableStorage opens the storage backends listed in STORAGE_BACKENDS.
+// The first one is the durable store; writes are mirrored to the others.
+// Git storage calls its remote through gitBreaker, which may be nil. The
+// returned func closes every backend opened.
+func newDurableStorage(cfg *config.Config, gitBreaker *breaker.Breaker, logger *logrus.Logger) (storage.Storage, func(), error) {
+	var backends []storage.NamedStorage
+	var closers []func() error
+	closeAll := func() {
+		for _, closeBackend := range closers {
+			closeBackend()
+		}
+	}
+
+	for _, name := range cfg.StorageBackends {
+		switch name {
+		case "git":
+			auth, err := gitAuth(cfg)
+			if err != nil {
+				closeAll()
+				return nil, nil, err
+			}
+			var gitStorage gitBackend
+			if len(cfg.GitRepoRoutes) > 0 {
+				gitStorage, err = storage.NewRoutedGitStorage(cfg.GitRepoURL, cfg.GitRepoRoutes, func(repoURL string) (storage.Storage, error) {
+					return newGitStorage(cfg, repoURL, auth, gitBreaker, logger)
+				}, logger)
+			} else {
+				gitStorage, err = newGitStorage(cfg, cfg.GitRepoURL, auth, gitBreaker, logger)
+			}
+			if err != nil {
+				closeAll()
+				return nil, nil, fmt.Errorf("failed to initialize Git storage: %w", err)
+			}
+			backends = append(backends, storage.NamedStorage{Name: name, Storage: gitStorage})
+			closers = append(closers, gitStorage.Close)
+		case "s3":
+			s3Storage, err := storage.NewS3Storage(storage.S3Options{
+				Endpoint:        cfg.S3Endpoint,
+				Region:          cfg.S3Region,
+				Bucket:          cfg.S3Bucket,
+				Prefix:          cfg.S3Prefix,
+				AccessKeyID:     cfg.S3AccessKeyID,
+				SecretAccessKey: cfg.S3SecretAccessKey,
+				PathStyle:       cfg.S3PathStyle,
+			}, logger)
+			if err != nil {
+				closeAll()
+				return nil, nil, fmt.Errorf("failed to initialize S3 storage: %w", err)
+			}
+			backends = append(backends, storage.NamedStorage{Name: name, Storage: s3Storage})
+		case "etcd":
+			etcdStorage, err := storage.NewEtcdStorage(storage.EtcdOptions{
+				Endpoints: cfg.EtcdEndpoints,
+				Prefix:    cfg.EtcdPrefix,
+				Username:  cfg.EtcdUsername,
+				Password:  cfg.EtcdPassword,
+			}, logger)
+			if err != nil {
+				closeAll()
+				return nil, nil, fmt.Errorf("failed to initialize etcd storage: %w", err)
+			}
+			backends = append(backends, storage.NamedStorage{Name: name, Storage: etcdStorage})
+		case "memory":
+			memoryStorage, err := storage.NewMemoryStorage(storage.MemoryOptions{SnapshotPath: cfg.MemorySnapshotPath}, logger)
+			if err != nil {
+				closeAll()
+				return nil, nil, fmt.Errorf("failed to initialize memory storage: %w", err)
+			}
+			backends = append(backends, storage.NamedStorage{Name: name, Storage: memoryStorage})
+		case "postgres":
+			postgresStorage, err := storage.NewPostgresStorage(cfg.PostgresURL, logger)
+			if err != nil {
+				closeAll()
+				return nil, nil, fmt.Errorf("failed to initialize PostgreSQL storage: %w", err)
+			}
+			backends = append(backends, storage.NamedStorage{Name: name, Storage: postgresStorage})
+			closers = append(closers, postgresStorage.Close)
+		default:
+			closeAll()
+			return nil, nil, fmt.Errorf("unknown storage backend %q", name)
+		}
+	}
+
+	if len(backends) == 1 {
+		return backends[0].Storage, closeAll, nil
+	}
+	logger.WithField("backends", cfg.StorageBackends).Info("Mirroring writes to secondary storage backends")
+	return storage.NewMirroredStorage(backends[0], backends[1:], logger), closeAll, nil
+}
+
+// newCacheStorage opens the cache in front of durable storage: Redis, or an
+// in-memory store when memory is the primary backend. The returned func
+// closes it.
+func newCacheStorage(cfg *config.Config, ids models.IDScheme, logger *logrus.Logger) (storage.Storage, func(), error) {
+	if cfg.InMemory() {
+		logger.Info("Using in-memory storage; Redis is not used")
+		cache, err := storage.NewMemoryStorage(storage.MemoryOptions{}, logger)
+		return cache, func() {}, err
+	}
+
+	redisCodec, err := storage.ParseCodec(cfg.RedisCodec)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid REDIS_CODEC: %w", err)
+	}
+
+	redisStorage, err := storage.NewRedisStorage(storage.RedisOptions{
+		URL:                   cfg.RedisURL,
+		Username:              cfg.RedisUsername,
+		Password:              cfg.RedisPassword,
+		TLS:                   cfg.RedisTLS,
+		TLSCAFile:             cfg.RedisTLSCAFile,
+		TLSCertFile:           cfg.RedisTLSCertFile,
+		TLSKeyFile:            cfg.RedisTLSKeyFile,
+		TLSInsecureSkipVerify: cfg.RedisTLSInsecureSkipVerify,
+		PoolSize:              cfg.RedisPoolSize,
+		MinIdleConns:          cfg.RedisMinIdleConns,
+		DialTimeout:           cfg.RedisDialTimeout,
+		ReadTimeout:           cfg.RedisReadTimeout,
+		WriteTimeout:          cfg.RedisWriteTimeout,
+		IDs:                   ids,
+	}, redisCodec, logger)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize Redis storage: %w", err)
+	}
+	return redisStorage, func() { redisStorage.Close() }, nil
+}